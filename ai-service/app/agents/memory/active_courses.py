@@ -50,6 +50,7 @@ import httpx
 from app.agents.core.course_matching import find_course_by_title
 from app.core.config import get_settings
 from app.core.database import get_ai_conn
+from app.core.service_auth import ServiceAuth
 
 logger = logging.getLogger(__name__)
 settings = get_settings()
@@ -269,11 +270,8 @@ def _has_nodes(data: dict) -> bool:
     return any(c.get("nodes") is not None for c in courses)
 
 
-def _lms_headers(user_id: int) -> dict[str, str]:
-    return {
-        "X-API-Secret": settings.ai_service_secret,
-        "X-User-Id": str(user_id),
-    }
+def _lms_auth(user_id: int) -> ServiceAuth:
+    return ServiceAuth(user_id=user_id)
 
 
 async def _fetch_teacher_courses(user_id: int) -> list[dict]:
@@ -282,7 +280,7 @@ async def _fetch_teacher_courses(user_id: int) -> list[dict]:
     async with httpx.AsyncClient(timeout=8.0) as client:
         resp = await client.get(
             f"{lms_base}/api/v1/courses/my",
-            headers=_lms_headers(user_id),
+            auth=_lms_auth(user_id),
         )
         if resp.status_code != 200:
             return []
@@ -318,7 +316,7 @@ async def _fetch_student_courses(user_id: int) -> list[dict]:
         resp = await client.get(
             f"{lms_base}/api/v1/enrollments/my",
             params={"status": "ACCEPTED"},
-            headers=_lms_headers(user_id),
+            auth=_lms_auth(user_id),
         )
         if resp.status_code != 200:
             return []
@@ -9,6 +9,7 @@ import logging
 import httpx
 from app.agents.tools.base_tool import BaseTool, ToolResult
 from app.core.config import get_settings
+from app.core.service_auth import ServiceAuth
 
 logger = logging.getLogger(__name__)
 settings = get_settings()
@@ -58,7 +59,7 @@ class CreateSectionTool(BaseTool):
                 async with httpx.AsyncClient(timeout=10) as client:
                     resp = await client.get(
                         f"{lms_base}/api/v1/courses/{course_id}/sections",
-                        headers={"X-API-Secret": settings.ai_service_secret},
+                        auth=ServiceAuth(),
                     )
                     if resp.status_code == 200:
                         sections = resp.json().get("data") or []
@@ -77,7 +78,7 @@ class CreateSectionTool(BaseTool):
                 resp = await client.post(
                     f"{lms_base}/api/v1/courses/{course_id}/sections",
                     json=payload,
-                    headers={"X-API-Secret": settings.ai_service_secret},
+                    auth=ServiceAuth(),
                 )
 
             if resp.status_code in (200, 201):
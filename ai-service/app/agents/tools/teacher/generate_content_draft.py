@@ -11,6 +11,7 @@ import logging
 import httpx
 from app.agents.tools.base_tool import BaseTool, ToolResult
 from app.core.config import get_settings
+from app.core.service_auth import ServiceAuth
 
 logger = logging.getLogger(__name__)
 settings = get_settings()
@@ -137,8 +138,8 @@ class GenerateContentDraftTool(BaseTool):
             courses_info = []
             if user_id:
                 async with httpx.AsyncClient(timeout=15) as client:
-                    headers = {"X-API-Secret": settings.ai_service_secret, "X-User-Id": str(user_id)}
-                    resp = await client.get(f"{lms_base}/api/v1/courses/my", headers=headers)
+                    auth = ServiceAuth(user_id=user_id)
+                    resp = await client.get(f"{lms_base}/api/v1/courses/my", auth=auth)
                     if resp.status_code == 200:
                         data = resp.json()
                         courses = data.get("data", []) if isinstance(data, dict) and "data" in data else data
@@ -147,7 +148,7 @@ class GenerateContentDraftTool(BaseTool):
                         if isinstance(courses, list):
                             for c in courses:
                                 c_id = c.get("id")
-                                sec_resp = await client.get(f"{lms_base}/api/v1/courses/{c_id}/sections", headers=headers)
+                                sec_resp = await client.get(f"{lms_base}/api/v1/courses/{c_id}/sections", auth=auth)
                                 sec_json = sec_resp.json() if sec_resp.status_code == 200 else None
                                 sections = sec_json.get("data", []) if isinstance(sec_json, dict) else []
                                 if not isinstance(sections, list):
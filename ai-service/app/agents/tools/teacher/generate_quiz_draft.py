@@ -11,6 +11,7 @@ import logging
 import httpx
 from app.agents.tools.base_tool import BaseTool, ToolResult
 from app.core.config import get_settings
+from app.core.service_auth import ServiceAuth
 
 logger = logging.getLogger(__name__)
 settings = get_settings()
@@ -144,7 +145,7 @@ class GenerateQuizDraftTool(BaseTool):
             async with httpx.AsyncClient(timeout=10) as client:
                 resp = await client.get(
                     f"{lms_base}/api/v1/courses/{course_id}/sections",
-                    headers={"X-API-Secret": settings.ai_service_secret},
+                    auth=ServiceAuth(),
                 )
                 if resp.status_code == 200:
                     sections = resp.json().get("data") or []
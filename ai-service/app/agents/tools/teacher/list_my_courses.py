@@ -10,6 +10,7 @@ import httpx
 
 from app.agents.tools.base_tool import BaseTool, ToolResult
 from app.core.config import get_settings
+from app.core.service_auth import ServiceAuth
 
 logger = logging.getLogger(__name__)
 settings = get_settings()
@@ -39,14 +40,11 @@ class ListMyCoursesTool(BaseTool):
             )
 
         url = f"{settings.lms_service_url}/api/v1/courses/my"
-        headers = {
-            "X-API-Secret": settings.ai_service_secret,
-            "X-User-Id": str(user_id),
-        }
+        auth = ServiceAuth(user_id=user_id)
 
         try:
             async with httpx.AsyncClient(timeout=10.0) as client:
-                resp = await client.get(url, headers=headers)
+                resp = await client.get(url, auth=auth)
                 resp.raise_for_status()
                 data = resp.json()
                 
@@ -66,7 +64,7 @@ class ListMyCoursesTool(BaseTool):
                 course_list = []
                 for c in courses:
                     c_id = c.get("id")
-                    sec_resp = await client.get(f"{settings.lms_service_url}/api/v1/courses/{c_id}/sections", headers=headers)
+                    sec_resp = await client.get(f"{settings.lms_service_url}/api/v1/courses/{c_id}/sections", auth=auth)
                     sec_json = sec_resp.json() if sec_resp.status_code == 200 else None
                     sections = sec_json.get("data", []) if isinstance(sec_json, dict) else []
                     if not isinstance(sections, list):
@@ -47,7 +47,11 @@ class BulkSaveFlashcardsRequest(BaseModel):
     node_id: Optional[int] = None
     lesson_id: Optional[int] = None
     content_id: Optional[int] = None
-    flashcards: list[dict] = Field(..., description="List of dicts with 'front_text' and 'back_text'")
+    flashcards: list[dict] = Field(
+        ...,
+        description="List of dicts with 'front_text' and 'back_text', optionally carrying SM-2 state "
+                    "(easiness_factor, interval_days, repetitions, quality_last, next_review_date, last_reviewed_at)",
+    )
 
 
 class ReviewRequest(BaseModel):
@@ -90,6 +94,26 @@ async def list_flashcards(
         raise HTTPException(status_code=500, detail=str(e))
 
 
+@router.get("/export")
+async def export_flashcards(
+    course_id: int,
+    request: Request,
+    student_id: Optional[int] = None,
+    node_id: Optional[int] = None,
+):
+    _verify_internal(request)
+    try:
+        flashcards = await flashcard_srv.list_flashcards_for_export(
+            course_id=course_id,
+            student_id=student_id,
+            node_id=node_id,
+        )
+        return {"flashcards": flashcards}
+    except Exception as e:
+        logger.error(f"Failed to export flashcards: {e}", exc_info=True)
+        raise HTTPException(status_code=500, detail=str(e))
+
+
 @router.post("/review")
 async def review_flashcard(body: ReviewRequest, request: Request):
     _verify_internal(request)
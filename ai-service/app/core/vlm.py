@@ -9,6 +9,7 @@ from typing import Optional
 import httpx
 
 from app.core.config import get_settings
+from app.core.service_auth import ServiceAuth
 
 logger = logging.getLogger(__name__)
 settings = get_settings()
@@ -144,12 +145,11 @@ async def _fetch_image_base64(url: str) -> tuple[Optional[str], str]:
     Returns (None, '') on failure.
     """
     try:
-        headers = {"X-API-Secret": settings.ai_service_secret}
         async with httpx.AsyncClient(
             timeout=30.0,
             follow_redirects=True,
             limits=httpx.Limits(max_connections=10),
-            headers=headers,
+            auth=ServiceAuth(),
         ) as client:
             response = await client.get(url)
             if response.status_code == 200:
@@ -355,12 +355,13 @@ async def _batch_embed(texts: list[str]) -> list[list[float]]:
 def _get_minio_presigned_url(path_key: str, expires_in_seconds: int = 3600) -> Optional[str]:
     try:
         import httpx
+        from app.core.service_auth import ServiceAuth
         lms_base = settings.lms_service_url.rstrip("/")
         with httpx.Client(timeout=10) as client:
             resp = client.get(
                 f"{lms_base}/api/v1/files/presigned/{path_key}",
                 params={"expires": expires_in_seconds},
-                headers={"X-API-Secret": settings.ai_service_secret},
+                auth=ServiceAuth(),
             )
             if resp.status_code == 200:
                 return resp.json().get("data", {}).get("presigned_url")
@@ -1,5 +1,5 @@
 import logging
-from datetime import date, timedelta
+from datetime import date, datetime, timedelta
 from typing import Optional
 from app.core.database import get_ai_conn
 
@@ -77,6 +77,34 @@ class FlashcardService:
                 rows = []
         return [dict(r) for r in rows]
 
+    async def list_flashcards_for_export(
+        self,
+        course_id: int,
+        student_id: Optional[int] = None,
+        node_id: Optional[int] = None,
+    ) -> list[dict]:
+        """Flashcards of a course (optionally one student / one node) with SM-2 state, for deck export."""
+        async with get_ai_conn() as conn:
+            rows = await conn.fetch(
+                """
+                SELECT f.id, f.course_id, f.node_id, f.lesson_id, f.content_id, f.student_id,
+                       f.front_text, f.back_text, f.status,
+                       (f.created_at AT TIME ZONE 'UTC') as created_at,
+                       fr.easiness_factor, fr.interval_days, fr.repetitions, fr.quality_last,
+                       fr.next_review_date, (fr.last_reviewed_at AT TIME ZONE 'UTC') as last_reviewed_at
+                FROM flashcards f
+                LEFT JOIN flashcard_repetitions fr
+                       ON fr.flashcard_id = f.id AND fr.student_id = f.student_id
+                WHERE f.course_id = $1
+                  AND ($2::bigint IS NULL OR f.student_id = $2)
+                  AND ($3::bigint IS NULL OR f.node_id = $3)
+                  AND f.status <> 'ARCHIVED'
+                ORDER BY f.created_at ASC
+                """,
+                course_id, student_id, node_id,
+            )
+        return [dict(r) for r in rows]
+
     # ── Write ─────────────────────────────────────────────────────────────────
 
     async def create_flashcards(
@@ -106,12 +134,25 @@ class FlashcardService:
                                 student_id, course_id, item.get("front_text", "")[:40])
                     continue
                 fc_id = row["id"]
+                # Imported decks (Anki/CSV) may carry an existing SM-2 schedule.
+                next_review = item.get("next_review_date")
+                last_reviewed = item.get("last_reviewed_at")
                 await conn.execute(
                     """
-                    INSERT INTO flashcard_repetitions (student_id, flashcard_id, course_id, next_review_date)
-                    VALUES ($1, $2, $3, CURRENT_DATE)
+                    INSERT INTO flashcard_repetitions (
+                        student_id, flashcard_id, course_id,
+                        easiness_factor, interval_days, repetitions, quality_last,
+                        next_review_date, last_reviewed_at
+                    )
+                    VALUES ($1, $2, $3,
+                            COALESCE($4, 2.5), COALESCE($5, 1), COALESCE($6, 0), COALESCE($7, 0),
+                            COALESCE($8::date, CURRENT_DATE), $9::timestamptz AT TIME ZONE 'UTC')
                     """,
                     student_id, fc_id, course_id,
+                    item.get("easiness_factor"), item.get("interval_days"),
+                    item.get("repetitions"), item.get("quality_last"),
+                    date.fromisoformat(next_review) if next_review else None,
+                    datetime.fromisoformat(last_reviewed.replace("Z", "+00:00")) if last_reviewed else None,
                 )
                 results.append({
                     "id":         fc_id,
@@ -32,6 +32,7 @@ import httpx
 from app.core.config import get_settings
 from app.core.llm import chat_complete_json
 from app.core.llm_gateway import TASK_MICRO_LESSON_GEN
+from app.core.service_auth import ServiceAuth
 from app.services.chunker import detect_language
 
 logger = logging.getLogger(__name__)
@@ -525,7 +526,7 @@ class MicroLessonService:
                 resp = await client.post(
                     url,
                     json=body,
-                    headers={"X-API-Secret": settings.ai_service_secret},
+                    auth=ServiceAuth(),
                 )
                 if resp.status_code >= 400:
                     logger.warning("LMS callback %s -> %d: %s", path, resp.status_code, resp.text[:200])
@@ -31,6 +31,7 @@ import httpx
 from app.core.config import get_settings
 from app.core.llm import chat_complete_json
 from app.core.llm_gateway import TASK_MICRO_QUIZ_GEN
+from app.core.service_auth import ServiceAuth
 
 logger = logging.getLogger(__name__)
 settings = get_settings()
@@ -549,7 +550,7 @@ class MicroQuizService:
             async with httpx.AsyncClient(timeout=30) as client:
                 resp = await client.post(
                     url, json=body,
-                    headers={"X-API-Secret": settings.ai_service_secret},
+                    auth=ServiceAuth(),
                 )
                 if resp.status_code >= 400:
                     logger.warning("LMS callback %s -> %d: %s",
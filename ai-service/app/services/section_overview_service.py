@@ -35,6 +35,7 @@ from app.core.llm_gateway.token_budget import (
     pack_by_token_budget,
     split_text_preserving_content,
 )
+from app.core.service_auth import ServiceAuth
 
 logger = logging.getLogger(__name__)
 settings = get_settings()
@@ -1395,7 +1396,7 @@ class SectionOverviewService:
                 resp = await client.post(
                     url,
                     json=body,
-                    headers={"X-API-Secret": settings.ai_service_secret},
+                    auth=ServiceAuth(),
                 )
                 if resp.status_code >= 400:
                     logger.warning(
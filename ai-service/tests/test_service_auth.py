@@ -0,0 +1,46 @@
+"""Signed LMS requests must match the Go verifier (lms-service pkg/svcauth)."""
+from __future__ import annotations
+
+import importlib.util
+from pathlib import Path
+import unittest
+
+
+_PATH = Path(__file__).resolve().parents[1] / "app/core/service_auth.py"
+_SPEC = importlib.util.spec_from_file_location("service_auth_under_test", _PATH)
+assert _SPEC and _SPEC.loader
+service_auth = importlib.util.module_from_spec(_SPEC)
+_SPEC.loader.exec_module(service_auth)
+
+
+class SignHeadersTests(unittest.TestCase):
+    def test_matches_go_verifier_vector(self) -> None:
+        # Same request as TestVerifyAcceptsFixedVector in pkg/svcauth
+        headers = service_auth.sign_headers(
+            "POST",
+            "/api/v1/internal/micro-lessons/status",
+            "a=1&b=x%20y",
+            b'{"job_id":"j1"}',
+            "ai-secret",
+            user_id="42",
+            timestamp=1700000000,
+            nonce="0123456789abcdef0123456789abcdef",
+        )
+
+        self.assertEqual(headers["X-Service-Name"], "ai-service")
+        self.assertEqual(headers["X-User-Id"], "42")
+        self.assertEqual(
+            headers["X-Service-Signature"],
+            "53dbe50001658daaa7e80f6ef7d1ea94b9d63185eea2a00f04a972b8649c098d",
+        )
+
+    def test_each_request_gets_a_fresh_nonce(self) -> None:
+        first = service_auth.sign_headers("GET", "/api/v1/courses/my", "", b"", "s")
+        second = service_auth.sign_headers("GET", "/api/v1/courses/my", "", b"", "s")
+
+        self.assertNotEqual(first["X-Service-Nonce"], second["X-Service-Nonce"])
+        self.assertNotIn("X-User-Id", first)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -27,15 +27,19 @@ import java.util.List;
 public class SecurityConfig {
 
     private final JwtAuthFilter jwtAuthFilter;
+    private final ServiceAuthFilter serviceAuthFilter;
 
     private static final String[] PUBLIC_PATHS = {
         "/swagger-ui/**",
         "/v3/api-docs/**",
         "/actuator/**",
         "/api/auth/**",
+        "/.well-known/jwks.json",
         "/uploads/profiles/**",
         "/api/organizations",
         "/api/internal/recruitment/**",
+        // Guarded by ServiceAuthFilter's request signatures instead of a JWT
+        "/api/internal/sync/**",
         "/hub/**",
         // Compatibility for callers that reach the service without the API gateway.
         "/api/v1/hub/**"
@@ -54,6 +58,7 @@ public class SecurityConfig {
                 .authorizeHttpRequests(auth -> auth
                         .requestMatchers(PUBLIC_PATHS).permitAll()
                         .anyRequest().authenticated())
+                .addFilterBefore(serviceAuthFilter, UsernamePasswordAuthenticationFilter.class)
                 .addFilterBefore(jwtAuthFilter, UsernamePasswordAuthenticationFilter.class)
                 .build();
     }
@@ -0,0 +1,81 @@
+package com.example.demo.config;
+
+import com.example.demo.utils.ServiceRequestVerifier;
+import com.example.demo.utils.ServiceRequestVerifier.SignedRequest;
+import jakarta.servlet.FilterChain;
+import jakarta.servlet.ServletException;
+import jakarta.servlet.http.HttpServletRequest;
+import jakarta.servlet.http.HttpServletResponse;
+import lombok.extern.slf4j.Slf4j;
+import org.springframework.beans.factory.annotation.Value;
+import org.springframework.http.HttpMethod;
+import org.springframework.http.MediaType;
+import org.springframework.stereotype.Component;
+import org.springframework.web.filter.OncePerRequestFilter;
+
+import java.io.IOException;
+import java.util.HashMap;
+
+/**
+ * Lets only requests signed by a known service (pkg/svcauth) reach the
+ * internal sync endpoints. Those endpoints are read-only, so only bodiless
+ * GET requests are accepted.
+ */
+@Slf4j
+@Component
+public class ServiceAuthFilter extends OncePerRequestFilter {
+
+    public static final String PROTECTED_PREFIX = "/api/internal/sync/";
+
+    private final ServiceRequestVerifier verifier;
+
+    public ServiceAuthFilter(
+            @Value("${internal-sync.service-name:lms-service}") String serviceName,
+            @Value("${internal-sync.secret:}") String secret) {
+        var secrets = new HashMap<String, String>();
+        if (secret != null && !secret.isBlank()) {
+            secrets.put(serviceName, secret);
+        } else {
+            log.warn("internal-sync.secret is not set; the internal sync API refuses every request");
+        }
+        this.verifier = new ServiceRequestVerifier(secrets);
+    }
+
+    @Override
+    protected boolean shouldNotFilter(HttpServletRequest req) {
+        return !req.getRequestURI().startsWith(req.getContextPath() + PROTECTED_PREFIX);
+    }
+
+    @Override
+    protected void doFilterInternal(HttpServletRequest req,
+                                    HttpServletResponse res,
+                                    FilterChain chain) throws ServletException, IOException {
+        if (!HttpMethod.GET.matches(req.getMethod())) {
+            reject(res, HttpServletResponse.SC_METHOD_NOT_ALLOWED, "Only GET is supported");
+            return;
+        }
+        try {
+            verifier.verify(new SignedRequest(
+                    req.getMethod(),
+                    req.getRequestURI(),
+                    req.getQueryString(),
+                    null,
+                    req.getHeader("X-Service-Name"),
+                    req.getHeader("X-Service-Timestamp"),
+                    req.getHeader("X-Service-Nonce"),
+                    req.getHeader("X-Service-Signature"),
+                    req.getHeader("X-User-Id")));
+        } catch (IllegalArgumentException e) {
+            log.warn("Rejected internal sync request to {}: {}", req.getRequestURI(), e.getMessage());
+            reject(res, HttpServletResponse.SC_UNAUTHORIZED, "Invalid service signature");
+            return;
+        }
+        chain.doFilter(req, res);
+    }
+
+    private static void reject(HttpServletResponse res, int status, String message) throws IOException {
+        res.setStatus(status);
+        res.setContentType(MediaType.APPLICATION_JSON_VALUE);
+        res.getWriter().write("{\"message\":\"" + message + "\"}");
+    }
+}
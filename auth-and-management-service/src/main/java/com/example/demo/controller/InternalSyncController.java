@@ -0,0 +1,39 @@
+package com.example.demo.controller;
+
+import com.example.demo.dto.sync.OrgSnapshot;
+import com.example.demo.dto.sync.SyncPage;
+import com.example.demo.dto.sync.UserSnapshot;
+import com.example.demo.service.sync.AuthSnapshotService;
+import lombok.RequiredArgsConstructor;
+import org.springframework.http.ResponseEntity;
+import org.springframework.web.bind.annotation.GetMapping;
+import org.springframework.web.bind.annotation.RequestMapping;
+import org.springframework.web.bind.annotation.RequestParam;
+import org.springframework.web.bind.annotation.RestController;
+
+/**
+ * Snapshot API that the LMS reconciler pages through to repair state missed
+ * by auth.user.events / auth.org.events. Only signed service requests reach
+ * it; see ServiceAuthFilter.
+ */
+@RestController
+@RequestMapping("/api/internal/sync")
+@RequiredArgsConstructor
+public class InternalSyncController {
+
+    private final AuthSnapshotService snapshotService;
+
+    @GetMapping("/users")
+    public ResponseEntity<SyncPage<UserSnapshot>> users(
+            @RequestParam(defaultValue = "0") int page,
+            @RequestParam(defaultValue = "500") int size) {
+        return ResponseEntity.ok(snapshotService.users(page, size));
+    }
+
+    @GetMapping("/organizations")
+    public ResponseEntity<SyncPage<OrgSnapshot>> organizations(
+            @RequestParam(defaultValue = "0") int page,
+            @RequestParam(defaultValue = "500") int size) {
+        return ResponseEntity.ok(snapshotService.organizations(page, size));
+    }
+}
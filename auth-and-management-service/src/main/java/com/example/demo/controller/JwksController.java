@@ -0,0 +1,29 @@
+package com.example.demo.controller;
+
+import com.example.demo.service.auth.JwtSigningKeys;
+import lombok.RequiredArgsConstructor;
+import org.springframework.http.CacheControl;
+import org.springframework.http.ResponseEntity;
+import org.springframework.web.bind.annotation.GetMapping;
+import org.springframework.web.bind.annotation.RestController;
+
+import java.time.Duration;
+import java.util.Map;
+
+/**
+ * Publishes the public keys access tokens are signed with, for the Go
+ * services' JWT_JWKS_URL.
+ */
+@RestController
+@RequiredArgsConstructor
+public class JwksController {
+
+    private final JwtSigningKeys signingKeys;
+
+    @GetMapping("/.well-known/jwks.json")
+    public ResponseEntity<Map<String, Object>> jwks() {
+        return ResponseEntity.ok()
+                .cacheControl(CacheControl.maxAge(Duration.ofMinutes(5)).cachePublic())
+                .body(signingKeys.jwks());
+    }
+}
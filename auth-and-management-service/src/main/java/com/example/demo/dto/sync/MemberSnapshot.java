@@ -0,0 +1,15 @@
+package com.example.demo.dto.sync;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+/** One user's membership of an organization (pkg/authsync MemberSnapshot). */
+public record MemberSnapshot(
+        @JsonProperty("org_id") Long orgId,
+        @JsonProperty("user_id") Long userId,
+        @JsonProperty("org_role") String orgRole,
+        long version) {
+
+    public MemberSnapshot withVersion(long version) {
+        return new MemberSnapshot(orgId, userId, orgRole, version);
+    }
+}
@@ -0,0 +1,20 @@
+package com.example.demo.dto.sync;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+import java.time.Instant;
+
+/**
+ * Published to auth.org.events keyed by org id, so membership changes stay
+ * ordered with their organization. organization is set for ORG_UPSERTED,
+ * member for MEMBER_UPSERTED.
+ */
+public record OrgEvent(
+        @JsonProperty("event_id") String eventId,
+        String type,
+        @JsonProperty("org_id") Long orgId,
+        @JsonProperty("user_id") Long userId,
+        long version,
+        @JsonProperty("occurred_at") Instant occurredAt,
+        OrgSnapshot organization,
+        MemberSnapshot member) {}
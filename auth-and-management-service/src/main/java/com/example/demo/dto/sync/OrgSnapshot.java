@@ -0,0 +1,26 @@
+package com.example.demo.dto.sync;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+import com.fasterxml.jackson.annotation.JsonRawValue;
+
+import java.util.List;
+
+/**
+ * Full state of one organization (pkg/authsync OrgSnapshot). Settings is
+ * the stored JSON document; members are only filled by the snapshot API.
+ */
+public record OrgSnapshot(
+        Long id,
+        String name,
+        String slug,
+        String description,
+        @JsonProperty("logo_url") String logoUrl,
+        @JsonProperty("is_active") boolean isActive,
+        @JsonRawValue String settings,
+        long version,
+        List<MemberSnapshot> members) {
+
+    public OrgSnapshot withVersion(long version) {
+        return new OrgSnapshot(id, name, slug, description, logoUrl, isActive, settings, version, members);
+    }
+}
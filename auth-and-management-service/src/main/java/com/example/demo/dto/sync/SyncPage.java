@@ -0,0 +1,6 @@
+package com.example.demo.dto.sync;
+
+import java.util.List;
+
+/** One page of a snapshot, in the fields of a Spring Data page the LMS reads. */
+public record SyncPage<T>(List<T> content, int number, int size, boolean last) {}
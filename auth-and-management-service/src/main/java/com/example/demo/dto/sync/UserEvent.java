@@ -0,0 +1,14 @@
+package com.example.demo.dto.sync;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+import java.time.Instant;
+
+/** Published to auth.user.events keyed by user id; user is set for USER_UPSERTED. */
+public record UserEvent(
+        @JsonProperty("event_id") String eventId,
+        String type,
+        @JsonProperty("user_id") Long userId,
+        long version,
+        @JsonProperty("occurred_at") Instant occurredAt,
+        UserSnapshot user) {}
@@ -0,0 +1,21 @@
+package com.example.demo.dto.sync;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+import java.util.List;
+
+/** Full state of one user as the LMS stores it (pkg/authsync UserSnapshot). */
+public record UserSnapshot(
+        @JsonProperty("user_id") Long userId,
+        String email,
+        @JsonProperty("full_name") String fullName,
+        @JsonProperty("profile_picture") String profilePicture,
+        List<String> roles,
+        String org,
+        @JsonProperty("is_active") boolean isActive,
+        long version) {
+
+    public UserSnapshot withVersion(long version) {
+        return new UserSnapshot(userId, email, fullName, profilePicture, roles, org, isActive, version);
+    }
+}
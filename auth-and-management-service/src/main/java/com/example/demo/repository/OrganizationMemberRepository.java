@@ -14,6 +14,7 @@ import java.util.Optional;
 public interface OrganizationMemberRepository extends JpaRepository<OrganizationMember, Long> {
     List<OrganizationMember> findByUser(User user);
     List<OrganizationMember> findByOrganization(Organization org);
+    List<OrganizationMember> findByOrganizationIdIn(Collection<Long> orgIds);
     Optional<OrganizationMember> findByOrganizationAndUser(Organization org, User user);
     boolean existsByOrganizationAndUser(Organization org, User user);
     void deleteByOrganizationAndUser(Organization org, User user);
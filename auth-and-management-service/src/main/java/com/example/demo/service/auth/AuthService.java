@@ -19,6 +19,7 @@ import com.example.demo.service.org.OrganizationSyncService;
 import com.example.demo.service.user.UserSyncService;
 import com.example.demo.strategy.RoleResolutionStrategy;
 import com.example.demo.utils.PasswordGenerator;
+import com.example.demo.service.sync.AuthSyncEventPublisher;
 import lombok.RequiredArgsConstructor;
 import lombok.extern.slf4j.Slf4j;
 import org.springframework.beans.factory.annotation.Value;
@@ -44,6 +45,7 @@ public class AuthService {
     private final PasswordEncoder passwordEncoder;
     private final EmailService emailService;
     private final UserSyncService userSyncService;
+    private final AuthSyncEventPublisher authSyncEvents;
     private final RoleResolutionStrategy roleStrategy;
     private final OrganizationRepository organizationRepository;
     private final OrganizationMemberRepository organizationMemberRepository;
@@ -239,6 +241,8 @@ public class AuthService {
         emailService.sendWelcomeBatch(emailToPassword, emailToName)
                     .exceptionally(ex -> { log.error("Batch email error: {}", ex.getMessage()); return null; });
 
+        saved.forEach(authSyncEvents::userUpserted);
+        savedMemberships.forEach(authSyncEvents::memberUpserted);
         userSyncService.syncUsers(saved)
                        .thenRun(() -> savedMemberships.forEach(organizationSyncService::syncMember))
                        .exceptionally(ex -> { log.error("LMS sync error: {}", ex.getMessage()); return null; });
@@ -22,6 +22,7 @@ import com.google.api.client.googleapis.auth.oauth2.GoogleIdTokenVerifier;
 import com.google.api.client.http.javanet.NetHttpTransport;
 import com.google.api.client.json.gson.GsonFactory;
 import jakarta.annotation.PostConstruct;
+import com.example.demo.service.sync.AuthSyncEventPublisher;
 import lombok.RequiredArgsConstructor;
 import lombok.extern.slf4j.Slf4j;
 import org.springframework.beans.factory.annotation.Value;
@@ -42,6 +43,7 @@ public class GoogleAuthService {
     private final OrganizationRepository organizationRepository;
     private final OrganizationMemberRepository organizationMemberRepository;
     private final OrganizationSyncService organizationSyncService;
+    private final AuthSyncEventPublisher authSyncEvents;
     private final PasswordEncoder passwordEncoder;
     private final UserSyncService userSyncService;
 
@@ -184,6 +186,7 @@ public class GoogleAuthService {
                         .orgRole("MEMBER")
                         .build();
                 organizationMemberRepository.save(member);
+                authSyncEvents.memberUpserted(member);
                 organizationSyncService.syncMember(member);
             } else {
                 log.warn("Organization '{}' not found during Google register!", req.getOrganization());
@@ -1,22 +1,36 @@
 package com.example.demo.service.auth;
 
 import io.jsonwebtoken.Claims;
+import io.jsonwebtoken.Header;
+import io.jsonwebtoken.JwtBuilder;
 import io.jsonwebtoken.JwtException;
 import io.jsonwebtoken.Jwts;
+import io.jsonwebtoken.ProtectedHeader;
 import io.jsonwebtoken.security.Keys;
 import jakarta.annotation.PostConstruct;
+import lombok.RequiredArgsConstructor;
 import lombok.extern.slf4j.Slf4j;
 import org.springframework.beans.factory.annotation.Value;
 import org.springframework.stereotype.Service;
 
 import javax.crypto.SecretKey;
+import java.security.Key;
 import java.util.Date;
 import java.util.List;
 
+/**
+ * Issues and validates access and refresh tokens. Tokens are signed with
+ * RS256 when a signing key is configured (see JwtSigningKeys) and with the
+ * shared HMAC secret otherwise; both kinds are accepted on the way in so
+ * tokens issued before the switch stay valid until they expire.
+ */
 @Slf4j
 @Service
+@RequiredArgsConstructor
 public class JwtService {
 
+    private final JwtSigningKeys signingKeys;
+
     @Value("${jwt.secret}")
     private String jwtSecret;
 
@@ -34,24 +48,22 @@ public class JwtService {
     }
 
     public String generateToken(Long userId, String email, List<String> roles) {
-        return Jwts.builder()
+        return sign(Jwts.builder()
                 .subject(email)
                 .claim("user_id", userId)
                 .claim("email",   email)
                 .claim("roles",   roles)
                 .issuedAt(new Date())
-                .expiration(expiryFrom(expirationMs))
-                .signWith(secretKey)
+                .expiration(expiryFrom(expirationMs)))
                 .compact();
     }
 
     public String generateRefreshToken(Long userId, String email) {
-        return Jwts.builder()
+        return sign(Jwts.builder()
                 .subject(email)
                 .claim("user_id", userId)
                 .issuedAt(new Date())
-                .expiration(expiryFrom(refreshExpirationMs))
-                .signWith(secretKey)
+                .expiration(expiryFrom(refreshExpirationMs)))
                 .compact();
     }
 
@@ -77,9 +89,30 @@ public class JwtService {
         }
     }
 
+    private JwtBuilder sign(JwtBuilder builder) {
+        if (!signingKeys.isEnabled()) {
+            return builder.signWith(secretKey);
+        }
+        return builder.header().keyId(signingKeys.keyId()).and()
+                .signWith(signingKeys.privateKey(), Jwts.SIG.RS256);
+    }
+
+    private Key verificationKey(Header header) {
+        String alg = header.getAlgorithm();
+        if (alg != null && alg.startsWith("HS")) {
+            return secretKey;
+        }
+        String kid = header instanceof ProtectedHeader protectedHeader ? protectedHeader.getKeyId() : null;
+        Key key = signingKeys.publicKey(kid);
+        if (key == null) {
+            throw new JwtException("Unknown signing key " + kid);
+        }
+        return key;
+    }
+
     private Claims claims(String token) {
         return Jwts.parser()
-                .verifyWith(secretKey)
+                .keyLocator(this::verificationKey)
                 .build()
                 .parseSignedClaims(token)
                 .getPayload();
@@ -0,0 +1,140 @@
+package com.example.demo.service.auth;
+
+import jakarta.annotation.PostConstruct;
+import lombok.extern.slf4j.Slf4j;
+import org.springframework.beans.factory.annotation.Value;
+import org.springframework.stereotype.Component;
+
+import java.math.BigInteger;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.security.KeyFactory;
+import java.security.MessageDigest;
+import java.security.interfaces.RSAPrivateCrtKey;
+import java.security.interfaces.RSAPublicKey;
+import java.security.spec.PKCS8EncodedKeySpec;
+import java.security.spec.RSAPublicKeySpec;
+import java.security.spec.X509EncodedKeySpec;
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.Base64;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * RSA key pair used to sign access tokens with RS256. The public half is
+ * published at /.well-known/jwks.json so the Go services can verify tokens
+ * without the shared JWT secret. When no private key is configured the
+ * service keeps signing with HMAC and the key set is empty.
+ *
+ * Previous public keys can be listed during a rotation so tokens signed
+ * with the old key stay verifiable until they expire.
+ */
+@Slf4j
+@Component
+public class JwtSigningKeys {
+
+    // PKCS#8 PEM ("BEGIN PRIVATE KEY"), inline or as a file path
+    @Value("${jwt.signing.private-key:}")
+    private String privateKeyPem;
+
+    @Value("${jwt.signing.private-key-file:}")
+    private String privateKeyFile;
+
+    // X.509 PEM public keys still accepted after a rotation
+    @Value("${jwt.signing.previous-public-keys:}")
+    private List<String> previousPublicKeyPems;
+
+    private RSAPrivateCrtKey privateKey;
+    private String keyId;
+    private final Map<String, RSAPublicKey> publicKeys = new LinkedHashMap<>();
+
+    @PostConstruct
+    public void init() throws Exception {
+        String pem = privateKeyPem;
+        if (pem.isBlank() && !privateKeyFile.isBlank()) {
+            pem = Files.readString(Path.of(privateKeyFile), StandardCharsets.UTF_8);
+        }
+        if (pem.isBlank()) {
+            log.warn("jwt.signing.private-key is not set; access tokens are signed with HMAC and the JWKS is empty");
+            return;
+        }
+
+        KeyFactory rsa = KeyFactory.getInstance("RSA");
+        privateKey = (RSAPrivateCrtKey) rsa.generatePrivate(new PKCS8EncodedKeySpec(decodePem(pem)));
+        RSAPublicKey publicKey = (RSAPublicKey) rsa.generatePublic(
+                new RSAPublicKeySpec(privateKey.getModulus(), privateKey.getPublicExponent()));
+        keyId = thumbprint(publicKey);
+        publicKeys.put(keyId, publicKey);
+
+        for (String previous : previousPublicKeyPems) {
+            if (previous == null || previous.isBlank()) {
+                continue;
+            }
+            RSAPublicKey key = (RSAPublicKey) rsa.generatePublic(
+                    new X509EncodedKeySpec(decodePem(previous)));
+            publicKeys.putIfAbsent(thumbprint(key), key);
+        }
+        log.info("Signing access tokens with RS256 key {}", keyId);
+    }
+
+    public boolean isEnabled() {
+        return privateKey != null;
+    }
+
+    public RSAPrivateCrtKey privateKey() {
+        return privateKey;
+    }
+
+    public String keyId() {
+        return keyId;
+    }
+
+    public RSAPublicKey publicKey(String kid) {
+        if (kid == null) {
+            return keyId == null ? null : publicKeys.get(keyId);
+        }
+        return publicKeys.get(kid);
+    }
+
+    /** The JSON Web Key Set document (RFC 7517) for the accepted public keys. */
+    public Map<String, Object> jwks() {
+        List<Map<String, Object>> keys = new ArrayList<>();
+        publicKeys.forEach((kid, key) -> {
+            Map<String, Object> jwk = new LinkedHashMap<>();
+            jwk.put("kty", "RSA");
+            jwk.put("use", "sig");
+            jwk.put("alg", "RS256");
+            jwk.put("kid", kid);
+            jwk.put("n", base64Url(key.getModulus()));
+            jwk.put("e", base64Url(key.getPublicExponent()));
+            keys.add(jwk);
+        });
+        return Map.of("keys", keys);
+    }
+
+    private static byte[] decodePem(String pem) {
+        String body = pem.replaceAll("-----(BEGIN|END) [A-Z ]+-----", "")
+                .replace("\\n", "")
+                .replaceAll("\\s", "");
+        return Base64.getDecoder().decode(body);
+    }
+
+    // RFC 7638 thumbprint, so the kid changes whenever the key does
+    private static String thumbprint(RSAPublicKey key) throws Exception {
+        String canonical = "{\"e\":\"" + base64Url(key.getPublicExponent())
+                + "\",\"kty\":\"RSA\",\"n\":\"" + base64Url(key.getModulus()) + "\"}";
+        byte[] digest = MessageDigest.getInstance("SHA-256").digest(canonical.getBytes(StandardCharsets.UTF_8));
+        return Base64.getUrlEncoder().withoutPadding().encodeToString(digest);
+    }
+
+    private static String base64Url(BigInteger value) {
+        byte[] bytes = value.toByteArray();
+        if (bytes.length > 1 && bytes[0] == 0) {
+            bytes = Arrays.copyOfRange(bytes, 1, bytes.length);
+        }
+        return Base64.getUrlEncoder().withoutPadding().encodeToString(bytes);
+    }
+}
@@ -0,0 +1,81 @@
+package com.example.demo.service.kafka;
+
+import com.example.demo.model.OrganizationMember;
+import com.example.demo.repository.OrganizationMemberRepository;
+import com.example.demo.repository.OrganizationRepository;
+import com.example.demo.repository.UserRepository;
+import com.example.demo.service.sync.AuthSyncEventPublisher;
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import lombok.RequiredArgsConstructor;
+import lombok.extern.slf4j.Slf4j;
+import org.springframework.kafka.annotation.KafkaListener;
+import org.springframework.stereotype.Service;
+import org.springframework.transaction.annotation.Transactional;
+
+import java.io.IOException;
+import java.util.Set;
+
+/**
+ * Applies membership changes made in the LMS (admin edits, invitations,
+ * join codes, email-domain joins) from lms.org.member.events. Each applied
+ * change is published back on auth.org.events like any other membership
+ * change, which the LMS accepts as a no-op.
+ */
+@Service
+@RequiredArgsConstructor
+@Slf4j
+public class LmsMemberEventConsumer {
+
+    private static final Set<String> ORG_ROLES = Set.of("OWNER", "ADMIN", "MEMBER");
+
+    private final OrganizationRepository orgRepo;
+    private final OrganizationMemberRepository memberRepo;
+    private final UserRepository userRepo;
+    private final AuthSyncEventPublisher authSyncEvents;
+    private final ObjectMapper objectMapper;
+
+    @KafkaListener(topics = "lms.org.member.events", groupId = "auth-lms-member-sync-group")
+    @Transactional
+    public void consume(String message) throws IOException {
+        JsonNode event = objectMapper.readTree(message);
+        String type = event.path("type").asText();
+        long orgId = event.path("org_id").asLong();
+        long userId = event.path("user_id").asLong();
+
+        var org = orgRepo.findById(orgId).orElse(null);
+        var user = userRepo.findById(userId).orElse(null);
+        if (org == null || user == null) {
+            log.warn("Ignoring LMS membership event {}: org {} or user {} does not exist",
+                    event.path("event_id").asText(), orgId, userId);
+            return;
+        }
+
+        switch (type) {
+            case "MEMBER_UPSERTED" -> {
+                String role = event.path("org_role").asText("MEMBER");
+                if (!ORG_ROLES.contains(role)) {
+                    log.warn("Ignoring LMS membership event {} with role {}", event.path("event_id").asText(), role);
+                    return;
+                }
+                var member = memberRepo.findByOrganizationAndUser(org, user)
+                        .orElseGet(() -> OrganizationMember.builder().organization(org).user(user).build());
+                if (member.getId() != null && role.equals(member.getOrgRole())) {
+                    return;
+                }
+                member.setOrgRole(role);
+                authSyncEvents.memberUpserted(memberRepo.save(member));
+                log.info("Applied LMS membership of user {} in org {} as {} ({})",
+                        userId, orgId, role, event.path("source").asText());
+            }
+            case "MEMBER_REMOVED" -> {
+                if (memberRepo.existsByOrganizationAndUser(org, user)) {
+                    memberRepo.deleteByOrganizationAndUser(org, user);
+                    authSyncEvents.memberRemoved(orgId, userId);
+                    log.info("Applied LMS removal of user {} from org {}", userId, orgId);
+                }
+            }
+            default -> log.warn("Ignoring LMS membership event of unknown type {}", type);
+        }
+    }
+}
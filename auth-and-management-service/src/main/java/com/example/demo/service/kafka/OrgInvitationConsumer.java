@@ -0,0 +1,80 @@
+package com.example.demo.service.kafka;
+
+import com.example.demo.service.email.EmailService;
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import lombok.RequiredArgsConstructor;
+import lombok.extern.slf4j.Slf4j;
+import org.springframework.kafka.annotation.KafkaListener;
+import org.springframework.stereotype.Service;
+import org.springframework.web.util.HtmlUtils;
+
+import java.time.OffsetDateTime;
+import java.time.ZoneId;
+import java.time.format.DateTimeFormatter;
+import java.util.List;
+
+/** Sends the invitation emails the LMS asks for on lms.org.invitation. */
+@Service
+@RequiredArgsConstructor
+@Slf4j
+public class OrgInvitationConsumer {
+
+    private static final DateTimeFormatter EXPIRY_FORMAT =
+            DateTimeFormatter.ofPattern("HH:mm dd/MM/yyyy").withZone(ZoneId.of("Asia/Ho_Chi_Minh"));
+
+    private final EmailService emailService;
+    private final ObjectMapper objectMapper;
+
+    @KafkaListener(topics = "lms.org.invitation", groupId = "auth-org-invitation-group")
+    public void consume(String message) {
+        try {
+            JsonNode event = objectMapper.readTree(message);
+            String email = event.path("email").asText("").trim();
+            String acceptUrl = event.path("accept_url").asText("").trim();
+            if (email.isBlank() || !(acceptUrl.startsWith("https://") || acceptUrl.startsWith("http://"))) {
+                log.warn("Ignoring invalid organization invitation event {}", event.path("event_id").asText());
+                return;
+            }
+            String orgName = event.path("org_name").asText("Tổ chức #" + event.path("org_id").asLong());
+            String inviter = event.path("inviter_name").asText("");
+            String role = event.path("org_role").asText("MEMBER");
+            String expiresAt = event.hasNonNull("expires_at")
+                    ? EXPIRY_FORMAT.format(OffsetDateTime.parse(event.path("expires_at").asText()))
+                    : "";
+
+            sendInvitationEmail(email, orgName, inviter, role, acceptUrl, expiresAt);
+            log.info("Sent invitation {} for organization {}", event.path("invitation_id").asLong(), event.path("org_id").asLong());
+        } catch (Exception exception) {
+            // Throwing lets Spring Kafka apply its configured retry/error handling
+            log.error("Failed to process organization invitation", exception);
+            throw new IllegalStateException("Could not process organization invitation", exception);
+        }
+    }
+
+    private void sendInvitationEmail(String email, String orgName, String inviter, String role,
+                                     String acceptUrl, String expiresAt) {
+        String safeOrg = HtmlUtils.htmlEscape(orgName);
+        String invitedBy = inviter.isBlank() ? "" : " bởi <strong>" + HtmlUtils.htmlEscape(inviter) + "</strong>";
+        String expiry = expiresAt.isBlank() ? "" : "<p>Lời mời có hiệu lực đến <strong>" + expiresAt + "</strong>.</p>";
+        String body = """
+                <p>Xin chào,</p>
+                <p>Bạn được mời tham gia tổ chức <strong>%s</strong>%s với vai trò <strong>%s</strong>.</p>
+                <p style='margin:24px 0'>
+                  <a href='%s' style='background:#2563eb;color:#fff;padding:12px 20px;border-radius:6px;text-decoration:none'>Chấp nhận lời mời</a>
+                </p>
+                %s
+                <p>Nếu bạn không mong đợi lời mời này, hãy bỏ qua email.</p>
+                """.formatted(safeOrg, invitedBy, HtmlUtils.htmlEscape(role), HtmlUtils.htmlEscape(acceptUrl), expiry);
+
+        emailService.sendAdminMailAsync(
+                email,
+                List.of(),
+                List.of(),
+                "[BDC Hub] Lời mời tham gia " + orgName,
+                body,
+                "bdc-1",
+                "default"
+        ).join();
+    }
+}
@@ -2,6 +2,9 @@ package com.example.demo.service.org;
 
 import com.example.demo.model.Organization;
 import com.example.demo.model.OrganizationMember;
+import com.example.demo.utils.ServiceRequestSigner;
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.databind.ObjectMapper;
 import lombok.RequiredArgsConstructor;
 import lombok.extern.slf4j.Slf4j;
 import org.springframework.beans.factory.annotation.Value;
@@ -19,6 +22,7 @@ import java.util.Map;
 public class OrganizationSyncService {
 
     private final RestTemplate restTemplate;
+    private final ObjectMapper objectMapper;
 
     @Value("${lms.api.url}")
     private String lmsApiUrl;
@@ -39,12 +43,7 @@ public class OrganizationSyncService {
                 "settings", org.getSettings()
             );
 
-            restTemplate.exchange(
-                lmsApiUrl + "/api/v1/sync/organizations",
-                HttpMethod.POST,
-                new HttpEntity<>(payload, jsonAuthHeaders()),
-                Void.class
-            );
+            post("/api/v1/sync/organizations", payload);
             log.info("Synced organization {} (ID: {}) to LMS", org.getName(), org.getId());
         } catch (RestClientException ex) {
             log.error("Failed to sync organization {} to LMS: {}", org.getId(), ex.getMessage());
@@ -54,12 +53,7 @@ public class OrganizationSyncService {
     @Async("syncExecutor")
     public void deleteOrganization(Long orgId) {
         try {
-            restTemplate.exchange(
-                lmsApiUrl + "/api/v1/sync/organizations/" + orgId,
-                HttpMethod.DELETE,
-                new HttpEntity<>(jsonAuthHeaders()),
-                Void.class
-            );
+            delete("/api/v1/sync/organizations/" + orgId);
             log.info("Synced organization delete (ID: {}) to LMS", orgId);
         } catch (RestClientException ex) {
             log.error("Failed to sync organization delete {} to LMS: {}", orgId, ex.getMessage());
@@ -75,12 +69,7 @@ public class OrganizationSyncService {
                 "org_role", member.getOrgRole()
             );
 
-            restTemplate.exchange(
-                lmsApiUrl + "/api/v1/sync/organization-members",
-                HttpMethod.POST,
-                new HttpEntity<>(payload, jsonAuthHeaders()),
-                Void.class
-            );
+            post("/api/v1/sync/organization-members", payload);
             log.info("Synced membership for user {} in org {} to LMS", member.getUser().getId(), member.getOrganization().getId());
         } catch (RestClientException ex) {
             log.error("Failed to sync membership to LMS: {}", ex.getMessage());
@@ -90,22 +79,30 @@ public class OrganizationSyncService {
     @Async("syncExecutor")
     public void removeMember(Long orgId, Long userId) {
         try {
-            restTemplate.exchange(
-                lmsApiUrl + "/api/v1/sync/organization-members/" + orgId + "/users/" + userId,
-                HttpMethod.DELETE,
-                new HttpEntity<>(jsonAuthHeaders()),
-                Void.class
-            );
+            delete("/api/v1/sync/organization-members/" + orgId + "/users/" + userId);
             log.info("Synced membership removal for user {} from org {} to LMS", userId, orgId);
         } catch (RestClientException ex) {
             log.error("Failed to sync membership removal to LMS: {}", ex.getMessage());
         }
     }
 
-    private HttpHeaders jsonAuthHeaders() {
-        HttpHeaders headers = new HttpHeaders();
+    private void post(String path, Map<String, Object> payload) {
+        var url = lmsApiUrl + path;
+        byte[] body;
+        try {
+            body = objectMapper.writeValueAsBytes(payload);
+        } catch (JsonProcessingException e) {
+            throw new IllegalArgumentException("Cannot serialize sync payload", e);
+        }
+        // Sent as the exact bytes that were signed
+        HttpHeaders headers = ServiceRequestSigner.sign(HttpMethod.POST, url, body, lmsApiSecret);
         headers.setContentType(MediaType.APPLICATION_JSON);
-        headers.set("X-Sync-Secret", lmsApiSecret);
-        return headers;
+        restTemplate.exchange(url, HttpMethod.POST, new HttpEntity<>(body, headers), Void.class);
+    }
+
+    private void delete(String path) {
+        var url = lmsApiUrl + path;
+        HttpHeaders headers = ServiceRequestSigner.sign(HttpMethod.DELETE, url, null, lmsApiSecret);
+        restTemplate.exchange(url, HttpMethod.DELETE, new HttpEntity<>(headers), Void.class);
     }
 }
@@ -13,6 +13,7 @@ import com.example.demo.service.org.OrganizationSyncService;
 import com.example.demo.service.user.UserSyncService;
 import com.fasterxml.jackson.core.JsonProcessingException;
 import com.fasterxml.jackson.databind.ObjectMapper;
+import com.example.demo.service.sync.AuthSyncEventPublisher;
 import lombok.RequiredArgsConstructor;
 import lombok.extern.slf4j.Slf4j;
 import org.springframework.stereotype.Service;
@@ -31,6 +32,7 @@ public class OrganizationServiceImpl implements OrganizationService {
     private final OrganizationMemberRepository memberRepo;
     private final UserRepository userRepo;
     private final OrganizationSyncService syncService;
+    private final AuthSyncEventPublisher authSyncEvents;
     private final UserSyncService userSyncService;
     private final ObjectMapper objectMapper;
 
@@ -76,6 +78,7 @@ public class OrganizationServiceImpl implements OrganizationService {
                 .build();
 
         org = orgRepo.save(org);
+        authSyncEvents.organizationUpserted(org);
         syncService.syncOrganization(org);
 
         return toResponse(org);
@@ -99,6 +102,7 @@ public class OrganizationServiceImpl implements OrganizationService {
         if (req.getSettings() != null) org.setSettings(serializeSettings(req.getSettings()));
 
         org = orgRepo.save(org);
+        authSyncEvents.organizationUpserted(org);
         syncService.syncOrganization(org);
 
         return toResponse(org);
@@ -117,6 +121,7 @@ public class OrganizationServiceImpl implements OrganizationService {
         );
 
         orgRepo.deleteById(id);
+        authSyncEvents.organizationDeleted(id);
         syncService.deleteOrganization(id);
 
         // Update each affected user's flat organization representation
@@ -166,6 +171,7 @@ public class OrganizationServiceImpl implements OrganizationService {
                 .build();
 
         member = memberRepo.save(member);
+        authSyncEvents.memberUpserted(member);
         syncService.syncMember(member);
 
         // Update User flat organization representation
@@ -186,6 +192,7 @@ public class OrganizationServiceImpl implements OrganizationService {
 
         member.setOrgRole(req.getOrgRole());
         member = memberRepo.save(member);
+        authSyncEvents.memberUpserted(member);
         syncService.syncMember(member);
     }
 
@@ -199,6 +206,7 @@ public class OrganizationServiceImpl implements OrganizationService {
                 .orElseThrow(() -> new ResourceNotFoundException("User not found with ID: " + userId));
 
         memberRepo.deleteByOrganizationAndUser(org, user);
+        authSyncEvents.memberRemoved(orgId, userId);
         syncService.removeMember(orgId, userId);
 
         // Update User flat organization representation
@@ -242,6 +250,7 @@ public class OrganizationServiceImpl implements OrganizationService {
                             .build();
 
                     member = memberRepo.save(member);
+                    authSyncEvents.memberUpserted(member);
                     syncService.syncMember(member);
                     updateUserFlatOrg(user);
                 }
@@ -330,6 +339,7 @@ public class OrganizationServiceImpl implements OrganizationService {
         userRepo.save(user);
 
         // Sync user profile change (including the updated flat organization string) to LMS/Chat
+        authSyncEvents.userUpserted(user);
         userSyncService.syncUser(user);
     }
 }
@@ -0,0 +1,66 @@
+package com.example.demo.service.sync;
+
+import com.example.demo.dto.sync.MemberSnapshot;
+import com.example.demo.dto.sync.OrgSnapshot;
+import com.example.demo.dto.sync.SyncPage;
+import com.example.demo.dto.sync.UserSnapshot;
+import com.example.demo.model.Organization;
+import com.example.demo.repository.OrganizationMemberRepository;
+import com.example.demo.repository.OrganizationRepository;
+import com.example.demo.repository.UserRepository;
+import com.example.demo.utils.SyncVersions;
+import lombok.RequiredArgsConstructor;
+import org.springframework.data.domain.PageRequest;
+import org.springframework.data.domain.Sort;
+import org.springframework.stereotype.Service;
+import org.springframework.transaction.annotation.Transactional;
+
+import java.util.List;
+import java.util.Map;
+import java.util.stream.Collectors;
+
+/**
+ * Pages through users and organizations for the LMS reconciler
+ * (cmd/reconcile). Every entity on a page carries a version taken before
+ * the page is read, so an event for a change the page missed is always
+ * newer and wins.
+ */
+@Service
+@RequiredArgsConstructor
+@Transactional(readOnly = true)
+public class AuthSnapshotService {
+
+    public static final int MAX_PAGE_SIZE = 1000;
+
+    private final UserRepository userRepository;
+    private final OrganizationRepository organizationRepository;
+    private final OrganizationMemberRepository memberRepository;
+    private final AuthSyncSnapshots snapshots;
+
+    public SyncPage<UserSnapshot> users(int page, int size) {
+        long version = SyncVersions.next();
+        var result = userRepository.findAll(pageRequest(page, size));
+        return new SyncPage<>(
+                result.getContent().stream().map(u -> snapshots.user(u, version)).toList(),
+                result.getNumber(), result.getSize(), result.isLast());
+    }
+
+    public SyncPage<OrgSnapshot> organizations(int page, int size) {
+        long version = SyncVersions.next();
+        var result = organizationRepository.findAll(pageRequest(page, size));
+        var orgIds = result.getContent().stream().map(Organization::getId).toList();
+        Map<Long, List<MemberSnapshot>> members = orgIds.isEmpty() ? Map.of()
+                : memberRepository.findByOrganizationIdIn(orgIds).stream()
+                        .map(m -> snapshots.member(m, version))
+                        .collect(Collectors.groupingBy(MemberSnapshot::orgId));
+        return new SyncPage<>(
+                result.getContent().stream()
+                        .map(o -> snapshots.organization(o, version, members.getOrDefault(o.getId(), List.of())))
+                        .toList(),
+                result.getNumber(), result.getSize(), result.isLast());
+    }
+
+    private static PageRequest pageRequest(int page, int size) {
+        return PageRequest.of(Math.max(page, 0), Math.min(Math.max(size, 1), MAX_PAGE_SIZE), Sort.by("id"));
+    }
+}
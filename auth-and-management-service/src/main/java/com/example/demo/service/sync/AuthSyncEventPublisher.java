@@ -0,0 +1,113 @@
+package com.example.demo.service.sync;
+
+import com.example.demo.dto.sync.OrgEvent;
+import com.example.demo.dto.sync.UserEvent;
+import com.example.demo.model.Organization;
+import com.example.demo.model.OrganizationMember;
+import com.example.demo.model.User;
+import com.example.demo.utils.SyncVersions;
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import lombok.RequiredArgsConstructor;
+import lombok.extern.slf4j.Slf4j;
+import org.springframework.kafka.core.KafkaTemplate;
+import org.springframework.stereotype.Service;
+import org.springframework.transaction.support.TransactionSynchronization;
+import org.springframework.transaction.support.TransactionSynchronizationManager;
+
+import java.time.Instant;
+import java.util.UUID;
+import java.util.function.LongFunction;
+
+/**
+ * Publishes user, organization and membership lifecycle events for the LMS
+ * (pkg/authsync). The entity state is captured when called; the event is
+ * versioned and sent once the surrounding transaction commits, so a
+ * snapshot read by the reconciler never carries a newer version than
+ * state it has not seen. A failed send is logged and left to the
+ * reconciler.
+ */
+@Slf4j
+@Service
+@RequiredArgsConstructor
+public class AuthSyncEventPublisher {
+
+    public static final String TOPIC_USER_EVENTS = "auth.user.events";
+    public static final String TOPIC_ORG_EVENTS  = "auth.org.events";
+
+    private final KafkaTemplate<String, String> kafkaTemplate;
+    private final ObjectMapper objectMapper;
+    private final AuthSyncSnapshots snapshots;
+
+    public void userUpserted(User user) {
+        var userId = user.getId();
+        var snapshot = snapshots.user(user, 0);
+        afterCommit(TOPIC_USER_EVENTS, userId, version -> new UserEvent(
+                eventId(), "USER_UPSERTED", userId, version, Instant.now(),
+                snapshot.withVersion(version)));
+    }
+
+    public void userDeleted(Long userId) {
+        afterCommit(TOPIC_USER_EVENTS, userId, version -> new UserEvent(
+                eventId(), "USER_DELETED", userId, version, Instant.now(), null));
+    }
+
+    public void organizationUpserted(Organization org) {
+        var orgId = org.getId();
+        var snapshot = snapshots.organization(org, 0, null);
+        afterCommit(TOPIC_ORG_EVENTS, orgId, version -> new OrgEvent(
+                eventId(), "ORG_UPSERTED", orgId, null, version, Instant.now(),
+                snapshot.withVersion(version), null));
+    }
+
+    public void organizationDeleted(Long orgId) {
+        afterCommit(TOPIC_ORG_EVENTS, orgId, version -> new OrgEvent(
+                eventId(), "ORG_DELETED", orgId, null, version, Instant.now(), null, null));
+    }
+
+    public void memberUpserted(OrganizationMember member) {
+        var snapshot = snapshots.member(member, 0);
+        afterCommit(TOPIC_ORG_EVENTS, snapshot.orgId(), version -> new OrgEvent(
+                eventId(), "MEMBER_UPSERTED", snapshot.orgId(), snapshot.userId(), version, Instant.now(),
+                null, snapshot.withVersion(version)));
+    }
+
+    public void memberRemoved(Long orgId, Long userId) {
+        afterCommit(TOPIC_ORG_EVENTS, orgId, version -> new OrgEvent(
+                eventId(), "MEMBER_REMOVED", orgId, userId, version, Instant.now(), null, null));
+    }
+
+    private void afterCommit(String topic, Long key, LongFunction<Object> event) {
+        if (!TransactionSynchronizationManager.isSynchronizationActive()) {
+            send(topic, key, event);
+            return;
+        }
+        TransactionSynchronizationManager.registerSynchronization(new TransactionSynchronization() {
+            @Override
+            public void afterCommit() {
+                send(topic, key, event);
+            }
+        });
+    }
+
+    private void send(String topic, Long key, LongFunction<Object> event) {
+        // Versioned after the commit; see SyncVersions
+        Object payload = event.apply(SyncVersions.next());
+        String json;
+        try {
+            json = objectMapper.writeValueAsString(payload);
+        } catch (JsonProcessingException e) {
+            log.error("Cannot serialize {} event for {}: {}", topic, key, e.getMessage());
+            return;
+        }
+        kafkaTemplate.send(topic, String.valueOf(key), json).whenComplete((result, ex) -> {
+            if (ex != null) {
+                log.error("Failed to publish {} event for {}: {}", topic, key, ex.getMessage());
+            }
+        });
+    }
+
+    private static String eventId() {
+        return UUID.randomUUID().toString();
+    }
+}
@@ -0,0 +1,54 @@
+package com.example.demo.service.sync;
+
+import com.example.demo.dto.sync.MemberSnapshot;
+import com.example.demo.dto.sync.OrgSnapshot;
+import com.example.demo.dto.sync.UserSnapshot;
+import com.example.demo.model.Organization;
+import com.example.demo.model.OrganizationMember;
+import com.example.demo.model.User;
+import com.example.demo.service.user.UserSyncService;
+import lombok.RequiredArgsConstructor;
+import org.springframework.stereotype.Component;
+
+import java.util.List;
+
+/** Maps entities to the state the LMS keeps, for events and snapshot pages alike. */
+@Component
+@RequiredArgsConstructor
+public class AuthSyncSnapshots {
+
+    private final UserSyncService userSyncService;
+
+    public UserSnapshot user(User user, long version) {
+        return new UserSnapshot(
+                user.getId(),
+                user.getEmail(),
+                user.getName(),
+                user.getProfilePicture() != null ? user.getProfilePicture() : "",
+                userSyncService.lmsRolesOf(user),
+                user.getOrganization() != null ? user.getOrganization() : "",
+                Boolean.TRUE.equals(user.getActive()),
+                version);
+    }
+
+    public OrgSnapshot organization(Organization org, long version, List<MemberSnapshot> members) {
+        return new OrgSnapshot(
+                org.getId(),
+                org.getName(),
+                org.getSlug(),
+                org.getDescription() != null ? org.getDescription() : "",
+                org.getLogoUrl() != null ? org.getLogoUrl() : "",
+                org.isActive(),
+                org.getSettings(),
+                version,
+                members);
+    }
+
+    public MemberSnapshot member(OrganizationMember member, long version) {
+        return new MemberSnapshot(
+                member.getOrganization().getId(),
+                member.getUser().getId(),
+                member.getOrgRole(),
+                version);
+    }
+}
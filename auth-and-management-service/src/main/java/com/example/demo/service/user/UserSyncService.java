@@ -2,6 +2,9 @@ package com.example.demo.service.user;
 
 import com.example.demo.model.User;
 import com.example.demo.strategy.RoleResolutionStrategy;
+import com.example.demo.utils.ServiceRequestSigner;
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.databind.ObjectMapper;
 import lombok.RequiredArgsConstructor;
 import lombok.extern.slf4j.Slf4j;
 import org.springframework.beans.factory.annotation.Value;
@@ -23,6 +26,7 @@ public class UserSyncService {
 
     private final RestTemplate restTemplate;
     private final RoleResolutionStrategy roleStrategy;
+    private final ObjectMapper objectMapper;
 
     // ── LMS service ───────────────────────────────────────────────────────────
     @Value("${lms.api.url}")
@@ -84,10 +88,11 @@ public class UserSyncService {
         // Delete from both LMS and Chat in parallel
         var lmsFuture = CompletableFuture.runAsync(() -> {
             try {
+                var url = lmsApiUrl + "/api/v1/sync/user/" + userId;
                 restTemplate.exchange(
-                    lmsApiUrl + "/api/v1/sync/user/" + userId,
+                    url,
                     HttpMethod.DELETE,
-                    new HttpEntity<>(authHeaders(lmsApiSecret)),
+                    new HttpEntity<>(ServiceRequestSigner.sign(HttpMethod.DELETE, url, null, lmsApiSecret)),
                     Void.class
                 );
                 log.info("Deleted user {} from LMS", userId);
@@ -99,10 +104,11 @@ public class UserSyncService {
         var chatFuture = CompletableFuture.runAsync(() -> {
             if (chatApiUrl == null || chatApiUrl.isBlank()) return;
             try {
+                var url = chatApiUrl + "/api/v1/sync/user/" + userId;
                 restTemplate.exchange(
-                    chatApiUrl + "/api/v1/sync/user/" + userId,
+                    url,
                     HttpMethod.DELETE,
-                    new HttpEntity<>(authHeaders(chatApiSecret)),
+                    new HttpEntity<>(ServiceRequestSigner.sign(HttpMethod.DELETE, url, null, chatApiSecret)),
                     Void.class
                 );
                 log.info("Deleted user {} from Chat", userId);
@@ -150,11 +156,16 @@ public class UserSyncService {
 
     // ── Helpers ───────────────────────────────────────────────────────────────
 
-    /** Payload for LMS service - uses "user_id" key */
-    private Map<String, Object> buildLmsPayload(User user) {
-        var lmsRoles = user.getLmsRoles() != null && !user.getLmsRoles().isEmpty()
+    /** The LMS roles of user: its explicit LMS roles, else ones derived from its roles */
+    public List<String> lmsRolesOf(User user) {
+        return user.getLmsRoles() != null && !user.getLmsRoles().isEmpty()
                 ? user.getLmsRoles().stream().distinct().toList()
                 : roleStrategy.resolveAll(user.effectiveRoles());
+    }
+
+    /** Payload for LMS service - uses "user_id" key */
+    private Map<String, Object> buildLmsPayload(User user) {
+        var lmsRoles = lmsRolesOf(user);
         return Map.of(
             "user_id",   user.getId(),
             "email",     user.getEmail(),
@@ -176,9 +187,13 @@ public class UserSyncService {
     }
 
     private void doPost(String url, Object payload, String secret) {
+        // Sent as the exact bytes that were signed
+        var body = toJson(payload);
+        var headers = ServiceRequestSigner.sign(HttpMethod.POST, url, body, secret);
+        headers.setContentType(MediaType.APPLICATION_JSON);
         var response = restTemplate.exchange(
             url, HttpMethod.POST,
-            new HttpEntity<>(payload, jsonAuthHeaders(secret)),
+            new HttpEntity<>(body, headers),
             new ParameterizedTypeReference<Map<String, Object>>() {}
         );
         if (!response.getStatusCode().is2xxSuccessful()) {
@@ -205,16 +220,12 @@ public class UserSyncService {
         }
     }
 
-    private HttpHeaders authHeaders(String secret) {
-        var headers = new HttpHeaders();
-        headers.set("X-Sync-Secret", secret);
-        return headers;
-    }
-
-    private HttpHeaders jsonAuthHeaders(String secret) {
-        var headers = authHeaders(secret);
-        headers.setContentType(MediaType.APPLICATION_JSON);
-        return headers;
+    private byte[] toJson(Object payload) {
+        try {
+            return objectMapper.writeValueAsBytes(payload);
+        } catch (JsonProcessingException e) {
+            throw new IllegalArgumentException("Cannot serialize sync payload", e);
+        }
     }
 
     private void sleep(long ms) {
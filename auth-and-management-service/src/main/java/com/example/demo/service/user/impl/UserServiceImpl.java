@@ -16,6 +16,7 @@ import com.example.demo.service.user.PasswordResetService;
 import com.example.demo.service.user.UserService;
 import com.example.demo.service.user.UserSyncService;
 
+import com.example.demo.service.sync.AuthSyncEventPublisher;
 import lombok.RequiredArgsConstructor;
 import lombok.extern.slf4j.Slf4j;
 import org.springframework.beans.factory.annotation.Value;
@@ -49,6 +50,7 @@ public class UserServiceImpl implements UserService {
     private final EmailService       emailService;
     private final PasswordResetService passwordResetService;
     private final UserSyncService    userSyncService;
+    private final AuthSyncEventPublisher authSyncEvents;
 
     @Value("${app.upload.dir:uploads/profiles/}")
     private String uploadDir;
@@ -126,6 +128,7 @@ public class UserServiceImpl implements UserService {
         if (req.getProfilePicture() != null) user.setProfilePicture(req.getProfilePicture());
         if (req.getOrganization() != null)   user.setOrganization(req.getOrganization());
         var saved = userRepository.save(user);
+        authSyncEvents.userUpserted(saved);
         userSyncService.syncUser(saved);
         return UserResponse.fromEntity(saved);
     }
@@ -149,6 +152,7 @@ public class UserServiceImpl implements UserService {
         user.getRoles().clear();
         user.getRoles().add(normalizedRole);
         var saved = userRepository.save(user);
+        authSyncEvents.userUpserted(saved);
         userSyncService.syncUser(saved);
         return UserResponse.fromEntity(saved);
     }
@@ -275,6 +279,7 @@ public class UserServiceImpl implements UserService {
             User saved = userRepository.save(user);
             // Keep LMS/course and chat projections in sync immediately, so
             // participant lists can use the uploaded image without extra lookups.
+            authSyncEvents.userUpserted(saved);
             userSyncService.syncUser(saved);
             return url;
 
@@ -289,6 +294,7 @@ public class UserServiceImpl implements UserService {
         var user = findUserEntity(id);
         deleteOldPicture(user.getProfilePicture());
         userRepository.deleteById(id);
+        authSyncEvents.userDeleted(id);
         userSyncService.deleteUser(id)
                 .exceptionally(ex -> {
                     log.warn("Cross-service cleanup failed for deleted user {}: {}", id, ex.getMessage());
@@ -336,6 +342,7 @@ public class UserServiceImpl implements UserService {
                 });
 
         // Sync to LMS
+        authSyncEvents.userUpserted(saved);
         userSyncService.syncUser(saved)
                 .exceptionally(ex -> {
                     log.error("LMS sync failed for approved user {}: {}", user.getEmail(), ex.getMessage());
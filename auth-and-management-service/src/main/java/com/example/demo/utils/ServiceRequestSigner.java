@@ -0,0 +1,80 @@
+package com.example.demo.utils;
+
+import org.springframework.http.HttpHeaders;
+import org.springframework.http.HttpMethod;
+
+import javax.crypto.Mac;
+import javax.crypto.spec.SecretKeySpec;
+import java.net.URI;
+import java.nio.charset.StandardCharsets;
+import java.security.GeneralSecurityException;
+import java.security.MessageDigest;
+import java.security.SecureRandom;
+import java.time.Instant;
+import java.util.HexFormat;
+
+/**
+ * Signs internal requests for the Go services (LMS, Chat), which verify them
+ * with pkg/svcauth. The signature covers the method, path, query, a hash of
+ * the exact body bytes, a timestamp and a one-time nonce, so the body must be
+ * sent exactly as it was signed.
+ */
+public final class ServiceRequestSigner {
+
+    public static final String SERVICE_NAME = "auth-service";
+
+    private static final HexFormat HEX = HexFormat.of();
+    private static final ThreadLocal<SecureRandom> RANDOM =
+            ThreadLocal.withInitial(SecureRandom::new);
+
+    private ServiceRequestSigner() {}
+
+    /** Headers that authenticate one request to {@code url}; body may be null. */
+    public static HttpHeaders sign(HttpMethod method, String url, byte[] body, String secret) {
+        if (secret == null || secret.isBlank()) {
+            throw new IllegalStateException("No signing secret configured for " + url);
+        }
+        var uri = URI.create(url);
+        var nonceBytes = new byte[16];
+        RANDOM.get().nextBytes(nonceBytes);
+        var nonce = HEX.formatHex(nonceBytes);
+        var timestamp = Long.toString(Instant.now().getEpochSecond());
+
+        // Must match canonical() in pkg/svcauth
+        var canonical = String.join("\n",
+                "v1",
+                method.name(),
+                uri.getRawPath(),
+                uri.getRawQuery() != null ? uri.getRawQuery() : "",
+                HEX.formatHex(sha256(body != null ? body : new byte[0])),
+                timestamp,
+                nonce,
+                SERVICE_NAME,
+                "");
+
+        var headers = new HttpHeaders();
+        headers.set("X-Service-Name", SERVICE_NAME);
+        headers.set("X-Service-Timestamp", timestamp);
+        headers.set("X-Service-Nonce", nonce);
+        headers.set("X-Service-Signature", HEX.formatHex(hmac(secret, canonical)));
+        return headers;
+    }
+
+    static byte[] sha256(byte[] data) {
+        try {
+            return MessageDigest.getInstance("SHA-256").digest(data);
+        } catch (GeneralSecurityException e) {
+            throw new IllegalStateException(e);
+        }
+    }
+
+    static byte[] hmac(String secret, String message) {
+        try {
+            var mac = Mac.getInstance("HmacSHA256");
+            mac.init(new SecretKeySpec(secret.getBytes(StandardCharsets.UTF_8), "HmacSHA256"));
+            return mac.doFinal(message.getBytes(StandardCharsets.UTF_8));
+        } catch (GeneralSecurityException e) {
+            throw new IllegalStateException(e);
+        }
+    }
+}
@@ -0,0 +1,94 @@
+package com.example.demo.utils;
+
+import java.security.MessageDigest;
+import java.time.Duration;
+import java.time.Instant;
+import java.util.HexFormat;
+import java.util.Map;
+import java.util.concurrent.ConcurrentHashMap;
+
+/**
+ * Verifies requests signed by the Go services with pkg/svcauth, the
+ * counterpart of {@link ServiceRequestSigner}. Nonces are remembered in
+ * memory for twice the allowed clock skew, so a captured request cannot be
+ * replayed against this instance.
+ */
+public final class ServiceRequestVerifier {
+
+    public static final Duration MAX_SKEW = Duration.ofSeconds(60);
+
+    private static final HexFormat HEX = HexFormat.of();
+
+    private final Map<String, String> secrets;
+    private final Map<String, Instant> seenNonces = new ConcurrentHashMap<>();
+
+    /** @param secrets signing secret per calling service name */
+    public ServiceRequestVerifier(Map<String, String> secrets) {
+        this.secrets = Map.copyOf(secrets);
+    }
+
+    /** The signed parts of one request. Header values may be null. */
+    public record SignedRequest(
+            String method, String rawPath, String rawQuery, byte[] body,
+            String service, String timestamp, String nonce, String signature, String userId) {}
+
+    /**
+     * Returns the calling service's name, or throws
+     * {@link IllegalArgumentException} saying why the request is refused.
+     */
+    public String verify(SignedRequest req) {
+        if (isBlank(req.service()) || isBlank(req.timestamp()) || isBlank(req.nonce()) || isBlank(req.signature())) {
+            throw new IllegalArgumentException("missing service signature headers");
+        }
+        String secret = secrets.get(req.service());
+        if (isBlank(secret)) {
+            throw new IllegalArgumentException("unknown service " + req.service());
+        }
+
+        Instant signedAt;
+        try {
+            signedAt = Instant.ofEpochSecond(Long.parseLong(req.timestamp()));
+        } catch (NumberFormatException e) {
+            throw new IllegalArgumentException("invalid timestamp");
+        }
+        Instant now = Instant.now();
+        if (Duration.between(signedAt, now).abs().compareTo(MAX_SKEW) > 0) {
+            throw new IllegalArgumentException("timestamp outside the allowed skew");
+        }
+
+        // Must match canonical() in pkg/svcauth
+        String canonical = String.join("\n",
+                "v1",
+                req.method(),
+                req.rawPath(),
+                req.rawQuery() != null ? req.rawQuery() : "",
+                HEX.formatHex(ServiceRequestSigner.sha256(req.body() != null ? req.body() : new byte[0])),
+                req.timestamp(),
+                req.nonce(),
+                req.service(),
+                req.userId() != null ? req.userId() : "");
+        byte[] expected = ServiceRequestSigner.hmac(secret, canonical);
+        byte[] given;
+        try {
+            given = HEX.parseHex(req.signature());
+        } catch (IllegalArgumentException e) {
+            throw new IllegalArgumentException("invalid signature");
+        }
+        if (!MessageDigest.isEqual(expected, given)) {
+            throw new IllegalArgumentException("invalid signature");
+        }
+
+        // Claimed only after the signature checks out, so forged requests
+        // cannot fill the cache
+        seenNonces.values().removeIf(expiry -> expiry.isBefore(now));
+        String key = req.service() + ":" + req.nonce();
+        if (seenNonces.putIfAbsent(key, now.plus(MAX_SKEW.multipliedBy(2))) != null) {
+            throw new IllegalArgumentException("nonce already used");
+        }
+        return req.service();
+    }
+
+    private static boolean isBlank(String s) {
+        return s == null || s.isBlank();
+    }
+}
@@ -0,0 +1,25 @@
+package com.example.demo.utils;
+
+import java.time.Instant;
+import java.time.temporal.ChronoUnit;
+import java.util.concurrent.atomic.AtomicLong;
+
+/**
+ * Versions for the user and organization state published to other services.
+ * A version is the time of the change in microseconds, kept strictly
+ * increasing within this process, so a membership removed and added again
+ * still gets a newer version than its removal. Receivers keep the highest
+ * version per entity and ignore older ones.
+ */
+public final class SyncVersions {
+
+    private static final AtomicLong LAST = new AtomicLong();
+
+    private SyncVersions() {}
+
+    /** A version newer than every one handed out before in this process. */
+    public static long next() {
+        long now = ChronoUnit.MICROS.between(Instant.EPOCH, Instant.now());
+        return LAST.updateAndGet(prev -> Math.max(prev + 1, now));
+    }
+}
@@ -14,11 +14,16 @@ import (
 	"chat-service/internal/handler"
 	"chat-service/internal/middleware"
 	"chat-service/internal/repository"
+	"chat-service/internal/service"
 	"chat-service/pkg/cache"
 	"chat-service/pkg/database"
 	"chat-service/pkg/hub"
+	"chat-service/pkg/jwks"
+	"chat-service/pkg/kafka"
 	"chat-service/pkg/logger"
 	"chat-service/pkg/storage"
+	"chat-service/pkg/svcauth"
+	"chat-service/pkg/telemetry"
 
 	"github.com/gin-gonic/gin"
 )
@@ -37,6 +42,17 @@ func main() {
 	// Use all available CPU cores for goroutine scheduling
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
+	shutdownTracing, err := telemetry.InitTracing(context.Background(), telemetry.TracingConfig{
+		Enabled:     cfg.Telemetry.TracingEnabled,
+		ServiceName: "chat-service",
+		Environment: cfg.App.Env,
+		SampleRatio: cfg.Telemetry.SampleRatio,
+	})
+	if err != nil {
+		logger.Errorf("tracing init: %v", err)
+		os.Exit(1)
+	}
+
 	// ── 2. Database ───────────────────────────────────────────────────────────
 	db, err := database.NewPostgresDB(cfg.Database)
 	if err != nil {
@@ -44,6 +60,7 @@ func main() {
 		os.Exit(1)
 	}
 	defer db.Close()
+	telemetry.RegisterDBStats(db, cfg.Database.Name)
 
 	if err := database.RunMigrations(db, "./migrations"); err != nil {
 		logger.Errorf("migrations: %v", err)
@@ -57,15 +74,27 @@ func main() {
 		os.Exit(1)
 	}
 	defer rdb.Close()
+	telemetry.RegisterRedisPool("chat", rdb.PoolStats)
 
 	// ── 4. WebSocket Hub (Redis Pub/Sub backed) ───────────────────────────────
 	wsHub := hub.New(rdb)
-	go wsHub.Run()
+	wsHub.RegisterMetrics()
 
 	// ── 5. Repositories ───────────────────────────────────────────────────────
 	userRepo := repository.NewUserRepository(db)
 	chatRepo := repository.NewChatRepository(db)
 
+	// Membership changes re-check access with the same rules as REST, so the
+	// hub starts once the repository exists
+	wsHub.SetAccessFunc(func(ctx context.Context, channelID int64, userIDs []int64) (hub.ReadAccess, error) {
+		access, err := chatRepo.GetChannelReadAccess(ctx, channelID, userIDs)
+		if err != nil {
+			return nil, err
+		}
+		return access, nil
+	})
+	go wsHub.Run()
+
 	// Seed default "general" channel if none exist
 	if seeded, err := chatRepo.SeedDefaultChannel(context.Background()); err != nil {
 		logger.Warnf("seed default channel: %v", err)
@@ -73,23 +102,77 @@ func main() {
 		logger.Info("Seeded default 'general' channel on startup")
 	}
 
-	// ── 6. Handlers ───────────────────────────────────────────────────────────
+	// ── 6. Token and service verification ─────────────────────────────────────
+	var jwksKeys *jwks.KeySet
+	if cfg.JWT.JWKSURL != "" {
+		jwksKeys = jwks.NewKeySet(cfg.JWT.JWKSURL, jwks.Options{RefreshInterval: cfg.JWT.JWKSRefreshInterval})
+		if err := jwksKeys.Refresh(context.Background()); err != nil {
+			logger.Warnf("initial JWKS fetch failed, retrying on demand: %v", err)
+		}
+		go jwksKeys.Run(context.Background())
+	}
+	hmacSecret := ""
+	if cfg.JWT.HMACEnabled {
+		hmacSecret = cfg.JWT.Secret
+	}
+	tokenVerifier := jwks.NewVerifier(jwksKeys, hmacSecret, cfg.JWT.HMACUntil)
+
+	// Signed service-to-service calls; nonces are claimed in Redis so a
+	// captured request cannot be replayed
+	serviceClients := make([]svcauth.Client, 0, len(cfg.Sync.Clients))
+	for _, client := range cfg.Sync.Clients {
+		serviceClients = append(serviceClients, svcauth.Client{Name: client.Name, Secret: client.Secret, Scopes: client.Scopes})
+	}
+	serviceAuth := svcauth.NewVerifier(serviceClients, svcauth.RedisNonces(rdb), svcauth.Options{
+		MaxSkew:     cfg.Sync.MaxSkew,
+		AllowLegacy: cfg.Sync.AllowLegacy,
+	})
+	if cfg.Sync.AllowLegacy {
+		logger.Warnf("legacy static sync secret is still accepted (SERVICE_AUTH_ALLOW_LEGACY=true)")
+	}
+
+	// ── 7. Auth and course sync consumers ─────────────────────────────────────
+	// User lifecycle events from auth-service; /sync stays for callers that
+	// still push
+	userSyncService := service.NewUserSyncService(userRepo, chatRepo, repository.NewSyncVersionRepository(db))
+	consumerCtx, stopConsumers := context.WithCancel(context.Background())
+	defer stopConsumers()
+	if cfg.AuthSync.ConsumerEnabled {
+		go kafka.StartUserEventConsumer(consumerCtx, cfg.AuthSync.KafkaBrokers, userSyncService.ApplyUserEvent)
+	}
+
+	// Course channels follow LMS publish, enrollment and co-teacher changes
+	courseChannelService := service.NewCourseChannelService(userRepo, chatRepo, wsHub)
+	if cfg.CourseSync.ConsumerEnabled {
+		go kafka.StartCourseEventConsumer(consumerCtx, cfg.AuthSync.KafkaBrokers, courseChannelService.ApplyCourseEvent)
+	}
+
+	// ── 8. Handlers ───────────────────────────────────────────────────────────
 	syncHandler := handler.NewSyncHandler(userRepo, chatRepo)
 	attachmentStore, storageErr := storage.NewObjectStore(cfg.Storage)
 	if storageErr != nil {
 		// Chat text/realtime must remain available if object storage is degraded.
 		logger.Warnf("attachment storage disabled: %v", storageErr)
 	}
-	chatHandler := handler.NewChatHandler(chatRepo, userRepo, wsHub, attachmentStore, cfg.JWT.Secret)
-	adminHandler := handler.NewAdminHandler(chatRepo, userRepo)
+	chatHandler := handler.NewChatHandler(chatRepo, userRepo, wsHub, attachmentStore, tokenVerifier)
+	retentionService := service.NewRetentionService(chatRepo, attachmentStore, service.RetentionOptions{
+		PurgeDeletedAfter: cfg.Retention.PurgeDeletedAfter,
+		OrphanGrace:       cfg.Retention.OrphanGrace,
+		BatchSize:         cfg.Retention.BatchSize,
+	})
+	if cfg.Retention.Enabled {
+		go retentionService.Run(consumerCtx, cfg.Retention.Interval)
+	}
+	adminHandler := handler.NewAdminHandler(chatRepo, userRepo, wsHub, attachmentStore, retentionService)
 
-	// ── 7. Router ─────────────────────────────────────────────────────────────
+	// ── 9. Router ─────────────────────────────────────────────────────────────
 	if cfg.App.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	r := gin.New()
 	r.Use(gin.Recovery())
+	r.Use(telemetry.Middleware("/health"))
 	r.Use(middleware.Logger())
 	r.Use(middleware.CORS(cfg.CORS.AllowedOrigins))
 
@@ -104,8 +187,8 @@ func main() {
 	r.GET("/health", healthHandler)
 	r.HEAD("/health", healthHandler)
 
-	// ── Sync routes (auth-service -> chat-service, secret-protected) ──────────
-	sync := r.Group("/api/v1/sync", middleware.SyncSecret(cfg.Sync.Secret))
+	// ── Sync routes (auth-service -> chat-service, signed requests) ───────────
+	sync := r.Group("/api/v1/sync", middleware.ServiceAuth(serviceAuth, middleware.ScopeSync))
 	{
 		sync.POST("/user", syncHandler.SyncUser)
 		sync.POST("/users/bulk", syncHandler.BulkSyncUsers)
@@ -117,7 +200,7 @@ func main() {
 	r.GET("/api/v1/chat/ws", chatHandler.ServeWS)
 
 	// ── Chat REST (JWT auth required) ─────────────────────────────────────────
-	auth := r.Group("/api/v1", middleware.Auth(cfg.JWT.Secret))
+	auth := r.Group("/api/v1", middleware.Auth(tokenVerifier))
 	{
 		chat := auth.Group("/chat")
 		{
@@ -125,11 +208,31 @@ func main() {
 			chat.GET("/channels/:id/messages", chatHandler.ListMessages)
 			chat.GET("/channels/:id/members/search", chatHandler.SearchChannelMembers)
 			chat.GET("/channels/:id/presence", chatHandler.GetChannelPresence)
+			chat.POST("/channels/:id/read", chatHandler.MarkRead)
+			chat.GET("/channels/:id/reads", chatHandler.ListReadReceipts)
+			chat.PUT("/channels/:id/mute", chatHandler.MuteChannel)
+			chat.DELETE("/channels/:id/mute", chatHandler.UnmuteChannel)
 			chat.POST("/channels/:id/messages", chatHandler.SendMessage)
 			chat.POST("/channels/:id/attachments", chatHandler.UploadAttachment)
 			chat.GET("/attachments/:attachmentId", chatHandler.DownloadAttachment)
 			chat.PUT("/channels/:id/messages/:msgId", chatHandler.EditMessage)
 			chat.DELETE("/channels/:id/messages/:msgId", chatHandler.DeleteMessage)
+			chat.GET("/channels/:id/messages/:msgId/context", chatHandler.GetMessageContext)
+			chat.POST("/channels/:id/messages/:msgId/report", chatHandler.ReportMessage)
+			chat.POST("/channels/:id/messages/:msgId/reactions", chatHandler.ToggleReaction)
+			chat.GET("/channels/:id/pins", chatHandler.ListPins)
+			chat.PUT("/channels/:id/messages/:msgId/pin", chatHandler.PinMessage)
+			chat.DELETE("/channels/:id/messages/:msgId/pin", chatHandler.UnpinMessage)
+			chat.PUT("/channels/:id/messages/:msgId/save", chatHandler.SaveMessage)
+			chat.DELETE("/channels/:id/messages/:msgId/save", chatHandler.UnsaveMessage)
+			chat.GET("/saved", chatHandler.ListSavedMessages)
+			chat.GET("/search", chatHandler.SearchMessages)
+			chat.GET("/mentions", chatHandler.ListMentions)
+			chat.GET("/channels/:id/threads/:msgId", chatHandler.GetThread)
+			chat.PUT("/channels/:id/threads/:msgId/follow", chatHandler.FollowThread)
+			chat.DELETE("/channels/:id/threads/:msgId/follow", chatHandler.UnfollowThread)
+			chat.POST("/channels/:id/threads/:msgId/read", chatHandler.MarkThreadRead)
+			chat.GET("/threads", chatHandler.ListFollowedThreads)
 			chat.GET("/users/search", chatHandler.SearchUsers)
 			chat.POST("/dm", chatHandler.GetOrCreateDM)
 		}
@@ -147,11 +250,32 @@ func main() {
 				adminChannels.PUT("/:id/roles", adminHandler.SetChannelRoles)
 				adminChannels.GET("/:id/users", adminHandler.GetChannelUsers)
 				adminChannels.PUT("/:id/users", adminHandler.SetChannelUsers)
+				adminChannels.PUT("/:id/slow-mode", adminHandler.SetSlowMode)
+				adminChannels.DELETE("/:id/messages/:msgId", adminHandler.ModeratorDeleteMessage)
+				adminChannels.GET("/:id/retention", adminHandler.GetRetention)
+				adminChannels.PUT("/:id/retention", adminHandler.SetRetention)
+				adminChannels.PUT("/:id/legal-hold", adminHandler.SetLegalHold)
+				adminChannels.GET("/:id/export", adminHandler.ExportChannel)
+			}
+
+			adminSanctions := admin.Group("/sanctions")
+			{
+				adminSanctions.GET("", adminHandler.ListSanctions)
+				adminSanctions.POST("", adminHandler.CreateSanction)
+				adminSanctions.DELETE("/:sanctionId", adminHandler.RevokeSanction)
+			}
+
+			adminReports := admin.Group("/reports")
+			{
+				adminReports.GET("", adminHandler.ListReports)
+				adminReports.POST("/:reportId/resolve", adminHandler.ResolveReport)
 			}
+
+			admin.POST("/retention/run", adminHandler.RunRetention)
 		}
 	}
 
-	// ── 8. HTTP Server with graceful shutdown ─────────────────────────────────
+	// ── 10. HTTP Server with graceful shutdown ────────────────────────────────
 	srv := &http.Server{
 		Addr:    ":" + cfg.App.Port,
 		Handler: r,
@@ -169,7 +293,16 @@ func main() {
 		}
 	}()
 
-	// ── 9. Graceful shutdown ──────────────────────────────────────────────────
+	// Prometheus scrapes its own port, which the ingress does not route to
+	metricsSrv := telemetry.NewMetricsServer(":" + cfg.Telemetry.MetricsPort)
+	go func() {
+		logger.Infof("Serving metrics on :%s", cfg.Telemetry.MetricsPort)
+		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("Metrics server: %v", err)
+		}
+	}()
+
+	// ── 11. Graceful shutdown ─────────────────────────────────────────────────
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
@@ -187,9 +320,16 @@ func main() {
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		logger.Errorf("Graceful shutdown failed: %v", err)
 	}
+	_ = metricsSrv.Shutdown(shutdownCtx)
+
+	stopConsumers()
 
 	// Shutdown WebSocket hub (closes all client connections)
 	wsHub.Shutdown()
 
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Errorf("flush traces: %v", err)
+	}
+
 	logger.Info("Chat service stopped cleanly")
 }
@@ -0,0 +1,65 @@
+// Command reconcile repairs user profiles that drifted from the auth
+// service, e.g. because lifecycle events were lost. It pages through the
+// auth service's snapshot API and applies every user whose version is not
+// older than the one chat-service holds.
+//
+// Usage:
+//
+//	go run ./cmd/reconcile [-page-size 500]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"chat-service/internal/config"
+	"chat-service/internal/repository"
+	"chat-service/internal/service"
+	"chat-service/pkg/authsync"
+	"chat-service/pkg/database"
+	"chat-service/pkg/logger"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config: %v\n", err)
+		os.Exit(1)
+	}
+
+	pageSize := flag.Int("page-size", cfg.AuthSync.PageSize, "users fetched per snapshot page")
+	flag.Parse()
+
+	logger.SetLevel(cfg.App.LogLevel)
+
+	db, err := database.NewPostgresDB(cfg.Database)
+	if err != nil {
+		logger.Errorf("database init: %v", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	syncService := service.NewUserSyncService(
+		repository.NewUserRepository(db),
+		repository.NewChatRepository(db),
+		repository.NewSyncVersionRepository(db),
+	)
+	client := authsync.NewClient(cfg.AuthSync.ServiceURL, cfg.AuthSync.ServiceName, cfg.AuthSync.SigningSecret)
+
+	report, err := syncService.Reconcile(ctx, client, *pageSize)
+	fmt.Printf("users: %d seen, %d applied, %d failed\n", report.UsersSeen, report.UsersApplied, report.Failed)
+	if err != nil {
+		logger.Errorf("reconcile aborted: %v", err)
+		os.Exit(1)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -20,6 +20,10 @@ type Config struct {
 	CORS     CORSConfig
 	Server   ServerConfig
 	Sync     SyncConfig
+	AuthSync AuthSyncConfig
+	CourseSync CourseSyncConfig
+	Retention RetentionConfig
+	Telemetry TelemetryConfig
 }
 
 // StorageConfig holds the S3-compatible object storage used for private chat
@@ -68,8 +72,15 @@ type RedisConfig struct {
 	PoolTimeout  time.Duration
 }
 
+// JWTConfig selects how access tokens are verified: RS256/ES256 against the
+// auth service's JWKS when JWKSURL is set, and HMAC with the shared Secret
+// while HMACEnabled and before HMACUntil (zero = no cutoff).
 type JWTConfig struct {
-	Secret string
+	Secret              string
+	JWKSURL             string
+	JWKSRefreshInterval time.Duration
+	HMACEnabled         bool
+	HMACUntil           time.Time
 }
 
 type CORSConfig struct {
@@ -85,9 +96,65 @@ type ServerConfig struct {
 	MaxConnectionsPerIP int
 }
 
-// SyncConfig holds the shared secret used by auth-service to push user data.
+// SyncConfig lists the services allowed to call /sync/*. Each client signs
+// requests with its own secret; AllowLegacy re-admits the static
+// X-Sync-Secret header for a caller that cannot sign yet and is off unless
+// set. Without SERVICE_AUTH_CLIENTS, auth-service signs with CHAT_SYNC_SECRET.
 type SyncConfig struct {
+	Secret      string
+	Clients     []ServiceClientConfig
+	MaxSkew     time.Duration
+	AllowLegacy bool
+}
+
+// AuthSyncConfig controls how user profiles are kept in step with the auth
+// service: lifecycle events from Kafka, plus a snapshot API that
+// cmd/reconcile pages through to repair drift. Snapshot requests are signed
+// as ServiceName with SigningSecret (CHAT_SYNC_SECRET by default).
+type AuthSyncConfig struct {
+	ConsumerEnabled bool
+	KafkaBrokers    []string
+	ServiceURL      string
+	ServiceName     string
+	SigningSecret   string
+	PageSize        int
+}
+
+// CourseSyncConfig controls the consumer of lms.course.membership, which
+// keeps course channels in step with LMS courses. It shares the auth sync
+// brokers.
+type CourseSyncConfig struct {
+	ConsumerEnabled bool
+}
+
+// RetentionConfig controls the background retention run. Each run applies
+// channel retention policies, purges the content of messages soft-deleted
+// more than PurgeDeletedAfter ago, and removes stored objects older than
+// OrphanGrace that no attachment refers to. Replicas take turns through a
+// database lock.
+type RetentionConfig struct {
+	Enabled           bool
+	Interval          time.Duration
+	PurgeDeletedAfter time.Duration
+	OrphanGrace       time.Duration
+	BatchSize         int
+}
+
+// TelemetryConfig controls trace export and the metrics listener. The
+// collector endpoint is read by the exporter from the standard
+// OTEL_EXPORTER_OTLP_ENDPOINT variable. MetricsPort serves /metrics apart
+// from the API and must stay cluster-internal.
+type TelemetryConfig struct {
+	TracingEnabled bool
+	SampleRatio    float64
+	MetricsPort    string
+}
+
+// ServiceClientConfig is one calling service
+type ServiceClientConfig struct {
+	Name   string
 	Secret string
+	Scopes []string
 }
 
 // Load reads configuration from environment (with .env fallback in dev).
@@ -137,7 +204,10 @@ func Load() (*Config, error) {
 			UseSSL: getEnv("CHAT_STORAGE_USE_SSL", getEnv("MINIO_USE_SSL", "false")) == "true",
 		},
 		JWT: JWTConfig{
-			Secret: requireEnv("JWT_SECRET"),
+			Secret:              getEnv("JWT_SECRET", ""),
+			JWKSURL:             getEnv("JWT_JWKS_URL", ""),
+			JWKSRefreshInterval: getEnvDuration("JWT_JWKS_REFRESH_INTERVAL", 15*time.Minute),
+			HMACEnabled:         getEnv("JWT_HMAC_ENABLED", "true") == "true",
 		},
 		CORS: CORSConfig{
 			AllowedOrigins: strings.Split(getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000"), ","),
@@ -150,10 +220,77 @@ func Load() (*Config, error) {
 			MaxConnectionsPerIP: getEnvInt("MAX_CONNECTIONS_PER_IP", 20),
 		},
 		Sync: SyncConfig{
-			Secret: requireEnv("CHAT_SYNC_SECRET"),
+			Secret:      requireEnv("CHAT_SYNC_SECRET"),
+			MaxSkew:     getEnvDuration("SERVICE_AUTH_MAX_SKEW", 5*time.Minute),
+			AllowLegacy: getEnv("SERVICE_AUTH_ALLOW_LEGACY", "false") == "true",
 		},
 	}
 
+	cfg.AuthSync = AuthSyncConfig{
+		ConsumerEnabled: getEnv("AUTH_SYNC_CONSUMER_ENABLED", "true") == "true",
+		KafkaBrokers:    strings.Split(getEnv("KAFKA_BROKERS", "localhost:9092"), ","),
+		ServiceURL:      getEnv("AUTH_SERVICE_URL", "http://localhost:8080"),
+		ServiceName:     getEnv("AUTH_SYNC_SERVICE_NAME", "chat-service"),
+		SigningSecret:   getEnv("AUTH_SYNC_SIGNING_SECRET", cfg.Sync.Secret),
+		PageSize:        getEnvInt("AUTH_SYNC_PAGE_SIZE", 500),
+	}
+
+	cfg.CourseSync = CourseSyncConfig{
+		ConsumerEnabled: getEnv("COURSE_SYNC_CONSUMER_ENABLED", "true") == "true",
+	}
+
+	cfg.Retention = RetentionConfig{
+		Enabled:           getEnv("CHAT_RETENTION_ENABLED", "true") == "true",
+		Interval:          getEnvDuration("CHAT_RETENTION_INTERVAL", time.Hour),
+		PurgeDeletedAfter: time.Duration(getEnvInt("CHAT_RETENTION_PURGE_DELETED_DAYS", 30)) * 24 * time.Hour,
+		OrphanGrace:       getEnvDuration("CHAT_RETENTION_ORPHAN_GRACE", 24*time.Hour),
+		BatchSize:         getEnvInt("CHAT_RETENTION_BATCH_SIZE", 500),
+	}
+
+	cfg.Telemetry = TelemetryConfig{
+		TracingEnabled: getEnv("OTEL_TRACING_ENABLED", "false") == "true",
+		SampleRatio:    0.1,
+		MetricsPort:    getEnv("METRICS_PORT", "9464"),
+	}
+	if v := os.Getenv("OTEL_TRACES_SAMPLE_RATIO"); v != "" {
+		ratio, err := strconv.ParseFloat(v, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("OTEL_TRACES_SAMPLE_RATIO must be between 0 and 1")
+		}
+		cfg.Telemetry.SampleRatio = ratio
+	}
+
+	cfg.Sync.Clients = []ServiceClientConfig{{Name: "auth-service", Secret: cfg.Sync.Secret, Scopes: []string{"sync"}}}
+	if names := getEnv("SERVICE_AUTH_CLIENTS", ""); names != "" {
+		cfg.Sync.Clients = nil
+		for _, name := range strings.Split(names, ",") {
+			name = strings.TrimSpace(name)
+			prefix := "SERVICE_AUTH_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+			client := ServiceClientConfig{Name: name, Secret: getEnv(prefix+"_SECRET", "")}
+			if client.Secret == "" {
+				return nil, fmt.Errorf("service client %q has no secret", name)
+			}
+			if scopes := getEnv(prefix+"_SCOPES", ""); scopes != "" {
+				client.Scopes = strings.Split(scopes, ",")
+			}
+			cfg.Sync.Clients = append(cfg.Sync.Clients, client)
+		}
+	}
+
+	if v := os.Getenv("JWT_HMAC_UNTIL"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_HMAC_UNTIL must be an RFC 3339 timestamp: %w", err)
+		}
+		cfg.JWT.HMACUntil = t
+	}
+	if cfg.JWT.HMACEnabled && cfg.JWT.Secret == "" {
+		return nil, fmt.Errorf("required environment variable %q is not set", "JWT_SECRET")
+	}
+	if !cfg.JWT.HMACEnabled && cfg.JWT.JWKSURL == "" {
+		return nil, fmt.Errorf("JWT_JWKS_URL is required when JWT_HMAC_ENABLED=false")
+	}
+
 	return cfg, nil
 }
 
@@ -37,15 +37,24 @@ type CreateDMRequest struct {
 	UserID int64 `json:"user_id" binding:"required"`
 }
 
+// ChannelResponse carries the caller's read state when listed through
+// GET /chat/channels; a missing count means zero.
 type ChannelResponse struct {
-	ID          int64         `json:"id"`
-	Slug        string        `json:"slug"`
-	Name        string        `json:"name"`
-	Description string        `json:"description"`
-	IsPrivate   bool          `json:"is_private"`
-	IsDM        bool          `json:"is_dm"`
-	DMUser      *UserResponse `json:"dm_user,omitempty"`
-	CreatedAt   time.Time     `json:"created_at"`
+	ID                int64         `json:"id"`
+	Slug              string        `json:"slug"`
+	Name              string        `json:"name"`
+	Description       string        `json:"description"`
+	IsPrivate         bool          `json:"is_private"`
+	IsDM              bool          `json:"is_dm"`
+	DMUser            *UserResponse `json:"dm_user,omitempty"`
+	UnreadCount       int           `json:"unread_count,omitempty"`
+	MentionCount      int           `json:"mention_count,omitempty"`
+	LastReadMessageID int64         `json:"last_read_message_id,omitempty"`
+	Muted             bool          `json:"muted,omitempty"`
+	SlowModeSeconds   int           `json:"slow_mode_seconds,omitempty"`
+	CourseID          *int64        `json:"course_id,omitempty"`   // set on an LMS course's channel
+	ArchivedAt        *time.Time    `json:"archived_at,omitempty"` // archived channels are read-only
+	CreatedAt         time.Time     `json:"created_at"`
 }
 
 // ─── Channel Role/User Access ─────────────────────────────────────────────────
@@ -72,9 +81,12 @@ type ChannelUsersResponse struct {
 
 // ─── Messages ─────────────────────────────────────────────────────────────────
 
+// SendMessageRequest may carry a client-generated ClientMsgID; resending the
+// same key returns the stored message instead of posting it twice.
 type SendMessageRequest struct {
-	Body     string `json:"body"      binding:"required,min=1,max=4000"`
-	ParentID *int64 `json:"parent_id"` // nil = top-level, non-nil = reply
+	Body        string `json:"body"          binding:"required,min=1,max=4000"`
+	ParentID    *int64 `json:"parent_id"` // nil = top-level, non-nil = reply
+	ClientMsgID string `json:"client_msg_id" binding:"omitempty,max=64"`
 }
 
 type EditMessageRequest struct {
@@ -92,9 +104,15 @@ type MessageResponse struct {
 	IsDeleted        bool                 `json:"is_deleted"`
 	IsEdited         bool                 `json:"is_edited"`
 	ParentID         *int64               `json:"parent_id,omitempty"`
+	ThreadRootID     *int64               `json:"thread_root_id,omitempty"`
 	ParentSenderName string               `json:"parent_sender_name,omitempty"`
 	ParentBody       string               `json:"parent_body,omitempty"`
 	Attachments      []AttachmentResponse `json:"attachments,omitempty"`
+	Reactions        []ReactionResponse   `json:"reactions,omitempty"`
+	IsPinned         bool                 `json:"is_pinned,omitempty"`
+	IsSaved          bool                 `json:"is_saved,omitempty"`
+	ReplyCount       int                  `json:"reply_count,omitempty"`
+	LastReplyAt      *time.Time           `json:"last_reply_at,omitempty"`
 	CreatedAt        time.Time            `json:"created_at"`
 }
 
@@ -114,6 +132,304 @@ type MessageListResponse struct {
 	HasMore    bool              `json:"has_more"`
 }
 
+// ─── Reactions, Pins and Saved Messages ───────────────────────────────────────
+
+// ReactionResponse aggregates one emoji on a message. ReactedByMe is
+// relative to the caller.
+type ReactionResponse struct {
+	Emoji       string `json:"emoji"`
+	Count       int    `json:"count"`
+	ReactedByMe bool   `json:"reacted_by_me"`
+}
+
+// ToggleReactionRequest adds the caller's emoji, or removes it when present.
+type ToggleReactionRequest struct {
+	Emoji string `json:"emoji" binding:"required,max=64"`
+}
+
+type ToggleReactionResponse struct {
+	Emoji string `json:"emoji"`
+	Added bool   `json:"added"`
+	Count int    `json:"count"`
+}
+
+type PinnedMessageResponse struct {
+	MessageResponse
+	PinnedBy int64     `json:"pinned_by"`
+	PinnedAt time.Time `json:"pinned_at"`
+}
+
+type SavedMessageResponse struct {
+	MessageResponse
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// SavedMessageListResponse pages through saved messages; pass NextCursor as
+// ?before= to load older ones.
+type SavedMessageListResponse struct {
+	Messages   []SavedMessageResponse `json:"messages"`
+	NextCursor *time.Time             `json:"next_cursor,omitempty"`
+	HasMore    bool                   `json:"has_more"`
+}
+
+// ─── Threads ──────────────────────────────────────────────────────────────────
+
+// ThreadResponse is a thread root with one page of replies. Pass NextCursor
+// as ?after_id= to load the next page.
+type ThreadResponse struct {
+	Root            MessageResponse   `json:"root"`
+	Replies         []MessageResponse `json:"replies"`
+	Participants    []UserResponse    `json:"participants"`
+	ReplyCount      int               `json:"reply_count"`
+	Following       bool              `json:"following"`
+	LastReadReplyID int64             `json:"last_read_reply_id"`
+	NextCursor      int64             `json:"next_cursor"` // 0 = no more pages
+	HasMore         bool              `json:"has_more"`
+}
+
+// FollowedThreadResponse is a followed thread with its unread replies.
+type FollowedThreadResponse struct {
+	Root        MessageResponse `json:"root"`
+	ReplyCount  int             `json:"reply_count"`
+	UnreadCount int             `json:"unread_count"`
+	LastReplyAt time.Time       `json:"last_reply_at"`
+}
+
+// ─── Mentions ─────────────────────────────────────────────────────────────────
+
+// MentionResponse is a message that mentioned the caller. Kind is "user",
+// "channel" or "here".
+type MentionResponse struct {
+	MessageResponse
+	Kind string `json:"mention_kind"`
+}
+
+// MentionListResponse pages through mentions newest first; pass NextCursor
+// as ?before_id= to load older ones.
+type MentionListResponse struct {
+	Mentions   []MentionResponse `json:"mentions"`
+	NextCursor int64             `json:"next_cursor"` // 0 = no more pages
+	HasMore    bool              `json:"has_more"`
+}
+
+// ─── Moderation ───────────────────────────────────────────────────────────────
+
+type ReportMessageRequest struct {
+	Reason string `json:"reason" binding:"required,min=1,max=500"`
+}
+
+// SetSlowModeRequest sets the seconds between a writer's messages; 0 turns
+// slow mode off.
+type SetSlowModeRequest struct {
+	Seconds int `json:"seconds" binding:"min=0,max=21600"`
+}
+
+// CreateSanctionRequest mutes or bans a user in ChannelID, or in every
+// channel when it is omitted. DurationMinutes 0 means until revoked.
+type CreateSanctionRequest struct {
+	UserID          int64  `json:"user_id"          binding:"required"`
+	ChannelID       int64  `json:"channel_id"       binding:"omitempty,min=1"`
+	Kind            string `json:"kind"             binding:"required,oneof=mute ban"`
+	DurationMinutes int    `json:"duration_minutes" binding:"min=0,max=525600"`
+	Reason          string `json:"reason"           binding:"max=500"`
+}
+
+type SanctionResponse struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	ChannelID *int64     `json:"channel_id"` // null = every channel
+	Kind      string     `json:"kind"`
+	Reason    string     `json:"reason"`
+	CreatedBy int64      `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"` // null = until revoked
+}
+
+// ModeratorDeleteRequest records why a moderator removed a message.
+type ModeratorDeleteRequest struct {
+	Reason string `json:"reason" binding:"required,min=1,max=500"`
+}
+
+// ResolveReportRequest closes a report and every other open report of the
+// same message. DeleteMessage also removes the message, with Note as the
+// recorded reason.
+type ResolveReportRequest struct {
+	Status        string `json:"status"         binding:"required,oneof=dismissed actioned"`
+	Note          string `json:"note"           binding:"max=500"`
+	DeleteMessage bool   `json:"delete_message"`
+}
+
+// ReportResponse shows the reported message as sent, even when it has since
+// been deleted.
+type ReportResponse struct {
+	ID           int64      `json:"id"`
+	MessageID    int64      `json:"message_id"`
+	ChannelID    int64      `json:"channel_id"`
+	ReporterID   int64      `json:"reporter_id"`
+	ReporterName string     `json:"reporter_name"`
+	Reason       string     `json:"reason"`
+	Status       string     `json:"status"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ReviewedBy   *int64     `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote   string     `json:"review_note,omitempty"`
+	SenderID     int64      `json:"sender_id"`
+	SenderName   string     `json:"sender_name"`
+	Body         string     `json:"body"`
+	IsDeleted    bool       `json:"is_deleted"`
+	ReportCount  int        `json:"report_count"`
+}
+
+// ReportListResponse pages through the review queue newest first; pass
+// NextCursor as ?before_id= to load older reports.
+type ReportListResponse struct {
+	Reports    []ReportResponse `json:"reports"`
+	NextCursor int64            `json:"next_cursor"` // 0 = no more pages
+	HasMore    bool             `json:"has_more"`
+}
+
+// ─── Retention ────────────────────────────────────────────────────────────────
+
+// SetRetentionRequest sets how many days a channel keeps its messages; 0
+// keeps them forever. Action defaults to delete, which removes expired
+// messages and their attachments. Anonymize removes only the sender: the
+// message is reassigned to the "Anonymous" user and loses its reactions and
+// mentions, but its body, attachments and replies are kept as they are, so
+// text that names someone still names them.
+type SetRetentionRequest struct {
+	RetentionDays int    `json:"retention_days" binding:"min=0,max=36500"`
+	Action        string `json:"action"         binding:"omitempty,oneof=delete anonymize"`
+}
+
+// SetLegalHoldRequest places or lifts a legal hold; a reason is required
+// to place one.
+type SetLegalHoldRequest struct {
+	Hold   bool   `json:"hold"`
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type RetentionPolicyResponse struct {
+	ChannelID       int64      `json:"channel_id"`
+	RetentionDays   int        `json:"retention_days"` // 0 = keep forever
+	Action          string     `json:"action"`
+	LegalHold       bool       `json:"legal_hold"`
+	LegalHoldReason string     `json:"legal_hold_reason,omitempty"`
+	LegalHoldBy     *int64     `json:"legal_hold_by,omitempty"`
+	LegalHoldAt     *time.Time `json:"legal_hold_at,omitempty"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty"` // null = never configured
+}
+
+// RetentionRunResponse reports a retention run started from the admin API.
+// Skipped means another replica was already running one.
+type RetentionRunResponse struct {
+	Skipped        bool `json:"skipped"`
+	Channels       int  `json:"channels"`
+	Deleted        int  `json:"deleted"`
+	Anonymized     int  `json:"anonymized"`
+	Purged         int  `json:"purged"`
+	ObjectsDeleted int  `json:"objects_deleted"`
+	OrphansDeleted int  `json:"orphans_deleted"`
+	Failed         int  `json:"failed"`
+}
+
+// ChannelExportManifest is manifest.json in a channel export, written last;
+// an archive without it did not finish.
+type ChannelExportManifest struct {
+	ChannelID          int64                    `json:"channel_id"`
+	Slug               string                   `json:"slug"`
+	Name               string                   `json:"name"`
+	IsPrivate          bool                     `json:"is_private"`
+	IsDM               bool                     `json:"is_dm"`
+	CourseID           *int64                   `json:"course_id,omitempty"`
+	ExportedAt         time.Time                `json:"exported_at"`
+	ExportedBy         int64                    `json:"exported_by"`
+	ThroughMessageID   int64                    `json:"through_message_id"` // later messages are not included
+	MessageCount       int                      `json:"message_count"`
+	AttachmentCount    int                      `json:"attachment_count"`
+	MissingAttachments []string                 `json:"missing_attachments"` // listed in messages.json but not in the archive
+	RetentionPolicy    *RetentionPolicyResponse `json:"retention_policy,omitempty"`
+}
+
+// ExportMessage is one message in messages.json, as stored: deleted
+// messages keep whatever body has not been purged.
+type ExportMessage struct {
+	ID            int64              `json:"id"`
+	SenderID      int64              `json:"sender_id"`
+	SenderName    string             `json:"sender_name"`
+	SenderEmail   string             `json:"sender_email"`
+	Body          string             `json:"body"`
+	IsEdited      bool               `json:"is_edited"`
+	IsDeleted     bool               `json:"is_deleted"`
+	DeletedBy     *int64             `json:"deleted_by,omitempty"`
+	DeletedReason string             `json:"deleted_reason,omitempty"`
+	DeletedAt     *time.Time         `json:"deleted_at,omitempty"`
+	ParentID      *int64             `json:"parent_id,omitempty"`
+	ThreadRootID  *int64             `json:"thread_root_id,omitempty"`
+	Attachments   []ExportAttachment `json:"attachments,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
+
+// ExportAttachment is an attachment in messages.json; Path is its file
+// inside the archive.
+type ExportAttachment struct {
+	ID        string    `json:"id"`
+	FileName  string    `json:"file_name"`
+	MimeType  string    `json:"mime_type"`
+	SizeBytes int64     `json:"size_bytes"`
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ─── Search ───────────────────────────────────────────────────────────────────
+
+// SearchResultResponse is a matching message. Snippet is HTML-escaped body
+// text with the matched terms wrapped in <mark>.
+type SearchResultResponse struct {
+	MessageResponse
+	ChannelSlug string `json:"channel_slug"`
+	ChannelName string `json:"channel_name"`
+	Snippet     string `json:"snippet"`
+}
+
+// SearchResponse pages through results newest first; pass NextCursor as
+// ?before_id= to load older ones.
+type SearchResponse struct {
+	Results    []SearchResultResponse `json:"results"`
+	NextCursor int64                  `json:"next_cursor"` // 0 = no more pages
+	HasMore    bool                   `json:"has_more"`
+}
+
+// MessageContextResponse is a window of a channel's timeline around
+// TargetID, oldest first. BeforeCursor continues upward through
+// ListMessages (?before_id=) and AfterCursor downward (?after_id=).
+type MessageContextResponse struct {
+	Messages      []MessageResponse `json:"messages"`
+	TargetID      int64             `json:"target_id"`
+	BeforeCursor  int64             `json:"before_cursor"` // 0 = start of channel
+	AfterCursor   int64             `json:"after_cursor"`  // 0 = end of channel
+	HasMoreBefore bool              `json:"has_more_before"`
+	HasMoreAfter  bool              `json:"has_more_after"`
+}
+
+// ─── Read State ───────────────────────────────────────────────────────────────
+
+// MarkReadRequest advances the read pointer to MessageID, or to the newest
+// message in the channel when it is omitted.
+type MarkReadRequest struct {
+	MessageID int64 `json:"message_id" binding:"omitempty,min=1"`
+}
+
+type MarkReadResponse struct {
+	LastReadMessageID int64 `json:"last_read_message_id"`
+	Advanced          bool  `json:"advanced"`
+}
+
+type ReadReceiptResponse struct {
+	UserID            int64     `json:"user_id"`
+	LastReadMessageID int64     `json:"last_read_message_id"`
+	ReadAt            time.Time `json:"read_at"`
+}
+
 // ─── Users ────────────────────────────────────────────────────────────────────
 
 type SyncUserRequest struct {
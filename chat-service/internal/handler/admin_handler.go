@@ -1,21 +1,38 @@
 package handler
 
 import (
+	"context"
+	"net/http"
+
 	"chat-service/internal/dto"
 	"chat-service/internal/repository"
+	"chat-service/internal/service"
+	"chat-service/pkg/hub"
 	"chat-service/pkg/logger"
+	"chat-service/pkg/storage"
 
 	"github.com/gin-gonic/gin"
 )
 
 // AdminHandler handles admin-only channel and role management endpoints.
+// Changes that grant or revoke access are announced through the hub so open
+// WebSocket connections follow them without reconnecting.
 type AdminHandler struct {
-	chatRepo *repository.ChatRepository
-	userRepo *repository.UserRepository
+	chatRepo  *repository.ChatRepository
+	userRepo  *repository.UserRepository
+	hub       *hub.Hub
+	store     *storage.ObjectStore // nil while attachment storage is down
+	retention *service.RetentionService
 }
 
-func NewAdminHandler(chatRepo *repository.ChatRepository, userRepo *repository.UserRepository) *AdminHandler {
-	return &AdminHandler{chatRepo: chatRepo, userRepo: userRepo}
+func NewAdminHandler(
+	chatRepo *repository.ChatRepository,
+	userRepo *repository.UserRepository,
+	h *hub.Hub,
+	store *storage.ObjectStore,
+	retention *service.RetentionService,
+) *AdminHandler {
+	return &AdminHandler{chatRepo: chatRepo, userRepo: userRepo, hub: h, store: store, retention: retention}
 }
 
 // ─── ListAllChannels GET /api/v1/admin/channels ──────────────────────────────
@@ -60,6 +77,7 @@ func (h *AdminHandler) CreateChannel(c *gin.Context) {
 		c.JSON(dto.ErrInternal("Failed to create channel (slug may already exist)"))
 		return
 	}
+	h.publishMembership(c.Request.Context(), hub.MembershipChange{ChannelID: ch.ID})
 
 	c.JSON(dto.Created(channelToDTO(*ch)))
 }
@@ -99,10 +117,23 @@ func (h *AdminHandler) DeleteChannel(c *gin.Context) {
 		return
 	}
 
+	// A legal hold preserves the channel's history until it is lifted
+	policy, err := h.chatRepo.GetRetentionPolicy(c.Request.Context(), id)
+	if err != nil {
+		logger.Errorf("delete channel %d: %v", id, err)
+		c.JSON(dto.ErrInternal("Failed to delete channel"))
+		return
+	}
+	if policy != nil && policy.LegalHold {
+		c.JSON(dto.Err(http.StatusConflict, "legal_hold", "Channel is under legal hold and cannot be deleted"))
+		return
+	}
+
 	if err := h.chatRepo.DeleteChannel(c.Request.Context(), id); err != nil {
 		c.JSON(dto.ErrInternal("Failed to delete channel"))
 		return
 	}
+	h.publishMembership(c.Request.Context(), hub.MembershipChange{ChannelID: id, Deleted: true})
 
 	c.JSON(dto.OK(gin.H{"deleted": true}))
 }
@@ -160,6 +191,7 @@ func (h *AdminHandler) SetChannelRoles(c *gin.Context) {
 		c.JSON(dto.ErrInternal("Failed to set roles"))
 		return
 	}
+	h.publishMembership(c.Request.Context(), hub.MembershipChange{ChannelID: id})
 
 	c.JSON(dto.OK(gin.H{"updated": true}))
 }
@@ -208,11 +240,23 @@ func (h *AdminHandler) SetChannelUsers(c *gin.Context) {
 		return
 	}
 
+	// Users dropped from the list must be re-checked as well as the new ones
+	previous, err := h.chatRepo.GetChannelUsers(c.Request.Context(), id)
+	if err != nil {
+		logger.Errorf("get channel whitelist %d: %v", id, err)
+		c.JSON(dto.ErrInternal("Failed to set whitelist"))
+		return
+	}
+
 	if err := h.chatRepo.SetChannelUsers(c.Request.Context(), id, req.UserIDs); err != nil {
 		logger.Errorf("set channel whitelist %d: %v", id, err)
 		c.JSON(dto.ErrInternal("Failed to set whitelist"))
 		return
 	}
+	h.publishMembership(c.Request.Context(), hub.MembershipChange{
+		ChannelID: id,
+		UserIDs:   append(previous, req.UserIDs...),
+	})
 
 	// Return the final list so the client is immediately consistent - no extra GET.
 	users, err := h.chatRepo.GetChannelUsersWithDetails(c.Request.Context(), id)
@@ -234,3 +278,11 @@ func (h *AdminHandler) SetChannelUsers(c *gin.Context) {
 	}
 	c.JSON(dto.OK(dto.ChannelUsersResponse{Users: resp}))
 }
+
+// publishMembership asks every replica to re-check its clients' access to a
+// channel. The change itself is already stored, so failures are only logged.
+func (h *AdminHandler) publishMembership(ctx context.Context, change hub.MembershipChange) {
+	if err := h.hub.PublishMembership(ctx, change); err != nil {
+		logger.Warnf("publish membership change channel=%d: %v", change.ChannelID, err)
+	}
+}
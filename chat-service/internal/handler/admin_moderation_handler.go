@@ -0,0 +1,340 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"chat-service/internal/dto"
+	"chat-service/internal/repository"
+	"chat-service/pkg/hub"
+	"chat-service/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ─── SetSlowMode PUT /api/v1/admin/channels/:id/slow-mode ─────────────────────
+
+func (h *AdminHandler) SetSlowMode(c *gin.Context) {
+	channelID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req dto.SetSlowModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(dto.ErrBadRequest(err.Error()))
+		return
+	}
+
+	found, err := h.chatRepo.SetSlowMode(c.Request.Context(), channelID, req.Seconds)
+	if err != nil {
+		logger.Errorf("set slow mode channel=%d: %v", channelID, err)
+		c.JSON(dto.ErrInternal("Failed to set slow mode"))
+		return
+	}
+	if !found {
+		c.JSON(dto.ErrNotFound("Channel not found"))
+		return
+	}
+
+	event := hub.WSEvent{
+		Type:      hub.EventSlowMode,
+		ChannelID: channelID,
+		Payload:   hub.SlowModePayload{Seconds: req.Seconds},
+		Timestamp: time.Now().UTC(),
+	}
+	if err := h.hub.Publish(c.Request.Context(), channelID, event); err != nil {
+		logger.Warnf("publish slow mode: %v", err)
+	}
+
+	c.JSON(dto.OK(gin.H{"slow_mode_seconds": req.Seconds}))
+}
+
+// ─── ModeratorDeleteMessage DELETE /api/v1/admin/channels/:id/messages/:msgId ─
+// Unlike the member delete, the reason and the moderator are recorded.
+
+func (h *AdminHandler) ModeratorDeleteMessage(c *gin.Context) {
+	channelID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	msgID, ok := parseID(c, "msgId")
+	if !ok {
+		return
+	}
+
+	var req dto.ModeratorDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(dto.ErrBadRequest(err.Error()))
+		return
+	}
+
+	deleted, err := h.moderatorDelete(c.Request.Context(), channelID, msgID, mustUserID(c), req.Reason)
+	if err != nil {
+		c.JSON(dto.ErrInternal("Failed to delete message"))
+		return
+	}
+	if !deleted {
+		c.JSON(dto.ErrNotFound("Message not found"))
+		return
+	}
+
+	c.JSON(dto.OK(gin.H{"deleted": true}))
+}
+
+// moderatorDelete removes a message on a moderator's behalf and broadcasts
+// the delete. Returns false when there was nothing to delete.
+func (h *AdminHandler) moderatorDelete(ctx context.Context, channelID, msgID, actorID int64, reason string) (bool, error) {
+	deleted, err := h.chatRepo.ModeratorDeleteMessage(ctx, channelID, msgID, actorID, reason)
+	if err != nil {
+		logger.Errorf("moderator delete msgID=%d actor=%d: %v", msgID, actorID, err)
+		return false, err
+	}
+	if !deleted {
+		return false, nil
+	}
+	logger.Infof("moderator delete msgID=%d channel=%d actor=%d", msgID, channelID, actorID)
+
+	event := hub.WSEvent{
+		Type:      hub.EventDelete,
+		ChannelID: channelID,
+		Payload:   hub.MessagePayload{ID: msgID, IsDeleted: true},
+		Timestamp: time.Now().UTC(),
+	}
+	if err := h.hub.Publish(ctx, channelID, event); err != nil {
+		logger.Warnf("publish moderator delete: %v", err)
+	}
+	return true, nil
+}
+
+// ─── ListSanctions GET /api/v1/admin/sanctions?user_id=&channel_id= ───────────
+
+func (h *AdminHandler) ListSanctions(c *gin.Context) {
+	var userID, channelID int64
+	if s := c.Query("user_id"); s != "" {
+		userID, _ = strconv.ParseInt(s, 10, 64)
+	}
+	if s := c.Query("channel_id"); s != "" {
+		channelID, _ = strconv.ParseInt(s, 10, 64)
+	}
+
+	sanctions, err := h.chatRepo.ListActiveSanctions(c.Request.Context(), userID, channelID)
+	if err != nil {
+		logger.Errorf("list sanctions: %v", err)
+		c.JSON(dto.ErrInternal("Failed to list sanctions"))
+		return
+	}
+
+	resp := make([]dto.SanctionResponse, len(sanctions))
+	for i, s := range sanctions {
+		resp[i] = sanctionToDTO(s)
+	}
+	c.JSON(dto.OK(resp))
+}
+
+// ─── CreateSanction POST /api/v1/admin/sanctions ──────────────────────────────
+
+func (h *AdminHandler) CreateSanction(c *gin.Context) {
+	var req dto.CreateSanctionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(dto.ErrBadRequest(err.Error()))
+		return
+	}
+	adminID := mustUserID(c)
+	if req.UserID == adminID {
+		c.JSON(dto.ErrBadRequest("You cannot sanction yourself"))
+		return
+	}
+
+	var expiresAt time.Time
+	if req.DurationMinutes > 0 {
+		expiresAt = time.Now().Add(time.Duration(req.DurationMinutes) * time.Minute)
+	}
+
+	s, err := h.chatRepo.CreateSanction(
+		c.Request.Context(),
+		req.UserID, req.ChannelID, req.Kind, req.Reason, expiresAt, adminID,
+	)
+	if err != nil {
+		logger.Errorf("create sanction user=%d: %v", req.UserID, err)
+		c.JSON(dto.ErrInternal("Failed to create sanction (user or channel may not exist)"))
+		return
+	}
+	logger.Infof("sanction %s user=%d channel=%d by=%d", s.Kind, s.UserID, req.ChannelID, adminID)
+	h.announceSanction(c.Request.Context(), s, false)
+
+	c.JSON(dto.Created(sanctionToDTO(*s)))
+}
+
+// ─── RevokeSanction DELETE /api/v1/admin/sanctions/:sanctionId ────────────────
+
+func (h *AdminHandler) RevokeSanction(c *gin.Context) {
+	sanctionID, ok := parseID(c, "sanctionId")
+	if !ok {
+		return
+	}
+
+	s, err := h.chatRepo.RevokeSanction(c.Request.Context(), sanctionID, mustUserID(c))
+	if err != nil {
+		logger.Errorf("revoke sanction %d: %v", sanctionID, err)
+		c.JSON(dto.ErrInternal("Failed to revoke sanction"))
+		return
+	}
+	if s == nil {
+		c.JSON(dto.ErrNotFound("Sanction not found or already revoked"))
+		return
+	}
+	h.announceSanction(c.Request.Context(), s, true)
+
+	c.JSON(dto.OK(gin.H{"revoked": true}))
+}
+
+// announceSanction tells the sanctioned user's connections and, for bans,
+// has every replica re-check their access so a ban closes the channel on
+// open connections at once. Lifting an everywhere-ban only takes effect on
+// open connections when they next subscribe or reconnect.
+func (h *AdminHandler) announceSanction(ctx context.Context, s *repository.Sanction, revoked bool) {
+	var channelID int64
+	if s.ChannelID != nil {
+		channelID = *s.ChannelID
+	}
+
+	event := hub.WSEvent{
+		Type:      hub.EventSanction,
+		ChannelID: channelID,
+		Payload: hub.SanctionPayload{
+			SanctionID: s.ID,
+			Kind:       s.Kind,
+			Reason:     s.Reason,
+			ExpiresAt:  s.ExpiresAt,
+			Revoked:    revoked,
+		},
+		Timestamp: time.Now().UTC(),
+	}
+	if err := h.hub.PublishToUser(ctx, s.UserID, event); err != nil {
+		logger.Warnf("publish sanction user=%d: %v", s.UserID, err)
+	}
+
+	if s.Kind == repository.SanctionBan {
+		h.publishMembership(ctx, hub.MembershipChange{ChannelID: channelID, UserIDs: []int64{s.UserID}})
+	}
+}
+
+// ─── ListReports GET /api/v1/admin/reports?status=open&before_id=&limit= ──────
+// status defaults to open; "all" lists every report.
+
+func (h *AdminHandler) ListReports(c *gin.Context) {
+	status := c.DefaultQuery("status", repository.ReportOpen)
+	switch status {
+	case "all":
+		status = ""
+	case repository.ReportOpen, repository.ReportDismissed, repository.ReportActioned:
+	default:
+		c.JSON(dto.ErrBadRequest("status must be open, dismissed, actioned or all"))
+		return
+	}
+
+	beforeID := int64(0)
+	if s := c.Query("before_id"); s != "" {
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			beforeID = v
+		}
+	}
+	limit := 50
+	if s := c.Query("limit"); s != "" {
+		if v, _ := strconv.Atoi(s); v > 0 && v <= 100 {
+			limit = v
+		}
+	}
+
+	reports, err := h.chatRepo.ListReports(c.Request.Context(), status, beforeID, limit)
+	if err != nil {
+		logger.Errorf("list reports: %v", err)
+		c.JSON(dto.ErrInternal("Failed to list reports"))
+		return
+	}
+
+	resp := dto.ReportListResponse{
+		Reports: make([]dto.ReportResponse, len(reports)),
+		HasMore: len(reports) == limit,
+	}
+	for i, rp := range reports {
+		resp.Reports[i] = dto.ReportResponse{
+			ID:           rp.ID,
+			MessageID:    rp.MessageID,
+			ChannelID:    rp.ChannelID,
+			ReporterID:   rp.ReporterID,
+			ReporterName: rp.ReporterName,
+			Reason:       rp.Reason,
+			Status:       rp.Status,
+			CreatedAt:    rp.CreatedAt,
+			ReviewedBy:   rp.ReviewedBy,
+			ReviewedAt:   rp.ReviewedAt,
+			ReviewNote:   rp.ReviewNote,
+			SenderID:     rp.SenderID,
+			SenderName:   rp.SenderName,
+			Body:         rp.Body,
+			IsDeleted:    rp.IsDeleted,
+			ReportCount:  rp.ReportCount,
+		}
+	}
+	if resp.HasMore {
+		resp.NextCursor = reports[len(reports)-1].ID
+	}
+	c.JSON(dto.OK(resp))
+}
+
+// ─── ResolveReport POST /api/v1/admin/reports/:reportId/resolve ───────────────
+
+func (h *AdminHandler) ResolveReport(c *gin.Context) {
+	reportID, ok := parseID(c, "reportId")
+	if !ok {
+		return
+	}
+
+	var req dto.ResolveReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(dto.ErrBadRequest(err.Error()))
+		return
+	}
+	adminID := mustUserID(c)
+
+	channelID, msgID, found, err := h.chatRepo.ResolveReport(c.Request.Context(), reportID, adminID, req.Status, req.Note)
+	if err != nil {
+		logger.Errorf("resolve report %d: %v", reportID, err)
+		c.JSON(dto.ErrInternal("Failed to resolve report"))
+		return
+	}
+	if !found {
+		c.JSON(dto.ErrNotFound("Report not found"))
+		return
+	}
+
+	deleted := false
+	if req.DeleteMessage {
+		reason := req.Note
+		if reason == "" {
+			reason = "Removed after report review"
+		}
+		if deleted, err = h.moderatorDelete(c.Request.Context(), channelID, msgID, adminID, reason); err != nil {
+			c.JSON(dto.ErrInternal("Report resolved but the message could not be deleted"))
+			return
+		}
+	}
+
+	c.JSON(dto.OK(gin.H{"status": req.Status, "message_deleted": deleted}))
+}
+
+func sanctionToDTO(s repository.Sanction) dto.SanctionResponse {
+	return dto.SanctionResponse{
+		ID:        s.ID,
+		UserID:    s.UserID,
+		ChannelID: s.ChannelID,
+		Kind:      s.Kind,
+		Reason:    s.Reason,
+		CreatedBy: s.CreatedBy,
+		CreatedAt: s.CreatedAt,
+		ExpiresAt: s.ExpiresAt,
+	}
+}
@@ -0,0 +1,479 @@
+package handler
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"chat-service/internal/dto"
+	"chat-service/internal/repository"
+	"chat-service/internal/service"
+	"chat-service/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportBatchSize is how many messages an export reads at a time
+const exportBatchSize = 500
+
+// ─── GetRetention GET /api/v1/admin/channels/:id/retention ────────────────────
+
+func (h *AdminHandler) GetRetention(c *gin.Context) {
+	channelID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	if !h.channelExists(c, channelID) {
+		return
+	}
+
+	policy, err := h.chatRepo.GetRetentionPolicy(c.Request.Context(), channelID)
+	if err != nil {
+		logger.Errorf("get retention channel=%d: %v", channelID, err)
+		c.JSON(dto.ErrInternal("Failed to load retention policy"))
+		return
+	}
+	c.JSON(dto.OK(retentionToDTO(channelID, policy)))
+}
+
+// ─── SetRetention PUT /api/v1/admin/channels/:id/retention ────────────────────
+// The anonymize action removes only who sent expired messages; their bodies
+// and attachments are kept. Use delete when the content itself must go.
+
+func (h *AdminHandler) SetRetention(c *gin.Context) {
+	channelID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req dto.SetRetentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(dto.ErrBadRequest(err.Error()))
+		return
+	}
+	if !h.channelExists(c, channelID) {
+		return
+	}
+
+	var days *int
+	if req.RetentionDays > 0 {
+		days = &req.RetentionDays
+	}
+	action := req.Action
+	if action == "" {
+		action = repository.RetentionDelete
+	}
+
+	adminID := mustUserID(c)
+	policy, err := h.chatRepo.SetRetentionPolicy(c.Request.Context(), channelID, days, action, adminID)
+	if err != nil {
+		logger.Errorf("set retention channel=%d: %v", channelID, err)
+		c.JSON(dto.ErrInternal("Failed to set retention policy"))
+		return
+	}
+	logger.Infof("retention channel=%d days=%d action=%s by=%d", channelID, req.RetentionDays, action, adminID)
+
+	c.JSON(dto.OK(retentionToDTO(channelID, policy)))
+}
+
+// ─── SetLegalHold PUT /api/v1/admin/channels/:id/legal-hold ───────────────────
+// While held, nothing in the channel expires or is purged and the channel
+// cannot be deleted.
+
+func (h *AdminHandler) SetLegalHold(c *gin.Context) {
+	channelID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req dto.SetLegalHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(dto.ErrBadRequest(err.Error()))
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Hold && req.Reason == "" {
+		c.JSON(dto.ErrBadRequest("A reason is required to place a legal hold"))
+		return
+	}
+	if !h.channelExists(c, channelID) {
+		return
+	}
+
+	adminID := mustUserID(c)
+	policy, err := h.chatRepo.SetLegalHold(c.Request.Context(), channelID, req.Hold, req.Reason, adminID)
+	if err != nil {
+		logger.Errorf("set legal hold channel=%d: %v", channelID, err)
+		c.JSON(dto.ErrInternal("Failed to set legal hold"))
+		return
+	}
+	logger.Infof("legal hold channel=%d hold=%t by=%d", channelID, req.Hold, adminID)
+
+	c.JSON(dto.OK(retentionToDTO(channelID, policy)))
+}
+
+// ─── RunRetention POST /api/v1/admin/retention/run ────────────────────────────
+
+func (h *AdminHandler) RunRetention(c *gin.Context) {
+	report, err := h.retention.RunOnce(c.Request.Context())
+	if err != nil {
+		logger.Errorf("retention run by=%d: %v", mustUserID(c), err)
+		c.JSON(dto.ErrInternal("Retention run failed"))
+		return
+	}
+	c.JSON(dto.OK(retentionRunToDTO(report)))
+}
+
+// ─── ExportChannel GET /api/v1/admin/channels/:id/export ──────────────────────
+// Streams a zip of manifest.json, messages.json, messages.html and the
+// attachments. Deleted messages are included with whatever content has not
+// been purged. Once streaming starts an error can only cut the archive
+// short, so manifest.json goes last as the marker of a complete export.
+
+func (h *AdminHandler) ExportChannel(c *gin.Context) {
+	channelID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	ch, err := h.chatRepo.GetChannelByID(ctx, channelID)
+	if err != nil {
+		logger.Errorf("export channel=%d: %v", channelID, err)
+		c.JSON(dto.ErrInternal("Failed to export channel"))
+		return
+	}
+	if ch == nil {
+		c.JSON(dto.ErrNotFound("Channel not found"))
+		return
+	}
+	policy, err := h.chatRepo.GetRetentionPolicy(ctx, channelID)
+	if err != nil {
+		logger.Errorf("export channel=%d: %v", channelID, err)
+		c.JSON(dto.ErrInternal("Failed to export channel"))
+		return
+	}
+	throughID, err := h.chatRepo.LatestMessageID(ctx, channelID)
+	if err != nil {
+		logger.Errorf("export channel=%d: %v", channelID, err)
+		c.JSON(dto.ErrInternal("Failed to export channel"))
+		return
+	}
+
+	adminID := mustUserID(c)
+	exportedAt := time.Now().UTC()
+	logger.Infof("channel export channel=%d through=%d by=%d", channelID, throughID, adminID)
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="channel-%d-%s.zip"`, channelID, exportedAt.Format("20060102-150405")))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	zw := zip.NewWriter(c.Writer)
+	e := &channelExport{
+		h:         h,
+		zw:        zw,
+		channel:   ch,
+		throughID: throughID,
+		manifest: dto.ChannelExportManifest{
+			ChannelID:          ch.ID,
+			Slug:               ch.Slug,
+			Name:               ch.Name,
+			IsPrivate:          ch.IsPrivate,
+			IsDM:               ch.IsDM,
+			CourseID:           ch.CourseID,
+			ExportedAt:         exportedAt,
+			ExportedBy:         adminID,
+			ThroughMessageID:   throughID,
+			MissingAttachments: []string{},
+		},
+	}
+	if policy != nil {
+		p := retentionToDTO(channelID, policy)
+		e.manifest.RetentionPolicy = &p
+	}
+	if err := e.write(ctx); err != nil {
+		// Leaving the archive unclosed makes the truncation visible
+		logger.Errorf("export channel=%d by=%d: %v", channelID, adminID, err)
+		return
+	}
+	if err := zw.Close(); err != nil {
+		logger.Errorf("export channel=%d by=%d: close archive: %v", channelID, adminID, err)
+	}
+}
+
+// channelExport writes the parts of one channel export in turn, each
+// reading the channel's messages up to throughID.
+type channelExport struct {
+	h         *AdminHandler
+	zw        *zip.Writer
+	channel   *repository.Channel
+	throughID int64
+	manifest  dto.ChannelExportManifest
+}
+
+func (e *channelExport) write(ctx context.Context) error {
+	if err := e.writeJSON(ctx); err != nil {
+		return fmt.Errorf("messages.json: %w", err)
+	}
+	if err := e.writeHTML(ctx); err != nil {
+		return fmt.Errorf("messages.html: %w", err)
+	}
+	if err := e.writeAttachments(ctx); err != nil {
+		return fmt.Errorf("attachments: %w", err)
+	}
+	w, err := e.create("manifest.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e.manifest)
+}
+
+// each calls fn for every exported message, oldest first.
+func (e *channelExport) each(ctx context.Context, fn func(repository.ExportMessage) error) error {
+	afterID := int64(0)
+	for afterID < e.throughID {
+		msgs, err := e.h.chatRepo.ExportMessages(ctx, e.channel.ID, afterID, e.throughID, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, m := range msgs {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		if len(msgs) < exportBatchSize {
+			return nil
+		}
+		afterID = msgs[len(msgs)-1].ID
+	}
+	return nil
+}
+
+func (e *channelExport) create(name string) (io.Writer, error) {
+	return e.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: e.manifest.ExportedAt,
+	})
+}
+
+func (e *channelExport) writeJSON(ctx context.Context) error {
+	w, err := e.create("messages.json")
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString("["); err != nil {
+		return err
+	}
+	first := true
+	err = e.each(ctx, func(m repository.ExportMessage) error {
+		e.manifest.MessageCount++
+		e.manifest.AttachmentCount += len(m.Attachments)
+		line, err := json.Marshal(exportMessageToDTO(m))
+		if err != nil {
+			return err
+		}
+		if !first {
+			bw.WriteString(",")
+		}
+		first = false
+		bw.WriteString("\n  ")
+		_, err = bw.Write(line)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	bw.WriteString("\n]\n")
+	return bw.Flush()
+}
+
+var exportHTML = template.Must(template.New("export").Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}} ({{.Slug}})</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+.msg { border-bottom: 1px solid #ddd; padding: .5em 0; }
+.meta { color: #555; font-size: .85em; }
+.body { white-space: pre-wrap; margin: .25em 0; }
+.deleted { background: #fff3f3; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<p class="meta">#{{.Slug}} &middot; channel {{.ChannelID}} &middot; messages through {{.ThroughMessageID}} &middot; exported {{.ExportedAt.Format "2006-01-02 15:04:05 MST"}} by user {{.ExportedBy}}</p>
+{{end}}
+{{define "message"}}<div class="msg{{if .IsDeleted}} deleted{{end}}" id="m{{.ID}}">
+<div class="meta">#{{.ID}} &middot; {{.CreatedAt.Format "2006-01-02 15:04:05 MST"}} &middot; {{.SenderName}} &lt;{{.SenderEmail}}&gt; (user {{.SenderID}})
+{{- if .ParentID}} &middot; reply to <a href="#m{{.ParentID}}">#{{.ParentID}}</a>{{end}}
+{{- if .IsEdited}} &middot; edited{{end}}
+{{- if .IsDeleted}} &middot; deleted{{if .DeletedBy}} by user {{.DeletedBy}}{{end}}{{if .DeletedAt}} at {{.DeletedAt.Format "2006-01-02 15:04:05 MST"}}{{end}}{{if .DeletedReason}}: {{.DeletedReason}}{{end}}{{end}}</div>
+<div class="body">{{.Body}}</div>
+{{- range .Attachments}}
+<div class="meta">attachment: <a href="{{.Path}}">{{.FileName}}</a> ({{.MimeType}}, {{.SizeBytes}} bytes)</div>
+{{- end}}
+</div>
+{{end}}
+{{define "foot"}}</body>
+</html>
+{{end}}`))
+
+func (e *channelExport) writeHTML(ctx context.Context) error {
+	w, err := e.create("messages.html")
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	if err := exportHTML.ExecuteTemplate(bw, "head", e.manifest); err != nil {
+		return err
+	}
+	err = e.each(ctx, func(m repository.ExportMessage) error {
+		return exportHTML.ExecuteTemplate(bw, "message", exportMessageToDTO(m))
+	})
+	if err != nil {
+		return err
+	}
+	if err := exportHTML.ExecuteTemplate(bw, "foot", nil); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeAttachments copies every attachment into the archive. Objects that
+// cannot be read are listed in the manifest instead of failing the export.
+func (e *channelExport) writeAttachments(ctx context.Context) error {
+	return e.each(ctx, func(m repository.ExportMessage) error {
+		for _, a := range m.Attachments {
+			if e.h.store == nil {
+				e.manifest.MissingAttachments = append(e.manifest.MissingAttachments, a.ID)
+				continue
+			}
+			object, err := e.h.store.Get(ctx, a.ObjectKey)
+			if err != nil {
+				logger.Warnf("export attachment id=%s: %v", a.ID, err)
+				e.manifest.MissingAttachments = append(e.manifest.MissingAttachments, a.ID)
+				continue
+			}
+			w, err := e.zw.CreateHeader(&zip.FileHeader{
+				Name:     exportAttachmentPath(a),
+				Method:   zip.Deflate,
+				Modified: a.CreatedAt,
+			})
+			if err == nil {
+				_, err = io.Copy(w, object.Body)
+			}
+			object.Body.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// exportAttachmentPath names an attachment inside the archive by its ID,
+// which is unique, and its file name made safe for any file system.
+func exportAttachmentPath(a repository.Attachment) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, a.FileName)
+	if runes := []rune(name); len(runes) > 100 {
+		name = string(runes[len(runes)-100:])
+	}
+	return "attachments/" + a.ID + "-" + name
+}
+
+// channelExists writes a 404 or 500 and returns false unless channelID
+// exists.
+func (h *AdminHandler) channelExists(c *gin.Context, channelID int64) bool {
+	ch, err := h.chatRepo.GetChannelByID(c.Request.Context(), channelID)
+	if err != nil {
+		logger.Errorf("get channel %d: %v", channelID, err)
+		c.JSON(dto.ErrInternal("Failed to load channel"))
+		return false
+	}
+	if ch == nil {
+		c.JSON(dto.ErrNotFound("Channel not found"))
+		return false
+	}
+	return true
+}
+
+// retentionToDTO describes channelID's policy; p nil is the default of
+// keeping everything.
+func retentionToDTO(channelID int64, p *repository.RetentionPolicy) dto.RetentionPolicyResponse {
+	resp := dto.RetentionPolicyResponse{ChannelID: channelID, Action: repository.RetentionDelete}
+	if p == nil {
+		return resp
+	}
+	if p.RetentionDays != nil {
+		resp.RetentionDays = *p.RetentionDays
+	}
+	resp.Action = p.Action
+	resp.LegalHold = p.LegalHold
+	resp.LegalHoldReason = p.LegalHoldReason
+	resp.LegalHoldBy = p.LegalHoldBy
+	resp.LegalHoldAt = p.LegalHoldAt
+	resp.UpdatedAt = &p.UpdatedAt
+	return resp
+}
+
+func retentionRunToDTO(r *service.RetentionReport) dto.RetentionRunResponse {
+	return dto.RetentionRunResponse{
+		Skipped:        r.Skipped,
+		Channels:       r.Channels,
+		Deleted:        r.Deleted,
+		Anonymized:     r.Anonymized,
+		Purged:         r.Purged,
+		ObjectsDeleted: r.ObjectsDeleted,
+		OrphansDeleted: r.OrphansDeleted,
+		Failed:         r.Failed,
+	}
+}
+
+func exportMessageToDTO(m repository.ExportMessage) dto.ExportMessage {
+	resp := dto.ExportMessage{
+		ID:            m.ID,
+		SenderID:      m.SenderID,
+		SenderName:    m.SenderName,
+		SenderEmail:   m.SenderEmail,
+		Body:          m.Body,
+		IsEdited:      m.IsEdited,
+		IsDeleted:     m.IsDeleted,
+		DeletedBy:     m.DeletedBy,
+		DeletedReason: m.DeletedReason,
+		DeletedAt:     m.DeletedAt,
+		ParentID:      m.ParentID,
+		ThreadRootID:  m.ThreadRootID,
+		CreatedAt:     m.CreatedAt,
+	}
+	for _, a := range m.Attachments {
+		resp.Attachments = append(resp.Attachments, dto.ExportAttachment{
+			ID:        a.ID,
+			FileName:  a.FileName,
+			MimeType:  a.MimeType,
+			SizeBytes: a.SizeBytes,
+			Path:      exportAttachmentPath(a),
+			CreatedAt: a.CreatedAt,
+		})
+	}
+	return resp
+}
@@ -17,6 +17,7 @@ import (
 	"chat-service/internal/middleware"
 	"chat-service/internal/repository"
 	"chat-service/pkg/hub"
+	"chat-service/pkg/jwks"
 	"chat-service/pkg/logger"
 	"chat-service/pkg/storage"
 
@@ -25,11 +26,11 @@ import (
 
 // ChatHandler handles REST and WebSocket chat endpoints.
 type ChatHandler struct {
-	chatRepo  *repository.ChatRepository
-	userRepo  *repository.UserRepository
-	hub       *hub.Hub
-	store     *storage.ObjectStore
-	jwtSecret string
+	chatRepo *repository.ChatRepository
+	userRepo *repository.UserRepository
+	hub      *hub.Hub
+	store    *storage.ObjectStore
+	verifier *jwks.Verifier
 }
 
 func NewChatHandler(
@@ -37,14 +38,14 @@ func NewChatHandler(
 	userRepo *repository.UserRepository,
 	h *hub.Hub,
 	store *storage.ObjectStore,
-	jwtSecret string,
+	verifier *jwks.Verifier,
 ) *ChatHandler {
 	return &ChatHandler{
-		chatRepo:  chatRepo,
-		userRepo:  userRepo,
-		hub:       h,
-		store:     store,
-		jwtSecret: jwtSecret,
+		chatRepo: chatRepo,
+		userRepo: userRepo,
+		hub:      h,
+		store:    store,
+		verifier: verifier,
 	}
 }
 
@@ -52,6 +53,13 @@ const (
 	maxAttachmentBytes       int64 = 20 * 1024 * 1024
 	maxAttachmentsPerMessage       = 10
 	maxTotalAttachmentBytes  int64 = 100 * 1024 * 1024
+
+	// resumeReplayLimit caps the messages replayed per channel on resume;
+	// larger gaps are paged in over REST.
+	resumeReplayLimit = 100
+	// resumeHoldTimeout releases held live events if a client that asked to
+	// resume never sends its cursors.
+	resumeHoldTimeout = 10 * time.Second
 )
 
 var allowedAttachmentTypes = map[string]string{
@@ -110,9 +118,21 @@ func (h *ChatHandler) ListChannels(c *gin.Context) {
 		}
 	}
 
+	channelIDs := make([]int64, len(channels))
+	for i, ch := range channels {
+		channelIDs[i] = ch.ID
+	}
+	readStates, err := h.chatRepo.GetReadStates(c.Request.Context(), userID, channelIDs)
+	if err != nil {
+		logger.Errorf("get read states user=%d: %v", userID, err)
+		c.JSON(dto.ErrInternal("Failed to load unread counts"))
+		return
+	}
+
 	resp := make([]dto.ChannelResponse, len(channels))
 	for i, ch := range channels {
 		dtoCh := channelToDTO(ch)
+		applyReadState(&dtoCh, readStates[ch.ID])
 		if ch.IsDM {
 			if u, ok := dmUsers[ch.ID]; ok {
 				dtoCh.DMUser = &dto.UserResponse{
@@ -265,6 +285,12 @@ func (h *ChatHandler) GetOrCreateDM(c *gin.Context) {
 		return
 	}
 
+	// Subscribe both participants' open connections to the conversation
+	h.publishMembership(c.Request.Context(), hub.MembershipChange{
+		ChannelID: ch.ID,
+		UserIDs:   []int64{userID, req.UserID},
+	})
+
 	resp := channelToDTO(*ch)
 	resp.DMUser = &dto.UserResponse{
 		ID:             targetUser.ID,
@@ -313,7 +339,24 @@ func (h *ChatHandler) ListMessages(c *gin.Context) {
 		}
 	}
 
-	msgs, err := h.chatRepo.ListMessages(c.Request.Context(), channelID, beforeID, limit)
+	// Thread replies live in their threads; ?include_replies=true brings them
+	// back into the timeline
+	topLevelOnly := c.Query("include_replies") != "true"
+
+	// ?after_id=<msgID> pages forward instead, e.g. down from a search hit
+	afterID := int64(0)
+	if s := c.Query("after_id"); s != "" {
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			afterID = v
+		}
+	}
+
+	var msgs []repository.Message
+	if afterID > 0 {
+		msgs, err = h.chatRepo.ListMessagesAfter(c.Request.Context(), channelID, afterID, limit)
+	} else {
+		msgs, err = h.chatRepo.ListMessages(c.Request.Context(), channelID, beforeID, limit, topLevelOnly)
+	}
 	if err != nil {
 		logger.Errorf("list messages channel=%d: %v", channelID, err)
 		c.JSON(dto.ErrInternal("Failed to load messages"))
@@ -325,12 +368,16 @@ func (h *ChatHandler) ListMessages(c *gin.Context) {
 	hasMore := len(msgs) == limit
 	if hasMore && len(msgs) > 0 {
 		nextCursor = msgs[0].ID // oldest ID in the current page = cursor for next page
+		if afterID > 0 {
+			nextCursor = msgs[len(msgs)-1].ID // newest ID when paging forward
+		}
 	}
 
 	resp := make([]dto.MessageResponse, len(msgs))
 	for i, m := range msgs {
 		resp[i] = messageToDTO(m)
 	}
+	h.decorateMessages(c.Request.Context(), channelID, userID, resp)
 
 	c.JSON(dto.OK(dto.MessageListResponse{
 		Messages:   resp,
@@ -364,18 +411,31 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 		c.JSON(dto.ErrBadRequest(err.Error()))
 		return
 	}
+	if block := h.checkPosting(c.Request.Context(), channelID, userID, roles, false); block != nil {
+		respondPostBlock(c, block)
+		return
+	}
 
 	// Ensure user exists in chat DB (may not have been synced yet)
 	if err := h.userRepo.EnsureGuestUser(c.Request.Context(), userID, email); err != nil {
 		logger.Warnf("ensure guest user %d: %v", userID, err)
 	}
 
-	msg, err := h.chatRepo.CreateMessage(c.Request.Context(), channelID, userID, req.Body, req.ParentID)
+	msg, created, err := h.chatRepo.CreateMessage(c.Request.Context(), channelID, userID, req.Body, req.ParentID, req.ClientMsgID, slowModeApplies(roles))
+	if block := asSlowModeBlock(err); block != nil {
+		respondPostBlock(c, block)
+		return
+	}
 	if err != nil {
 		logger.Errorf("create message channel=%d user=%d: %v", channelID, userID, err)
 		c.JSON(dto.ErrInternal("Failed to send message"))
 		return
 	}
+	if !created {
+		// Retried request: the message was stored and broadcast the first time
+		c.JSON(dto.OK(messageToDTO(*msg)))
+		return
+	}
 
 	// Publish to Redis so WebSocket clients on all replicas receive it
 	event := hub.WSEvent{
@@ -387,6 +447,7 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 	if err := h.hub.Publish(c.Request.Context(), channelID, event); err != nil {
 		logger.Warnf("publish message: %v", err)
 	}
+	h.syncMentions(c.Request.Context(), msg, canWrite, false)
 
 	c.JSON(dto.Created(messageToDTO(*msg)))
 }
@@ -411,6 +472,12 @@ func (h *ChatHandler) UploadAttachment(c *gin.Context) {
 		c.JSON(dto.ErrForbidden("Cannot upload to this channel"))
 		return
 	}
+	// Checked before streaming so a slow-moded upload is not stored only to
+	// be rejected
+	if block := h.checkPosting(c.Request.Context(), channelID, userID, roles, true); block != nil {
+		respondPostBlock(c, block)
+		return
+	}
 
 	// A total request cap protects bandwidth and object-storage cost. Each part
 	// is separately capped below; all data is streamed, never buffered in the pod.
@@ -547,7 +614,12 @@ func (h *ChatHandler) UploadAttachment(c *gin.Context) {
 	if err := h.userRepo.EnsureGuestUser(c.Request.Context(), userID, c.GetString("user_email")); err != nil {
 		logger.Warnf("ensure attachment sender user=%d: %v", userID, err)
 	}
-	msg, err := h.chatRepo.CreateMessageWithAttachments(c.Request.Context(), channelID, userID, body, parentID, stored)
+	msg, err := h.chatRepo.CreateMessageWithAttachments(c.Request.Context(), channelID, userID, body, parentID, stored, slowModeApplies(roles))
+	if block := asSlowModeBlock(err); block != nil {
+		cleanupStored()
+		respondPostBlock(c, block)
+		return
+	}
 	if err != nil {
 		cleanupStored()
 		logger.Errorf("create attachment message channel=%d user=%d: %v", channelID, userID, err)
@@ -558,6 +630,7 @@ func (h *ChatHandler) UploadAttachment(c *gin.Context) {
 	if err := h.hub.Publish(c.Request.Context(), channelID, event); err != nil {
 		logger.Warnf("publish attachment message: %v", err)
 	}
+	h.syncMentions(c.Request.Context(), msg, canWrite, false)
 	c.JSON(dto.Created(messageToDTO(*msg)))
 }
 
@@ -681,6 +754,11 @@ func (h *ChatHandler) EditMessage(c *gin.Context) {
 		c.JSON(dto.ErrBadRequest(err.Error()))
 		return
 	}
+	// A muted user may not rewrite what they already posted either
+	if block := h.checkPosting(c.Request.Context(), channelID, userID, mustRoles(c), false); block != nil {
+		respondPostBlock(c, block)
+		return
+	}
 
 	msg, err := h.chatRepo.UpdateMessageBody(c.Request.Context(), msgID, userID, req.Body)
 	if err != nil {
@@ -698,6 +776,13 @@ func (h *ChatHandler) EditMessage(c *gin.Context) {
 	}
 	_ = h.hub.Publish(c.Request.Context(), channelID, event)
 
+	// @channel in an edit needs write access now, not when first sent
+	_, canWrite, err := h.chatRepo.CanUserAccess(c.Request.Context(), msg.ChannelID, userID, mustRoles(c))
+	if err != nil {
+		logger.Warnf("edit mention access check channel=%d user=%d: %v", msg.ChannelID, userID, err)
+	}
+	h.syncMentions(c.Request.Context(), msg, canWrite, true)
+
 	c.JSON(dto.OK(messageToDTO(*msg)))
 }
 
@@ -705,7 +790,7 @@ func (h *ChatHandler) EditMessage(c *gin.Context) {
 
 func (h *ChatHandler) ServeWS(c *gin.Context) {
 	// 1. Authenticate via JWT in query param (browsers can't set headers on WS)
-	claims, ok := middleware.ParseTokenFromQuery(c, h.jwtSecret)
+	claims, ok := middleware.ParseTokenFromQuery(c, h.verifier)
 	if !ok {
 		return
 	}
@@ -728,16 +813,13 @@ func (h *ChatHandler) ServeWS(c *gin.Context) {
 		return
 	}
 
+	// A user without channels still connects: channels granted later arrive
+	// as join events
 	channelIDs := make([]int64, len(channels))
 	for i, ch := range channels {
 		channelIDs[i] = ch.ID
 	}
 
-	if len(channelIDs) == 0 {
-		c.JSON(http.StatusForbidden, gin.H{"error": "No accessible channels"})
-		return
-	}
-
 	// 4. Upgrade to WebSocket
 	conn, err := hub.Upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -745,8 +827,12 @@ func (h *ChatHandler) ServeWS(c *gin.Context) {
 		return
 	}
 
-	// 5. Create client and register with hub
+	// 5. Create client and register with hub. A reconnecting client asks for
+	// live events to wait until it has sent its resume cursors.
 	client := hub.NewClient(h.hub, conn, userID, email, roles, channelIDs)
+	if c.Query("resume") == "1" {
+		client.HoldUntilResume(resumeHoldTimeout)
+	}
 	h.hub.RegisterClient(client)
 
 	logger.Infof("ws: user=%d ip=%s channels=%v roles=%v", userID, c.ClientIP(), channelIDs, roles)
@@ -762,25 +848,51 @@ func (h *ChatHandler) ServeWS(c *gin.Context) {
 func (h *ChatHandler) handleWSMessage(ctx context.Context, c *hub.Client, msg hub.InboundMsg) {
 	switch msg.Type {
 	case hub.EventMessage:
-		if msg.Body == "" || len(msg.Body) > 4000 {
+		if msg.Body == "" || len(msg.Body) > 4000 || len(msg.ClientMsgID) > 64 {
+			sendWSError(c, msg, "bad_request", "Message body must be 1-4000 characters and client_msg_id at most 64")
 			return
 		}
 
 		// Access check (authoritative - re-check even though client is registered)
 		_, canWrite, err := h.chatRepo.CanUserAccess(ctx, msg.ChannelID, c.UserID, c.Roles)
 		if err != nil || !canWrite {
+			sendWSError(c, msg, "forbidden", "Cannot write to this channel")
+			return
+		}
+		if block := h.checkPosting(ctx, msg.ChannelID, c.UserID, c.Roles, false); block != nil {
+			sendWSBlock(c, msg, block)
 			return
 		}
 
 		// Persist
 		t0 := time.Now()
-		newMsg, err := h.chatRepo.CreateMessage(ctx, msg.ChannelID, c.UserID, msg.Body, msg.ParentID)
+		newMsg, created, err := h.chatRepo.CreateMessage(ctx, msg.ChannelID, c.UserID, msg.Body, msg.ParentID, msg.ClientMsgID, slowModeApplies(c.Roles))
+		if block := asSlowModeBlock(err); block != nil {
+			sendWSBlock(c, msg, block)
+			return
+		}
 		if err != nil {
 			logger.Errorf("ws: persist message channelID=%d userID=%d: %v", msg.ChannelID, c.UserID, err)
+			sendWSError(c, msg, "internal_error", "Failed to send message")
+			return
+		}
+		logger.Debugf("ws: message persisted msgID=%d channelID=%d duplicate=%t latency=%dms",
+			newMsg.ID, msg.ChannelID, !created, time.Since(t0).Milliseconds())
+
+		// Confirm to the sender first; a retry of a stored message is only acked
+		c.Send(hub.WSEvent{
+			Type:      hub.EventAck,
+			ChannelID: msg.ChannelID,
+			Payload: hub.AckPayload{
+				ClientMsgID: msg.ClientMsgID,
+				MessageID:   newMsg.ID,
+				Duplicate:   !created,
+			},
+			Timestamp: time.Now().UTC(),
+		})
+		if !created {
 			return
 		}
-		logger.Debugf("ws: message persisted msgID=%d channelID=%d latency=%dms",
-			newMsg.ID, msg.ChannelID, time.Since(t0).Milliseconds())
 
 		// Publish to Redis (all replicas fan-out to their local clients)
 		event := hub.WSEvent{
@@ -792,6 +904,7 @@ func (h *ChatHandler) handleWSMessage(ctx context.Context, c *hub.Client, msg hu
 		if err := h.hub.Publish(ctx, msg.ChannelID, event); err != nil {
 			logger.Warnf("ws publish: %v", err)
 		}
+		h.syncMentions(ctx, newMsg, canWrite, false)
 
 	case hub.EventTyping:
 		// Publish ephemeral typing indicator (not persisted)
@@ -806,11 +919,107 @@ func (h *ChatHandler) handleWSMessage(ctx context.Context, c *hub.Client, msg hu
 		}
 		_ = h.hub.Publish(ctx, msg.ChannelID, event)
 
+	case hub.EventAck:
+		// Advance the read pointer; the channel was checked by ReadPump
+		if msg.MessageID <= 0 {
+			return
+		}
+		if _, _, err := h.advanceRead(ctx, msg.ChannelID, c.UserID, msg.MessageID); err != nil {
+			logger.Errorf("ws: mark read channelID=%d userID=%d: %v", msg.ChannelID, c.UserID, err)
+		}
+
+	case hub.EventSubscribe:
+		canRead, _, err := h.chatRepo.CanUserAccess(ctx, msg.ChannelID, c.UserID, c.Roles)
+		if err != nil || !canRead {
+			sendWSError(c, msg, "forbidden", "Cannot read this channel")
+			return
+		}
+		h.hub.Subscribe(c, msg.ChannelID)
+		c.Send(hub.WSEvent{Type: hub.EventJoin, ChannelID: msg.ChannelID, Timestamp: time.Now().UTC()})
+
+	case hub.EventUnsubscribe:
+		h.hub.Unsubscribe(c, msg.ChannelID)
+		c.Send(hub.WSEvent{Type: hub.EventLeave, ChannelID: msg.ChannelID, Timestamp: time.Now().UTC()})
+
+	case hub.EventResume:
+		h.replayGap(ctx, c, msg.Cursors)
+
 	case hub.EventPing:
 		// Client-initiated keepalive ping - no-op (pong is handled at WS layer)
 	}
 }
 
+// replayGap sends one EventReplay per channel in cursors with the messages
+// published after the client's last seen ID, then releases the live events
+// held since it connected. Channels the connection is not subscribed to are
+// ignored; a cursor of 0 means the client has nothing to catch up on.
+func (h *ChatHandler) replayGap(ctx context.Context, c *hub.Client, cursors map[int64]int64) {
+	replay := make([]hub.WSEvent, 0, len(cursors))
+	for channelID, lastSeenID := range cursors {
+		if lastSeenID <= 0 || !c.SubscribedTo(channelID) {
+			continue
+		}
+		msgs, err := h.chatRepo.ListMessagesAfter(ctx, channelID, lastSeenID, resumeReplayLimit+1)
+		if err != nil {
+			logger.Errorf("ws: replay channelID=%d userID=%d: %v", channelID, c.UserID, err)
+			continue
+		}
+
+		payload := hub.ReplayPayload{Messages: make([]hub.MessagePayload, 0, len(msgs))}
+		if len(msgs) > resumeReplayLimit {
+			msgs = msgs[:resumeReplayLimit]
+			payload.HasMore = true
+		}
+		for i := range msgs {
+			payload.Messages = append(payload.Messages, messagepayload(&msgs[i]))
+		}
+		replay = append(replay, hub.WSEvent{
+			Type:      hub.EventReplay,
+			ChannelID: channelID,
+			Payload:   payload,
+			Timestamp: time.Now().UTC(),
+		})
+	}
+	c.Resume(replay)
+}
+
+// publishMembership asks every replica to re-check its clients' access to a
+// channel. The change itself is already stored, so failures are only logged.
+func (h *ChatHandler) publishMembership(ctx context.Context, change hub.MembershipChange) {
+	if err := h.hub.PublishMembership(ctx, change); err != nil {
+		logger.Warnf("publish membership change channel=%d: %v", change.ChannelID, err)
+	}
+}
+
+// sendWSError tells the sender why its frame was rejected.
+func sendWSError(c *hub.Client, msg hub.InboundMsg, code, message string) {
+	c.Send(hub.WSEvent{
+		Type:      hub.EventError,
+		ChannelID: msg.ChannelID,
+		Payload: hub.ErrorPayload{
+			ClientMsgID: msg.ClientMsgID,
+			Code:        code,
+			Message:     message,
+		},
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// sendWSBlock reports a mute or slow mode rejection of msg to its sender.
+func sendWSBlock(c *hub.Client, msg hub.InboundMsg, b *postBlock) {
+	c.Send(hub.WSEvent{
+		Type:      hub.EventError,
+		ChannelID: msg.ChannelID,
+		Payload: hub.ErrorPayload{
+			ClientMsgID: msg.ClientMsgID,
+			Code:        b.code,
+			Message:     b.message,
+			RetryAfter:  b.retryAfter,
+		},
+		Timestamp: time.Now().UTC(),
+	})
+}
+
 // ── helpers ──────────────────────────────────────────────────────────────────
 
 func mustUserID(c *gin.Context) int64 {
@@ -846,13 +1055,16 @@ func parseID(c *gin.Context, param string) (int64, bool) {
 
 func channelToDTO(ch repository.Channel) dto.ChannelResponse {
 	return dto.ChannelResponse{
-		ID:          ch.ID,
-		Slug:        ch.Slug,
-		Name:        ch.Name,
-		Description: ch.Description,
-		IsPrivate:   ch.IsPrivate,
-		IsDM:        ch.IsDM,
-		CreatedAt:   ch.CreatedAt,
+		ID:              ch.ID,
+		Slug:            ch.Slug,
+		Name:            ch.Name,
+		Description:     ch.Description,
+		IsPrivate:       ch.IsPrivate,
+		IsDM:            ch.IsDM,
+		SlowModeSeconds: ch.SlowMode,
+		CourseID:        ch.CourseID,
+		ArchivedAt:      ch.ArchivedAt,
+		CreatedAt:       ch.CreatedAt,
 	}
 }
 
@@ -868,6 +1080,7 @@ func messageToDTO(m repository.Message) dto.MessageResponse {
 		IsDeleted:        m.IsDeleted,
 		IsEdited:         m.IsEdited,
 		ParentID:         m.ParentID,
+		ThreadRootID:     m.ThreadRootID,
 		ParentSenderName: m.ParentSenderName,
 		ParentBody:       m.ParentBody,
 		CreatedAt:        m.CreatedAt,
@@ -890,10 +1103,13 @@ func messagepayload(m *repository.Message) hub.MessagePayload {
 		SenderName:   m.SenderName,
 		SenderAvatar: m.SenderAvatar,
 		Body:         m.Body,
+		IsDeleted:    m.IsDeleted,
 		IsEdited:     m.IsEdited,
+		CreatedAt:    m.CreatedAt,
 	}
 	if m.ParentID != nil {
 		p.ParentID = m.ParentID
+		p.ThreadRootID = m.ThreadRootID
 		p.ParentSenderName = m.ParentSenderName
 		p.ParentBody = m.ParentBody
 	}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"chat-service/internal/dto"
+	"chat-service/internal/repository"
+	"chat-service/pkg/hub"
+	"chat-service/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mentionPreviewRunes is how much of the body an EventMention carries.
+const mentionPreviewRunes = 140
+
+// mentionPattern matches "@handle" where handle is an email or its local
+// part. The @ must start a word, so emails in running text are not mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@([\p{L}\p{N}_.+\-]+(?:@[\p{L}\p{N}\-]+(?:\.[\p{L}\p{N}\-]+)+)?)`)
+
+// parseMentions returns the lower-cased user handles in body and whether it
+// mentions @channel or @here.
+func parseMentions(body string) (handles []string, channel, here bool) {
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		handle := strings.ToLower(strings.TrimRight(m[1], ".-"))
+		switch handle {
+		case "":
+			continue
+		case "channel":
+			channel = true
+		case "here":
+			here = true
+		default:
+			if !seen[handle] {
+				seen[handle] = true
+				handles = append(handles, handle)
+			}
+		}
+	}
+	return handles, channel, here
+}
+
+// syncMentions stores who msg mentions and sends EventMention to everyone it
+// did not mention before, so an edit only notifies newly added users.
+// @channel and @here are honoured only when the sender can write to the
+// channel. Failures are logged: the message itself is already stored.
+func (h *ChatHandler) syncMentions(ctx context.Context, msg *repository.Message, canWrite, edited bool) {
+	handles, all, here := parseMentions(msg.Body)
+
+	mentions := make(map[int64]string)
+	if canWrite && (all || here) {
+		audience, err := h.chatRepo.ChannelAudience(ctx, msg.ChannelID)
+		if err != nil {
+			logger.Errorf("mention audience channel=%d: %v", msg.ChannelID, err)
+		}
+		var online map[int64]bool
+		if !all && len(audience) > 0 {
+			if online, err = h.hub.OnlineUsers(ctx, audience); err != nil {
+				logger.Warnf("mention presence channel=%d: %v", msg.ChannelID, err)
+			}
+		}
+		for _, id := range audience {
+			switch {
+			case all:
+				mentions[id] = repository.MentionChannel
+			case online[id]:
+				mentions[id] = repository.MentionHere
+			}
+		}
+	}
+	userIDs, err := h.chatRepo.ResolveMentionHandles(ctx, msg.ChannelID, handles)
+	if err != nil {
+		logger.Errorf("resolve mentions msgID=%d: %v", msg.ID, err)
+	}
+	for _, id := range userIDs {
+		mentions[id] = repository.MentionUser
+	}
+	delete(mentions, msg.SenderID)
+	if len(mentions) == 0 && !edited {
+		return
+	}
+
+	added, err := h.chatRepo.ReplaceMentions(ctx, msg.ID, msg.ChannelID, mentions)
+	if err != nil {
+		logger.Errorf("store mentions msgID=%d: %v", msg.ID, err)
+		return
+	}
+
+	preview := msg.Body
+	if utf8.RuneCountInString(preview) > mentionPreviewRunes {
+		preview = string([]rune(preview)[:mentionPreviewRunes]) + "…"
+	}
+	for _, userID := range added {
+		event := hub.WSEvent{
+			Type:      hub.EventMention,
+			ChannelID: msg.ChannelID,
+			Payload: hub.MentionPayload{
+				MessageID:  msg.ID,
+				SenderID:   msg.SenderID,
+				SenderName: msg.SenderName,
+				Kind:       mentions[userID],
+				Preview:    preview,
+			},
+			Timestamp: time.Now().UTC(),
+		}
+		if err := h.hub.PublishToMember(ctx, msg.ChannelID, userID, event); err != nil {
+			logger.Warnf("publish mention user=%d: %v", userID, err)
+		}
+	}
+}
+
+// ─── ListMentions GET /api/v1/chat/mentions?before_id=<msgID>&limit=<n> ───────
+// Mentions in channels the caller can no longer read are left out.
+
+func (h *ChatHandler) ListMentions(c *gin.Context) {
+	userID := mustUserID(c)
+	roles := mustRoles(c)
+
+	beforeID := int64(0)
+	if s := c.Query("before_id"); s != "" {
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			beforeID = v
+		}
+	}
+	limit := 50
+	if s := c.Query("limit"); s != "" {
+		if v, _ := strconv.Atoi(s); v > 0 && v <= 100 {
+			limit = v
+		}
+	}
+
+	mentions, err := h.chatRepo.ListMentions(c.Request.Context(), userID, beforeID, limit)
+	if err != nil {
+		logger.Errorf("list mentions user=%d: %v", userID, err)
+		c.JSON(dto.ErrInternal("Failed to load mentions"))
+		return
+	}
+
+	resp := dto.MentionListResponse{
+		Mentions: make([]dto.MentionResponse, 0, len(mentions)),
+		HasMore:  len(mentions) == limit,
+	}
+	if resp.HasMore {
+		resp.NextCursor = mentions[len(mentions)-1].ID
+	}
+
+	access := make(map[int64]bool)
+	var visible []repository.Mention
+	for _, m := range mentions {
+		canRead, checked := access[m.ChannelID]
+		if !checked {
+			canRead, _, err = h.chatRepo.CanUserAccess(c.Request.Context(), m.ChannelID, userID, roles)
+			if err != nil {
+				logger.Warnf("mention access check channel=%d user=%d: %v", m.ChannelID, userID, err)
+			}
+			access[m.ChannelID] = canRead
+		}
+		if canRead {
+			visible = append(visible, m)
+		}
+	}
+
+	msgs := make([]dto.MessageResponse, len(visible))
+	for i, m := range visible {
+		msgs[i] = messageToDTO(m.Message)
+	}
+	h.decorateMessages(c.Request.Context(), 0, userID, msgs)
+	for i, m := range visible {
+		resp.Mentions = append(resp.Mentions, dto.MentionResponse{MessageResponse: msgs[i], Kind: m.Kind})
+	}
+
+	c.JSON(dto.OK(resp))
+}
+
+// ─── MuteChannel PUT /api/v1/chat/channels/:id/mute ───────────────────────────
+
+func (h *ChatHandler) MuteChannel(c *gin.Context) {
+	h.setMuted(c, true)
+}
+
+// ─── UnmuteChannel DELETE /api/v1/chat/channels/:id/mute ──────────────────────
+
+func (h *ChatHandler) UnmuteChannel(c *gin.Context) {
+	h.setMuted(c, false)
+}
+
+// setMuted mutes or unmutes a channel for the caller and tells their other
+// connections, so every open tab badges the channel the same way.
+func (h *ChatHandler) setMuted(c *gin.Context, muted bool) {
+	channelID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	userID := mustUserID(c)
+
+	canRead, _, err := h.chatRepo.CanUserAccess(c.Request.Context(), channelID, userID, mustRoles(c))
+	if err != nil || !canRead {
+		c.JSON(dto.ErrForbidden("You do not have access to this channel"))
+		return
+	}
+
+	if err := h.chatRepo.SetChannelMuted(c.Request.Context(), channelID, userID, muted); err != nil {
+		logger.Errorf("set muted=%t channel=%d user=%d: %v", muted, channelID, userID, err)
+		c.JSON(dto.ErrInternal("Failed to update channel mute"))
+		return
+	}
+
+	event := hub.WSEvent{
+		Type:      hub.EventMute,
+		ChannelID: channelID,
+		Payload:   hub.MutePayload{Muted: muted},
+		Timestamp: time.Now().UTC(),
+	}
+	if err := h.hub.PublishToUser(c.Request.Context(), userID, event); err != nil {
+		logger.Warnf("publish mute: %v", err)
+	}
+
+	c.JSON(dto.OK(gin.H{"muted": muted}))
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	cases := []struct {
+		body          string
+		handles       []string
+		channel, here bool
+	}{
+		{"hi @Alice and @bob.", []string{"alice", "bob"}, false, false},
+		{"@alice @ALICE @alice-", []string{"alice"}, false, false},
+		{"ping @Nguyen.Van@Uni.edu.vn please", []string{"nguyen.van@uni.edu.vn"}, false, false},
+		{"(@trần_thị)", []string{"trần_thị"}, false, false},
+		{"mail bob@example.com or a.b@c", nil, false, false},
+		{"@@alice and x@alice", nil, false, false},
+		{"@channel heads up, @here too", nil, true, true},
+		{"no mentions @", nil, false, false},
+	}
+	for _, tc := range cases {
+		handles, channel, here := parseMentions(tc.body)
+		if !reflect.DeepEqual(handles, tc.handles) || channel != tc.channel || here != tc.here {
+			t.Errorf("parseMentions(%q) = %q, %v, %v; want %q, %v, %v",
+				tc.body, handles, channel, here, tc.handles, tc.channel, tc.here)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"chat-service/internal/dto"
+	"chat-service/internal/repository"
+	"chat-service/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// postBlock explains why a user may not post in a channel right now.
+type postBlock struct {
+	code       string // "muted", "slow_mode" or "internal_error"
+	message    string
+	retryAfter int // seconds, for slow_mode
+}
+
+// checkPosting enforces moderation mutes and, when preCheckSlowMode is set,
+// slow mode ahead of a send that is expensive to reject late. Message
+// inserts enforce slow mode themselves (see slowModeApplies). Admins are
+// exempt from both. Returns nil when the user may post.
+func (h *ChatHandler) checkPosting(
+	ctx context.Context,
+	channelID, userID int64,
+	roles []string,
+	preCheckSlowMode bool,
+) *postBlock {
+	if hasRole(roles, "ADMIN") {
+		return nil
+	}
+
+	mute, err := h.chatRepo.ActiveSanction(ctx, channelID, userID, repository.SanctionMute)
+	if err != nil {
+		logger.Errorf("mute check channel=%d user=%d: %v", channelID, userID, err)
+		return &postBlock{code: "internal_error", message: "Failed to send message"}
+	}
+	if mute != nil {
+		msg := "You are muted in this channel"
+		if mute.ExpiresAt != nil {
+			msg = fmt.Sprintf("You are muted in this channel until %s", mute.ExpiresAt.UTC().Format(time.RFC3339))
+		}
+		return &postBlock{code: "muted", message: msg}
+	}
+
+	if !preCheckSlowMode {
+		return nil
+	}
+	wait, err := h.chatRepo.SlowModeWait(ctx, channelID, userID)
+	if err != nil {
+		logger.Errorf("slow mode check channel=%d user=%d: %v", channelID, userID, err)
+		return &postBlock{code: "internal_error", message: "Failed to send message"}
+	}
+	if wait > 0 {
+		return slowModeBlock(wait)
+	}
+	return nil
+}
+
+// slowModeApplies reports whether a send by a user with roles counts
+// against slow mode; admins are exempt.
+func slowModeApplies(roles []string) bool {
+	return !hasRole(roles, "ADMIN")
+}
+
+// slowModeBlock tells the sender how long to wait.
+func slowModeBlock(wait time.Duration) *postBlock {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	return &postBlock{
+		code:       "slow_mode",
+		message:    fmt.Sprintf("Slow mode is on, you can post again in %ds", seconds),
+		retryAfter: seconds,
+	}
+}
+
+// asSlowModeBlock returns the postBlock for a *repository.SlowModeError,
+// or nil for any other error.
+func asSlowModeBlock(err error) *postBlock {
+	var slow *repository.SlowModeError
+	if errors.As(err, &slow) {
+		return slowModeBlock(slow.Wait)
+	}
+	return nil
+}
+
+// respondPostBlock writes b as a REST error: 403 for a mute, 429 with
+// Retry-After for slow mode.
+func respondPostBlock(c *gin.Context, b *postBlock) {
+	switch b.code {
+	case "muted":
+		c.JSON(dto.Err(http.StatusForbidden, b.code, b.message))
+	case "slow_mode":
+		c.Header("Retry-After", strconv.Itoa(b.retryAfter))
+		c.JSON(dto.Err(http.StatusTooManyRequests, b.code, b.message))
+	default:
+		c.JSON(dto.ErrInternal(b.message))
+	}
+}
+
+// ─── ReportMessage POST /api/v1/chat/channels/:id/messages/:msgId/report ──────
+// Reporting the same message twice is a no-op.
+
+func (h *ChatHandler) ReportMessage(c *gin.Context) {
+	channelID, msgID, ok := h.parseChannelMessage(c, false)
+	if !ok {
+		return
+	}
+	userID := mustUserID(c)
+
+	var req dto.ReportMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(dto.ErrBadRequest(err.Error()))
+		return
+	}
+
+	msg, err := h.chatRepo.GetMessage(c.Request.Context(), msgID)
+	if err != nil || msg == nil || msg.IsDeleted {
+		c.JSON(dto.ErrNotFound("Message not found"))
+		return
+	}
+	if msg.SenderID == userID {
+		c.JSON(dto.ErrForbidden("You cannot report your own message"))
+		return
+	}
+
+	created, err := h.chatRepo.CreateReport(c.Request.Context(), channelID, msgID, userID, req.Reason)
+	if err != nil {
+		logger.Errorf("report message msgID=%d user=%d: %v", msgID, userID, err)
+		c.JSON(dto.ErrInternal("Failed to report message"))
+		return
+	}
+	if created {
+		logger.Infof("message reported msgID=%d channel=%d reporter=%d", msgID, channelID, userID)
+	}
+
+	c.JSON(dto.OK(gin.H{"reported": true}))
+}
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"chat-service/internal/dto"
+	"chat-service/internal/repository"
+	"chat-service/pkg/hub"
+	"chat-service/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ─── ListPins GET /api/v1/chat/channels/:id/pins ──────────────────────────────
+
+func (h *ChatHandler) ListPins(c *gin.Context) {
+	channelID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	userID := mustUserID(c)
+
+	canRead, _, err := h.chatRepo.CanUserAccess(c.Request.Context(), channelID, userID, mustRoles(c))
+	if err != nil || !canRead {
+		c.JSON(dto.ErrForbidden("You do not have access to this channel"))
+		return
+	}
+
+	pins, err := h.chatRepo.ListPins(c.Request.Context(), channelID)
+	if err != nil {
+		logger.Errorf("list pins channel=%d: %v", channelID, err)
+		c.JSON(dto.ErrInternal("Failed to load pinned messages"))
+		return
+	}
+
+	msgs := make([]dto.MessageResponse, len(pins))
+	for i, p := range pins {
+		msgs[i] = messageToDTO(p.Message)
+	}
+	h.decorateMessages(c.Request.Context(), channelID, userID, msgs)
+
+	resp := make([]dto.PinnedMessageResponse, len(pins))
+	for i, p := range pins {
+		resp[i] = dto.PinnedMessageResponse{MessageResponse: msgs[i], PinnedBy: p.PinnedBy, PinnedAt: p.PinnedAt}
+	}
+	c.JSON(dto.OK(resp))
+}
+
+// ─── PinMessage PUT /api/v1/chat/channels/:id/messages/:msgId/pin ────────────
+// Pins are shared by the whole channel, so only its writers (and admins) manage them.
+
+func (h *ChatHandler) PinMessage(c *gin.Context) {
+	channelID, msgID, ok := h.parseChannelMessage(c, true)
+	if !ok {
+		return
+	}
+	userID := mustUserID(c)
+
+	pinnedAt, created, err := h.chatRepo.PinMessage(c.Request.Context(), channelID, msgID, userID)
+	if errors.Is(err, repository.ErrPinLimit) {
+		c.JSON(dto.ErrBadRequest(err.Error()))
+		return
+	}
+	if err != nil {
+		logger.Errorf("pin message channel=%d msgID=%d: %v", channelID, msgID, err)
+		c.JSON(dto.ErrInternal("Failed to pin message"))
+		return
+	}
+
+	if created {
+		event := hub.WSEvent{
+			Type:      hub.EventPin,
+			ChannelID: channelID,
+			Payload:   hub.PinPayload{MessageID: msgID, PinnedBy: userID, PinnedAt: pinnedAt},
+			Timestamp: time.Now().UTC(),
+		}
+		if err := h.hub.Publish(c.Request.Context(), channelID, event); err != nil {
+			logger.Warnf("publish pin: %v", err)
+		}
+	}
+
+	c.JSON(dto.OK(gin.H{"pinned": true}))
+}
+
+// ─── UnpinMessage DELETE /api/v1/chat/channels/:id/messages/:msgId/pin ────────
+
+func (h *ChatHandler) UnpinMessage(c *gin.Context) {
+	channelID, msgID, ok := h.parseChannelMessage(c, true)
+	if !ok {
+		return
+	}
+
+	removed, err := h.chatRepo.UnpinMessage(c.Request.Context(), channelID, msgID)
+	if err != nil {
+		logger.Errorf("unpin message channel=%d msgID=%d: %v", channelID, msgID, err)
+		c.JSON(dto.ErrInternal("Failed to unpin message"))
+		return
+	}
+
+	if removed {
+		event := hub.WSEvent{
+			Type:      hub.EventUnpin,
+			ChannelID: channelID,
+			Payload:   hub.PinPayload{MessageID: msgID},
+			Timestamp: time.Now().UTC(),
+		}
+		if err := h.hub.Publish(c.Request.Context(), channelID, event); err != nil {
+			logger.Warnf("publish unpin: %v", err)
+		}
+	}
+
+	c.JSON(dto.OK(gin.H{"pinned": false}))
+}
+
+// ─── SaveMessage PUT /api/v1/chat/channels/:id/messages/:msgId/save ───────────
+
+func (h *ChatHandler) SaveMessage(c *gin.Context) {
+	h.setSaved(c, true)
+}
+
+// ─── UnsaveMessage DELETE /api/v1/chat/channels/:id/messages/:msgId/save ──────
+
+func (h *ChatHandler) UnsaveMessage(c *gin.Context) {
+	h.setSaved(c, false)
+}
+
+// setSaved bookmarks or un-bookmarks a message and tells the caller's other
+// connections, so every open tab shows the same saved state.
+func (h *ChatHandler) setSaved(c *gin.Context, save bool) {
+	channelID, msgID, ok := h.parseChannelMessage(c, false)
+	if !ok {
+		return
+	}
+	userID := mustUserID(c)
+
+	var changed bool
+	var err error
+	if save {
+		changed, err = h.chatRepo.SaveMessage(c.Request.Context(), userID, msgID)
+	} else {
+		changed, err = h.chatRepo.UnsaveMessage(c.Request.Context(), userID, msgID)
+	}
+	if err != nil {
+		logger.Errorf("set saved=%t msgID=%d user=%d: %v", save, msgID, userID, err)
+		c.JSON(dto.ErrInternal("Failed to update saved messages"))
+		return
+	}
+
+	if changed {
+		event := hub.WSEvent{
+			Type:      hub.EventSaved,
+			ChannelID: channelID,
+			Payload:   hub.SavedPayload{MessageID: msgID, Saved: save},
+			Timestamp: time.Now().UTC(),
+		}
+		if err := h.hub.PublishToUser(c.Request.Context(), userID, event); err != nil {
+			logger.Warnf("publish saved: %v", err)
+		}
+	}
+
+	c.JSON(dto.OK(gin.H{"saved": save}))
+}
+
+// ─── ListSavedMessages GET /api/v1/chat/saved?before=<RFC3339>&limit=<n> ──────
+// Messages from channels the caller can no longer read are left out.
+
+func (h *ChatHandler) ListSavedMessages(c *gin.Context) {
+	userID := mustUserID(c)
+	roles := mustRoles(c)
+
+	var before time.Time
+	if s := c.Query("before"); s != "" {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			c.JSON(dto.ErrBadRequest("before must be an RFC 3339 timestamp"))
+			return
+		}
+		before = t
+	}
+	limit := 50
+	if s := c.Query("limit"); s != "" {
+		if v, _ := strconv.Atoi(s); v > 0 && v <= 100 {
+			limit = v
+		}
+	}
+
+	saved, err := h.chatRepo.ListSavedMessages(c.Request.Context(), userID, before, limit)
+	if err != nil {
+		logger.Errorf("list saved messages user=%d: %v", userID, err)
+		c.JSON(dto.ErrInternal("Failed to load saved messages"))
+		return
+	}
+
+	resp := dto.SavedMessageListResponse{
+		Messages: make([]dto.SavedMessageResponse, 0, len(saved)),
+		HasMore:  len(saved) == limit,
+	}
+	if resp.HasMore {
+		cursor := saved[len(saved)-1].SavedAt
+		resp.NextCursor = &cursor
+	}
+
+	access := make(map[int64]bool)
+	var visible []repository.SavedMessage
+	for _, s := range saved {
+		canRead, checked := access[s.ChannelID]
+		if !checked {
+			canRead, _, err = h.chatRepo.CanUserAccess(c.Request.Context(), s.ChannelID, userID, roles)
+			if err != nil {
+				logger.Warnf("saved access check channel=%d user=%d: %v", s.ChannelID, userID, err)
+			}
+			access[s.ChannelID] = canRead
+		}
+		if canRead {
+			visible = append(visible, s)
+		}
+	}
+
+	msgs := make([]dto.MessageResponse, len(visible))
+	for i, s := range visible {
+		msgs[i] = messageToDTO(s.Message)
+	}
+	h.decorateMessages(c.Request.Context(), 0, userID, msgs)
+	for i, s := range visible {
+		resp.Messages = append(resp.Messages, dto.SavedMessageResponse{MessageResponse: msgs[i], SavedAt: s.SavedAt})
+	}
+
+	c.JSON(dto.OK(resp))
+}
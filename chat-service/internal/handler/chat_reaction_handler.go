@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"chat-service/internal/dto"
+	"chat-service/internal/repository"
+	"chat-service/pkg/hub"
+	"chat-service/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ─── ToggleReaction POST /api/v1/chat/channels/:id/messages/:msgId/reactions ─
+// Anyone who can read the channel may react, so read-only announcement
+// channels still collect reactions.
+
+func (h *ChatHandler) ToggleReaction(c *gin.Context) {
+	channelID, msgID, ok := h.parseChannelMessage(c, false)
+	if !ok {
+		return
+	}
+	userID := mustUserID(c)
+
+	var req dto.ToggleReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(dto.ErrBadRequest(err.Error()))
+		return
+	}
+	emoji := strings.TrimSpace(req.Emoji)
+	if !validEmoji(emoji) {
+		c.JSON(dto.ErrBadRequest("emoji must be a single emoji or :shortcode: without spaces"))
+		return
+	}
+
+	added, count, err := h.chatRepo.ToggleReaction(c.Request.Context(), msgID, userID, emoji)
+	if errors.Is(err, repository.ErrReactionLimit) {
+		c.JSON(dto.ErrBadRequest(err.Error()))
+		return
+	}
+	if err != nil {
+		logger.Errorf("toggle reaction msgID=%d user=%d: %v", msgID, userID, err)
+		c.JSON(dto.ErrInternal("Failed to update reaction"))
+		return
+	}
+
+	event := hub.WSEvent{
+		Type:      hub.EventReaction,
+		ChannelID: channelID,
+		Payload: hub.ReactionPayload{
+			MessageID: msgID,
+			UserID:    userID,
+			Emoji:     emoji,
+			Added:     added,
+			Count:     count,
+		},
+		Timestamp: time.Now().UTC(),
+	}
+	if err := h.hub.Publish(c.Request.Context(), channelID, event); err != nil {
+		logger.Warnf("publish reaction: %v", err)
+	}
+
+	c.JSON(dto.OK(dto.ToggleReactionResponse{Emoji: emoji, Added: added, Count: count}))
+}
+
+// parseChannelMessage reads :id and :msgId, checks the caller may read (or,
+// with needWrite, write to) the channel and that the message belongs to it.
+// It writes the error response itself.
+func (h *ChatHandler) parseChannelMessage(c *gin.Context, needWrite bool) (channelID, msgID int64, ok bool) {
+	if channelID, ok = parseID(c, "id"); !ok {
+		return 0, 0, false
+	}
+	if msgID, ok = parseID(c, "msgId"); !ok {
+		return 0, 0, false
+	}
+
+	canRead, canWrite, err := h.chatRepo.CanUserAccess(c.Request.Context(), channelID, mustUserID(c), mustRoles(c))
+	if err != nil || !canRead || (needWrite && !canWrite) {
+		c.JSON(dto.ErrForbidden("You do not have access to this channel"))
+		return 0, 0, false
+	}
+
+	msgChannelID, err := h.chatRepo.GetMessageChannelID(c.Request.Context(), msgID)
+	if err != nil || msgChannelID != channelID {
+		c.JSON(dto.ErrNotFound("Message not found"))
+		return 0, 0, false
+	}
+	return channelID, msgID, true
+}
+
+// decorateMessages fills in reactions, the caller's saved flags and thread
+// reply counts, plus pin flags when all messages come from channelID (0 =
+// mixed channels). Failures leave the messages undecorated rather than
+// failing the request.
+func (h *ChatHandler) decorateMessages(ctx context.Context, channelID, userID int64, msgs []dto.MessageResponse) {
+	if len(msgs) == 0 {
+		return
+	}
+	ids := make([]int64, len(msgs))
+	for i, m := range msgs {
+		ids[i] = m.ID
+	}
+
+	reactions, err := h.chatRepo.ListReactions(ctx, ids, userID)
+	if err != nil {
+		logger.Warnf("list reactions user=%d: %v", userID, err)
+	}
+	saved, err := h.chatRepo.SavedMessageIDs(ctx, userID, ids)
+	if err != nil {
+		logger.Warnf("list saved ids user=%d: %v", userID, err)
+	}
+	var rootIDs []int64
+	for _, m := range msgs {
+		if m.ThreadRootID == nil {
+			rootIDs = append(rootIDs, m.ID)
+		}
+	}
+	threads, err := h.chatRepo.GetThreadStats(ctx, rootIDs)
+	if err != nil {
+		logger.Warnf("thread stats user=%d: %v", userID, err)
+	}
+	var pinned map[int64]bool
+	if channelID > 0 {
+		if pinned, err = h.chatRepo.PinnedMessageIDs(ctx, channelID); err != nil {
+			logger.Warnf("list pinned ids channel=%d: %v", channelID, err)
+		}
+	}
+
+	for i := range msgs {
+		m := &msgs[i]
+		for _, rc := range reactions[m.ID] {
+			m.Reactions = append(m.Reactions, dto.ReactionResponse{
+				Emoji:       rc.Emoji,
+				Count:       rc.Count,
+				ReactedByMe: rc.ReactedByMe,
+			})
+		}
+		m.IsSaved = saved[m.ID]
+		m.IsPinned = pinned[m.ID]
+		if st, ok := threads[m.ID]; ok {
+			lastReplyAt := st.LastReplyAt
+			m.ReplyCount = st.ReplyCount
+			m.LastReplyAt = &lastReplyAt
+		}
+	}
+}
+
+// validEmoji accepts one emoji (up to 16 code points, covering ZWJ
+// sequences, skin tones and keycaps) or a :shortcode: for custom emojis.
+// Plain words are rejected so reactions cannot carry arbitrary text.
+func validEmoji(s string) bool {
+	if s == "" || len(s) > 64 || !utf8.ValidString(s) {
+		return false
+	}
+	if strings.HasPrefix(s, ":") && strings.HasSuffix(s, ":") && len(s) > 2 {
+		for _, r := range s[1 : len(s)-1] {
+			if !(r == '_' || r == '-' || r == '+' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				return false
+			}
+		}
+		return true
+	}
+	if utf8.RuneCountInString(s) > 16 {
+		return false
+	}
+	for _, r := range s {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || unicode.IsLetter(r) || (unicode.IsDigit(r) && r > unicode.MaxASCII) {
+			return false
+		}
+	}
+	return true
+}
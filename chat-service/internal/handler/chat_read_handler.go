@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"chat-service/internal/dto"
+	"chat-service/internal/repository"
+	"chat-service/pkg/hub"
+	"chat-service/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readReceiptMaxMembers is the largest private channel whose members see each
+// other's read receipts. DMs always do; role-based public channels never do.
+const readReceiptMaxMembers = 20
+
+// ─── MarkRead POST /api/v1/chat/channels/:id/read ─────────────────────────────
+// REST counterpart of the WebSocket "ack" frame.
+
+func (h *ChatHandler) MarkRead(c *gin.Context) {
+	channelID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	userID := mustUserID(c)
+	roles := mustRoles(c)
+
+	canRead, _, err := h.chatRepo.CanUserAccess(c.Request.Context(), channelID, userID, roles)
+	if err != nil || !canRead {
+		c.JSON(dto.ErrForbidden("Cannot read this channel"))
+		return
+	}
+
+	// The body is optional: without a message ID the whole channel is read
+	var req dto.MarkReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(dto.ErrBadRequest(err.Error()))
+		return
+	}
+
+	lastReadID, advanced, err := h.advanceRead(c.Request.Context(), channelID, userID, req.MessageID)
+	if err != nil {
+		logger.Errorf("mark read channel=%d user=%d: %v", channelID, userID, err)
+		c.JSON(dto.ErrInternal("Failed to mark channel read"))
+		return
+	}
+
+	c.JSON(dto.OK(dto.MarkReadResponse{LastReadMessageID: lastReadID, Advanced: advanced}))
+}
+
+// ─── ListReadReceipts GET /api/v1/chat/channels/:id/reads ─────────────────────
+
+func (h *ChatHandler) ListReadReceipts(c *gin.Context) {
+	channelID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	userID := mustUserID(c)
+	roles := mustRoles(c)
+
+	canRead, _, err := h.chatRepo.CanUserAccess(c.Request.Context(), channelID, userID, roles)
+	if err != nil || !canRead {
+		c.JSON(dto.ErrForbidden("Cannot read this channel"))
+		return
+	}
+
+	enabled, err := h.readReceiptsEnabled(c.Request.Context(), channelID)
+	if err != nil {
+		logger.Errorf("read receipts check channel=%d: %v", channelID, err)
+		c.JSON(dto.ErrInternal("Failed to load read receipts"))
+		return
+	}
+	if !enabled {
+		c.JSON(dto.ErrBadRequest("Read receipts are only available in direct messages and small private channels"))
+		return
+	}
+
+	receipts, err := h.chatRepo.ListReadReceipts(c.Request.Context(), channelID)
+	if err != nil {
+		logger.Errorf("list read receipts channel=%d: %v", channelID, err)
+		c.JSON(dto.ErrInternal("Failed to load read receipts"))
+		return
+	}
+
+	resp := make([]dto.ReadReceiptResponse, len(receipts))
+	for i, rr := range receipts {
+		resp[i] = dto.ReadReceiptResponse{
+			UserID:            rr.UserID,
+			LastReadMessageID: rr.LastReadMessageID,
+			ReadAt:            rr.ReadAt,
+		}
+	}
+	c.JSON(dto.OK(resp))
+}
+
+// advanceRead moves userID's read pointer to messageID (or the newest message
+// when messageID is 0) and, when it moved in a DM or small private channel,
+// broadcasts a read receipt to the other members.
+func (h *ChatHandler) advanceRead(ctx context.Context, channelID, userID, messageID int64) (int64, bool, error) {
+	var (
+		readAt   time.Time
+		advanced bool
+		err      error
+	)
+	if messageID > 0 {
+		readAt, advanced, err = h.chatRepo.MarkRead(ctx, channelID, userID, messageID)
+	} else {
+		messageID, readAt, advanced, err = h.chatRepo.MarkChannelRead(ctx, channelID, userID)
+	}
+	if err != nil || !advanced {
+		return messageID, advanced, err
+	}
+
+	enabled, err := h.readReceiptsEnabled(ctx, channelID)
+	if err != nil {
+		// The pointer is saved; only the live receipt is lost
+		logger.Warnf("read receipts check channel=%d: %v", channelID, err)
+		return messageID, true, nil
+	}
+	if enabled {
+		event := hub.WSEvent{
+			Type:      hub.EventRead,
+			ChannelID: channelID,
+			Payload: hub.ReadReceiptPayload{
+				UserID:            userID,
+				LastReadMessageID: messageID,
+				ReadAt:            readAt,
+			},
+			Timestamp: time.Now().UTC(),
+		}
+		if err := h.hub.Publish(ctx, channelID, event); err != nil {
+			logger.Warnf("publish read receipt: %v", err)
+		}
+	}
+	return messageID, true, nil
+}
+
+// readReceiptsEnabled reports whether members of a channel see each other's
+// read pointers.
+func (h *ChatHandler) readReceiptsEnabled(ctx context.Context, channelID int64) (bool, error) {
+	ch, err := h.chatRepo.GetChannelByID(ctx, channelID)
+	if err != nil || ch == nil {
+		return false, err
+	}
+	if ch.IsDM {
+		return true, nil
+	}
+	if !ch.IsPrivate {
+		return false, nil
+	}
+	n, err := h.chatRepo.CountChannelUsers(ctx, channelID)
+	if err != nil {
+		return false, err
+	}
+	return n <= readReceiptMaxMembers, nil
+}
+
+// applyReadState copies the caller's unread and mention counts onto a channel.
+// A muted channel reports only its mentions as unread.
+func applyReadState(resp *dto.ChannelResponse, s repository.ReadState) {
+	resp.UnreadCount = s.Unread
+	if s.Muted {
+		resp.UnreadCount = s.Mentions
+	}
+	resp.Muted = s.Muted
+	resp.MentionCount = s.Mentions
+	resp.LastReadMessageID = s.LastReadMessageID
+}
@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"errors"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"chat-service/internal/dto"
+	"chat-service/internal/repository"
+	"chat-service/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// searchDateLayout is the format of after:/before:/on: dates, read as UTC days.
+const searchDateLayout = "2006-01-02"
+
+// ─── SearchMessages GET /api/v1/chat/search?q=<query>&before_id=<msgID>&limit=<n> ─
+// q is free text plus optional operators:
+//
+//	from:<email|name|me>  in:<#slug|name>  has:attachment
+//	after:YYYY-MM-DD  before:YYYY-MM-DD  on:YYYY-MM-DD
+//
+// from: matches a full email, its local part or the full name, ignoring
+// case; quote names with spaces, as in from:"Ann Lee".
+//
+// Only channels the caller can read are searched; admins are not shown other
+// people's direct messages.
+
+func (h *ChatHandler) SearchMessages(c *gin.Context) {
+	userID := mustUserID(c)
+	roles := mustRoles(c)
+
+	query, err := parseSearchQuery(c.Query("q"))
+	if err != nil {
+		c.JSON(dto.ErrBadRequest(err.Error()))
+		return
+	}
+	if query.from == "me" {
+		query.from = c.GetString("user_email")
+	}
+
+	beforeID := int64(0)
+	if s := c.Query("before_id"); s != "" {
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			beforeID = v
+		}
+	}
+	limit := 20
+	if s := c.Query("limit"); s != "" {
+		if v, _ := strconv.Atoi(s); v > 0 && v <= 100 {
+			limit = v
+		}
+	}
+
+	channels, err := h.chatRepo.ListAccessibleChannels(c.Request.Context(), userID, roles)
+	if err != nil {
+		logger.Errorf("search channels user=%d: %v", userID, err)
+		c.JSON(dto.ErrInternal("Failed to search messages"))
+		return
+	}
+	byID := make(map[int64]repository.Channel, len(channels))
+	var channelIDs []int64
+	for _, ch := range channels {
+		if len(query.in) > 0 && !matchesChannel(ch, query.in) {
+			continue
+		}
+		byID[ch.ID] = ch
+		channelIDs = append(channelIDs, ch.ID)
+	}
+
+	hits, err := h.chatRepo.SearchMessages(c.Request.Context(), repository.SearchFilter{
+		Text:          query.text,
+		ChannelIDs:    channelIDs,
+		From:          query.from,
+		HasAttachment: query.hasAttachment,
+		After:         query.after,
+		Before:        query.before,
+		BeforeID:      beforeID,
+		Limit:         limit,
+	})
+	if err != nil {
+		logger.Errorf("search messages user=%d q=%q: %v", userID, c.Query("q"), err)
+		c.JSON(dto.ErrInternal("Failed to search messages"))
+		return
+	}
+
+	msgs := make([]dto.MessageResponse, len(hits))
+	for i, hit := range hits {
+		msgs[i] = messageToDTO(hit.Message)
+	}
+	h.decorateMessages(c.Request.Context(), 0, userID, msgs)
+
+	resp := dto.SearchResponse{
+		Results: make([]dto.SearchResultResponse, len(hits)),
+		HasMore: len(hits) == limit,
+	}
+	if resp.HasMore {
+		resp.NextCursor = hits[len(hits)-1].ID
+	}
+	for i, hit := range hits {
+		ch := byID[hit.ChannelID]
+		resp.Results[i] = dto.SearchResultResponse{
+			MessageResponse: msgs[i],
+			ChannelSlug:     ch.Slug,
+			ChannelName:     ch.Name,
+			Snippet:         highlightSnippet(hit.Snippet),
+		}
+	}
+	c.JSON(dto.OK(resp))
+}
+
+// ─── GetMessageContext GET /api/v1/chat/channels/:id/messages/:msgId/context ──
+// Returns ?limit=<n> messages centred on :msgId so a search hit can be opened
+// in place; the cursors continue through ListMessages in either direction.
+
+func (h *ChatHandler) GetMessageContext(c *gin.Context) {
+	channelID, msgID, ok := h.parseChannelMessage(c, false)
+	if !ok {
+		return
+	}
+	userID := mustUserID(c)
+
+	limit := 50
+	if s := c.Query("limit"); s != "" {
+		if v, _ := strconv.Atoi(s); v > 0 && v <= 100 {
+			limit = v
+		}
+	}
+	// The older half includes the target itself
+	olderLimit := limit/2 + 1
+	newerLimit := limit - olderLimit
+	if newerLimit < 1 {
+		newerLimit = 1
+	}
+
+	older, err := h.chatRepo.ListMessages(c.Request.Context(), channelID, msgID+1, olderLimit, false)
+	if err != nil {
+		logger.Errorf("message context channel=%d msgID=%d: %v", channelID, msgID, err)
+		c.JSON(dto.ErrInternal("Failed to load messages"))
+		return
+	}
+	newer, err := h.chatRepo.ListMessagesAfter(c.Request.Context(), channelID, msgID, newerLimit)
+	if err != nil {
+		logger.Errorf("message context channel=%d msgID=%d: %v", channelID, msgID, err)
+		c.JSON(dto.ErrInternal("Failed to load messages"))
+		return
+	}
+
+	msgs := make([]dto.MessageResponse, 0, len(older)+len(newer))
+	for _, m := range older {
+		msgs = append(msgs, messageToDTO(m))
+	}
+	for _, m := range newer {
+		msgs = append(msgs, messageToDTO(m))
+	}
+	h.decorateMessages(c.Request.Context(), channelID, userID, msgs)
+
+	resp := dto.MessageContextResponse{
+		Messages:      msgs,
+		TargetID:      msgID,
+		HasMoreBefore: len(older) == olderLimit,
+		HasMoreAfter:  len(newer) == newerLimit,
+	}
+	if resp.HasMoreBefore {
+		resp.BeforeCursor = older[0].ID
+	}
+	if resp.HasMoreAfter {
+		resp.AfterCursor = newer[len(newer)-1].ID
+	}
+	c.JSON(dto.OK(resp))
+}
+
+// searchQuery is a parsed ?q= of SearchMessages.
+type searchQuery struct {
+	text          string
+	from          string
+	in            []string
+	hasAttachment bool
+	after         time.Time
+	before        time.Time
+}
+
+// parseSearchQuery splits operators out of q and leaves the remaining words
+// (quoted phrases kept intact) as the full-text query.
+func parseSearchQuery(q string) (searchQuery, error) {
+	var sq searchQuery
+	var words []string
+	for _, tok := range splitSearchTokens(q) {
+		key, value, isOp := strings.Cut(tok, ":")
+		if !isOp || value == "" || strings.HasPrefix(tok, "\"") {
+			words = append(words, tok)
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "from":
+			sq.from = strings.Trim(strings.TrimPrefix(value, "@"), "\"")
+		case "in":
+			sq.in = append(sq.in, strings.ToLower(strings.TrimPrefix(value, "#")))
+		case "has":
+			if !strings.EqualFold(value, "attachment") && !strings.EqualFold(value, "file") {
+				return sq, errors.New("has: only supports attachment")
+			}
+			sq.hasAttachment = true
+		case "after", "before", "on":
+			day, err := time.Parse(searchDateLayout, value)
+			if err != nil {
+				return sq, errors.New(key + ": dates must be YYYY-MM-DD")
+			}
+			switch strings.ToLower(key) {
+			case "after":
+				sq.after = day
+			case "before":
+				sq.before = day
+			default:
+				sq.after, sq.before = day, day.AddDate(0, 0, 1)
+			}
+		default:
+			words = append(words, tok) // e.g. a time like 10:30 or a URL
+		}
+	}
+	sq.text = strings.Join(words, " ")
+
+	if sq.text == "" && sq.from == "" && len(sq.in) == 0 && !sq.hasAttachment && sq.after.IsZero() && sq.before.IsZero() {
+		return sq, errors.New("q is required")
+	}
+	if len(sq.text) > 500 {
+		return sq, errors.New("q is too long")
+	}
+	return sq, nil
+}
+
+// splitSearchTokens splits q on whitespace, keeping "quoted phrases" whole.
+func splitSearchTokens(q string) []string {
+	var tokens []string
+	var cur strings.Builder
+	quoted := false
+	for _, r := range q {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			cur.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
+
+// matchesChannel reports whether an in: value names ch by slug or name.
+func matchesChannel(ch repository.Channel, names []string) bool {
+	for _, n := range names {
+		if strings.ToLower(ch.Slug) == n || strings.ToLower(ch.Name) == n {
+			return true
+		}
+	}
+	return false
+}
+
+// highlightSnippet escapes a search snippet and turns the repository's
+// highlight markers into <mark> tags, so clients can render it as HTML.
+func highlightSnippet(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, repository.SearchHighlightStart, "<mark>")
+	return strings.ReplaceAll(s, repository.SearchHighlightStop, "</mark>")
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"chat-service/internal/repository"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	sq, err := parseSearchQuery(`from:@Alice in:#General "meet at 10:30" has:file on:2026-03-01 https://x.io -tea`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := searchQuery{
+		text:          `"meet at 10:30" https://x.io -tea`,
+		from:          "Alice",
+		in:            []string{"general"},
+		hasAttachment: true,
+		after:         day,
+		before:        day.AddDate(0, 0, 1),
+	}
+	if !reflect.DeepEqual(sq, want) {
+		t.Errorf("parseSearchQuery = %+v;\nwant %+v", sq, want)
+	}
+
+	sq, err = parseSearchQuery("after:2026-03-01 before:2026-03-05")
+	if err != nil || !sq.after.Equal(day) || !sq.before.Equal(day.AddDate(0, 0, 4)) || sq.text != "" {
+		t.Errorf("date range = %+v, %v", sq, err)
+	}
+
+	sq, err = parseSearchQuery(`from:"Ann Lee" budget`)
+	if err != nil || sq.from != "Ann Lee" || sq.text != "budget" {
+		t.Errorf("quoted name = %+v, %v; want from Ann Lee", sq, err)
+	}
+
+	for _, q := range []string{"", "   ", "has:link", "on:yesterday"} {
+		if _, err := parseSearchQuery(q); err == nil {
+			t.Errorf("parseSearchQuery(%q) accepted", q)
+		}
+	}
+}
+
+func TestSplitSearchTokens(t *testing.T) {
+	got := splitSearchTokens(`  a "b  c"  d"e f"` + "\tg")
+	want := []string{"a", `"b  c"`, `d"e f"`, "g"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitSearchTokens = %q; want %q", got, want)
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	in := "<b>" + repository.SearchHighlightStart + "café" + repository.SearchHighlightStop + " & tea"
+	if got, want := highlightSnippet(in), "&lt;b&gt;<mark>café</mark> &amp; tea"; got != want {
+		t.Errorf("highlightSnippet = %q; want %q", got, want)
+	}
+}
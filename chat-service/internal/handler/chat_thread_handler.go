@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"io"
+	"strconv"
+
+	"chat-service/internal/dto"
+	"chat-service/internal/repository"
+	"chat-service/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ─── GetThread GET /api/v1/chat/channels/:id/threads/:msgId ──────────────────
+// :msgId may be the root or any reply; the whole thread is returned either
+// way. Replies page forward with ?after_id=<msgID>&limit=<n>.
+
+func (h *ChatHandler) GetThread(c *gin.Context) {
+	channelID, root, ok := h.resolveThreadRoot(c)
+	if !ok {
+		return
+	}
+	userID := mustUserID(c)
+	ctx := c.Request.Context()
+
+	afterID := int64(0)
+	if s := c.Query("after_id"); s != "" {
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			afterID = v
+		}
+	}
+	limit := 50
+	if s := c.Query("limit"); s != "" {
+		if v, _ := strconv.Atoi(s); v > 0 && v <= 100 {
+			limit = v
+		}
+	}
+
+	replies, err := h.chatRepo.ListThreadReplies(ctx, root.ID, afterID, limit)
+	if err != nil {
+		logger.Errorf("list thread replies root=%d: %v", root.ID, err)
+		c.JSON(dto.ErrInternal("Failed to load thread"))
+		return
+	}
+	participants, err := h.chatRepo.ListThreadParticipants(ctx, root.ID)
+	if err != nil {
+		logger.Errorf("list thread participants root=%d: %v", root.ID, err)
+		c.JSON(dto.ErrInternal("Failed to load thread"))
+		return
+	}
+	member, err := h.chatRepo.GetThreadMember(ctx, root.ID, userID)
+	if err != nil {
+		logger.Errorf("get thread member root=%d user=%d: %v", root.ID, userID, err)
+		c.JSON(dto.ErrInternal("Failed to load thread"))
+		return
+	}
+
+	// Root and replies are decorated together; the root carries the reply count
+	msgs := make([]dto.MessageResponse, 0, len(replies)+1)
+	msgs = append(msgs, messageToDTO(*root))
+	for _, m := range replies {
+		msgs = append(msgs, messageToDTO(m))
+	}
+	h.decorateMessages(ctx, channelID, userID, msgs)
+
+	resp := dto.ThreadResponse{
+		Root:            msgs[0],
+		Replies:         msgs[1:],
+		Participants:    make([]dto.UserResponse, len(participants)),
+		ReplyCount:      msgs[0].ReplyCount,
+		Following:       member.Following,
+		LastReadReplyID: member.LastReadReplyID,
+		HasMore:         len(replies) == limit,
+	}
+	if resp.HasMore {
+		resp.NextCursor = replies[len(replies)-1].ID
+	}
+	for i, u := range participants {
+		resp.Participants[i] = dto.UserResponse{
+			ID:             u.ID,
+			Email:          u.Email,
+			FullName:       u.FullName,
+			ProfilePicture: u.ProfilePicture,
+		}
+	}
+	c.JSON(dto.OK(resp))
+}
+
+// ─── FollowThread PUT /api/v1/chat/channels/:id/threads/:msgId/follow ─────────
+
+func (h *ChatHandler) FollowThread(c *gin.Context) {
+	h.setThreadFollow(c, true)
+}
+
+// ─── UnfollowThread DELETE /api/v1/chat/channels/:id/threads/:msgId/follow ────
+
+func (h *ChatHandler) UnfollowThread(c *gin.Context) {
+	h.setThreadFollow(c, false)
+}
+
+func (h *ChatHandler) setThreadFollow(c *gin.Context, follow bool) {
+	_, root, ok := h.resolveThreadRoot(c)
+	if !ok {
+		return
+	}
+	userID := mustUserID(c)
+
+	if err := h.chatRepo.SetThreadFollow(c.Request.Context(), root.ID, userID, follow); err != nil {
+		logger.Errorf("set thread follow=%t root=%d user=%d: %v", follow, root.ID, userID, err)
+		c.JSON(dto.ErrInternal("Failed to update thread follow"))
+		return
+	}
+	c.JSON(dto.OK(gin.H{"following": follow}))
+}
+
+// ─── MarkThreadRead POST /api/v1/chat/channels/:id/threads/:msgId/read ────────
+// Body {"message_id": <replyID>} is optional; without it every reply is read.
+
+func (h *ChatHandler) MarkThreadRead(c *gin.Context) {
+	_, root, ok := h.resolveThreadRoot(c)
+	if !ok {
+		return
+	}
+	userID := mustUserID(c)
+
+	var req dto.MarkReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(dto.ErrBadRequest(err.Error()))
+		return
+	}
+
+	lastRead, err := h.chatRepo.MarkThreadRead(c.Request.Context(), root.ID, userID, req.MessageID)
+	if err != nil {
+		logger.Errorf("mark thread read root=%d user=%d: %v", root.ID, userID, err)
+		c.JSON(dto.ErrInternal("Failed to mark thread read"))
+		return
+	}
+	c.JSON(dto.OK(gin.H{"last_read_reply_id": lastRead}))
+}
+
+// ─── ListFollowedThreads GET /api/v1/chat/threads?limit=<n> ───────────────────
+// Threads in channels the caller can no longer read are left out.
+
+func (h *ChatHandler) ListFollowedThreads(c *gin.Context) {
+	userID := mustUserID(c)
+	roles := mustRoles(c)
+
+	limit := 50
+	if s := c.Query("limit"); s != "" {
+		if v, _ := strconv.Atoi(s); v > 0 && v <= 100 {
+			limit = v
+		}
+	}
+
+	threads, err := h.chatRepo.ListFollowedThreads(c.Request.Context(), userID, limit)
+	if err != nil {
+		logger.Errorf("list followed threads user=%d: %v", userID, err)
+		c.JSON(dto.ErrInternal("Failed to load threads"))
+		return
+	}
+
+	access := make(map[int64]bool)
+	var visible []repository.FollowedThread
+	for _, t := range threads {
+		canRead, checked := access[t.Root.ChannelID]
+		if !checked {
+			canRead, _, err = h.chatRepo.CanUserAccess(c.Request.Context(), t.Root.ChannelID, userID, roles)
+			if err != nil {
+				logger.Warnf("thread access check channel=%d user=%d: %v", t.Root.ChannelID, userID, err)
+			}
+			access[t.Root.ChannelID] = canRead
+		}
+		if canRead {
+			visible = append(visible, t)
+		}
+	}
+
+	roots := make([]dto.MessageResponse, len(visible))
+	for i, t := range visible {
+		roots[i] = messageToDTO(t.Root)
+	}
+	h.decorateMessages(c.Request.Context(), 0, userID, roots)
+
+	resp := make([]dto.FollowedThreadResponse, len(visible))
+	for i, t := range visible {
+		resp[i] = dto.FollowedThreadResponse{
+			Root:        roots[i],
+			ReplyCount:  t.ReplyCount,
+			UnreadCount: t.Unread,
+			LastReplyAt: t.LastReplyAt,
+		}
+	}
+	c.JSON(dto.OK(resp))
+}
+
+// resolveThreadRoot checks read access to :id and loads the root of the
+// thread :msgId belongs to. It writes the error response itself.
+func (h *ChatHandler) resolveThreadRoot(c *gin.Context) (int64, *repository.Message, bool) {
+	channelID, msgID, ok := h.parseChannelMessage(c, false)
+	if !ok {
+		return 0, nil, false
+	}
+
+	rootID := msgID
+	msg, err := h.chatRepo.GetMessage(c.Request.Context(), msgID)
+	if err == nil && msg != nil && msg.ThreadRootID != nil {
+		rootID = *msg.ThreadRootID
+		msg, err = h.chatRepo.GetMessage(c.Request.Context(), rootID)
+	}
+	if err != nil {
+		logger.Errorf("load thread root msgID=%d: %v", msgID, err)
+		c.JSON(dto.ErrInternal("Failed to load thread"))
+		return 0, nil, false
+	}
+	if msg == nil {
+		c.JSON(dto.ErrNotFound("Message not found"))
+		return 0, nil, false
+	}
+	return channelID, msg, true
+}
@@ -1,11 +1,15 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
 	"chat-service/internal/dto"
+	"chat-service/pkg/jwks"
 	"chat-service/pkg/logger"
+	"chat-service/pkg/svcauth"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -19,10 +23,11 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-// Auth validates the JWT from Authorization header or authToken cookie.
-// Sets ctx keys: user_id (int64), user_email (string), user_roles ([]string),
+// Auth validates the JWT from Authorization header or authToken cookie
+// against the auth service's JWKS (or the shared HMAC secret while the
+// migration window is open). Sets ctx keys: user_id (int64), user_email (string), user_roles ([]string),
 // user_role (string - primary role).
-func Auth(jwtSecret string) gin.HandlerFunc {
+func Auth(verifier *jwks.Verifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenStr := extractToken(c)
 		if tokenStr == "" {
@@ -33,7 +38,7 @@ func Auth(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		claims, err := parseToken(tokenStr, jwtSecret)
+		claims, err := parseToken(c.Request.Context(), tokenStr, verifier)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, dto.APIResponse{
 				Error: &dto.APIError{Code: "unauthorized", Message: "Invalid or expired token"},
@@ -76,21 +81,47 @@ func RequireAdmin() gin.HandlerFunc {
 	}
 }
 
-// SyncSecret validates that the caller supplies the correct X-Sync-Secret header.
-// Used exclusively on the /sync/* endpoints called by auth-service.
-func SyncSecret(secret string) gin.HandlerFunc {
+// ScopeSync is the scope auth-service needs for the /sync/* endpoints
+const ScopeSync = "sync"
+
+// ServiceAuth admits only internal services holding scope. Requests are
+// HMAC-signed per calling service and their nonces claimed in Redis; the
+// static X-Sync-Secret header is accepted only during the migration.
+func ServiceAuth(services *svcauth.Verifier, scope string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		provided := c.GetHeader("X-Sync-Secret")
-		if provided == "" {
-			provided = c.GetHeader("X-API-Secret")
+		caller, err := services.Verify(c.Request)
+		if err != nil {
+			status, code, msg := http.StatusUnauthorized, "unauthorized", "Invalid service credentials"
+			switch {
+			case errors.Is(err, svcauth.ErrNoCredentials):
+				msg = "Missing service credentials"
+			case errors.Is(err, svcauth.ErrReplay):
+				code, msg = "replayed_request", "Request nonce has already been used"
+			case errors.Is(err, svcauth.ErrBodyTooLarge):
+				status, code, msg = http.StatusRequestEntityTooLarge, "payload_too_large", "Request body too large"
+			case errors.Is(err, svcauth.ErrUnknownService), errors.Is(err, svcauth.ErrBadSignature),
+				errors.Is(err, svcauth.ErrStale), errors.Is(err, svcauth.ErrMalformed):
+				logger.Warnf("rejected service request to %s: %v", c.Request.URL.Path, err)
+			default:
+				logger.Errorf("verify service request: %v", err)
+				status, code, msg = http.StatusServiceUnavailable, "service_unavailable", "Unable to verify service credentials"
+			}
+			c.JSON(status, dto.APIResponse{Error: &dto.APIError{Code: code, Message: msg}})
+			c.Abort()
+			return
 		}
-		if provided != secret || secret == "" {
-			c.JSON(http.StatusUnauthorized, dto.APIResponse{
-				Error: &dto.APIError{Code: "unauthorized", Message: "Invalid sync secret"},
+		if !caller.HasScope(scope) {
+			logger.Warnf("service %s lacks scope %q for %s", caller.Service, scope, c.Request.URL.Path)
+			c.JSON(http.StatusForbidden, dto.APIResponse{
+				Error: &dto.APIError{Code: "forbidden", Message: "Service is not allowed to call this endpoint"},
 			})
 			c.Abort()
 			return
 		}
+		if caller.Legacy {
+			logger.Warnf("service %s used a legacy static secret for %s", caller.Service, c.Request.URL.Path)
+		}
+		c.Set("service_name", caller.Service)
 		c.Next()
 	}
 }
@@ -98,7 +129,7 @@ func SyncSecret(secret string) gin.HandlerFunc {
 // ParseTokenFromQuery extracts and validates a JWT from the ?token= query param.
 // Used for WebSocket upgrade requests (browsers cannot set custom headers on WS).
 // Returns Claims and true on success; writes HTTP error and returns false on failure.
-func ParseTokenFromQuery(c *gin.Context, jwtSecret string) (*Claims, bool) {
+func ParseTokenFromQuery(c *gin.Context, verifier *jwks.Verifier) (*Claims, bool) {
 	tokenStr := c.Query("token")
 	if tokenStr == "" {
 		c.JSON(http.StatusUnauthorized, dto.APIResponse{
@@ -107,7 +138,7 @@ func ParseTokenFromQuery(c *gin.Context, jwtSecret string) (*Claims, bool) {
 		return nil, false
 	}
 
-	claims, err := parseToken(tokenStr, jwtSecret)
+	claims, err := parseToken(c.Request.Context(), tokenStr, verifier)
 	if err != nil {
 		logger.Warnf("ws: invalid token: %v", err)
 		c.JSON(http.StatusUnauthorized, dto.APIResponse{
@@ -135,13 +166,8 @@ func extractToken(c *gin.Context) string {
 	return ""
 }
 
-func parseToken(tokenStr, secret string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(secret), nil
-	})
+func parseToken(ctx context.Context, tokenStr string, verifier *jwks.Verifier) (*Claims, error) {
+	token, err := verifier.Parse(ctx, tokenStr, &Claims{})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,499 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// CourseMember is one role a user holds in an LMS course: CREATOR,
+// CO_TEACHER or STUDENT, or GROUP_MEMBER in one of its groups.
+type CourseMember struct {
+	UserID int64
+	Role   string
+}
+
+// Course staff hold their role in the course's group channels as well.
+const courseStaffRoles = `('CREATOR', 'CO_TEACHER')`
+
+// ─── Course Channels ──────────────────────────────────────────────────────────
+
+// ProvisionCourseChannel returns the private channel of courseID, creating
+// it on first use and otherwise renaming and unarchiving it. created
+// reports whether it is new, restored whether it had been archived.
+func (r *ChatRepository) ProvisionCourseChannel(
+	ctx context.Context,
+	courseID int64,
+	name string,
+	createdBy int64,
+) (ch *Channel, created, restored bool, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	ch = &Channel{}
+	err = tx.QueryRowContext(ctx, `
+		WITH prev AS (
+			SELECT archived_at FROM chat_channels WHERE course_id = $4 AND course_group_id IS NULL
+		)
+		INSERT INTO chat_channels (slug, name, description, is_private, is_dm, created_by, course_id)
+		VALUES ($1, $2, '', true, false, $3, $4)
+		ON CONFLICT (course_id) WHERE course_id IS NOT NULL AND course_group_id IS NULL
+		DO UPDATE SET name = EXCLUDED.name, archived_at = NULL
+		RETURNING id, slug, name, description, is_private, is_dm, slow_mode_seconds, course_id, archived_at, created_by, created_at,
+		          (xmax = 0), COALESCE((SELECT archived_at IS NOT NULL FROM prev), false)
+	`, fmt.Sprintf("course:%d", courseID), name, createdBy, courseID).Scan(
+		&ch.ID, &ch.Slug, &ch.Name, &ch.Description,
+		&ch.IsPrivate, &ch.IsDM, &ch.SlowMode, &ch.CourseID, &ch.ArchivedAt, &ch.CreatedBy, &ch.CreatedAt,
+		&created, &restored,
+	)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("provision course channel: %w", err)
+	}
+
+	if err := seedAdminRole(ctx, tx, ch.ID); err != nil {
+		return nil, false, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, false, err
+	}
+	return ch, created, restored, nil
+}
+
+// ProvisionCourseGroupChannel returns the private channel of groupID in
+// courseID, creating it on first use and otherwise renaming and
+// unarchiving it. Its name is the course channel's followed by groupName,
+// which must leave room for it, and it is created by the same user. Returns a nil channel when the course has no channel or it is
+// archived, since a group channel cannot outlive its course's.
+func (r *ChatRepository) ProvisionCourseGroupChannel(
+	ctx context.Context,
+	courseID, groupID int64,
+	groupName string,
+) (ch *Channel, created, restored bool, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	ch = &Channel{}
+	err = tx.QueryRowContext(ctx, `
+		WITH course AS (
+			SELECT name, created_by FROM chat_channels
+			WHERE course_id = $2 AND course_group_id IS NULL AND archived_at IS NULL
+		), prev AS (
+			SELECT archived_at FROM chat_channels WHERE course_group_id = $3
+		)
+		INSERT INTO chat_channels (slug, name, description, is_private, is_dm, created_by, course_id, course_group_id)
+		SELECT $1, LEFT(course.name, 117 - char_length($4)) || ' / ' || $4, '', true, false, course.created_by, $2, $3
+		FROM course
+		ON CONFLICT (course_group_id) WHERE course_group_id IS NOT NULL
+		DO UPDATE SET name = EXCLUDED.name, archived_at = NULL
+		RETURNING id, slug, name, description, is_private, is_dm, slow_mode_seconds, course_id, archived_at, created_by, created_at,
+		          (xmax = 0), COALESCE((SELECT archived_at IS NOT NULL FROM prev), false)
+	`, fmt.Sprintf("course:%d:group:%d", courseID, groupID), courseID, groupID, groupName).Scan(
+		&ch.ID, &ch.Slug, &ch.Name, &ch.Description,
+		&ch.IsPrivate, &ch.IsDM, &ch.SlowMode, &ch.CourseID, &ch.ArchivedAt, &ch.CreatedBy, &ch.CreatedAt,
+		&created, &restored,
+	)
+	if err == sql.ErrNoRows {
+		return nil, false, false, nil
+	}
+	if err != nil {
+		return nil, false, false, fmt.Errorf("provision course group channel: %w", err)
+	}
+
+	if err := seedAdminRole(ctx, tx, ch.ID); err != nil {
+		return nil, false, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, false, err
+	}
+	return ch, created, restored, nil
+}
+
+// seedAdminRole lets admins read and configure a course channel, like any
+// private channel
+func seedAdminRole(ctx context.Context, tx *sql.Tx, channelID int64) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO chat_channel_roles (channel_id, role_name, can_read, can_write)
+		VALUES ($1, 'ADMIN', true, true)
+		ON CONFLICT DO NOTHING
+	`, channelID); err != nil {
+		return fmt.Errorf("seed role ADMIN: %w", err)
+	}
+	return nil
+}
+
+// ArchiveCourseChannel makes the channel of courseID and those of its
+// groups read-only. Returns the channels it archived; those already
+// archived are left out.
+func (r *ChatRepository) ArchiveCourseChannel(ctx context.Context, courseID int64) ([]int64, error) {
+	return r.archiveChannels(ctx, `
+		UPDATE chat_channels SET archived_at = NOW()
+		WHERE course_id = $1 AND archived_at IS NULL
+		RETURNING id
+	`, courseID)
+}
+
+// ArchiveCourseGroupChannel makes the channel of groupID in courseID
+// read-only. Returns its ID, or 0 when the group has no channel or it is
+// already archived.
+func (r *ChatRepository) ArchiveCourseGroupChannel(ctx context.Context, courseID, groupID int64) (int64, error) {
+	ids, err := r.archiveChannels(ctx, `
+		UPDATE chat_channels SET archived_at = NOW()
+		WHERE course_id = $1 AND course_group_id = $2 AND archived_at IS NULL
+		RETURNING id
+	`, courseID, groupID)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// ArchiveStaleGroupChannels makes the channels of courseID's groups other
+// than groupIDs read-only, for groups deleted while their events were
+// missed. Returns the channels it archived.
+func (r *ChatRepository) ArchiveStaleGroupChannels(ctx context.Context, courseID int64, groupIDs []int64) ([]int64, error) {
+	keep := ""
+	args := []interface{}{courseID}
+	if len(groupIDs) > 0 {
+		placeholders, ids := idPlaceholders(groupIDs, 1)
+		keep = fmt.Sprintf(" AND course_group_id NOT IN (%s)", placeholders)
+		args = append(args, ids...)
+	}
+	return r.archiveChannels(ctx, `
+		UPDATE chat_channels SET archived_at = NOW()
+		WHERE course_id = $1 AND course_group_id IS NOT NULL AND archived_at IS NULL`+keep+`
+		RETURNING id
+	`, args...)
+}
+
+func (r *ChatRepository) archiveChannels(ctx context.Context, query string, args ...interface{}) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// courseRole is a role held on one channel of a course
+type courseRole struct {
+	channelID int64
+	role      string
+}
+
+// courseRoleTargets returns where a change to role in courseID lands.
+// Changes to a group (groupID > 0) land on its channel only. Course staff
+// roles also land on every group channel, and a student leaving the course
+// leaves its groups too. Returns nothing when the course or group has no
+// channel.
+func (r *ChatRepository) courseRoleTargets(ctx context.Context, courseID, groupID int64, role string, removing bool) ([]courseRole, error) {
+	cond := "course_group_id IS NULL"
+	args := []interface{}{courseID}
+	switch {
+	case groupID > 0:
+		cond = "course_group_id = $2"
+		args = append(args, groupID)
+	case role != "STUDENT" || removing:
+		cond = "true"
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, course_group_id IS NOT NULL FROM chat_channels
+		WHERE course_id = $1 AND `+cond+`
+		ORDER BY course_group_id NULLS FIRST
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []courseRole
+	for rows.Next() {
+		var t courseRole
+		var isGroup bool
+		if err := rows.Scan(&t.channelID, &isGroup); err != nil {
+			return nil, err
+		}
+		t.role = role
+		if isGroup && groupID == 0 && role == "STUDENT" {
+			t.role = "GROUP_MEMBER"
+		}
+		targets = append(targets, t)
+	}
+	return targets, rows.Err()
+}
+
+// ─── Course Members ───────────────────────────────────────────────────────────
+
+// SyncCourseMembers makes members the complete set of course roles on
+// channelID and puts exactly those users on its whitelist. Whitelist
+// entries added by hand are left alone. Returns the users whose access
+// changed.
+func (r *ChatRepository) SyncCourseMembers(ctx context.Context, channelID int64, members []CourseMember) ([]int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	changed, err := syncCourseMembers(ctx, tx, channelID, members)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// SyncCourseGroupMembers makes studentIDs the GROUP_MEMBER roles on the
+// group channel channelID, next to the staff roles of courseID's channel,
+// like SyncCourseMembers.
+func (r *ChatRepository) SyncCourseGroupMembers(ctx context.Context, courseID, channelID int64, studentIDs []int64) ([]int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT m.user_id, m.role
+		FROM chat_course_members m
+		JOIN chat_channels c ON c.id = m.channel_id
+		WHERE c.course_id = $1 AND c.course_group_id IS NULL AND m.role IN `+courseStaffRoles+`
+	`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	var members []CourseMember
+	for rows.Next() {
+		var m CourseMember
+		if err := rows.Scan(&m.UserID, &m.Role); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, id := range studentIDs {
+		members = append(members, CourseMember{UserID: id, Role: "GROUP_MEMBER"})
+	}
+
+	changed, err := syncCourseMembers(ctx, tx, channelID, members)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+func syncCourseMembers(ctx context.Context, tx *sql.Tx, channelID int64, members []CourseMember) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM chat_course_members WHERE channel_id = $1 RETURNING user_id
+	`, channelID)
+	if err != nil {
+		return nil, err
+	}
+	previous := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		previous[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	current := make(map[int64]bool)
+	var userIDs []int64
+	for _, m := range members {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO chat_course_members (channel_id, user_id, role)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING
+		`, channelID, m.UserID, m.Role); err != nil {
+			return nil, fmt.Errorf("insert course member: %w", err)
+		}
+		if !current[m.UserID] {
+			current[m.UserID] = true
+			userIDs = append(userIDs, m.UserID)
+		}
+	}
+
+	changed, err := whitelistUsers(ctx, tx, channelID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	for id := range previous {
+		if current[id] {
+			continue
+		}
+		removed, err := unlistUser(ctx, tx, channelID, id)
+		if err != nil {
+			return nil, err
+		}
+		if removed {
+			changed = append(changed, id)
+		}
+	}
+	return changed, nil
+}
+
+// AddCourseMembers gives userIDs role in courseID's channel, or in the
+// channel of its group groupID when that is set. Staff roles are given in
+// the course's group channels too. Returns the users who gained access by
+// channel; courses and groups without a channel yet store nothing.
+func (r *ChatRepository) AddCourseMembers(ctx context.Context, courseID, groupID int64, role string, userIDs []int64) (map[int64][]int64, error) {
+	targets, err := r.courseRoleTargets(ctx, courseID, groupID, role, false)
+	if err != nil || len(targets) == 0 {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	changed := make(map[int64][]int64, len(targets))
+	for _, t := range targets {
+		for _, userID := range userIDs {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO chat_course_members (channel_id, user_id, role)
+				VALUES ($1, $2, $3)
+				ON CONFLICT DO NOTHING
+			`, t.channelID, userID, t.role); err != nil {
+				return nil, fmt.Errorf("insert course member: %w", err)
+			}
+		}
+		added, err := whitelistUsers(ctx, tx, t.channelID, userIDs)
+		if err != nil {
+			return nil, err
+		}
+		changed[t.channelID] = added
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// RemoveCourseMembers takes role away from userIDs in courseID's channel,
+// or in the channel of its group groupID when that is set; those left with
+// no role on a channel lose access to it. Staff roles are taken away in the
+// course's group channels too, and students leaving the course leave its
+// groups. Returns the users who lost access by channel.
+func (r *ChatRepository) RemoveCourseMembers(ctx context.Context, courseID, groupID int64, role string, userIDs []int64) (map[int64][]int64, error) {
+	targets, err := r.courseRoleTargets(ctx, courseID, groupID, role, true)
+	if err != nil || len(targets) == 0 {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	changed := make(map[int64][]int64, len(targets))
+	for _, t := range targets {
+		for _, userID := range userIDs {
+			var stillMember bool
+			if err := tx.QueryRowContext(ctx, `
+				WITH gone AS (
+					DELETE FROM chat_course_members
+					WHERE channel_id = $1 AND user_id = $2 AND role = $3
+				)
+				SELECT EXISTS(
+					SELECT 1 FROM chat_course_members
+					WHERE channel_id = $1 AND user_id = $2 AND role <> $3
+				)
+			`, t.channelID, userID, t.role).Scan(&stillMember); err != nil {
+				return nil, fmt.Errorf("delete course member: %w", err)
+			}
+			if stillMember {
+				continue
+			}
+			removed, err := unlistUser(ctx, tx, t.channelID, userID)
+			if err != nil {
+				return nil, err
+			}
+			if removed {
+				changed[t.channelID] = append(changed[t.channelID], userID)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// ApplyCourseMemberships whitelists a newly synced user on the course
+// channels whose roles arrived before the user did.
+func (r *ChatRepository) ApplyCourseMemberships(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO chat_channel_users (channel_id, user_id)
+		SELECT DISTINCT channel_id, user_id FROM chat_course_members WHERE user_id = $1
+		ON CONFLICT DO NOTHING
+	`, userID)
+	return err
+}
+
+// whitelistUsers adds the userIDs already known here to channelID's
+// whitelist and returns those who were not on it.
+func whitelistUsers(ctx context.Context, tx *sql.Tx, channelID int64, userIDs []int64) ([]int64, error) {
+	var added []int64
+	for _, userID := range userIDs {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO chat_channel_users (channel_id, user_id)
+			SELECT $1, id FROM users WHERE id = $2
+			ON CONFLICT DO NOTHING
+		`, channelID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("whitelist user %d: %w", userID, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			added = append(added, userID)
+		}
+	}
+	return added, nil
+}
+
+// unlistUser removes userID from channelID's whitelist.
+func unlistUser(ctx context.Context, tx *sql.Tx, channelID, userID int64) (bool, error) {
+	res, err := tx.ExecContext(ctx,
+		`DELETE FROM chat_channel_users WHERE channel_id = $1 AND user_id = $2`, channelID, userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("unlist user %d: %w", userID, err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// courseChannels answers the channel lookup of a course change with its
+// channel 10 and group channel 11, and reports every user as losing their
+// last role.
+func courseChannels(query string, _ []driver.NamedValue) (*fakeRows, error) {
+	switch {
+	case strings.Contains(query, "SELECT id, course_group_id IS NOT NULL"):
+		return resultRows([]driver.Value{int64(10), false}, []driver.Value{int64(11), true}), nil
+	case strings.Contains(query, "WITH gone AS"):
+		return oneRow(false), nil
+	}
+	return nil, nil
+}
+
+// roleArgs returns the (channel, user, role) arguments of the statements
+// that contain frag.
+func roleArgs(db *fakeDB, frag string) [][]interface{} {
+	var args [][]interface{}
+	for _, stmt := range db.statements() {
+		if strings.Contains(stmt.Query, frag) {
+			args = append(args, stmt.Args)
+		}
+	}
+	return args
+}
+
+func TestRemoveCourseStudentLeavesGroups(t *testing.T) {
+	repo, db := newFakeRepo(t, courseChannels)
+
+	if _, err := repo.RemoveCourseMembers(context.Background(), 3, 0, "STUDENT", []int64{7}); err != nil {
+		t.Fatal(err)
+	}
+	lookup := db.statements()[0]
+	if strings.Contains(lookup.Query, "course_group_id IS NULL") || !reflect.DeepEqual(lookup.Args, []interface{}{int64(3)}) {
+		t.Errorf("student removal does not look at the group channels: %v\n%s", lookup.Args, lookup.Query)
+	}
+	want := [][]interface{}{
+		{int64(10), int64(7), "STUDENT"},
+		{int64(11), int64(7), "GROUP_MEMBER"},
+	}
+	if got := roleArgs(db, "WITH gone AS"); !reflect.DeepEqual(got, want) {
+		t.Errorf("removed roles = %v; want %v", got, want)
+	}
+}
+
+func TestAddCourseMembersFansOutStaffOnly(t *testing.T) {
+	cases := []struct {
+		role    string
+		groupID int64
+		cond    string
+		args    []interface{}
+	}{
+		{"STUDENT", 0, "AND course_group_id IS NULL", []interface{}{int64(3)}},
+		{"CO_TEACHER", 0, "AND true", []interface{}{int64(3)}},
+		{"GROUP_MEMBER", 5, "AND course_group_id = $2", []interface{}{int64(3), int64(5)}},
+	}
+	for _, tc := range cases {
+		repo, db := newFakeRepo(t, nil)
+		if _, err := repo.AddCourseMembers(context.Background(), 3, tc.groupID, tc.role, []int64{7}); err != nil {
+			t.Fatal(err)
+		}
+		lookup := db.statements()[0]
+		if !strings.Contains(lookup.Query, tc.cond) || !reflect.DeepEqual(lookup.Args, tc.args) {
+			t.Errorf("%s in group %d looked up channels with %v:\n%s", tc.role, tc.groupID, lookup.Args, lookup.Query)
+		}
+	}
+
+	repo, db := newFakeRepo(t, courseChannels)
+	if _, err := repo.AddCourseMembers(context.Background(), 3, 0, "CO_TEACHER", []int64{7}); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]interface{}{
+		{int64(10), int64(7), "CO_TEACHER"},
+		{int64(11), int64(7), "CO_TEACHER"},
+	}
+	if got := roleArgs(db, "INSERT INTO chat_course_members"); !reflect.DeepEqual(got, want) {
+		t.Errorf("added roles = %v; want %v", got, want)
+	}
+}
+
+func TestSyncCourseGroupMembersKeepsCourseStaff(t *testing.T) {
+	repo, db := newFakeRepo(t, func(query string, _ []driver.NamedValue) (*fakeRows, error) {
+		if strings.Contains(query, "m.role IN ('CREATOR', 'CO_TEACHER')") {
+			return oneRow(int64(1), "CREATOR"), nil
+		}
+		return nil, nil
+	})
+
+	if _, err := repo.SyncCourseGroupMembers(context.Background(), 3, 11, []int64{7}); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]interface{}{
+		{int64(11), int64(1), "CREATOR"},
+		{int64(11), int64(7), "GROUP_MEMBER"},
+	}
+	if got := roleArgs(db, "INSERT INTO chat_course_members"); !reflect.DeepEqual(got, want) {
+		t.Errorf("group roles = %v; want %v", got, want)
+	}
+}
+
+func TestProvisionCourseGroupChannelNeedsCourseChannel(t *testing.T) {
+	repo, db := newFakeRepo(t, nil)
+
+	ch, created, restored, err := repo.ProvisionCourseGroupChannel(context.Background(), 3, 5, "Lab A")
+	if err != nil || ch != nil || created || restored {
+		t.Fatalf("ProvisionCourseGroupChannel = %v, %v, %v, %v; want nothing", ch, created, restored, err)
+	}
+	stmts := db.statements()
+	if len(stmts) != 1 {
+		t.Fatalf("ran %d statements; want only the insert", len(stmts))
+	}
+	if q := stmts[0].Query; !strings.Contains(q, "course_group_id IS NULL AND archived_at IS NULL") {
+		t.Errorf("group channel is not tied to a live course channel:\n%s", q)
+	}
+	if want := []interface{}{"course:3:group:5", int64(3), int64(5), "Lab A"}; !reflect.DeepEqual(stmts[0].Args, want) {
+		t.Errorf("args = %v; want %v", stmts[0].Args, want)
+	}
+}
+
+func TestArchiveStaleGroupChannels(t *testing.T) {
+	repo, db := newFakeRepo(t, func(query string, _ []driver.NamedValue) (*fakeRows, error) {
+		return oneRow(int64(12)), nil
+	})
+
+	ids, err := repo.ArchiveStaleGroupChannels(context.Background(), 3, []int64{5, 6})
+	if err != nil || !reflect.DeepEqual(ids, []int64{12}) {
+		t.Fatalf("ArchiveStaleGroupChannels = %v, %v; want [12]", ids, err)
+	}
+	stmt := db.statements()[0]
+	if !strings.Contains(stmt.Query, "course_group_id NOT IN ($2,$3)") {
+		t.Errorf("query does not keep the listed groups:\n%s", stmt.Query)
+	}
+	if want := []interface{}{int64(3), int64(5), int64(6)}; !reflect.DeepEqual(stmt.Args, want) {
+		t.Errorf("args = %v; want %v", stmt.Args, want)
+	}
+
+	// With no groups left, every group channel goes
+	if _, err := repo.ArchiveStaleGroupChannels(context.Background(), 3, nil); err != nil {
+		t.Fatal(err)
+	}
+	if q := db.statements()[1].Query; strings.Contains(q, "NOT IN") {
+		t.Errorf("query keeps groups that were not listed:\n%s", q)
+	}
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// Mention kinds stored in chat_message_mentions. A user mentioned both by
+// name and by @channel keeps MentionUser.
+const (
+	MentionUser    = "user"
+	MentionChannel = "channel"
+	MentionHere    = "here"
+)
+
+// Mention is a message that mentioned the user.
+type Mention struct {
+	Message
+	Kind string
+}
+
+// ─── Mentions ─────────────────────────────────────────────────────────────────
+
+// ResolveMentionHandles maps @handles (a full email or its local part,
+// case-insensitive) to user IDs. Like SearchMentionableUsers, private
+// channels and DMs only resolve their members; public channels resolve any
+// user.
+func (r *ChatRepository) ResolveMentionHandles(ctx context.Context, channelID int64, handles []string) ([]int64, error) {
+	if len(handles) == 0 {
+		return nil, nil
+	}
+
+	var isPrivate, isDM bool
+	if err := r.db.QueryRowContext(ctx, `SELECT is_private, is_dm FROM chat_channels WHERE id = $1`, channelID).Scan(&isPrivate, &isDM); err != nil {
+		return nil, err
+	}
+
+	in, args := buildRoleArgs(channelID, handles)
+	query := fmt.Sprintf(`
+		SELECT u.id FROM users u
+		WHERE u.id > 0
+		  AND (LOWER(u.email) IN (%[1]s) OR LOWER(split_part(u.email, '@', 1)) IN (%[1]s))
+		  AND (NOT $%[2]d OR EXISTS (
+		      SELECT 1 FROM chat_channel_users ccu
+		      WHERE ccu.channel_id = $1 AND ccu.user_id = u.id
+		  ))
+	`, in, len(args)+1)
+	args = append(args, isPrivate || isDM)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ChannelAudience returns who @channel reaches: the explicit members and,
+// for role-based public channels that have no member list, everyone who
+// has opened the channel.
+func (r *ChatRepository) ChannelAudience(ctx context.Context, channelID int64) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT user_id FROM chat_channel_users WHERE channel_id = $1
+		UNION
+		SELECT cr.user_id FROM chat_channel_reads cr
+		JOIN chat_channels c ON c.id = cr.channel_id
+		WHERE cr.channel_id = $1 AND c.is_private = false AND c.is_dm = false
+	`, channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ReplaceMentions makes mentions (user ID → kind) the complete set of
+// mentions of msgID, so an edit drops the users it no longer mentions.
+// Returns the users who were not mentioned by it before.
+func (r *ChatRepository) ReplaceMentions(
+	ctx context.Context,
+	msgID, channelID int64,
+	mentions map[int64]string,
+) ([]int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT user_id FROM chat_message_mentions WHERE message_id = $1 FOR UPDATE`, msgID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		existing[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var added []int64
+	for userID, kind := range mentions {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO chat_message_mentions (message_id, user_id, channel_id, kind)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (message_id, user_id) DO UPDATE SET kind = EXCLUDED.kind
+		`, msgID, userID, channelID, kind); err != nil {
+			return nil, fmt.Errorf("insert mention: %w", err)
+		}
+		if !existing[userID] {
+			added = append(added, userID)
+		}
+	}
+	for userID := range existing {
+		if _, ok := mentions[userID]; ok {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM chat_message_mentions WHERE message_id = $1 AND user_id = $2`, msgID, userID,
+		); err != nil {
+			return nil, fmt.Errorf("delete mention: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// ListMentions returns up to `limit` non-deleted messages mentioning userID
+// older than beforeID (0 = newest), newest first.
+func (r *ChatRepository) ListMentions(ctx context.Context, userID, beforeID int64, limit int) ([]Mention, error) {
+	if limit <= 0 || limit > 100 {
+		limit = defaultPageSize
+	}
+	if beforeID <= 0 {
+		beforeID = 1<<63 - 1
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT mm.message_id, mm.kind
+		FROM chat_message_mentions mm
+		JOIN chat_messages m ON m.id = mm.message_id AND m.is_deleted = false
+		WHERE mm.user_id = $1 AND mm.message_id < $2
+		ORDER BY mm.message_id DESC
+		LIMIT $3
+	`, userID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mentions []Mention
+	var ids []int64
+	for rows.Next() {
+		var m Mention
+		if err := rows.Scan(&m.ID, &m.Kind); err != nil {
+			return nil, err
+		}
+		mentions = append(mentions, m)
+		ids = append(ids, m.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	msgs, err := r.getMessagesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Mention, 0, len(mentions))
+	for _, m := range mentions {
+		if msg, ok := msgs[m.ID]; ok {
+			m.Message = msg
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// ─── Mutes ────────────────────────────────────────────────────────────────────
+
+// SetChannelMuted mutes or unmutes a channel for userID.
+func (r *ChatRepository) SetChannelMuted(ctx context.Context, channelID, userID int64, muted bool) error {
+	var err error
+	if muted {
+		_, err = r.db.ExecContext(ctx, `
+			INSERT INTO chat_channel_mutes (channel_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, channelID, userID)
+	} else {
+		_, err = r.db.ExecContext(ctx,
+			`DELETE FROM chat_channel_mutes WHERE channel_id = $1 AND user_id = $2`, channelID, userID,
+		)
+	}
+	return err
+}
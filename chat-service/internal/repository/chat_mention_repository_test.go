@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveMentionHandlesLimitsPrivateChannelsToMembers(t *testing.T) {
+	for _, private := range []bool{false, true} {
+		repo, db := newFakeRepo(t, func(query string, _ []driver.NamedValue) (*fakeRows, error) {
+			if strings.Contains(query, "FROM chat_channels") {
+				return oneRow(private, false), nil
+			}
+			return resultRows([]driver.Value{int64(7)}, []driver.Value{int64(8)}), nil
+		})
+
+		ids, err := repo.ResolveMentionHandles(context.Background(), 3, []string{"alice", "bob@uni.edu"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []int64{7, 8}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("ids = %v; want %v", ids, want)
+		}
+		stmts := db.statements()
+		if len(stmts) != 2 {
+			t.Fatalf("ran %d statements; want 2", len(stmts))
+		}
+		q := stmts[1].Query
+		for _, frag := range []string{
+			"u.id > 0",
+			"LOWER(u.email) IN ($2,$3) OR LOWER(split_part(u.email, '@', 1)) IN ($2,$3)",
+			"NOT $4 OR EXISTS",
+		} {
+			if !strings.Contains(q, frag) {
+				t.Errorf("query lacks %q:\n%s", frag, q)
+			}
+		}
+		if want := []interface{}{int64(3), "alice", "bob@uni.edu", private}; !reflect.DeepEqual(stmts[1].Args, want) {
+			t.Errorf("private=%v: args = %#v; want %#v", private, stmts[1].Args, want)
+		}
+	}
+
+	repo, db := newFakeRepo(t, nil)
+	if ids, err := repo.ResolveMentionHandles(context.Background(), 3, nil); ids != nil || err != nil || len(db.statements()) != 0 {
+		t.Errorf("no handles = %v, %v after %d statements; want nothing", ids, err, len(db.statements()))
+	}
+}
@@ -0,0 +1,397 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Sanction kinds stored in chat_user_sanctions.
+const (
+	SanctionMute = "mute" // may read but not post
+	SanctionBan  = "ban"  // may not access at all
+)
+
+// Report statuses stored in chat_message_reports.
+const (
+	ReportOpen      = "open"
+	ReportDismissed = "dismissed"
+	ReportActioned  = "actioned"
+)
+
+// Sanction mutes or bans UserID in ChannelID, or in every channel when
+// ChannelID is nil. ExpiresAt nil means until revoked.
+type Sanction struct {
+	ID        int64
+	UserID    int64
+	ChannelID *int64
+	Kind      string
+	Reason    string
+	CreatedBy int64
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+}
+
+// Report is a user's report of a message, with the reported message as the
+// moderator sees it: the original body even when the message was deleted.
+type Report struct {
+	ID           int64
+	MessageID    int64
+	ChannelID    int64
+	ReporterID   int64
+	ReporterName string
+	Reason       string
+	Status       string
+	CreatedAt    time.Time
+	ReviewedBy   *int64
+	ReviewedAt   *time.Time
+	ReviewNote   string
+
+	SenderID    int64
+	SenderName  string
+	Body        string
+	IsDeleted   bool
+	ReportCount int // open and closed reports of the same message
+}
+
+// activeSanction matches unexpired, unrevoked sanctions of kind $3 for user
+// $2 in channel $1 or everywhere.
+const activeSanction = `
+	user_id = $2 AND kind = $3
+	AND (channel_id = $1 OR channel_id IS NULL)
+	AND revoked_at IS NULL
+	AND (expires_at IS NULL OR expires_at > NOW())`
+
+// ─── Sanctions ────────────────────────────────────────────────────────────────
+
+// ActiveSanction returns the sanction of kind that applies to userID in
+// channelID, preferring the one that lasts longest, or nil when none does.
+func (r *ChatRepository) ActiveSanction(ctx context.Context, channelID, userID int64, kind string) (*Sanction, error) {
+	s, err := scanSanction(r.db.QueryRowContext(ctx, `
+		SELECT id, user_id, channel_id, kind, reason, created_by, created_at, expires_at
+		FROM chat_user_sanctions
+		WHERE `+activeSanction+`
+		ORDER BY expires_at DESC NULLS FIRST
+		LIMIT 1
+	`, channelID, userID, kind))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+// CreateSanction stores a new sanction; channelID 0 applies it everywhere
+// and a zero expiresAt makes it permanent.
+func (r *ChatRepository) CreateSanction(
+	ctx context.Context,
+	userID, channelID int64,
+	kind, reason string,
+	expiresAt time.Time,
+	createdBy int64,
+) (*Sanction, error) {
+	var channel sql.NullInt64
+	if channelID > 0 {
+		channel = sql.NullInt64{Int64: channelID, Valid: true}
+	}
+	var expires sql.NullTime
+	if !expiresAt.IsZero() {
+		expires = sql.NullTime{Time: expiresAt, Valid: true}
+	}
+
+	s, err := scanSanction(r.db.QueryRowContext(ctx, `
+		INSERT INTO chat_user_sanctions (user_id, channel_id, kind, reason, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, user_id, channel_id, kind, reason, created_by, created_at, expires_at
+	`, userID, channel, kind, reason, createdBy, expires))
+	if err != nil {
+		return nil, fmt.Errorf("create sanction: %w", err)
+	}
+	return s, nil
+}
+
+// RevokeSanction lifts a sanction early. Returns nil when it does not exist
+// or was already revoked.
+func (r *ChatRepository) RevokeSanction(ctx context.Context, sanctionID, revokedBy int64) (*Sanction, error) {
+	s, err := scanSanction(r.db.QueryRowContext(ctx, `
+		UPDATE chat_user_sanctions
+		SET revoked_at = NOW(), revoked_by = $2
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING id, user_id, channel_id, kind, reason, created_by, created_at, expires_at
+	`, sanctionID, revokedBy))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+// ListActiveSanctions returns unexpired, unrevoked sanctions, newest first,
+// optionally narrowed to one user and/or channel (0 = any). Narrowing to a
+// channel includes sanctions that apply everywhere.
+func (r *ChatRepository) ListActiveSanctions(ctx context.Context, userID, channelID int64) ([]Sanction, error) {
+	query := `
+		SELECT id, user_id, channel_id, kind, reason, created_by, created_at, expires_at
+		FROM chat_user_sanctions
+		WHERE revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())`
+	var args []interface{}
+	if userID > 0 {
+		args = append(args, userID)
+		query += fmt.Sprintf(" AND user_id = $%d", len(args))
+	}
+	if channelID > 0 {
+		args = append(args, channelID)
+		query += fmt.Sprintf(" AND (channel_id = $%d OR channel_id IS NULL)", len(args))
+	}
+	rows, err := r.db.QueryContext(ctx, query+" ORDER BY id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sanctions := make([]Sanction, 0)
+	for rows.Next() {
+		s, err := scanSanction(rows)
+		if err != nil {
+			return nil, err
+		}
+		sanctions = append(sanctions, *s)
+	}
+	return sanctions, rows.Err()
+}
+
+// scanSanction reads the sanction columns selected above from a *sql.Row
+// or *sql.Rows.
+func scanSanction(row interface{ Scan(...interface{}) error }) (*Sanction, error) {
+	var s Sanction
+	var channelID sql.NullInt64
+	var expiresAt sql.NullTime
+	if err := row.Scan(
+		&s.ID, &s.UserID, &channelID, &s.Kind, &s.Reason,
+		&s.CreatedBy, &s.CreatedAt, &expiresAt,
+	); err != nil {
+		return nil, err
+	}
+	if channelID.Valid {
+		v := channelID.Int64
+		s.ChannelID = &v
+	}
+	if expiresAt.Valid {
+		v := expiresAt.Time
+		s.ExpiresAt = &v
+	}
+	return &s, nil
+}
+
+// ─── Slow Mode ────────────────────────────────────────────────────────────────
+
+// SetSlowMode sets the seconds writers must wait between messages in a
+// channel (0 turns slow mode off). Returns false when the channel does not
+// exist.
+func (r *ChatRepository) SetSlowMode(ctx context.Context, channelID int64, seconds int) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE chat_channels SET slow_mode_seconds = $2 WHERE id = $1`, channelID, seconds,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// SlowModeError rejects a send made before the writer's slow-mode window
+// has passed.
+type SlowModeError struct {
+	Wait time.Duration
+}
+
+func (e *SlowModeError) Error() string {
+	return fmt.Sprintf("slow mode: retry in %s", e.Wait)
+}
+
+// SlowModeWait returns how long userID must still wait before posting again
+// in channelID; zero when slow mode is off or the wait is over. It is only a
+// pre-check for sends that are expensive to reject late (uploads); the
+// message insert enforces slow mode itself.
+func (r *ChatRepository) SlowModeWait(ctx context.Context, channelID, userID int64) (time.Duration, error) {
+	var seconds float64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(EXTRACT(EPOCH FROM
+		           p.last_post_at + make_interval(secs => c.slow_mode_seconds) - now()), 0)::float8
+		FROM chat_channels c
+		LEFT JOIN chat_slow_mode_posts p ON p.channel_id = c.id AND p.user_id = $2
+		WHERE c.id = $1 AND c.slow_mode_seconds > 0
+	`, channelID, userID).Scan(&seconds)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil || seconds <= 0 {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// claimSlowMode counts a post by userID in channelID and returns a
+// *SlowModeError when the channel's slow mode window since their last post
+// has not passed. It runs in the message insert's transaction: concurrent
+// sends serialize on the row, a rolled-back insert leaves the previous post
+// time in place, and both the check and the stamp use the database clock.
+func claimSlowMode(ctx context.Context, tx *sql.Tx, channelID, userID int64) error {
+	var allowed bool
+	var seconds float64
+	err := tx.QueryRowContext(ctx, `
+		WITH ch AS (
+			SELECT slow_mode_seconds AS secs FROM chat_channels WHERE id = $1
+		), claim AS (
+			INSERT INTO chat_slow_mode_posts (channel_id, user_id, last_post_at)
+			SELECT $1, $2, now() FROM ch WHERE ch.secs > 0
+			ON CONFLICT (channel_id, user_id) DO UPDATE SET last_post_at = EXCLUDED.last_post_at
+			WHERE chat_slow_mode_posts.last_post_at
+			      <= now() - make_interval(secs => (SELECT secs FROM ch))
+			RETURNING 1
+		)
+		SELECT EXISTS (SELECT 1 FROM claim) OR COALESCE((SELECT secs FROM ch), 0) = 0,
+		       COALESCE((SELECT EXTRACT(EPOCH FROM p.last_post_at + make_interval(secs => ch.secs) - now())
+		                 FROM chat_slow_mode_posts p, ch
+		                 WHERE p.channel_id = $1 AND p.user_id = $2), 0)::float8
+	`, channelID, userID).Scan(&allowed, &seconds)
+	if err != nil {
+		return fmt.Errorf("slow mode: %w", err)
+	}
+	if allowed {
+		return nil
+	}
+	wait := time.Duration(seconds * float64(time.Second))
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return &SlowModeError{Wait: wait}
+}
+
+// ─── Moderator Deletes ────────────────────────────────────────────────────────
+
+// ModeratorDeleteMessage soft-deletes any message of channelID and records
+// who deleted it and why. Returns false when the message is not in the
+// channel or was already deleted.
+func (r *ChatRepository) ModeratorDeleteMessage(
+	ctx context.Context,
+	channelID, msgID, actorID int64,
+	reason string,
+) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE chat_messages
+		SET is_deleted = true, deleted_by = $3, deleted_reason = $4, deleted_at = NOW()
+		WHERE id = $1 AND channel_id = $2 AND is_deleted = false
+	`, msgID, channelID, actorID, reason)
+	if err != nil {
+		return false, fmt.Errorf("moderator delete: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ─── Reports ──────────────────────────────────────────────────────────────────
+
+// CreateReport files reporterID's report of a message. Returns false when
+// they already reported it.
+func (r *ChatRepository) CreateReport(
+	ctx context.Context,
+	channelID, msgID, reporterID int64,
+	reason string,
+) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO chat_message_reports (message_id, channel_id, reporter_id, reason)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (message_id, reporter_id) DO NOTHING
+	`, msgID, channelID, reporterID, reason)
+	if err != nil {
+		return false, fmt.Errorf("create report: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListReports returns up to `limit` reports with the given status (empty =
+// any) older than beforeID (0 = newest), newest first.
+func (r *ChatRepository) ListReports(ctx context.Context, status string, beforeID int64, limit int) ([]Report, error) {
+	if limit <= 0 || limit > 100 {
+		limit = defaultPageSize
+	}
+	if beforeID <= 0 {
+		beforeID = 1<<63 - 1
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT rp.id, rp.message_id, rp.channel_id, rp.reporter_id,
+		       COALESCE(NULLIF(ru.full_name, ''), ru.email) AS reporter_name,
+		       rp.reason, rp.status, rp.created_at,
+		       rp.reviewed_by, rp.reviewed_at, COALESCE(rp.review_note, ''),
+		       m.sender_id, COALESCE(NULLIF(su.full_name, ''), su.email) AS sender_name,
+		       m.body, m.is_deleted,
+		       (SELECT COUNT(*) FROM chat_message_reports o WHERE o.message_id = rp.message_id)
+		FROM chat_message_reports rp
+		JOIN users ru ON ru.id = rp.reporter_id
+		JOIN chat_messages m ON m.id = rp.message_id
+		JOIN users su ON su.id = m.sender_id
+		WHERE ($1 = '' OR rp.status = $1) AND rp.id < $2
+		ORDER BY rp.id DESC
+		LIMIT $3
+	`, status, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := make([]Report, 0)
+	for rows.Next() {
+		var rp Report
+		var reviewedBy sql.NullInt64
+		var reviewedAt sql.NullTime
+		if err := rows.Scan(
+			&rp.ID, &rp.MessageID, &rp.ChannelID, &rp.ReporterID, &rp.ReporterName,
+			&rp.Reason, &rp.Status, &rp.CreatedAt,
+			&reviewedBy, &reviewedAt, &rp.ReviewNote,
+			&rp.SenderID, &rp.SenderName, &rp.Body, &rp.IsDeleted, &rp.ReportCount,
+		); err != nil {
+			return nil, err
+		}
+		if reviewedBy.Valid {
+			v := reviewedBy.Int64
+			rp.ReviewedBy = &v
+		}
+		if reviewedAt.Valid {
+			v := reviewedAt.Time
+			rp.ReviewedAt = &v
+		}
+		reports = append(reports, rp)
+	}
+	return reports, rows.Err()
+}
+
+// ResolveReport closes a report, and every other open report of the same
+// message, with status and note. Returns the reported message's channel and
+// ID, or found = false when the report does not exist.
+func (r *ChatRepository) ResolveReport(
+	ctx context.Context,
+	reportID, reviewerID int64,
+	status, note string,
+) (channelID, msgID int64, found bool, err error) {
+	err = r.db.QueryRowContext(ctx,
+		`SELECT channel_id, message_id FROM chat_message_reports WHERE id = $1`, reportID,
+	).Scan(&channelID, &msgID)
+	if err == sql.ErrNoRows {
+		return 0, 0, false, nil
+	}
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	if _, err = r.db.ExecContext(ctx, `
+		UPDATE chat_message_reports
+		SET status = $3, reviewed_by = $4, reviewed_at = NOW(), review_note = $5
+		WHERE id = $1 OR (message_id = $2 AND status = 'open')
+	`, reportID, msgID, status, reviewerID, note); err != nil {
+		return 0, 0, false, fmt.Errorf("resolve report: %w", err)
+	}
+	return channelID, msgID, true, nil
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCanUserAccessBansAndArchives(t *testing.T) {
+	cases := []struct {
+		name                string
+		banned, archived    bool
+		whitelisted         bool
+		wantRead, wantWrite bool
+	}{
+		{"banned member", true, false, true, false, false},
+		{"member", false, false, true, true, true},
+		{"member of archived channel", false, true, true, true, false},
+		{"role grant on archived channel", false, true, false, true, false},
+	}
+	for _, tc := range cases {
+		repo, db := newFakeRepo(t, func(query string, _ []driver.NamedValue) (*fakeRows, error) {
+			switch {
+			case strings.Contains(query, "chat_user_sanctions"):
+				return oneRow(tc.banned, tc.archived), nil
+			case strings.Contains(query, "chat_channel_users"):
+				return oneRow(tc.whitelisted), nil
+			default:
+				return oneRow(true, true), nil
+			}
+		})
+
+		canRead, canWrite, err := repo.CanUserAccess(context.Background(), 5, 9, []string{"STUDENT"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if canRead != tc.wantRead || canWrite != tc.wantWrite {
+			t.Errorf("%s: access = %v, %v; want %v, %v", tc.name, canRead, canWrite, tc.wantRead, tc.wantWrite)
+		}
+		want := []interface{}{int64(5), int64(9), SanctionBan}
+		if got := db.statements()[0].Args; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: ban check args = %v; want %v", tc.name, got, want)
+		}
+	}
+
+	repo, db := newFakeRepo(t, nil)
+	if r, w, err := repo.CanUserAccess(context.Background(), 5, 9, []string{"ADMIN"}); !r || !w || err != nil {
+		t.Errorf("admin access = %v, %v, %v; want full access", r, w, err)
+	}
+	if n := len(db.statements()); n != 0 {
+		t.Errorf("admin access ran %d statements; want none", n)
+	}
+}
+
+func TestSlowModeWait(t *testing.T) {
+	cases := []struct {
+		name string
+		rows *fakeRows
+		want time.Duration
+	}{
+		{"window open", oneRow(7.5), 7500 * time.Millisecond},
+		{"window passed", oneRow(-3.0), 0},
+		{"slow mode off", nil, 0},
+	}
+	for _, tc := range cases {
+		repo, db := newFakeRepo(t, func(string, []driver.NamedValue) (*fakeRows, error) {
+			return tc.rows, nil
+		})
+
+		wait, err := repo.SlowModeWait(context.Background(), 5, 9)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wait != tc.want {
+			t.Errorf("%s: wait = %v; want %v", tc.name, wait, tc.want)
+		}
+		stmt := db.statements()[0]
+		if want := []interface{}{int64(5), int64(9)}; !reflect.DeepEqual(stmt.Args, want) {
+			t.Errorf("%s: args = %v; want %v", tc.name, stmt.Args, want)
+		}
+		// The window is measured against the database clock only
+		if !strings.Contains(stmt.Query, "now()") {
+			t.Errorf("%s: query does not use the database clock:\n%s", tc.name, stmt.Query)
+		}
+	}
+}
+
+// slowModeAnswer answers CreateMessage's statements: the slow mode claim
+// with claimed and wait, the insert with message 11 in channel 5.
+func slowModeAnswer(claimed bool, wait float64) func(string, []driver.NamedValue) (*fakeRows, error) {
+	return func(query string, _ []driver.NamedValue) (*fakeRows, error) {
+		switch {
+		case strings.Contains(query, "INSERT INTO chat_slow_mode_posts"):
+			return oneRow(claimed, wait), nil
+		case strings.Contains(query, "INSERT INTO chat_messages"):
+			return oneRow(int64(11), nil), nil
+		case strings.Contains(query, "WHERE m.id = $1"):
+			return messageRow(11, 5, 9), nil
+		}
+		return nil, nil
+	}
+}
+
+func TestCreateMessageSlowModeRejectsInsideTheInsert(t *testing.T) {
+	repo, db := newFakeRepo(t, slowModeAnswer(false, 4.2))
+
+	_, _, err := repo.CreateMessage(context.Background(), 5, 9, "hi", nil, "", true)
+	var slow *SlowModeError
+	if !errors.As(err, &slow) {
+		t.Fatalf("err = %v; want a *SlowModeError", err)
+	}
+	if slow.Wait != 4200*time.Millisecond {
+		t.Errorf("wait = %v; want 4.2s", slow.Wait)
+	}
+	for _, stmt := range db.statements() {
+		if strings.Contains(stmt.Query, "INSERT INTO chat_messages") {
+			t.Errorf("message inserted although slow mode rejected it")
+		}
+	}
+}
+
+func TestCreateMessageSlowModeClaimsInTheSameTransaction(t *testing.T) {
+	repo, db := newFakeRepo(t, slowModeAnswer(true, 0))
+
+	msg, created, err := repo.CreateMessage(context.Background(), 5, 9, "hi", nil, "", true)
+	if err != nil || !created || msg.ID != 11 {
+		t.Fatalf("CreateMessage = %v, %v, %v; want message 11 created", msg, created, err)
+	}
+	stmts := db.statements()
+	if len(stmts) < 2 || !strings.Contains(stmts[0].Query, "INSERT INTO chat_slow_mode_posts") ||
+		!strings.Contains(stmts[1].Query, "INSERT INTO chat_messages") {
+		t.Fatalf("statements = %v; want the slow mode claim before the insert", stmts)
+	}
+	if stmts[0].Conn != stmts[1].Conn {
+		t.Errorf("claim ran on connection %d and insert on %d; want one transaction", stmts[0].Conn, stmts[1].Conn)
+	}
+	if want := []interface{}{int64(5), int64(9)}; !reflect.DeepEqual(stmts[0].Args, want) {
+		t.Errorf("claim args = %v; want %v", stmts[0].Args, want)
+	}
+}
+
+func TestCreateMessageSlowModeExemptions(t *testing.T) {
+	// An admin send and a retry of a stored message never claim the window
+	repo, db := newFakeRepo(t, slowModeAnswer(false, 4.2))
+	if _, _, err := repo.CreateMessage(context.Background(), 5, 9, "hi", nil, "", false); err != nil {
+		t.Fatalf("unclaimed send: %v", err)
+	}
+
+	retryRepo, retryDB := newFakeRepo(t, func(query string, args []driver.NamedValue) (*fakeRows, error) {
+		if strings.Contains(query, "client_msg_id = $3") && !strings.Contains(query, "INSERT") {
+			return oneRow(int64(11)), nil
+		}
+		return slowModeAnswer(false, 4.2)(query, args)
+	})
+	msg, created, err := retryRepo.CreateMessage(context.Background(), 5, 9, "hi", nil, "retry-1", true)
+	if err != nil || created || msg.ID != 11 {
+		t.Fatalf("retry = %v, %v, %v; want stored message 11 returned", msg, created, err)
+	}
+
+	for _, f := range []*fakeDB{db, retryDB} {
+		for _, stmt := range f.statements() {
+			if strings.Contains(stmt.Query, "chat_slow_mode_posts") {
+				t.Errorf("slow mode claimed by an exempt send:\n%s", stmt.Query)
+			}
+		}
+	}
+}
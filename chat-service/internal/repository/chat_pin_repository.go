@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxPinsPerChannel caps the pinned messages in one channel.
+const maxPinsPerChannel = 50
+
+// ErrPinLimit is returned when a channel already has maxPinsPerChannel pins.
+var ErrPinLimit = errors.New("channel has too many pinned messages")
+
+// PinnedMessage is a message pinned in its channel.
+type PinnedMessage struct {
+	Message
+	PinnedBy int64
+	PinnedAt time.Time
+}
+
+// SavedMessage is a message the user bookmarked for themselves.
+type SavedMessage struct {
+	Message
+	SavedAt time.Time
+}
+
+// ─── Pins ─────────────────────────────────────────────────────────────────────
+
+// PinMessage pins a message of channelID. created is false when it was
+// already pinned; deleted messages and messages of other channels are not
+// pinned and also report false.
+func (r *ChatRepository) PinMessage(
+	ctx context.Context,
+	channelID, msgID, pinnedBy int64,
+) (pinnedAt time.Time, created bool, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Serialise pinning per channel so the limit holds under concurrency
+	if _, err := tx.ExecContext(ctx,
+		`SELECT 1 FROM chat_channels WHERE id = $1 FOR UPDATE`, channelID,
+	); err != nil {
+		return time.Time{}, false, err
+	}
+	var pins int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM chat_channel_pins WHERE channel_id = $1`, channelID,
+	).Scan(&pins); err != nil {
+		return time.Time{}, false, err
+	}
+	if pins >= maxPinsPerChannel {
+		return time.Time{}, false, ErrPinLimit
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO chat_channel_pins (channel_id, message_id, pinned_by)
+		SELECT $1, m.id, $3
+		FROM chat_messages m
+		WHERE m.id = $2 AND m.channel_id = $1 AND m.is_deleted = false
+		ON CONFLICT DO NOTHING
+		RETURNING pinned_at
+	`, channelID, msgID, pinnedBy).Scan(&pinnedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("pin message: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, false, err
+	}
+	return pinnedAt, true, nil
+}
+
+// UnpinMessage removes a pin. Returns false when the message was not pinned.
+func (r *ChatRepository) UnpinMessage(ctx context.Context, channelID, msgID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM chat_channel_pins WHERE channel_id = $1 AND message_id = $2`,
+		channelID, msgID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListPins returns a channel's pinned messages, most recently pinned first.
+func (r *ChatRepository) ListPins(ctx context.Context, channelID int64) ([]PinnedMessage, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT message_id, pinned_by, pinned_at
+		FROM chat_channel_pins
+		WHERE channel_id = $1
+		ORDER BY pinned_at DESC
+	`, channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pins []PinnedMessage
+	var ids []int64
+	for rows.Next() {
+		var p PinnedMessage
+		if err := rows.Scan(&p.ID, &p.PinnedBy, &p.PinnedAt); err != nil {
+			return nil, err
+		}
+		pins = append(pins, p)
+		ids = append(ids, p.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	msgs, err := r.getMessagesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]PinnedMessage, 0, len(pins))
+	for _, p := range pins {
+		if m, ok := msgs[p.ID]; ok {
+			p.Message = m
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// PinnedMessageIDs returns the IDs of a channel's pinned messages.
+func (r *ChatRepository) PinnedMessageIDs(ctx context.Context, channelID int64) (map[int64]bool, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT message_id FROM chat_channel_pins WHERE channel_id = $1`, channelID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// ─── Saved Messages ───────────────────────────────────────────────────────────
+
+// SaveMessage bookmarks a message for userID. Returns false when it was
+// already saved.
+func (r *ChatRepository) SaveMessage(ctx context.Context, userID, msgID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO chat_saved_messages (user_id, message_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, userID, msgID)
+	if err != nil {
+		return false, fmt.Errorf("save message: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// UnsaveMessage removes a bookmark. Returns false when it was not saved.
+func (r *ChatRepository) UnsaveMessage(ctx context.Context, userID, msgID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM chat_saved_messages WHERE user_id = $1 AND message_id = $2`,
+		userID, msgID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListSavedMessages returns up to `limit` of userID's bookmarks saved before
+// `before` (zero = newest), most recent first.
+func (r *ChatRepository) ListSavedMessages(
+	ctx context.Context,
+	userID int64,
+	before time.Time,
+	limit int,
+) ([]SavedMessage, error) {
+	if limit <= 0 || limit > 100 {
+		limit = defaultPageSize
+	}
+	if before.IsZero() {
+		before = time.Now().Add(time.Hour)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT message_id, saved_at
+		FROM chat_saved_messages
+		WHERE user_id = $1 AND saved_at < $2
+		ORDER BY saved_at DESC
+		LIMIT $3
+	`, userID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var saved []SavedMessage
+	var ids []int64
+	for rows.Next() {
+		var s SavedMessage
+		if err := rows.Scan(&s.ID, &s.SavedAt); err != nil {
+			return nil, err
+		}
+		saved = append(saved, s)
+		ids = append(ids, s.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	msgs, err := r.getMessagesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]SavedMessage, 0, len(saved))
+	for _, s := range saved {
+		if m, ok := msgs[s.ID]; ok {
+			s.Message = m
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+// SavedMessageIDs reports which of msgIDs userID has saved.
+func (r *ChatRepository) SavedMessageIDs(ctx context.Context, userID int64, msgIDs []int64) (map[int64]bool, error) {
+	ids := make(map[int64]bool)
+	if len(msgIDs) == 0 {
+		return ids, nil
+	}
+
+	placeholders, idArgs := idPlaceholders(msgIDs, 1)
+	args := append([]interface{}{userID}, idArgs...)
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT message_id FROM chat_saved_messages
+		WHERE user_id = $1 AND message_id IN (%s)
+	`, placeholders), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// maxReactionEmojis caps the distinct emojis on one message.
+const maxReactionEmojis = 20
+
+// ErrReactionLimit is returned when a message already carries
+// maxReactionEmojis different emojis.
+var ErrReactionLimit = errors.New("message has too many different reactions")
+
+// Reaction aggregates one emoji on a message for the calling user.
+type Reaction struct {
+	Emoji       string
+	Count       int
+	ReactedByMe bool
+}
+
+// ─── Reactions ────────────────────────────────────────────────────────────────
+
+// ToggleReaction adds userID's emoji to a message, or removes it when already
+// there. Returns whether it was added and how many users now carry the emoji.
+func (r *ChatRepository) ToggleReaction(
+	ctx context.Context,
+	msgID, userID int64,
+	emoji string,
+) (added bool, count int, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM chat_message_reactions
+		WHERE message_id = $1 AND user_id = $2 AND emoji = $3
+	`, msgID, userID, emoji)
+	if err != nil {
+		return false, 0, fmt.Errorf("remove reaction: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		var emojis int
+		var exists sql.NullBool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(DISTINCT emoji), BOOL_OR(emoji = $2)
+			FROM chat_message_reactions WHERE message_id = $1
+		`, msgID, emoji).Scan(&emojis, &exists); err != nil {
+			return false, 0, fmt.Errorf("count reactions: %w", err)
+		}
+		if !exists.Bool && emojis >= maxReactionEmojis {
+			return false, 0, ErrReactionLimit
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO chat_message_reactions (message_id, user_id, emoji)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING
+		`, msgID, userID, emoji); err != nil {
+			return false, 0, fmt.Errorf("add reaction: %w", err)
+		}
+		added = true
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM chat_message_reactions WHERE message_id = $1 AND emoji = $2
+	`, msgID, emoji).Scan(&count); err != nil {
+		return false, 0, fmt.Errorf("count emoji: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, 0, err
+	}
+	return added, count, nil
+}
+
+// ListReactions returns the aggregated reactions for each message, emojis in
+// the order they were first used. ReactedByMe is relative to userID.
+func (r *ChatRepository) ListReactions(
+	ctx context.Context,
+	msgIDs []int64,
+	userID int64,
+) (map[int64][]Reaction, error) {
+	result := make(map[int64][]Reaction)
+	if len(msgIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders, idArgs := idPlaceholders(msgIDs, 1)
+	args := append([]interface{}{userID}, idArgs...)
+	query := fmt.Sprintf(`
+		SELECT message_id, emoji, COUNT(*), BOOL_OR(user_id = $1)
+		FROM chat_message_reactions
+		WHERE message_id IN (%s)
+		GROUP BY message_id, emoji
+		ORDER BY message_id, MIN(created_at)
+	`, placeholders)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var msgID int64
+		var rc Reaction
+		if err := rows.Scan(&msgID, &rc.Emoji, &rc.Count, &rc.ReactedByMe); err != nil {
+			return nil, err
+		}
+		result[msgID] = append(result[msgID], rc)
+	}
+	return result, rows.Err()
+}
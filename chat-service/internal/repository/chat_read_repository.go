@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ReadState is a user's position in one channel. Unread and Mentions count
+// messages from other senders after LastReadMessageID. Muted channels badge
+// only their mentions.
+type ReadState struct {
+	ChannelID         int64
+	LastReadMessageID int64
+	Unread            int
+	Mentions          int
+	Muted             bool
+}
+
+// ReadReceipt is how far one member has read a channel.
+type ReadReceipt struct {
+	UserID            int64
+	LastReadMessageID int64
+	ReadAt            time.Time
+}
+
+// ─── Read State ───────────────────────────────────────────────────────────────
+
+// MarkRead moves the caller's read pointer in channelID up to messageID.
+// Pointers never move backwards and only accept a message that belongs to
+// the channel. advanced is false when the pointer was already at or past it.
+func (r *ChatRepository) MarkRead(
+	ctx context.Context,
+	channelID, userID, messageID int64,
+) (readAt time.Time, advanced bool, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO chat_channel_reads (channel_id, user_id, last_read_message_id, read_at)
+		SELECT $1, $2, m.id, NOW()
+		FROM chat_messages m
+		WHERE m.id = $3 AND m.channel_id = $1
+		ON CONFLICT (channel_id, user_id) DO UPDATE
+		SET last_read_message_id = EXCLUDED.last_read_message_id, read_at = EXCLUDED.read_at
+		WHERE chat_channel_reads.last_read_message_id < EXCLUDED.last_read_message_id
+		RETURNING read_at
+	`, channelID, userID, messageID).Scan(&readAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("mark read: %w", err)
+	}
+	return readAt, true, nil
+}
+
+// MarkChannelRead moves the caller's read pointer to the newest message in
+// the channel. Returns the message ID it now points at (0 for an empty channel).
+func (r *ChatRepository) MarkChannelRead(
+	ctx context.Context,
+	channelID, userID int64,
+) (lastReadID int64, readAt time.Time, advanced bool, err error) {
+	err = r.db.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(id), 0) FROM chat_messages WHERE channel_id = $1`, channelID,
+	).Scan(&lastReadID)
+	if err != nil || lastReadID == 0 {
+		return lastReadID, time.Time{}, false, err
+	}
+	readAt, advanced, err = r.MarkRead(ctx, channelID, userID, lastReadID)
+	return lastReadID, readAt, advanced, err
+}
+
+// GetReadStates returns the caller's read pointer with unread and mention
+// counts and mute state for each channel. Channels the user never
+// acknowledged count their whole history as unread. Mentions are the
+// user's chat_message_mentions rows, so @channel and @here count only where
+// they were delivered.
+func (r *ChatRepository) GetReadStates(
+	ctx context.Context,
+	userID int64,
+	channelIDs []int64,
+) (map[int64]ReadState, error) {
+	result := make(map[int64]ReadState, len(channelIDs))
+	if len(channelIDs) == 0 {
+		return result, nil
+	}
+
+	// $1 is userID; channel IDs start at $2
+	placeholders := make([]string, len(channelIDs))
+	args := make([]interface{}, len(channelIDs)+1)
+	args[0] = userID
+	for i, cid := range channelIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args[i+1] = cid
+		result[cid] = ReadState{ChannelID: cid}
+	}
+
+	query := fmt.Sprintf(`
+		WITH channels AS (
+			SELECT c.id AS channel_id, COALESCE(cr.last_read_message_id, 0) AS last_read_id,
+			       (cm.user_id IS NOT NULL) AS muted
+			FROM chat_channels c
+			LEFT JOIN chat_channel_reads cr ON cr.channel_id = c.id AND cr.user_id = $1
+			LEFT JOIN chat_channel_mutes cm ON cm.channel_id = c.id AND cm.user_id = $1
+			WHERE c.id IN (%s)
+		)
+		SELECT ch.channel_id, ch.last_read_id, ch.muted,
+		       COUNT(m.id),
+		       COUNT(mm.message_id)
+		FROM channels ch
+		LEFT JOIN chat_messages m
+		       ON m.channel_id = ch.channel_id
+		      AND m.id > ch.last_read_id
+		      AND m.sender_id != $1
+		      AND m.is_deleted = false
+		LEFT JOIN chat_message_mentions mm
+		       ON mm.message_id = m.id
+		      AND mm.user_id = $1
+		GROUP BY ch.channel_id, ch.last_read_id, ch.muted
+	`, strings.Join(placeholders, ","))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s ReadState
+		if err := rows.Scan(&s.ChannelID, &s.LastReadMessageID, &s.Muted, &s.Unread, &s.Mentions); err != nil {
+			return nil, err
+		}
+		result[s.ChannelID] = s
+	}
+	return result, rows.Err()
+}
+
+// ListReadReceipts returns every member's read pointer in a channel, most
+// recently read first. Members who never acknowledged a message are omitted.
+func (r *ChatRepository) ListReadReceipts(ctx context.Context, channelID int64) ([]ReadReceipt, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT user_id, last_read_message_id, read_at
+		FROM chat_channel_reads
+		WHERE channel_id = $1
+		ORDER BY read_at DESC
+	`, channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	receipts := make([]ReadReceipt, 0)
+	for rows.Next() {
+		var rr ReadReceipt
+		if err := rows.Scan(&rr.UserID, &rr.LastReadMessageID, &rr.ReadAt); err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, rr)
+	}
+	return receipts, rows.Err()
+}
+
+// CountChannelUsers returns the size of a channel's explicit member list.
+func (r *ChatRepository) CountChannelUsers(ctx context.Context, channelID int64) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM chat_channel_users WHERE channel_id = $1`, channelID,
+	).Scan(&n)
+	return n, err
+}
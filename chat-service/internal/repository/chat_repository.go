@@ -18,6 +18,9 @@ type Channel struct {
 	Description string
 	IsPrivate   bool
 	IsDM        bool
+	SlowMode    int        // seconds a non-admin must wait between messages; 0 = off
+	CourseID    *int64     // LMS course the channel belongs to, if any
+	ArchivedAt  *time.Time // archived channels are read-only
 	CreatedBy   int64
 	CreatedAt   time.Time
 }
@@ -42,6 +45,7 @@ type Message struct {
 	IsDeleted        bool
 	IsEdited         bool
 	ParentID         *int64
+	ThreadRootID     *int64 // first message of the reply chain; nil for top-level messages
 	ParentSenderName string
 	ParentBody       string
 	Attachments      []Attachment
@@ -76,6 +80,8 @@ func NewChatRepository(db *sql.DB) *ChatRepository {
 //   - It is not private (is_private = false), AND has a role entry matching one of userRoles, OR
 //   - The caller's userID is in chat_channel_users (whitelist), OR
 //   - The caller has ADMIN role (sees all)
+//
+// Channels the caller is banned from are left out.
 func (r *ChatRepository) ListAccessibleChannels(
 	ctx context.Context,
 	userID int64,
@@ -96,7 +102,7 @@ func (r *ChatRepository) ListAccessibleChannels(
 
 	if isAdmin {
 		rows, err = r.db.QueryContext(ctx, `
-			SELECT id, slug, name, description, is_private, is_dm, created_by, created_at
+			SELECT id, slug, name, description, is_private, is_dm, slow_mode_seconds, course_id, archived_at, created_by, created_at
 			FROM chat_channels c
 			WHERE c.is_dm = false
 			   OR (c.is_dm = true AND EXISTS (
@@ -109,7 +115,7 @@ func (r *ChatRepository) ListAccessibleChannels(
 		// Build role placeholder list: $2, $3, ...
 		placeholders, args := buildRoleArgs(userID, userRoles)
 		query := fmt.Sprintf(`
-			SELECT DISTINCT c.id, c.slug, c.name, c.description, c.is_private, c.is_dm, c.created_by, c.created_at
+			SELECT DISTINCT c.id, c.slug, c.name, c.description, c.is_private, c.is_dm, c.slow_mode_seconds, c.course_id, c.archived_at, c.created_by, c.created_at
 			FROM chat_channels c
 			WHERE (
 				(c.is_dm = false AND (
@@ -131,6 +137,13 @@ func (r *ChatRepository) ListAccessibleChannels(
 					  AND ccu.user_id = $1
 				))
 			)
+			AND NOT EXISTS (
+				SELECT 1 FROM chat_user_sanctions s
+				WHERE s.user_id = $1 AND s.kind = 'ban'
+				  AND (s.channel_id = c.id OR s.channel_id IS NULL)
+				  AND s.revoked_at IS NULL
+				  AND (s.expires_at IS NULL OR s.expires_at > NOW())
+			)
 			ORDER BY c.created_at ASC
 		`, placeholders)
 		rows, err = r.db.QueryContext(ctx, query, args...)
@@ -146,7 +159,7 @@ func (r *ChatRepository) ListAccessibleChannels(
 		var ch Channel
 		if err := rows.Scan(
 			&ch.ID, &ch.Slug, &ch.Name, &ch.Description,
-			&ch.IsPrivate, &ch.IsDM, &ch.CreatedBy, &ch.CreatedAt,
+			&ch.IsPrivate, &ch.IsDM, &ch.SlowMode, &ch.CourseID, &ch.ArchivedAt, &ch.CreatedBy, &ch.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -159,11 +172,11 @@ func (r *ChatRepository) ListAccessibleChannels(
 func (r *ChatRepository) GetChannelByID(ctx context.Context, id int64) (*Channel, error) {
 	ch := &Channel{}
 	err := r.db.QueryRowContext(ctx, `
-		SELECT id, slug, name, description, is_private, is_dm, created_by, created_at
+		SELECT id, slug, name, description, is_private, is_dm, slow_mode_seconds, course_id, archived_at, created_by, created_at
 		FROM chat_channels WHERE id = $1
 	`, id).Scan(
 		&ch.ID, &ch.Slug, &ch.Name, &ch.Description,
-		&ch.IsPrivate, &ch.IsDM, &ch.CreatedBy, &ch.CreatedAt,
+		&ch.IsPrivate, &ch.IsDM, &ch.SlowMode, &ch.CourseID, &ch.ArchivedAt, &ch.CreatedBy, &ch.CreatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -188,10 +201,10 @@ func (r *ChatRepository) CreateChannel(
 	err = tx.QueryRowContext(ctx, `
 		INSERT INTO chat_channels (slug, name, description, is_private, is_dm, created_by)
 		VALUES ($1, $2, $3, $4, false, $5)
-		RETURNING id, slug, name, description, is_private, is_dm, created_by, created_at
+		RETURNING id, slug, name, description, is_private, is_dm, slow_mode_seconds, course_id, archived_at, created_by, created_at
 	`, slug, name, description, isPrivate, createdBy).Scan(
 		&ch.ID, &ch.Slug, &ch.Name, &ch.Description,
-		&ch.IsPrivate, &ch.IsDM, &ch.CreatedBy, &ch.CreatedAt,
+		&ch.IsPrivate, &ch.IsDM, &ch.SlowMode, &ch.CourseID, &ch.ArchivedAt, &ch.CreatedBy, &ch.CreatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -233,10 +246,10 @@ func (r *ChatRepository) UpdateChannel(
 		UPDATE chat_channels
 		SET name = $2, description = $3, is_private = $4
 		WHERE id = $1
-		RETURNING id, slug, name, description, is_private, is_dm, created_by, created_at
+		RETURNING id, slug, name, description, is_private, is_dm, slow_mode_seconds, course_id, archived_at, created_by, created_at
 	`, id, name, description, isPrivate).Scan(
 		&ch.ID, &ch.Slug, &ch.Name, &ch.Description,
-		&ch.IsPrivate, &ch.IsDM, &ch.CreatedBy, &ch.CreatedAt,
+		&ch.IsPrivate, &ch.IsDM, &ch.SlowMode, &ch.CourseID, &ch.ArchivedAt, &ch.CreatedBy, &ch.CreatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -250,7 +263,7 @@ func (r *ChatRepository) GetOrCreateDMChannel(ctx context.Context, user1ID, user
 	// First, try to find an existing DM channel where both users are whitelisted.
 	ch := &Channel{}
 	err := r.db.QueryRowContext(ctx, `
-		SELECT c.id, c.slug, c.name, c.description, c.is_private, c.is_dm, c.created_by, c.created_at
+		SELECT c.id, c.slug, c.name, c.description, c.is_private, c.is_dm, c.slow_mode_seconds, c.course_id, c.archived_at, c.created_by, c.created_at
 		FROM chat_channels c
 		JOIN chat_channel_users ccu1 ON ccu1.channel_id = c.id AND ccu1.user_id = $1
 		JOIN chat_channel_users ccu2 ON ccu2.channel_id = c.id AND ccu2.user_id = $2
@@ -258,7 +271,7 @@ func (r *ChatRepository) GetOrCreateDMChannel(ctx context.Context, user1ID, user
 		LIMIT 1
 	`, user1ID, user2ID).Scan(
 		&ch.ID, &ch.Slug, &ch.Name, &ch.Description,
-		&ch.IsPrivate, &ch.IsDM, &ch.CreatedBy, &ch.CreatedAt,
+		&ch.IsPrivate, &ch.IsDM, &ch.SlowMode, &ch.CourseID, &ch.ArchivedAt, &ch.CreatedBy, &ch.CreatedAt,
 	)
 	if err == nil {
 		return ch, nil
@@ -289,10 +302,10 @@ func (r *ChatRepository) GetOrCreateDMChannel(ctx context.Context, user1ID, user
 		INSERT INTO chat_channels (slug, name, description, is_private, is_dm, created_by)
 		VALUES ($1, $2, $3, true, true, $4)
 		ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
-		RETURNING id, slug, name, description, is_private, is_dm, created_by, created_at
+		RETURNING id, slug, name, description, is_private, is_dm, slow_mode_seconds, course_id, archived_at, created_by, created_at
 	`, slug, name, description, user1ID).Scan(
 		&ch.ID, &ch.Slug, &ch.Name, &ch.Description,
-		&ch.IsPrivate, &ch.IsDM, &ch.CreatedBy, &ch.CreatedAt,
+		&ch.IsPrivate, &ch.IsDM, &ch.SlowMode, &ch.CourseID, &ch.ArchivedAt, &ch.CreatedBy, &ch.CreatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("create dm channel: %w", err)
@@ -490,7 +503,7 @@ func (r *ChatRepository) SearchMentionableUsers(ctx context.Context, channelID,
 		rows, err = r.db.QueryContext(ctx, `
 			SELECT id, email, full_name, profile_picture
 			FROM users
-			WHERE id != $1 AND (LOWER(email) LIKE $2 OR LOWER(full_name) LIKE $2)
+			WHERE id != $1 AND id > 0 AND (LOWER(email) LIKE $2 OR LOWER(full_name) LIKE $2)
 			ORDER BY full_name ASC
 			LIMIT $3
 		`, excludeUserID, pattern, limit)
@@ -514,6 +527,7 @@ func (r *ChatRepository) SearchMentionableUsers(ctx context.Context, channelID,
 }
 
 // CanUserAccess returns (canRead, canWrite) for the given channel+user+roles combination.
+// Banned users get neither; an archived channel is read-only for everyone but admins.
 func (r *ChatRepository) CanUserAccess(
 	ctx context.Context,
 	channelID, userID int64,
@@ -526,6 +540,19 @@ func (r *ChatRepository) CanUserAccess(
 		}
 	}
 
+	// A ban removes all access until it expires or is revoked; an archived
+	// channel stays readable but takes no new messages
+	var banned, archived bool
+	if err = r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM chat_user_sanctions WHERE `+activeSanction+`),
+		       EXISTS(SELECT 1 FROM chat_channels WHERE id = $1 AND archived_at IS NOT NULL)
+	`, channelID, userID, SanctionBan).Scan(&banned, &archived); err != nil {
+		return
+	}
+	if banned {
+		return false, false, nil
+	}
+
 	// Check user whitelist
 	var inWhitelist bool
 	if err = r.db.QueryRowContext(ctx, `
@@ -534,7 +561,7 @@ func (r *ChatRepository) CanUserAccess(
 		return
 	}
 	if inWhitelist {
-		return true, true, nil
+		return true, !archived, nil
 	}
 
 	// Check role permissions - aggregate max permissions across all matching roles
@@ -552,7 +579,90 @@ func (r *ChatRepository) CanUserAccess(
 	if err = r.db.QueryRowContext(ctx, query, args...).Scan(&r1, &w1); err != nil {
 		return
 	}
-	return r1.Bool, w1.Bool, nil
+	return r1.Bool, w1.Bool && !archived, nil
+}
+
+// ChannelReadAccess is what CanUserAccess consults for reading one channel,
+// loaded for many users at once.
+type ChannelReadAccess struct {
+	banned  map[int64]bool
+	members map[int64]bool
+	roles   map[string]bool // roles with can_read
+}
+
+// CanRead applies the read rules of CanUserAccess to userID with roles.
+func (a *ChannelReadAccess) CanRead(userID int64, roles []string) bool {
+	for _, role := range roles {
+		if role == "ADMIN" {
+			return true
+		}
+	}
+	if a.banned[userID] {
+		return false
+	}
+	if a.members[userID] {
+		return true
+	}
+	for _, role := range roles {
+		if a.roles[role] {
+			return true
+		}
+	}
+	return false
+}
+
+// GetChannelReadAccess loads, in one query, the bans and whitelist entries of
+// userIDs on channelID and the roles that may read it.
+func (r *ChatRepository) GetChannelReadAccess(ctx context.Context, channelID int64, userIDs []int64) (*ChannelReadAccess, error) {
+	access := &ChannelReadAccess{
+		banned:  make(map[int64]bool),
+		members: make(map[int64]bool),
+		roles:   make(map[string]bool),
+	}
+	placeholders, idArgs := idPlaceholders(userIDs, 2)
+	if len(userIDs) == 0 {
+		placeholders = "NULL"
+	}
+	args := append([]interface{}{channelID, SanctionBan}, idArgs...)
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT user_id, NULL, TRUE
+		FROM chat_user_sanctions
+		WHERE user_id IN (%[1]s) AND kind = $2
+		  AND (channel_id = $1 OR channel_id IS NULL)
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		UNION ALL
+		SELECT user_id, NULL, FALSE
+		FROM chat_channel_users
+		WHERE channel_id = $1 AND user_id IN (%[1]s)
+		UNION ALL
+		SELECT NULL, role_name, FALSE
+		FROM chat_channel_roles
+		WHERE channel_id = $1 AND can_read
+	`, placeholders), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID sql.NullInt64
+		var role sql.NullString
+		var banned bool
+		if err := rows.Scan(&userID, &role, &banned); err != nil {
+			return nil, err
+		}
+		switch {
+		case role.Valid:
+			access.roles[role.String] = true
+		case banned:
+			access.banned[userID.Int64] = true
+		default:
+			access.members[userID.Int64] = true
+		}
+	}
+	return access, rows.Err()
 }
 
 // ─── Messages ─────────────────────────────────────────────────────────────────
@@ -560,10 +670,12 @@ func (r *ChatRepository) CanUserAccess(
 // ListMessages returns up to `limit` messages before `beforeID` (cursor pagination).
 // If beforeID == 0, returns the latest `limit` messages.
 // Results are returned oldest-first within the page for chronological display.
+// With topLevelOnly, thread replies are left out of the timeline.
 func (r *ChatRepository) ListMessages(
 	ctx context.Context,
 	channelID, beforeID int64,
 	limit int,
+	topLevelOnly bool,
 ) ([]Message, error) {
 	if limit <= 0 || limit > 100 {
 		limit = defaultPageSize
@@ -577,48 +689,22 @@ func (r *ChatRepository) ListMessages(
 	// We fetch newest-first using a cursor, then reverse in code for display order.
 	if beforeID > 0 {
 		rows, err = r.db.QueryContext(ctx, `
-			SELECT m.id, m.channel_id, m.sender_id,
-			       COALESCE(u.full_name, u.email)       AS sender_name,
-			       u.email                              AS sender_email,
-			       COALESCE(u.profile_picture, '')       AS sender_avatar,
-			       CASE WHEN m.is_deleted THEN '[deleted]' ELSE m.body END AS body,
-			       m.is_deleted, m.is_edited,
-			       m.parent_id,
-			       COALESCE(pu.full_name, pu.email, '') AS parent_sender_name,
-			       CASE WHEN pm.is_deleted THEN '[deleted]'
-			            WHEN pm.body IS NOT NULL THEN LEFT(pm.body, 200)
-			            ELSE '' END                      AS parent_body,
-			       m.created_at
-			FROM chat_messages m
-			JOIN users u ON u.id = m.sender_id
-			LEFT JOIN chat_messages pm ON pm.id = m.parent_id
-			LEFT JOIN users pu ON pu.id = pm.sender_id
+			SELECT `+messageColumns+`
+			`+messageJoins+`
 			WHERE m.channel_id = $1 AND m.id < $2
+			  AND (NOT $4 OR m.thread_root_id IS NULL)
 			ORDER BY m.id DESC
 			LIMIT $3
-		`, channelID, beforeID, limit)
+		`, channelID, beforeID, limit, topLevelOnly)
 	} else {
 		rows, err = r.db.QueryContext(ctx, `
-			SELECT m.id, m.channel_id, m.sender_id,
-			       COALESCE(u.full_name, u.email)       AS sender_name,
-			       u.email                              AS sender_email,
-			       COALESCE(u.profile_picture, '')       AS sender_avatar,
-			       CASE WHEN m.is_deleted THEN '[deleted]' ELSE m.body END AS body,
-			       m.is_deleted, m.is_edited,
-			       m.parent_id,
-			       COALESCE(pu.full_name, pu.email, '') AS parent_sender_name,
-			       CASE WHEN pm.is_deleted THEN '[deleted]'
-			            WHEN pm.body IS NOT NULL THEN LEFT(pm.body, 200)
-			            ELSE '' END                      AS parent_body,
-			       m.created_at
-			FROM chat_messages m
-			JOIN users u ON u.id = m.sender_id
-			LEFT JOIN chat_messages pm ON pm.id = m.parent_id
-			LEFT JOIN users pu ON pu.id = pm.sender_id
+			SELECT `+messageColumns+`
+			`+messageJoins+`
 			WHERE m.channel_id = $1
+			  AND (NOT $3 OR m.thread_root_id IS NULL)
 			ORDER BY m.id DESC
 			LIMIT $2
-		`, channelID, limit)
+		`, channelID, limit, topLevelOnly)
 	}
 
 	if err != nil {
@@ -626,29 +712,8 @@ func (r *ChatRepository) ListMessages(
 	}
 	defer rows.Close()
 
-	var msgs []Message
-	for rows.Next() {
-		var msg Message
-		var parentID sql.NullInt64
-		var parentSenderName, parentBody sql.NullString
-		if err := rows.Scan(
-			&msg.ID, &msg.ChannelID, &msg.SenderID,
-			&msg.SenderName, &msg.SenderEmail, &msg.SenderAvatar,
-			&msg.Body, &msg.IsDeleted, &msg.IsEdited,
-			&parentID, &parentSenderName, &parentBody,
-			&msg.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		if parentID.Valid {
-			v := parentID.Int64
-			msg.ParentID = &v
-			msg.ParentSenderName = parentSenderName.String
-			msg.ParentBody = parentBody.String
-		}
-		msgs = append(msgs, msg)
-	}
-	if err := rows.Err(); err != nil {
+	msgs, err := scanMessageRows(rows)
+	if err != nil {
 		return nil, err
 	}
 	if err := r.populateAttachments(ctx, msgs); err != nil {
@@ -663,32 +728,120 @@ func (r *ChatRepository) ListMessages(
 	return msgs, nil
 }
 
+// ListMessagesAfter returns up to `limit` messages with an ID greater than
+// afterID, oldest first. It is how a reconnecting client catches up on what
+// was published while it was away.
+func (r *ChatRepository) ListMessagesAfter(
+	ctx context.Context,
+	channelID, afterID int64,
+	limit int,
+) ([]Message, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+messageColumns+`
+		`+messageJoins+`
+		WHERE m.channel_id = $1 AND m.id > $2
+		ORDER BY m.id ASC
+		LIMIT $3
+	`, channelID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	msgs, err := scanMessageRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.populateAttachments(ctx, msgs); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
 // CreateMessage inserts a new message and returns it with sender info.
 // parentID is nil for top-level messages, non-nil for direct replies.
+// clientMsgID is the sender's idempotency key in the channel (empty = none):
+// when the sender already stored a message under it there, that message is
+// returned with created = false and nothing is inserted. With slowMode set a new message
+// counts against the channel's slow mode and a *SlowModeError is returned
+// while the sender's window has not passed; a retry of a stored message is
+// never held back.
 func (r *ChatRepository) CreateMessage(
 	ctx context.Context,
 	channelID, senderID int64,
 	body string,
 	parentID *int64,
-) (*Message, error) {
+	clientMsgID string,
+	slowMode bool,
+) (msg *Message, created bool, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
 	var msgID int64
-	var createdAt time.Time
+	if clientMsgID != "" {
+		err := tx.QueryRowContext(ctx, `
+			SELECT id FROM chat_messages WHERE channel_id = $1 AND sender_id = $2 AND client_msg_id = $3
+		`, channelID, senderID, clientMsgID).Scan(&msgID)
+		switch {
+		case err == nil:
+			return r.storedMessage(ctx, msgID)
+		case err != sql.ErrNoRows:
+			return nil, false, fmt.Errorf("find deduplicated message: %w", err)
+		}
+	}
+	if slowMode {
+		if err := claimSlowMode(ctx, tx, channelID, senderID); err != nil {
+			return nil, false, err
+		}
+	}
 
-	err := r.db.QueryRowContext(ctx, `
-		INSERT INTO chat_messages (channel_id, sender_id, body, parent_id)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at
-	`, channelID, senderID, body, nullInt64(parentID)).Scan(&msgID, &createdAt)
-	if err != nil {
-		return nil, fmt.Errorf("create message: %w", err)
+	var threadRootID sql.NullInt64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO chat_messages (channel_id, sender_id, body, parent_id, thread_root_id, client_msg_id)
+		VALUES ($1, $2, $3, $4, (`+threadRootOf4+`), NULLIF($5, ''))
+		ON CONFLICT (channel_id, sender_id, client_msg_id) WHERE client_msg_id IS NOT NULL DO NOTHING
+		RETURNING id, thread_root_id
+	`, channelID, senderID, body, nullInt64(parentID), clientMsgID).Scan(&msgID, &threadRootID)
+	switch {
+	case err == sql.ErrNoRows:
+		// A concurrent retry stored it first; rolling back leaves the slow
+		// mode claim uncounted
+		if err := tx.QueryRowContext(ctx, `
+			SELECT id FROM chat_messages WHERE channel_id = $1 AND sender_id = $2 AND client_msg_id = $3
+		`, channelID, senderID, clientMsgID).Scan(&msgID); err != nil {
+			return nil, false, fmt.Errorf("find deduplicated message: %w", err)
+		}
+		return r.storedMessage(ctx, msgID)
+	case err != nil:
+		return nil, false, fmt.Errorf("create message: %w", err)
+	}
+	if threadRootID.Valid {
+		if err := followThreadOnReply(ctx, tx, threadRootID.Int64, senderID, msgID); err != nil {
+			return nil, false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
 	}
 
 	// Fetch with sender join for the full response
-	msgs, err := r.getMessageByID(ctx, msgID)
+	msg, err = r.getMessageByID(ctx, msgID)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	return msgs, nil
+	return msg, true, nil
+}
+
+// storedMessage returns a deduplicated message with created = false.
+func (r *ChatRepository) storedMessage(ctx context.Context, msgID int64) (*Message, bool, error) {
+	msg, err := r.getMessageByID(ctx, msgID)
+	if err != nil {
+		return nil, false, err
+	}
+	return msg, false, nil
 }
 
 // CreateMessageWithAttachment atomically creates a (possibly caption-less)
@@ -700,19 +853,21 @@ func (r *ChatRepository) CreateMessageWithAttachment(
 	body string,
 	parentID *int64,
 	attachment Attachment,
+	slowMode bool,
 ) (*Message, error) {
-	return r.CreateMessageWithAttachments(ctx, channelID, senderID, body, parentID, []Attachment{attachment})
+	return r.CreateMessageWithAttachments(ctx, channelID, senderID, body, parentID, []Attachment{attachment}, slowMode)
 }
 
 // CreateMessageWithAttachments atomically creates one message and all of its
 // attachment metadata. The caller owns cleanup of already-uploaded objects if
-// this transaction fails.
+// this transaction fails. slowMode is as for CreateMessage.
 func (r *ChatRepository) CreateMessageWithAttachments(
 	ctx context.Context,
 	channelID, senderID int64,
 	body string,
 	parentID *int64,
 	attachments []Attachment,
+	slowMode bool,
 ) (*Message, error) {
 	if len(attachments) == 0 {
 		return nil, fmt.Errorf("at least one attachment is required")
@@ -723,14 +878,25 @@ func (r *ChatRepository) CreateMessageWithAttachments(
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	if slowMode {
+		if err := claimSlowMode(ctx, tx, channelID, senderID); err != nil {
+			return nil, err
+		}
+	}
 	var msgID int64
+	var threadRootID sql.NullInt64
 	if err := tx.QueryRowContext(ctx, `
-		INSERT INTO chat_messages (channel_id, sender_id, body, parent_id)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id
-	`, channelID, senderID, body, nullInt64(parentID)).Scan(&msgID); err != nil {
+		INSERT INTO chat_messages (channel_id, sender_id, body, parent_id, thread_root_id)
+		VALUES ($1, $2, $3, $4, (`+threadRootOf4+`))
+		RETURNING id, thread_root_id
+	`, channelID, senderID, body, nullInt64(parentID)).Scan(&msgID, &threadRootID); err != nil {
 		return nil, fmt.Errorf("create attachment message: %w", err)
 	}
+	if threadRootID.Valid {
+		if err := followThreadOnReply(ctx, tx, threadRootID.Int64, senderID, msgID); err != nil {
+			return nil, err
+		}
+	}
 
 	stmt, err := tx.PrepareContext(ctx, `
 		INSERT INTO chat_attachments (id, message_id, object_key, file_name, mime_type, size_bytes)
@@ -753,41 +919,14 @@ func (r *ChatRepository) CreateMessageWithAttachments(
 
 func (r *ChatRepository) getMessageByID(ctx context.Context, msgID int64) (*Message, error) {
 	msg := &Message{}
-	var parentID sql.NullInt64
-	var parentSenderName, parentBody sql.NullString
-	err := r.db.QueryRowContext(ctx, `
-		SELECT m.id, m.channel_id, m.sender_id,
-		       COALESCE(u.full_name, u.email)       AS sender_name,
-		       u.email                              AS sender_email,
-		       COALESCE(u.profile_picture, '')       AS sender_avatar,
-		       m.body, m.is_deleted, m.is_edited,
-		       m.parent_id,
-		       COALESCE(pu.full_name, pu.email, '') AS parent_sender_name,
-		       CASE WHEN pm.is_deleted THEN '[deleted]'
-		            WHEN pm.body IS NOT NULL THEN LEFT(pm.body, 200)
-		            ELSE '' END                      AS parent_body,
-		       m.created_at
-		FROM chat_messages m
-		JOIN users u ON u.id = m.sender_id
-		LEFT JOIN chat_messages pm ON pm.id = m.parent_id
-		LEFT JOIN users pu ON pu.id = pm.sender_id
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+messageColumns+`
+		`+messageJoins+`
 		WHERE m.id = $1
-	`, msgID).Scan(
-		&msg.ID, &msg.ChannelID, &msg.SenderID,
-		&msg.SenderName, &msg.SenderEmail, &msg.SenderAvatar,
-		&msg.Body, &msg.IsDeleted, &msg.IsEdited,
-		&parentID, &parentSenderName, &parentBody,
-		&msg.CreatedAt,
-	)
-	if err != nil {
+	`, msgID)
+	if err := scanMessage(row, msg); err != nil {
 		return nil, err
 	}
-	if parentID.Valid {
-		v := parentID.Int64
-		msg.ParentID = &v
-		msg.ParentSenderName = parentSenderName.String
-		msg.ParentBody = parentBody.String
-	}
 	one := []Message{*msg}
 	if err := r.populateAttachments(ctx, one); err != nil {
 		return nil, err
@@ -796,6 +935,40 @@ func (r *ChatRepository) getMessageByID(ctx context.Context, msgID int64) (*Mess
 	return msg, nil
 }
 
+// getMessagesByIDs loads several messages keyed by ID. Deleted messages are
+// masked as in ListMessages; IDs that do not exist are absent from the map.
+func (r *ChatRepository) getMessagesByIDs(ctx context.Context, msgIDs []int64) (map[int64]Message, error) {
+	result := make(map[int64]Message, len(msgIDs))
+	if len(msgIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders, args := idPlaceholders(msgIDs, 0)
+	query := fmt.Sprintf(`
+		SELECT `+messageColumns+`
+		`+messageJoins+`
+		WHERE m.id IN (%s)
+	`, placeholders)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	msgs, err := scanMessageRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.populateAttachments(ctx, msgs); err != nil {
+		return nil, err
+	}
+	for _, m := range msgs {
+		result[m.ID] = m
+	}
+	return result, nil
+}
+
 func (r *ChatRepository) populateAttachments(ctx context.Context, messages []Message) error {
 	if len(messages) == 0 {
 		return nil
@@ -889,14 +1062,12 @@ func (r *ChatRepository) SoftDeleteMessage(
 	isAdmin bool,
 ) error {
 	var query string
-	var args []interface{}
+	args := []interface{}{msgID, callerID}
 
 	if isAdmin {
-		query = `UPDATE chat_messages SET is_deleted = true WHERE id = $1`
-		args = []interface{}{msgID}
+		query = `UPDATE chat_messages SET is_deleted = true, deleted_by = $2, deleted_at = NOW() WHERE id = $1`
 	} else {
-		query = `UPDATE chat_messages SET is_deleted = true WHERE id = $1 AND sender_id = $2`
-		args = []interface{}{msgID, callerID}
+		query = `UPDATE chat_messages SET is_deleted = true, deleted_by = $2, deleted_at = NOW() WHERE id = $1 AND sender_id = $2`
 	}
 
 	res, err := r.db.ExecContext(ctx, query, args...)
@@ -925,7 +1096,7 @@ func (r *ChatRepository) GetMessageChannelID(ctx context.Context, msgID int64) (
 // ListAllChannels returns all channels for admin view (excludes DMs).
 func (r *ChatRepository) ListAllChannels(ctx context.Context) ([]Channel, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT id, slug, name, description, is_private, is_dm, created_by, created_at
+		SELECT id, slug, name, description, is_private, is_dm, slow_mode_seconds, course_id, archived_at, created_by, created_at
 		FROM chat_channels
 		WHERE is_dm = false
 		ORDER BY created_at ASC
@@ -940,7 +1111,7 @@ func (r *ChatRepository) ListAllChannels(ctx context.Context) ([]Channel, error)
 		var ch Channel
 		if err := rows.Scan(
 			&ch.ID, &ch.Slug, &ch.Name, &ch.Description,
-			&ch.IsPrivate, &ch.IsDM, &ch.CreatedBy, &ch.CreatedAt,
+			&ch.IsPrivate, &ch.IsDM, &ch.SlowMode, &ch.CourseID, &ch.ArchivedAt, &ch.CreatedBy, &ch.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -1007,6 +1178,86 @@ func buildRoleArgs(firstArg interface{}, roles []string) (string, []interface{})
 	return strings.Join(phs, ","), args
 }
 
+// idPlaceholders builds an IN list for ids whose placeholders start after
+// the first `offset` query arguments, e.g. offset 1 gives "$2,$3,...".
+func idPlaceholders(ids []int64, offset int) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	phs := make([]string, len(ids))
+	for i, id := range ids {
+		args[i] = id
+		phs[i] = fmt.Sprintf("$%d", offset+i+1)
+	}
+	return strings.Join(phs, ","), args
+}
+
+// messageColumns selects a message with its sender and the preview of the
+// message it replies to, masking deleted bodies. The columns need the tables
+// of messageJoins and are read back with scanMessage.
+const messageColumns = `m.id, m.channel_id, m.sender_id,
+		       COALESCE(u.full_name, u.email)       AS sender_name,
+		       u.email                              AS sender_email,
+		       COALESCE(u.profile_picture, '')       AS sender_avatar,
+		       CASE WHEN m.is_deleted THEN '[deleted]' ELSE m.body END AS body,
+		       m.is_deleted, m.is_edited,
+		       m.parent_id, m.thread_root_id,
+		       COALESCE(pu.full_name, pu.email, '') AS parent_sender_name,
+		       CASE WHEN pm.is_deleted THEN '[deleted]'
+		            WHEN pm.body IS NOT NULL THEN LEFT(pm.body, 200)
+		            ELSE '' END                      AS parent_body,
+		       m.created_at`
+
+// messageJoins is the FROM clause for messageColumns.
+const messageJoins = `FROM chat_messages m
+		JOIN users u ON u.id = m.sender_id
+		LEFT JOIN chat_messages pm ON pm.id = m.parent_id
+		LEFT JOIN users pu ON pu.id = pm.sender_id`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMessage reads one row selected with messageColumns into msg. extra
+// receives any columns a query selects after them.
+func scanMessage(row rowScanner, msg *Message, extra ...interface{}) error {
+	var parentID, threadRootID sql.NullInt64
+	var parentSenderName, parentBody sql.NullString
+	dest := []interface{}{
+		&msg.ID, &msg.ChannelID, &msg.SenderID,
+		&msg.SenderName, &msg.SenderEmail, &msg.SenderAvatar,
+		&msg.Body, &msg.IsDeleted, &msg.IsEdited,
+		&parentID, &threadRootID, &parentSenderName, &parentBody,
+		&msg.CreatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	if parentID.Valid {
+		v := parentID.Int64
+		msg.ParentID = &v
+		msg.ParentSenderName = parentSenderName.String
+		msg.ParentBody = parentBody.String
+	}
+	if threadRootID.Valid {
+		v := threadRootID.Int64
+		msg.ThreadRootID = &v
+	}
+	return nil
+}
+
+// scanMessageRows reads every row selected with messageColumns.
+func scanMessageRows(rows *sql.Rows) ([]Message, error) {
+	var msgs []Message
+	for rows.Next() {
+		var msg Message
+		if err := scanMessage(rows, &msg); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, rows.Err()
+}
+
 // nullInt64 converts a *int64 pointer to a sql.NullInt64 for DB insertion.
 func nullInt64(v *int64) sql.NullInt64 {
 	if v == nil {
@@ -1028,7 +1279,7 @@ func (r *ChatRepository) SeedDefaultChannel(ctx context.Context) (bool, error) {
 	}
 
 	var firstUserID int64
-	err = r.db.QueryRowContext(ctx, `SELECT id FROM users ORDER BY id ASC LIMIT 1`).Scan(&firstUserID)
+	err = r.db.QueryRowContext(ctx, `SELECT id FROM users WHERE id > 0 ORDER BY id ASC LIMIT 1`).Scan(&firstUserID)
 	if err == sql.ErrNoRows {
 		return false, nil // no users synced yet
 	}
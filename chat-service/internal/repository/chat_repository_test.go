@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChannelReadAccessCanRead(t *testing.T) {
+	access := &ChannelReadAccess{
+		banned:  map[int64]bool{2: true},
+		members: map[int64]bool{2: true, 3: true},
+		roles:   map[string]bool{"TEACHER": true},
+	}
+	cases := []struct {
+		name   string
+		userID int64
+		roles  []string
+		want   bool
+	}{
+		{"admin overrides ban", 2, []string{"ADMIN"}, true},
+		{"ban overrides whitelist", 2, []string{"TEACHER"}, false},
+		{"whitelisted", 3, nil, true},
+		{"readable role", 4, []string{"STUDENT", "TEACHER"}, true},
+		{"no access", 4, []string{"STUDENT"}, false},
+	}
+	for _, tc := range cases {
+		if got := access.CanRead(tc.userID, tc.roles); got != tc.want {
+			t.Errorf("%s: CanRead(%d, %v) = %v; want %v", tc.name, tc.userID, tc.roles, got, tc.want)
+		}
+	}
+}
+
+func TestCreateMessageDeduplicatesPerChannel(t *testing.T) {
+	repo, db := newFakeRepo(t, func(query string, _ []driver.NamedValue) (*fakeRows, error) {
+		switch {
+		case strings.Contains(query, "SELECT id FROM chat_messages"):
+			return oneRow(int64(42)), nil
+		case strings.Contains(query, "WHERE m.id = $1"):
+			return messageRow(42, 5, 9), nil
+		}
+		return nil, nil
+	})
+
+	msg, created, err := repo.CreateMessage(context.Background(), 5, 9, "hi", nil, "retry-1", false)
+	if err != nil || created || msg.ID != 42 {
+		t.Fatalf("CreateMessage = %v, %v, %v; want stored message 42", msg, created, err)
+	}
+	lookup := db.statements()[0]
+	if want := []interface{}{int64(5), int64(9), "retry-1"}; !reflect.DeepEqual(lookup.Args, want) {
+		t.Errorf("dedup lookup args = %v; want channel, sender and key %v", lookup.Args, want)
+	}
+	for _, stmt := range db.statements() {
+		if strings.Contains(stmt.Query, "INSERT INTO chat_messages") {
+			t.Error("retry inserted a second message")
+		}
+	}
+}
+
+func TestCreateMessageConcurrentRetryReturnsStoredMessage(t *testing.T) {
+	// The first lookup misses; the insert then conflicts with a concurrent
+	// retry and the message it stored is returned
+	lookups := 0
+	repo, db := newFakeRepo(t, func(query string, _ []driver.NamedValue) (*fakeRows, error) {
+		switch {
+		case strings.Contains(query, "INSERT INTO chat_messages"):
+			return nil, nil
+		case strings.Contains(query, "SELECT id FROM chat_messages"):
+			lookups++
+			if lookups == 1 {
+				return nil, nil
+			}
+			return oneRow(int64(42)), nil
+		case strings.Contains(query, "WHERE m.id = $1"):
+			return messageRow(42, 5, 9), nil
+		}
+		return nil, nil
+	})
+
+	msg, created, err := repo.CreateMessage(context.Background(), 5, 9, "hi", nil, "retry-1", false)
+	if err != nil || created || msg.ID != 42 {
+		t.Fatalf("CreateMessage = %v, %v, %v; want stored message 42", msg, created, err)
+	}
+	for _, stmt := range db.statements() {
+		if strings.Contains(stmt.Query, "INSERT INTO chat_messages") &&
+			!strings.Contains(stmt.Query, "ON CONFLICT (channel_id, sender_id, client_msg_id)") {
+			t.Errorf("insert does not deduplicate per channel:\n%s", stmt.Query)
+		}
+	}
+}
+
+func TestListMessagesLeavesThreadRepliesOut(t *testing.T) {
+	// Newest first from the database, a reply to message 7 among them
+	created := time.Unix(1700000000, 0)
+	repo, db := newFakeRepo(t, func(query string, _ []driver.NamedValue) (*fakeRows, error) {
+		if strings.Contains(query, "FROM chat_messages m") {
+			return resultRows(
+				[]driver.Value{int64(8), int64(5), int64(9), "Bob", "bob@example.com", "",
+					"[deleted]", true, false, int64(7), int64(7), "Ann", "hi", created},
+				[]driver.Value{int64(7), int64(5), int64(3), "Ann", "ann@example.com", "",
+					"hi", false, true, nil, nil, "", "", created},
+			), nil
+		}
+		return nil, nil
+	})
+
+	msgs, err := repo.ListMessages(context.Background(), 5, 0, 20, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := db.statements()[0]
+	if !strings.Contains(list.Query, "(NOT $3 OR m.thread_root_id IS NULL)") {
+		t.Errorf("timeline query does not filter thread replies:\n%s", list.Query)
+	}
+	if want := []interface{}{int64(5), 20, true}; !reflect.DeepEqual(list.Args, want) {
+		t.Errorf("args = %v; want %v", list.Args, want)
+	}
+
+	if len(msgs) != 2 || msgs[0].ID != 7 || msgs[1].ID != 8 {
+		t.Fatalf("messages = %+v; want 7 then 8", msgs)
+	}
+	if m := msgs[0]; m.SenderName != "Ann" || !m.IsEdited || m.ParentID != nil || m.ThreadRootID != nil {
+		t.Errorf("root scanned as %+v", m)
+	}
+	if m := msgs[1]; m.Body != "[deleted]" || !m.IsDeleted || m.ParentID == nil || *m.ParentID != 7 ||
+		m.ThreadRootID == nil || *m.ThreadRootID != 7 || m.ParentSenderName != "Ann" || m.ParentBody != "hi" {
+		t.Errorf("reply scanned as %+v", m)
+	}
+}
@@ -0,0 +1,463 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Retention actions stored in chat_retention_policies.
+const (
+	RetentionDelete    = "delete"    // expired messages are removed with their attachments
+	RetentionAnonymize = "anonymize" // expired messages lose the sender only; body and attachments stay
+)
+
+// AnonymousUserID is the placeholder user that anonymized messages are
+// reassigned to.
+const AnonymousUserID int64 = 0
+
+// RetentionPolicy is a channel's retention settings. RetentionDays nil
+// keeps messages forever; a legal hold suspends retention and purging.
+type RetentionPolicy struct {
+	ChannelID       int64
+	RetentionDays   *int
+	Action          string
+	LegalHold       bool
+	LegalHoldReason string
+	LegalHoldBy     *int64
+	LegalHoldAt     *time.Time
+	UpdatedBy       int64
+	UpdatedAt       time.Time
+}
+
+// ExportMessage is a message as an investigator sees it: the stored body
+// even when deleted, with who deleted it and why.
+type ExportMessage struct {
+	Message
+	DeletedBy     *int64
+	DeletedReason string
+	DeletedAt     *time.Time
+}
+
+// notHeld matches channel $1 unless it is under a legal hold. It is part of
+// every retention statement so a hold placed mid-run stops the next batch.
+const notHeld = `NOT EXISTS (
+	SELECT 1 FROM chat_retention_policies hp
+	WHERE hp.channel_id = $1 AND hp.legal_hold)`
+
+// retentionLockKey serialises retention runs across replicas
+const retentionLockKey = "chat-service:retention"
+
+// ─── Policies ─────────────────────────────────────────────────────────────────
+
+const retentionPolicyColumns = `
+	channel_id, retention_days, action, legal_hold, legal_hold_reason,
+	legal_hold_by, legal_hold_at, updated_by, updated_at`
+
+// GetRetentionPolicy returns channelID's policy, or nil when it has none.
+func (r *ChatRepository) GetRetentionPolicy(ctx context.Context, channelID int64) (*RetentionPolicy, error) {
+	p, err := scanRetentionPolicy(r.db.QueryRowContext(ctx,
+		`SELECT `+retentionPolicyColumns+` FROM chat_retention_policies WHERE channel_id = $1`, channelID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// SetRetentionPolicy sets how long channelID keeps messages; days nil
+// keeps them forever. A legal hold is left as it is.
+func (r *ChatRepository) SetRetentionPolicy(ctx context.Context, channelID int64, days *int, action string, actorID int64) (*RetentionPolicy, error) {
+	return scanRetentionPolicy(r.db.QueryRowContext(ctx, `
+		INSERT INTO chat_retention_policies (channel_id, retention_days, action, updated_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (channel_id) DO UPDATE
+		SET retention_days = EXCLUDED.retention_days, action = EXCLUDED.action,
+		    updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		RETURNING `+retentionPolicyColumns,
+		channelID, nullInt(days), action, actorID,
+	))
+}
+
+// SetLegalHold places or lifts a legal hold on channelID, recording who
+// changed it last.
+func (r *ChatRepository) SetLegalHold(ctx context.Context, channelID int64, hold bool, reason string, actorID int64) (*RetentionPolicy, error) {
+	if !hold {
+		reason = ""
+	}
+	return scanRetentionPolicy(r.db.QueryRowContext(ctx, `
+		INSERT INTO chat_retention_policies
+		       (channel_id, legal_hold, legal_hold_reason, legal_hold_by, legal_hold_at, updated_by)
+		VALUES ($1, $2, $3, $4, NOW(), $4)
+		ON CONFLICT (channel_id) DO UPDATE
+		SET legal_hold = EXCLUDED.legal_hold, legal_hold_reason = EXCLUDED.legal_hold_reason,
+		    legal_hold_by = EXCLUDED.legal_hold_by, legal_hold_at = NOW(),
+		    updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		RETURNING `+retentionPolicyColumns,
+		channelID, hold, reason, actorID,
+	))
+}
+
+// ListExpiringPolicies returns the policies with a retention period on
+// channels not under a legal hold.
+func (r *ChatRepository) ListExpiringPolicies(ctx context.Context) ([]RetentionPolicy, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+retentionPolicyColumns+`
+		FROM chat_retention_policies
+		WHERE retention_days IS NOT NULL AND NOT legal_hold
+		ORDER BY channel_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []RetentionPolicy
+	for rows.Next() {
+		p, err := scanRetentionPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *p)
+	}
+	return policies, rows.Err()
+}
+
+func scanRetentionPolicy(row interface{ Scan(...interface{}) error }) (*RetentionPolicy, error) {
+	var p RetentionPolicy
+	var days sql.NullInt32
+	var holdBy sql.NullInt64
+	var holdAt sql.NullTime
+	if err := row.Scan(
+		&p.ChannelID, &days, &p.Action, &p.LegalHold, &p.LegalHoldReason,
+		&holdBy, &holdAt, &p.UpdatedBy, &p.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if days.Valid {
+		v := int(days.Int32)
+		p.RetentionDays = &v
+	}
+	if holdBy.Valid {
+		v := holdBy.Int64
+		p.LegalHoldBy = &v
+	}
+	if holdAt.Valid {
+		v := holdAt.Time
+		p.LegalHoldAt = &v
+	}
+	return &p, nil
+}
+
+// ─── Retention ────────────────────────────────────────────────────────────────
+
+// ExpireMessages applies action to up to limit messages of channelID sent
+// before cutoff. Messages with an open report wait for review, and a thread
+// root waits for its last reply before it is deleted. Anonymizing reassigns
+// messages to AnonymousUserID and drops their reactions and mentions
+// without touching the body or attachments. Returns how many messages
+// changed and the object keys of the attachments deleted with them.
+func (r *ChatRepository) ExpireMessages(
+	ctx context.Context,
+	channelID int64,
+	action string,
+	cutoff time.Time,
+	limit int,
+) (int, []string, error) {
+	expired := `
+		SELECT m.id FROM chat_messages m
+		WHERE m.channel_id = $1 AND m.created_at < $2
+		  AND ` + notHeld + `
+		  AND NOT EXISTS (
+		      SELECT 1 FROM chat_message_reports rp
+		      WHERE rp.message_id = m.id AND rp.status = 'open')`
+
+	if action == RetentionAnonymize {
+		var n int
+		err := r.db.QueryRowContext(ctx, `
+			WITH expired AS (`+expired+`
+				  AND m.sender_id <> $4
+				ORDER BY m.id
+				LIMIT $3
+			), reactions AS (
+				DELETE FROM chat_message_reactions WHERE message_id IN (SELECT id FROM expired)
+			), mentions AS (
+				DELETE FROM chat_message_mentions WHERE message_id IN (SELECT id FROM expired)
+			), anonymized AS (
+				UPDATE chat_messages SET sender_id = $4, client_msg_id = NULL
+				WHERE id IN (SELECT id FROM expired)
+				RETURNING id
+			)
+			SELECT COUNT(*) FROM anonymized
+		`, channelID, cutoff, limit, AnonymousUserID).Scan(&n)
+		if err != nil {
+			return 0, nil, fmt.Errorf("anonymize messages: %w", err)
+		}
+		return n, nil, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	ids, err := selectIDs(ctx, tx, expired+`
+		  AND NOT EXISTS (
+		      SELECT 1 FROM chat_messages reply
+		      WHERE reply.thread_root_id = m.id AND reply.created_at >= $2)
+		ORDER BY m.id
+		LIMIT $3
+		FOR UPDATE OF m SKIP LOCKED
+	`, channelID, cutoff, limit)
+	if err != nil || len(ids) == 0 {
+		return 0, nil, err
+	}
+
+	keys, err := deleteAttachmentRows(ctx, tx, ids)
+	if err != nil {
+		return 0, nil, err
+	}
+	in, args := idPlaceholders(ids, 0)
+	if _, err := tx.ExecContext(ctx, `DELETE FROM chat_messages WHERE id IN (`+in+`)`, args...); err != nil {
+		return 0, nil, fmt.Errorf("delete messages: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	return len(ids), keys, nil
+}
+
+// PurgeDeletedMessages clears the body and attachments of up to limit
+// messages soft-deleted before cutoff, outside held channels. Messages with
+// an open report keep their content until reviewed. Returns how many were
+// purged and the object keys of their attachments.
+func (r *ChatRepository) PurgeDeletedMessages(ctx context.Context, cutoff time.Time, limit int) (int, []string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	ids, err := selectIDs(ctx, tx, `
+		SELECT m.id FROM chat_messages m
+		WHERE m.is_deleted = true
+		  AND COALESCE(m.deleted_at, m.created_at) < $1
+		  AND (m.body <> '' OR EXISTS (SELECT 1 FROM chat_attachments a WHERE a.message_id = m.id))
+		  AND NOT EXISTS (
+		      SELECT 1 FROM chat_retention_policies hp
+		      WHERE hp.channel_id = m.channel_id AND hp.legal_hold)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM chat_message_reports rp
+		      WHERE rp.message_id = m.id AND rp.status = 'open')
+		ORDER BY m.id
+		LIMIT $2
+		FOR UPDATE OF m SKIP LOCKED
+	`, cutoff, limit)
+	if err != nil || len(ids) == 0 {
+		return 0, nil, err
+	}
+
+	keys, err := deleteAttachmentRows(ctx, tx, ids)
+	if err != nil {
+		return 0, nil, err
+	}
+	in, args := idPlaceholders(ids, 0)
+	if _, err := tx.ExecContext(ctx, `UPDATE chat_messages SET body = '' WHERE id IN (`+in+`)`, args...); err != nil {
+		return 0, nil, fmt.Errorf("purge message bodies: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	return len(ids), keys, nil
+}
+
+func selectIDs(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// deleteAttachmentRows deletes the attachments of ids and returns their
+// object keys.
+func deleteAttachmentRows(ctx context.Context, tx *sql.Tx, ids []int64) ([]string, error) {
+	in, args := idPlaceholders(ids, 0)
+	rows, err := tx.QueryContext(ctx,
+		`DELETE FROM chat_attachments WHERE message_id IN (`+in+`) RETURNING object_key`, args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("delete attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// KnownObjectKeys returns which of keys belong to an attachment.
+func (r *ChatRepository) KnownObjectKeys(ctx context.Context, keys []string) (map[string]bool, error) {
+	known := make(map[string]bool)
+	if len(keys) == 0 {
+		return known, nil
+	}
+	phs := make([]string, len(keys))
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		phs[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = key
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT object_key FROM chat_attachments WHERE object_key IN (`+strings.Join(phs, ",")+`)`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		known[key] = true
+	}
+	return known, rows.Err()
+}
+
+// WithRetentionLock runs fn while holding a Postgres advisory lock, so one
+// replica runs retention at a time. Returns false without calling fn when
+// another replica holds the lock.
+func (r *ChatRepository) WithRetentionLock(ctx context.Context, fn func() error) (bool, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx,
+		`SELECT pg_try_advisory_lock(hashtext($1))`, retentionLockKey,
+	).Scan(&locked); err != nil {
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+	defer func() {
+		// The lock belongs to this session, so release it even if ctx ended
+		_, _ = conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, retentionLockKey)
+	}()
+	return true, fn()
+}
+
+// ─── Export ───────────────────────────────────────────────────────────────────
+
+// LatestMessageID returns the newest message of channelID, or 0 when it
+// has none. An export reads up to it so every part covers the same messages.
+func (r *ChatRepository) LatestMessageID(ctx context.Context, channelID int64) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(id), 0) FROM chat_messages WHERE channel_id = $1`, channelID,
+	).Scan(&id)
+	return id, err
+}
+
+// ExportMessages returns up to limit messages of channelID after afterID
+// and up to throughID, oldest first, deleted ones included, with their
+// attachments.
+func (r *ChatRepository) ExportMessages(ctx context.Context, channelID, afterID, throughID int64, limit int) ([]ExportMessage, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT m.id, m.channel_id, m.sender_id,
+		       COALESCE(NULLIF(u.full_name, ''), u.email) AS sender_name,
+		       u.email, m.body, m.is_deleted, m.is_edited,
+		       m.parent_id, m.thread_root_id, m.created_at,
+		       m.deleted_by, COALESCE(m.deleted_reason, ''), m.deleted_at
+		FROM chat_messages m
+		JOIN users u ON u.id = m.sender_id
+		WHERE m.channel_id = $1 AND m.id > $2 AND m.id <= $3
+		ORDER BY m.id ASC
+		LIMIT $4
+	`, channelID, afterID, throughID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []ExportMessage
+	for rows.Next() {
+		var msg ExportMessage
+		var parentID, threadRootID, deletedBy sql.NullInt64
+		var deletedAt sql.NullTime
+		if err := rows.Scan(
+			&msg.ID, &msg.ChannelID, &msg.SenderID, &msg.SenderName,
+			&msg.SenderEmail, &msg.Body, &msg.IsDeleted, &msg.IsEdited,
+			&parentID, &threadRootID, &msg.CreatedAt,
+			&deletedBy, &msg.DeletedReason, &deletedAt,
+		); err != nil {
+			return nil, err
+		}
+		if parentID.Valid {
+			v := parentID.Int64
+			msg.ParentID = &v
+		}
+		if threadRootID.Valid {
+			v := threadRootID.Int64
+			msg.ThreadRootID = &v
+		}
+		if deletedBy.Valid {
+			v := deletedBy.Int64
+			msg.DeletedBy = &v
+		}
+		if deletedAt.Valid {
+			v := deletedAt.Time
+			msg.DeletedAt = &v
+		}
+		msgs = append(msgs, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	plain := make([]Message, len(msgs))
+	for i := range msgs {
+		plain[i] = msgs[i].Message
+	}
+	if err := r.populateAttachments(ctx, plain); err != nil {
+		return nil, err
+	}
+	for i := range msgs {
+		msgs[i].Attachments = plain[i].Attachments
+	}
+	return msgs, nil
+}
+
+// nullInt converts an *int pointer to a sql.NullInt32 for DB insertion.
+func nullInt(v *int) sql.NullInt32 {
+	if v == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(*v), Valid: true}
+}
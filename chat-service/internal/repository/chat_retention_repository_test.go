@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// squash collapses runs of whitespace so SQL can be matched across lines.
+func squash(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Both retention actions must skip held channels and reported messages.
+var expireExclusions = []string{
+	"m.channel_id = $1 AND m.created_at < $2",
+	"NOT EXISTS ( SELECT 1 FROM chat_retention_policies hp WHERE hp.channel_id = $1 AND hp.legal_hold)",
+	"NOT EXISTS ( SELECT 1 FROM chat_message_reports rp WHERE rp.message_id = m.id AND rp.status = 'open')",
+}
+
+func TestExpireMessagesDeleteSkipsHeldAndReported(t *testing.T) {
+	cutoff := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo, db := newFakeRepo(t, func(query string, _ []driver.NamedValue) (*fakeRows, error) {
+		switch {
+		case strings.Contains(query, "SELECT m.id FROM chat_messages"):
+			return resultRows([]driver.Value{int64(11)}, []driver.Value{int64(12)}), nil
+		case strings.Contains(query, "DELETE FROM chat_attachments"):
+			return oneRow("chat/a.png"), nil
+		}
+		return nil, nil
+	})
+
+	n, keys, err := repo.ExpireMessages(context.Background(), 5, RetentionDelete, cutoff, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || !reflect.DeepEqual(keys, []string{"chat/a.png"}) {
+		t.Errorf("ExpireMessages = %d, %v; want 2, [chat/a.png]", n, keys)
+	}
+
+	stmts := db.statements()
+	if len(stmts) != 3 {
+		t.Fatalf("ran %d statements; want select, attachment delete, message delete", len(stmts))
+	}
+	sel := squash(stmts[0].Query)
+	for _, frag := range append(expireExclusions,
+		"NOT EXISTS ( SELECT 1 FROM chat_messages reply WHERE reply.thread_root_id = m.id AND reply.created_at >= $2)",
+		"LIMIT $3 FOR UPDATE OF m SKIP LOCKED",
+	) {
+		if !strings.Contains(sel, frag) {
+			t.Errorf("select lacks %q:\n%s", frag, sel)
+		}
+	}
+	if want := []interface{}{int64(5), cutoff, 100}; !reflect.DeepEqual(stmts[0].Args, want) {
+		t.Errorf("select args = %v; want %v", stmts[0].Args, want)
+	}
+	if q := squash(stmts[2].Query); q != "DELETE FROM chat_messages WHERE id IN ($1,$2)" ||
+		!reflect.DeepEqual(stmts[2].Args, []interface{}{int64(11), int64(12)}) {
+		t.Errorf("delete = %q %v", q, stmts[2].Args)
+	}
+}
+
+func TestExpireMessagesAnonymizeSkipsHeldAndReported(t *testing.T) {
+	cutoff := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo, db := newFakeRepo(t, func(string, []driver.NamedValue) (*fakeRows, error) {
+		return oneRow(int64(3)), nil
+	})
+
+	n, keys, err := repo.ExpireMessages(context.Background(), 5, RetentionAnonymize, cutoff, 100)
+	if err != nil || n != 3 || keys != nil {
+		t.Fatalf("ExpireMessages = %d, %v, %v; want 3 and no keys", n, keys, err)
+	}
+
+	stmts := db.statements()
+	if len(stmts) != 1 {
+		t.Fatalf("ran %d statements; want 1", len(stmts))
+	}
+	q := squash(stmts[0].Query)
+	for _, frag := range append(expireExclusions,
+		"AND m.sender_id <> $4",
+		"DELETE FROM chat_message_reactions WHERE message_id IN (SELECT id FROM expired)",
+		"DELETE FROM chat_message_mentions WHERE message_id IN (SELECT id FROM expired)",
+		"UPDATE chat_messages SET sender_id = $4, client_msg_id = NULL WHERE id IN (SELECT id FROM expired)",
+	) {
+		if !strings.Contains(q, frag) {
+			t.Errorf("query lacks %q:\n%s", frag, q)
+		}
+	}
+	if want := []interface{}{int64(5), cutoff, 100, AnonymousUserID}; !reflect.DeepEqual(stmts[0].Args, want) {
+		t.Errorf("args = %v; want %v", stmts[0].Args, want)
+	}
+}
+
+func TestPurgeDeletedMessagesSkipsHeldAndReported(t *testing.T) {
+	repo, db := newFakeRepo(t, nil)
+
+	if n, _, err := repo.PurgeDeletedMessages(context.Background(), time.Now(), 10); n != 0 || err != nil {
+		t.Fatalf("PurgeDeletedMessages = %d, %v; want nothing to purge", n, err)
+	}
+	stmts := db.statements()
+	if len(stmts) != 1 {
+		t.Fatalf("ran %d statements; want only the select", len(stmts))
+	}
+	q := squash(stmts[0].Query)
+	for _, frag := range []string{
+		"m.is_deleted = true",
+		"NOT EXISTS ( SELECT 1 FROM chat_retention_policies hp WHERE hp.channel_id = m.channel_id AND hp.legal_hold)",
+		"NOT EXISTS ( SELECT 1 FROM chat_message_reports rp WHERE rp.message_id = m.id AND rp.status = 'open')",
+	} {
+		if !strings.Contains(q, frag) {
+			t.Errorf("query lacks %q:\n%s", frag, q)
+		}
+	}
+}
+
+func TestWithRetentionLockSkipsWhenHeldElsewhere(t *testing.T) {
+	repo, db := newFakeRepo(t, func(string, []driver.NamedValue) (*fakeRows, error) {
+		return oneRow(false), nil
+	})
+
+	ran, err := repo.WithRetentionLock(context.Background(), func() error {
+		t.Error("fn ran without the lock")
+		return nil
+	})
+	if ran || err != nil {
+		t.Errorf("WithRetentionLock = %v, %v; want false, nil", ran, err)
+	}
+	stmts := db.statements()
+	if len(stmts) != 1 || !strings.Contains(stmts[0].Query, "pg_try_advisory_lock") {
+		t.Errorf("statements = %v; want only the lock attempt", stmts)
+	}
+}
+
+func TestWithRetentionLockReleasesOnSameSession(t *testing.T) {
+	repo, db := newFakeRepo(t, func(query string, _ []driver.NamedValue) (*fakeRows, error) {
+		if strings.Contains(query, "pg_try_advisory_lock") {
+			return oneRow(true), nil
+		}
+		return nil, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	failed := errors.New("run failed")
+
+	ran, err := repo.WithRetentionLock(ctx, func() error {
+		cancel() // the lock must still be released after ctx ends
+		return failed
+	})
+	if !ran || !errors.Is(err, failed) {
+		t.Errorf("WithRetentionLock = %v, %v; want true, %v", ran, err, failed)
+	}
+
+	stmts := db.statements()
+	if len(stmts) != 2 {
+		t.Fatalf("ran %d statements; want lock and unlock", len(stmts))
+	}
+	lock, unlock := stmts[0], stmts[1]
+	if !strings.Contains(unlock.Query, "pg_advisory_unlock(hashtext($1))") {
+		t.Errorf("second statement = %q; want the unlock", unlock.Query)
+	}
+	if unlock.Conn != lock.Conn {
+		t.Errorf("unlocked on connection %d; lock is held by %d", unlock.Conn, lock.Conn)
+	}
+	for _, s := range stmts {
+		if !reflect.DeepEqual(s.Args, []interface{}{retentionLockKey}) {
+			t.Errorf("%q args = %v; want the retention lock key", s.Query, s.Args)
+		}
+	}
+}
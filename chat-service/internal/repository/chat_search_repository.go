@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Search highlights are wrapped in these private-use runes so the handler can
+// escape the snippet before turning them into markup.
+const (
+	SearchHighlightStart = "\ue000"
+	SearchHighlightStop  = "\ue001"
+)
+
+// SearchFilter narrows a message search. Text is matched with web-search
+// syntax ("quoted phrases", -exclusions, or) against bodies and attachment
+// file names, accent-insensitively. Empty Text matches every message, so the
+// other filters can be used on their own.
+type SearchFilter struct {
+	Text          string
+	ChannelIDs    []int64 // channels to search; must already be access-checked
+	From          string  // sender email, email local part or full name; empty = anyone
+	HasAttachment bool
+	After         time.Time // inclusive; zero = unbounded
+	Before        time.Time // exclusive; zero = unbounded
+	BeforeID      int64     // cursor: only messages older than this ID
+	Limit         int
+}
+
+// SearchHit is a matching message with a highlighted excerpt of its body.
+type SearchHit struct {
+	Message
+	Snippet string
+}
+
+// ─── Search ───────────────────────────────────────────────────────────────────
+
+// SearchMessages returns up to f.Limit non-deleted messages matching f,
+// newest first.
+func (r *ChatRepository) SearchMessages(ctx context.Context, f SearchFilter) ([]SearchHit, error) {
+	if len(f.ChannelIDs) == 0 {
+		return nil, nil
+	}
+	if f.Limit <= 0 || f.Limit > 100 {
+		f.Limit = defaultPageSize
+	}
+
+	placeholders, args := idPlaceholders(f.ChannelIDs, 0)
+	conds := []string{
+		fmt.Sprintf("m.channel_id IN (%s)", placeholders),
+		"m.is_deleted = false",
+	}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	snippet := "LEFT(m.body, 200)"
+	if f.Text != "" {
+		q := "websearch_to_tsquery('chat_search'::regconfig, " + arg(f.Text) + ")"
+		conds = append(conds, fmt.Sprintf(`(m.search_vector @@ %[1]s OR EXISTS (
+			SELECT 1 FROM chat_attachments a
+			WHERE a.message_id = m.id
+			  AND to_tsvector('chat_search'::regconfig, a.file_name) @@ %[1]s
+		))`, q))
+		snippet = fmt.Sprintf("ts_headline('chat_search'::regconfig, m.body, %s, %s)", q, arg(
+			"StartSel="+SearchHighlightStart+", StopSel="+SearchHighlightStop+
+				", MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=\" … \"",
+		))
+	}
+	if f.From != "" {
+		p := arg(strings.ToLower(f.From))
+		conds = append(conds, fmt.Sprintf(
+			"(LOWER(u.email) = %[1]s OR LOWER(split_part(u.email, '@', 1)) = %[1]s OR LOWER(u.full_name) = %[1]s)", p))
+	}
+	if f.HasAttachment {
+		conds = append(conds, "EXISTS (SELECT 1 FROM chat_attachments a WHERE a.message_id = m.id)")
+	}
+	if !f.After.IsZero() {
+		conds = append(conds, "m.created_at >= "+arg(f.After))
+	}
+	if !f.Before.IsZero() {
+		conds = append(conds, "m.created_at < "+arg(f.Before))
+	}
+	if f.BeforeID > 0 {
+		conds = append(conds, "m.id < "+arg(f.BeforeID))
+	}
+	limit := arg(f.Limit)
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT `+messageColumns+`,
+		       %s AS snippet
+		`+messageJoins+`
+		WHERE %s
+		ORDER BY m.id DESC
+		LIMIT %s
+	`, snippet, strings.Join(conds, " AND "), limit), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		var h SearchHit
+		if err := scanMessage(rows, &h.Message, &h.Snippet); err != nil {
+			return nil, err
+		}
+		hits = append(hits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Attachments are loaded on the embedded messages and copied back
+	msgs := make([]Message, len(hits))
+	for i := range hits {
+		msgs[i] = hits[i].Message
+	}
+	if err := r.populateAttachments(ctx, msgs); err != nil {
+		return nil, err
+	}
+	for i := range hits {
+		hits[i].Attachments = msgs[i].Attachments
+	}
+	return hits, nil
+}
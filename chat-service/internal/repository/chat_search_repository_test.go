@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchMessagesBuildsFilters(t *testing.T) {
+	repo, db := newFakeRepo(t, nil)
+	after := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	before := after.AddDate(0, 0, 1)
+
+	_, err := repo.SearchMessages(context.Background(), SearchFilter{
+		Text:          `"café au lait" -tea`,
+		ChannelIDs:    []int64{4, 9},
+		From:          "Alice",
+		HasAttachment: true,
+		After:         after,
+		Before:        before,
+		BeforeID:      120,
+		Limit:         500,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmts := db.statements()
+	if len(stmts) != 1 {
+		t.Fatalf("ran %d statements; want 1", len(stmts))
+	}
+	query, args := stmts[0].Query, stmts[0].Args
+	headline := "StartSel=" + SearchHighlightStart + ", StopSel=" + SearchHighlightStop +
+		", MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=\" … \""
+	want := []interface{}{int64(4), int64(9), `"café au lait" -tea`, headline, "alice", after, before, int64(120), defaultPageSize}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %#v;\nwant %#v", args, want)
+	}
+	for _, frag := range []string{
+		"m.channel_id IN ($1,$2)",
+		"m.is_deleted = false",
+		"m.search_vector @@ websearch_to_tsquery('chat_search'::regconfig, $3)",
+		"to_tsvector('chat_search'::regconfig, a.file_name) @@ websearch_to_tsquery('chat_search'::regconfig, $3)",
+		"ts_headline('chat_search'::regconfig, m.body, websearch_to_tsquery('chat_search'::regconfig, $3), $4) AS snippet",
+		"(LOWER(u.email) = $5 OR LOWER(split_part(u.email, '@', 1)) = $5 OR LOWER(u.full_name) = $5)",
+		"EXISTS (SELECT 1 FROM chat_attachments a WHERE a.message_id = m.id)",
+		"m.created_at >= $6",
+		"m.created_at < $7",
+		"m.id < $8",
+		"LIMIT $9",
+	} {
+		if !strings.Contains(query, frag) {
+			t.Errorf("query lacks %q:\n%s", frag, query)
+		}
+	}
+}
+
+func TestSearchMessagesWithoutText(t *testing.T) {
+	repo, db := newFakeRepo(t, nil)
+
+	if _, err := repo.SearchMessages(context.Background(), SearchFilter{ChannelIDs: []int64{4}, Limit: 20}); err != nil {
+		t.Fatal(err)
+	}
+	stmts := db.statements()
+	if len(stmts) != 1 {
+		t.Fatalf("ran %d statements; want 1", len(stmts))
+	}
+	if q := stmts[0].Query; strings.Contains(q, "tsquery") || !strings.Contains(q, "LEFT(m.body, 200) AS snippet") {
+		t.Errorf("query without text should not search and should snip the body:\n%s", q)
+	}
+	if want := []interface{}{int64(4), 20}; !reflect.DeepEqual(stmts[0].Args, want) {
+		t.Errorf("args = %#v; want %#v", stmts[0].Args, want)
+	}
+
+	if hits, err := repo.SearchMessages(context.Background(), SearchFilter{Text: "x"}); err != nil || hits != nil {
+		t.Errorf("no channels = %v, %v; want nothing", hits, err)
+	}
+	if n := len(db.statements()); n != 1 {
+		t.Errorf("search without channels ran a query")
+	}
+}
+
+func TestSearchMessagesScansSnippet(t *testing.T) {
+	repo, _ := newFakeRepo(t, func(query string, _ []driver.NamedValue) (*fakeRows, error) {
+		if strings.Contains(query, "AS snippet") {
+			row := messageRow(11, 4, 9).values[0]
+			return oneRow(append(row, "a <hit> here")...), nil
+		}
+		return nil, nil
+	})
+
+	hits, err := repo.SearchMessages(context.Background(), SearchFilter{Text: "hit", ChannelIDs: []int64{4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 {
+		t.Fatalf("hits = %+v; want one", hits)
+	}
+	if h := hits[0]; h.ID != 11 || h.ChannelID != 4 || h.SenderEmail != "sender@example.com" || h.Snippet != "a <hit> here" {
+		t.Errorf("hit = %+v", h)
+	}
+}
+
+func TestSearchMessagesFromMatchesFullName(t *testing.T) {
+	repo, db := newFakeRepo(t, nil)
+
+	if _, err := repo.SearchMessages(context.Background(), SearchFilter{ChannelIDs: []int64{4}, From: "Ann Lee"}); err != nil {
+		t.Fatal(err)
+	}
+	stmt := db.statements()[0]
+	if want := []interface{}{int64(4), "ann lee", defaultPageSize}; !reflect.DeepEqual(stmt.Args, want) {
+		t.Errorf("args = %#v; want %#v", stmt.Args, want)
+	}
+	if !strings.Contains(stmt.Query, "LOWER(u.full_name) = $2") {
+		t.Errorf("from: does not match the sender's name:\n%s", stmt.Query)
+	}
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// threadRootOf4 resolves the thread a new message joins from its parent ($4
+// in the insert): the parent's own root, or the parent itself when it is
+// top-level. Parents in another channel start no thread.
+const threadRootOf4 = `SELECT COALESCE(p.thread_root_id, p.id) FROM chat_messages p WHERE p.id = $4 AND p.channel_id = $1`
+
+// ThreadStats summarises the replies under one root message.
+type ThreadStats struct {
+	ReplyCount  int
+	LastReplyAt time.Time
+}
+
+// ThreadMember is a user's follow state and read pointer in one thread.
+type ThreadMember struct {
+	Following       bool
+	LastReadReplyID int64
+}
+
+// FollowedThread is a thread the user follows with its unread replies.
+type FollowedThread struct {
+	Root        Message
+	ReplyCount  int
+	Unread      int
+	LastReplyAt time.Time
+}
+
+// followThreadOnReply makes a replier follow the thread (again, if they had
+// unfollowed) with their own reply read, and the root's author follow it
+// unless they opted out before.
+func followThreadOnReply(ctx context.Context, tx *sql.Tx, rootID, senderID, replyID int64) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO chat_thread_members (root_message_id, user_id, following, last_read_reply_id)
+		VALUES ($1, $2, true, $3)
+		ON CONFLICT (root_message_id, user_id) DO UPDATE
+		SET following = true,
+		    last_read_reply_id = GREATEST(chat_thread_members.last_read_reply_id, EXCLUDED.last_read_reply_id),
+		    updated_at = NOW()
+	`, rootID, senderID, replyID); err != nil {
+		return fmt.Errorf("follow thread as replier: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO chat_thread_members (root_message_id, user_id)
+		SELECT id, sender_id FROM chat_messages WHERE id = $1
+		ON CONFLICT DO NOTHING
+	`, rootID); err != nil {
+		return fmt.Errorf("follow thread as author: %w", err)
+	}
+	return nil
+}
+
+// ─── Threads ──────────────────────────────────────────────────────────────────
+
+// GetMessage returns one message, or nil when it does not exist.
+func (r *ChatRepository) GetMessage(ctx context.Context, msgID int64) (*Message, error) {
+	msg, err := r.getMessageByID(ctx, msgID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return msg, err
+}
+
+// GetThreadStats returns reply counts and last-reply times for the given
+// root messages. Roots without replies are absent from the map.
+func (r *ChatRepository) GetThreadStats(ctx context.Context, rootIDs []int64) (map[int64]ThreadStats, error) {
+	result := make(map[int64]ThreadStats)
+	if len(rootIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders, args := idPlaceholders(rootIDs, 0)
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT thread_root_id, COUNT(*), MAX(created_at)
+		FROM chat_messages
+		WHERE thread_root_id IN (%s) AND is_deleted = false
+		GROUP BY thread_root_id
+	`, placeholders), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rootID int64
+		var st ThreadStats
+		if err := rows.Scan(&rootID, &st.ReplyCount, &st.LastReplyAt); err != nil {
+			return nil, err
+		}
+		result[rootID] = st
+	}
+	return result, rows.Err()
+}
+
+// ListThreadReplies returns up to `limit` replies in a thread after afterID,
+// oldest first.
+func (r *ChatRepository) ListThreadReplies(
+	ctx context.Context,
+	rootID, afterID int64,
+	limit int,
+) ([]Message, error) {
+	if limit <= 0 || limit > 100 {
+		limit = defaultPageSize
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+messageColumns+`
+		`+messageJoins+`
+		WHERE m.thread_root_id = $1 AND m.id > $2
+		ORDER BY m.id ASC
+		LIMIT $3
+	`, rootID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	msgs, err := scanMessageRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.populateAttachments(ctx, msgs); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// ListThreadParticipants returns the root's author followed by everyone who
+// replied, in order of their first message.
+func (r *ChatRepository) ListThreadParticipants(ctx context.Context, rootID int64) ([]User, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.id, u.email, u.full_name, u.profile_picture
+		FROM (
+			SELECT sender_id, MIN(id) AS first_id
+			FROM chat_messages
+			WHERE id = $1 OR thread_root_id = $1
+			GROUP BY sender_id
+		) p
+		JOIN users u ON u.id = p.sender_id
+		ORDER BY p.first_id ASC
+	`, rootID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]User, 0)
+	for rows.Next() {
+		var u User
+		var pic sql.NullString
+		if err := rows.Scan(&u.ID, &u.Email, &u.FullName, &pic); err != nil {
+			return nil, err
+		}
+		u.ProfilePicture = pic.String
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// GetThreadMember returns userID's state in a thread; the zero value when
+// they never followed or read it.
+func (r *ChatRepository) GetThreadMember(ctx context.Context, rootID, userID int64) (ThreadMember, error) {
+	var m ThreadMember
+	err := r.db.QueryRowContext(ctx, `
+		SELECT following, last_read_reply_id
+		FROM chat_thread_members
+		WHERE root_message_id = $1 AND user_id = $2
+	`, rootID, userID).Scan(&m.Following, &m.LastReadReplyID)
+	if err == sql.ErrNoRows {
+		return ThreadMember{}, nil
+	}
+	return m, err
+}
+
+// SetThreadFollow follows or unfollows a thread. An unfollow is remembered,
+// so replies by others do not re-follow the user.
+func (r *ChatRepository) SetThreadFollow(ctx context.Context, rootID, userID int64, follow bool) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO chat_thread_members (root_message_id, user_id, following)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (root_message_id, user_id) DO UPDATE
+		SET following = EXCLUDED.following, updated_at = NOW()
+	`, rootID, userID, follow)
+	return err
+}
+
+// MarkThreadRead moves userID's read pointer in a thread up to replyID (or
+// the newest reply when replyID is 0) without changing whether they follow
+// it. Returns the reply ID the pointer now covers.
+func (r *ChatRepository) MarkThreadRead(ctx context.Context, rootID, userID, replyID int64) (int64, error) {
+	var lastRead int64
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO chat_thread_members (root_message_id, user_id, following, last_read_reply_id)
+		SELECT $1, $2, false, COALESCE(MAX(id), 0)
+		FROM chat_messages
+		WHERE thread_root_id = $1 AND ($3 = 0 OR id <= $3)
+		ON CONFLICT (root_message_id, user_id) DO UPDATE
+		SET last_read_reply_id = GREATEST(chat_thread_members.last_read_reply_id, EXCLUDED.last_read_reply_id),
+		    updated_at = NOW()
+		RETURNING last_read_reply_id
+	`, rootID, userID, replyID).Scan(&lastRead)
+	return lastRead, err
+}
+
+// ListFollowedThreads returns the threads userID follows, those with the most
+// recent replies first, with how many replies by others are unread.
+func (r *ChatRepository) ListFollowedThreads(ctx context.Context, userID int64, limit int) ([]FollowedThread, error) {
+	if limit <= 0 || limit > 100 {
+		limit = defaultPageSize
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tm.root_message_id,
+		       COUNT(m.id),
+		       COUNT(m.id) FILTER (WHERE m.id > tm.last_read_reply_id AND m.sender_id != $1),
+		       MAX(m.created_at)
+		FROM chat_thread_members tm
+		JOIN chat_messages m ON m.thread_root_id = tm.root_message_id AND m.is_deleted = false
+		WHERE tm.user_id = $1 AND tm.following = true
+		GROUP BY tm.root_message_id
+		ORDER BY MAX(m.created_at) DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var threads []FollowedThread
+	var ids []int64
+	for rows.Next() {
+		var t FollowedThread
+		if err := rows.Scan(&t.Root.ID, &t.ReplyCount, &t.Unread, &t.LastReplyAt); err != nil {
+			return nil, err
+		}
+		threads = append(threads, t)
+		ids = append(ids, t.Root.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	roots, err := r.getMessagesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]FollowedThread, 0, len(threads))
+	for _, t := range threads {
+		if root, ok := roots[t.Root.ID]; ok {
+			t.Root = root
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
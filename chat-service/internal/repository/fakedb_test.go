@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDB is a database/sql driver that records every statement and answers
+// queries from a test-supplied function, so query building can be checked
+// without a Postgres server.
+type fakeDB struct {
+	mu     sync.Mutex
+	stmts  []fakeStmt
+	conns  int
+	answer func(query string, args []driver.NamedValue) (*fakeRows, error)
+}
+
+// fakeStmt is one recorded statement and the connection it ran on.
+type fakeStmt struct {
+	Conn  int
+	Query string
+	Args  []interface{}
+}
+
+var (
+	fakeDBsMu sync.Mutex
+	fakeDBs   = make(map[string]*fakeDB)
+	fakeDBSeq int
+)
+
+func init() {
+	sql.Register("fakedb", fakeDriver{})
+}
+
+// newFakeRepo returns a repository backed by a fakeDB whose queries are
+// answered by answer; a nil answer returns no rows.
+func newFakeRepo(t *testing.T, answer func(query string, args []driver.NamedValue) (*fakeRows, error)) (*ChatRepository, *fakeDB) {
+	t.Helper()
+	f := &fakeDB{answer: answer}
+	fakeDBsMu.Lock()
+	fakeDBSeq++
+	name := fmt.Sprintf("%s#%d", t.Name(), fakeDBSeq)
+	fakeDBs[name] = f
+	fakeDBsMu.Unlock()
+	db, err := sql.Open("fakedb", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		fakeDBsMu.Lock()
+		delete(fakeDBs, name)
+		fakeDBsMu.Unlock()
+	})
+	return NewChatRepository(db), f
+}
+
+// statements returns the statements run so far.
+func (f *fakeDB) statements() []fakeStmt {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeStmt(nil), f.stmts...)
+}
+
+func (f *fakeDB) run(conn int, query string, args []driver.NamedValue) (*fakeRows, error) {
+	f.mu.Lock()
+	values := make([]interface{}, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	f.stmts = append(f.stmts, fakeStmt{Conn: conn, Query: query, Args: values})
+	answer := f.answer
+	f.mu.Unlock()
+	if answer == nil {
+		return &fakeRows{}, nil
+	}
+	rows, err := answer(query, args)
+	if rows == nil && err == nil {
+		rows = &fakeRows{}
+	}
+	return rows, err
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDBsMu.Lock()
+	f := fakeDBs[name]
+	fakeDBsMu.Unlock()
+	if f == nil {
+		return nil, fmt.Errorf("fakedb: unknown database %q", name)
+	}
+	f.mu.Lock()
+	f.conns++
+	id := f.conns
+	f.mu.Unlock()
+	return &fakeConn{db: f, id: id}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+	id int
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakedb: prepared statements are not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.db.run(c.id, query, args)
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if _, err := c.db.run(c.id, query, args); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(0), nil
+}
+
+// CheckNamedValue accepts every argument as is.
+func (c *fakeConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+// fakeRows is a canned result set; every row has the same width.
+type fakeRows struct {
+	values [][]driver.Value
+	next   int
+}
+
+// resultRows returns a result set with the given rows.
+func resultRows(values ...[]driver.Value) *fakeRows {
+	return &fakeRows{values: values}
+}
+
+// oneRow returns a result set with a single row.
+func oneRow(values ...driver.Value) *fakeRows {
+	return resultRows(values)
+}
+
+// messageRow returns a single message row as messageColumns selects it.
+func messageRow(id, channelID, senderID int64) *fakeRows {
+	return oneRow(id, channelID, senderID, "Sender", "sender@example.com", "",
+		"hello", false, false, nil, nil, "", "", time.Unix(1700000000, 0))
+}
+
+func (r *fakeRows) Columns() []string {
+	width := 1
+	if len(r.values) > 0 {
+		width = len(r.values[0])
+	}
+	cols := make([]string, width)
+	for i := range cols {
+		cols[i] = fmt.Sprintf("c%d", i)
+	}
+	return cols
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// SyncEntityUser is the only entity type chat-service tracks in sync_versions
+const SyncEntityUser = "user"
+
+// SyncVersionRepository records the newest auth-service version applied to
+// each synced entity.
+type SyncVersionRepository struct {
+	db *sql.DB
+}
+
+func NewSyncVersionRepository(db *sql.DB) *SyncVersionRepository {
+	return &SyncVersionRepository{db: db}
+}
+
+// Claim records version for the entity and reports whether the change
+// should be applied. It returns false when a newer version has already been
+// applied; the same version is claimed again so a retried event re-applies.
+func (r *SyncVersionRepository) Claim(ctx context.Context, entityType, entityID string, version int64, deleted bool) (bool, error) {
+	var claimed int64
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO sync_versions (entity_type, entity_id, version, deleted)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (entity_type, entity_id) DO UPDATE SET
+			version    = EXCLUDED.version,
+			deleted    = EXCLUDED.deleted,
+			updated_at = NOW()
+		WHERE sync_versions.version <= EXCLUDED.version
+		RETURNING version
+	`, entityType, entityID, version, deleted).Scan(&claimed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
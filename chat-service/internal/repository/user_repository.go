@@ -149,7 +149,7 @@ func (r *UserRepository) SearchUsers(ctx context.Context, query string, excludeU
 	rows, err := r.db.QueryContext(ctx, `
 		SELECT id, email, full_name, profile_picture
 		FROM users
-		WHERE id != $1 AND (LOWER(email) LIKE $2 OR LOWER(full_name) LIKE $2)
+		WHERE id != $1 AND id > 0 AND (LOWER(email) LIKE $2 OR LOWER(full_name) LIKE $2)
 		ORDER BY full_name ASC
 		LIMIT $3
 	`, excludeUserID, likePattern, limit)
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"chat-service/internal/repository"
+	"chat-service/pkg/coursesync"
+	"chat-service/pkg/hub"
+	"chat-service/pkg/logger"
+)
+
+// maxChannelNameRunes matches chat_channels.name
+const maxChannelNameRunes = 120
+
+// CourseChannelService keeps one private channel per published LMS course,
+// and one per group of its students, in step with the course's members.
+type CourseChannelService struct {
+	userRepo *repository.UserRepository
+	chatRepo *repository.ChatRepository
+	hub      *hub.Hub
+}
+
+func NewCourseChannelService(userRepo *repository.UserRepository, chatRepo *repository.ChatRepository, h *hub.Hub) *CourseChannelService {
+	return &CourseChannelService{userRepo: userRepo, chatRepo: chatRepo, hub: h}
+}
+
+// ApplyCourseEvent applies an event from lms.course.membership. Malformed
+// events are logged and dropped; only storage errors are returned for retry.
+func (s *CourseChannelService) ApplyCourseEvent(ctx context.Context, e coursesync.CourseEvent) error {
+	if e.CourseID <= 0 {
+		logger.Warnf("dropping course event %q without course_id", e.EventID)
+		return nil
+	}
+
+	switch e.Type {
+	case coursesync.CourseMembersSynced:
+		return s.syncChannel(ctx, e)
+	case coursesync.CourseArchived, coursesync.CourseDeleted:
+		// The history stays readable; a deleted course cannot come back
+		// but its channels are kept like any other record of messages
+		channelIDs, err := s.chatRepo.ArchiveCourseChannel(ctx, e.CourseID)
+		if err != nil {
+			return err
+		}
+		for _, channelID := range channelIDs {
+			logger.Infof("archived channel %d of course %d (%s)", channelID, e.CourseID, e.Type)
+			s.publishArchive(ctx, channelID, true)
+		}
+		return nil
+	case coursesync.MemberAdded, coursesync.MemberRemoved:
+		return s.changeMembers(ctx, e)
+	case coursesync.GroupSynced:
+		if e.GroupID <= 0 {
+			logger.Warnf("dropping course event %q without group_id", e.EventID)
+			return nil
+		}
+		members := validMembers(e)
+		s.ensureUsers(ctx, members)
+		studentIDs := make([]int64, 0, len(members))
+		for _, m := range members {
+			studentIDs = append(studentIDs, m.UserID)
+		}
+		return s.syncGroupChannel(ctx, e.CourseID, e.GroupID, e.GroupName, studentIDs)
+	case coursesync.GroupDeleted:
+		channelID, err := s.chatRepo.ArchiveCourseGroupChannel(ctx, e.CourseID, e.GroupID)
+		if err != nil || channelID == 0 {
+			return err
+		}
+		logger.Infof("archived channel %d of group %d in course %d", channelID, e.GroupID, e.CourseID)
+		s.publishArchive(ctx, channelID, true)
+		return nil
+	default:
+		logger.Warnf("ignoring course event %q of unknown type %s", e.EventID, e.Type)
+		return nil
+	}
+}
+
+// syncChannel provisions the course's channel and replaces its members
+// with e.Members, then does the same for every group in e.Groups and
+// archives the channels of groups no longer listed.
+func (s *CourseChannelService) syncChannel(ctx context.Context, e coursesync.CourseEvent) error {
+	members := validMembers(e)
+	s.ensureUsers(ctx, members)
+
+	creator, err := s.userRepo.GetByID(ctx, e.CreatedBy)
+	if err != nil {
+		return err
+	}
+	if creator == nil {
+		// Retrying cannot help; the course's next sync will
+		logger.Warnf("dropping course event %q: creator %d is not known here", e.EventID, e.CreatedBy)
+		return nil
+	}
+
+	ch, created, restored, err := s.chatRepo.ProvisionCourseChannel(ctx, e.CourseID, channelName(e), e.CreatedBy)
+	if err != nil {
+		return err
+	}
+	if created {
+		logger.Infof("created channel %d for course %d", ch.ID, e.CourseID)
+	}
+
+	roles := make([]repository.CourseMember, len(members))
+	for i, m := range members {
+		roles[i] = repository.CourseMember{UserID: m.UserID, Role: m.Role}
+	}
+	changed, err := s.chatRepo.SyncCourseMembers(ctx, ch.ID, roles)
+	if err != nil {
+		return err
+	}
+
+	if restored {
+		s.publishArchive(ctx, ch.ID, false)
+	}
+	s.publishMembership(ctx, ch.ID, changed)
+
+	groupIDs := make([]int64, 0, len(e.Groups))
+	for _, g := range e.Groups {
+		if g.GroupID <= 0 {
+			continue
+		}
+		groupIDs = append(groupIDs, g.GroupID)
+		if err := s.syncGroupChannel(ctx, e.CourseID, g.GroupID, g.Name, g.MemberIDs); err != nil {
+			return err
+		}
+	}
+	stale, err := s.chatRepo.ArchiveStaleGroupChannels(ctx, e.CourseID, groupIDs)
+	if err != nil {
+		return err
+	}
+	for _, channelID := range stale {
+		s.publishArchive(ctx, channelID, true)
+	}
+	return nil
+}
+
+// syncGroupChannel provisions the channel of a group and makes studentIDs
+// and the course staff its members. Until the course has a live channel
+// there is nothing to do; the course's next sync lists the group again.
+func (s *CourseChannelService) syncGroupChannel(ctx context.Context, courseID, groupID int64, name string, studentIDs []int64) error {
+	ch, created, restored, err := s.chatRepo.ProvisionCourseGroupChannel(ctx, courseID, groupID, groupChannelName(groupID, name))
+	if err != nil || ch == nil {
+		return err
+	}
+	if created {
+		logger.Infof("created channel %d for group %d of course %d", ch.ID, groupID, courseID)
+	}
+
+	changed, err := s.chatRepo.SyncCourseGroupMembers(ctx, courseID, ch.ID, studentIDs)
+	if err != nil {
+		return err
+	}
+	if restored {
+		s.publishArchive(ctx, ch.ID, false)
+	}
+	s.publishMembership(ctx, ch.ID, changed)
+	return nil
+}
+
+// changeMembers applies MEMBER_ADDED or MEMBER_REMOVED to the course, or
+// to its group e.GroupID. Courses without a channel are not published yet,
+// so there is nothing to change.
+func (s *CourseChannelService) changeMembers(ctx context.Context, e coursesync.CourseEvent) error {
+	byRole := make(map[string][]int64)
+	members := validMembers(e)
+	for _, m := range members {
+		byRole[m.Role] = append(byRole[m.Role], m.UserID)
+	}
+	if e.Type == coursesync.MemberAdded {
+		s.ensureUsers(ctx, members)
+	}
+
+	for role, userIDs := range byRole {
+		var changed map[int64][]int64
+		var err error
+		if e.Type == coursesync.MemberAdded {
+			changed, err = s.chatRepo.AddCourseMembers(ctx, e.CourseID, e.GroupID, role, userIDs)
+		} else {
+			changed, err = s.chatRepo.RemoveCourseMembers(ctx, e.CourseID, e.GroupID, role, userIDs)
+		}
+		if err != nil {
+			return err
+		}
+		for channelID, userIDs := range changed {
+			s.publishMembership(ctx, channelID, userIDs)
+		}
+	}
+	return nil
+}
+
+// ensureUsers creates a minimal record for members not synced from the
+// auth service yet, so they can be whitelisted now. Members without an
+// email wait for auth sync instead.
+func (s *CourseChannelService) ensureUsers(ctx context.Context, members []coursesync.CourseMember) {
+	for _, m := range members {
+		if m.Email == "" {
+			continue
+		}
+		if err := s.userRepo.EnsureGuestUser(ctx, m.UserID, m.Email); err != nil {
+			logger.Warnf("ensure course member %d: %v", m.UserID, err)
+		}
+	}
+}
+
+func (s *CourseChannelService) publishMembership(ctx context.Context, channelID int64, userIDs []int64) {
+	if len(userIDs) == 0 {
+		return
+	}
+	if err := s.hub.PublishMembership(ctx, hub.MembershipChange{ChannelID: channelID, UserIDs: userIDs}); err != nil {
+		logger.Warnf("publish membership change channel=%d: %v", channelID, err)
+	}
+}
+
+func (s *CourseChannelService) publishArchive(ctx context.Context, channelID int64, archived bool) {
+	event := hub.WSEvent{
+		Type:      hub.EventArchive,
+		ChannelID: channelID,
+		Payload:   hub.ArchivePayload{Archived: archived},
+		Timestamp: time.Now().UTC(),
+	}
+	if err := s.hub.Publish(ctx, channelID, event); err != nil {
+		logger.Warnf("publish archive channel=%d: %v", channelID, err)
+	}
+}
+
+// validMembers drops members with no user id or a role that does not fit
+// the event, which would otherwise fail it on every retry. Group events
+// only carry GROUP_MEMBER roles and course events only course roles.
+func validMembers(e coursesync.CourseEvent) []coursesync.CourseMember {
+	members := make([]coursesync.CourseMember, 0, len(e.Members))
+	for _, m := range e.Members {
+		switch {
+		case e.GroupID > 0 && m.Role == coursesync.RoleGroupMember:
+		case e.GroupID <= 0 && (m.Role == coursesync.RoleCreator || m.Role == coursesync.RoleCoTeacher || m.Role == coursesync.RoleStudent):
+		default:
+			logger.Warnf("course event %q: skipping member %d with role %q", e.EventID, m.UserID, m.Role)
+			continue
+		}
+		if m.UserID <= 0 {
+			continue
+		}
+		members = append(members, m)
+	}
+	return members
+}
+
+// channelName is the course title, cut to fit a channel name
+func channelName(e coursesync.CourseEvent) string {
+	name := []rune(strings.TrimSpace(e.CourseTitle))
+	if len(name) > maxChannelNameRunes {
+		name = name[:maxChannelNameRunes]
+	}
+	if len(name) < 2 {
+		return fmt.Sprintf("Course %d", e.CourseID)
+	}
+	return string(name)
+}
+
+// groupChannelName is the group's name, cut to fit after the course name
+// that the channel name starts with
+func groupChannelName(groupID int64, groupName string) string {
+	name := []rune(strings.TrimSpace(groupName))
+	if len(name) > maxChannelNameRunes/2 {
+		name = name[:maxChannelNameRunes/2]
+	}
+	if len(name) == 0 {
+		return fmt.Sprintf("Group %d", groupID)
+	}
+	return string(name)
+}
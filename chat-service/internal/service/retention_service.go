@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"chat-service/internal/repository"
+	"chat-service/pkg/logger"
+	"chat-service/pkg/storage"
+)
+
+// attachmentPrefix is where ChatHandler.UploadAttachment stores objects
+const attachmentPrefix = "chat/"
+
+// RetentionOptions tunes a retention run; see config.RetentionConfig.
+type RetentionOptions struct {
+	PurgeDeletedAfter time.Duration
+	OrphanGrace       time.Duration
+	BatchSize         int
+}
+
+// RetentionService expires messages by channel policy, purges soft-deleted
+// content and removes orphaned attachment objects. It runs on a timer and
+// on demand from the admin API.
+type RetentionService struct {
+	chatRepo *repository.ChatRepository
+	store    *storage.ObjectStore // nil while attachment storage is down
+	opts     RetentionOptions
+}
+
+func NewRetentionService(chatRepo *repository.ChatRepository, store *storage.ObjectStore, opts RetentionOptions) *RetentionService {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	return &RetentionService{chatRepo: chatRepo, store: store, opts: opts}
+}
+
+// RetentionReport summarises a retention run. Skipped means another
+// replica was already running one.
+type RetentionReport struct {
+	Skipped        bool
+	Channels       int
+	Deleted        int
+	Anonymized     int
+	Purged         int
+	ObjectsDeleted int
+	OrphansDeleted int
+	Failed         int
+}
+
+// Run starts a retention run every interval until ctx is cancelled.
+func (s *RetentionService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				logger.Errorf("retention run: %v", err)
+			}
+		}
+	}
+}
+
+// RunOnce applies retention once, unless another replica is already doing
+// so. Failures in one channel are counted and the run moves on.
+func (s *RetentionService) RunOnce(ctx context.Context) (*RetentionReport, error) {
+	report := &RetentionReport{}
+	ran, err := s.chatRepo.WithRetentionLock(ctx, func() error {
+		return s.run(ctx, report)
+	})
+	if err != nil {
+		return report, err
+	}
+	if !ran {
+		report.Skipped = true
+		return report, nil
+	}
+	logger.Infof("retention finished: %+v", *report)
+	return report, nil
+}
+
+func (s *RetentionService) run(ctx context.Context, report *RetentionReport) error {
+	policies, err := s.chatRepo.ListExpiringPolicies(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, p := range policies {
+		report.Channels++
+		cutoff := now.AddDate(0, 0, -*p.RetentionDays)
+		n, err := s.drain(ctx, report, func() (int, []string, error) {
+			return s.chatRepo.ExpireMessages(ctx, p.ChannelID, p.Action, cutoff, s.opts.BatchSize)
+		})
+		if p.Action == repository.RetentionAnonymize {
+			report.Anonymized += n
+		} else {
+			report.Deleted += n
+		}
+		if err != nil {
+			report.Failed++
+			logger.Errorf("retention channel=%d: %v", p.ChannelID, err)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	if s.opts.PurgeDeletedAfter > 0 {
+		cutoff := now.Add(-s.opts.PurgeDeletedAfter)
+		n, err := s.drain(ctx, report, func() (int, []string, error) {
+			return s.chatRepo.PurgeDeletedMessages(ctx, cutoff, s.opts.BatchSize)
+		})
+		report.Purged += n
+		if err != nil {
+			report.Failed++
+			logger.Errorf("purge deleted messages: %v", err)
+		}
+	}
+
+	if s.store == nil {
+		logger.Warnf("retention: attachment storage unavailable, orphaned objects are left for a later run")
+		return nil
+	}
+	if err := s.removeOrphans(ctx, report, now.Add(-s.opts.OrphanGrace)); err != nil {
+		report.Failed++
+		logger.Errorf("remove orphaned attachments: %v", err)
+	}
+	return ctx.Err()
+}
+
+// drain calls batch until it returns a short batch, deleting the objects
+// each batch released. Returns the number of messages changed.
+func (s *RetentionService) drain(ctx context.Context, report *RetentionReport, batch func() (int, []string, error)) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		n, keys, err := batch()
+		if err != nil {
+			return total, err
+		}
+		total += n
+		s.deleteObjects(ctx, report, keys)
+		if n < s.opts.BatchSize {
+			break
+		}
+	}
+	return total, ctx.Err()
+}
+
+// deleteObjects removes objects whose attachment rows are gone. A failure
+// leaves an orphan for removeOrphans to find later.
+func (s *RetentionService) deleteObjects(ctx context.Context, report *RetentionReport, keys []string) {
+	if s.store == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			logger.Warnf("retention: delete object %s: %v", key, err)
+			continue
+		}
+		report.ObjectsDeleted++
+	}
+}
+
+// removeOrphans deletes attachment objects last modified before cutoff
+// that no attachment row refers to: uploads whose message was never
+// stored, and objects a previous run failed to delete.
+func (s *RetentionService) removeOrphans(ctx context.Context, report *RetentionReport, cutoff time.Time) error {
+	var pending []string
+	flush := func() error {
+		known, err := s.chatRepo.KnownObjectKeys(ctx, pending)
+		if err != nil {
+			return err
+		}
+		for _, key := range pending {
+			if known[key] {
+				continue
+			}
+			if err := s.store.Delete(ctx, key); err != nil {
+				logger.Warnf("retention: delete orphan %s: %v", key, err)
+				continue
+			}
+			report.OrphansDeleted++
+		}
+		pending = pending[:0]
+		return nil
+	}
+
+	err := s.store.List(ctx, attachmentPrefix, func(key string, modifiedAt time.Time) error {
+		if !modifiedAt.Before(cutoff) {
+			return nil
+		}
+		pending = append(pending, key)
+		if len(pending) < s.opts.BatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
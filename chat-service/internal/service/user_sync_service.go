@@ -0,0 +1,121 @@
+// Package service holds chat-service logic that is driven by more than one
+// entry point, such as user sync arriving over Kafka and from reconciliation.
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"chat-service/internal/repository"
+	"chat-service/pkg/authsync"
+	"chat-service/pkg/logger"
+)
+
+// UserSyncService applies user lifecycle changes from the auth service.
+// Every change is checked against the version ledger so an older version
+// never overwrites a newer one.
+type UserSyncService struct {
+	userRepo    *repository.UserRepository
+	chatRepo    *repository.ChatRepository
+	versionRepo *repository.SyncVersionRepository
+}
+
+func NewUserSyncService(userRepo *repository.UserRepository, chatRepo *repository.ChatRepository, versionRepo *repository.SyncVersionRepository) *UserSyncService {
+	return &UserSyncService{userRepo: userRepo, chatRepo: chatRepo, versionRepo: versionRepo}
+}
+
+// ApplyUserEvent applies an event from auth.user.events. Malformed events
+// are logged and dropped; only storage errors are returned for retry.
+func (s *UserSyncService) ApplyUserEvent(ctx context.Context, e authsync.UserEvent) error {
+	if e.UserID <= 0 || e.Version <= 0 {
+		logger.Warnf("dropping user event %q without user_id or version", e.EventID)
+		return nil
+	}
+
+	switch e.Type {
+	case authsync.UserUpserted:
+		if e.User == nil {
+			logger.Warnf("dropping user event %q: %s without user", e.EventID, e.Type)
+			return nil
+		}
+		user := *e.User
+		user.UserID = e.UserID
+		_, err := s.applyUser(ctx, user, e.Version)
+		return err
+	case authsync.UserDeleted:
+		// Messages are a historical record, so the user row stays; the
+		// tombstone only stops stale upserts from arriving after the delete
+		_, err := s.claim(ctx, e.UserID, e.Version, true)
+		return err
+	default:
+		logger.Warnf("ignoring user event %q of unknown type %s", e.EventID, e.Type)
+		return nil
+	}
+}
+
+func (s *UserSyncService) claim(ctx context.Context, userID, version int64, deleted bool) (bool, error) {
+	ok, err := s.versionRepo.Claim(ctx, repository.SyncEntityUser, strconv.FormatInt(userID, 10), version, deleted)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		logger.Infof("skipping stale user %d version %d", userID, version)
+	}
+	return ok, nil
+}
+
+func (s *UserSyncService) applyUser(ctx context.Context, u authsync.UserSnapshot, version int64) (bool, error) {
+	ok, err := s.claim(ctx, u.UserID, version, false)
+	if err != nil || !ok {
+		return false, err
+	}
+	err = s.userRepo.Upsert(ctx, repository.User{
+		ID:             u.UserID,
+		Email:          u.Email,
+		FullName:       u.FullName,
+		ProfilePicture: u.ProfilePicture,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// Course roles that arrived before the user did
+	if err := s.chatRepo.ApplyCourseMemberships(ctx, u.UserID); err != nil {
+		logger.Warnf("apply course memberships of user %d: %v", u.UserID, err)
+	}
+
+	// The default channel is created once the first user exists
+	if seeded, err := s.chatRepo.SeedDefaultChannel(ctx); err != nil {
+		logger.Warnf("seed default channel after syncing user %d: %v", u.UserID, err)
+	} else if seeded {
+		logger.Infof("seeded default channel using user %d", u.UserID)
+	}
+	return true, nil
+}
+
+// ReconcileReport summarises a reconciliation run.
+type ReconcileReport struct {
+	UsersSeen    int
+	UsersApplied int
+	Failed       int
+}
+
+// Reconcile pages through the auth service's user snapshot and applies
+// every user whose version is not older than the one held here. Users
+// missing from the snapshot are left alone, since their messages stay.
+func (s *UserSyncService) Reconcile(ctx context.Context, client *authsync.Client, pageSize int) (*ReconcileReport, error) {
+	report := &ReconcileReport{}
+	err := client.EachUser(ctx, pageSize, func(u authsync.UserSnapshot) error {
+		report.UsersSeen++
+		applied, err := s.applyUser(ctx, u, u.Version)
+		if err != nil {
+			report.Failed++
+			logger.Errorf("reconcile: apply user %d: %v", u.UserID, err)
+		} else if applied {
+			report.UsersApplied++
+		}
+		return ctx.Err()
+	})
+	logger.Infof("reconcile finished: %+v", *report)
+	return report, err
+}
@@ -0,0 +1,13 @@
+-- Version ledger for user sync from the auth service. Lifecycle events carry
+-- a version that grows with every change; the highest version applied per
+-- user is kept here so a redelivered or out-of-order event cannot roll a
+-- profile back. Deleted users keep their row (and their message history)
+-- with deleted = TRUE, which blocks stale upserts.
+CREATE TABLE IF NOT EXISTS sync_versions (
+    entity_type VARCHAR(20)  NOT NULL,
+    entity_id   VARCHAR(100) NOT NULL,
+    version     BIGINT       NOT NULL,
+    deleted     BOOLEAN      NOT NULL DEFAULT FALSE,
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (entity_type, entity_id)
+);
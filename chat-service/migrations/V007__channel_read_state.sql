@@ -0,0 +1,17 @@
+-- ============================================================
+-- Chat Service - V007 Read State
+-- One row per (channel, user) holding the highest message the
+-- user has acknowledged. Unread counts are everything after it;
+-- the pointer only moves forward.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS chat_channel_reads (
+    channel_id           BIGINT      NOT NULL REFERENCES chat_channels(id) ON DELETE CASCADE,
+    user_id              BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_read_message_id BIGINT      NOT NULL DEFAULT 0,
+    read_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (channel_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_reads_user
+    ON chat_channel_reads (user_id);
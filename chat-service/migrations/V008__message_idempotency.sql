@@ -0,0 +1,13 @@
+-- ============================================================
+-- Chat Service - V008 Message Idempotency
+-- Clients tag each send with a client_msg_id they generate; a
+-- retry with the same key returns the message already stored
+-- instead of creating a duplicate. Keys are scoped per sender.
+-- ============================================================
+
+ALTER TABLE chat_messages
+    ADD COLUMN IF NOT EXISTS client_msg_id VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_messages_sender_client_msg_id
+    ON chat_messages (sender_id, client_msg_id)
+    WHERE client_msg_id IS NOT NULL;
@@ -0,0 +1,32 @@
+-- ============================================================
+-- Chat Service - V009 Reactions, Pins and Saved Messages
+-- Reactions: one row per (message, user, emoji); toggling deletes.
+-- Pins: shared per channel, managed by channel writers.
+-- Saved: personal bookmarks, visible only to their owner.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS chat_message_reactions (
+    message_id BIGINT      NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+    user_id    BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    emoji      VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (message_id, user_id, emoji)
+);
+
+CREATE TABLE IF NOT EXISTS chat_channel_pins (
+    channel_id BIGINT      NOT NULL REFERENCES chat_channels(id) ON DELETE CASCADE,
+    message_id BIGINT      NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+    pinned_by  BIGINT      NOT NULL REFERENCES users(id),
+    pinned_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (channel_id, message_id)
+);
+
+CREATE TABLE IF NOT EXISTS chat_saved_messages (
+    user_id    BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id BIGINT      NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+    saved_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_messages_user_saved
+    ON chat_saved_messages (user_id, saved_at DESC);
@@ -0,0 +1,48 @@
+-- ============================================================
+-- Chat Service - V010 Threads
+-- thread_root_id points every reply at the first message of its
+-- reply chain, so a whole thread is one indexed lookup however
+-- deeply replies nest. chat_thread_members holds who follows a
+-- thread and how far they have read its replies.
+-- ============================================================
+
+ALTER TABLE chat_messages
+    ADD COLUMN IF NOT EXISTS thread_root_id BIGINT
+        REFERENCES chat_messages(id) ON DELETE SET NULL;
+
+-- ── Backfill existing replies with their chain's root ───────
+WITH RECURSIVE chain AS (
+    SELECT id, id AS root_id
+    FROM chat_messages
+    WHERE parent_id IS NULL
+    UNION ALL
+    SELECT m.id, chain.root_id
+    FROM chat_messages m
+    JOIN chain ON m.parent_id = chain.id
+)
+UPDATE chat_messages m
+SET thread_root_id = chain.root_id
+FROM chain
+WHERE chain.id = m.id
+  AND m.parent_id IS NOT NULL
+  AND m.thread_root_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_messages_thread_root
+    ON chat_messages (thread_root_id, id)
+    WHERE thread_root_id IS NOT NULL;
+
+-- ── Thread followers and read pointers ──────────────────────
+-- following = false keeps an explicit unfollow (and the read
+-- pointer of a thread the user only read) without notifying.
+CREATE TABLE IF NOT EXISTS chat_thread_members (
+    root_message_id    BIGINT      NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+    user_id            BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    following          BOOLEAN     NOT NULL DEFAULT true,
+    last_read_reply_id BIGINT      NOT NULL DEFAULT 0,
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (root_message_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_thread_members_following
+    ON chat_thread_members (user_id, root_message_id)
+    WHERE following = true;
@@ -0,0 +1,30 @@
+-- ============================================================
+-- Chat Service - V011 Message Search
+-- chat_search is the 'simple' parser with unaccent in front, so
+-- "viet nam" matches "Việt Nam" and đ matches d. Bodies keep a
+-- stored tsvector; attachment names are indexed by expression.
+-- ============================================================
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'chat_search') THEN
+        CREATE TEXT SEARCH CONFIGURATION chat_search (COPY = simple);
+        ALTER TEXT SEARCH CONFIGURATION chat_search
+            ALTER MAPPING FOR asciiword, asciihword, hword_asciipart,
+                              word, hword, hword_part
+            WITH unaccent, simple;
+    END IF;
+END;
+$$;
+
+ALTER TABLE chat_messages
+    ADD COLUMN IF NOT EXISTS search_vector tsvector
+        GENERATED ALWAYS AS (to_tsvector('chat_search'::regconfig, body)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_messages_search
+    ON chat_messages USING GIN (search_vector);
+
+CREATE INDEX IF NOT EXISTS idx_chat_attachments_search
+    ON chat_attachments USING GIN (to_tsvector('chat_search'::regconfig, file_name));
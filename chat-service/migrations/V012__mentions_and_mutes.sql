@@ -0,0 +1,34 @@
+-- ============================================================
+-- Chat Service - V012 Mentions and Mutes
+-- chat_message_mentions has one row per (message, mentioned user),
+-- written when a message is created or edited. @channel and @here
+-- expand to one row per recipient, so a user's mention feed and
+-- mention badges are plain indexed lookups. Messages sent before
+-- this migration have no rows.
+-- chat_channel_mutes silences a channel's unread badge for one
+-- user; mentions still notify.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS chat_message_mentions (
+    message_id BIGINT      NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+    user_id    BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel_id BIGINT      NOT NULL REFERENCES chat_channels(id) ON DELETE CASCADE,
+    kind       VARCHAR(16) NOT NULL CHECK (kind IN ('user', 'channel', 'here')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (message_id, user_id)
+);
+
+-- Mention feed, newest first
+CREATE INDEX IF NOT EXISTS idx_mentions_user
+    ON chat_message_mentions (user_id, message_id DESC);
+
+-- Mention badges: mentions after the read pointer in one channel
+CREATE INDEX IF NOT EXISTS idx_mentions_user_channel
+    ON chat_message_mentions (user_id, channel_id, message_id);
+
+CREATE TABLE IF NOT EXISTS chat_channel_mutes (
+    channel_id BIGINT      NOT NULL REFERENCES chat_channels(id) ON DELETE CASCADE,
+    user_id    BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (channel_id, user_id)
+);
@@ -0,0 +1,58 @@
+-- ============================================================
+-- Chat Service - V013 Moderation
+-- Slow mode spaces out each writer's messages in a channel.
+-- Sanctions mute (no posting) or ban (no access) a user in one
+-- channel, or everywhere when channel_id is NULL, until they
+-- expire or are revoked. Reports queue messages for admin review.
+-- Moderator deletes record who deleted a message and why.
+-- ============================================================
+
+ALTER TABLE chat_channels
+    ADD COLUMN IF NOT EXISTS slow_mode_seconds INT NOT NULL DEFAULT 0
+        CHECK (slow_mode_seconds BETWEEN 0 AND 21600);
+
+ALTER TABLE chat_messages
+    ADD COLUMN IF NOT EXISTS deleted_by     BIGINT REFERENCES users(id),
+    ADD COLUMN IF NOT EXISTS deleted_reason VARCHAR(500),
+    ADD COLUMN IF NOT EXISTS deleted_at     TIMESTAMPTZ;
+
+-- Slow mode looks up a sender's latest message in a channel
+CREATE INDEX IF NOT EXISTS idx_messages_channel_sender
+    ON chat_messages (channel_id, sender_id, created_at DESC);
+
+-- ── Sanctions ───────────────────────────────────────────────
+CREATE TABLE IF NOT EXISTS chat_user_sanctions (
+    id         BIGSERIAL   PRIMARY KEY,
+    user_id    BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel_id BIGINT      REFERENCES chat_channels(id) ON DELETE CASCADE,
+    kind       VARCHAR(8)  NOT NULL CHECK (kind IN ('mute', 'ban')),
+    reason     VARCHAR(500) NOT NULL DEFAULT '',
+    created_by BIGINT      NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    revoked_by BIGINT      REFERENCES users(id),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sanctions_user_active
+    ON chat_user_sanctions (user_id, kind)
+    WHERE revoked_at IS NULL;
+
+-- ── Reports ─────────────────────────────────────────────────
+CREATE TABLE IF NOT EXISTS chat_message_reports (
+    id          BIGSERIAL    PRIMARY KEY,
+    message_id  BIGINT       NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+    channel_id  BIGINT       NOT NULL REFERENCES chat_channels(id) ON DELETE CASCADE,
+    reporter_id BIGINT       NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason      VARCHAR(500) NOT NULL,
+    status      VARCHAR(16)  NOT NULL DEFAULT 'open'
+                CHECK (status IN ('open', 'dismissed', 'actioned')),
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    reviewed_by BIGINT       REFERENCES users(id),
+    reviewed_at TIMESTAMPTZ,
+    review_note VARCHAR(500),
+    UNIQUE (message_id, reporter_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reports_status
+    ON chat_message_reports (status, id DESC);
@@ -0,0 +1,29 @@
+-- ============================================================
+-- Chat Service - V014 Course Channels
+-- Every published LMS course gets one private channel, linked by
+-- course_id. chat_course_members mirrors the LMS roles (creator,
+-- co-teacher, accepted student) from lms.course.membership
+-- events; a user holding any role is on the channel whitelist.
+-- Member rows have no user FK so a role can arrive before the
+-- user is synced; the whitelist entry is added once they are.
+-- Archived channels stay readable but take no new messages.
+-- ============================================================
+
+ALTER TABLE chat_channels
+    ADD COLUMN IF NOT EXISTS course_id   BIGINT,
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_channels_course
+    ON chat_channels (course_id)
+    WHERE course_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS chat_course_members (
+    channel_id BIGINT      NOT NULL REFERENCES chat_channels(id) ON DELETE CASCADE,
+    user_id    BIGINT      NOT NULL,
+    role       VARCHAR(16) NOT NULL CHECK (role IN ('CREATOR', 'CO_TEACHER', 'STUDENT')),
+    PRIMARY KEY (channel_id, user_id, role)
+);
+
+-- Pending whitelist entries for a newly synced user
+CREATE INDEX IF NOT EXISTS idx_course_members_user
+    ON chat_course_members (user_id);
@@ -0,0 +1,38 @@
+-- ============================================================
+-- Chat Service - V015 Retention
+-- A channel's retention policy deletes or anonymizes messages
+-- older than retention_days; channels without a policy keep
+-- everything. Independently, soft-deleted messages lose their
+-- body and attachments once the purge grace period passes.
+-- A legal hold stops both on the channel until it is lifted.
+-- Anonymized messages are reassigned to the placeholder user 0
+-- and lose their reactions and mentions. Anonymizing removes only
+-- the sender: the body and attachments are kept unchanged.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS chat_retention_policies (
+    channel_id        BIGINT       PRIMARY KEY REFERENCES chat_channels(id) ON DELETE CASCADE,
+    retention_days    INT          CHECK (retention_days BETWEEN 1 AND 36500),
+    action            VARCHAR(10)  NOT NULL DEFAULT 'delete'
+                      CHECK (action IN ('delete', 'anonymize')),
+    legal_hold        BOOLEAN      NOT NULL DEFAULT false,
+    legal_hold_reason VARCHAR(500) NOT NULL DEFAULT '',
+    legal_hold_by     BIGINT       REFERENCES users(id),
+    legal_hold_at     TIMESTAMPTZ,
+    updated_by        BIGINT       NOT NULL REFERENCES users(id),
+    updated_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- Auth-service ids start at 1, so 0 never collides with a real user
+INSERT INTO users (id, email, full_name)
+VALUES (0, 'anonymous@chat.invalid', 'Anonymous')
+ON CONFLICT (id) DO NOTHING;
+
+-- Retention walks a channel's messages oldest first
+CREATE INDEX IF NOT EXISTS idx_messages_channel_created
+    ON chat_messages (channel_id, created_at);
+
+-- The purge walks soft-deleted messages by when they were deleted
+CREATE INDEX IF NOT EXISTS idx_messages_deleted_at
+    ON chat_messages (deleted_at)
+    WHERE is_deleted = true;
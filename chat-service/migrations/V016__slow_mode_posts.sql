@@ -0,0 +1,14 @@
+-- ============================================================
+-- Chat Service - V016 Slow Mode Posts
+-- One row per writer and slow-mode channel holding the time of
+-- their last counted post. A send claims the row in the same
+-- transaction as its insert, against the database clock, so
+-- concurrent sends cannot both slip through the window.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS chat_slow_mode_posts (
+    channel_id   BIGINT      NOT NULL REFERENCES chat_channels(id) ON DELETE CASCADE,
+    user_id      BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_post_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (channel_id, user_id)
+);
@@ -0,0 +1,12 @@
+-- ============================================================
+-- Chat Service - V017 Channel-Scoped Idempotency Keys
+-- client_msg_id keys are scoped per sender and channel, so a
+-- client that reuses a key in another channel gets a new message
+-- there instead of the one stored in the first channel.
+-- ============================================================
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_messages_channel_sender_client_msg_id
+    ON chat_messages (channel_id, sender_id, client_msg_id)
+    WHERE client_msg_id IS NOT NULL;
+
+DROP INDEX IF EXISTS uq_messages_sender_client_msg_id;
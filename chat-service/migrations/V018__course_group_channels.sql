@@ -0,0 +1,33 @@
+-- ============================================================
+-- Chat Service - V018 Course Group Channels
+-- Every group of a published course gets its own private channel,
+-- linked by course_group_id and still carrying the course_id.
+-- Its members are the group's students (role GROUP_MEMBER) plus
+-- the course's creator and co-teachers. The course channel is
+-- the one with no course_group_id.
+-- ============================================================
+
+ALTER TABLE chat_channels
+    ADD COLUMN IF NOT EXISTS course_group_id BIGINT;
+
+DROP INDEX IF EXISTS idx_channels_course;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_channels_course
+    ON chat_channels (course_id)
+    WHERE course_id IS NOT NULL AND course_group_id IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_channels_course_group
+    ON chat_channels (course_group_id)
+    WHERE course_group_id IS NOT NULL;
+
+-- Archiving a course finds its group channels through course_id
+CREATE INDEX IF NOT EXISTS idx_channels_course_groups
+    ON chat_channels (course_id)
+    WHERE course_group_id IS NOT NULL;
+
+ALTER TABLE chat_course_members
+    DROP CONSTRAINT IF EXISTS chat_course_members_role_check;
+
+ALTER TABLE chat_course_members
+    ADD CONSTRAINT chat_course_members_role_check
+    CHECK (role IN ('CREATOR', 'CO_TEACHER', 'STUDENT', 'GROUP_MEMBER'));
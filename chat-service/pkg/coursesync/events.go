@@ -0,0 +1,71 @@
+// Package coursesync carries course lifecycle and membership from
+// lms-service. A published course gets a private chat channel whose members
+// follow the course: its creator, co-teachers and accepted students.
+//
+// Events are keyed by course, so one course's changes arrive in order.
+// COURSE_MEMBERS_SYNCED carries the full member list and replaces whatever
+// was held before, which repairs any member event that was missed.
+//
+// Groups of a course's students each get a channel of their own, shared
+// with the course's creator and co-teachers. Events about a group carry
+// its GroupID.
+package coursesync
+
+import "time"
+
+const TopicCourseMembership = "lms.course.membership"
+
+// Event types
+const (
+	CourseMembersSynced = "COURSE_MEMBERS_SYNCED"
+	CourseArchived      = "COURSE_ARCHIVED"
+	CourseDeleted       = "COURSE_DELETED"
+	MemberAdded         = "MEMBER_ADDED"
+	MemberRemoved       = "MEMBER_REMOVED"
+	GroupSynced         = "GROUP_SYNCED"
+	GroupDeleted        = "GROUP_DELETED"
+)
+
+// Member roles
+const (
+	RoleCreator   = "CREATOR"
+	RoleCoTeacher = "CO_TEACHER"
+	RoleStudent   = "STUDENT"
+
+	// RoleGroupMember is a student's place in one of the course's groups
+	RoleGroupMember = "GROUP_MEMBER"
+)
+
+// CourseMember is one person's role in a course. Email and FullName are
+// set when the LMS has them.
+type CourseMember struct {
+	UserID   int64  `json:"user_id"`
+	Email    string `json:"email,omitempty"`
+	FullName string `json:"full_name,omitempty"`
+	Role     string `json:"role"`
+}
+
+// CourseGroup is one group of a course with the user ids of its students.
+type CourseGroup struct {
+	GroupID   int64   `json:"group_id"`
+	Name      string  `json:"name"`
+	MemberIDs []int64 `json:"member_ids"`
+}
+
+// CourseEvent is published to lms.course.membership keyed by course id.
+// Members is every member for COURSE_MEMBERS_SYNCED and GROUP_SYNCED and the
+// changed members for MEMBER_ADDED and MEMBER_REMOVED, which change the
+// group GroupID when it is set. COURSE_MEMBERS_SYNCED also lists every
+// group in Groups.
+type CourseEvent struct {
+	EventID     string         `json:"event_id"`
+	Type        string         `json:"type"`
+	CourseID    int64          `json:"course_id"`
+	CourseTitle string         `json:"course_title,omitempty"`
+	CreatedBy   int64          `json:"created_by,omitempty"`
+	GroupID     int64          `json:"group_id,omitempty"`
+	GroupName   string         `json:"group_name,omitempty"`
+	Members     []CourseMember `json:"members,omitempty"`
+	Groups      []CourseGroup  `json:"groups,omitempty"`
+	OccurredAt  time.Time      `json:"occurred_at"`
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"sync"
 	"time"
 
 	"chat-service/pkg/logger"
@@ -28,6 +29,19 @@ type Client struct {
 	// unregistered. The writePump exits on channel close.
 	send chan []byte
 
+	// mu guards closed and the resume hold below, so that frames are never
+	// queued on a closed send channel.
+	mu     sync.Mutex
+	closed bool
+
+	// While holding, live frames are parked in held until Resume has queued
+	// the replay, so a reconnecting client sees the gap before new events.
+	holding bool
+	held    [][]byte
+
+	// resumeMu serializes Resume calls
+	resumeMu sync.Mutex
+
 	// UserID is set from the validated JWT and used for permission checks.
 	UserID int64
 
@@ -37,14 +51,19 @@ type Client struct {
 	// Roles holds the JWT roles (e.g. ["ADMIN", "TEACHER"]).
 	Roles []string
 
-	// channelIDs is the set of channels this connection is subscribed to.
-	channelIDs []int64
+	// channels is the set of channels this connection is subscribed to. It
+	// changes while connected; the hub updates it together with its rooms.
+	subMu    sync.RWMutex
+	channels map[int64]struct{}
 
 	// publishFn is called by readPump to publish outbound events.
 	// Injected so that tests can replace it without a real Redis client.
 	publishFn func(channelID int64, event WSEvent) error
 }
 
+// resumePollInterval is how often a paced replay retries a full send buffer.
+const resumePollInterval = 10 * time.Millisecond
+
 // NewClient constructs a Client and registers it with the hub.
 func NewClient(
 	h *Hub,
@@ -55,13 +74,16 @@ func NewClient(
 	channelIDs []int64,
 ) *Client {
 	c := &Client{
-		hub:        h,
-		conn:       conn,
-		send:       make(chan []byte, ClientSendBuffer),
-		UserID:     userID,
-		Email:      email,
-		Roles:      roles,
-		channelIDs: channelIDs,
+		hub:      h,
+		conn:     conn,
+		send:     make(chan []byte, ClientSendBuffer),
+		UserID:   userID,
+		Email:    email,
+		Roles:    roles,
+		channels: make(map[int64]struct{}, len(channelIDs)),
+	}
+	for _, id := range channelIDs {
+		c.channels[id] = struct{}{}
 	}
 	c.publishFn = func(channelID int64, event WSEvent) error {
 		return h.Publish(context.Background(), channelID, event)
@@ -71,10 +93,156 @@ func NewClient(
 
 // InboundMsg is the client-side JSON frame sent TO the server.
 type InboundMsg struct {
-	Type      EventType `json:"type"`
-	ChannelID int64     `json:"channel_id"`
-	Body      string    `json:"body,omitempty"`      // for EventMessage
-	ParentID  *int64    `json:"parent_id,omitempty"` // for threaded replies
+	Type        EventType       `json:"type"`
+	ChannelID   int64           `json:"channel_id"`
+	Body        string          `json:"body,omitempty"`          // for EventMessage
+	ParentID    *int64          `json:"parent_id,omitempty"`     // for threaded replies
+	ClientMsgID string          `json:"client_msg_id,omitempty"` // for EventMessage: idempotency key
+	MessageID   int64           `json:"message_id,omitempty"`    // for EventAck: last message read
+	Cursors     map[int64]int64 `json:"cursors,omitempty"`       // for EventResume: channelID -> last seen message ID
+}
+
+// Send queues an event for this client only, bypassing Redis. Used for acks,
+// errors and replays that concern a single connection.
+func (c *Client) Send(event WSEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		logger.Warnf("client %d: marshal %s: %v", c.UserID, event.Type, err)
+		return
+	}
+	if !c.enqueue(data) {
+		droppedMessages.WithLabelValues("slow_client").Inc()
+		c.hub.UnregisterClient(c)
+	}
+}
+
+// HoldUntilResume parks live events until Resume is called, or until timeout
+// passes without a resume frame.
+func (c *Client) HoldUntilResume(timeout time.Duration) {
+	c.mu.Lock()
+	c.holding = true
+	c.mu.Unlock()
+	time.AfterFunc(timeout, func() { c.Resume(nil) })
+}
+
+// Resume queues the replay events followed by any live frames held since the
+// client connected, then switches back to live delivery. A replay can be
+// larger than the send buffer, so it is paced: frames are queued as the write
+// pump makes room, and live frames keep being held until everything before
+// them is queued. Only a client that drains nothing for WriteWait is dropped.
+func (c *Client) Resume(replay []WSEvent) {
+	frames := make([][]byte, 0, len(replay))
+	for _, event := range replay {
+		data, err := json.Marshal(event)
+		if err != nil {
+			logger.Warnf("client %d: marshal replay: %v", c.UserID, err)
+			continue
+		}
+		frames = append(frames, data)
+	}
+
+	// The hold timeout may fire while a resume frame is being handled
+	c.resumeMu.Lock()
+	defer c.resumeMu.Unlock()
+
+	ok := c.pushPaced(frames)
+	for ok {
+		c.mu.Lock()
+		held := c.held
+		c.held = nil
+		if len(held) == 0 {
+			c.holding = false
+			c.mu.Unlock()
+			break
+		}
+		c.mu.Unlock()
+		ok = c.pushPaced(held)
+	}
+
+	if !ok {
+		droppedMessages.WithLabelValues("slow_client").Inc()
+		c.hub.UnregisterClient(c)
+	}
+}
+
+// pushPaced queues frames in order, waiting for the write pump to make room
+// when the send buffer is full. It returns false when no frame could be
+// queued for WriteWait.
+func (c *Client) pushPaced(frames [][]byte) bool {
+	deadline := time.Now().Add(WriteWait)
+	for len(frames) > 0 {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return true
+		}
+		n := 0
+	fill:
+		for _, data := range frames {
+			select {
+			case c.send <- data:
+				n++
+			default:
+				break fill
+			}
+		}
+		c.mu.Unlock()
+
+		frames = frames[n:]
+		if n > 0 {
+			deadline = time.Now().Add(WriteWait)
+		}
+		if len(frames) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(resumePollInterval)
+	}
+	return true
+}
+
+// enqueue queues one frame without blocking. It returns false only when the
+// client is too slow to keep up; frames for a closed client are discarded.
+func (c *Client) enqueue(data []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.holding {
+		if len(c.held) >= ClientSendBuffer {
+			return false
+		}
+		c.held = append(c.held, data)
+		return true
+	}
+	return c.pushLocked([][]byte{data})
+}
+
+// pushLocked writes frames to the send channel. Caller must hold mu.
+func (c *Client) pushLocked(frames [][]byte) bool {
+	if c.closed {
+		return true
+	}
+	for _, data := range frames {
+		select {
+		case c.send <- data:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// close closes the send channel once. It reports whether this call closed it.
+func (c *Client) close() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+	c.closed = true
+	close(c.send)
+	return true
 }
 
 // WritePump pumps messages from the send channel to the WebSocket.
@@ -163,8 +331,10 @@ func (c *Client) ReadPump(onMessage func(client *Client, msg InboundMsg)) {
 		}
 		c.hub.TouchPresence(c.UserID)
 
-		// Validate the client is subscribed to the target channel
-		if !c.subscribedTo(msg.ChannelID) {
+		// Validate the client is subscribed to the target channel. A resume
+		// frame names its channels in Cursors, which the handler filters, and a
+		// subscribe frame is access-checked by the handler.
+		if msg.Type != EventResume && msg.Type != EventSubscribe && !c.SubscribedTo(msg.ChannelID) {
 			continue
 		}
 
@@ -172,13 +342,30 @@ func (c *Client) ReadPump(onMessage func(client *Client, msg InboundMsg)) {
 	}
 }
 
-func (c *Client) subscribedTo(channelID int64) bool {
-	for _, id := range c.channelIDs {
-		if id == channelID {
-			return true
-		}
+// SubscribedTo reports whether this connection receives channelID's events.
+func (c *Client) SubscribedTo(channelID int64) bool {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	_, ok := c.channels[channelID]
+	return ok
+}
+
+// ChannelIDs returns a snapshot of the subscribed channels.
+func (c *Client) ChannelIDs() []int64 {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	ids := make([]int64, 0, len(c.channels))
+	for id := range c.channels {
+		ids = append(ids, id)
 	}
-	return false
+	return ids
+}
+
+// isClosed reports whether the hub has already removed this client.
+func (c *Client) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
 }
 
 // Upgrader is a gorilla WebSocket upgrader with sensible defaults.
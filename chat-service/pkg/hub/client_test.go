@@ -0,0 +1,83 @@
+package hub
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestResumeQueuesReplayBeforeHeldFrames(t *testing.T) {
+	c := connect(New(nil), 1, 7)
+	c.HoldUntilResume(time.Hour)
+
+	c.Send(WSEvent{Type: EventMessage, ChannelID: 7})
+	if ev, ok := nextEvent(t, c); ok {
+		t.Fatalf("got %+v while holding; want live frames held", ev)
+	}
+
+	c.Resume([]WSEvent{{Type: EventReplay, ChannelID: 7}})
+	for _, want := range []EventType{EventReplay, EventMessage} {
+		if ev, ok := nextEvent(t, c); !ok || ev.Type != want {
+			t.Fatalf("got %+v, %v; want %s", ev, ok, want)
+		}
+	}
+
+	c.Send(WSEvent{Type: EventTyping, ChannelID: 7})
+	if ev, ok := nextEvent(t, c); !ok || ev.Type != EventTyping {
+		t.Errorf("got %+v, %v after resume; want live delivery", ev, ok)
+	}
+}
+
+func TestResumePacesReplayLargerThanSendBuffer(t *testing.T) {
+	h := New(nil)
+	c := connect(h, 1, 7)
+	c.HoldUntilResume(time.Hour)
+
+	const held = 10
+	for i := 0; i < held; i++ {
+		c.Send(WSEvent{Type: EventMessage, ChannelID: 7})
+	}
+	replay := make([]WSEvent, ClientSendBuffer+50)
+	for i := range replay {
+		replay[i] = WSEvent{Type: EventReplay, ChannelID: int64(i)}
+	}
+
+	// A write pump that drains slower than Resume queues
+	total := len(replay) + held
+	got := make(chan []byte, total)
+	go func() {
+		for i := 0; i < total; i++ {
+			data := <-c.send
+			got <- data
+			if i%64 == 0 {
+				time.Sleep(2 * resumePollInterval)
+			}
+		}
+	}()
+
+	c.Resume(replay)
+
+	for i := 0; i < total; i++ {
+		var data []byte
+		select {
+		case data = <-got:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of %d frames", i, total)
+		}
+		var ev WSEvent
+		if err := json.Unmarshal(data, &ev); err != nil {
+			t.Fatalf("decode frame %d: %v", i, err)
+		}
+		switch {
+		case i < len(replay) && (ev.Type != EventReplay || ev.ChannelID != int64(i)):
+			t.Fatalf("frame %d = %s for channel %d; want replay %d in order", i, ev.Type, ev.ChannelID, i)
+		case i >= len(replay) && ev.Type != EventMessage:
+			t.Fatalf("frame %d = %s; want held frames after the replay", i, ev.Type)
+		}
+	}
+	select {
+	case dropped := <-h.unregister:
+		t.Errorf("client %d dropped although the write pump kept draining", dropped.UserID)
+	default:
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -32,6 +33,10 @@ const (
 	// PubSubPrefix is the Redis key prefix for per-channel Pub/Sub topics.
 	PubSubPrefix = "chat:ch:"
 
+	// UserPubSubPrefix is the Redis key prefix for per-user topics, which
+	// reach every connection of one user whatever channels it follows.
+	UserPubSubPrefix = "chat:user:"
+
 	// ClientSendBuffer is the size of each client's outbound channel.
 	// When full the client is considered "slow" and dropped.
 	ClientSendBuffer = 256
@@ -68,7 +73,33 @@ const (
 	EventJoin    EventType = "join"
 	EventLeave   EventType = "leave"
 	EventPing    EventType = "ping"
-	EventAck     EventType = "ack"
+	EventAck     EventType = "ack" // client: read up to message_id; server: message stored
+	EventRead    EventType = "read"
+	EventResume  EventType = "resume"
+	EventReplay  EventType = "replay"
+	EventError   EventType = "error"
+
+	// Control frames a client sends to change its subscriptions; the server
+	// answers with EventJoin / EventLeave, which it also sends unprompted
+	// when an admin change grants or revokes access.
+	EventSubscribe   EventType = "subscribe"
+	EventUnsubscribe EventType = "unsubscribe"
+
+	EventReaction EventType = "reaction"
+	EventPin      EventType = "pin"
+	EventUnpin    EventType = "unpin"
+	EventSaved    EventType = "saved" // sent to the user's own connections only
+	EventMute     EventType = "mute"  // sent to the user's own connections only
+
+	// EventMention goes to a mentioned user's connections subscribed to the
+	// channel, whether or not they muted it.
+	EventMention EventType = "mention"
+
+	EventSlowMode EventType = "slow_mode"
+	EventSanction EventType = "sanction" // sent to the sanctioned user's own connections only
+
+	// EventArchive marks a course channel read-only, or writable again.
+	EventArchive EventType = "channel_archived"
 )
 
 // WSEvent is the JSON envelope sent over WebSocket and through Redis Pub/Sub.
@@ -89,9 +120,11 @@ type MessagePayload struct {
 	IsDeleted        bool                `json:"is_deleted,omitempty"`
 	IsEdited         bool                `json:"is_edited,omitempty"`
 	ParentID         *int64              `json:"parent_id,omitempty"`
+	ThreadRootID     *int64              `json:"thread_root_id,omitempty"`
 	ParentSenderName string              `json:"parent_sender_name,omitempty"`
 	ParentBody       string              `json:"parent_body,omitempty"`
 	Attachments      []AttachmentPayload `json:"attachments,omitempty"`
+	CreatedAt        time.Time           `json:"created_at,omitzero"`
 }
 
 type AttachmentPayload struct {
@@ -102,6 +135,99 @@ type AttachmentPayload struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// AckPayload is the Payload of the EventAck the server sends back to the
+// sender once a message is stored. Duplicate is true when ClientMsgID had
+// already been used and MessageID is the earlier message.
+type AckPayload struct {
+	ClientMsgID string `json:"client_msg_id,omitempty"`
+	MessageID   int64  `json:"message_id"`
+	Duplicate   bool   `json:"duplicate,omitempty"`
+}
+
+// ErrorPayload is the Payload of EventError, sent only to the client whose
+// frame could not be processed.
+type ErrorPayload struct {
+	ClientMsgID string `json:"client_msg_id,omitempty"`
+	Code        string `json:"code"`
+	Message     string `json:"message"`
+	RetryAfter  int    `json:"retry_after,omitempty"` // seconds, for code "slow_mode"
+}
+
+// ReplayPayload is the Payload of EventReplay: the messages of one channel
+// published after the ID the client resumed from, oldest first. HasMore
+// means the gap was larger than one replay and the rest must be paged in
+// over REST.
+type ReplayPayload struct {
+	Messages []MessagePayload `json:"messages"`
+	HasMore  bool             `json:"has_more"`
+}
+
+// ReactionPayload is the Payload for EventReaction: UserID added or removed
+// Emoji, after which Count users carry it.
+type ReactionPayload struct {
+	MessageID int64  `json:"message_id"`
+	UserID    int64  `json:"user_id"`
+	Emoji     string `json:"emoji"`
+	Added     bool   `json:"added"`
+	Count     int    `json:"count"`
+}
+
+// PinPayload is the Payload for EventPin / EventUnpin.
+type PinPayload struct {
+	MessageID int64     `json:"message_id"`
+	PinnedBy  int64     `json:"pinned_by,omitempty"`
+	PinnedAt  time.Time `json:"pinned_at,omitzero"`
+}
+
+// MutePayload is the Payload for EventMute.
+type MutePayload struct {
+	Muted bool `json:"muted"`
+}
+
+// MentionPayload is the Payload for EventMention. Kind is "user",
+// "channel" or "here"; Preview is the start of the message body.
+type MentionPayload struct {
+	MessageID  int64  `json:"message_id"`
+	SenderID   int64  `json:"sender_id"`
+	SenderName string `json:"sender_name"`
+	Kind       string `json:"kind"`
+	Preview    string `json:"preview"`
+}
+
+// SlowModePayload is the Payload for EventSlowMode; 0 turns slow mode off.
+type SlowModePayload struct {
+	Seconds int `json:"seconds"`
+}
+
+// SanctionPayload is the Payload for EventSanction. ChannelID 0 in the
+// envelope means every channel; Revoked reports a sanction lifted early.
+type SanctionPayload struct {
+	SanctionID int64      `json:"sanction_id"`
+	Kind       string     `json:"kind"`
+	Reason     string     `json:"reason,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Revoked    bool       `json:"revoked,omitempty"`
+}
+
+// ArchivePayload is the Payload for EventArchive.
+type ArchivePayload struct {
+	Archived bool `json:"archived"`
+}
+
+// SavedPayload is the Payload for EventSaved.
+type SavedPayload struct {
+	MessageID int64 `json:"message_id"`
+	Saved     bool  `json:"saved"`
+}
+
+// ReadReceiptPayload is the Payload for EventRead, published when a member
+// of a DM or small channel advances their read pointer.
+type ReadReceiptPayload struct {
+	UserID            int64     `json:"user_id"`
+	LastReadMessageID int64     `json:"last_read_message_id"`
+	ReadAt            time.Time `json:"read_at"`
+}
+
 // TypingPayload is the Payload for EventTyping.
 type TypingPayload struct {
 	UserID   int64  `json:"user_id"`
@@ -115,8 +241,13 @@ type TypingPayload struct {
 // Hub manages the in-process registry of active WebSocket clients and
 // bridges local broadcasts with Redis Pub/Sub for multi-replica support.
 type Hub struct {
-	mu    sync.RWMutex
-	rooms map[int64]map[*Client]struct{} // channelID -> set of local clients
+	mu      sync.RWMutex
+	rooms   map[int64]map[*Client]struct{} // channelID -> set of local clients
+	clients map[*Client]struct{}           // connected local clients, subscribed or not
+	users   map[int64]map[*Client]struct{} // userID -> that user's local clients
+
+	// canRead re-checks channel access when membership changes; see SetAccessFunc.
+	canRead AccessFunc
 
 	register   chan *Client
 	unregister chan *Client
@@ -137,6 +268,8 @@ func New(rdb *redis.Client) *Hub {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Hub{
 		rooms:      make(map[int64]map[*Client]struct{}),
+		clients:    make(map[*Client]struct{}),
+		users:      make(map[int64]map[*Client]struct{}),
 		register:   make(chan *Client, 512),
 		unregister: make(chan *Client, 512),
 		incoming:   make(chan *redis.Message, 4096),
@@ -153,7 +286,10 @@ func (h *Hub) Run() {
 		broadcastWorkers, 4096, PubSubPrefix+"*")
 
 	// Start Redis Pub/Sub subscriber
-	h.pubsub = h.rdb.PSubscribe(h.ctx, PubSubPrefix+"*")
+	h.pubsub = h.rdb.PSubscribe(h.ctx, PubSubPrefix+"*", UserPubSubPrefix+"*")
+	if err := h.pubsub.Subscribe(h.ctx, MembershipTopic); err != nil {
+		logger.Errorf("hub: subscribe %s: %v", MembershipTopic, err)
+	}
 
 	// Forward Redis messages to the incoming queue
 	h.wg.Add(1)
@@ -165,6 +301,7 @@ func (h *Hub) Run() {
 			case h.incoming <- msg:
 			default:
 				// Drop if incoming is full - prevents Redis subscriber from blocking
+				droppedMessages.WithLabelValues("queue_full").Inc()
 				logger.Warn("hub: incoming queue full, dropping Redis message")
 			}
 		}
@@ -223,9 +360,34 @@ func (h *Hub) Publish(ctx context.Context, channelID int64, event WSEvent) error
 	}
 	logger.Debugf("hub: publish type=%s channelID=%d localClients=%d",
 		event.Type, channelID, h.LocalCount(channelID))
+	publishedEvents.WithLabelValues(string(event.Type)).Inc()
 	return h.rdb.Publish(ctx, redisKey(channelID), data).Err()
 }
 
+// PublishToUser sends event to every connection of userID on any replica,
+// independent of channel subscriptions. Used for personal events such as
+// saved messages.
+func (h *Hub) PublishToUser(ctx context.Context, userID int64, event WSEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	publishedEvents.WithLabelValues(string(event.Type)).Inc()
+	return h.rdb.Publish(ctx, UserPubSubPrefix+int64ToString(userID), data).Err()
+}
+
+// PublishToMember sends event to the connections of userID that are
+// subscribed to channelID, on any replica. Unlike PublishToUser it never
+// reaches a connection that cannot read the channel.
+func (h *Hub) PublishToMember(ctx context.Context, channelID, userID int64, event WSEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	publishedEvents.WithLabelValues(string(event.Type)).Inc()
+	return h.rdb.Publish(ctx, memberKey(channelID, userID), data).Err()
+}
+
 // RegisterClient queues a client for registration.
 func (h *Hub) RegisterClient(c *Client) {
 	h.TouchPresence(c.UserID)
@@ -266,6 +428,28 @@ func (h *Hub) IsOnline(ctx context.Context, userID int64) (bool, error) {
 	return count > 0, err
 }
 
+// OnlineUsers is IsOnline for many users in one Redis round trip.
+func (h *Hub) OnlineUsers(ctx context.Context, userIDs []int64) (map[int64]bool, error) {
+	online := make(map[int64]bool, len(userIDs))
+	if len(userIDs) == 0 {
+		return online, nil
+	}
+	pipe := h.rdb.Pipeline()
+	cmds := make([]*redis.IntCmd, len(userIDs))
+	for i, id := range userIDs {
+		cmds[i] = pipe.Exists(ctx, presenceKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	for i, id := range userIDs {
+		if cmds[i].Val() > 0 {
+			online[id] = true
+		}
+	}
+	return online, nil
+}
+
 // -------------------------------------------------------------------
 // internal helpers
 // -------------------------------------------------------------------
@@ -274,30 +458,39 @@ func (h *Hub) addClient(c *Client) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	for _, chID := range c.channelIDs {
-		if h.rooms[chID] == nil {
-			h.rooms[chID] = make(map[*Client]struct{})
-		}
-		h.rooms[chID][c] = struct{}{}
+	channelIDs := c.ChannelIDs()
+	for _, chID := range channelIDs {
+		h.joinRoomLocked(c, chID)
 	}
+	h.clients[c] = struct{}{}
+	if h.users[c.UserID] == nil {
+		h.users[c.UserID] = make(map[*Client]struct{})
+	}
+	h.users[c.UserID][c] = struct{}{}
 	totalLocal := h.totalLocalClients()
 	logger.Infof("hub: client connected userID=%d channels=%v totalLocal=%d",
-		c.UserID, c.channelIDs, totalLocal)
+		c.UserID, channelIDs, totalLocal)
 }
 
 func (h *Hub) removeClient(c *Client) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	for _, chID := range c.channelIDs {
-		if room, ok := h.rooms[chID]; ok {
-			delete(room, c)
-			if len(room) == 0 {
-				delete(h.rooms, chID)
-			}
+	// A slow client can be queued for removal by fanOut and by its own readPump
+	if !c.close() {
+		return
+	}
+
+	for _, chID := range c.ChannelIDs() {
+		h.leaveRoomLocked(c, chID)
+	}
+	delete(h.clients, c)
+	if conns, ok := h.users[c.UserID]; ok {
+		delete(conns, c)
+		if len(conns) == 0 {
+			delete(h.users, c.UserID)
 		}
 	}
-	close(c.send)
 	totalLocal := h.totalLocalClients()
 	logger.Infof("hub: client disconnected userID=%d totalLocal=%d", c.UserID, totalLocal)
 }
@@ -326,30 +519,46 @@ func (h *Hub) broadcastWorker() {
 }
 
 func (h *Hub) fanOut(msg *redis.Message) {
-	// Parse channel ID from key "chat:ch:<id>"
-	var channelID int64
-	if !parseChannelID(msg.Channel, &channelID) {
+	if msg.Channel == MembershipTopic {
+		// Access re-checks hit the database; keep the worker free for fan-out
+		go h.applyMembership(msg.Payload)
+		return
+	}
+
+	// Parse the target from key "chat:ch:<id>", "chat:user:<id>" or
+	// "chat:user:<id>:ch:<id>"
+	var id, channelID int64
+	var room map[*Client]struct{}
+	switch {
+	case parseKeyID(msg.Channel, PubSubPrefix, &id):
+		h.mu.RLock()
+		room = h.rooms[id]
+	case parseKeyID(msg.Channel, UserPubSubPrefix, &id):
+		h.mu.RLock()
+		room = h.users[id]
+	case parseMemberKey(msg.Channel, &id, &channelID):
+		h.mu.RLock()
+		room = h.users[id]
+	default:
 		return
 	}
 
 	data := []byte(msg.Payload)
 
-	h.mu.RLock()
-	room := h.rooms[channelID]
 	// Copy client references under read lock to minimise lock hold time
 	clients := make([]*Client, 0, len(room))
 	for c := range room {
-		clients = append(clients, c)
+		if channelID == 0 || c.SubscribedTo(channelID) {
+			clients = append(clients, c)
+		}
 	}
 	h.mu.RUnlock()
 
 	for _, c := range clients {
-		select {
-		case c.send <- data:
-			// delivered
-		default:
+		if !c.enqueue(data) {
 			// Client is too slow - drop it. The writePump will detect the closed
 			// channel and terminate the connection.
+			droppedMessages.WithLabelValues("slow_client").Inc()
 			logger.Warnf("hub: slow client %d, dropping", c.UserID)
 			h.UnregisterClient(c)
 		}
@@ -391,9 +600,24 @@ func int64ToString(n int64) string {
 	return string(buf[pos:])
 }
 
-func parseChannelID(key string, out *int64) bool {
-	prefix := PubSubPrefix
-	if len(key) <= len(prefix) {
+func memberKey(channelID, userID int64) string {
+	return UserPubSubPrefix + int64ToString(userID) + ":ch:" + int64ToString(channelID)
+}
+
+// parseMemberKey parses a memberKey into its user and channel IDs.
+func parseMemberKey(key string, userID, channelID *int64) bool {
+	rest, ok := strings.CutPrefix(key, UserPubSubPrefix)
+	if !ok {
+		return false
+	}
+	user, channel, ok := strings.Cut(rest, ":ch:")
+	return ok &&
+		parseKeyID(user, "", userID) &&
+		parseKeyID(channel, "", channelID)
+}
+
+func parseKeyID(key, prefix string, out *int64) bool {
+	if len(key) <= len(prefix) || key[:len(prefix)] != prefix {
 		return false
 	}
 	s := key[len(prefix):]
@@ -0,0 +1,188 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"chat-service/pkg/logger"
+)
+
+// MembershipTopic is the Redis Pub/Sub topic on which replicas announce
+// channel access changes. It sits outside PubSubPrefix so it is never fanned
+// out to clients as a chat event.
+const MembershipTopic = "chat:membership"
+
+// membershipCheckTimeout bounds the access re-checks for one change.
+const membershipCheckTimeout = 30 * time.Second
+
+// ReadAccess answers whether a user with the given roles may read the
+// channel it was loaded for.
+type ReadAccess interface {
+	CanRead(userID int64, roles []string) bool
+}
+
+// AccessFunc loads the read access of userIDs to a channel. The hub calls it
+// once per channel to re-evaluate connected clients after a
+// MembershipChange, so it should cost a single query however many users are
+// passed.
+type AccessFunc func(ctx context.Context, channelID int64, userIDs []int64) (ReadAccess, error)
+
+// MembershipChange announces that access to ChannelID may have changed for
+// UserIDs (every connected user when empty). Each replica re-checks its
+// local clients, subscribing those who gained access and unsubscribing those
+// who lost it. Deleted unsubscribes everyone without a check. ChannelID 0
+// re-checks every channel the UserIDs' connections are subscribed to, so it
+// can only revoke access.
+type MembershipChange struct {
+	ChannelID int64   `json:"channel_id"`
+	UserIDs   []int64 `json:"user_ids,omitempty"`
+	Deleted   bool    `json:"deleted,omitempty"`
+}
+
+// SetAccessFunc installs the access check used for membership changes. It
+// must be called before Run.
+func (h *Hub) SetAccessFunc(fn AccessFunc) {
+	h.canRead = fn
+}
+
+// PublishMembership announces a membership change to every replica,
+// including this one.
+func (h *Hub) PublishMembership(ctx context.Context, change MembershipChange) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	return h.rdb.Publish(ctx, MembershipTopic, data).Err()
+}
+
+// Subscribe starts delivering channelID's events to c. It reports whether c
+// was not subscribed before.
+func (h *Hub) Subscribe(c *Client, channelID int64) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c.isClosed() || c.SubscribedTo(channelID) {
+		return false
+	}
+	c.subMu.Lock()
+	c.channels[channelID] = struct{}{}
+	c.subMu.Unlock()
+	h.joinRoomLocked(c, channelID)
+	return true
+}
+
+// Unsubscribe stops delivering channelID's events to c. It reports whether c
+// was subscribed.
+func (h *Hub) Unsubscribe(c *Client, channelID int64) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !c.SubscribedTo(channelID) {
+		return false
+	}
+	c.subMu.Lock()
+	delete(c.channels, channelID)
+	c.subMu.Unlock()
+	h.leaveRoomLocked(c, channelID)
+	return true
+}
+
+// applyMembership re-evaluates the local clients a MembershipChange concerns
+// and tells each one whose subscriptions changed with EventJoin / EventLeave.
+func (h *Hub) applyMembership(payload string) {
+	var change MembershipChange
+	if err := json.Unmarshal([]byte(payload), &change); err != nil ||
+		change.ChannelID < 0 || (change.ChannelID == 0 && len(change.UserIDs) == 0) {
+		logger.Warnf("hub: malformed membership change %q", payload)
+		return
+	}
+
+	users := make(map[int64]bool, len(change.UserIDs))
+	for _, id := range change.UserIDs {
+		users[id] = true
+	}
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.clients))
+	for c := range h.clients {
+		if len(users) == 0 || users[c.UserID] {
+			clients = append(clients, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	// Group the clients by channel so each channel costs one access query
+	// however many connections it has
+	byChannel := make(map[int64][]*Client)
+	for _, c := range clients {
+		if change.ChannelID != 0 {
+			byChannel[change.ChannelID] = append(byChannel[change.ChannelID], c)
+			continue
+		}
+		for _, channelID := range c.ChannelIDs() {
+			byChannel[channelID] = append(byChannel[channelID], c)
+		}
+	}
+	if len(byChannel) == 0 {
+		return
+	}
+	if !change.Deleted && h.canRead == nil {
+		logger.Warn("hub: membership change ignored, no access check installed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(h.ctx, membershipCheckTimeout)
+	defer cancel()
+
+	for channelID, members := range byChannel {
+		h.recheckChannel(ctx, channelID, members, change.Deleted)
+	}
+}
+
+// recheckChannel subscribes or unsubscribes each of clients to channelID to
+// match its current access and tells it with EventJoin / EventLeave.
+func (h *Hub) recheckChannel(ctx context.Context, channelID int64, clients []*Client, deleted bool) {
+	var access ReadAccess
+	if !deleted {
+		seen := make(map[int64]bool, len(clients))
+		userIDs := make([]int64, 0, len(clients))
+		for _, c := range clients {
+			if !seen[c.UserID] {
+				seen[c.UserID] = true
+				userIDs = append(userIDs, c.UserID)
+			}
+		}
+		var err error
+		access, err = h.canRead(ctx, channelID, userIDs)
+		if err != nil {
+			logger.Warnf("hub: membership check channelID=%d users=%d: %v", channelID, len(userIDs), err)
+			return
+		}
+	}
+
+	for _, c := range clients {
+		allowed := access != nil && access.CanRead(c.UserID, c.Roles)
+		switch {
+		case allowed && h.Subscribe(c, channelID):
+			c.Send(WSEvent{Type: EventJoin, ChannelID: channelID, Timestamp: time.Now().UTC()})
+		case !allowed && h.Unsubscribe(c, channelID):
+			c.Send(WSEvent{Type: EventLeave, ChannelID: channelID, Timestamp: time.Now().UTC()})
+		}
+	}
+}
+
+// joinRoomLocked adds c to a channel's room. Caller must hold mu.
+func (h *Hub) joinRoomLocked(c *Client, channelID int64) {
+	if h.rooms[channelID] == nil {
+		h.rooms[channelID] = make(map[*Client]struct{})
+	}
+	h.rooms[channelID][c] = struct{}{}
+}
+
+// leaveRoomLocked removes c from a channel's room. Caller must hold mu.
+func (h *Hub) leaveRoomLocked(c *Client, channelID int64) {
+	if room, ok := h.rooms[channelID]; ok {
+		delete(room, c)
+		if len(room) == 0 {
+			delete(h.rooms, channelID)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+type readers map[int64]bool
+
+func (r readers) CanRead(userID int64, _ []string) bool { return r[userID] }
+
+// connect adds a client to h as registration would, subscribed to channelIDs.
+func connect(h *Hub, userID int64, channelIDs ...int64) *Client {
+	c := NewClient(h, nil, userID, "", nil, channelIDs)
+	h.clients[c] = struct{}{}
+	for _, id := range channelIDs {
+		h.joinRoomLocked(c, id)
+	}
+	return c
+}
+
+func nextEvent(t *testing.T, c *Client) (WSEvent, bool) {
+	t.Helper()
+	select {
+	case data := <-c.send:
+		var ev WSEvent
+		if err := json.Unmarshal(data, &ev); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		return ev, true
+	default:
+		return WSEvent{}, false
+	}
+}
+
+func TestApplyMembershipQueriesOncePerChannel(t *testing.T) {
+	h := New(nil)
+	var calls []int64
+	h.SetAccessFunc(func(_ context.Context, channelID int64, userIDs []int64) (ReadAccess, error) {
+		calls = append(calls, channelID)
+		if len(userIDs) != 2 {
+			t.Errorf("channel %d checked for users %v; want each user once", channelID, userIDs)
+		}
+		return readers{1: true}, nil
+	})
+	alice1, alice2, bob := connect(h, 1), connect(h, 1), connect(h, 2, 7)
+
+	h.applyMembership(`{"channel_id":7}`)
+
+	if len(calls) != 1 || calls[0] != 7 {
+		t.Fatalf("access checks = %v; want one for channel 7", calls)
+	}
+	for _, c := range []*Client{alice1, alice2} {
+		if ev, ok := nextEvent(t, c); !ok || ev.Type != EventJoin || !c.SubscribedTo(7) {
+			t.Errorf("user 1 got %+v, subscribed=%v; want a join", ev, c.SubscribedTo(7))
+		}
+	}
+	if ev, ok := nextEvent(t, bob); !ok || ev.Type != EventLeave || bob.SubscribedTo(7) {
+		t.Errorf("user 2 got %+v, subscribed=%v; want a leave", ev, bob.SubscribedTo(7))
+	}
+}
+
+func TestApplyMembershipRechecksSubscribedChannels(t *testing.T) {
+	h := New(nil)
+	checked := make(map[int64]int)
+	h.SetAccessFunc(func(_ context.Context, channelID int64, _ []int64) (ReadAccess, error) {
+		checked[channelID]++
+		return readers{}, nil
+	})
+	a, b := connect(h, 1, 3, 4), connect(h, 1, 4)
+	connect(h, 2, 5)
+
+	h.applyMembership(`{"channel_id":0,"user_ids":[1]}`)
+
+	if len(checked) != 2 || checked[3] != 1 || checked[4] != 1 {
+		t.Fatalf("access checks = %v; want channels 3 and 4 once each", checked)
+	}
+	if len(a.ChannelIDs()) != 0 || len(b.ChannelIDs()) != 0 {
+		t.Errorf("user 1 still subscribed to %v and %v", a.ChannelIDs(), b.ChannelIDs())
+	}
+}
+
+func TestApplyMembershipDeletedSkipsCheck(t *testing.T) {
+	h := New(nil)
+	c := connect(h, 1, 9)
+
+	h.applyMembership(`{"channel_id":9,"deleted":true}`)
+
+	if ev, ok := nextEvent(t, c); !ok || ev.Type != EventLeave || c.SubscribedTo(9) {
+		t.Errorf("got %+v, subscribed=%v; want a leave", ev, c.SubscribedTo(9))
+	}
+}
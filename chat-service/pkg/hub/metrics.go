@@ -0,0 +1,50 @@
+package hub
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	droppedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "chat_ws_dropped_messages_total",
+		Help: "Messages not delivered locally: queue_full (Redis fan-out queue) or slow_client.",
+	}, []string{"reason"})
+
+	publishedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "chat_ws_published_events_total",
+		Help: "Events published to Redis for fan-out, by event type.",
+	}, []string{"type"})
+
+	clientsDesc = prometheus.NewDesc("chat_ws_clients",
+		"WebSocket clients connected to this replica.", nil, nil)
+	subscriptionsDesc = prometheus.NewDesc("chat_ws_channel_subscriptions",
+		"Client-channel subscriptions held by this replica.", nil, nil)
+	roomsDesc = prometheus.NewDesc("chat_ws_active_channels",
+		"Channels with at least one client on this replica.", nil, nil)
+)
+
+// RegisterMetrics exports the hub's client counts on the default registry
+func (h *Hub) RegisterMetrics() {
+	prometheus.MustRegister(hubCollector{h})
+}
+
+type hubCollector struct{ h *Hub }
+
+func (c hubCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- clientsDesc
+	ch <- subscriptionsDesc
+	ch <- roomsDesc
+}
+
+func (c hubCollector) Collect(ch chan<- prometheus.Metric) {
+	c.h.mu.RLock()
+	clients := len(c.h.clients)
+	subscriptions := c.h.totalLocalClients()
+	rooms := len(c.h.rooms)
+	c.h.mu.RUnlock()
+
+	ch <- prometheus.MustNewConstMetric(clientsDesc, prometheus.GaugeValue, float64(clients))
+	ch <- prometheus.MustNewConstMetric(subscriptionsDesc, prometheus.GaugeValue, float64(subscriptions))
+	ch <- prometheus.MustNewConstMetric(roomsDesc, prometheus.GaugeValue, float64(rooms))
+}
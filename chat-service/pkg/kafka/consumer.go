@@ -0,0 +1,160 @@
+// Package kafka consumes the auth service's user lifecycle events and the
+// LMS's course membership events.
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"chat-service/pkg/authsync"
+	"chat-service/pkg/coursesync"
+	"chat-service/pkg/logger"
+	"chat-service/pkg/telemetry"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxHandlerAttempts bounds retries of a failing event before it is
+// skipped; cmd/reconcile repairs skipped users, and a course's next
+// COURSE_MEMBERS_SYNCED repairs its channel
+const maxHandlerAttempts = 8
+
+var (
+	messagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_messages_consumed_total",
+		Help: "Messages consumed, by result (processed, malformed, skipped, cancelled).",
+	}, []string{"topic", "result"})
+
+	handlerRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_handler_retries_total",
+		Help: "Handler attempts retried after a failure.",
+	}, []string{"topic"})
+
+	handlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_handler_duration_seconds",
+		Help:    "Time from fetching a message to settling it, retries included.",
+		Buckets: prometheus.ExponentialBuckets(0.005, 4, 8),
+	}, []string{"topic"})
+)
+
+// StartUserEventConsumer reads auth.user.events until ctx is cancelled.
+// Offsets are committed only after the handler succeeds or gives up, so a
+// crash or database outage does not silently drop a user change.
+func StartUserEventConsumer(ctx context.Context, brokers []string, handler func(ctx context.Context, event authsync.UserEvent) error) {
+	consume(ctx, brokers, authsync.TopicUserEvents, "chat-service-auth-user-sync-group", "user event",
+		func(e authsync.UserEvent) string { return e.EventID }, handler)
+}
+
+// StartCourseEventConsumer reads lms.course.membership until ctx is
+// cancelled, with the same commit rules as StartUserEventConsumer.
+func StartCourseEventConsumer(ctx context.Context, brokers []string, handler func(ctx context.Context, event coursesync.CourseEvent) error) {
+	consume(ctx, brokers, coursesync.TopicCourseMembership, "chat-service-course-channel-group", "course event",
+		func(e coursesync.CourseEvent) string { return e.EventID }, handler)
+}
+
+// consume reads topic as groupID, decoding each message as T. kind names
+// the events in logs.
+func consume[T any](
+	ctx context.Context,
+	brokers []string,
+	topic, groupID, kind string,
+	eventID func(T) string,
+	handler func(ctx context.Context, event T) error,
+) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  brokers,
+		Topic:    topic,
+		GroupID:  groupID,
+		MinBytes: 1,
+		MaxBytes: 10e6,
+	})
+	defer reader.Close()
+
+	logger.Infof("kafka consumer started for topic %s", topic)
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				logger.Infof("%s consumer stopped", kind)
+				return
+			}
+			logger.Errorf("kafka fetch: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		if !handleEvent(ctx, msg, kind, eventID, handler) {
+			return
+		}
+
+		if err := reader.CommitMessages(ctx, msg); err != nil && ctx.Err() == nil {
+			logger.Errorf("commit %s offset: %v", kind, err)
+		}
+	}
+}
+
+// handleEvent applies one message inside a consumer span that continues
+// the producer's trace. It returns false only when ctx was cancelled.
+func handleEvent[T any](
+	ctx context.Context,
+	msg kafka.Message,
+	kind string,
+	eventID func(T) string,
+	handler func(ctx context.Context, event T) error,
+) bool {
+	started := time.Now()
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	ctx, span := telemetry.Tracer().Start(telemetry.ExtractHeaders(ctx, headers), msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.Int64("messaging.kafka.offset", msg.Offset),
+		))
+	defer span.End()
+
+	result := "processed"
+	defer func() {
+		messagesConsumed.WithLabelValues(msg.Topic, result).Inc()
+		handlerDuration.WithLabelValues(msg.Topic).Observe(time.Since(started).Seconds())
+	}()
+
+	var event T
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		result = "malformed"
+		span.SetStatus(codes.Error, "malformed event")
+		logger.Errorf("decode %s at offset %d: %v", kind, msg.Offset, err)
+		return true
+	}
+	for attempt := 1; ; attempt++ {
+		err := handler(ctx, event)
+		if err == nil {
+			return true
+		}
+		span.RecordError(err)
+		if attempt >= maxHandlerAttempts {
+			result = "skipped"
+			span.SetStatus(codes.Error, "retries exhausted")
+			logger.Errorf("giving up on %s %q after %d attempts: %v", kind, eventID(event), attempt, err)
+			return true
+		}
+		handlerRetries.WithLabelValues(msg.Topic).Inc()
+		logger.Warnf("%s %q failed (attempt %d): %v", kind, eventID(event), attempt, err)
+		select {
+		case <-ctx.Done():
+			result = "cancelled"
+			return false
+		case <-time.After(time.Duration(1<<attempt) * 250 * time.Millisecond):
+		}
+	}
+}
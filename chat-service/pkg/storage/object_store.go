@@ -82,3 +82,19 @@ func (s *ObjectStore) Get(ctx context.Context, key string) (*Object, error) {
 func (s *ObjectStore) Delete(ctx context.Context, key string) error {
 	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
 }
+
+// List calls fn for every object whose key starts with prefix, stopping
+// at the first error fn returns.
+func (s *ObjectStore) List(ctx context.Context, prefix string, fn func(key string, modifiedAt time.Time) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for info := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if info.Err != nil {
+			return fmt.Errorf("list objects: %w", info.Err)
+		}
+		if err := fn(info.Key, info.LastModified); err != nil {
+			return err
+		}
+	}
+	return nil
+}
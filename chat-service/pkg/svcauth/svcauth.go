@@ -0,0 +1,307 @@
+// Package svcauth authenticates internal service-to-service requests.
+//
+// Each calling service has its own secret and a set of scopes naming the
+// internal route groups it may call. A request is signed with HMAC-SHA256
+// over its method, path, query, body hash, timestamp, nonce, calling service
+// and acted-for user; the receiver rejects stale timestamps and nonces it
+// has already seen. Signatures are computed over the path the receiving
+// service sees, so callers must sign the upstream path, not a gateway path.
+package svcauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	HeaderService   = "X-Service-Name"
+	HeaderTimestamp = "X-Service-Timestamp"
+	HeaderNonce     = "X-Service-Nonce"
+	HeaderSignature = "X-Service-Signature"
+	HeaderUserID    = "X-User-Id"
+
+	// Legacy static-secret headers, accepted only while AllowLegacy is set
+	HeaderLegacyAPISecret  = "X-API-Secret"
+	HeaderLegacySyncSecret = "X-Sync-Secret"
+
+	// ScopeActAsUser lets a caller name the user it acts for in X-User-Id.
+	// The receiver then applies that user's own roles, never ADMIN.
+	ScopeActAsUser = "act-as-user"
+
+	// MaxBodySize bounds how much of a request body is read for hashing.
+	// Internal calls carry JSON, never uploads.
+	MaxBodySize = 1 << 20
+)
+
+var (
+	ErrNoCredentials  = errors.New("svcauth: request carries no service credentials")
+	ErrUnknownService = errors.New("svcauth: unknown calling service")
+	ErrBadSignature   = errors.New("svcauth: signature mismatch")
+	ErrStale          = errors.New("svcauth: timestamp outside the allowed window")
+	ErrReplay         = errors.New("svcauth: nonce already used")
+	ErrMalformed      = errors.New("svcauth: malformed service credentials")
+	ErrBodyTooLarge   = errors.New("svcauth: request body too large to verify")
+)
+
+// Client is one calling service
+type Client struct {
+	Name   string
+	Secret string
+	Scopes []string
+}
+
+// Caller is the authenticated identity of a verified request
+type Caller struct {
+	Service string
+	Scopes  []string
+	// UserID is the user the service acts for; 0 unless the caller holds
+	// ScopeActAsUser and named one
+	UserID int64
+	// Legacy reports that the request used a static secret header
+	Legacy bool
+}
+
+func (c *Caller) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// NonceStore remembers nonces for the replay window. SetNX must return
+// false when the key already exists; see RedisNonces.
+type NonceStore interface {
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
+}
+
+type redisNonces struct {
+	rdb *redis.Client
+}
+
+// RedisNonces keeps nonces in Redis so every replica sees them
+func RedisNonces(rdb *redis.Client) NonceStore {
+	return redisNonces{rdb: rdb}
+}
+
+func (n redisNonces) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return n.rdb.SetNX(ctx, key, value, expiration).Result()
+}
+
+// Options tune a Verifier
+type Options struct {
+	// MaxSkew is the allowed clock difference between caller and receiver.
+	// Defaults to 5 minutes; nonces are remembered for twice as long.
+	MaxSkew time.Duration
+	// AllowLegacy accepts the old X-API-Secret/X-Sync-Secret headers during
+	// the migration to signed requests
+	AllowLegacy bool
+}
+
+// Verifier checks signed requests against the configured clients
+type Verifier struct {
+	clients map[string]Client
+	nonces  NonceStore
+	opts    Options
+	now     func() time.Time
+}
+
+func NewVerifier(clients []Client, nonces NonceStore, opts Options) *Verifier {
+	if opts.MaxSkew <= 0 {
+		opts.MaxSkew = 5 * time.Minute
+	}
+	byName := make(map[string]Client, len(clients))
+	for _, c := range clients {
+		if c.Name != "" && c.Secret != "" {
+			byName[c.Name] = c
+		}
+	}
+	return &Verifier{clients: byName, nonces: nonces, opts: opts, now: time.Now}
+}
+
+// Verify authenticates r. It returns ErrNoCredentials when r carries neither
+// a signature nor (with AllowLegacy) a static secret, so callers can fall
+// back to user authentication. The body is read and restored.
+func (v *Verifier) Verify(r *http.Request) (*Caller, error) {
+	if r.Header.Get(HeaderSignature) == "" {
+		return v.verifyLegacy(r)
+	}
+
+	name := r.Header.Get(HeaderService)
+	client, ok := v.clients[name]
+	if !ok {
+		return nil, ErrUnknownService
+	}
+
+	tsHeader := r.Header.Get(HeaderTimestamp)
+	ts, err := strconv.ParseInt(tsHeader, 10, 64)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	skew := v.now().Sub(time.Unix(ts, 0))
+	if skew > v.opts.MaxSkew || skew < -v.opts.MaxSkew {
+		return nil, ErrStale
+	}
+
+	nonce := r.Header.Get(HeaderNonce)
+	if !validNonce(nonce) {
+		return nil, ErrMalformed
+	}
+	// The body is read only for a well-formed signature
+	sig, err := hex.DecodeString(r.Header.Get(HeaderSignature))
+	if err != nil || len(sig) != sha256.Size {
+		return nil, ErrMalformed
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	userHeader := r.Header.Get(HeaderUserID)
+	expected := mac(client.Secret, canonical(r, body, tsHeader, nonce, name, userHeader))
+	if !hmac.Equal(sig, expected) {
+		return nil, ErrBadSignature
+	}
+
+	// Claim the nonce only after the signature checks out, so unsigned
+	// garbage cannot fill the store
+	if v.nonces == nil {
+		return nil, errors.New("svcauth: no nonce store configured")
+	}
+	fresh, err := v.nonces.SetNX(r.Context(), "svcauth:nonce:"+name+":"+nonce, 1, 2*v.opts.MaxSkew)
+	if err != nil {
+		return nil, fmt.Errorf("svcauth: nonce store: %w", err)
+	}
+	if !fresh {
+		return nil, ErrReplay
+	}
+
+	return newCaller(client, userHeader, false)
+}
+
+func (v *Verifier) verifyLegacy(r *http.Request) (*Caller, error) {
+	secret := r.Header.Get(HeaderLegacyAPISecret)
+	if secret == "" {
+		secret = r.Header.Get(HeaderLegacySyncSecret)
+	}
+	if secret == "" || !v.opts.AllowLegacy {
+		return nil, ErrNoCredentials
+	}
+
+	// Compare against every client so timing does not reveal which matched
+	var match *Client
+	for name := range v.clients {
+		c := v.clients[name]
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(c.Secret)) == 1 {
+			match = &c
+		}
+	}
+	if match == nil {
+		return nil, ErrBadSignature
+	}
+	return newCaller(*match, r.Header.Get(HeaderUserID), true)
+}
+
+func newCaller(client Client, userHeader string, legacy bool) (*Caller, error) {
+	caller := &Caller{Service: client.Name, Scopes: client.Scopes, Legacy: legacy}
+	if userHeader != "" && caller.HasScope(ScopeActAsUser) {
+		id, err := strconv.ParseInt(userHeader, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, ErrMalformed
+		}
+		caller.UserID = id
+	}
+	return caller, nil
+}
+
+// Sign adds service credentials to r, which a Verifier holding the same
+// secret for service accepts once. Set X-User-Id before signing; it is
+// covered by the signature.
+func Sign(r *http.Request, service, secret string) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return err
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	r.Header.Set(HeaderService, service)
+	r.Header.Set(HeaderTimestamp, ts)
+	r.Header.Set(HeaderNonce, nonce)
+	r.Header.Set(HeaderSignature, hex.EncodeToString(
+		mac(secret, canonical(r, body, ts, nonce, service, r.Header.Get(HeaderUserID)))))
+	return nil
+}
+
+// canonical is the string both sides sign; the version prefix lets the
+// format change without ambiguity
+func canonical(r *http.Request, body []byte, ts, nonce, service, userID string) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		"v1",
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		hex.EncodeToString(bodyHash[:]),
+		ts,
+		nonce,
+		service,
+		userID,
+	}, "\n")
+}
+
+func mac(secret, msg string) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(msg))
+	return h.Sum(nil)
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	if r.ContentLength > MaxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxBodySize+1))
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > MaxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func validNonce(nonce string) bool {
+	if len(nonce) < 16 || len(nonce) > 128 {
+		return false
+	}
+	for _, ch := range nonce {
+		if !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '-' || ch == '_') {
+			return false
+		}
+	}
+	return true
+}
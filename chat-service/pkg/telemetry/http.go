@@ -0,0 +1,106 @@
+package telemetry
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route template.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"method", "route", "status"})
+
+	httpRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "HTTP requests currently being served.",
+	})
+)
+
+// Middleware records a server span and a latency observation per request,
+// except for requests to skipPaths such as health probes. Routes are
+// labelled by their template (/courses/:id), never the raw path, so label
+// cardinality stays bounded. WebSocket upgrades are skipped since their
+// duration is the connection's lifetime. Register it before other
+// middleware so their time is included.
+func Middleware(skipPaths ...string) gin.HandlerFunc {
+	tracer := Tracer()
+	propagator := otel.GetTextMapPropagator()
+	skip := make(map[string]bool, len(skipPaths))
+	for _, p := range skipPaths {
+		skip[p] = true
+	}
+
+	return func(c *gin.Context) {
+		if skip[c.Request.URL.Path] || c.IsWebsocket() {
+			c.Next()
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+			))
+		c.Request = c.Request.WithContext(ctx)
+
+		start := time.Now()
+		httpRequestsInFlight.Inc()
+		c.Next()
+		httpRequestsInFlight.Dec()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, strconv.Itoa(status))
+		}
+		if userID, ok := c.Get("user_id"); ok {
+			span.SetAttributes(attribute.String("enduser.id", userIDString(userID)))
+		}
+		span.End()
+
+		httpRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(status)).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// NewMetricsServer returns a server for Handler at /metrics on addr. It
+// listens apart from the API so scrapes never pass through the gateway,
+// its auth or its rate limits; addr must only be reachable in-cluster.
+func NewMetricsServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
+
+func userIDString(v interface{}) string {
+	switch id := v.(type) {
+	case int64:
+		return strconv.FormatInt(id, 10)
+	case string:
+		return id
+	default:
+		return ""
+	}
+}
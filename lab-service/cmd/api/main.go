@@ -18,8 +18,11 @@ import (
 	"lab-service/internal/service"
 	"lab-service/pkg/cache"
 	"lab-service/pkg/database"
+	"lab-service/pkg/jwks"
 	"lab-service/pkg/kafka"
 	"lab-service/pkg/logger"
+	"lab-service/pkg/svcauth"
+	"lab-service/pkg/telemetry"
 
 	"github.com/gin-gonic/gin"
 )
@@ -35,12 +38,25 @@ func main() {
 	logger.Init(cfg.App.Env)
 	logger.Info(fmt.Sprintf("Starting %s v%s (%s)", cfg.App.Name, cfg.App.Version, cfg.App.Env))
 
+	// -- Tracing -------------------------------------------------
+	shutdownTracing, err := telemetry.InitTracing(context.Background(), telemetry.TracingConfig{
+		Enabled:        cfg.Telemetry.TracingEnabled,
+		ServiceName:    "lab-service",
+		ServiceVersion: cfg.App.Version,
+		Environment:    cfg.App.Env,
+		SampleRatio:    cfg.Telemetry.SampleRatio,
+	})
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", err)
+	}
+
 	// -- Database ------------------------------------------------
 	db, err := database.NewPostgresDB(cfg.Database)
 	if err != nil {
 		logger.Fatal("Failed to connect to database", err)
 	}
 	defer db.Close()
+	telemetry.RegisterDBStats(db, cfg.Database.Name)
 
 	// -- Run migrations ------------------------------------------
 	runMigrations(db)
@@ -51,8 +67,8 @@ func main() {
 		logger.Warn(fmt.Sprintf("Failed to connect to Redis: %v (continuing without cache)", err))
 	} else {
 		defer redisCache.Close()
+		telemetry.RegisterRedisPool("cache", redisCache.PoolStats)
 	}
-	_ = redisCache // Will be used in Phase 2 for session tokens
 
 	// -- Kafka ---------------------------------------------------
 	kafka.InitProducer()
@@ -79,6 +95,7 @@ func main() {
 	userRepo := repository.NewUserRepository(db)
 
 	// -- Services ------------------------------------------------
+	userSyncService := service.NewUserSyncService(userRepo, repository.NewSyncVersionRepository(db))
 	labService := service.NewLabService(labRepo, enrollmentRepo)
 	experimentService := service.NewExperimentService(experimentRepo, labRepo, enrollmentRepo)
 	submissionService := service.NewSubmissionService(
@@ -101,6 +118,7 @@ func main() {
 	}
 	r := gin.New()
 	r.Use(gin.Recovery())
+	r.Use(telemetry.Middleware("/health", "/api/v1/health"))
 	r.Use(middleware.Logger())
 	r.Use(middleware.CORS(cfg.CORS))
 
@@ -126,13 +144,44 @@ func main() {
 	r.GET("/api/v1/health", healthHandler)
 	r.HEAD("/api/v1/health", healthHandler)
 
-	// -- Sync Routes (service secret) ----------------------------
-	syncSecret := os.Getenv("LMS_SYNC_SECRET")
-	if syncSecret == "" {
-		syncSecret = cfg.JWT.Secret
+	// -- Token verification (JWKS, HMAC during migration) -------
+	var jwksKeys *jwks.KeySet
+	if cfg.JWT.JWKSURL != "" {
+		jwksKeys = jwks.NewKeySet(cfg.JWT.JWKSURL, jwks.Options{RefreshInterval: cfg.JWT.JWKSRefreshInterval})
+		if err := jwksKeys.Refresh(context.Background()); err != nil {
+			logger.Warn(fmt.Sprintf("Initial JWKS fetch failed, retrying on demand: %v", err))
+		}
+		go jwksKeys.Run(context.Background())
+	}
+	hmacSecret := ""
+	if cfg.JWT.HMACEnabled {
+		hmacSecret = cfg.JWT.Secret
+	}
+	hmacUntil, _ := cfg.JWT.HMACDeadline() // validated in config.Load
+	tokenVerifier := jwks.NewVerifier(jwksKeys, hmacSecret, hmacUntil)
+
+	// -- Service auth (signed requests, Redis nonces) -----------
+	serviceClients := make([]svcauth.Client, 0, len(cfg.ServiceAuth.Clients))
+	for _, client := range cfg.ServiceAuth.Clients {
+		serviceClients = append(serviceClients, svcauth.Client{Name: client.Name, Secret: client.Secret, Scopes: client.Scopes})
+	}
+	var nonces svcauth.NonceStore
+	if redisCache != nil {
+		nonces = redisCache
+	} else {
+		logger.Warn("Redis unavailable: signed service requests will be rejected")
 	}
+	serviceAuth := svcauth.NewVerifier(serviceClients, nonces, svcauth.Options{
+		MaxSkew:     cfg.ServiceAuth.MaxSkew,
+		AllowLegacy: cfg.ServiceAuth.AllowLegacy,
+	})
+	if cfg.ServiceAuth.AllowLegacy {
+		logger.Warn("Legacy static service secrets are still accepted (SERVICE_AUTH_ALLOW_LEGACY=true)")
+	}
+
+	// -- Sync Routes (service credentials or JWT) ----------------
 	syncGroup := r.Group("/api/v1/sync")
-	syncGroup.Use(middleware.ServiceOrAuthMiddleware(cfg.JWT.Secret, syncSecret))
+	syncGroup.Use(middleware.ServiceOrAuthMiddleware(tokenVerifier, serviceAuth, middleware.ScopeSync))
 	{
 		syncGroup.POST("/user", syncHandler.SyncUser)
 		syncGroup.POST("/users/bulk", syncHandler.BulkSyncUsers)
@@ -140,7 +189,7 @@ func main() {
 
 	// -- Protected Routes (JWT) ----------------------------------
 	api := r.Group("/api/v1")
-	api.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+	api.Use(middleware.AuthMiddleware(tokenVerifier))
 	{
 		// Labs CRUD
 		api.GET("/labs", labHandler.ListPublishedLabs)
@@ -222,6 +271,12 @@ func main() {
 		return nil
 	})
 
+	// User lifecycle events from the auth service; /sync stays for callers
+	// that still push
+	if cfg.AuthSync.ConsumerEnabled {
+		go kafka.StartUserEventConsumer(ctx, userSyncService.ApplyUserEvent)
+	}
+
 	// -- Start HTTP Server ---------------------------------------
 	srv := &http.Server{
 		Addr:         ":" + cfg.App.Port,
@@ -238,6 +293,15 @@ func main() {
 		}
 	}()
 
+	// Prometheus scrapes its own port, which Traefik does not route to
+	metricsSrv := telemetry.NewMetricsServer(":" + cfg.Telemetry.MetricsPort)
+	go func() {
+		logger.Info(fmt.Sprintf("Serving metrics on :%s", cfg.Telemetry.MetricsPort))
+		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Metrics server failed", err)
+		}
+	}()
+
 	// -- Graceful Shutdown ---------------------------------------
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -252,6 +316,10 @@ func main() {
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		logger.Error("Server forced to shutdown", err)
 	}
+	_ = metricsSrv.Shutdown(shutdownCtx)
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("Failed to flush traces", err)
+	}
 	logger.Info("Lab Service stopped")
 }
 
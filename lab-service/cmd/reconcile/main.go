@@ -0,0 +1,66 @@
+// Command reconcile repairs users that drifted from the auth service, e.g.
+// because lifecycle events were lost. It pages through the auth service's
+// snapshot API and applies every user whose version is not older than the
+// one lab-service holds.
+//
+// Usage:
+//
+//	go run ./cmd/reconcile [-page-size 500] [-prune]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"lab-service/internal/config"
+	"lab-service/internal/repository"
+	"lab-service/internal/service"
+	"lab-service/pkg/authsync"
+	"lab-service/pkg/database"
+	"lab-service/pkg/logger"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	pageSize := flag.Int("page-size", cfg.AuthSync.PageSize, "users fetched per snapshot page")
+	prune := flag.Bool("prune", false, "deactivate synced users missing from the snapshot")
+	flag.Parse()
+
+	logger.Init(cfg.App.Env)
+
+	if cfg.AuthSync.SigningSecret == "" {
+		fmt.Println("AUTH_SYNC_SIGNING_SECRET (or LMS_SYNC_SECRET) is required to call the auth service")
+		os.Exit(1)
+	}
+
+	db, err := database.NewPostgresDB(cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	syncService := service.NewUserSyncService(repository.NewUserRepository(db), repository.NewSyncVersionRepository(db))
+	client := authsync.NewClient(cfg.AuthSync.ServiceURL, cfg.AuthSync.ServiceName, cfg.AuthSync.SigningSecret)
+
+	report, err := syncService.Reconcile(ctx, client, *pageSize, *prune)
+	fmt.Printf("users: %d seen, %d applied, %d pruned, %d failed\n",
+		report.UsersSeen, report.UsersApplied, report.UsersPruned, report.Failed)
+	if err != nil {
+		logger.Fatal("Reconcile aborted", err)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
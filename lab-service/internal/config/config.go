@@ -25,6 +25,48 @@ type Config struct {
 	DatabaseLab DatabaseLabConfig
 	RuntimeSecurity RuntimeSecurityConfig
 	CodingSandbox CodingSandboxConfig
+	ServiceAuth ServiceAuthConfig
+	AuthSync AuthSyncConfig
+	Telemetry TelemetryConfig
+}
+
+// ServiceAuthConfig lists the internal services allowed to call lab-service.
+// Each client signs requests with its own secret and may only reach the
+// route groups named in its scopes. AllowLegacy re-admits the static
+// X-API-Secret/X-Sync-Secret headers for a caller that cannot sign yet;
+// it is off unless set.
+type ServiceAuthConfig struct {
+	Clients     []ServiceClientConfig
+	MaxSkew     time.Duration
+	AllowLegacy bool
+}
+
+// AuthSyncConfig controls how users are kept in step with the auth service:
+// lifecycle events from Kafka, plus a snapshot API that cmd/reconcile pages
+// through to repair drift
+type AuthSyncConfig struct {
+	ConsumerEnabled bool
+	ServiceURL      string
+	ServiceName     string // name lab-service signs snapshot requests as
+	SigningSecret   string
+	PageSize        int
+}
+
+// TelemetryConfig controls trace export and the metrics listener. The
+// collector endpoint is read by the exporter from the standard
+// OTEL_EXPORTER_OTLP_ENDPOINT variable. MetricsPort serves /metrics apart
+// from the API and must stay cluster-internal.
+type TelemetryConfig struct {
+	TracingEnabled bool
+	SampleRatio    float64
+	MetricsPort    string
+}
+
+// ServiceClientConfig is one calling service
+type ServiceClientConfig struct {
+	Name   string
+	Secret string
+	Scopes []string
 }
 
 type AppConfig struct {
@@ -60,9 +102,17 @@ type RedisConfig struct {
 	PoolTimeout  time.Duration
 }
 
+// JWTConfig holds JWT configuration. Tokens are verified against the auth
+// service's JWKS when JWKSURL is set; the shared Secret keeps HMAC tokens
+// working until HMACUntil (RFC 3339, empty = no cutoff) or until HMACEnabled
+// is switched off.
 type JWTConfig struct {
-	Secret          string
-	ExpirationHours int
+	Secret              string
+	ExpirationHours     int
+	JWKSURL             string
+	JWKSRefreshInterval time.Duration
+	HMACEnabled         bool
+	HMACUntil           string
 }
 
 type CORSConfig struct {
@@ -220,6 +270,10 @@ func Load() (*Config, error) {
 		JWT: JWTConfig{
 			Secret:          getEnv("JWT_SECRET", "very_secret_key_change_me_please"),
 			ExpirationHours: getEnvAsInt("JWT_EXPIRATION_HOURS", 1),
+			JWKSURL:             getEnv("JWT_JWKS_URL", ""),
+			JWKSRefreshInterval: getEnvAsDuration("JWT_JWKS_REFRESH_INTERVAL", 15*time.Minute),
+			HMACEnabled:         getEnvAsBool("JWT_HMAC_ENABLED", true),
+			HMACUntil:           getEnv("JWT_HMAC_UNTIL", ""),
 		},
 		CORS: CORSConfig{
 			AllowedOrigins: getEnvAsSlice("CORS_ALLOWED_ORIGINS", []string{
@@ -311,6 +365,23 @@ func Load() (*Config, error) {
 			PollInterval:     getEnvAsDuration("LAB_CODING_SANDBOX_POLL_INTERVAL", 250*time.Millisecond),
 			JobTTLSeconds:    getEnvAsInt("LAB_CODING_SANDBOX_JOB_TTL_SECONDS", 60),
 		},
+		ServiceAuth: ServiceAuthConfig{
+			Clients:     loadServiceClients(),
+			MaxSkew:     getEnvAsDuration("SERVICE_AUTH_MAX_SKEW", 5*time.Minute),
+			AllowLegacy: getEnvAsBool("SERVICE_AUTH_ALLOW_LEGACY", false),
+		},
+		AuthSync: AuthSyncConfig{
+			ConsumerEnabled: getEnvAsBool("AUTH_SYNC_CONSUMER_ENABLED", true),
+			ServiceURL:      getEnv("AUTH_SERVICE_URL", "http://localhost:8080"),
+			ServiceName:     getEnv("AUTH_SYNC_SERVICE_NAME", "lab-service"),
+			SigningSecret:   getEnv("AUTH_SYNC_SIGNING_SECRET", getEnv("LMS_SYNC_SECRET", "")),
+			PageSize:        getEnvAsInt("AUTH_SYNC_PAGE_SIZE", 500),
+		},
+		Telemetry: TelemetryConfig{
+			TracingEnabled: getEnvAsBool("OTEL_TRACING_ENABLED", false),
+			SampleRatio:    getEnvAsFloat("OTEL_TRACES_SAMPLE_RATIO", 0.1),
+			MetricsPort:    getEnv("METRICS_PORT", "9464"),
+		},
 	}
 	if cfg.App.Env == "production" {
 		cfg.RuntimeSecurity.AllowUnsafeLocalExecution = false
@@ -330,17 +401,70 @@ func (c *Config) Validate() error {
 	if c.Database.Name == "" {
 		return fmt.Errorf("database name is required")
 	}
-	if c.JWT.Secret == "" || c.JWT.Secret == "very_secret_key_change_me_please" {
-		if c.App.Env == "production" {
-			return fmt.Errorf("JWT secret must be set in production")
+	if c.JWT.HMACEnabled {
+		if c.JWT.Secret == "" || c.JWT.Secret == "very_secret_key_change_me_please" {
+			if c.App.Env == "production" {
+				return fmt.Errorf("JWT secret must be set in production")
+			}
+		}
+		if len(c.JWT.Secret) < 32 {
+			return fmt.Errorf("JWT secret must be at least 32 characters")
+		}
+	} else if c.JWT.JWKSURL == "" {
+		return fmt.Errorf("JWT_JWKS_URL is required when JWT_HMAC_ENABLED=false")
+	}
+	if _, err := c.JWT.HMACDeadline(); err != nil {
+		return err
+	}
+	for _, client := range c.ServiceAuth.Clients {
+		if client.Secret == "" {
+			return fmt.Errorf("service client %q has no secret", client.Name)
 		}
 	}
-	if len(c.JWT.Secret) < 32 {
-		return fmt.Errorf("JWT secret must be at least 32 characters")
+	if c.Telemetry.SampleRatio < 0 || c.Telemetry.SampleRatio > 1 {
+		return fmt.Errorf("OTEL_TRACES_SAMPLE_RATIO must be between 0 and 1")
 	}
 	return nil
 }
 
+// HMACDeadline parses HMACUntil; the zero time means no cutoff
+func (j JWTConfig) HMACDeadline() (time.Time, error) {
+	if j.HMACUntil == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, j.HMACUntil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("JWT_HMAC_UNTIL must be an RFC 3339 timestamp: %w", err)
+	}
+	return t, nil
+}
+
+// loadServiceClients reads SERVICE_AUTH_CLIENTS (comma-separated names) and
+// SERVICE_AUTH_<NAME>_SECRET / SERVICE_AUTH_<NAME>_SCOPES for each. Without
+// it, auth-service keeps signing /sync calls with LMS_SYNC_SECRET.
+func loadServiceClients() []ServiceClientConfig {
+	names := getEnvAsSlice("SERVICE_AUTH_CLIENTS", nil)
+	if len(names) == 0 {
+		secret := getEnv("LMS_SYNC_SECRET", getEnv("JWT_SECRET", ""))
+		if secret == "" {
+			return nil
+		}
+		return []ServiceClientConfig{{Name: "auth-service", Secret: secret, Scopes: []string{"sync"}}}
+	}
+
+	clients := make([]ServiceClientConfig, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		prefix := "SERVICE_AUTH_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		clients = append(clients, ServiceClientConfig{
+			Name:   name,
+			Secret: getEnv(prefix+"_SECRET", ""),
+			Scopes: getEnvAsSlice(prefix+"_SCOPES", nil),
+		})
+	}
+	return clients
+}
+
 func (c *Config) GetDSN() string {
 	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
@@ -365,6 +489,13 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if v, err := strconv.ParseFloat(getEnv(key, ""), 64); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if v, err := strconv.ParseBool(getEnv(key, "")); err == nil {
 		return v
@@ -1,12 +1,15 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"lab-service/internal/dto"
+	"lab-service/pkg/jwks"
 	"lab-service/pkg/logger"
+	"lab-service/pkg/svcauth"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -20,8 +23,10 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-// AuthMiddleware validates JWT token and sets user info in context.
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// AuthMiddleware validates JWT token and sets user info in context. Tokens
+// are verified against the auth service's JWKS, or the shared HMAC secret
+// during the migration window.
+func AuthMiddleware(verifier *jwks.Verifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var tokenString string
 		authHeader := c.GetHeader("Authorization")
@@ -50,12 +55,7 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(jwtSecret), nil
-		})
+		token, err := verifier.Parse(c.Request.Context(), tokenString, &Claims{})
 
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("unauthorized", "Invalid or expired token"))
@@ -104,25 +104,52 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 	}
 }
 
-// ServiceOrAuthMiddleware allows access via JWT or shared service secret.
-func ServiceOrAuthMiddleware(jwtSecret string, serviceSecret string) gin.HandlerFunc {
+// ScopeSync is the scope auth-service needs for the /sync/* endpoints
+const ScopeSync = "sync"
+
+// ServiceOrAuthMiddleware allows access via JWT or a signed request from an
+// internal service holding scope.
+func ServiceOrAuthMiddleware(verifier *jwks.Verifier, services *svcauth.Verifier, scope string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		apiSecret := c.GetHeader("X-API-Secret")
-		if apiSecret == "" {
-			apiSecret = c.GetHeader("X-Sync-Secret")
+		caller, err := services.Verify(c.Request)
+		if errors.Is(err, svcauth.ErrNoCredentials) {
+			// Fallback to JWT
+			AuthMiddleware(verifier)(c)
+			return
 		}
-
-		if apiSecret != "" && apiSecret == serviceSecret {
-			c.Set("user_id", int64(0))
-			c.Set("user_email", "system@bdc.internal")
-			c.Set("user_roles", []string{"ADMIN", "TEACHER"})
-			c.Set("user_role", "ADMIN")
-			c.Next()
+		if err != nil {
+			switch {
+			case errors.Is(err, svcauth.ErrReplay):
+				c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("replayed_request", "Request nonce has already been used"))
+			case errors.Is(err, svcauth.ErrBodyTooLarge):
+				c.JSON(http.StatusRequestEntityTooLarge, dto.NewErrorResponse("payload_too_large", "Request body too large"))
+			case errors.Is(err, svcauth.ErrUnknownService), errors.Is(err, svcauth.ErrBadSignature),
+				errors.Is(err, svcauth.ErrStale), errors.Is(err, svcauth.ErrMalformed):
+				logger.Warn(fmt.Sprintf("Rejected service request to %s: %v", c.Request.URL.Path, err))
+				c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("unauthorized", "Invalid service credentials"))
+			default:
+				logger.Error("Failed to verify service request", err)
+				c.JSON(http.StatusServiceUnavailable, dto.NewErrorResponse("service_unavailable", "Unable to verify service credentials"))
+			}
+			c.Abort()
+			return
+		}
+		if !caller.HasScope(scope) {
+			logger.Warn(fmt.Sprintf("Service %s lacks scope %q for %s", caller.Service, scope, c.Request.URL.Path))
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", "Service is not allowed to call this endpoint"))
+			c.Abort()
 			return
 		}
+		if caller.Legacy {
+			logger.Warn(fmt.Sprintf("Service %s used a legacy static secret for %s", caller.Service, c.Request.URL.Path))
+		}
 
-		// Fallback to JWT
-		AuthMiddleware(jwtSecret)(c)
+		c.Set("service_name", caller.Service)
+		c.Set("user_id", int64(0))
+		c.Set("user_email", "system@bdc.internal")
+		c.Set("user_roles", []string{"ADMIN", "TEACHER"})
+		c.Set("user_role", "ADMIN")
+		c.Next()
 	}
 }
 
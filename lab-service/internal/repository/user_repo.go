@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type UserRepository struct{ db *sql.DB }
@@ -30,7 +32,7 @@ func (r *UserRepository) SyncUser(ctx context.Context, req *UserSyncRequest) err
 			roles = EXCLUDED.roles,
 			is_active = EXCLUDED.is_active,
 			synced_at = EXCLUDED.synced_at`,
-		req.ID, req.Email, req.FullName, req.Roles, req.IsActive, time.Now())
+		req.ID, req.Email, req.FullName, pq.Array(req.Roles), req.IsActive, time.Now())
 	return err
 }
 
@@ -42,3 +44,12 @@ func (r *UserRepository) BulkSyncUsers(ctx context.Context, users []UserSyncRequ
 	}
 	return nil
 }
+
+// DeactivateUser marks a user deleted in the auth service as inactive. The
+// row is kept because labs and submissions reference it.
+func (r *UserRepository) DeactivateUser(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE users SET is_active = FALSE, synced_at = $2 WHERE id = $1`,
+		id, time.Now())
+	return err
+}
@@ -98,8 +98,9 @@ func NewRegistry() *Registry {
 	return &Registry{adapters: make(map[RuntimeType]RuntimeAdapter)}
 }
 
+// Register adds adapter, wrapped so its executions are timed and traced.
 func (r *Registry) Register(adapter RuntimeAdapter) {
-	r.adapters[adapter.Type()] = adapter
+	r.adapters[adapter.Type()] = instrumentedAdapter{adapter}
 }
 
 func (r *Registry) Get(t RuntimeType) (RuntimeAdapter, error) {
@@ -0,0 +1,52 @@
+package runtime
+
+import (
+	"context"
+	"time"
+
+	"lab-service/pkg/telemetry"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var executionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "lab_runner_execution_duration_seconds",
+	Help:    "Runtime adapter Execute latency, by runtime and result status.",
+	Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+}, []string{"runtime", "status"})
+
+// instrumentedAdapter records a span and a duration observation around
+// every Execute call of the adapter it wraps.
+type instrumentedAdapter struct {
+	RuntimeAdapter
+}
+
+func (a instrumentedAdapter) Execute(ctx context.Context, req ExecutionRequest) (*ExecutionResult, error) {
+	runtimeType := string(a.Type())
+	ctx, span := telemetry.Tracer().Start(ctx, "runtime.Execute "+runtimeType,
+		trace.WithAttributes(
+			attribute.String("lab.runtime", runtimeType),
+			attribute.Int64("lab.id", req.LabID),
+			attribute.Int64("lab.submission_id", req.SubmissionID),
+			attribute.String("lab.language", req.Language),
+		))
+	defer span.End()
+
+	started := time.Now()
+	result, err := a.RuntimeAdapter.Execute(ctx, req)
+
+	status := "ERROR"
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if result != nil {
+		status = result.Status
+		span.SetAttributes(attribute.String("lab.result_status", status))
+	}
+	executionDuration.WithLabelValues(runtimeType, status).Observe(time.Since(started).Seconds())
+	return result, err
+}
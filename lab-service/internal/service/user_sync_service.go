@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"lab-service/internal/repository"
+	"lab-service/pkg/authsync"
+	"lab-service/pkg/logger"
+)
+
+// UserSyncService applies user lifecycle changes from the auth service.
+// Every change is checked against the version ledger so an older version
+// never overwrites a newer one.
+type UserSyncService struct {
+	userRepo    *repository.UserRepository
+	versionRepo *repository.SyncVersionRepository
+}
+
+func NewUserSyncService(userRepo *repository.UserRepository, versionRepo *repository.SyncVersionRepository) *UserSyncService {
+	return &UserSyncService{userRepo: userRepo, versionRepo: versionRepo}
+}
+
+// ApplyUserEvent applies an event from auth.user.events. Malformed events
+// are logged and dropped; only storage errors are returned for retry.
+func (s *UserSyncService) ApplyUserEvent(ctx context.Context, e authsync.UserEvent) error {
+	if e.UserID <= 0 || e.Version <= 0 {
+		logger.Warn(fmt.Sprintf("Dropping user event %q without user_id or version", e.EventID))
+		return nil
+	}
+
+	switch e.Type {
+	case authsync.UserUpserted:
+		if e.User == nil {
+			logger.Warn(fmt.Sprintf("Dropping user event %q: %s without user", e.EventID, e.Type))
+			return nil
+		}
+		user := *e.User
+		user.UserID = e.UserID
+		_, err := s.applyUser(ctx, user, e.Version)
+		return err
+	case authsync.UserDeleted:
+		_, err := s.deleteUser(ctx, e.UserID, e.Version)
+		return err
+	default:
+		logger.Warn(fmt.Sprintf("Ignoring user event %q of unknown type %s", e.EventID, e.Type))
+		return nil
+	}
+}
+
+func (s *UserSyncService) claim(ctx context.Context, userID, version int64, deleted bool) (bool, error) {
+	ok, err := s.versionRepo.Claim(ctx, repository.SyncEntityUser, strconv.FormatInt(userID, 10), version, deleted)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim user %d version %d: %w", userID, version, err)
+	}
+	if !ok {
+		logger.Info(fmt.Sprintf("Skipping stale user %d version %d", userID, version))
+	}
+	return ok, nil
+}
+
+func (s *UserSyncService) applyUser(ctx context.Context, u authsync.UserSnapshot, version int64) (bool, error) {
+	ok, err := s.claim(ctx, u.UserID, version, false)
+	if err != nil || !ok {
+		return false, err
+	}
+	err = s.userRepo.SyncUser(ctx, &repository.UserSyncRequest{
+		ID:       u.UserID,
+		Email:    u.Email,
+		FullName: u.FullName,
+		Roles:    u.Roles,
+		IsActive: u.IsActive,
+	})
+	return err == nil, err
+}
+
+func (s *UserSyncService) deleteUser(ctx context.Context, userID, version int64) (bool, error) {
+	ok, err := s.claim(ctx, userID, version, true)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, s.userRepo.DeactivateUser(ctx, userID)
+}
+
+// ReconcileReport summarises a reconciliation run
+type ReconcileReport struct {
+	UsersSeen    int
+	UsersApplied int
+	UsersPruned  int
+	Failed       int
+}
+
+// Reconcile pages through the auth service's user snapshot and applies
+// every user whose version is not older than the one held here. With
+// prune, users recorded by an earlier sync but missing from the snapshot
+// are deactivated; a failed page fetch aborts the run before pruning.
+func (s *UserSyncService) Reconcile(ctx context.Context, client *authsync.Client, pageSize int, prune bool) (*ReconcileReport, error) {
+	started := time.Now()
+	report := &ReconcileReport{}
+	seen := make(map[string]bool)
+
+	err := client.EachUser(ctx, pageSize, func(u authsync.UserSnapshot) error {
+		report.UsersSeen++
+		seen[strconv.FormatInt(u.UserID, 10)] = true
+		applied, err := s.applyUser(ctx, u, u.Version)
+		if err != nil {
+			report.Failed++
+			logger.Error(fmt.Sprintf("Reconcile: failed to apply user %d", u.UserID), err)
+		} else if applied {
+			report.UsersApplied++
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return report, fmt.Errorf("reconcile users: %w", err)
+	}
+
+	if prune {
+		// Users recorded after the run started may be missing from pages
+		// fetched earlier, so only users untouched since then are pruned
+		ids, err := s.versionRepo.ListStale(ctx, repository.SyncEntityUser, time.Since(started))
+		if err != nil {
+			return report, fmt.Errorf("list synced users: %w", err)
+		}
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			userID, err := strconv.ParseInt(id, 10, 64)
+			if err == nil {
+				err = s.userRepo.DeactivateUser(ctx, userID)
+			}
+			if err == nil {
+				err = s.versionRepo.MarkDeleted(ctx, repository.SyncEntityUser, id)
+			}
+			if err != nil {
+				report.Failed++
+				logger.Error("Reconcile: failed to prune user "+id, err)
+				continue
+			}
+			report.UsersPruned++
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Reconcile finished: %+v", *report))
+	return report, nil
+}
@@ -0,0 +1,20 @@
+-- V005__sync_versions.sql
+-- Version ledger for user sync from the auth service.
+--
+-- Lifecycle events carry a version that grows with every change. The
+-- highest version applied per user is recorded here, so a redelivered or
+-- out-of-order event older than what lab-service already holds is ignored.
+-- Deletes leave a tombstone row that blocks stale upserts from reactivating
+-- the user.
+
+CREATE TABLE IF NOT EXISTS sync_versions (
+    entity_type VARCHAR(20)  NOT NULL,
+    entity_id   VARCHAR(100) NOT NULL,
+    version     BIGINT       NOT NULL,
+    deleted     BOOLEAN      NOT NULL DEFAULT FALSE,
+    updated_at  TIMESTAMP    NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (entity_type, entity_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_versions_updated_at
+    ON sync_versions(entity_type, updated_at);
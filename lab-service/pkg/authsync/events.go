@@ -0,0 +1,88 @@
+// Package authsync carries user and organization state from
+// auth-and-management-service. Lifecycle events arrive on Kafka; a paged
+// snapshot API lets a reconciliation run repair anything the events missed.
+//
+// Every user, organization and membership carries a version that increases
+// with each change in the auth service. Receivers record the highest version
+// applied per entity and ignore anything older, so redelivered, reordered
+// or replayed events cannot roll state back.
+package authsync
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	TopicUserEvents = "auth.user.events"
+	TopicOrgEvents  = "auth.org.events"
+)
+
+// Event types
+const (
+	UserUpserted   = "USER_UPSERTED"
+	UserDeleted    = "USER_DELETED"
+	OrgUpserted    = "ORG_UPSERTED"
+	OrgDeleted     = "ORG_DELETED"
+	MemberUpserted = "MEMBER_UPSERTED"
+	MemberRemoved  = "MEMBER_REMOVED"
+)
+
+// UserSnapshot is the full state of one user
+type UserSnapshot struct {
+	UserID         int64    `json:"user_id"`
+	Email          string   `json:"email"`
+	FullName       string   `json:"full_name"`
+	ProfilePicture string   `json:"profile_picture"`
+	Roles          []string `json:"roles"`
+	Org            string   `json:"org"`
+	IsActive       bool     `json:"is_active"`
+	Version        int64    `json:"version"`
+}
+
+// UserEvent is published to auth.user.events keyed by user id. User is
+// set for USER_UPSERTED.
+type UserEvent struct {
+	EventID    string        `json:"event_id"`
+	Type       string        `json:"type"`
+	UserID     int64         `json:"user_id"`
+	Version    int64         `json:"version"`
+	OccurredAt time.Time     `json:"occurred_at"`
+	User       *UserSnapshot `json:"user,omitempty"`
+}
+
+// MemberSnapshot is one user's membership of an organization
+type MemberSnapshot struct {
+	OrgID   int64  `json:"org_id"`
+	UserID  int64  `json:"user_id"`
+	OrgRole string `json:"org_role"`
+	Version int64  `json:"version"`
+}
+
+// OrgSnapshot is the full state of one organization. Members is filled by
+// the snapshot API, not by events.
+type OrgSnapshot struct {
+	ID          int64            `json:"id"`
+	Name        string           `json:"name"`
+	Slug        string           `json:"slug"`
+	Description string           `json:"description"`
+	LogoURL     string           `json:"logo_url"`
+	IsActive    bool             `json:"is_active"`
+	Settings    json.RawMessage  `json:"settings"`
+	Version     int64            `json:"version"`
+	Members     []MemberSnapshot `json:"members,omitempty"`
+}
+
+// OrgEvent is published to auth.org.events keyed by org id, so membership
+// changes stay ordered with the organization they belong to. Organization
+// is set for ORG_UPSERTED, Member for MEMBER_UPSERTED.
+type OrgEvent struct {
+	EventID      string          `json:"event_id"`
+	Type         string          `json:"type"`
+	OrgID        int64           `json:"org_id"`
+	UserID       int64           `json:"user_id,omitempty"`
+	Version      int64           `json:"version"`
+	OccurredAt   time.Time       `json:"occurred_at"`
+	Organization *OrgSnapshot    `json:"organization,omitempty"`
+	Member       *MemberSnapshot `json:"member,omitempty"`
+}
@@ -44,6 +44,11 @@ func (c *RedisCache) Close() error {
 	return c.client.Close()
 }
 
+// PoolStats exposes the connection pool counters for metrics.
+func (c *RedisCache) PoolStats() *redis.PoolStats {
+	return c.client.PoolStats()
+}
+
 func (c *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
 	return c.client.Set(ctx, key, value, ttl).Err()
 }
@@ -60,3 +65,7 @@ func (c *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
 	n, err := c.client.Exists(ctx, key).Result()
 	return n > 0, err
 }
+
+func (c *RedisCache) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, key, value, ttl).Result()
+}
@@ -0,0 +1,175 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksServer serves whatever keys are currently installed and counts fetches
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    []map[string]string
+	fetches atomic.Int32
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func rsaJWK(kid string, pub *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": b64(pub.N.Bytes()), "e": b64(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, pub *ecdsa.PublicKey) map[string]string {
+	x, y := make([]byte, 32), make([]byte, 32)
+	pub.X.FillBytes(x)
+	pub.Y.FillBytes(y)
+	return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(x), "y": b64(y)}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, jwt.MapClaims{
+		"user_id": 7,
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func parse(v *Verifier, token string) error {
+	_, err := v.Parse(context.Background(), token, jwt.MapClaims{})
+	return err
+}
+
+func TestVerifierSelectsKeyByKid(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	srv := newJWKSServer(t)
+	srv.setKeys(rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey))
+
+	v := NewVerifier(NewKeySet(srv.URL, Options{}), "", time.Time{})
+	if err := parse(v, sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey)); err != nil {
+		t.Errorf("RS256: %v", err)
+	}
+	if err := parse(v, sign(t, jwt.SigningMethodES256, "ec-1", ecKey)); err != nil {
+		t.Errorf("ES256: %v", err)
+	}
+	if err := parse(v, sign(t, jwt.SigningMethodES256, "rsa-1", ecKey)); err == nil {
+		t.Error("accepted an ES256 token pointing at an RSA key")
+	}
+	if err := parse(v, sign(t, jwt.SigningMethodRS256, "", rsaKey)); err == nil {
+		t.Error("accepted a token without kid although the set holds two keys")
+	}
+	if n := srv.fetches.Load(); n != 1 {
+		t.Errorf("fetched JWKS %d times; want 1", n)
+	}
+}
+
+func TestVerifierPicksUpRotatedKey(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	srv := newJWKSServer(t)
+	srv.setKeys(rsaJWK("old", &oldKey.PublicKey))
+
+	keys := NewKeySet(srv.URL, Options{MinRefetchInterval: time.Nanosecond})
+	v := NewVerifier(keys, "", time.Time{})
+	if err := parse(v, sign(t, jwt.SigningMethodRS256, "old", oldKey)); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.setKeys(rsaJWK("new", &newKey.PublicKey))
+	if err := parse(v, sign(t, jwt.SigningMethodRS256, "new", newKey)); err != nil {
+		t.Errorf("token signed with rotated key: %v", err)
+	}
+	if err := parse(v, sign(t, jwt.SigningMethodRS256, "old", oldKey)); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("token signed with retired key: err = %v; want ErrKeyNotFound", err)
+	}
+}
+
+func TestUnknownKidRefetchIsRateLimited(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	srv := newJWKSServer(t)
+	srv.setKeys(rsaJWK("k1", &key.PublicKey))
+
+	v := NewVerifier(NewKeySet(srv.URL, Options{MinRefetchInterval: time.Hour}), "", time.Time{})
+	if err := parse(v, sign(t, jwt.SigningMethodRS256, "k1", key)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		parse(v, sign(t, jwt.SigningMethodRS256, "unknown", key))
+	}
+	if n := srv.fetches.Load(); n != 1 {
+		t.Errorf("fetched JWKS %d times; want unknown kids throttled to 1 fetch", n)
+	}
+}
+
+func TestVerifierHMACMigrationWindow(t *testing.T) {
+	const secret = "legacy-secret-with-at-least-32-characters"
+	token := sign(t, jwt.SigningMethodHS256, "", []byte(secret))
+
+	open := NewVerifier(nil, secret, time.Now().Add(time.Hour))
+	if err := parse(open, token); err != nil {
+		t.Errorf("HMAC inside the migration window: %v", err)
+	}
+
+	closed := NewVerifier(nil, secret, time.Now().Add(-time.Hour))
+	if err := parse(closed, token); err == nil {
+		t.Error("accepted an HMAC token after the migration window closed")
+	}
+}
+
+func TestVerifierRejectsPublicKeyAsHMACSecret(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	srv := newJWKSServer(t)
+	jwk := rsaJWK("k1", &key.PublicKey)
+	srv.setKeys(jwk)
+
+	// Classic alg confusion: HS256 signed with the public modulus
+	forged := sign(t, jwt.SigningMethodHS256, "k1", []byte(jwk["n"]))
+	v := NewVerifier(NewKeySet(srv.URL, Options{}), "", time.Time{})
+	if err := parse(v, forged); err == nil {
+		t.Error("accepted an HS256 token with HMAC disabled")
+	}
+}
@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"lab-service/pkg/authsync"
+	"lab-service/pkg/logger"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// maxHandlerAttempts bounds retries of a failing user event before it is
+// skipped; the reconcile command repairs whatever was skipped
+const maxHandlerAttempts = 8
+
+// StartUserEventConsumer listens for user lifecycle events from the auth
+// service. Unlike the job status consumer, offsets are committed only after
+// the handler succeeds or gives up, so a crash or database outage does not
+// silently drop a user change.
+func StartUserEventConsumer(ctx context.Context, handler func(ctx context.Context, event authsync.UserEvent) error) {
+	brokers := os.Getenv("KAFKA_BROKERS")
+	if brokers == "" {
+		brokers = "localhost:9092"
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  []string{brokers},
+		Topic:    authsync.TopicUserEvents,
+		GroupID:  "lab-service-auth-user-sync-group",
+		MinBytes: 1,
+		MaxBytes: 10e6,
+	})
+	defer reader.Close()
+
+	logger.Info(fmt.Sprintf("Kafka consumer started for topic: %s", authsync.TopicUserEvents))
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				logger.Info("User event consumer shutting down")
+				return
+			}
+			logger.Error("Failed to read Kafka message", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		if !handleUserEvent(ctx, msg, handler) {
+			return
+		}
+
+		if err := reader.CommitMessages(ctx, msg); err != nil && ctx.Err() == nil {
+			logger.Error("Failed to commit user event offset", err)
+		}
+	}
+}
+
+// handleUserEvent applies one message with bounded retries. It returns
+// false only when ctx was cancelled before the event was settled.
+func handleUserEvent(ctx context.Context, msg kafka.Message, handler func(ctx context.Context, event authsync.UserEvent) error) bool {
+	ctx, span := startConsumeSpan(ctx, msg)
+	defer span.End()
+
+	var event authsync.UserEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		messagesConsumed.WithLabelValues(msg.Topic, "malformed").Inc()
+		span.SetStatus(codes.Error, "malformed event")
+		logger.Error("Failed to unmarshal user event", err)
+		return true
+	}
+	for attempt := 1; ; attempt++ {
+		err := handler(ctx, event)
+		if err == nil {
+			messagesConsumed.WithLabelValues(msg.Topic, "processed").Inc()
+			return true
+		}
+		span.RecordError(err)
+		if attempt >= maxHandlerAttempts {
+			messagesConsumed.WithLabelValues(msg.Topic, "skipped").Inc()
+			span.SetStatus(codes.Error, "retries exhausted")
+			logger.Error(fmt.Sprintf("Giving up on user event %q after %d attempts", event.EventID, attempt), err)
+			return true
+		}
+		handlerRetries.WithLabelValues(msg.Topic).Inc()
+		logger.Warn(fmt.Sprintf("User event %q failed (attempt %d): %v", event.EventID, attempt, err))
+		select {
+		case <-ctx.Done():
+			messagesConsumed.WithLabelValues(msg.Topic, "cancelled").Inc()
+			return false
+		case <-time.After(time.Duration(1<<attempt) * 250 * time.Millisecond):
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"lab-service/pkg/logger"
 
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // StartJobStatusConsumer listens for job status updates from lab-worker.
@@ -44,15 +45,29 @@ func StartJobStatusConsumer(ctx context.Context, handler func(ctx context.Contex
 				continue
 			}
 
-			var event JobStatusEvent
-			if err := json.Unmarshal(msg.Value, &event); err != nil {
-				logger.Error("Failed to unmarshal job status event", err)
-				continue
-			}
-
-			if err := handler(ctx, event); err != nil {
-				logger.Error("Failed to handle job status event", err)
-			}
+			handleJobStatus(ctx, msg, handler)
 		}
 	}
 }
+
+func handleJobStatus(ctx context.Context, msg kafka.Message, handler func(ctx context.Context, event JobStatusEvent) error) {
+	ctx, span := startConsumeSpan(ctx, msg)
+	defer span.End()
+
+	var event JobStatusEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		messagesConsumed.WithLabelValues(msg.Topic, "malformed").Inc()
+		span.SetStatus(codes.Error, "malformed event")
+		logger.Error("Failed to unmarshal job status event", err)
+		return
+	}
+
+	if err := handler(ctx, event); err != nil {
+		messagesConsumed.WithLabelValues(msg.Topic, "failed").Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		logger.Error("Failed to handle job status event", err)
+		return
+	}
+	messagesConsumed.WithLabelValues(msg.Topic, "processed").Inc()
+}
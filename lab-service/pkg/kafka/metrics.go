@@ -0,0 +1,54 @@
+package kafka
+
+import (
+	"context"
+
+	"lab-service/pkg/telemetry"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	messagesPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_messages_published_total",
+		Help: "Messages written to Kafka, by topic and result (ok, error).",
+	}, []string{"topic", "result"})
+
+	messagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_messages_consumed_total",
+		Help: "Messages consumed, by result (processed, malformed, failed, skipped, cancelled).",
+	}, []string{"topic", "result"})
+
+	handlerRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_handler_retries_total",
+		Help: "Handler attempts retried after a failure.",
+	}, []string{"topic"})
+)
+
+func resultLabel(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// startConsumeSpan starts a consumer span for msg that continues the trace
+// carried in its headers, if any.
+func startConsumeSpan(ctx context.Context, msg kafka.Message) (context.Context, trace.Span) {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	return telemetry.Tracer().Start(telemetry.ExtractHeaders(ctx, headers), msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.Int("messaging.kafka.destination.partition", msg.Partition),
+			attribute.Int64("messaging.kafka.offset", msg.Offset),
+		))
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"lab-service/pkg/logger"
+	"lab-service/pkg/telemetry"
 
 	"github.com/segmentio/kafka-go"
 )
@@ -45,8 +46,15 @@ func PublishEvent(ctx context.Context, topic string, key []byte, payload interfa
 		Key:   key,
 		Value: data,
 	}
+	headers := map[string]string{}
+	telemetry.InjectHeaders(ctx, headers)
+	for k, v := range headers {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
 
-	if err := Writer.WriteMessages(ctx, msg); err != nil {
+	err = Writer.WriteMessages(ctx, msg)
+	messagesPublished.WithLabelValues(topic, resultLabel(err)).Inc()
+	if err != nil {
 		return fmt.Errorf("failed to publish to kafka topic %s: %w", topic, err)
 	}
 
@@ -0,0 +1,326 @@
+package svcauth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lab-service/internal/config"
+	"lab-service/pkg/cache"
+	"lab-service/pkg/logger"
+)
+
+type memoryNonces struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (m *memoryNonces) SetNX(_ context.Context, key string, _ interface{}, _ time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.seen[key] {
+		return false, nil
+	}
+	m.seen[key] = true
+	return true, nil
+}
+
+func testVerifier(allowLegacy bool) *Verifier {
+	return NewVerifier([]Client{
+		{Name: "ai-service", Secret: "ai-secret", Scopes: []string{"internal", ScopeActAsUser}},
+		{Name: "auth-service", Secret: "sync-secret", Scopes: []string{"sync"}},
+	}, &memoryNonces{seen: map[string]bool{}}, Options{AllowLegacy: allowLegacy})
+}
+
+func signed(t *testing.T, method, target, body, service, secret string) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if err := Sign(r, service, secret); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestVerifyAcceptsSignedRequestOnce(t *testing.T) {
+	v := testVerifier(false)
+	req := signed(t, "POST", "/api/v1/internal/micro-lessons/status?x=1", `{"status":"done"}`, "ai-service", "ai-secret")
+
+	caller, err := v.Verify(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caller.Service != "ai-service" || !caller.HasScope("internal") || caller.Legacy {
+		t.Errorf("caller = %+v", caller)
+	}
+	if b, _ := io.ReadAll(req.Body); string(b) != `{"status":"done"}` {
+		t.Errorf("body not restored: %q", b)
+	}
+
+	req.Body = io.NopCloser(strings.NewReader(`{"status":"done"}`))
+	if _, err := v.Verify(req); !errors.Is(err, ErrReplay) {
+		t.Errorf("replayed request: err = %v; want ErrReplay", err)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	v := testVerifier(false)
+
+	req := signed(t, "POST", "/api/v1/internal/x", `{"a":1}`, "ai-service", "ai-secret")
+	req.Body = io.NopCloser(strings.NewReader(`{"a":2}`))
+	if _, err := v.Verify(req); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("changed body: err = %v; want ErrBadSignature", err)
+	}
+
+	req = signed(t, "GET", "/api/v1/courses/my", "", "ai-service", "ai-secret")
+	req.Header.Set(HeaderUserID, "1")
+	if _, err := v.Verify(req); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("injected X-User-Id: err = %v; want ErrBadSignature", err)
+	}
+
+	req = signed(t, "GET", "/api/v1/sync/user", "", "auth-service", "ai-secret")
+	if _, err := v.Verify(req); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("signed with another service's secret: err = %v; want ErrBadSignature", err)
+	}
+}
+
+func TestVerifyRejectsStaleTimestamp(t *testing.T) {
+	v := testVerifier(false)
+	v.now = func() time.Time { return time.Now().Add(10 * time.Minute) }
+	req := signed(t, "GET", "/api/v1/internal/x", "", "ai-service", "ai-secret")
+	if _, err := v.Verify(req); !errors.Is(err, ErrStale) {
+		t.Errorf("err = %v; want ErrStale", err)
+	}
+}
+
+// unreadBody fails the test when the verifier reads it
+type unreadBody struct{ t *testing.T }
+
+func (b unreadBody) Read([]byte) (int, error) {
+	b.t.Error("body read before the request was checked")
+	return 0, io.EOF
+}
+
+func TestVerifyChecksSignatureBeforeReadingBody(t *testing.T) {
+	v := testVerifier(false)
+
+	for sig, want := range map[string]error{"": ErrNoCredentials, "not-hex": ErrMalformed, "abcd": ErrMalformed} {
+		req := signed(t, "POST", "/api/v1/internal/x", `{}`, "ai-service", "ai-secret")
+		req.Header.Set(HeaderSignature, sig)
+		req.Body = io.NopCloser(unreadBody{t})
+		if _, err := v.Verify(req); !errors.Is(err, want) {
+			t.Errorf("signature %q: err = %v; want %v", sig, err, want)
+		}
+	}
+
+	req := signed(t, "POST", "/api/v1/internal/x", `{}`, "ai-service", "ai-secret")
+	req.Body = io.NopCloser(unreadBody{t})
+	req.ContentLength = MaxBodySize + 1
+	if _, err := v.Verify(req); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("declared oversize body: err = %v; want ErrBodyTooLarge", err)
+	}
+
+	req = signed(t, "POST", "/api/v1/internal/x", `{}`, "ai-service", "ai-secret")
+	req.Body = io.NopCloser(strings.NewReader(strings.Repeat("x", MaxBodySize+1)))
+	req.ContentLength = -1
+	if _, err := v.Verify(req); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("chunked oversize body: err = %v; want ErrBodyTooLarge", err)
+	}
+}
+
+func TestActAsUserRequiresScope(t *testing.T) {
+	v := testVerifier(false)
+
+	r := httptest.NewRequest("GET", "/api/v1/courses/my", nil)
+	r.Header.Set(HeaderUserID, "42")
+	if err := Sign(r, "ai-service", "ai-secret"); err != nil {
+		t.Fatal(err)
+	}
+	caller, err := v.Verify(r)
+	if err != nil || caller.UserID != 42 {
+		t.Errorf("caller = %+v, err = %v; want user 42", caller, err)
+	}
+
+	r = httptest.NewRequest("POST", "/api/v1/sync/user", nil)
+	r.Header.Set(HeaderUserID, "42")
+	if err := Sign(r, "auth-service", "sync-secret"); err != nil {
+		t.Fatal(err)
+	}
+	caller, err = v.Verify(r)
+	if err != nil || caller.UserID != 0 {
+		t.Errorf("caller = %+v, err = %v; want X-User-Id ignored without %s", caller, err, ScopeActAsUser)
+	}
+}
+
+func TestLegacySecretOnlyDuringMigration(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/v1/sync/user", nil)
+	r.Header.Set(HeaderLegacySyncSecret, "sync-secret")
+
+	if _, err := testVerifier(false).Verify(r); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("legacy disabled: err = %v; want ErrNoCredentials", err)
+	}
+	caller, err := testVerifier(true).Verify(r)
+	if err != nil || caller.Service != "auth-service" || !caller.Legacy {
+		t.Errorf("legacy enabled: caller = %+v, err = %v", caller, err)
+	}
+
+	r.Header.Set(HeaderLegacySyncSecret, "wrong")
+	if _, err := testVerifier(true).Verify(r); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("wrong legacy secret: err = %v; want ErrBadSignature", err)
+	}
+}
+
+// The ai-service and auth-service signers are tested against this same
+// request, so a change to canonical() must update them too.
+func TestVerifyAcceptsFixedVector(t *testing.T) {
+	v := testVerifier(false)
+	v.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	r := httptest.NewRequest("POST", "/api/v1/internal/micro-lessons/status?a=1&b=x%20y", strings.NewReader(`{"job_id":"j1"}`))
+	r.Header.Set(HeaderService, "ai-service")
+	r.Header.Set(HeaderTimestamp, "1700000000")
+	r.Header.Set(HeaderNonce, "0123456789abcdef0123456789abcdef")
+	r.Header.Set(HeaderUserID, "42")
+	r.Header.Set(HeaderSignature, "53dbe50001658daaa7e80f6ef7d1ea94b9d63185eea2a00f04a972b8649c098d")
+
+	caller, err := v.Verify(r)
+	if err != nil || caller.UserID != 42 {
+		t.Errorf("caller = %+v, err = %v; want ai-service acting for user 42", caller, err)
+	}
+}
+
+// fakeRedis speaks just enough RESP for go-redis to PING and SET ... NX,
+// recording the expiry each key was set with.
+type fakeRedis struct {
+	net.Listener
+	mu   sync.Mutex
+	keys map[string]string // key -> expiry arguments
+}
+
+func startFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{Listener: ln, keys: map[string]string{}}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		reply := "-ERR unknown command\r\n"
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			reply = "+PONG\r\n"
+		case "CLIENT", "SELECT":
+			reply = "+OK\r\n"
+		case "SET":
+			// SET key value [EX s | PX ms] NX
+			f.mu.Lock()
+			if _, taken := f.keys[args[1]]; taken {
+				reply = "$-1\r\n"
+			} else {
+				f.keys[args[1]] = strings.Join(args[3:len(args)-1], " ")
+				reply = "+OK\r\n"
+			}
+			f.mu.Unlock()
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("bad command header %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil { // $len
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestRedisCacheNoncesRejectReplayAcrossVerifiers(t *testing.T) {
+	logger.Init("test")
+	srv := startFakeRedis(t)
+	host, port, _ := net.SplitHostPort(srv.Addr().String())
+	rc, err := cache.NewRedisClient(config.RedisConfig{Host: host, Port: port})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	clients := []Client{{Name: "auth-service", Secret: "sync-secret", Scopes: []string{"sync"}}}
+	// Two replicas sharing one Redis
+	first := NewVerifier(clients, rc, Options{MaxSkew: time.Minute})
+	second := NewVerifier(clients, rc, Options{MaxSkew: time.Minute})
+
+	req := signed(t, "POST", "/api/v1/sync/user", `{"id":1}`, "auth-service", "sync-secret")
+	if _, err := first.Verify(req); err != nil {
+		t.Fatal(err)
+	}
+	req.Body = io.NopCloser(strings.NewReader(`{"id":1}`))
+	if _, err := second.Verify(req); !errors.Is(err, ErrReplay) {
+		t.Errorf("replay on another replica: err = %v; want ErrReplay", err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.keys) != 1 {
+		t.Fatalf("stored nonces = %v; want one", srv.keys)
+	}
+	for key, expiry := range srv.keys {
+		if !strings.HasPrefix(key, "svcauth:nonce:auth-service:") || expiry != "ex 120" {
+			t.Errorf("nonce %q stored with expiry %q; want svcauth:nonce:auth-service:* for twice MaxSkew", key, expiry)
+		}
+	}
+}
+
+func TestVerifyWithoutNonceStoreFailsClosed(t *testing.T) {
+	// main.go passes a nil store when Redis is down
+	v := NewVerifier([]Client{{Name: "auth-service", Secret: "sync-secret"}}, nil, Options{})
+	req := signed(t, "POST", "/api/v1/sync/user", "", "auth-service", "sync-secret")
+	if caller, err := v.Verify(req); err == nil {
+		t.Errorf("caller = %+v; want an error without a nonce store", caller)
+	}
+}
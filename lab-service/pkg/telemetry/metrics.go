@@ -0,0 +1,68 @@
+package telemetry
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+)
+
+// Handler serves the default registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterDBStats exports the sql.DB pool counters as go_sql_* metrics
+// labelled with dbName
+func RegisterDBStats(db *sql.DB, dbName string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// RegisterRedisPool exports go-redis connection pool counters. stats is
+// called on every scrape.
+func RegisterRedisPool(name string, stats func() *redis.PoolStats) {
+	prometheus.MustRegister(&redisPoolCollector{name: name, stats: stats})
+}
+
+var (
+	redisHitsDesc     = redisDesc("hits_total", "Times a free connection was found in the pool.")
+	redisMissesDesc   = redisDesc("misses_total", "Times a free connection was not found in the pool.")
+	redisTimeoutsDesc = redisDesc("timeouts_total", "Times a wait for a connection timed out.")
+	redisTotalDesc    = redisDesc("connections", "Connections currently in the pool.")
+	redisIdleDesc     = redisDesc("idle_connections", "Idle connections currently in the pool.")
+	redisStaleDesc    = redisDesc("stale_connections_total", "Stale connections removed from the pool.")
+)
+
+func redisDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc("redis_pool_"+name, help, []string{"client"}, nil)
+}
+
+type redisPoolCollector struct {
+	name  string
+	stats func() *redis.PoolStats
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisHitsDesc
+	ch <- redisMissesDesc
+	ch <- redisTimeoutsDesc
+	ch <- redisTotalDesc
+	ch <- redisIdleDesc
+	ch <- redisStaleDesc
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+	if s == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(redisHitsDesc, prometheus.CounterValue, float64(s.Hits), c.name)
+	ch <- prometheus.MustNewConstMetric(redisMissesDesc, prometheus.CounterValue, float64(s.Misses), c.name)
+	ch <- prometheus.MustNewConstMetric(redisTimeoutsDesc, prometheus.CounterValue, float64(s.Timeouts), c.name)
+	ch <- prometheus.MustNewConstMetric(redisTotalDesc, prometheus.GaugeValue, float64(s.TotalConns), c.name)
+	ch <- prometheus.MustNewConstMetric(redisIdleDesc, prometheus.GaugeValue, float64(s.IdleConns), c.name)
+	ch <- prometheus.MustNewConstMetric(redisStaleDesc, prometheus.CounterValue, float64(s.StaleConns), c.name)
+}
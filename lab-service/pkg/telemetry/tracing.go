@@ -0,0 +1,84 @@
+// Package telemetry wires Prometheus metrics and OpenTelemetry tracing.
+//
+// Metrics are registered on the default Prometheus registry and served by
+// Handler. Traces are exported over OTLP/HTTP to the collector named by the
+// standard OTEL_EXPORTER_OTLP_* environment variables. W3C trace context is
+// always propagated, even with export disabled, so a trace started upstream
+// continues through this service to the services it calls.
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies spans created by this module
+const instrumentationName = "lab-service"
+
+// TracingConfig selects whether and how spans are exported
+type TracingConfig struct {
+	Enabled        bool
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+	// SampleRatio is the fraction of new traces recorded; traces started
+	// upstream follow the caller's sampling decision
+	SampleRatio float64
+}
+
+// InitTracing installs the global propagator and, when enabled, an OTLP
+// tracer provider. The returned function flushes pending spans.
+func InitTracing(ctx context.Context, cfg TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.ServiceName),
+		attribute.String("service.version", cfg.ServiceVersion),
+		attribute.String("deployment.environment", cfg.Environment),
+	))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer for spans created by this service
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// InjectHeaders writes the trace context of ctx into headers, e.g. Kafka
+// message headers
+func InjectHeaders(ctx context.Context, headers map[string]string) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+}
+
+// ExtractHeaders returns ctx carrying the trace context found in headers,
+// e.g. Kafka message headers
+func ExtractHeaders(ctx context.Context, headers map[string]string) context.Context {
+	if len(headers) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(headers))
+}
@@ -19,9 +19,14 @@ import (
 	"example/hello/pkg/ai"
 	"example/hello/pkg/cache"
 	"example/hello/pkg/database"
+	"example/hello/pkg/jwks"
 	"example/hello/pkg/kafka"
 	"example/hello/pkg/logger"
+	"example/hello/pkg/moderation"
+	"example/hello/pkg/ratelimit"
 	"example/hello/pkg/storage"
+	"example/hello/pkg/svcauth"
+	"example/hello/pkg/telemetry"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
@@ -62,12 +67,32 @@ func main() {
 	// Initialize logger
 	logger.Init(cfg.App.Env)
 
+	// Initialize tracing
+	shutdownTracing, err := telemetry.InitTracing(context.Background(), telemetry.TracingConfig{
+		Enabled:        cfg.Telemetry.TracingEnabled,
+		ServiceName:    "lms-service",
+		ServiceVersion: cfg.App.Version,
+		Environment:    cfg.App.Env,
+		SampleRatio:    cfg.Telemetry.SampleRatio,
+	})
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Failed to flush traces", err)
+		}
+	}()
+
 	// Initialize database
 	db, err := database.NewPostgresDB(cfg.Database)
 	if err != nil {
 		logger.Fatal("Failed to connect to database", err)
 	}
 	defer db.Close()
+	telemetry.RegisterDBStats(db, cfg.Database.Name)
 
 	// Read-path indexes are non-critical and idempotent. Build them in the
 	// background so a rollout remains available even on a large existing DB.
@@ -87,6 +112,7 @@ func main() {
 		logger.Fatal("Failed to connect to Redis", err)
 	}
 	defer redisClient.Close()
+	telemetry.RegisterRedisPool("cache", redisClient.PoolStats)
 
 	// Initialize storage
 	storageCfg := config.LoadStorageConfig()
@@ -106,14 +132,48 @@ func main() {
 		logger.Info("Using local storage")
 	}
 
+	// Token verification: RS256/ES256 against the auth service's JWKS, with
+	// the shared HMAC secret accepted during the migration window
+	var jwksKeys *jwks.KeySet
+	if cfg.JWT.JWKSURL != "" {
+		jwksKeys = jwks.NewKeySet(cfg.JWT.JWKSURL, jwks.Options{RefreshInterval: cfg.JWT.JWKSRefreshInterval})
+		if err := jwksKeys.Refresh(context.Background()); err != nil {
+			logger.Warn(fmt.Sprintf("Initial JWKS fetch failed, retrying on demand: %v", err))
+		}
+		go jwksKeys.Run(context.Background())
+	}
+	hmacSecret := ""
+	if cfg.JWT.HMACEnabled {
+		hmacSecret = cfg.JWT.Secret
+	}
+	hmacUntil, _ := cfg.JWT.HMACDeadline() // validated in config.Load
+	tokenVerifier := jwks.NewVerifier(jwksKeys, hmacSecret, hmacUntil)
+
+	// Internal callers sign requests with per-service secrets; nonces are
+	// claimed in Redis so a captured request cannot be replayed
+	serviceClients := make([]svcauth.Client, 0, len(cfg.ServiceAuth.Clients))
+	for _, client := range cfg.ServiceAuth.Clients {
+		serviceClients = append(serviceClients, svcauth.Client{Name: client.Name, Secret: client.Secret, Scopes: client.Scopes})
+	}
+	serviceAuth := svcauth.NewVerifier(serviceClients, redisClient, svcauth.Options{
+		MaxSkew:     cfg.ServiceAuth.MaxSkew,
+		AllowLegacy: cfg.ServiceAuth.AllowLegacy,
+	})
+	if cfg.ServiceAuth.AllowLegacy {
+		logger.Warn("Legacy static service secrets are still accepted (SERVICE_AUTH_ALLOW_LEGACY=true)")
+	}
+
 	aiClient := ai.NewClient()
 
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	courseRepo := repository.NewCourseRepository(db)
 	enrollmentRepo := repository.NewEnrollmentRepository(db)
+	courseGroupRepo := repository.NewCourseGroupRepository(db)
 	quizRepo := repository.NewQuizRepository(db)
 	forumRepo := repository.NewForumRepository(db)
+	forumNotificationRepo := repository.NewForumNotificationRepository(db)
+	forumAttachmentRepo := repository.NewForumAttachmentRepository(db)
 	progressRepo := repository.NewProgressRepository(db)
 	analyticsRepo := repository.NewAnalyticsRepository(db)
 	roleDefRepo := repository.NewRoleDefinitionRepository(db)
@@ -121,15 +181,63 @@ func main() {
 	orgRepo := repository.NewOrganizationRepository(db)
 
 	flashcardRepo := repository.NewFlashcardRepository(db)
+	flashcardDeckRepo := repository.NewFlashcardDeckRepository(db)
 	microLessonRepo := repository.NewMicroLessonRepository(db)
 	microInteractionRepo := repository.NewMicroInteractionRepository(db)
 	microQuizRepo := repository.NewMicroQuizRepository(db)
 	sectionOverviewRepo := repository.NewSectionOverviewRepository(db)
 	learningEventRepo := repository.NewLearningEventRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
 
 	kafka.InitProducer()
 	defer kafka.CloseProducer()
 
+	// Outbox relay: events written alongside domain changes are published
+	// here, with retries and per-key ordering
+	var outboxPublisher kafka.Publisher
+	if cfg.Outbox.Publisher == "memory" {
+		logger.Warn("Outbox publishing to in-memory broker; events will not reach Kafka")
+		outboxPublisher = kafka.NewMemoryBroker()
+	} else {
+		brokers := os.Getenv("KAFKA_BROKERS")
+		if brokers == "" {
+			brokers = "localhost:9092"
+		}
+		outboxPublisher = kafka.NewWriterPublisher(brokers)
+	}
+	defer outboxPublisher.Close()
+	outboxService := service.NewOutboxService(outboxRepo, outboxPublisher, service.OutboxConfig{
+		PollInterval: cfg.Outbox.PollInterval,
+		BatchSize:    cfg.Outbox.BatchSize,
+		MaxAttempts:  cfg.Outbox.MaxAttempts,
+		BaseBackoff:  cfg.Outbox.BaseBackoff,
+		MaxBackoff:   cfg.Outbox.MaxBackoff,
+		Retention:    cfg.Outbox.Retention,
+	})
+	go outboxService.RunRelay(context.Background())
+
+	// Shared consumer runtime: bounded retries, <topic>.dlq dead letters and
+	// a processed-event ledger so redelivered events are not applied twice
+	consumerLedgerRepo := repository.NewConsumerLedgerRepository(db)
+	kafka.ConfigureConsumers(kafka.ConsumerOptions{
+		MaxRetries:  cfg.Consumer.MaxRetries,
+		BaseBackoff: cfg.Consumer.BaseBackoff,
+		MaxBackoff:  cfg.Consumer.MaxBackoff,
+		Ledger:      consumerLedgerRepo,
+		DeadLetter:  outboxPublisher,
+	})
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if n, err := consumerLedgerRepo.Prune(context.Background(), cfg.Consumer.LedgerRetention); err != nil {
+				logger.Error("Failed to prune consumer ledger", err)
+			} else if n > 0 {
+				logger.Info(fmt.Sprintf("Pruned %d consumer ledger entries", n))
+			}
+		}
+	}()
+
 	go kafka.StartConsumer(context.Background(), func(ctx context.Context, event kafka.ProcessDocumentStatusEvent) error {
 		logger.Info(fmt.Sprintf("Received status update for content %d: %s", event.ContentID, event.Status))
 		if event.Status == "completed" || event.Status == "success" {
@@ -189,18 +297,20 @@ func main() {
 			"Cascading node merge: survivor=%d absorbed=%d",
 			event.SurvivorID, len(event.AbsorbedIDs)))
 
+		// Runs inside the consumer ledger's transaction
+		tx := repository.Conn(ctx, db)
 		absorbed := pq.Array(event.AbsorbedIDs)
-		if _, err := db.ExecContext(ctx,
+		if _, err := tx.ExecContext(ctx,
 			`UPDATE micro_lessons SET node_id = $1 WHERE node_id = ANY($2)`,
 			event.SurvivorID, absorbed); err != nil {
 			return fmt.Errorf("micro_lessons cascade: %w", err)
 		}
-		if _, err := db.ExecContext(ctx,
+		if _, err := tx.ExecContext(ctx,
 			`UPDATE micro_quizzes SET node_id = $1 WHERE node_id = ANY($2)`,
 			event.SurvivorID, absorbed); err != nil {
 			return fmt.Errorf("micro_quizzes cascade: %w", err)
 		}
-		if _, err := db.ExecContext(ctx,
+		if _, err := tx.ExecContext(ctx,
 			`UPDATE quiz_questions SET node_id = $1 WHERE node_id = ANY($2)`,
 			event.SurvivorID, absorbed); err != nil {
 			return fmt.Errorf("quiz_questions cascade: %w", err)
@@ -213,20 +323,49 @@ func main() {
 	// singleflight-backed Loader internally so cache stampedes on hot keys
 	// only ever produce one DB query per process.
 	userService := service.NewUserService(userRepo, redisClient)
-	orgService := service.NewOrganizationService(orgRepo, userRepo, redisClient)
-	courseService := service.NewCourseService(courseRepo, userRepo, enrollmentRepo, orgRepo, redisClient)
+	auditService := service.NewAuditService(repository.NewAuditRepository(db))
+	permService := service.NewPermissionService(permRepo, roleDefRepo, orgRepo, courseRepo, redisClient, auditService)
+	orgService := service.NewOrganizationService(orgRepo, userRepo, redisClient, auditService, permService)
+	courseService := service.NewCourseService(courseRepo, userRepo, enrollmentRepo, orgRepo, courseGroupRepo, redisClient, auditService, permService)
 	enrollmentService := service.NewEnrollmentService(enrollmentRepo, courseRepo, userRepo, progressRepo, orgRepo, redisClient)
-	quizService := service.NewQuizService(quizRepo, courseRepo, userRepo, progressRepo, aiClient)
+	quizService := service.NewQuizService(quizRepo, courseRepo, userRepo, progressRepo, aiClient, auditService)
+
+	orgJoinService := service.NewOrgJoinService(orgService, orgRepo, repository.NewOrgInvitationRepository(db), userRepo, service.OrgInvitationConfig{
+		TTL:       cfg.OrgJoin.InviteTTL,
+		AcceptURL: cfg.OrgJoin.InviteAcceptURL,
+	})
 
-	userSyncService := service.NewUserSyncService(userRepo, redisClient)
-	forumService := service.NewForumService(forumRepo, courseRepo)
-	syncSecret := os.Getenv("LMS_SYNC_SECRET")
+	userSyncService := service.NewUserSyncService(userRepo, repository.NewSyncVersionRepository(db), redisClient, orgJoinService)
+	forumContentFilter := moderation.NewFilter(moderation.Config{
+		BannedWords:       cfg.Forum.BannedWords,
+		MaxLinks:          cfg.Forum.MaxLinksPerPost,
+		BlockedDomains:    cfg.Forum.BlockedLinkDomains,
+		MaxLinksPerDomain: cfg.Forum.MaxLinksPerDomain,
+	})
+	forumNotificationService := service.NewForumNotificationService(forumNotificationRepo, forumRepo, enrollmentService)
+	forumAttachmentService := service.NewForumAttachmentService(forumAttachmentRepo, forumRepo, enrollmentService, storageProvider, service.ForumAttachmentConfig{
+		MaxSize:     cfg.Forum.AttachmentMaxSize,
+		CourseQuota: cfg.Forum.AttachmentCourseQuota,
+		Extensions:  cfg.Forum.AttachmentExtensions,
+	})
+	forumService := service.NewForumService(forumRepo, courseRepo, orgRepo, enrollmentService, forumContentFilter, service.ForumModerationConfig{
+		AutoHideReportCount: cfg.Forum.AutoHideReportCount,
+		MaxBanDuration:      cfg.Forum.MaxBanDuration,
+	}, forumNotificationService, forumAttachmentService)
+
+	// Uploads never linked to a post or comment (or left behind by deletes)
+	go forumAttachmentService.RunOrphanSweeper(context.Background(), time.Hour)
+
+	// Daily forum digest: rolls unread notifications into one email event per user
+	if cfg.Forum.DigestEnabled {
+		go forumNotificationService.RunDigestProducer(context.Background(), cfg.Forum.DigestHourUTC)
+	}
 	progressService := service.NewProgressService(progressRepo, enrollmentRepo, redisClient)
 	analyticsService := service.NewAnalyticsService(analyticsRepo, courseRepo, enrollmentRepo, aiClient, redisClient)
-	flashcardService := service.NewFlashcardService(flashcardRepo, aiClient, redisClient)
+	flashcardService := service.NewFlashcardService(flashcardRepo, courseRepo, aiClient, redisClient)
+	flashcardDeckService := service.NewFlashcardDeckService(flashcardDeckRepo, courseRepo, enrollmentService)
 	microInteractionService := service.NewMicroInteractionService(microInteractionRepo, microLessonRepo)
-	roleAdminService := service.NewRoleAdminService(roleDefRepo, userRepo, redisClient)
-	permService := service.NewPermissionService(permRepo, redisClient)
+	roleAdminService := service.NewRoleAdminService(roleDefRepo, userRepo, redisClient, auditService)
 	learningEventService := service.NewLearningEventService(learningEventRepo, service.NewKafkaService())
 
 	// Heatmap analytics worker: consumes Quick Action Panel interactions
@@ -235,19 +374,32 @@ func main() {
 		return microInteractionService.ApplyEvent(ctx, ev)
 	})
 
+	// User and organization lifecycle events from the auth service; the
+	// /sync endpoints stay for callers that still push
+	if cfg.AuthSync.ConsumerEnabled {
+		go kafka.StartUserEventConsumer(context.Background(), userSyncService.ApplyUserEvent)
+		go kafka.StartOrgEventConsumer(context.Background(), userSyncService.ApplyOrgEvent)
+	}
+
 	// Initialize handlers
 	userHandler := handler.NewUserHandler(userService)
 	courseHandler := handler.NewCourseHandler(courseService)
 	coTeacherHandler := handler.NewCoTeacherHandler(courseService)
+	courseGroupHandler := handler.NewCourseGroupHandler(courseService)
 	enrollmentHandler := handler.NewEnrollmentHandler(enrollmentService)
 	fileHandler := handler.NewFileHandler(storageProvider, cfg.Upload)
-	syncHandler := handler.NewUserSyncHandler(userSyncService, syncSecret)
+	syncHandler := handler.NewUserSyncHandler(userSyncService)
 	quizHandler := handler.NewQuizHandler(quizService, storageProvider)
 	forumHandler := handler.NewForumHandler(forumService)
+	forumNotificationHandler := handler.NewForumNotificationHandler(forumNotificationService)
+	forumAttachmentHandler := handler.NewForumAttachmentHandler(forumAttachmentService, forumService)
+	outboxHandler := handler.NewOutboxHandler(outboxService)
+	auditHandler := handler.NewAuditHandler(auditService)
 	progressHandler := handler.NewProgressHandler(progressService)
 	analyticsHandler := handler.NewAnalyticsHandler(analyticsService, aiClient)
 	aiHandler := handler.NewAIHandler(aiClient, courseRepo, quizRepo, redisClient)
 	flashcardHandler := handler.NewFlashcardHandler(flashcardService, enrollmentService)
+	flashcardDeckHandler := handler.NewFlashcardDeckHandler(flashcardDeckService)
 	microLessonHandler := handler.NewMicroLessonHandler(microLessonRepo, courseRepo, aiClient, redisClient)
 	microQuizHandler := handler.NewMicroQuizHandler(microQuizRepo, courseRepo, quizRepo, aiClient, redisClient)
 	microInteractionHandler := handler.NewMicroInteractionHandler(microInteractionService)
@@ -255,6 +407,7 @@ func main() {
 	roleAdminHandler := handler.NewRoleAdminHandler(roleAdminService)
 	permHandler := handler.NewPermissionHandler(permService)
 	orgHandler := handler.NewOrganizationHandler(orgService)
+	orgJoinHandler := handler.NewOrgJoinHandler(orgJoinService)
 	courseBlueprintHandler := handler.NewCourseBlueprintHandler(aiClient, orgRepo, courseService)
 	competencyAIHandler := handler.NewCompetencyAIHandler(aiClient)
 	personalizedLearningHandler := handler.NewPersonalizedLearningHandler(learningEventService, courseService)
@@ -264,6 +417,50 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Rate limits are GCRA buckets in Redis shared by every replica; while
+	// Redis is unreachable each replica falls back to its own buckets
+	var rateLimiter *middleware.RateLimiter
+	if cfg.RateLimit.Enabled {
+		globalRule, err := ratelimit.ParseRule(cfg.RateLimit.Global)
+		if err != nil {
+			logger.Fatal("Invalid RATE_LIMIT_GLOBAL", err)
+		}
+		var policies []ratelimit.Policy
+		for name, spec := range map[string]string{
+			middleware.RatePolicyAIGeneration: cfg.RateLimit.AIGeneration,
+			middleware.RatePolicyUpload:       cfg.RateLimit.Upload,
+			middleware.RatePolicyQuizSubmit:   cfg.RateLimit.QuizSubmit,
+		} {
+			policy, err := ratelimit.ParsePolicy(name, spec)
+			if err != nil {
+				logger.Fatal("Invalid rate limit policy", err)
+			}
+			policies = append(policies, policy)
+		}
+		overrides, err := ratelimit.ParseOverrides(cfg.RateLimit.BypassNetworks, cfg.RateLimit.BypassUsers, cfg.RateLimit.BypassServices)
+		if err != nil {
+			logger.Fatal("Invalid rate limit bypass list", err)
+		}
+		rateLimitStore := &ratelimit.FallbackStore{
+			Primary:   ratelimit.NewRedisStore(redisClient, "ratelimit:"),
+			Secondary: ratelimit.NewMemoryStore(),
+			OnError: func(err error) {
+				logger.Warn(fmt.Sprintf("Rate limit store unavailable, using local buckets: %v", err))
+			},
+		}
+		orgLoader := cache.NewLoader(redisClient)
+		rateLimiter = middleware.NewRateLimiter(rateLimitStore,
+			ratelimit.Policy{Name: "global", Default: globalRule},
+			policies, overrides,
+			// Membership changes reach the limiter within a minute
+			func(ctx context.Context, userID int64) ([]int64, error) {
+				return cache.GetOrLoad(ctx, orgLoader, cache.KeyUserOrgIDs(userID), time.Minute,
+					func(ctx context.Context) ([]int64, error) { return orgRepo.GetUserOrgIDs(ctx, userID) })
+			})
+	} else {
+		logger.Warn("Rate limiting is disabled (RATE_LIMIT_ENABLED=false)")
+	}
+
 	router := gin.New()
 	// Trust private network proxies to correctly resolve client IP (matching the Docker/Traefik network setup)
 	_ = router.SetTrustedProxies([]string{
@@ -275,16 +472,34 @@ func main() {
 	})
 	router.MaxMultipartMemory = 64 << 20 // 64 MB
 	router.Use(gin.Recovery())
+	router.Use(telemetry.Middleware("/health"))
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger())
 	router.Use(middleware.CORS(cfg.CORS))
-	router.Use(middleware.RateLimit(redisClient, cfg.AIConf.Secret))
+	router.Use(rateLimiter.Global(cfg.AIConf.Secret, serviceAuth))
+
+	// Per-route-group limits run after authentication so the caller's
+	// roles and organizations pick the rule
+	aiGenerationLimit := rateLimiter.Policy(middleware.RatePolicyAIGeneration)
+	uploadLimit := rateLimiter.Policy(middleware.RatePolicyUpload)
+	quizSubmitLimit := rateLimiter.Policy(middleware.RatePolicyQuizSubmit)
 
 	// Health check
 	healthHandler := func(c *gin.Context) {
+		// A stopped consumer, a blocked dead-letter topic or a large
+		// backlog degrades the service without making the API unusable
+		status := "healthy"
+		consumers := kafka.ConsumerStatuses()
+		for _, cs := range consumers {
+			if !cs.Running || cs.DeadLetterDown || cs.Lag > cfg.Consumer.MaxHealthyLag {
+				status = "degraded"
+			}
+		}
 		c.JSON(http.StatusOK, gin.H{
-			"status":  "healthy",
-			"time":    time.Now(),
-			"version": cfg.App.Version,
+			"status":    status,
+			"time":      time.Now(),
+			"version":   cfg.App.Version,
+			"consumers": consumers,
 		})
 	}
 	router.GET("/health", healthHandler)
@@ -307,7 +522,7 @@ func main() {
 	{
 		// SYNC ROUTES
 		sync := v1.Group("/sync")
-		sync.Use(syncHandler.SyncSecret())
+		sync.Use(middleware.ServiceAuth(serviceAuth, middleware.ScopeSync))
 		{
 			sync.POST("/user", syncHandler.SyncUser)
 			sync.POST("/users/bulk", syncHandler.BulkSyncUsers)
@@ -330,7 +545,7 @@ func main() {
 			// Protected endpoints - require authentication
 			// 1. Flexible endpoints (Internal Service Secret OR JWT)
 			flexible := files.Group("")
-			flexible.Use(middleware.ServiceOrAuthMiddleware(cfg.JWT.Secret, cfg.AIConf.Secret))
+			flexible.Use(middleware.ServiceOrAuthMiddleware(tokenVerifier, serviceAuth, middleware.ScopeFiles))
 			flexible.Use(middleware.LoadLocalRoles(userRepo, redisClient))
 			{
 				flexible.GET("/presigned/*filepath", fileHandler.GetPresignedURL)
@@ -338,19 +553,19 @@ func main() {
 
 			// 2. Strict protected endpoints (JWT ONLY)
 			protected := files.Group("")
-			protected.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+			protected.Use(middleware.AuthMiddleware(tokenVerifier))
 			protected.Use(middleware.LoadLocalRoles(userRepo, redisClient))
 			{
-				protected.POST("/upload", fileHandler.UploadFile)
+				protected.POST("/upload", uploadLimit, fileHandler.UploadFile)
 				protected.DELETE("/delete/*filepath", fileHandler.DeleteFile)
 			}
 		}
 
 		// -- Section management (Internal Service Secret OR JWT) --------------
-		// AI service calls these endpoints using X-API-Secret header.
+		// AI service calls these endpoints with signed service requests.
 		// Normal users continue to use JWT authentication.
 		flexCourses := v1.Group("/courses")
-		flexCourses.Use(middleware.ServiceOrAuthMiddleware(cfg.JWT.Secret, cfg.AIConf.Secret))
+		flexCourses.Use(middleware.ServiceOrAuthMiddleware(tokenVerifier, serviceAuth, middleware.ScopeCourses))
 		flexCourses.Use(middleware.LoadLocalRoles(userRepo, redisClient))
 		{
 			flexCourses.POST("/:courseId/sections", courseHandler.CreateSection)
@@ -360,8 +575,9 @@ func main() {
 
 		// Protected routes - require authentication
 		auth := v1.Group("")
-		auth.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+		auth.Use(middleware.AuthMiddleware(tokenVerifier))
 		auth.Use(middleware.LoadLocalRoles(userRepo, redisClient))
+		auth.Use(middleware.AuditContext())
 		{
 			// User role management
 			auth.GET("/me/roles", userHandler.GetMyRoles)
@@ -414,8 +630,67 @@ func main() {
 				adminCourses.GET("", courseHandler.ListAllCoursesForAdmin)
 			}
 
+			// Audit log of administrative and grading actions
+			adminAudit := auth.Group("/admin/audit-logs")
+			adminAudit.Use(middleware.RequireRoles("ADMIN"))
+			{
+				adminAudit.GET("", auditHandler.ListEntries)
+				adminAudit.GET("/export", auditHandler.ExportCSV)
+			}
+
+			// Kafka outbox: inspect, replay or discard stuck events
+			adminOutbox := auth.Group("/admin/outbox")
+			adminOutbox.Use(middleware.RequireRoles("ADMIN"))
+			{
+				adminOutbox.GET("", outboxHandler.ListEvents)
+				adminOutbox.GET("/stats", outboxHandler.GetStats)
+				adminOutbox.POST("/replay", outboxHandler.ReplayDead)
+				adminOutbox.GET("/:id", outboxHandler.GetEvent)
+				adminOutbox.POST("/:id/replay", outboxHandler.ReplayEvent)
+				adminOutbox.POST("/:id/discard", outboxHandler.DiscardEvent)
+			}
+
 			// Student-facing: list my orgs
 			auth.GET("/my/orgs", orgHandler.GetMyOrganizations)
+			auth.POST("/my/orgs/join", orgJoinHandler.RedeemJoinCode)
+			auth.POST("/my/orgs/invitations/accept", orgJoinHandler.AcceptInvitation)
+
+			// ORGANIZATION SELF-MANAGEMENT - granted by the caller's role in
+			// the organization, or by the global role (e.g. ADMIN)
+			orgScope := middleware.OrgFromParam("id")
+			orgMembers := auth.Group("/organizations/:id/members")
+			orgMembers.Use(middleware.RequirePermission(permService, "ORG_MEMBER_MANAGE", orgScope))
+			{
+				orgMembers.GET("", orgHandler.ListMembers)
+				orgMembers.POST("", orgHandler.AddMember)
+				orgMembers.POST("/bulk", orgHandler.BulkAddMembers)
+				orgMembers.PUT("/:userId/role", orgHandler.UpdateMemberRole)
+				orgMembers.DELETE("/:userId", orgHandler.RemoveMember)
+			}
+			orgInvitations := auth.Group("/organizations/:id/invitations")
+			orgInvitations.Use(middleware.RequirePermission(permService, "ORG_MEMBER_MANAGE", orgScope))
+			{
+				orgInvitations.GET("", orgJoinHandler.ListInvitations)
+				orgInvitations.POST("", orgJoinHandler.CreateInvitation)
+				orgInvitations.DELETE("/:invitationId", orgJoinHandler.RevokeInvitation)
+			}
+			orgJoinCodes := auth.Group("/organizations/:id/join-codes")
+			orgJoinCodes.Use(middleware.RequirePermission(permService, "ORG_MEMBER_MANAGE", orgScope))
+			{
+				orgJoinCodes.GET("", orgJoinHandler.ListJoinCodes)
+				orgJoinCodes.POST("", orgJoinHandler.CreateJoinCode)
+				orgJoinCodes.DELETE("/:codeId", orgJoinHandler.RevokeJoinCode)
+			}
+			orgRoles := auth.Group("/organizations/:id/roles")
+			orgRoles.Use(middleware.RequirePermission(permService, "ORG_ROLE_MANAGE", orgScope))
+			{
+				orgRoles.GET("", roleAdminHandler.ListOrgRoles)
+				orgRoles.POST("", roleAdminHandler.CreateOrgRole)
+				orgRoles.PUT("/:roleId", roleAdminHandler.UpdateOrgRole)
+				orgRoles.DELETE("/:roleId", roleAdminHandler.DeleteOrgRole)
+				orgRoles.GET("/:roleId/permissions", permHandler.GetOrgRolePermissions)
+				orgRoles.PUT("/:roleId/permissions", permHandler.AssignOrgRolePermissions)
+			}
 
 			// -- Composite Analytics (Quick Action Panel + heatmap) ---------
 			// POST /analytics/micro-interaction is hit by every flashcard
@@ -434,7 +709,7 @@ func main() {
 			}
 
 			// COURSE MANAGEMENT
-			auth.POST("/course-blueprints", courseBlueprintHandler.Create)
+			auth.POST("/course-blueprints", aiGenerationLimit, courseBlueprintHandler.Create)
 			auth.GET("/course-blueprints/:blueprintId", courseBlueprintHandler.Get)
 			auth.PUT("/course-blueprints/:blueprintId", courseBlueprintHandler.Update)
 			auth.POST("/course-blueprints/:blueprintId/approve", courseBlueprintHandler.Approve)
@@ -442,7 +717,7 @@ func main() {
 			auth.POST("/course-blueprints/:blueprintId/cancel", courseBlueprintHandler.Cancel)
 			courses := auth.Group("/courses")
 			{
-				courses.POST("/:courseId/material-routing", courseBlueprintHandler.CreateMaterialRouting)
+				courses.POST("/:courseId/material-routing", aiGenerationLimit, courseBlueprintHandler.CreateMaterialRouting)
 				courses.GET("/:courseId/material-routing/:routingId", courseBlueprintHandler.GetMaterialRouting)
 				courses.POST("/:courseId/material-routing/apply", courseBlueprintHandler.ApplyMaterialRouting)
 				// Public course routes (anyone authenticated can view published courses)
@@ -468,6 +743,15 @@ func main() {
 				courses.DELETE("/:courseId/co-teachers/:userId", coTeacherHandler.RemoveCoTeacher)
 				courses.GET("/:courseId/co-teachers", coTeacherHandler.ListCoTeachers)
 
+				// Student groups (staff only; each group gets a chat channel)
+				courses.POST("/:courseId/groups", courseGroupHandler.CreateGroup)
+				courses.GET("/:courseId/groups", courseGroupHandler.ListGroups)
+				courses.PUT("/:courseId/groups/:groupId", courseGroupHandler.RenameGroup)
+				courses.DELETE("/:courseId/groups/:groupId", courseGroupHandler.DeleteGroup)
+				courses.GET("/:courseId/groups/:groupId/members", courseGroupHandler.ListGroupMembers)
+				courses.POST("/:courseId/groups/:groupId/members", courseGroupHandler.AddGroupMembers)
+				courses.POST("/:courseId/groups/:groupId/members/remove", courseGroupHandler.RemoveGroupMembers)
+
 				// -- Analytics (Teacher / Admin only)
 				courses.GET("/:courseId/quiz-analytics", analyticsHandler.GetCourseQuizAnalytics)
 				courses.GET("/:courseId/student-progress-overview", analyticsHandler.GetStudentProgressOverview)
@@ -483,16 +767,29 @@ func main() {
 				courses.GET("/:courseId/analytics/student-summary", analyticsHandler.GetStudentAnalyticsSummary)
 
 				// -- Flashcards (Student) ----------------------------------
-				courses.POST("/:courseId/nodes/:nodeId/flashcards/generate", flashcardHandler.GenerateFlashcards)
-				courses.POST("/:courseId/flashcards/generate", flashcardHandler.GenerateFlashcards)
+				courses.POST("/:courseId/nodes/:nodeId/flashcards/generate", aiGenerationLimit, flashcardHandler.GenerateFlashcards)
+				courses.POST("/:courseId/flashcards/generate", aiGenerationLimit, flashcardHandler.GenerateFlashcards)
 				courses.GET("/:courseId/flashcards/due", flashcardHandler.ListDueFlashcards)
 				courses.GET("/:courseId/nodes/:nodeId/flashcards", flashcardHandler.ListFlashcards)
 				courses.GET("/:courseId/flashcards", flashcardHandler.ListFlashcards)
 				courses.POST("/:courseId/flashcards/bulk-save", flashcardHandler.BulkSaveFlashcards)
+				courses.GET("/:courseId/flashcards/export", flashcardHandler.ExportFlashcards)
+				courses.POST("/:courseId/nodes/:nodeId/flashcards/import", flashcardHandler.ImportFlashcards)
+
+				// -- Shared flashcard decks (Teacher authors, Student studies)
+				courses.POST("/:courseId/flashcard-decks", flashcardDeckHandler.CreateDeck)
+				courses.GET("/:courseId/flashcard-decks", flashcardDeckHandler.ListDecks)
+				courses.GET("/:courseId/flashcard-decks/due", flashcardDeckHandler.ListDueCards)
 
 				// -- Progress tracking (Student) ---------------------------
 				courses.GET("/:courseId/my-progress", progressHandler.GetMyProgress)
 				courses.GET("/:courseId/progress-detail", progressHandler.GetMyProgressDetail)
+
+				// -- Forum posting bans (moderators, checked in service) ---
+				courses.POST("/:courseId/forum/bans", forumHandler.BanUser)
+				courses.GET("/:courseId/forum/bans", forumHandler.ListBans)
+				courses.DELETE("/:courseId/forum/bans/:userId", forumHandler.LiftBan)
+				courses.GET("/:courseId/forum/attachments/usage", forumAttachmentHandler.GetUsage)
 			}
 
 			// FLASHCARD ROUTE (Outside course root context)
@@ -501,6 +798,21 @@ func main() {
 				flashcards.POST("/:flashcardId/review", flashcardHandler.ReviewFlashcard)
 			}
 
+			// SHARED FLASHCARD DECKS
+			flashcardDecks := auth.Group("/flashcard-decks")
+			{
+				flashcardDecks.GET("/:deckId", flashcardDeckHandler.GetDeck)
+				flashcardDecks.PUT("/:deckId", flashcardDeckHandler.UpdateDeck)
+				flashcardDecks.DELETE("/:deckId", flashcardDeckHandler.ArchiveDeck)
+				flashcardDecks.POST("/:deckId/publish", flashcardDeckHandler.PublishDeck)
+				flashcardDecks.GET("/:deckId/versions", flashcardDeckHandler.ListVersions)
+				flashcardDecks.POST("/:deckId/cards", flashcardDeckHandler.AddCards)
+				flashcardDecks.POST("/:deckId/import", flashcardDeckHandler.ImportCards)
+				flashcardDecks.PUT("/cards/:cardId", flashcardDeckHandler.UpdateCard)
+				flashcardDecks.DELETE("/cards/:cardId", flashcardDeckHandler.RemoveCard)
+				flashcardDecks.POST("/cards/:cardId/review", flashcardDeckHandler.ReviewCard)
+			}
+
 			// SECTION MANAGEMENT
 			sections := auth.Group("/sections")
 			{
@@ -523,16 +835,16 @@ func main() {
 				content.DELETE("/:contentId", courseHandler.DeleteContent)
 				// -- Progress tracking (Student) ---------------------------
 				content.POST("/:contentId/complete", progressHandler.MarkComplete)
-				content.POST("/:contentId/process", aiHandler.TriggerDocumentProcess)
+				content.POST("/:contentId/process", aiGenerationLimit, aiHandler.TriggerDocumentProcess)
 
-				content.POST("/:contentId/ai-index", aiHandler.TriggerContentAutoIndex)
+				content.POST("/:contentId/ai-index", aiGenerationLimit, aiHandler.TriggerContentAutoIndex)
 				content.GET("/:contentId/ai-index-status", aiHandler.GetContentAutoIndexStatus)
 				content.POST("/batch-ai-index-status", aiHandler.BatchGetContentAutoIndexStatus)
 			}
 
 			// ENROLLMENT MANAGEMENT (Internal Service Secret OR JWT)
 			enrollments := v1.Group("/enrollments")
-			enrollments.Use(middleware.ServiceOrAuthMiddleware(cfg.JWT.Secret, cfg.AIConf.Secret))
+			enrollments.Use(middleware.ServiceOrAuthMiddleware(tokenVerifier, serviceAuth, middleware.ScopeEnrollments))
 			enrollments.Use(middleware.LoadLocalRoles(userRepo, redisClient))
 			{
 				// Student enrollment
@@ -575,7 +887,7 @@ func main() {
 				questions.PUT("/:questionId", quizHandler.UpdateQuestion)
 				questions.DELETE("/:questionId", quizHandler.DeleteQuestion)
 
-				questions.POST("/:questionId/images", quizHandler.UploadQuestionImage)
+				questions.POST("/:questionId/images", uploadLimit, quizHandler.UploadQuestionImage)
 				questions.GET("/:questionId/images", quizHandler.ListQuestionImages)
 				questions.DELETE("/:questionId/images/:imageId", quizHandler.DeleteQuestionImage)
 			}
@@ -584,8 +896,8 @@ func main() {
 			attempts := auth.Group("/attempts")
 			{
 				attempts.GET("/:attemptId/answers", quizHandler.GetAttemptAnswers)
-				attempts.POST("/:attemptId/answers", quizHandler.SubmitAnswer)
-				attempts.POST("/:attemptId/submit", quizHandler.SubmitQuiz)
+				attempts.POST("/:attemptId/answers", quizSubmitLimit, quizHandler.SubmitAnswer)
+				attempts.POST("/:attemptId/submit", quizSubmitLimit, quizHandler.SubmitQuiz)
 				attempts.GET("/:attemptId/result", quizHandler.GetQuizResult)
 				attempts.GET("/:attemptId/review", quizHandler.ReviewQuiz)
 				attempts.GET("/:attemptId/summary", quizHandler.GetAttemptSummary)
@@ -601,6 +913,9 @@ func main() {
 			// Forum posts on content
 			content.POST("/:contentId/forum/posts", forumHandler.CreatePost)
 			content.GET("/:contentId/forum/posts", forumHandler.ListPosts)
+			content.POST("/:contentId/forum/subscription", forumNotificationHandler.SubscribeForum)
+			content.DELETE("/:contentId/forum/subscription", forumNotificationHandler.UnsubscribeForum)
+			content.POST("/:contentId/forum/attachments", uploadLimit, forumAttachmentHandler.UploadAttachment)
 
 			// Individual forum posts
 			forum := auth.Group("/forum")
@@ -619,6 +934,14 @@ func main() {
 					// Voting
 					posts.POST("/:postId/vote", forumHandler.VotePost)
 
+					// Reporting
+					posts.POST("/:postId/report", forumHandler.ReportPost)
+
+					// Subscriptions and read state
+					posts.POST("/:postId/subscription", forumNotificationHandler.SubscribePost)
+					posts.DELETE("/:postId/subscription", forumNotificationHandler.UnsubscribePost)
+					posts.POST("/:postId/read", forumNotificationHandler.MarkPostRead)
+
 					// Comments on posts
 					posts.POST("/:postId/comments", forumHandler.CreateComment)
 					posts.GET("/:postId/comments", forumHandler.ListComments)
@@ -631,7 +954,27 @@ func main() {
 					comments.DELETE("/:commentId", middleware.RequireRoles("STUDENT", "TEACHER", "ADMIN"), forumHandler.DeleteComment)
 					comments.POST("/:commentId/accept", forumHandler.AcceptComment)
 					comments.POST("/:commentId/vote", forumHandler.VoteComment)
+					comments.POST("/:commentId/report", forumHandler.ReportComment)
+				}
+
+				// Moderator queue (course teachers, org admins, admins - checked in service)
+				moderationGroup := forum.Group("/moderation")
+				{
+					moderationGroup.GET("/reports", forumHandler.ListReportQueue)
+					moderationGroup.GET("/posts/:postId/reports", forumHandler.ListTargetReports)
+					moderationGroup.POST("/posts/:postId/resolve", forumHandler.ResolveReports)
+					moderationGroup.GET("/comments/:commentId/reports", forumHandler.ListTargetReports)
+					moderationGroup.POST("/comments/:commentId/resolve", forumHandler.ResolveReports)
 				}
+
+				// Subscriptions and notification feed
+				forum.GET("/subscriptions", forumNotificationHandler.ListSubscriptions)
+				forum.GET("/notifications", forumNotificationHandler.ListNotifications)
+				forum.POST("/notifications/read", forumNotificationHandler.MarkNotificationsRead)
+
+				// Attachments (access checked per course in service)
+				forum.GET("/attachments/:attachmentId", forumAttachmentHandler.ServeAttachment)
+				forum.DELETE("/attachments/:attachmentId", forumAttachmentHandler.DeleteAttachment)
 			}
 
 			aiGroup := auth.Group("/ai")
@@ -639,11 +982,11 @@ func main() {
 				// -- Phase 1: Error Diagnosis ------------------------------------------
 				// POST /api/v1/ai/attempts/:attemptId/questions/:questionId/diagnose
 				aiGroup.POST("/attempts/:attemptId/questions/:questionId/diagnose",
-					aiHandler.DiagnoseWrongAnswer)
+					aiGenerationLimit, aiHandler.DiagnoseWrongAnswer)
 				aiGroup.GET("/knowledge-graph/global",
 					aiHandler.GetGlobalKnowledgeGraph)
 				aiGroup.POST("/knowledge-graph/link-global",
-					aiHandler.TriggerGlobalLinking)
+					aiGenerationLimit, aiHandler.TriggerGlobalLinking)
 
 				// System-wide Polling Endpoint for AI Jobs
 				aiGroup.GET("/jobs/:jobId/status",
@@ -651,11 +994,11 @@ func main() {
 
 				// Quick Action Panel - Concept Check
 				aiGroup.POST("/concept-check",
-					aiHandler.GenerateConceptCheck)
+					aiGenerationLimit, aiHandler.GenerateConceptCheck)
 
 				// Quiz Smart Import - Parse raw text into structured questions
 				aiGroup.POST("/quizzes/parse-text",
-					aiHandler.ParseQuizText)
+					aiGenerationLimit, aiHandler.ParseQuizText)
 
 				// Spaced Repetition total due reviews (student dashboard)
 				aiGroup.GET("/reviews/total-due-today",
@@ -663,11 +1006,15 @@ func main() {
 			}
 
 			// Per-course AI routes (reuse courseId param)
+			// Per-course permissions can also come from the caller's role in
+			// the course's organization
+			courseOrg := middleware.OrgFromCourse(permService, "courseId")
+
 			aiCourses := auth.Group("/courses/:courseId/ai")
 			{
 				// -- Phase 1: Heatmap --------------------------------------------------
 				aiCourses.GET("/heatmap",
-					middleware.RequirePermission(permService, "ANALYTICS_VIEW"),
+					middleware.RequirePermission(permService, "ANALYTICS_VIEW", courseOrg),
 					aiHandler.GetClassHeatmap)
 
 				aiCourses.GET("/my-heatmap",
@@ -675,7 +1022,7 @@ func main() {
 
 				// -- Knowledge Graph ---------------------------------------------------
 				aiCourses.POST("/nodes",
-					middleware.RequirePermission(permService, "AI_INDEX"),
+					middleware.RequirePermission(permService, "AI_INDEX", courseOrg),
 					aiHandler.CreateKnowledgeNode)
 
 				aiCourses.GET("/nodes",
@@ -683,11 +1030,11 @@ func main() {
 
 				// -- Phase 2: Quiz Generation ------------------------------------------
 				aiCourses.POST("/generate-quiz",
-					middleware.RequirePermission(permService, "AI_GENERATE"),
-					aiHandler.GenerateQuiz)
+					middleware.RequirePermission(permService, "AI_GENERATE", courseOrg),
+					aiGenerationLimit, aiHandler.GenerateQuiz)
 
 				aiCourses.GET("/drafts",
-					middleware.RequirePermission(permService, "AI_GENERATE"),
+					middleware.RequirePermission(permService, "AI_GENERATE", courseOrg),
 					aiHandler.ListDraftQuestions)
 
 				// -- Phase 2: Spaced Repetition ----------------------------------------
@@ -704,26 +1051,26 @@ func main() {
 
 				// "Compact Graph" - teacher-triggered intelligent node consolidation.
 				aiCourses.GET("/consolidate-graph/preview",
-					middleware.RequirePermission(permService, "AI_INDEX"),
+					middleware.RequirePermission(permService, "AI_INDEX", courseOrg),
 					aiHandler.PreviewGraphConsolidation)
 				aiCourses.POST("/consolidate-graph",
-					middleware.RequirePermission(permService, "AI_INDEX"),
-					aiHandler.ConsolidateGraph)
+					middleware.RequirePermission(permService, "AI_INDEX", courseOrg),
+					aiGenerationLimit, aiHandler.ConsolidateGraph)
 
 				aiCourses.GET("/nodes/:nodeId/chunks", aiHandler.GetNodeChunks)
 				aiCourses.DELETE("/nodes/:nodeId", aiHandler.DeleteKnowledgeNode)
 
 				// -- Graph Teacher Tools ---------------------------------------
 				aiCourses.POST("/link-isolated",
-					middleware.RequirePermission(permService, "AI_INDEX"),
-					aiHandler.LinkIsolatedNodes)
+					middleware.RequirePermission(permService, "AI_INDEX", courseOrg),
+					aiGenerationLimit, aiHandler.LinkIsolatedNodes)
 				aiCourses.GET("/link-isolated/status",
 					aiHandler.GetLinkIsolatedStatus)
 				aiCourses.POST("/graph/edge",
-					middleware.RequirePermission(permService, "AI_INDEX"),
+					middleware.RequirePermission(permService, "AI_INDEX", courseOrg),
 					aiHandler.UpsertGraphEdge)
 				aiCourses.DELETE("/graph/edge",
-					middleware.RequirePermission(permService, "AI_INDEX"),
+					middleware.RequirePermission(permService, "AI_INDEX", courseOrg),
 					aiHandler.DeleteGraphEdge)
 			}
 
@@ -742,9 +1089,9 @@ func main() {
 			// -- Micro-Lessons (Teacher / Admin) ---------------------------
 			// Per-course generation triggers + job listing.
 			microPerCourse := auth.Group("/courses/:courseId/micro-lessons")
-			microPerCourse.Use(middleware.RequirePermission(permService, "AI_GENERATE"))
+			microPerCourse.Use(middleware.RequirePermission(permService, "AI_GENERATE", courseOrg))
 			{
-				microPerCourse.POST("/generate", microLessonHandler.GenerateMicroLessons)
+				microPerCourse.POST("/generate", aiGenerationLimit, microLessonHandler.GenerateMicroLessons)
 				microPerCourse.GET("/jobs", microLessonHandler.ListJobs)
 			}
 
@@ -760,9 +1107,9 @@ func main() {
 
 			// -- Micro-Quizzes (Teacher / Admin) ---------------------------
 			microQuizPerCourse := auth.Group("/courses/:courseId/micro-quizzes")
-			microQuizPerCourse.Use(middleware.RequirePermission(permService, "AI_GENERATE"))
+			microQuizPerCourse.Use(middleware.RequirePermission(permService, "AI_GENERATE", courseOrg))
 			{
-				microQuizPerCourse.POST("/generate", microQuizHandler.GenerateMicroQuizzes)
+				microQuizPerCourse.POST("/generate", aiGenerationLimit, microQuizHandler.GenerateMicroQuizzes)
 				microQuizPerCourse.GET("/jobs", microQuizHandler.ListJobs)
 			}
 
@@ -778,9 +1125,9 @@ func main() {
 			// -- Section Overview (Teacher / Admin) ------------------------
 			// Per-section trigger and job listing routes.
 			sectionOverviewPerSection := auth.Group("/courses/:courseId/sections/:sectionId")
-			sectionOverviewPerSection.Use(middleware.RequirePermission(permService, "AI_GENERATE"))
+			sectionOverviewPerSection.Use(middleware.RequirePermission(permService, "AI_GENERATE", courseOrg))
 			{
-				sectionOverviewPerSection.POST("/overview/generate", sectionOverviewHandler.GenerateOverview)
+				sectionOverviewPerSection.POST("/overview/generate", aiGenerationLimit, sectionOverviewHandler.GenerateOverview)
 				sectionOverviewPerSection.GET("/overview/jobs", sectionOverviewHandler.ListJobs)
 			}
 
@@ -818,7 +1165,7 @@ func main() {
 		// Authenticated via shared service secret only - never reachable
 		// with user JWTs because the path lives outside the auth group.
 		internalAI := v1.Group("/internal")
-		internalAI.Use(middleware.ServiceOrAuthMiddleware(cfg.JWT.Secret, cfg.AIConf.Secret))
+		internalAI.Use(middleware.ServiceOrAuthMiddleware(tokenVerifier, serviceAuth, middleware.ScopeInternal))
 		{
 			internalAI.GET("/sections/:sectionId/contents", courseHandler.InternalGetSectionContents)
 			internalAI.GET("/contents/:contentId/hierarchy", courseHandler.InternalGetContentHierarchy)
@@ -828,14 +1175,14 @@ func main() {
 		}
 
 		internal := v1.Group("/internal/micro-lessons")
-		internal.Use(middleware.ServiceOrAuthMiddleware(cfg.JWT.Secret, cfg.AIConf.Secret))
+		internal.Use(middleware.ServiceOrAuthMiddleware(tokenVerifier, serviceAuth, middleware.ScopeInternal))
 		{
 			internal.POST("/status", microLessonHandler.CallbackStatus)
 			internal.POST("/lessons", microLessonHandler.CallbackLessons)
 		}
 
 		internalQuiz := v1.Group("/internal/micro-quizzes")
-		internalQuiz.Use(middleware.ServiceOrAuthMiddleware(cfg.JWT.Secret, cfg.AIConf.Secret))
+		internalQuiz.Use(middleware.ServiceOrAuthMiddleware(tokenVerifier, serviceAuth, middleware.ScopeInternal))
 		{
 			internalQuiz.POST("/status", microQuizHandler.CallbackStatus)
 			internalQuiz.POST("/quizzes", microQuizHandler.CallbackQuizzes)
@@ -843,7 +1190,7 @@ func main() {
 
 		// -- Section Overview internal callbacks (AI service -> LMS) -----
 		internalOverview := v1.Group("/internal/section-overview")
-		internalOverview.Use(middleware.ServiceOrAuthMiddleware(cfg.JWT.Secret, cfg.AIConf.Secret))
+		internalOverview.Use(middleware.ServiceOrAuthMiddleware(tokenVerifier, serviceAuth, middleware.ScopeInternal))
 		{
 			internalOverview.POST("/status", sectionOverviewHandler.CallbackStatus)
 			internalOverview.POST("/results", sectionOverviewHandler.CallbackResults)
@@ -878,6 +1225,15 @@ func main() {
 		}
 	}()
 
+	// Prometheus scrapes its own port, which the gateway does not route to
+	metricsSrv := telemetry.NewMetricsServer(":" + cfg.Telemetry.MetricsPort)
+	go func() {
+		logger.Info(fmt.Sprintf("Serving metrics on port %s", cfg.Telemetry.MetricsPort))
+		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Metrics server failed", err)
+		}
+	}()
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -891,6 +1247,7 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Fatal("Server forced to shutdown", err)
 	}
+	_ = metricsSrv.Shutdown(ctx)
 
 	logger.Info("Server exited")
 }
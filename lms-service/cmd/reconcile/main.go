@@ -0,0 +1,87 @@
+// Command reconcile repairs users, organizations and memberships that
+// drifted from the auth service, e.g. because lifecycle events were lost. It
+// pages through the auth service's snapshot API and applies every entity
+// whose version is not older than the one LMS holds.
+//
+// Usage:
+//
+//	go run ./cmd/reconcile [-page-size 500] [-prune]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"example/hello/internal/config"
+	"example/hello/internal/repository"
+	"example/hello/internal/service"
+	"example/hello/pkg/authsync"
+	"example/hello/pkg/cache"
+	"example/hello/pkg/database"
+	"example/hello/pkg/logger"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	pageSize := flag.Int("page-size", cfg.AuthSync.PageSize, "entities fetched per snapshot page")
+	prune := flag.Bool("prune", false, "remove synced users, organizations and memberships missing from the snapshot")
+	flag.Parse()
+
+	logger.Init(cfg.App.Env)
+
+	if cfg.AuthSync.SigningSecret == "" {
+		log.Fatal("AUTH_SYNC_SIGNING_SECRET (or LMS_SYNC_SECRET) is required to call the auth service")
+	}
+
+	db, err := database.NewPostgresDB(cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", err)
+	}
+	defer db.Close()
+
+	// Without Redis the run still succeeds; cached roles expire on their own
+	redisClient, err := cache.NewRedisClient(cfg.Redis)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Redis unavailable, cached roles will not be invalidated: %v", err))
+		redisClient = nil
+	} else {
+		defer redisClient.Close()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	syncService := service.NewUserSyncService(
+		repository.NewUserRepository(db),
+		repository.NewSyncVersionRepository(db),
+		redisClient,
+		nil, // repairing drift does not auto-join anyone by email domain
+	)
+	client := authsync.NewClient(cfg.AuthSync.ServiceURL, cfg.AuthSync.ServiceName, cfg.AuthSync.SigningSecret)
+
+	report, err := syncService.Reconcile(ctx, client, service.ReconcileOptions{
+		PageSize: *pageSize,
+		Prune:    *prune,
+	})
+	if report != nil {
+		fmt.Printf("users:   %d seen, %d applied, %d pruned\n", report.UsersSeen, report.UsersApplied, report.UsersPruned)
+		fmt.Printf("orgs:    %d seen, %d applied, %d pruned\n", report.OrgsSeen, report.OrgsApplied, report.OrgsPruned)
+		fmt.Printf("members: %d seen, %d applied, %d pruned\n", report.MembersSeen, report.MembersApplied, report.MembersPruned)
+		fmt.Printf("failed:  %d\n", report.Failed)
+	}
+	if err != nil {
+		logger.Fatal("Reconcile aborted", err)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -22,6 +22,14 @@ type Config struct {
 	Server   ServerConfig
 	Email    EmailConfig
 	AIConf	 AIConfig
+	Forum    ForumConfig
+	OrgJoin  OrgJoinConfig
+	Outbox   OutboxConfig
+	Consumer ConsumerConfig
+	ServiceAuth ServiceAuthConfig
+	AuthSync AuthSyncConfig
+	Telemetry TelemetryConfig
+	RateLimit RateLimitConfig
 }
 
 // AppConfig holds application-specific configuration
@@ -66,10 +74,71 @@ type RedisConfig struct {
 	PoolTimeout  time.Duration
 }
 
-// JWTConfig holds JWT configuration
+// JWTConfig holds JWT configuration. Tokens are verified against the auth
+// service's JWKS when JWKSURL is set; the shared Secret keeps HMAC tokens
+// working until HMACUntil (RFC 3339, empty = no cutoff) or until HMACEnabled
+// is switched off.
 type JWTConfig struct {
-	Secret          string
-	ExpirationHours int
+	Secret              string
+	ExpirationHours     int
+	JWKSURL             string
+	JWKSRefreshInterval time.Duration
+	HMACEnabled         bool
+	HMACUntil           string
+}
+
+// ServiceAuthConfig lists the internal services allowed to call LMS. Each
+// client signs requests with its own secret and may only reach the route
+// groups named in its scopes. AllowLegacy re-admits the old static
+// X-API-Secret/X-Sync-Secret headers for a caller that cannot sign yet;
+// every in-repo caller signs, so it is off unless set.
+type ServiceAuthConfig struct {
+	Clients     []ServiceClientConfig
+	MaxSkew     time.Duration
+	AllowLegacy bool
+}
+
+// AuthSyncConfig controls how users and organizations are kept in step with
+// the auth service: lifecycle events from Kafka, plus a snapshot API that
+// cmd/reconcile pages through to repair drift
+type AuthSyncConfig struct {
+	ConsumerEnabled bool
+	ServiceURL      string
+	ServiceName     string // name LMS signs snapshot requests as
+	SigningSecret   string
+	PageSize        int
+}
+
+// TelemetryConfig controls trace export and the metrics listener. The
+// collector endpoint is read by the exporter from the standard
+// OTEL_EXPORTER_OTLP_ENDPOINT variable. MetricsPort serves /metrics apart
+// from the API and must stay cluster-internal.
+type TelemetryConfig struct {
+	TracingEnabled bool
+	SampleRatio    float64
+	MetricsPort    string
+}
+
+// RateLimitConfig holds the rate limit rules as written in the environment;
+// pkg/ratelimit parses them. A rule is "limit/period[:burst]" or "unlimited";
+// a policy is a comma-separated list of "default=", "<ROLE>=" and
+// "org:<id>=" rules. Bypass lists replace the old private-IP exemption.
+type RateLimitConfig struct {
+	Enabled        bool
+	Global         string // per client IP, on every request
+	AIGeneration   string
+	Upload         string
+	QuizSubmit     string
+	BypassNetworks []string // CIDRs or single IPs
+	BypassUsers    []string // user IDs
+	BypassServices []string // verified internal callers
+}
+
+// ServiceClientConfig is one calling service
+type ServiceClientConfig struct {
+	Name   string
+	Secret string
+	Scopes []string
 }
 
 // UploadConfig holds file upload configuration
@@ -122,6 +191,50 @@ type AIConfig struct {
 	Secret		string
 }
 
+// ForumConfig holds forum moderation settings. Word and domain lists are
+// comma-separated in the environment.
+type ForumConfig struct {
+	BannedWords         []string
+	BlockedLinkDomains  []string
+	MaxLinksPerPost     int
+	MaxLinksPerDomain   int
+	AutoHideReportCount int // distinct reports that hide a post/comment pending review
+	MaxBanDuration      time.Duration
+	DigestEnabled       bool
+	DigestHourUTC       int // hour of day the daily digest is produced
+	// Attachments uploaded to posts/comments
+	AttachmentMaxSize     int64    // bytes per file
+	AttachmentCourseQuota int64    // total bytes per course
+	AttachmentExtensions  []string // allowed extensions, without the dot
+}
+
+// OrgJoinConfig holds settings for organization invitations
+type OrgJoinConfig struct {
+	InviteTTL       time.Duration // how long an invitation can be accepted
+	InviteAcceptURL string        // frontend page the invitation email links to; the token is appended as ?token=
+}
+
+// OutboxConfig controls the relay that publishes outbox events to Kafka
+type OutboxConfig struct {
+	Publisher    string // kafka, or memory to run without a broker
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int // failed publishes before an event is marked DEAD
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Retention    time.Duration // how long PUBLISHED events are kept
+}
+
+// ConsumerConfig controls retries, dead-lettering and deduplication for
+// Kafka consumers
+type ConsumerConfig struct {
+	MaxRetries      int // retries after the first attempt before dead-lettering
+	BaseBackoff     time.Duration
+	MaxBackoff      time.Duration
+	LedgerRetention time.Duration // how long processed event IDs are remembered
+	MaxHealthyLag   int64         // lag above which /health reports degraded
+}
+
 func LoadStorageConfig() StorageConfig {
 	storageType := getEnv("STORAGE_TYPE", "local")
 	
@@ -190,6 +303,10 @@ func Load() (*Config, error) {
 		JWT: JWTConfig{
 			Secret:          getEnv("JWT_SECRET", "very_secret_key_change_me_please"),
 			ExpirationHours: getEnvAsInt("JWT_EXPIRATION_HOURS", 1),
+			JWKSURL:             getEnv("JWT_JWKS_URL", ""),
+			JWKSRefreshInterval: getEnvAsDuration("JWT_JWKS_REFRESH_INTERVAL", 15*time.Minute),
+			HMACEnabled:         getEnvAsBool("JWT_HMAC_ENABLED", true),
+			HMACUntil:           getEnv("JWT_HMAC_UNTIL", ""),
 		},
 
 		Upload: UploadConfig{
@@ -242,6 +359,75 @@ func Load() (*Config, error) {
 			Secret: 	getEnv("AI_SERVICE_SECRET", "None"),
 		},
 
+		Forum: ForumConfig{
+			BannedWords:           getEnvAsSlice("FORUM_BANNED_WORDS", []string{}),
+			BlockedLinkDomains:    getEnvAsSlice("FORUM_BLOCKED_LINK_DOMAINS", []string{}),
+			MaxLinksPerPost:       getEnvAsInt("FORUM_MAX_LINKS_PER_POST", 5),
+			MaxLinksPerDomain:     getEnvAsInt("FORUM_MAX_LINKS_PER_DOMAIN", 3),
+			AutoHideReportCount:   getEnvAsInt("FORUM_AUTO_HIDE_REPORT_COUNT", 3),
+			MaxBanDuration:        getEnvAsDuration("FORUM_MAX_BAN_DURATION", 30*24*time.Hour),
+			DigestEnabled:         getEnv("FORUM_DIGEST_ENABLED", "true") == "true",
+			DigestHourUTC:         getEnvAsInt("FORUM_DIGEST_HOUR_UTC", 1),
+			AttachmentMaxSize:     getEnvAsInt64("FORUM_ATTACHMENT_MAX_SIZE", 10485760),       // 10MB
+			AttachmentCourseQuota: getEnvAsInt64("FORUM_ATTACHMENT_COURSE_QUOTA", 1073741824), // 1GB
+			AttachmentExtensions: getEnvAsSlice("FORUM_ATTACHMENT_EXTENSIONS",
+				[]string{"png", "jpg", "jpeg", "gif", "webp", "pdf", "txt", "csv", "zip", "ipynb", "py", "docx", "xlsx", "pptx"}),
+		},
+
+		OrgJoin: OrgJoinConfig{
+			InviteTTL:       getEnvAsDuration("ORG_INVITE_TTL", 7*24*time.Hour),
+			InviteAcceptURL: getEnv("ORG_INVITE_ACCEPT_URL", "http://localhost:3000/organizations/invitations/accept"),
+		},
+
+		Outbox: OutboxConfig{
+			Publisher:    getEnv("OUTBOX_PUBLISHER", "kafka"),
+			PollInterval: getEnvAsDuration("OUTBOX_POLL_INTERVAL", time.Second),
+			BatchSize:    getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+			MaxAttempts:  getEnvAsInt("OUTBOX_MAX_ATTEMPTS", 12),
+			BaseBackoff:  getEnvAsDuration("OUTBOX_BASE_BACKOFF", time.Second),
+			MaxBackoff:   getEnvAsDuration("OUTBOX_MAX_BACKOFF", 10*time.Minute),
+			Retention:    getEnvAsDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+		},
+
+		Consumer: ConsumerConfig{
+			MaxRetries:      getEnvAsInt("CONSUMER_MAX_RETRIES", 5),
+			BaseBackoff:     getEnvAsDuration("CONSUMER_BASE_BACKOFF", 500*time.Millisecond),
+			MaxBackoff:      getEnvAsDuration("CONSUMER_MAX_BACKOFF", 30*time.Second),
+			LedgerRetention: getEnvAsDuration("CONSUMER_LEDGER_RETENTION", 30*24*time.Hour),
+			MaxHealthyLag:   getEnvAsInt64("CONSUMER_MAX_HEALTHY_LAG", 10000),
+		},
+
+		ServiceAuth: ServiceAuthConfig{
+			Clients:     loadServiceClients(),
+			MaxSkew:     getEnvAsDuration("SERVICE_AUTH_MAX_SKEW", 5*time.Minute),
+			AllowLegacy: getEnvAsBool("SERVICE_AUTH_ALLOW_LEGACY", false),
+		},
+
+		AuthSync: AuthSyncConfig{
+			ConsumerEnabled: getEnvAsBool("AUTH_SYNC_CONSUMER_ENABLED", true),
+			ServiceURL:      getEnv("AUTH_SERVICE_URL", "http://localhost:8080"),
+			ServiceName:     getEnv("AUTH_SYNC_SERVICE_NAME", "lms-service"),
+			SigningSecret:   getEnv("AUTH_SYNC_SIGNING_SECRET", getEnv("LMS_SYNC_SECRET", "")),
+			PageSize:        getEnvAsInt("AUTH_SYNC_PAGE_SIZE", 500),
+		},
+
+		Telemetry: TelemetryConfig{
+			TracingEnabled: getEnvAsBool("OTEL_TRACING_ENABLED", false),
+			SampleRatio:    getEnvAsFloat("OTEL_TRACES_SAMPLE_RATIO", 0.1),
+			MetricsPort:    getEnv("METRICS_PORT", "9464"),
+		},
+
+		RateLimit: RateLimitConfig{
+			Enabled:        getEnvAsBool("RATE_LIMIT_ENABLED", true),
+			Global:         getEnv("RATE_LIMIT_GLOBAL", "1000/1m"),
+			AIGeneration:   getEnv("RATE_LIMIT_AI_GENERATION", "default=30/1h:10,TEACHER=120/1h:20,ADMIN=unlimited"),
+			Upload:         getEnv("RATE_LIMIT_UPLOAD", "default=60/1h:20,TEACHER=300/1h:50,ADMIN=unlimited"),
+			QuizSubmit:     getEnv("RATE_LIMIT_QUIZ_SUBMIT", "default=30/1m:10"),
+			BypassNetworks: getEnvAsSlice("RATE_LIMIT_BYPASS_NETWORKS", []string{}),
+			BypassUsers:    getEnvAsSlice("RATE_LIMIT_BYPASS_USERS", []string{}),
+			BypassServices: getEnvAsSlice("RATE_LIMIT_BYPASS_SERVICES", []string{"ai-service"}),
+		},
+
 		Storage: LoadStorageConfig(),
 	}
 
@@ -264,17 +450,87 @@ func (c *Config) Validate() error {
 	if c.Database.User == "" {
 		return fmt.Errorf("database user is required")
 	}
-	if c.JWT.Secret == "" || c.JWT.Secret == "very_secret_key_change_me_please" {
+	if c.JWT.HMACEnabled {
+		if c.JWT.Secret == "" || c.JWT.Secret == "very_secret_key_change_me_please" {
+			if c.App.Env == "production" {
+				return fmt.Errorf("JWT secret must be set in production")
+			}
+		}
+		if len(c.JWT.Secret) < 32 {
+			return fmt.Errorf("JWT secret must be at least 32 characters")
+		}
+	} else if c.JWT.JWKSURL == "" {
+		return fmt.Errorf("JWT_JWKS_URL is required when JWT_HMAC_ENABLED=false")
+	}
+	if _, err := c.JWT.HMACDeadline(); err != nil {
+		return err
+	}
+	for _, client := range c.ServiceAuth.Clients {
+		if client.Secret == "" {
+			return fmt.Errorf("service client %q has no secret", client.Name)
+		}
+	}
+	switch c.Outbox.Publisher {
+	case "kafka":
+	case "memory":
+		// Events would be dropped on the floor instead of reaching consumers
 		if c.App.Env == "production" {
-			return fmt.Errorf("JWT secret must be set in production")
+			return fmt.Errorf("OUTBOX_PUBLISHER=memory is not allowed in production")
 		}
+	default:
+		return fmt.Errorf("OUTBOX_PUBLISHER must be kafka or memory")
 	}
-	if len(c.JWT.Secret) < 32 {
-		return fmt.Errorf("JWT secret must be at least 32 characters")
+	if c.Telemetry.SampleRatio < 0 || c.Telemetry.SampleRatio > 1 {
+		return fmt.Errorf("OTEL_TRACES_SAMPLE_RATIO must be between 0 and 1")
 	}
 	return nil
 }
 
+// loadServiceClients reads SERVICE_AUTH_CLIENTS (comma-separated names) and
+// SERVICE_AUTH_<NAME>_SECRET / SERVICE_AUTH_<NAME>_SCOPES for each. Without
+// it, the AI service and auth service keep their existing secrets.
+func loadServiceClients() []ServiceClientConfig {
+	names := getEnvAsSlice("SERVICE_AUTH_CLIENTS", nil)
+	if len(names) == 0 {
+		var clients []ServiceClientConfig
+		if secret := getEnv("AI_SERVICE_SECRET", ""); secret != "" && secret != "None" {
+			clients = append(clients, ServiceClientConfig{
+				Name:   "ai-service",
+				Secret: secret,
+				Scopes: []string{"files", "courses", "enrollments", "internal", "act-as-user"},
+			})
+		}
+		if secret := getEnv("LMS_SYNC_SECRET", ""); secret != "" {
+			clients = append(clients, ServiceClientConfig{Name: "auth-service", Secret: secret, Scopes: []string{"sync"}})
+		}
+		return clients
+	}
+
+	clients := make([]ServiceClientConfig, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		prefix := "SERVICE_AUTH_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		clients = append(clients, ServiceClientConfig{
+			Name:   name,
+			Secret: getEnv(prefix+"_SECRET", ""),
+			Scopes: getEnvAsSlice(prefix+"_SCOPES", nil),
+		})
+	}
+	return clients
+}
+
+// HMACDeadline parses HMACUntil; the zero time means no cutoff
+func (j JWTConfig) HMACDeadline() (time.Time, error) {
+	if j.HMACUntil == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, j.HMACUntil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("JWT_HMAC_UNTIL must be an RFC 3339 timestamp: %w", err)
+	}
+	return t, nil
+}
+
 // IsDevelopment returns true if running in development mode
 func (c *Config) IsDevelopment() bool {
 	return c.App.Env == "development"
@@ -328,6 +584,14 @@ func getEnvAsInt64(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	valueStr := getEnv(key, "")
 	if value, err := strconv.ParseBool(valueStr); err == nil {
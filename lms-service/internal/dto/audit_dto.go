@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ListAuditLogRequest represents audit log filters. From and To are RFC 3339
+// timestamps; To is exclusive.
+type ListAuditLogRequest struct {
+	ActorID    int64     `form:"actor_id" binding:"omitempty,min=1"`
+	Action     string    `form:"action"`
+	TargetType string    `form:"target_type"`
+	TargetID   string    `form:"target_id"`
+	OrgID      int64     `form:"org_id" binding:"omitempty,min=1"`
+	CourseID   int64     `form:"course_id" binding:"omitempty,min=1"`
+	RequestID  string    `form:"request_id"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page       int       `form:"page" binding:"omitempty,min=1"`
+	Limit      int       `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// AuditEntryResponse represents one audit log entry
+type AuditEntryResponse struct {
+	ID           int64           `json:"id"`
+	OccurredAt   time.Time       `json:"occurred_at"`
+	ActorID      *int64          `json:"actor_id,omitempty"`
+	ActorRole    string          `json:"actor_role,omitempty"`
+	ActorService string          `json:"actor_service,omitempty"`
+	Action       string          `json:"action"`
+	TargetType   string          `json:"target_type"`
+	TargetID     string          `json:"target_id"`
+	OrgID        *int64          `json:"org_id,omitempty"`
+	CourseID     *int64          `json:"course_id,omitempty"`
+	Reason       *string         `json:"reason,omitempty"`
+	Before       json.RawMessage `json:"before,omitempty"`
+	After        json.RawMessage `json:"after,omitempty"`
+	RequestID    string          `json:"request_id,omitempty"`
+	IPAddress    string          `json:"ip_address,omitempty"`
+	UserAgent    string          `json:"user_agent,omitempty"`
+}
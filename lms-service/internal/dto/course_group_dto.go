@@ -0,0 +1,44 @@
+package dto
+
+import "time"
+
+// CreateCourseGroupRequest creates a group, optionally with its first members
+type CreateCourseGroupRequest struct {
+	Name      string  `json:"name" binding:"required,min=2,max=120"`
+	MemberIDs []int64 `json:"member_ids" binding:"omitempty,max=500"`
+}
+
+// UpdateCourseGroupRequest renames a group
+type UpdateCourseGroupRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=120"`
+}
+
+// CourseGroupMembersRequest lists students to add to or remove from a group
+type CourseGroupMembersRequest struct {
+	UserIDs []int64 `json:"user_ids" binding:"required,min=1,max=500"`
+}
+
+type CourseGroupResponse struct {
+	ID          int64     `json:"id"`
+	CourseID    int64     `json:"course_id"`
+	Name        string    `json:"name"`
+	CreatedBy   int64     `json:"created_by"`
+	MemberCount int       `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CourseGroupMemberResponse struct {
+	UserID    int64     `json:"user_id"`
+	FullName  string    `json:"full_name"`
+	Email     string    `json:"email"`
+	AvatarURL string    `json:"avatar_url,omitempty"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// CourseGroupMembersResponse reports which of the requested students changed.
+// Students without an accepted enrollment are never added.
+type CourseGroupMembersResponse struct {
+	Changed []int64 `json:"changed"`
+	Skipped []int64 `json:"skipped"`
+}
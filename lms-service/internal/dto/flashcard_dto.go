@@ -59,3 +59,120 @@ type ReviewFlashcardResponse struct {
 	Repetitions    int        `json:"repetitions"`
 	NextReviewDate time.Time  `json:"next_review_date"`
 }
+
+// Deck import / export
+
+// Flashcard deck file formats
+const (
+	FlashcardFormatAPKG = "apkg"
+	FlashcardFormatCSV  = "csv"
+)
+
+// Flashcard export scopes
+const (
+	FlashcardExportScopeMine   = "mine"
+	FlashcardExportScopeCourse = "course"
+)
+
+// ExportFlashcardsQuery selects which flashcards go into an exported deck
+type ExportFlashcardsQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=apkg csv"`
+	Scope  string `form:"scope" binding:"omitempty,oneof=mine course"`
+	NodeID *int64 `form:"nodeId"`
+}
+
+// ImportFlashcardsResponse summarises a deck import into a course node
+type ImportFlashcardsResponse struct {
+	Format      string `json:"format"`
+	Total       int    `json:"total"`
+	Imported    int    `json:"imported"`
+	Skipped     int    `json:"skipped"`
+	WithHistory int    `json:"with_history"`
+}
+
+// Shared decks (teacher-authored)
+
+// CreateFlashcardDeckRequest creates a course-level deck
+type CreateFlashcardDeckRequest struct {
+	Title       string                   `json:"title" binding:"required,max=255"`
+	Description string                   `json:"description"`
+	NodeID      *int64                   `json:"node_id,omitempty"`
+	Cards       []FlashcardDeckCardInput `json:"cards,omitempty" binding:"omitempty,max=500,dive"`
+}
+
+// UpdateFlashcardDeckRequest updates deck metadata
+type UpdateFlashcardDeckRequest struct {
+	Title       *string `json:"title,omitempty" binding:"omitempty,max=255"`
+	Description *string `json:"description,omitempty"`
+	NodeID      *int64  `json:"node_id,omitempty"`
+}
+
+// FlashcardDeckCardInput is a single card in a deck create/add request
+type FlashcardDeckCardInput struct {
+	FrontText string `json:"front_text" binding:"required"`
+	BackText  string `json:"back_text" binding:"required"`
+}
+
+// AddFlashcardDeckCardsRequest appends cards to a deck
+type AddFlashcardDeckCardsRequest struct {
+	Cards []FlashcardDeckCardInput `json:"cards" binding:"required,min=1,max=500,dive"`
+}
+
+// UpdateFlashcardDeckCardRequest edits a card. ResetProgress drops every
+// student's schedule for the card (use when the answer itself changed).
+type UpdateFlashcardDeckCardRequest struct {
+	FrontText     *string `json:"front_text,omitempty"`
+	BackText      *string `json:"back_text,omitempty"`
+	ResetProgress bool    `json:"reset_progress"`
+}
+
+// PublishFlashcardDeckRequest publishes a deck with an optional changelog note
+type PublishFlashcardDeckRequest struct {
+	Summary string `json:"summary"`
+}
+
+// FlashcardDeckResponse represents a shared deck
+type FlashcardDeckResponse struct {
+	ID          int64                       `json:"id"`
+	CourseID    int64                       `json:"course_id"`
+	NodeID      *int64                      `json:"node_id,omitempty"`
+	Title       string                      `json:"title"`
+	Description string                      `json:"description,omitempty"`
+	Status      string                      `json:"status"`
+	Version     int                         `json:"version"`
+	CardCount   int                         `json:"card_count"`
+	CreatedBy   int64                       `json:"created_by"`
+	PublishedAt *time.Time                  `json:"published_at,omitempty"`
+	CreatedAt   time.Time                   `json:"created_at"`
+	UpdatedAt   time.Time                   `json:"updated_at"`
+	Cards       []FlashcardDeckCardResponse `json:"cards,omitempty"`
+}
+
+// FlashcardDeckCardResponse is a deck card with the caller's SM-2 state
+type FlashcardDeckCardResponse struct {
+	ID               int64  `json:"id"`
+	DeckID           int64  `json:"deck_id"`
+	DeckTitle        string `json:"deck_title,omitempty"`
+	FrontText        string `json:"front_text"`
+	BackText         string `json:"back_text"`
+	OrderIndex       int    `json:"order_index"`
+	Revision         int    `json:"revision"`
+	UpdatedInVersion int    `json:"updated_in_version"`
+	IsNew            bool   `json:"is_new"`
+	// UpdatedSinceReview is true when the teacher edited the card after the
+	// student last reviewed it.
+	UpdatedSinceReview bool       `json:"updated_since_review"`
+	EasinessFactor     *float64   `json:"easiness_factor,omitempty"`
+	IntervalDays       *int       `json:"interval_days,omitempty"`
+	Repetitions        *int       `json:"repetitions,omitempty"`
+	NextReviewDate     *time.Time `json:"next_review_date,omitempty"`
+	LastReviewedAt     *time.Time `json:"last_reviewed_at,omitempty"`
+}
+
+// FlashcardDeckVersionResponse is a deck changelog entry
+type FlashcardDeckVersionResponse struct {
+	Version   int       `json:"version"`
+	Summary   string    `json:"summary"`
+	ChangedBy *int64    `json:"changed_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -8,38 +8,47 @@ import "time"
 
 // CreateForumPostRequest represents the request to create a forum post
 type CreateForumPostRequest struct {
-	Title string   `json:"title" binding:"required,min=5,max=255"`
-	Body  string   `json:"body" binding:"required,min=10"`
-	Tags  []string `json:"tags" binding:"omitempty,max=5,dive,max=50"`
+	Title         string   `json:"title" binding:"required,min=5,max=255"`
+	Body          string   `json:"body" binding:"required,min=10,max=50000"` // Markdown
+	Tags          []string `json:"tags" binding:"omitempty,max=5,dive,max=50"`
+	AttachmentIDs []int64  `json:"attachment_ids" binding:"omitempty,max=10"`
 }
 
 // UpdateForumPostRequest represents the request to update a forum post
 type UpdateForumPostRequest struct {
-	Title *string   `json:"title" binding:"omitempty,min=5,max=255"`
-	Body  *string   `json:"body" binding:"omitempty,min=10"`
-	Tags  *[]string `json:"tags" binding:"omitempty,max=5,dive,max=50"`
+	Title         *string   `json:"title" binding:"omitempty,min=5,max=255"`
+	Body          *string   `json:"body" binding:"omitempty,min=10,max=50000"`
+	Tags          *[]string `json:"tags" binding:"omitempty,max=5,dive,max=50"`
+	AttachmentIDs []int64   `json:"attachment_ids" binding:"omitempty,max=10"` // new uploads to attach
 }
 
 // ForumPostResponse represents the response for a forum post
 type ForumPostResponse struct {
-	ID              int64     `json:"id"`
-	ContentID       int64     `json:"content_id"`
-	UserID          int64     `json:"user_id"`
-	UserName        string    `json:"user_name"`
-	UserEmail       string    `json:"user_email"`
-	Title           string    `json:"title"`
-	Body            string    `json:"body"`
-	Tags            []string  `json:"tags"`
-	Upvotes         int       `json:"upvotes"`
-	Downvotes       int       `json:"downvotes"`
-	Score           int       `json:"score"` // upvotes - downvotes
-	CommentCount    int       `json:"comment_count"`
-	ViewCount       int       `json:"view_count"`
-	IsPinned        bool      `json:"is_pinned"`
-	IsLocked        bool      `json:"is_locked"`
-	CurrentUserVote *string   `json:"current_user_vote,omitempty"` // "upvote", "downvote", or null
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID                 int64                      `json:"id"`
+	ContentID          int64                      `json:"content_id"`
+	UserID             int64                      `json:"user_id"`
+	UserName           string                     `json:"user_name"`
+	UserEmail          string                     `json:"user_email"`
+	Title              string                     `json:"title"`
+	Body               string                     `json:"body"`      // Markdown source
+	BodyHTML           string                     `json:"body_html"` // sanitized HTML rendering of Body
+	Tags               []string                   `json:"tags"`
+	Upvotes            int                        `json:"upvotes"`
+	Downvotes          int                        `json:"downvotes"`
+	Score              int                        `json:"score"` // upvotes - downvotes
+	CommentCount       int                        `json:"comment_count"`
+	ViewCount          int                        `json:"view_count"`
+	IsPinned           bool                       `json:"is_pinned"`
+	IsLocked           bool                       `json:"is_locked"`
+	IsHidden           bool                       `json:"is_hidden"`
+	HiddenReason       *string                    `json:"hidden_reason,omitempty"`
+	CurrentUserVote    *string                    `json:"current_user_vote,omitempty"` // "upvote", "downvote", or null
+	IsUnread           bool                       `json:"is_unread"`                   // never opened by the current user (list only)
+	UnreadCommentCount int                        `json:"unread_comment_count"`        // comments since the user last opened the post (list only)
+	IsSubscribed       *bool                      `json:"is_subscribed,omitempty"`     // set on single-post reads
+	Attachments        []*ForumAttachmentResponse `json:"attachments,omitempty"`
+	CreatedAt          time.Time                  `json:"created_at"`
+	UpdatedAt          time.Time                  `json:"updated_at"`
 }
 
 // ListForumPostsRequest represents query parameters for listing posts
@@ -57,33 +66,39 @@ type ListForumPostsRequest struct {
 
 // CreateForumCommentRequest represents the request to create a comment
 type CreateForumCommentRequest struct {
-	Body            string  `json:"body" binding:"required,min=1"`
+	Body            string  `json:"body" binding:"required,min=1,max=50000"` // Markdown
 	ParentCommentID *int64  `json:"parent_comment_id"`
+	AttachmentIDs   []int64 `json:"attachment_ids" binding:"omitempty,max=10"`
 }
 
 // UpdateForumCommentRequest represents the request to update a comment
 type UpdateForumCommentRequest struct {
-	Body *string `json:"body" binding:"omitempty,min=1"`
+	Body          *string `json:"body" binding:"omitempty,min=1,max=50000"`
+	AttachmentIDs []int64 `json:"attachment_ids" binding:"omitempty,max=10"` // new uploads to attach
 }
 
 // ForumCommentResponse represents the response for a forum comment
 type ForumCommentResponse struct {
-	ID              int64                   `json:"id"`
-	PostID          int64                   `json:"post_id"`
-	ParentCommentID *int64                  `json:"parent_comment_id"`
-	UserID          int64                   `json:"user_id"`
-	UserName        string                  `json:"user_name"`
-	UserEmail       string                  `json:"user_email"`
-	Body            string                  `json:"body"`
-	Upvotes         int                     `json:"upvotes"`
-	Downvotes       int                     `json:"downvotes"`
-	Score           int                     `json:"score"`
-	IsAccepted      bool                    `json:"is_accepted"`
-	Depth           int                     `json:"depth"`
-	CurrentUserVote *string                 `json:"current_user_vote,omitempty"`
-	Replies         []*ForumCommentResponse `json:"replies,omitempty"`
-	CreatedAt       time.Time               `json:"created_at"`
-	UpdatedAt       time.Time               `json:"updated_at"`
+	ID              int64                      `json:"id"`
+	PostID          int64                      `json:"post_id"`
+	ParentCommentID *int64                     `json:"parent_comment_id"`
+	UserID          int64                      `json:"user_id"`
+	UserName        string                     `json:"user_name"`
+	UserEmail       string                     `json:"user_email"`
+	Body            string                     `json:"body"`      // Markdown source
+	BodyHTML        string                     `json:"body_html"` // sanitized HTML rendering of Body
+	Upvotes         int                        `json:"upvotes"`
+	Downvotes       int                        `json:"downvotes"`
+	Score           int                        `json:"score"`
+	IsAccepted      bool                       `json:"is_accepted"`
+	Depth           int                        `json:"depth"`
+	IsHidden        bool                       `json:"is_hidden"`
+	HiddenReason    *string                    `json:"hidden_reason,omitempty"`
+	CurrentUserVote *string                    `json:"current_user_vote,omitempty"`
+	Attachments     []*ForumAttachmentResponse `json:"attachments,omitempty"`
+	Replies         []*ForumCommentResponse    `json:"replies,omitempty"`
+	CreatedAt       time.Time                  `json:"created_at"`
+	UpdatedAt       time.Time                  `json:"updated_at"`
 }
 
 // ============================================
@@ -121,4 +136,164 @@ type LockPostRequest struct {
 // AcceptCommentRequest represents request to accept a comment as answer
 type AcceptCommentRequest struct {
 	IsAccepted bool `json:"is_accepted"`
-}
\ No newline at end of file
+}
+
+// ============================================
+// MODERATION
+// ============================================
+
+// Moderator actions on reported content
+const (
+	ForumModerationDismiss = "DISMISS" // reports were unfounded; lifts an automatic hide
+	ForumModerationHide    = "HIDE"
+	ForumModerationRestore = "RESTORE" // un-hide regardless of who hid it
+	ForumModerationDelete  = "DELETE"
+)
+
+// ReportForumContentRequest represents a user's report of a post or comment
+type ReportForumContentRequest struct {
+	Reason  string `json:"reason" binding:"required,oneof=SPAM HARASSMENT INAPPROPRIATE OFF_TOPIC OTHER"`
+	Details string `json:"details" binding:"omitempty,max=1000"`
+}
+
+// ReportForumContentResponse represents the outcome of a report
+type ReportForumContentResponse struct {
+	Reported        bool `json:"reported"`
+	AlreadyReported bool `json:"already_reported"`
+	IsHidden        bool `json:"is_hidden"`
+}
+
+// ListForumReportsRequest represents query parameters for the moderator queue
+type ListForumReportsRequest struct {
+	CourseID *int64 `form:"course_id"`
+	OrgID    *int64 `form:"org_id"`
+	Status   string `form:"status" binding:"omitempty,oneof=OPEN DISMISSED ACTIONED"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// ForumReportQueueItemResponse is one reported post/comment in the queue
+type ForumReportQueueItemResponse struct {
+	TargetType      string    `json:"target_type"`
+	TargetID        int64     `json:"target_id"`
+	PostID          int64     `json:"post_id"`
+	CourseID        int64     `json:"course_id"`
+	CourseTitle     string    `json:"course_title"`
+	ReportCount     int       `json:"report_count"`
+	Reasons         []string  `json:"reasons"`
+	FirstReportedAt time.Time `json:"first_reported_at"`
+	LastReportedAt  time.Time `json:"last_reported_at"`
+	IsHidden        bool      `json:"is_hidden"`
+	HiddenReason    *string   `json:"hidden_reason,omitempty"`
+	Excerpt         string    `json:"excerpt"`
+	AuthorID        *int64    `json:"author_id,omitempty"`
+	AuthorName      string    `json:"author_name,omitempty"`
+	TargetDeleted   bool      `json:"target_deleted"`
+}
+
+// ForumReportResponse is a single report as shown to moderators
+type ForumReportResponse struct {
+	ID             int64      `json:"id"`
+	ReporterID     int64      `json:"reporter_id"`
+	Reason         string     `json:"reason"`
+	Details        string     `json:"details,omitempty"`
+	Status         string     `json:"status"`
+	ResolvedBy     *int64     `json:"resolved_by,omitempty"`
+	ResolutionNote string     `json:"resolution_note,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ResolveForumReportRequest applies a moderator decision. BanHours, when set,
+// also bans the author from posting in the course for that many hours.
+type ResolveForumReportRequest struct {
+	Action   string `json:"action" binding:"required,oneof=DISMISS HIDE RESTORE DELETE"`
+	Note     string `json:"note" binding:"omitempty,max=1000"`
+	BanHours *int   `json:"ban_hours" binding:"omitempty,min=1"`
+}
+
+// CreateForumBanRequest temporarily bans a user from posting in a course forum
+type CreateForumBanRequest struct {
+	UserID        int64  `json:"user_id" binding:"required"`
+	DurationHours int    `json:"duration_hours" binding:"required,min=1"`
+	Reason        string `json:"reason" binding:"omitempty,max=1000"`
+}
+
+// ForumBanResponse represents an active posting ban
+type ForumBanResponse struct {
+	ID        int64     `json:"id"`
+	CourseID  int64     `json:"course_id"`
+	UserID    int64     `json:"user_id"`
+	UserName  string    `json:"user_name,omitempty"`
+	BannedBy  *int64    `json:"banned_by,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ForumSubscriptionResponse represents a followed forum or thread
+type ForumSubscriptionResponse struct {
+	ID          int64     `json:"id"`
+	TargetType  string    `json:"target_type"` // forum, post
+	TargetID    int64     `json:"target_id"`
+	CourseID    int64     `json:"course_id"`
+	TargetTitle string    `json:"target_title,omitempty"`
+	IsAuto      bool      `json:"is_auto"` // created by posting or commenting
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ListForumNotificationsRequest represents query parameters for the notification feed
+type ListForumNotificationsRequest struct {
+	UnreadOnly bool `form:"unread_only"`
+	Page       int  `form:"page" binding:"omitempty,min=1"`
+	Limit      int  `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// ForumNotificationResponse represents a single in-app forum notification
+type ForumNotificationResponse struct {
+	ID          int64     `json:"id"`
+	Type        string    `json:"type"` // NEW_POST, REPLY, MENTION, ACCEPTED
+	CourseID    int64     `json:"course_id"`
+	CourseTitle string    `json:"course_title"`
+	PostID      int64     `json:"post_id"`
+	PostTitle   string    `json:"post_title"`
+	CommentID   *int64    `json:"comment_id,omitempty"`
+	ActorID     *int64    `json:"actor_id,omitempty"`
+	ActorName   string    `json:"actor_name,omitempty"`
+	Excerpt     string    `json:"excerpt,omitempty"`
+	IsRead      bool      `json:"is_read"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ForumNotificationListResponse is a page of notifications plus the total unread count
+type ForumNotificationListResponse struct {
+	*ListResponse
+	UnreadCount int `json:"unread_count"`
+}
+
+// MarkForumNotificationsReadRequest marks notifications read; empty IDs marks all
+type MarkForumNotificationsReadRequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+// ForumAttachmentResponse represents a file attached to a post or comment.
+// URL requires the caller's session; images can be embedded in Markdown with
+// ![alt](url).
+type ForumAttachmentResponse struct {
+	ID          int64     `json:"id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	IsImage     bool      `json:"is_image"` // served inline
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ForumAttachmentUsageResponse reports a course's attachment quota
+type ForumAttachmentUsageResponse struct {
+	CourseID     int64    `json:"course_id"`
+	UsedBytes    int64    `json:"used_bytes"`
+	QuotaBytes   int64    `json:"quota_bytes"` // 0 means unlimited
+	MaxFileBytes int64    `json:"max_file_bytes"`
+	Extensions   []string `json:"extensions"`
+}
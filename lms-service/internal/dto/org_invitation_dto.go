@@ -0,0 +1,55 @@
+package dto
+
+import "time"
+
+// CreateInvitationRequest invites an email address to an organization.
+// OrgRole defaults to MEMBER.
+type CreateInvitationRequest struct {
+	Email   string `json:"email"    binding:"required,email,max=255"`
+	OrgRole string `json:"org_role" binding:"omitempty,max=50"`
+}
+
+// InvitationResponse represents an organization invitation. Status is
+// PENDING, ACCEPTED, REVOKED or EXPIRED.
+type InvitationResponse struct {
+	ID         int64      `json:"id"`
+	OrgID      int64      `json:"org_id"`
+	Email      string     `json:"email"`
+	OrgRole    string     `json:"org_role"`
+	Status     string     `json:"status"`
+	InvitedBy  *int64     `json:"invited_by,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// AcceptInvitationRequest carries the token from the invitation email
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required,len=64,hexadecimal"`
+}
+
+// CreateJoinCodeRequest creates a join code. Without MaxUses or
+// ExpiresInHours the code works until revoked.
+type CreateJoinCodeRequest struct {
+	OrgRole        string `json:"org_role"         binding:"omitempty,max=50"`
+	MaxUses        *int   `json:"max_uses"         binding:"omitempty,min=1"`
+	ExpiresInHours *int   `json:"expires_in_hours" binding:"omitempty,min=1,max=8760"`
+}
+
+// JoinCodeResponse represents an organization join code
+type JoinCodeResponse struct {
+	ID        int64      `json:"id"`
+	OrgID     int64      `json:"org_id"`
+	Code      string     `json:"code"`
+	OrgRole   string     `json:"org_role"`
+	MaxUses   *int       `json:"max_uses,omitempty"`
+	UseCount  int        `json:"use_count"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Usable    bool       `json:"usable"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// RedeemJoinCodeRequest joins the organization a code belongs to
+type RedeemJoinCodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
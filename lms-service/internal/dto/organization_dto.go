@@ -8,7 +8,11 @@ type OrgSettingsDTO struct {
 	AllowSelfEnrollment        bool   `json:"allow_self_enrollment"`
 	AllowCrossOrgCourses       bool   `json:"allow_cross_org_courses"`
 	DefaultCourseVisibility    string `json:"default_course_visibility" enums:"PUBLIC,ORG_ONLY"`
-	MaxMembers                 *int   `json:"max_members,omitempty"`
+	MaxMembers                 *int   `json:"max_members,omitempty" binding:"omitempty,min=1"`
+	// Users signing in for the first time with an email in one of these
+	// domains join automatically, with DomainJoinRole (MEMBER by default)
+	AllowedEmailDomains        []string `json:"allowed_email_domains,omitempty" binding:"omitempty,max=20,dive,min=3,max=255"`
+	DomainJoinRole             string   `json:"domain_join_role,omitempty" binding:"omitempty,max=50"`
 }
 
 // CreateOrgRequest represents the request to create an organization
@@ -43,15 +47,16 @@ type OrgResponse struct {
 	UpdatedAt   time.Time       `json:"updated_at"`
 }
 
-// AddMemberRequest represents the request to add a member to an organization
+// AddMemberRequest represents the request to add a member to an organization.
+// OrgRole is a template role (OWNER, ADMIN, MEMBER) or one of the organization's own.
 type AddMemberRequest struct {
 	UserID  int64  `json:"user_id"  binding:"required"`
-	OrgRole string `json:"org_role" binding:"required,oneof=OWNER ADMIN MEMBER"`
+	OrgRole string `json:"org_role" binding:"required,max=50"`
 }
 
 // UpdateMemberRoleRequest represents the request to update a member's role
 type UpdateMemberRoleRequest struct {
-	OrgRole string `json:"org_role" binding:"required,oneof=OWNER ADMIN MEMBER"`
+	OrgRole string `json:"org_role" binding:"required,max=50"`
 }
 
 // OrgMemberResponse represents the response for an organization member
@@ -75,7 +80,7 @@ type OrgStatsResponse struct {
 type BulkAddMembersRequest struct {
 	Emails   []string `json:"emails"`
 	RawInput string   `json:"raw_input"`
-	OrgRole  string   `json:"org_role" binding:"required,oneof=OWNER ADMIN MEMBER"`
+	OrgRole  string   `json:"org_role" binding:"required,max=50"`
 }
 
 // BulkAddMembersResponse represents the response for bulk adding members
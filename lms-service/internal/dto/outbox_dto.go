@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ListOutboxEventsRequest represents query parameters for the outbox listing
+type ListOutboxEventsRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=PENDING PUBLISHED DEAD DISCARDED"`
+	Topic  string `form:"topic"`
+	Page   int    `form:"page" binding:"omitempty,min=1"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// ReplayOutboxRequest replays every DEAD event, optionally of one topic
+type ReplayOutboxRequest struct {
+	Topic string `json:"topic"`
+}
+
+// OutboxEventResponse represents an outbox event
+type OutboxEventResponse struct {
+	ID            int64           `json:"id"`
+	Topic         string          `json:"topic"`
+	Key           string          `json:"key"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     *string         `json:"last_error,omitempty"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	PublishedAt   *time.Time      `json:"published_at,omitempty"`
+}
+
+// OutboxStatsResponse summarises the outbox backlog
+type OutboxStatsResponse struct {
+	Pending                 int        `json:"pending"`
+	Dead                    int        `json:"dead"`
+	Published               int        `json:"published"`
+	Discarded               int        `json:"discarded"`
+	OldestPendingAt         *time.Time `json:"oldest_pending_at,omitempty"`
+	OldestPendingAgeSeconds int64      `json:"oldest_pending_age_seconds"`
+}
+
+// ReplayOutboxResponse reports how many events were made due again
+type ReplayOutboxResponse struct {
+	Replayed int64 `json:"replayed"`
+}
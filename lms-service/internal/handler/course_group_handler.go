@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/service"
+	"example/hello/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CourseGroupHandler struct {
+	courseService *service.CourseService
+}
+
+func NewCourseGroupHandler(courseService *service.CourseService) *CourseGroupHandler {
+	return &CourseGroupHandler{
+		courseService: courseService,
+	}
+}
+
+// CreateGroup creates a group of students in a course
+// @Summary Create a course group
+// @Description Create a named group of a course's students (course staff or admin only)
+// @Tags course-groups
+// @Accept json
+// @Produce json
+// @Param courseId path int true "Course ID"
+// @Param request body dto.CreateCourseGroupRequest true "Create group request"
+// @Security BearerAuth
+// @Success 201 {object} dto.CourseGroupResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /courses/{courseId}/groups [post]
+func (h *CourseGroupHandler) CreateGroup(c *gin.Context) {
+	courseID, ok := getCourseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateCourseGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("validation_error", err.Error()))
+		return
+	}
+
+	group, err := h.courseService.CreateGroup(c.Request.Context(), courseID, c.GetInt64("user_id"), getRoleFromContext(c), &req)
+	if err != nil {
+		respondCourseGroupError(c, "Failed to create course group", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewDataResponse(group))
+}
+
+// ListGroups lists the groups of a course
+// @Summary List course groups
+// @Description List a course's groups with their member counts (course staff or admin only)
+// @Tags course-groups
+// @Produce json
+// @Param courseId path int true "Course ID"
+// @Security BearerAuth
+// @Success 200 {array} dto.CourseGroupResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /courses/{courseId}/groups [get]
+func (h *CourseGroupHandler) ListGroups(c *gin.Context) {
+	courseID, ok := getCourseIDParam(c)
+	if !ok {
+		return
+	}
+
+	groups, err := h.courseService.ListGroups(c.Request.Context(), courseID, c.GetInt64("user_id"), getRoleFromContext(c))
+	if err != nil {
+		respondCourseGroupError(c, "Failed to list course groups", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(groups))
+}
+
+// RenameGroup renames a course group
+// @Summary Rename a course group
+// @Description Rename a course group (course staff or admin only)
+// @Tags course-groups
+// @Accept json
+// @Produce json
+// @Param courseId path int true "Course ID"
+// @Param groupId path int true "Group ID"
+// @Param request body dto.UpdateCourseGroupRequest true "Rename group request"
+// @Security BearerAuth
+// @Success 200 {object} dto.CourseGroupResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /courses/{courseId}/groups/{groupId} [put]
+func (h *CourseGroupHandler) RenameGroup(c *gin.Context) {
+	courseID, groupID, ok := parseCourseGroupIDs(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateCourseGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("validation_error", err.Error()))
+		return
+	}
+
+	group, err := h.courseService.RenameGroup(c.Request.Context(), courseID, groupID, c.GetInt64("user_id"), getRoleFromContext(c), &req)
+	if err != nil {
+		respondCourseGroupError(c, "Failed to rename course group", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(group))
+}
+
+// DeleteGroup deletes a course group
+// @Summary Delete a course group
+// @Description Delete a course group and its memberships (course staff or admin only)
+// @Tags course-groups
+// @Produce json
+// @Param courseId path int true "Course ID"
+// @Param groupId path int true "Group ID"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /courses/{courseId}/groups/{groupId} [delete]
+func (h *CourseGroupHandler) DeleteGroup(c *gin.Context) {
+	courseID, groupID, ok := parseCourseGroupIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.courseService.DeleteGroup(c.Request.Context(), courseID, groupID, c.GetInt64("user_id"), getRoleFromContext(c)); err != nil {
+		respondCourseGroupError(c, "Failed to delete course group", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMessageResponse("Course group deleted successfully"))
+}
+
+// ListGroupMembers lists the students in a course group
+// @Summary List course group members
+// @Description List the students in a course group (course staff or admin only)
+// @Tags course-groups
+// @Produce json
+// @Param courseId path int true "Course ID"
+// @Param groupId path int true "Group ID"
+// @Security BearerAuth
+// @Success 200 {array} dto.CourseGroupMemberResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /courses/{courseId}/groups/{groupId}/members [get]
+func (h *CourseGroupHandler) ListGroupMembers(c *gin.Context) {
+	courseID, groupID, ok := parseCourseGroupIDs(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.courseService.ListGroupMembers(c.Request.Context(), courseID, groupID, c.GetInt64("user_id"), getRoleFromContext(c))
+	if err != nil {
+		respondCourseGroupError(c, "Failed to list course group members", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(members))
+}
+
+// AddGroupMembers adds students to a course group
+// @Summary Add students to a course group
+// @Description Add enrolled students to a course group; others are reported as skipped (course staff or admin only)
+// @Tags course-groups
+// @Accept json
+// @Produce json
+// @Param courseId path int true "Course ID"
+// @Param groupId path int true "Group ID"
+// @Param request body dto.CourseGroupMembersRequest true "Students to add"
+// @Security BearerAuth
+// @Success 200 {object} dto.CourseGroupMembersResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /courses/{courseId}/groups/{groupId}/members [post]
+func (h *CourseGroupHandler) AddGroupMembers(c *gin.Context) {
+	h.changeGroupMembers(c, h.courseService.AddGroupMembers, "Failed to add course group members")
+}
+
+// RemoveGroupMembers removes students from a course group
+// @Summary Remove students from a course group
+// @Description Remove students from a course group (course staff or admin only)
+// @Tags course-groups
+// @Accept json
+// @Produce json
+// @Param courseId path int true "Course ID"
+// @Param groupId path int true "Group ID"
+// @Param request body dto.CourseGroupMembersRequest true "Students to remove"
+// @Security BearerAuth
+// @Success 200 {object} dto.CourseGroupMembersResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /courses/{courseId}/groups/{groupId}/members/remove [post]
+func (h *CourseGroupHandler) RemoveGroupMembers(c *gin.Context) {
+	h.changeGroupMembers(c, h.courseService.RemoveGroupMembers, "Failed to remove course group members")
+}
+
+type groupMembersChange func(ctx context.Context, courseID, groupID, userID int64, role string, req *dto.CourseGroupMembersRequest) (*dto.CourseGroupMembersResponse, error)
+
+func (h *CourseGroupHandler) changeGroupMembers(c *gin.Context, change groupMembersChange, failure string) {
+	courseID, groupID, ok := parseCourseGroupIDs(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CourseGroupMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("validation_error", err.Error()))
+		return
+	}
+
+	resp, err := change(c.Request.Context(), courseID, groupID, c.GetInt64("user_id"), getRoleFromContext(c), &req)
+	if err != nil {
+		respondCourseGroupError(c, failure, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(resp))
+}
+
+// parseCourseGroupIDs reads the course and group IDs from the path
+func parseCourseGroupIDs(c *gin.Context) (courseID, groupID int64, ok bool) {
+	courseID, ok = getCourseIDParam(c)
+	if !ok {
+		return 0, 0, false
+	}
+	groupID, err := strconv.ParseInt(c.Param("groupId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_group_id", "Invalid group ID"))
+		return 0, 0, false
+	}
+	return courseID, groupID, true
+}
+
+func respondCourseGroupError(c *gin.Context, msg string, err error) {
+	switch {
+	case strings.Contains(err.Error(), "unauthorized"):
+		c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", err.Error()))
+	default:
+		logger.Error(msg, err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", err.Error()))
+	}
+}
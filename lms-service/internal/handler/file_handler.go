@@ -228,8 +228,9 @@ func (h *FileHandler) ServeFile(c *gin.Context) {
 	}
 	// Chat attachments are private to a channel/DM. They are fetched through
 	// chat-service, which checks membership for every request, never through the
-	// LMS public file endpoint.
-	if strings.HasPrefix(filename, "chat/") {
+	// LMS public file endpoint. Forum attachments likewise go through the
+	// course-checked forum endpoint.
+	if isPrivateFilePath(filename) {
 		c.JSON(http.StatusNotFound, dto.NewErrorResponse("file_not_found", "File not found"))
 		return
 	}
@@ -300,7 +301,7 @@ func (h *FileHandler) DownloadFile(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_filename", "Invalid file path"))
 		return
 	}
-	if strings.HasPrefix(filename, "chat/") {
+	if isPrivateFilePath(filename) {
 		c.JSON(http.StatusNotFound, dto.NewErrorResponse("file_not_found", "File not found"))
 		return
 	}
@@ -362,7 +363,7 @@ func (h *FileHandler) GetPresignedURL(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_filename", "Invalid file path"))
 		return
 	}
-	if strings.HasPrefix(filename, "chat/") {
+	if isPrivateFilePath(filename) {
 		c.JSON(http.StatusNotFound, dto.NewErrorResponse("file_not_found", "File not found"))
 		return
 	}
@@ -571,3 +572,9 @@ func isVideo(ext string) bool {
 	}
 	return false
 }
+
+// isPrivateFilePath reports whether a storage key belongs to a service that
+// serves it through its own access-checked endpoint
+func isPrivateFilePath(filename string) bool {
+	return strings.HasPrefix(filename, "chat/") || strings.HasPrefix(filename, "forum/")
+}
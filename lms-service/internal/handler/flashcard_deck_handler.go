@@ -0,0 +1,343 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/service"
+	"example/hello/pkg/anki"
+	"example/hello/pkg/logger"
+)
+
+type FlashcardDeckHandler struct {
+	deckService *service.FlashcardDeckService
+}
+
+func NewFlashcardDeckHandler(deckService *service.FlashcardDeckService) *FlashcardDeckHandler {
+	return &FlashcardDeckHandler{deckService: deckService}
+}
+
+// CreateDeck POST /api/v1/courses/:courseId/flashcard-decks
+func (h *FlashcardDeckHandler) CreateDeck(c *gin.Context) {
+	userID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	courseID, err := strconv.ParseInt(c.Param("courseId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_course_id", "Invalid course ID"))
+		return
+	}
+
+	var req dto.CreateFlashcardDeckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	deck, err := h.deckService.CreateDeck(c.Request.Context(), userID, userRole, courseID, &req)
+	if err != nil {
+		h.respondError(c, "Failed to create flashcard deck", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewDataResponse(deck))
+}
+
+// ListDecks GET /api/v1/courses/:courseId/flashcard-decks
+func (h *FlashcardDeckHandler) ListDecks(c *gin.Context) {
+	userID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	courseID, err := strconv.ParseInt(c.Param("courseId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_course_id", "Invalid course ID"))
+		return
+	}
+
+	decks, err := h.deckService.ListDecks(c.Request.Context(), userID, userRole, courseID)
+	if err != nil {
+		h.respondError(c, "Failed to list flashcard decks", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(decks))
+}
+
+// ListDueCards GET /api/v1/courses/:courseId/flashcard-decks/due
+func (h *FlashcardDeckHandler) ListDueCards(c *gin.Context) {
+	userID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	courseID, err := strconv.ParseInt(c.Param("courseId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_course_id", "Invalid course ID"))
+		return
+	}
+
+	cards, err := h.deckService.ListDueCards(c.Request.Context(), userID, userRole, courseID)
+	if err != nil {
+		h.respondError(c, "Failed to list due deck cards", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(cards))
+}
+
+// GetDeck GET /api/v1/flashcard-decks/:deckId
+func (h *FlashcardDeckHandler) GetDeck(c *gin.Context) {
+	userID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	deckID, err := strconv.ParseInt(c.Param("deckId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_deck_id", "Invalid deck ID"))
+		return
+	}
+
+	deck, err := h.deckService.GetDeck(c.Request.Context(), userID, userRole, deckID)
+	if err != nil {
+		h.respondError(c, "Failed to get flashcard deck", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(deck))
+}
+
+// UpdateDeck PUT /api/v1/flashcard-decks/:deckId
+func (h *FlashcardDeckHandler) UpdateDeck(c *gin.Context) {
+	userID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	deckID, err := strconv.ParseInt(c.Param("deckId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_deck_id", "Invalid deck ID"))
+		return
+	}
+
+	var req dto.UpdateFlashcardDeckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	deck, err := h.deckService.UpdateDeck(c.Request.Context(), userID, userRole, deckID, &req)
+	if err != nil {
+		h.respondError(c, "Failed to update flashcard deck", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(deck))
+}
+
+// ArchiveDeck DELETE /api/v1/flashcard-decks/:deckId
+func (h *FlashcardDeckHandler) ArchiveDeck(c *gin.Context) {
+	userID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	deckID, err := strconv.ParseInt(c.Param("deckId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_deck_id", "Invalid deck ID"))
+		return
+	}
+
+	if err := h.deckService.ArchiveDeck(c.Request.Context(), userID, userRole, deckID); err != nil {
+		h.respondError(c, "Failed to archive flashcard deck", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMessageResponse("Deck archived successfully"))
+}
+
+// PublishDeck POST /api/v1/flashcard-decks/:deckId/publish
+func (h *FlashcardDeckHandler) PublishDeck(c *gin.Context) {
+	userID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	deckID, err := strconv.ParseInt(c.Param("deckId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_deck_id", "Invalid deck ID"))
+		return
+	}
+
+	var req dto.PublishFlashcardDeckRequest
+	// Body is optional
+	_ = c.ShouldBindJSON(&req)
+
+	deck, err := h.deckService.PublishDeck(c.Request.Context(), userID, userRole, deckID, req.Summary)
+	if err != nil {
+		h.respondError(c, "Failed to publish flashcard deck", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(deck))
+}
+
+// ListVersions GET /api/v1/flashcard-decks/:deckId/versions
+func (h *FlashcardDeckHandler) ListVersions(c *gin.Context) {
+	userID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	deckID, err := strconv.ParseInt(c.Param("deckId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_deck_id", "Invalid deck ID"))
+		return
+	}
+
+	versions, err := h.deckService.ListVersions(c.Request.Context(), userID, userRole, deckID)
+	if err != nil {
+		h.respondError(c, "Failed to list flashcard deck versions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(versions))
+}
+
+// AddCards POST /api/v1/flashcard-decks/:deckId/cards
+func (h *FlashcardDeckHandler) AddCards(c *gin.Context) {
+	userID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	deckID, err := strconv.ParseInt(c.Param("deckId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_deck_id", "Invalid deck ID"))
+		return
+	}
+
+	var req dto.AddFlashcardDeckCardsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	deck, err := h.deckService.AddCards(c.Request.Context(), userID, userRole, deckID, &req)
+	if err != nil {
+		h.respondError(c, "Failed to add deck cards", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(deck))
+}
+
+// ImportCards POST /api/v1/flashcard-decks/:deckId/import (multipart "file": .apkg or .csv)
+func (h *FlashcardDeckHandler) ImportCards(c *gin.Context) {
+	userID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	deckID, err := strconv.ParseInt(c.Param("deckId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_deck_id", "Invalid deck ID"))
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_file", "A .apkg or .csv file is required"))
+		return
+	}
+	if file.Size > maxFlashcardImportSize {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("file_too_large", "Deck must be smaller than 50MB"))
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		logger.Error("Failed to open uploaded deck", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("upload_failed", "Failed to process file"))
+		return
+	}
+	defer src.Close()
+	data, err := io.ReadAll(io.LimitReader(src, maxFlashcardImportSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("upload_failed", "Failed to read file"))
+		return
+	}
+
+	result, err := h.deckService.ImportCards(c.Request.Context(), userID, userRole, deckID, file.Filename, data)
+	if err != nil {
+		if anki.IsInvalidDeck(err) {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_deck", err.Error()))
+			return
+		}
+		h.respondError(c, "Failed to import deck cards", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(result))
+}
+
+// UpdateCard PUT /api/v1/flashcard-decks/cards/:cardId
+func (h *FlashcardDeckHandler) UpdateCard(c *gin.Context) {
+	userID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	cardID, err := strconv.ParseInt(c.Param("cardId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_card_id", "Invalid card ID"))
+		return
+	}
+
+	var req dto.UpdateFlashcardDeckCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	card, err := h.deckService.UpdateCard(c.Request.Context(), userID, userRole, cardID, &req)
+	if err != nil {
+		h.respondError(c, "Failed to update deck card", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(card))
+}
+
+// RemoveCard DELETE /api/v1/flashcard-decks/cards/:cardId
+func (h *FlashcardDeckHandler) RemoveCard(c *gin.Context) {
+	userID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	cardID, err := strconv.ParseInt(c.Param("cardId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_card_id", "Invalid card ID"))
+		return
+	}
+
+	if err := h.deckService.RemoveCard(c.Request.Context(), userID, userRole, cardID); err != nil {
+		h.respondError(c, "Failed to remove deck card", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMessageResponse("Card removed successfully"))
+}
+
+// ReviewCard POST /api/v1/flashcard-decks/cards/:cardId/review
+func (h *FlashcardDeckHandler) ReviewCard(c *gin.Context) {
+	userID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	cardID, err := strconv.ParseInt(c.Param("cardId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_card_id", "Invalid card ID"))
+		return
+	}
+
+	var req dto.ReviewFlashcardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	result, err := h.deckService.ReviewCard(c.Request.Context(), userID, userRole, cardID, req.Quality)
+	if err != nil {
+		h.respondError(c, "Failed to review deck card", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(result))
+}
+
+func (h *FlashcardDeckHandler) respondError(c *gin.Context, msg string, err error) {
+	switch {
+	case strings.Contains(err.Error(), "unauthorized"):
+		c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", err.Error()))
+	case strings.Contains(err.Error(), "already published"),
+		strings.Contains(err.Error(), "empty"):
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+	default:
+		logger.Error(msg, err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", err.Error()))
+	}
+}
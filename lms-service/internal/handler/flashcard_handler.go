@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -8,6 +11,7 @@ import (
 
 	"example/hello/internal/dto"
 	"example/hello/internal/service"
+	"example/hello/pkg/anki"
 	"example/hello/pkg/logger"
 )
 
@@ -177,3 +181,107 @@ func (h *FlashcardHandler) BulkSaveFlashcards(c *gin.Context) {
 
 	c.JSON(http.StatusOK, dto.NewDataResponse(results))
 }
+
+// maxFlashcardImportSize caps .apkg/CSV uploads (media-heavy Anki decks are
+// rejected rather than streamed, since only note text is imported).
+const maxFlashcardImportSize = 50 * 1024 * 1024
+
+// ExportFlashcards GET /api/v1/courses/:courseId/flashcards/export?format=apkg|csv&scope=mine|course&nodeId=
+func (h *FlashcardHandler) ExportFlashcards(c *gin.Context) {
+	userID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	courseID, err := strconv.ParseInt(c.Param("courseId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_course_id", "Invalid course ID"))
+		return
+	}
+
+	var q dto.ExportFlashcardsQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	if err := h.enrollmentSvc.VerifyAccess(c.Request.Context(), userID, courseID, userRole); err != nil {
+		c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", "Bạn không có quyền truy cập khóa học này"))
+		return
+	}
+
+	deck, err := h.flashcardService.ExportFlashcards(c.Request.Context(), userID, userRole, courseID, q)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotCourseTeacher):
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
+		case errors.Is(err, service.ErrFlashcardCourseNotFound), errors.Is(err, service.ErrFlashcardNodeNotFound), errors.Is(err, service.ErrNoFlashcardsToExport):
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", err.Error()))
+		default:
+			logger.Error("Failed to export flashcards", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", err.Error()))
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, deck.FileName))
+	c.Header("X-Flashcard-Count", strconv.Itoa(deck.CardCount))
+	c.Data(http.StatusOK, deck.ContentType, deck.Data)
+}
+
+// ImportFlashcards POST /api/v1/courses/:courseId/nodes/:nodeId/flashcards/import (multipart "file": .apkg or .csv)
+func (h *FlashcardHandler) ImportFlashcards(c *gin.Context) {
+	studentID := c.MustGet("user_id").(int64)
+	userRole := c.MustGet("user_role").(string)
+	courseID, err := strconv.ParseInt(c.Param("courseId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_course_id", "Invalid course ID"))
+		return
+	}
+	nodeID, err := strconv.ParseInt(c.Param("nodeId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_node_id", "Invalid node ID"))
+		return
+	}
+
+	if err := h.enrollmentSvc.VerifyAccess(c.Request.Context(), studentID, courseID, userRole); err != nil {
+		c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", "Bạn không có quyền truy cập khóa học này"))
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_file", "A .apkg or .csv file is required"))
+		return
+	}
+	if file.Size > maxFlashcardImportSize {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("file_too_large", "Deck must be smaller than 50MB"))
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		logger.Error("Failed to open uploaded deck", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("upload_failed", "Failed to process file"))
+		return
+	}
+	defer src.Close()
+	data, err := io.ReadAll(io.LimitReader(src, maxFlashcardImportSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("upload_failed", "Failed to read file"))
+		return
+	}
+
+	result, err := h.flashcardService.ImportFlashcards(c.Request.Context(), studentID, courseID, nodeID, file.Filename, data)
+	if err != nil {
+		if anki.IsInvalidDeck(err) {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_deck", err.Error()))
+			return
+		}
+		if errors.Is(err, service.ErrFlashcardNodeNotFound) {
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", err.Error()))
+			return
+		}
+		logger.Error("Failed to import flashcards", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(result))
+}
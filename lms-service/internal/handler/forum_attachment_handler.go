@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ForumAttachmentHandler struct {
+	attachmentService *service.ForumAttachmentService
+	forumService      *service.ForumService
+}
+
+func NewForumAttachmentHandler(attachmentService *service.ForumAttachmentService, forumService *service.ForumService) *ForumAttachmentHandler {
+	return &ForumAttachmentHandler{
+		attachmentService: attachmentService,
+		forumService:      forumService,
+	}
+}
+
+// UploadAttachment godoc
+// @Summary Upload a forum attachment
+// @Description Stores a file for the forum. Link it by passing its ID in attachment_ids when creating or editing a post or comment; unlinked uploads are removed after a day.
+// @Tags Forum Attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param contentId path int true "Content ID"
+// @Param file formData file true "File"
+// @Security BearerAuth
+// @Success 201 {object} dto.SuccessResponse{data=dto.ForumAttachmentResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 413 {object} dto.ErrorResponse
+// @Router /content/{contentId}/forum/attachments [post]
+func (h *ForumAttachmentHandler) UploadAttachment(c *gin.Context) {
+	contentID, err := strconv.ParseInt(c.Param("contentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid content ID"))
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", "file is required"))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("upload_error", "Error reading file stream"))
+		return
+	}
+	defer file.Close()
+
+	userID, _ := c.Get("user_id")
+
+	attachment, err := h.attachmentService.Upload(c.Request.Context(), userID.(int64), c.GetString("user_role"),
+		contentID, fileHeader.Filename, file, fileHeader.Size)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "file exceeds"):
+			c.JSON(http.StatusRequestEntityTooLarge, dto.NewErrorResponse("file_too_large", err.Error()))
+		case strings.HasSuffix(err.Error(), "is not allowed"):
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse("file_type_not_allowed", err.Error()))
+		case strings.Contains(err.Error(), "quota exceeded"):
+			c.JSON(http.StatusRequestEntityTooLarge, dto.NewErrorResponse("quota_exceeded", err.Error()))
+		case strings.HasPrefix(err.Error(), "posting banned"):
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse("posting_banned", err.Error()))
+		default:
+			respondForumModerationFailure(c, "Failed to upload forum attachment", "upload_failed", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewDataResponse(attachment))
+}
+
+// ServeAttachment godoc
+// @Summary Download a forum attachment
+// @Description Streams an attachment to course members; those of hidden posts and comments only to their author and moderators. Images are shown inline, everything else is downloaded.
+// @Tags Forum Attachments
+// @Produce octet-stream
+// @Param attachmentId path int true "Attachment ID"
+// @Security BearerAuth
+// @Success 200 {file} binary
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /forum/attachments/{attachmentId} [get]
+func (h *ForumAttachmentHandler) ServeAttachment(c *gin.Context) {
+	attachmentID, err := strconv.ParseInt(c.Param("attachmentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid attachment ID"))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	attachment, object, err := h.forumService.OpenAttachment(c.Request.Context(), userID.(int64), c.GetString("user_role"),
+		isAdminRequest(c), attachmentID)
+	if err != nil {
+		respondForumModerationFailure(c, "Failed to open forum attachment", "attachment_failed", err)
+		return
+	}
+	defer object.Body.Close()
+
+	// Only raster images render inline; anything else (including SVG and
+	// HTML) is forced to download so it cannot run in the app's origin.
+	disposition := "attachment"
+	if service.IsInlineImage(attachment.ContentType) {
+		disposition = "inline"
+	}
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, max-age=3600")
+	if object.ETag != "" {
+		c.Header("ETag", fmt.Sprintf("%q", strings.Trim(object.ETag, `"`)))
+	}
+
+	http.ServeContent(c.Writer, c.Request, attachment.FileName, object.LastModified, object.Body)
+}
+
+// DeleteAttachment godoc
+// @Summary Delete a forum attachment
+// @Tags Forum Attachments
+// @Produce json
+// @Param attachmentId path int true "Attachment ID"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /forum/attachments/{attachmentId} [delete]
+func (h *ForumAttachmentHandler) DeleteAttachment(c *gin.Context) {
+	attachmentID, err := strconv.ParseInt(c.Param("attachmentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid attachment ID"))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.attachmentService.Delete(c.Request.Context(), userID.(int64), isAdminRequest(c), attachmentID); err != nil {
+		respondForumModerationFailure(c, "Failed to delete forum attachment", "delete_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMessageResponse("Attachment deleted successfully"))
+}
+
+// GetUsage godoc
+// @Summary Get forum attachment usage for a course
+// @Tags Forum Attachments
+// @Produce json
+// @Param courseId path int true "Course ID"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse{data=dto.ForumAttachmentUsageResponse}
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /courses/{courseId}/forum/attachments/usage [get]
+func (h *ForumAttachmentHandler) GetUsage(c *gin.Context) {
+	courseID, err := strconv.ParseInt(c.Param("courseId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid course ID"))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	usage, err := h.attachmentService.GetUsage(c.Request.Context(), userID.(int64), c.GetString("user_role"), courseID)
+	if err != nil {
+		respondForumModerationFailure(c, "Failed to get forum attachment usage", "usage_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(usage))
+}
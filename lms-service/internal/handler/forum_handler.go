@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"example/hello/internal/dto"
+	"example/hello/internal/models"
 	"example/hello/internal/service"
 	"example/hello/pkg/logger"
+	"example/hello/pkg/moderation"
 
 	"github.com/gin-gonic/gin"
 )
@@ -55,6 +59,9 @@ func (h *ForumHandler) CreatePost(c *gin.Context) {
 
 	post, err := h.forumService.CreatePost(c.Request.Context(), contentID, userID.(int64), &req)
 	if err != nil {
+		if respondModerationError(c, err) {
+			return
+		}
 		logger.Error("Failed to create post", err)
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("create_failed", err.Error()))
 		return
@@ -94,7 +101,7 @@ func (h *ForumHandler) ListPosts(c *gin.Context) {
 		return
 	}
 
-	result, err := h.forumService.ListPosts(c.Request.Context(), contentID, userID.(int64), &req)
+	result, err := h.forumService.ListPosts(c.Request.Context(), contentID, userID.(int64), isAdminRequest(c), &req)
 	if err != nil {
 		logger.Error("Failed to list posts", err)
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("list_failed", err.Error()))
@@ -125,7 +132,7 @@ func (h *ForumHandler) GetPost(c *gin.Context) {
 
 	userID, _ := c.Get("user_id")
 
-	post, err := h.forumService.GetPost(c.Request.Context(), postID, userID.(int64))
+	post, err := h.forumService.GetPost(c.Request.Context(), postID, userID.(int64), isAdminRequest(c))
 	if err != nil {
 		logger.Error("Failed to get post", err)
 		c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", err.Error()))
@@ -165,6 +172,9 @@ func (h *ForumHandler) UpdatePost(c *gin.Context) {
 
 	post, err := h.forumService.UpdatePost(c.Request.Context(), postID, userID.(int64), &req)
 	if err != nil {
+		if respondModerationError(c, err) {
+			return
+		}
 		logger.Error("Failed to update post", err)
 		c.JSON(http.StatusForbidden, dto.NewErrorResponse("update_failed", err.Error()))
 		return
@@ -321,6 +331,9 @@ func (h *ForumHandler) CreateComment(c *gin.Context) {
 
 	comment, err := h.forumService.CreateComment(c.Request.Context(), postID, userID.(int64), &req)
 	if err != nil {
+		if respondModerationError(c, err) {
+			return
+		}
 		logger.Error("Failed to create comment", err)
 		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("create_failed", err.Error()))
 		return
@@ -349,7 +362,7 @@ func (h *ForumHandler) ListComments(c *gin.Context) {
 
 	userID, _ := c.Get("user_id")
 
-	comments, err := h.forumService.ListComments(c.Request.Context(), postID, userID.(int64))
+	comments, err := h.forumService.ListComments(c.Request.Context(), postID, userID.(int64), isAdminRequest(c))
 	if err != nil {
 		logger.Error("Failed to list comments", err)
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("list_failed", err.Error()))
@@ -389,6 +402,9 @@ func (h *ForumHandler) UpdateComment(c *gin.Context) {
 
 	comment, err := h.forumService.UpdateComment(c.Request.Context(), commentID, userID.(int64), &req)
 	if err != nil {
+		if respondModerationError(c, err) {
+			return
+		}
 		logger.Error("Failed to update comment", err)
 		c.JSON(http.StatusForbidden, dto.NewErrorResponse("update_failed", err.Error()))
 		return
@@ -541,6 +557,331 @@ func (h *ForumHandler) VoteComment(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.NewDataResponse(result))
 }
 
+// ============================================
+// MODERATION ENDPOINTS
+// ============================================
+
+// ReportPost godoc
+// @Summary Report a forum post
+// @Description Report a post for moderator review. Posts reaching the report threshold are hidden pending review.
+// @Tags Forum Moderation
+// @Accept json
+// @Produce json
+// @Param postId path int true "Post ID"
+// @Param request body dto.ReportForumContentRequest true "Report"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse{data=dto.ReportForumContentResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /forum/posts/{postId}/report [post]
+func (h *ForumHandler) ReportPost(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("postId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid post ID"))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var req dto.ReportForumContentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	result, err := h.forumService.ReportPost(c.Request.Context(), postID, userID.(int64), isAdminRequest(c), &req)
+	if err != nil {
+		respondForumModerationFailure(c, "Failed to report post", "report_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(result))
+}
+
+// ReportComment godoc
+// @Summary Report a forum comment
+// @Description Report a comment for moderator review. Comments reaching the report threshold are hidden pending review.
+// @Tags Forum Moderation
+// @Accept json
+// @Produce json
+// @Param commentId path int true "Comment ID"
+// @Param request body dto.ReportForumContentRequest true "Report"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse{data=dto.ReportForumContentResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /forum/comments/{commentId}/report [post]
+func (h *ForumHandler) ReportComment(c *gin.Context) {
+	commentID, err := strconv.ParseInt(c.Param("commentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid comment ID"))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var req dto.ReportForumContentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	result, err := h.forumService.ReportComment(c.Request.Context(), commentID, userID.(int64), isAdminRequest(c), &req)
+	if err != nil {
+		respondForumModerationFailure(c, "Failed to report comment", "report_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(result))
+}
+
+// ListReportQueue godoc
+// @Summary Moderator report queue
+// @Description List reported posts and comments grouped by target. Teachers pass course_id, org admins org_id.
+// @Tags Forum Moderation
+// @Produce json
+// @Param course_id query int false "Course ID"
+// @Param org_id query int false "Organization ID"
+// @Param status query string false "OPEN, DISMISSED or ACTIONED" default(OPEN)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse{data=dto.ListResponse}
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /forum/moderation/reports [get]
+func (h *ForumHandler) ListReportQueue(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req dto.ListForumReportsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	result, err := h.forumService.ListReportQueue(c.Request.Context(), userID.(int64), isAdminRequest(c), &req)
+	if err != nil {
+		respondForumModerationFailure(c, "Failed to list report queue", "list_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(result))
+}
+
+// ListTargetReports godoc
+// @Summary List reports against a post or comment
+// @Tags Forum Moderation
+// @Produce json
+// @Param postId path int false "Post ID"
+// @Param commentId path int false "Comment ID"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.ForumReportResponse}
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /forum/moderation/posts/{postId}/reports [get]
+// @Router /forum/moderation/comments/{commentId}/reports [get]
+func (h *ForumHandler) ListTargetReports(c *gin.Context) {
+	targetType, targetID, ok := moderationTarget(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	reports, err := h.forumService.ListTargetReports(c.Request.Context(), targetType, targetID, userID.(int64), isAdminRequest(c))
+	if err != nil {
+		respondForumModerationFailure(c, "Failed to list reports", "list_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(reports))
+}
+
+// ResolveReports godoc
+// @Summary Resolve reports against a post or comment
+// @Description Dismiss, hide, restore or delete reported content, optionally banning its author
+// @Tags Forum Moderation
+// @Accept json
+// @Produce json
+// @Param postId path int false "Post ID"
+// @Param commentId path int false "Comment ID"
+// @Param request body dto.ResolveForumReportRequest true "Decision"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /forum/moderation/posts/{postId}/resolve [post]
+// @Router /forum/moderation/comments/{commentId}/resolve [post]
+func (h *ForumHandler) ResolveReports(c *gin.Context) {
+	targetType, targetID, ok := moderationTarget(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var req dto.ResolveForumReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	if err := h.forumService.ResolveReports(c.Request.Context(), targetType, targetID, userID.(int64), isAdminRequest(c), &req); err != nil {
+		respondForumModerationFailure(c, "Failed to resolve reports", "resolve_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMessageResponse("Reports resolved successfully"))
+}
+
+// BanUser godoc
+// @Summary Ban a user from posting in a course forum
+// @Tags Forum Moderation
+// @Accept json
+// @Produce json
+// @Param courseId path int true "Course ID"
+// @Param request body dto.CreateForumBanRequest true "Ban"
+// @Security BearerAuth
+// @Success 201 {object} dto.SuccessResponse{data=dto.ForumBanResponse}
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /courses/{courseId}/forum/bans [post]
+func (h *ForumHandler) BanUser(c *gin.Context) {
+	courseID, err := strconv.ParseInt(c.Param("courseId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid course ID"))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var req dto.CreateForumBanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	ban, err := h.forumService.BanUser(c.Request.Context(), courseID, userID.(int64), isAdminRequest(c), &req)
+	if err != nil {
+		respondForumModerationFailure(c, "Failed to ban user", "ban_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewDataResponse(ban))
+}
+
+// ListBans godoc
+// @Summary List active posting bans in a course
+// @Tags Forum Moderation
+// @Produce json
+// @Param courseId path int true "Course ID"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.ForumBanResponse}
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /courses/{courseId}/forum/bans [get]
+func (h *ForumHandler) ListBans(c *gin.Context) {
+	courseID, err := strconv.ParseInt(c.Param("courseId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid course ID"))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	bans, err := h.forumService.ListBans(c.Request.Context(), courseID, userID.(int64), isAdminRequest(c))
+	if err != nil {
+		respondForumModerationFailure(c, "Failed to list bans", "list_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(bans))
+}
+
+// LiftBan godoc
+// @Summary Lift a user's posting ban in a course
+// @Tags Forum Moderation
+// @Produce json
+// @Param courseId path int true "Course ID"
+// @Param userId path int true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /courses/{courseId}/forum/bans/{userId} [delete]
+func (h *ForumHandler) LiftBan(c *gin.Context) {
+	courseID, err := strconv.ParseInt(c.Param("courseId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid course ID"))
+		return
+	}
+	bannedUserID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid user ID"))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.forumService.LiftBan(c.Request.Context(), courseID, bannedUserID, userID.(int64), isAdminRequest(c)); err != nil {
+		respondForumModerationFailure(c, "Failed to lift ban", "unban_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMessageResponse("Ban lifted successfully"))
+}
+
+// moderationTarget reads the post or comment ID from the route
+func moderationTarget(c *gin.Context) (string, int64, bool) {
+	targetType, param := models.VotableTypePost, "postId"
+	if c.Param("commentId") != "" {
+		targetType, param = models.VotableTypeComment, "commentId"
+	}
+
+	id, err := strconv.ParseInt(c.Param(param), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid "+targetType+" ID"))
+		return "", 0, false
+	}
+	return targetType, id, true
+}
+
+// respondModerationError writes the response for content-filter rejections and
+// posting bans, returning false for any other error.
+func respondModerationError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, moderation.ErrRejected):
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("content_rejected", err.Error()))
+	case strings.HasPrefix(err.Error(), "posting banned"):
+		c.JSON(http.StatusForbidden, dto.NewErrorResponse("posting_banned", err.Error()))
+	default:
+		return false
+	}
+	return true
+}
+
+func respondForumModerationFailure(c *gin.Context, msg, code string, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", err.Error()))
+	case strings.HasPrefix(err.Error(), "cannot"),
+		strings.Contains(err.Error(), "required"),
+		strings.Contains(err.Error(), "exceeds"):
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(code, err.Error()))
+	default:
+		logger.Error(msg, err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(code, err.Error()))
+	}
+}
+
+// isAdminRequest reports whether the caller holds the system ADMIN role
+func isAdminRequest(c *gin.Context) bool {
+	roles, ok := c.Get("user_roles")
+	if !ok {
+		return false
+	}
+	list, _ := roles.([]string)
+	return containsRole(list, "ADMIN")
+}
+
 // Helper function
 func containsRole(roles []string, role string) bool {
 	for _, r := range roles {
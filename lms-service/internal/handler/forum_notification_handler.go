@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/models"
+	"example/hello/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ForumNotificationHandler struct {
+	notificationService *service.ForumNotificationService
+}
+
+func NewForumNotificationHandler(notificationService *service.ForumNotificationService) *ForumNotificationHandler {
+	return &ForumNotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// ============================================
+// SUBSCRIPTION ENDPOINTS
+// ============================================
+
+// SubscribePost godoc
+// @Summary Follow a forum thread
+// @Description Receive notifications for replies to a post. Posting or commenting subscribes automatically.
+// @Tags Forum Notifications
+// @Produce json
+// @Param postId path int true "Post ID"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse{data=dto.ForumSubscriptionResponse}
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /forum/posts/{postId}/subscription [post]
+func (h *ForumNotificationHandler) SubscribePost(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("postId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid post ID"))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	sub, err := h.notificationService.SubscribePost(c.Request.Context(), userID.(int64), c.GetString("user_role"), postID)
+	if err != nil {
+		respondForumModerationFailure(c, "Failed to subscribe to post", "subscribe_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(sub))
+}
+
+// UnsubscribePost godoc
+// @Summary Unfollow a forum thread
+// @Tags Forum Notifications
+// @Produce json
+// @Param postId path int true "Post ID"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /forum/posts/{postId}/subscription [delete]
+func (h *ForumNotificationHandler) UnsubscribePost(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("postId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid post ID"))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.notificationService.Unsubscribe(c.Request.Context(), userID.(int64), models.SubscriptionTargetPost, postID); err != nil {
+		respondForumModerationFailure(c, "Failed to unsubscribe from post", "unsubscribe_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMessageResponse("Unsubscribed successfully"))
+}
+
+// SubscribeForum godoc
+// @Summary Follow a forum
+// @Description Receive notifications for every new post in a forum
+// @Tags Forum Notifications
+// @Produce json
+// @Param contentId path int true "Content ID"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse{data=dto.ForumSubscriptionResponse}
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /content/{contentId}/forum/subscription [post]
+func (h *ForumNotificationHandler) SubscribeForum(c *gin.Context) {
+	contentID, err := strconv.ParseInt(c.Param("contentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid content ID"))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	sub, err := h.notificationService.SubscribeForum(c.Request.Context(), userID.(int64), c.GetString("user_role"), contentID)
+	if err != nil {
+		respondForumModerationFailure(c, "Failed to subscribe to forum", "subscribe_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(sub))
+}
+
+// UnsubscribeForum godoc
+// @Summary Unfollow a forum
+// @Tags Forum Notifications
+// @Produce json
+// @Param contentId path int true "Content ID"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /content/{contentId}/forum/subscription [delete]
+func (h *ForumNotificationHandler) UnsubscribeForum(c *gin.Context) {
+	contentID, err := strconv.ParseInt(c.Param("contentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid content ID"))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.notificationService.Unsubscribe(c.Request.Context(), userID.(int64), models.SubscriptionTargetForum, contentID); err != nil {
+		respondForumModerationFailure(c, "Failed to unsubscribe from forum", "unsubscribe_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMessageResponse("Unsubscribed successfully"))
+}
+
+// ListSubscriptions godoc
+// @Summary List followed forums and threads
+// @Tags Forum Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.ForumSubscriptionResponse}
+// @Router /forum/subscriptions [get]
+func (h *ForumNotificationHandler) ListSubscriptions(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	subs, err := h.notificationService.ListSubscriptions(c.Request.Context(), userID.(int64))
+	if err != nil {
+		respondForumModerationFailure(c, "Failed to list subscriptions", "list_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(subs))
+}
+
+// ============================================
+// NOTIFICATION ENDPOINTS
+// ============================================
+
+// ListNotifications godoc
+// @Summary List forum notifications
+// @Description New posts, replies, mentions and accepted answers for the current user
+// @Tags Forum Notifications
+// @Produce json
+// @Param unread_only query bool false "Only unread notifications"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse{data=dto.ForumNotificationListResponse}
+// @Router /forum/notifications [get]
+func (h *ForumNotificationHandler) ListNotifications(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req dto.ListForumNotificationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	result, err := h.notificationService.ListNotifications(c.Request.Context(), userID.(int64), &req)
+	if err != nil {
+		respondForumModerationFailure(c, "Failed to list notifications", "list_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(result))
+}
+
+// MarkNotificationsRead godoc
+// @Summary Mark forum notifications read
+// @Description Marks the given notifications read, or all of them when ids is empty
+// @Tags Forum Notifications
+// @Accept json
+// @Produce json
+// @Param request body dto.MarkForumNotificationsReadRequest false "Notification IDs"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Router /forum/notifications/read [post]
+func (h *ForumNotificationHandler) MarkNotificationsRead(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req dto.MarkForumNotificationsReadRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+			return
+		}
+	}
+
+	updated, err := h.notificationService.MarkNotificationsRead(c.Request.Context(), userID.(int64), req.IDs)
+	if err != nil {
+		respondForumModerationFailure(c, "Failed to mark notifications read", "update_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(gin.H{"updated": updated}))
+}
+
+// MarkPostRead godoc
+// @Summary Mark a forum thread read
+// @Description Resets the thread's unread state and clears its notifications
+// @Tags Forum Notifications
+// @Produce json
+// @Param postId path int true "Post ID"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /forum/posts/{postId}/read [post]
+func (h *ForumNotificationHandler) MarkPostRead(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("postId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid post ID"))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.notificationService.MarkThreadRead(c.Request.Context(), userID.(int64), postID); err != nil {
+		respondForumModerationFailure(c, "Failed to mark post read", "update_failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMessageResponse("Post marked as read"))
+}
@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/repository"
+	"example/hello/internal/service"
+	"example/hello/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrgJoinHandler struct {
+	joinService *service.OrgJoinService
+}
+
+func NewOrgJoinHandler(joinService *service.OrgJoinService) *OrgJoinHandler {
+	return &OrgJoinHandler{joinService: joinService}
+}
+
+// CreateInvitation invites an email address to an organization
+// @Summary Invite to organization
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param invitation body dto.CreateInvitationRequest true "Invitation"
+// @Success 201 {object} dto.InvitationResponse
+// @Router /organizations/{id}/invitations [post]
+func (h *OrgJoinHandler) CreateInvitation(c *gin.Context) {
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid organization ID"))
+		return
+	}
+
+	var req dto.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("validation_error", err.Error()))
+		return
+	}
+
+	inv, err := h.joinService.Invite(c.Request.Context(), orgID, &req, c.GetInt64("user_id"))
+	if err != nil {
+		h.respondJoinError(c, err, "Failed to create invitation")
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewDataResponse(inv))
+}
+
+// ListInvitations lists an organization's invitations
+// @Summary List organization invitations
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Success 200 {object} dto.ListResponse
+// @Router /organizations/{id}/invitations [get]
+func (h *OrgJoinHandler) ListInvitations(c *gin.Context) {
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid organization ID"))
+		return
+	}
+
+	var pagination dto.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("validation_error", err.Error()))
+		return
+	}
+	limit, offset := pagination.GetPagination()
+
+	invs, total, err := h.joinService.ListInvitations(c.Request.Context(), orgID, limit, offset)
+	if err != nil {
+		logger.Error("Failed to list org invitations", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", "Failed to retrieve invitations"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(dto.NewListResponse(invs, pagination.Page, limit, total)))
+}
+
+// RevokeInvitation revokes an open invitation
+// @Summary Revoke organization invitation
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param invitationId path int true "Invitation ID"
+// @Success 200 {object} dto.MessageResponse
+// @Router /organizations/{id}/invitations/{invitationId} [delete]
+func (h *OrgJoinHandler) RevokeInvitation(c *gin.Context) {
+	orgID, invitationID, ok := parseOrgChildIDs(c, "invitationId", "Invalid invitation ID")
+	if !ok {
+		return
+	}
+
+	if err := h.joinService.RevokeInvitation(c.Request.Context(), orgID, invitationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", "No open invitation found"))
+			return
+		}
+		logger.Error("Failed to revoke org invitation", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", "Failed to revoke invitation"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMessageResponse("Invitation revoked"))
+}
+
+// AcceptInvitation joins the organization an invitation token was sent for
+// @Summary Accept organization invitation
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.AcceptInvitationRequest true "Invitation token"
+// @Success 200 {object} dto.OrgResponse
+// @Router /my/orgs/invitations/accept [post]
+func (h *OrgJoinHandler) AcceptInvitation(c *gin.Context) {
+	var req dto.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("validation_error", err.Error()))
+		return
+	}
+
+	org, err := h.joinService.AcceptInvitation(c.Request.Context(), req.Token, c.GetInt64("user_id"))
+	if err != nil {
+		h.respondJoinError(c, err, "Failed to accept invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(org))
+}
+
+// CreateJoinCode creates a join code for an organization
+// @Summary Create organization join code
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param code body dto.CreateJoinCodeRequest true "Join code options"
+// @Success 201 {object} dto.JoinCodeResponse
+// @Router /organizations/{id}/join-codes [post]
+func (h *OrgJoinHandler) CreateJoinCode(c *gin.Context) {
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid organization ID"))
+		return
+	}
+
+	var req dto.CreateJoinCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("validation_error", err.Error()))
+		return
+	}
+
+	code, err := h.joinService.CreateJoinCode(c.Request.Context(), orgID, &req, c.GetInt64("user_id"))
+	if err != nil {
+		h.respondJoinError(c, err, "Failed to create join code")
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewDataResponse(code))
+}
+
+// ListJoinCodes lists an organization's join codes
+// @Summary List organization join codes
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Success 200 {array} dto.JoinCodeResponse
+// @Router /organizations/{id}/join-codes [get]
+func (h *OrgJoinHandler) ListJoinCodes(c *gin.Context) {
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid organization ID"))
+		return
+	}
+
+	codes, err := h.joinService.ListJoinCodes(c.Request.Context(), orgID)
+	if err != nil {
+		logger.Error("Failed to list org join codes", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", "Failed to retrieve join codes"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(codes))
+}
+
+// RevokeJoinCode revokes a join code
+// @Summary Revoke organization join code
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param codeId path int true "Join code ID"
+// @Success 200 {object} dto.MessageResponse
+// @Router /organizations/{id}/join-codes/{codeId} [delete]
+func (h *OrgJoinHandler) RevokeJoinCode(c *gin.Context) {
+	orgID, codeID, ok := parseOrgChildIDs(c, "codeId", "Invalid join code ID")
+	if !ok {
+		return
+	}
+
+	if err := h.joinService.RevokeJoinCode(c.Request.Context(), orgID, codeID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", "No active join code found"))
+			return
+		}
+		logger.Error("Failed to revoke org join code", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", "Failed to revoke join code"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMessageResponse("Join code revoked"))
+}
+
+// RedeemJoinCode joins the organization a code belongs to
+// @Summary Join organization with a code
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.RedeemJoinCodeRequest true "Join code"
+// @Success 200 {object} dto.OrgResponse
+// @Router /my/orgs/join [post]
+func (h *OrgJoinHandler) RedeemJoinCode(c *gin.Context) {
+	var req dto.RedeemJoinCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("validation_error", err.Error()))
+		return
+	}
+
+	org, err := h.joinService.RedeemJoinCode(c.Request.Context(), req.Code, c.GetInt64("user_id"))
+	if err != nil {
+		h.respondJoinError(c, err, "Failed to join organization")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(org))
+}
+
+// respondJoinError maps the errors shared by invitations and join codes
+func (h *OrgJoinHandler) respondJoinError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvitationNotFound), errors.Is(err, service.ErrJoinCodeNotFound):
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", err.Error()))
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", "Organization not found"))
+	case errors.Is(err, repository.ErrInvitationUnavailable), errors.Is(err, repository.ErrJoinCodeUnavailable):
+		c.JSON(http.StatusGone, dto.NewErrorResponse("expired", err.Error()))
+	case errors.Is(err, service.ErrInvitationEmailMismatch):
+		c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
+	case errors.Is(err, service.ErrUnknownOrgRole), errors.Is(err, service.ErrOwnerNotJoinable):
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("validation_error", err.Error()))
+	case errors.Is(err, repository.ErrAlreadyMember):
+		c.JSON(http.StatusConflict, dto.NewErrorResponse("already_member", err.Error()))
+	case errors.Is(err, repository.ErrOrgMemberLimit):
+		c.JSON(http.StatusConflict, dto.NewErrorResponse("member_limit_reached", err.Error()))
+	case errors.Is(err, service.ErrOrgInactive):
+		c.JSON(http.StatusConflict, dto.NewErrorResponse("org_inactive", err.Error()))
+	default:
+		logger.Error(message, err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", message))
+	}
+}
+
+// parseOrgChildIDs reads the organization ID and the ID of one of its
+// invitations or join codes from the path
+func parseOrgChildIDs(c *gin.Context, param, invalidMessage string) (orgID, id int64, ok bool) {
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid organization ID"))
+		return 0, 0, false
+	}
+	id, err = strconv.ParseInt(c.Param(param), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", invalidMessage))
+		return 0, 0, false
+	}
+	return orgID, id, true
+}
@@ -2,10 +2,12 @@ package handler
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"example/hello/internal/dto"
+	"example/hello/internal/repository"
 	"example/hello/internal/service"
 	"example/hello/pkg/logger"
 
@@ -75,6 +77,10 @@ func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
 			c.JSON(http.StatusConflict, dto.NewErrorResponse("conflict", err.Error()))
 			return
 		}
+		if errors.Is(err, service.ErrUnknownOrgRole) || errors.Is(err, service.ErrOwnerNotJoinable) {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse("validation_error", err.Error()))
+			return
+		}
 		logger.Error("Failed to create organization", err)
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", "Failed to create organization"))
 		return
@@ -148,6 +154,10 @@ func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
 			c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
 			return
 		}
+		if errors.Is(err, service.ErrUnknownOrgRole) || errors.Is(err, service.ErrOwnerNotJoinable) {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse("validation_error", err.Error()))
+			return
+		}
 		logger.Error("Failed to update organization", err)
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", "Failed to update organization"))
 		return
@@ -278,6 +288,18 @@ func (h *OrganizationHandler) AddMember(c *gin.Context) {
 			c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
 			return
 		}
+		if errors.Is(err, service.ErrOwnerRequired) {
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
+			return
+		}
+		if errors.Is(err, service.ErrUnknownOrgRole) {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse("validation_error", err.Error()))
+			return
+		}
+		if errors.Is(err, repository.ErrOrgMemberLimit) {
+			c.JSON(http.StatusConflict, dto.NewErrorResponse("member_limit_reached", err.Error()))
+			return
+		}
 		if err.Error() == "user not found" {
 			c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", err.Error()))
 			return
@@ -328,6 +350,14 @@ func (h *OrganizationHandler) UpdateMemberRole(c *gin.Context) {
 			c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
 			return
 		}
+		if errors.Is(err, service.ErrOwnerRequired) {
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
+			return
+		}
+		if errors.Is(err, service.ErrUnknownOrgRole) {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse("validation_error", err.Error()))
+			return
+		}
 		if err.Error() == "user is not a member of this organization" {
 			c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", err.Error()))
 			return
@@ -370,6 +400,10 @@ func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
 			c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
 			return
 		}
+		if errors.Is(err, service.ErrOwnerRequired) {
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
+			return
+		}
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", "Member not found"))
 			return
@@ -434,6 +468,18 @@ func (h *OrganizationHandler) BulkAddMembers(c *gin.Context) {
 			c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
 			return
 		}
+		if errors.Is(err, service.ErrOwnerRequired) {
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
+			return
+		}
+		if errors.Is(err, service.ErrUnknownOrgRole) {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse("validation_error", err.Error()))
+			return
+		}
+		if errors.Is(err, repository.ErrOrgMemberLimit) {
+			c.JSON(http.StatusConflict, dto.NewErrorResponse("member_limit_reached", err.Error()))
+			return
+		}
 		logger.Error("Failed to bulk add org members", err)
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", "Failed to bulk add members"))
 		return
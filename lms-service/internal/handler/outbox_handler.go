@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/service"
+	"example/hello/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OutboxHandler struct {
+	outboxService *service.OutboxService
+}
+
+func NewOutboxHandler(outboxService *service.OutboxService) *OutboxHandler {
+	return &OutboxHandler{
+		outboxService: outboxService,
+	}
+}
+
+// ListEvents godoc
+// @Summary List outbox events
+// @Description Inspect Kafka events waiting in, published from or stuck in the outbox
+// @Tags Admin Outbox
+// @Produce json
+// @Param status query string false "PENDING, PUBLISHED, DEAD or DISCARDED"
+// @Param topic query string false "Topic"
+// @Param page query int false "Page"
+// @Param limit query int false "Page size"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse{data=dto.ListResponse}
+// @Router /admin/outbox [get]
+func (h *OutboxHandler) ListEvents(c *gin.Context) {
+	var req dto.ListOutboxEventsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	result, err := h.outboxService.ListEvents(c.Request.Context(), &req)
+	if err != nil {
+		logger.Error("Failed to list outbox events", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", "Failed to list outbox events"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(result))
+}
+
+// GetStats godoc
+// @Summary Get outbox backlog statistics
+// @Tags Admin Outbox
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse{data=dto.OutboxStatsResponse}
+// @Router /admin/outbox/stats [get]
+func (h *OutboxHandler) GetStats(c *gin.Context) {
+	stats, err := h.outboxService.GetStats(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to get outbox stats", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", "Failed to get outbox stats"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(stats))
+}
+
+// GetEvent godoc
+// @Summary Get an outbox event
+// @Tags Admin Outbox
+// @Produce json
+// @Param id path int true "Outbox event ID"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse{data=dto.OutboxEventResponse}
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/outbox/{id} [get]
+func (h *OutboxHandler) GetEvent(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid outbox event ID"))
+		return
+	}
+
+	event, err := h.outboxService.GetEvent(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", "Outbox event not found"))
+			return
+		}
+		logger.Error("Failed to get outbox event", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", "Failed to get outbox event"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(event))
+}
+
+// ReplayEvent godoc
+// @Summary Replay an outbox event
+// @Description Makes a DEAD, DISCARDED or backed-off event due now with a fresh attempt budget
+// @Tags Admin Outbox
+// @Produce json
+// @Param id path int true "Outbox event ID"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/outbox/{id}/replay [post]
+func (h *OutboxHandler) ReplayEvent(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid outbox event ID"))
+		return
+	}
+
+	if err := h.outboxService.ReplayEvent(c.Request.Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", "Unpublished outbox event not found"))
+			return
+		}
+		logger.Error("Failed to replay outbox event", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", "Failed to replay outbox event"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMessageResponse("Outbox event scheduled for replay"))
+}
+
+// ReplayDead godoc
+// @Summary Replay all dead outbox events
+// @Tags Admin Outbox
+// @Accept json
+// @Produce json
+// @Param request body dto.ReplayOutboxRequest false "Optional topic filter"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse{data=dto.ReplayOutboxResponse}
+// @Router /admin/outbox/replay [post]
+func (h *OutboxHandler) ReplayDead(c *gin.Context) {
+	var req dto.ReplayOutboxRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+			return
+		}
+	}
+
+	n, err := h.outboxService.ReplayDead(c.Request.Context(), req.Topic)
+	if err != nil {
+		logger.Error("Failed to replay dead outbox events", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", "Failed to replay outbox events"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewDataResponse(dto.ReplayOutboxResponse{Replayed: n}))
+}
+
+// DiscardEvent godoc
+// @Summary Discard an outbox event
+// @Description Gives up on an unpublished event so later events with the same key can be published
+// @Tags Admin Outbox
+// @Produce json
+// @Param id path int true "Outbox event ID"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/outbox/{id}/discard [post]
+func (h *OutboxHandler) DiscardEvent(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid outbox event ID"))
+		return
+	}
+
+	if err := h.outboxService.DiscardEvent(c.Request.Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", "Unpublished outbox event not found"))
+			return
+		}
+		logger.Error("Failed to discard outbox event", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", "Failed to discard outbox event"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMessageResponse("Outbox event discarded"))
+}
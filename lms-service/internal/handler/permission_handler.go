@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -72,3 +73,49 @@ func (h *PermissionHandler) AssignPermissions(c *gin.Context) {
 
 	c.JSON(http.StatusOK, dto.NewMessageResponse("Permissions updated"))
 }
+
+// GetOrgRolePermissions returns the permissions of a role usable in the organization.
+// GET /api/v1/organizations/:id/roles/:roleId/permissions
+func (h *PermissionHandler) GetOrgRolePermissions(c *gin.Context) {
+	orgID, roleID, ok := parseOrgRoleIDs(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.permService.GetOrgRolePermissions(c.Request.Context(), orgID, roleID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewDataResponse(resp))
+}
+
+// AssignOrgRolePermissions replaces the permissions of one of the organization's own roles.
+// PUT /api/v1/organizations/:id/roles/:roleId/permissions
+func (h *PermissionHandler) AssignOrgRolePermissions(c *gin.Context) {
+	orgID, roleID, ok := parseOrgRoleIDs(c)
+	if !ok {
+		return
+	}
+
+	var body dto.AssignPermissionsRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	err := h.permService.AssignOrgRolePermissions(c.Request.Context(), orgID, roleID, body.PermissionIDs)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, dto.NewMessageResponse("Permissions updated"))
+	case errors.Is(err, service.ErrOrgRoleNotFound):
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", err.Error()))
+	case errors.Is(err, service.ErrOrgRoleTemplate):
+		c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", err.Error()))
+	case errors.Is(err, service.ErrOrgRolePermission):
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+	default:
+		logger.Error("AssignOrgRolePermissions failed", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("db_error", err.Error()))
+	}
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -59,6 +61,10 @@ func (h *RoleAdminHandler) UpdateRole(c *gin.Context) {
 	}
 
 	if err := h.roleService.UpdateRole(c.Request.Context(), id, &req); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", "Role not found"))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("update_failed", err.Error()))
 		return
 	}
@@ -75,6 +81,10 @@ func (h *RoleAdminHandler) DeleteRole(c *gin.Context) {
 	}
 
 	if err := h.roleService.DeleteRole(c.Request.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", "Role not found"))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("delete_failed", err.Error()))
 		return
 	}
@@ -82,6 +92,107 @@ func (h *RoleAdminHandler) DeleteRole(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// ListOrgRoles lists the roles members of an organization can hold.
+// GET /api/v1/organizations/:id/roles
+func (h *RoleAdminHandler) ListOrgRoles(c *gin.Context) {
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid organization ID"))
+		return
+	}
+
+	roles, err := h.roleService.ListOrgRoles(c.Request.Context(), orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("internal_error", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// CreateOrgRole creates a role owned by the organization.
+// POST /api/v1/organizations/:id/roles
+func (h *RoleAdminHandler) CreateOrgRole(c *gin.Context) {
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid organization ID"))
+		return
+	}
+
+	var req dto.RoleDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	role, err := h.roleService.CreateOrgRole(c.Request.Context(), orgID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("creation_failed", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateOrgRole edits one of the organization's own roles.
+// PUT /api/v1/organizations/:id/roles/:roleId
+func (h *RoleAdminHandler) UpdateOrgRole(c *gin.Context) {
+	orgID, roleID, ok := parseOrgRoleIDs(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RoleDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_request", err.Error()))
+		return
+	}
+
+	if err := h.roleService.UpdateOrgRole(c.Request.Context(), orgID, roleID, &req); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", "Role not found in this organization"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("update_failed", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated successfully"})
+}
+
+// DeleteOrgRole deletes one of the organization's own roles.
+// DELETE /api/v1/organizations/:id/roles/:roleId
+func (h *RoleAdminHandler) DeleteOrgRole(c *gin.Context) {
+	orgID, roleID, ok := parseOrgRoleIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.roleService.DeleteOrgRole(c.Request.Context(), orgID, roleID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("not_found", "Role not found in this organization"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("delete_failed", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func parseOrgRoleIDs(c *gin.Context) (orgID, roleID int64, ok bool) {
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid organization ID"))
+		return 0, 0, false
+	}
+	roleID, err = strconv.ParseInt(c.Param("roleId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("invalid_id", "Invalid role ID"))
+		return 0, 0, false
+	}
+	return orgID, roleID, true
+}
+
 func (h *RoleAdminHandler) GetUserRoles(c *gin.Context) {
 	idStr := c.Param("userId")
 	userID, err := strconv.ParseInt(idStr, 10, 64)
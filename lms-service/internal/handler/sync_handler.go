@@ -13,26 +13,13 @@ import (
 
 type UserSyncHandler struct {
 	syncService *service.UserSyncService
-	syncSecret  string
 }
 
-func NewUserSyncHandler(syncService *service.UserSyncService, syncSecret string) *UserSyncHandler {
+// NewUserSyncHandler builds the handler for /sync routes; callers are
+// authenticated by middleware.ServiceAuth with the sync scope.
+func NewUserSyncHandler(syncService *service.UserSyncService) *UserSyncHandler {
 	return &UserSyncHandler{
 		syncService: syncService,
-		syncSecret:  syncSecret,
-	}
-}
-
-// SyncSecret middleware validates the sync secret
-func (h *UserSyncHandler) SyncSecret() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		secret := c.GetHeader("X-Sync-Secret")
-		if secret == "" || secret != h.syncSecret {
-			c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("unauthorized", "Invalid sync secret"))
-			c.Abort()
-			return
-		}
-		c.Next()
 	}
 }
 
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"example/hello/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditContext puts the authenticated caller on the request context so
+// services can attribute audit log entries. Register it after the
+// authentication and LoadLocalRoles middleware.
+func AuditContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := service.AuditActor{
+			UserID:    c.GetInt64("user_id"),
+			Role:      c.GetString("user_role"),
+			Service:   c.GetString("service_name"),
+			RequestID: c.GetString("request_id"),
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}
+		c.Request = c.Request.WithContext(service.WithAuditActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
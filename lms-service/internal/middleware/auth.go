@@ -2,16 +2,18 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
 	"example/hello/internal/dto"
 	"example/hello/internal/repository"
 	"example/hello/pkg/cache"
+	"example/hello/pkg/jwks"
 	"example/hello/pkg/logger"
+	"example/hello/pkg/svcauth"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -25,8 +27,10 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-// AuthMiddleware validates JWT token and sets user info in context
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// AuthMiddleware validates JWT token and sets user info in context. Tokens
+// are verified against the auth service's JWKS, or the shared HMAC secret
+// during the migration window.
+func AuthMiddleware(verifier *jwks.Verifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from Authorization header or Cookie
 		var tokenString string
@@ -55,13 +59,7 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		}
 
 		// Parse and validate token
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			// Validate signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(jwtSecret), nil
-		})
+		token, err := verifier.Parse(c.Request.Context(), tokenString, &Claims{})
 
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("unauthorized", "Invalid or expired token"))
@@ -117,29 +115,32 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 	}
 }
 
-// ServiceOrAuthMiddleware allows access via either a valid JWT OR a shared service secret
-func ServiceOrAuthMiddleware(jwtSecret string, serviceSecret string) gin.HandlerFunc {
+// Route-group scopes granted to internal callers (see config.ServiceAuthConfig)
+const (
+	ScopeSync        = "sync"
+	ScopeFiles       = "files"
+	ScopeCourses     = "courses"
+	ScopeEnrollments = "enrollments"
+	ScopeInternal    = "internal"
+)
+
+// ServiceAuth admits only internal services holding scope
+func ServiceAuth(services *svcauth.Verifier, scope string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 1. First check for Service Secret (used by AI service and other internal services)
-		apiSecret := c.GetHeader("X-API-Secret")
-		if apiSecret == "" {
-			apiSecret = c.GetHeader("X-Sync-Secret")
-		}
-
-		if apiSecret != "" && apiSecret == serviceSecret {
-			// Authorized as internal service
-			userID := int64(0)
-			if userIDStr := c.GetHeader("X-User-Id"); userIDStr != "" {
-				if id, err := strconv.ParseInt(userIDStr, 10, 64); err == nil {
-					userID = id
-				}
-			}
-			
-			c.Set("user_id", userID) 
-			c.Set("user_email", "system@bdc.internal")
-			c.Set("user_roles", []string{"ADMIN", "TEACHER"})
-			c.Set("user_role", "ADMIN")
-			c.Next()
+		if authenticateService(c, services, scope) {
+			return
+		}
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("unauthorized", "Missing service credentials"))
+		c.Abort()
+	}
+}
+
+// ServiceOrAuthMiddleware allows access via either a valid JWT OR a signed
+// request from an internal service holding scope
+func ServiceOrAuthMiddleware(verifier *jwks.Verifier, services *svcauth.Verifier, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 1. First check for service credentials (AI service, auth service)
+		if authenticateService(c, services, scope) {
 			return
 		}
 
@@ -171,13 +172,7 @@ func ServiceOrAuthMiddleware(jwtSecret string, serviceSecret string) gin.Handler
 		}
 
 		// Parse and validate token
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			// Validate signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(jwtSecret), nil
-		})
+		token, err := verifier.Parse(c.Request.Context(), tokenString, &Claims{})
 
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("unauthorized", "Invalid or expired token"))
@@ -233,6 +228,82 @@ func ServiceOrAuthMiddleware(jwtSecret string, serviceSecret string) gin.Handler
 	}
 }
 
+// authenticateService verifies service credentials on the request. It
+// returns false only when there are none, leaving the caller to try user
+// auth; otherwise it has either aborted or run the rest of the chain.
+func authenticateService(c *gin.Context, services *svcauth.Verifier, scope string) bool {
+	caller, err := verifiedService(c, services)
+	if errors.Is(err, svcauth.ErrNoCredentials) {
+		return false
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, svcauth.ErrReplay):
+			c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("replayed_request", "Request nonce has already been used"))
+		case errors.Is(err, svcauth.ErrBodyTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, dto.NewErrorResponse("payload_too_large", "Request body too large"))
+		case errors.Is(err, svcauth.ErrUnknownService), errors.Is(err, svcauth.ErrBadSignature),
+			errors.Is(err, svcauth.ErrStale), errors.Is(err, svcauth.ErrMalformed):
+			logger.Warn(fmt.Sprintf("Rejected service request to %s: %v", c.Request.URL.Path, err))
+			c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("unauthorized", "Invalid service credentials"))
+		default:
+			logger.Error("Failed to verify service request", err)
+			c.JSON(http.StatusServiceUnavailable, dto.NewErrorResponse("service_unavailable", "Unable to verify service credentials"))
+		}
+		c.Abort()
+		return true
+	}
+
+	if !caller.HasScope(scope) {
+		logger.Warn(fmt.Sprintf("Service %s lacks scope %q for %s", caller.Service, scope, c.Request.URL.Path))
+		c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", "Service is not allowed to call this endpoint"))
+		c.Abort()
+		return true
+	}
+	if caller.Legacy {
+		logger.Warn(fmt.Sprintf("Service %s used a legacy static secret for %s", caller.Service, c.Request.URL.Path))
+	}
+
+	c.Set("service_name", caller.Service)
+	if caller.UserID > 0 {
+		// Acting for a user: LoadLocalRoles applies that user's own roles
+		c.Set("user_id", caller.UserID)
+		c.Set("user_email", "")
+		c.Set("user_roles", []string{})
+		c.Set("user_role", "")
+	} else {
+		c.Set("user_id", int64(0))
+		c.Set("user_email", "system@bdc.internal")
+		c.Set("user_roles", []string{"ADMIN", "TEACHER"})
+		c.Set("user_role", "ADMIN")
+	}
+	c.Next()
+	return true
+}
+
+const serviceCallerKey = "svcauth_result"
+
+type serviceResult struct {
+	caller *svcauth.Caller
+	err    error
+}
+
+// verifiedService verifies the request's service credentials once and
+// caches the outcome on c: Verify claims the nonce, so a second call in the
+// same request would report a replay
+func verifiedService(c *gin.Context, services *svcauth.Verifier) (*svcauth.Caller, error) {
+	if v, ok := c.Get(serviceCallerKey); ok {
+		r := v.(serviceResult)
+		return r.caller, r.err
+	}
+	if services == nil {
+		return nil, svcauth.ErrNoCredentials
+	}
+	caller, err := services.Verify(c.Request)
+	c.Set(serviceCallerKey, serviceResult{caller: caller, err: err})
+	return caller, err
+}
+
 // normalizeRole converts role strings (for backward compatibility)
 // Note: Roles now come from Java already normalized, so this is mainly for reference
 func normalizeRole(role string) string {
@@ -324,7 +395,7 @@ func RequireRoles(roles ...string) gin.HandlerFunc {
 }
 
 // OptionalAuth is similar to AuthMiddleware but doesn't abort if token is missing
-func OptionalAuth(jwtSecret string) gin.HandlerFunc {
+func OptionalAuth(verifier *jwks.Verifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -339,12 +410,7 @@ func OptionalAuth(jwtSecret string) gin.HandlerFunc {
 		}
 
 		tokenString := parts[1]
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(jwtSecret), nil
-		})
+		token, err := verifier.Parse(c.Request.Context(), tokenString, &Claims{})
 
 		if err != nil {
 			c.Next()
@@ -79,7 +79,7 @@ func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get request ID from header or generate new one
 		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
+		if !validRequestID(requestID) {
 			requestID = generateRequestID()
 		}
 
@@ -91,6 +91,20 @@ func RequestID() gin.HandlerFunc {
 	}
 }
 
+// validRequestID accepts caller-supplied IDs that are safe to log and to
+// store in the audit log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, ch := range id {
+		if !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '-' || ch == '_' || ch == '.') {
+			return false
+		}
+	}
+	return true
+}
+
 // generateRequestID generates a unique request ID
 func generateRequestID() string {
 	// Simple timestamp-based ID
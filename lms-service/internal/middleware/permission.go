@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
 
 	"example/hello/internal/dto"
 	"example/hello/internal/service"
@@ -10,19 +11,50 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// OrgScope resolves the organization a request acts on, so a permission can
+// also be granted by the user's role in that organization. It returns 0
+// when the request is not tied to one.
+type OrgScope func(c *gin.Context) (int64, error)
+
+// OrgFromParam takes the organization ID from a route parameter
+func OrgFromParam(name string) OrgScope {
+	return func(c *gin.Context) (int64, error) {
+		return strconv.ParseInt(c.Param(name), 10, 64)
+	}
+}
+
+// OrgFromCourse takes the organization of the course named by a route parameter
+func OrgFromCourse(permService *service.PermissionService, param string) OrgScope {
+	return func(c *gin.Context) (int64, error) {
+		courseID, err := strconv.ParseInt(c.Param(param), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return permService.CourseOrgID(c.Request.Context(), courseID)
+	}
+}
+
 // RequirePermission returns a Gin middleware that checks whether the current
 // user's primary role has the specified permission code.
 // ADMIN always passes (super-admin bypass handled inside PermissionService).
-func RequirePermission(permService *service.PermissionService, code string) gin.HandlerFunc {
+// With an OrgScope, the role the user holds in that organization can grant
+// the permission too.
+func RequirePermission(permService *service.PermissionService, code string, scope ...OrgScope) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		roleName := c.GetString("user_role")
-		if roleName == "" {
+		if roleName == "" && len(scope) == 0 {
 			c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", "No role in context"))
 			c.Abort()
 			return
 		}
 
 		allowed, err := permService.CheckPermission(c.Request.Context(), roleName, code)
+		if err == nil && !allowed && len(scope) > 0 {
+			var orgID int64
+			if orgID, err = scope[0](c); err == nil {
+				allowed, err = permService.HasOrgPermission(c.Request.Context(), c.GetInt64("user_id"), orgID, code)
+			}
+		}
 		if err != nil {
 			logger.Warn("Permission check error: " + err.Error())
 			c.JSON(http.StatusForbidden, dto.NewErrorResponse("forbidden", "Permission check failed"))
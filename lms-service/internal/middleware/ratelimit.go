@@ -1,143 +1,171 @@
 package middleware
 
 import (
+	"context"
+	"crypto/subtle"
 	"fmt"
-	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	"example/hello/internal/dto"
-	"example/hello/pkg/cache"
+	"example/hello/pkg/logger"
+	"example/hello/pkg/ratelimit"
+	"example/hello/pkg/svcauth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
-// isPrivateOrLocalIP checks if an IP address is a loopback or within private subnet ranges
-// (RFC 1918: 10.0.0.0/8, 172.16.0.0/12, 192.168.0.0/16).
-func isPrivateOrLocalIP(ipStr string) bool {
-	ip := net.ParseIP(ipStr)
-	if ip == nil {
-		return false
-	}
-	if ip.IsLoopback() {
-		return true
-	}
-	ip4 := ip.To4()
-	if ip4 != nil {
-		// 10.0.0.0/8
-		if ip4[0] == 10 {
-			return true
-		}
-		// 172.16.0.0/12 (172.16.x.x - 172.31.x.x)
-		if ip4[0] == 172 && ip4[1] >= 16 && ip4[1] <= 31 {
-			return true
-		}
-		// 192.168.0.0/16
-		if ip4[0] == 192 && ip4[1] == 168 {
-			return true
-		}
+// Rate limit policies attached to route groups in cmd/api
+const (
+	RatePolicyAIGeneration = "ai_generation"
+	RatePolicyUpload       = "upload"
+	RatePolicyQuizSubmit   = "quiz_submit"
+)
+
+var rateLimitDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "rate_limit_decisions_total",
+	Help: "Rate limit decisions by policy and result (allowed, limited, exempt).",
+}, []string{"policy", "result"})
+
+// OrgIDsFunc returns the organizations a user belongs to
+type OrgIDsFunc func(ctx context.Context, userID int64) ([]int64, error)
+
+// RateLimiter enforces a per-IP global budget on every request and named
+// policies on selected route groups. Exemptions come only from the
+// configured override list, never from the caller's network alone. A nil
+// RateLimiter admits everything.
+type RateLimiter struct {
+	store     ratelimit.Store
+	global    ratelimit.Policy
+	policies  map[string]ratelimit.Policy
+	overrides ratelimit.Overrides
+	orgIDs    OrgIDsFunc
+}
+
+func NewRateLimiter(store ratelimit.Store, global ratelimit.Policy, policies []ratelimit.Policy, overrides ratelimit.Overrides, orgIDs OrgIDsFunc) *RateLimiter {
+	byName := make(map[string]ratelimit.Policy, len(policies))
+	for _, p := range policies {
+		byName[p.Name] = p
 	}
-	return false
+	return &RateLimiter{store: store, global: global, policies: byName, overrides: overrides, orgIDs: orgIDs}
 }
 
-// RateLimit middleware limits requests per IP address
-func RateLimit(redisCache *cache.RedisCache, aiSecret string) gin.HandlerFunc {
+// Global limits every request per client IP. It runs before authentication,
+// so it verifies service signatures itself: exempt services, override
+// networks and the legacy AI service secret skip it. The verified caller is
+// kept on the context for the service auth middleware, so each nonce is
+// claimed once.
+func (l *RateLimiter) Global(aiSecret string, services *svcauth.Verifier) gin.HandlerFunc {
+	if l == nil {
+		return passThrough
+	}
 	return func(c *gin.Context) {
-		// Bypass rate limit for internal AI service calls
-		if aiSecret != "" && c.GetHeader("X-API-Secret") == aiSecret {
+		path := c.Request.URL.Path
+		if path == "/health" || path == "/metrics" ||
+			// Public file serving is cached by browsers and the CDN
+			strings.HasPrefix(path, "/api/v1/files/serve") || strings.HasPrefix(path, "/api/v1/files/download") {
 			c.Next()
 			return
 		}
-
-		// Bypass rate limit for health check
-		if c.Request.URL.Path == "/health" {
+		if aiSecret != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("X-API-Secret")), []byte(aiSecret)) == 1 {
 			c.Next()
 			return
 		}
-
-		// Bypass rate limit for public file serving endpoints
-		if strings.HasPrefix(c.Request.URL.Path, "/api/v1/files/serve") || strings.HasPrefix(c.Request.URL.Path, "/api/v1/files/download") {
+		// Invalid credentials are bucketed like anonymous traffic and
+		// rejected later by the route's auth middleware
+		if caller, err := verifiedService(c, services); err == nil && l.overrides.ExemptService(caller.Service) {
+			rateLimitDecisions.WithLabelValues(l.global.Name, "exempt").Inc()
 			c.Next()
 			return
 		}
 
-		// Get client IP
 		ip := c.ClientIP()
-
-		// Bypass rate limit for localhost and private network/intranet IPs (e.g. frontend, VPN, campus LAN)
-		if isPrivateOrLocalIP(ip) {
+		if l.overrides.ExemptIP(ip) {
+			rateLimitDecisions.WithLabelValues(l.global.Name, "exempt").Inc()
 			c.Next()
 			return
 		}
-		
-		// Create rate limit key
-		key := fmt.Sprintf("ratelimit:%s", ip)
-		
-		// Increment request count
-		count, err := redisCache.IncrementWithExpiry(c.Request.Context(), key, 1*time.Minute)
-		if err != nil {
-			// If Redis is down, allow the request
-			c.Next()
-			return
-		}
-
-		// Set rate limit headers
-		c.Writer.Header().Set("X-RateLimit-Limit", "1000")
-		c.Writer.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", max(0, 1000-count)))
-		c.Writer.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", time.Now().Add(1*time.Minute).Unix()))
-
-		// Check if limit exceeded
-		if count > 1000 {
-			c.JSON(http.StatusTooManyRequests, dto.NewErrorResponse("rate_limit_exceeded", "Too many requests. Please try again later."))
-			c.Abort()
-			return
-		}
-
-		c.Next()
+		l.enforce(c, l.global, ratelimit.Subject{IP: ip})
 	}
 }
 
-// RateLimitByUser limits requests per authenticated user
-func RateLimitByUser(redisCache *cache.RedisCache, limit int64, window time.Duration) gin.HandlerFunc {
+// Policy limits a route group by the named policy. Register it after the
+// authentication middleware so the caller's user, roles and organizations
+// select the rule; it panics at startup on an unknown name.
+func (l *RateLimiter) Policy(name string) gin.HandlerFunc {
+	if l == nil {
+		return passThrough
+	}
+	policy, ok := l.policies[name]
+	if !ok {
+		panic(fmt.Sprintf("rate limit policy %q is not configured", name))
+	}
+
 	return func(c *gin.Context) {
-		// Get user ID from context
-		userID, exists := c.Get("user_id")
-		if !exists {
-			// If not authenticated, use IP-based rate limiting
-			c.Next()
-			return
+		subject := ratelimit.Subject{IP: c.ClientIP()}
+		if id, ok := c.Get("user_id"); ok {
+			subject.UserID, _ = id.(int64)
+		}
+		if roles, ok := c.Get("user_roles"); ok {
+			subject.Roles, _ = roles.([]string)
 		}
 
-		// Create rate limit key
-		key := fmt.Sprintf("ratelimit:user:%v", userID)
-		
-		// Increment request count
-		count, err := redisCache.IncrementWithExpiry(c.Request.Context(), key, window)
-		if err != nil {
+		if l.overrides.ExemptService(c.GetString("service_name")) ||
+			l.overrides.ExemptUser(subject.UserID) || l.overrides.ExemptIP(subject.IP) {
+			rateLimitDecisions.WithLabelValues(policy.Name, "exempt").Inc()
 			c.Next()
 			return
 		}
 
-		// Set rate limit headers
-		c.Writer.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", limit))
-		c.Writer.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", max(0, limit-count)))
-		c.Writer.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", time.Now().Add(window).Unix()))
-
-		// Check if limit exceeded
-		if count > limit {
-			c.JSON(http.StatusTooManyRequests, dto.NewErrorResponse("rate_limit_exceeded", "Too many requests. Please try again later."))
-			c.Abort()
-			return
+		if policy.NeedsOrgs() && subject.UserID > 0 && l.orgIDs != nil {
+			orgIDs, err := l.orgIDs(c.Request.Context(), subject.UserID)
+			if err != nil {
+				// Fall back to the role or default rule
+				logger.Warn(fmt.Sprintf("Rate limit: failed to load organizations of user %d: %v", subject.UserID, err))
+			}
+			subject.OrgIDs = orgIDs
 		}
+		l.enforce(c, policy, subject)
+	}
+}
+
+func passThrough(c *gin.Context) { c.Next() }
 
+func (l *RateLimiter) enforce(c *gin.Context, policy ratelimit.Policy, subject ratelimit.Subject) {
+	rule := policy.RuleFor(subject)
+	if rule.Unlimited() {
+		rateLimitDecisions.WithLabelValues(policy.Name, "allowed").Inc()
 		c.Next()
+		return
 	}
-}
 
-// max returns the maximum of two int64 values
-func max(a, b int64) int64 {
-	if a > b {
-		return a
+	res, err := l.store.Allow(c.Request.Context(), policy.Key(subject), rule)
+	if err != nil {
+		// Never turn a limiter failure into an outage
+		logger.Error("Rate limit check failed for policy "+policy.Name, err)
+		c.Next()
+		return
 	}
-	return b
-}
\ No newline at end of file
+
+	h := c.Writer.Header()
+	h.Set("X-RateLimit-Policy", policy.Name)
+	h.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(res.ResetAfter).Unix(), 10))
+
+	if !res.Allowed {
+		rateLimitDecisions.WithLabelValues(policy.Name, "limited").Inc()
+		retryAfter := ratelimit.RetryAfterSeconds(res.RetryAfter)
+		h.Set("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, dto.NewErrorResponse("rate_limit_exceeded",
+			fmt.Sprintf("Too many requests. Please try again in %d seconds.", retryAfter)))
+		c.Abort()
+		return
+	}
+	rateLimitDecisions.WithLabelValues(policy.Name, "allowed").Inc()
+	c.Next()
+}
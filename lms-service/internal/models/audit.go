@@ -0,0 +1,49 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Audited actions, stored in audit_log.action
+const (
+	AuditRoleAssign          = "role.assign"
+	AuditRoleRemove          = "role.remove"
+	AuditRolePermissionsSet  = "role.permissions.set"
+	AuditOrgMemberAdd        = "org.member.add"
+	AuditOrgMemberRemove     = "org.member.remove"
+	AuditOrgMemberRoleChange = "org.member.role_change"
+	AuditCourseDelete        = "course.delete"
+	AuditAnswerGrade         = "quiz.answer.grade"
+	AuditAnswerBulkGrade     = "quiz.answer.bulk_grade"
+)
+
+// Audit target types
+const (
+	AuditTargetUser   = "user"
+	AuditTargetRole   = "role"
+	AuditTargetCourse = "course"
+	AuditTargetAnswer = "quiz_answer"
+	AuditTargetQuiz   = "quiz"
+)
+
+// AuditEntry is one row of the append-only audit log
+type AuditEntry struct {
+	ID           int64           `json:"id" db:"id"`
+	OccurredAt   time.Time       `json:"occurred_at" db:"occurred_at"`
+	ActorID      sql.NullInt64   `json:"actor_id" db:"actor_id"`
+	ActorRole    string          `json:"actor_role" db:"actor_role"`
+	ActorService string          `json:"actor_service" db:"actor_service"`
+	Action       string          `json:"action" db:"action"`
+	TargetType   string          `json:"target_type" db:"target_type"`
+	TargetID     string          `json:"target_id" db:"target_id"`
+	OrgID        sql.NullInt64   `json:"org_id" db:"org_id"`
+	CourseID     sql.NullInt64   `json:"course_id" db:"course_id"`
+	Reason       sql.NullString  `json:"reason" db:"reason"`
+	Before       json.RawMessage `json:"before" db:"before_state"`
+	After        json.RawMessage `json:"after" db:"after_state"`
+	RequestID    string          `json:"request_id" db:"request_id"`
+	IPAddress    string          `json:"ip_address" db:"ip_address"`
+	UserAgent    string          `json:"user_agent" db:"user_agent"`
+}
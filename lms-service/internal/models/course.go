@@ -105,3 +105,12 @@ type CourseCoTeacherWithUser struct {
 	Email    string `json:"email" db:"email"`
 	AvatarURL string `json:"avatar_url" db:"avatar_url"`
 }
+
+// CourseMember is one role a user holds in a course: CREATOR, CO_TEACHER or
+// STUDENT (accepted enrollments only)
+type CourseMember struct {
+	UserID   int64  `json:"user_id" db:"user_id"`
+	Email    string `json:"email" db:"email"`
+	FullName string `json:"full_name" db:"full_name"`
+	Role     string `json:"role" db:"role"`
+}
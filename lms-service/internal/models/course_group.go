@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// CourseGroup is a named subset of a course's students
+type CourseGroup struct {
+	ID          int64     `json:"id" db:"id"`
+	CourseID    int64     `json:"course_id" db:"course_id"`
+	Name        string    `json:"name" db:"name"`
+	CreatedBy   int64     `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	MemberCount int       `json:"member_count" db:"member_count"`
+}
+
+// CourseGroupMember is a student in a course group
+type CourseGroupMember struct {
+	GroupID   int64     `json:"group_id" db:"group_id"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	Email     string    `json:"email" db:"email"`
+	FullName  string    `json:"full_name" db:"full_name"`
+	AvatarURL string    `json:"avatar_url" db:"avatar_url"`
+	AddedAt   time.Time `json:"added_at" db:"added_at"`
+}
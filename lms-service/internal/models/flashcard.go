@@ -53,3 +53,82 @@ type FlashcardWithRepetition struct {
 	NextReviewDate sql.NullTime    `json:"next_review_date" db:"next_review_date"`
 	LastReviewedAt sql.NullTime    `json:"last_reviewed_at" db:"last_reviewed_at"`
 }
+
+// Flashcard deck status constants
+const (
+	FlashcardDeckStatusDraft     = "DRAFT"
+	FlashcardDeckStatusPublished = "PUBLISHED"
+	FlashcardDeckStatusArchived  = "ARCHIVED"
+)
+
+// FlashcardDeck is a teacher-authored deck shared with every enrolled student
+type FlashcardDeck struct {
+	ID          int64          `json:"id" db:"id"`
+	CourseID    int64          `json:"course_id" db:"course_id"`
+	NodeID      sql.NullInt64  `json:"node_id" db:"node_id"`
+	Title       string         `json:"title" db:"title"`
+	Description sql.NullString `json:"description" db:"description"`
+	Status      string         `json:"status" db:"status"`
+	Version     int            `json:"version" db:"version"`
+	CreatedBy   int64          `json:"created_by" db:"created_by"`
+	PublishedAt sql.NullTime   `json:"published_at" db:"published_at"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+	CardCount   int            `json:"card_count" db:"card_count"`
+}
+
+// FlashcardDeckCard is a card inside a shared deck. Edits keep the ID and
+// bump Revision so per-student progress survives deck updates.
+type FlashcardDeckCard struct {
+	ID               int64     `json:"id" db:"id"`
+	DeckID           int64     `json:"deck_id" db:"deck_id"`
+	FrontText        string    `json:"front_text" db:"front_text"`
+	BackText         string    `json:"back_text" db:"back_text"`
+	OrderIndex       int       `json:"order_index" db:"order_index"`
+	Revision         int       `json:"revision" db:"revision"`
+	AddedInVersion   int       `json:"added_in_version" db:"added_in_version"`
+	UpdatedInVersion int       `json:"updated_in_version" db:"updated_in_version"`
+	IsActive         bool      `json:"is_active" db:"is_active"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// FlashcardDeckCardWithRepetition joins a deck card with one student's SM-2
+// state; the repetition columns are NULL until the student first reviews it.
+type FlashcardDeckCardWithRepetition struct {
+	FlashcardDeckCard
+	RepetitionID   sql.NullInt64   `json:"repetition_id" db:"repetition_id"`
+	EasinessFactor sql.NullFloat64 `json:"easiness_factor" db:"easiness_factor"`
+	IntervalDays   sql.NullInt32   `json:"interval_days" db:"interval_days"`
+	Repetitions    sql.NullInt32   `json:"repetitions" db:"repetitions"`
+	NextReviewDate sql.NullTime    `json:"next_review_date" db:"next_review_date"`
+	LastReviewedAt sql.NullTime    `json:"last_reviewed_at" db:"last_reviewed_at"`
+	SeenRevision   sql.NullInt32   `json:"seen_revision" db:"seen_revision"`
+	DeckTitle      string          `json:"deck_title" db:"deck_title"`
+}
+
+// FlashcardDeckRepetition is one student's SM-2 state for a shared deck card
+type FlashcardDeckRepetition struct {
+	ID             int64        `json:"id" db:"id"`
+	StudentID      int64        `json:"student_id" db:"student_id"`
+	DeckCardID     int64        `json:"deck_card_id" db:"deck_card_id"`
+	DeckID         int64        `json:"deck_id" db:"deck_id"`
+	CourseID       int64        `json:"course_id" db:"course_id"`
+	EasinessFactor float64      `json:"easiness_factor" db:"easiness_factor"`
+	IntervalDays   int          `json:"interval_days" db:"interval_days"`
+	Repetitions    int          `json:"repetitions" db:"repetitions"`
+	QualityLast    int          `json:"quality_last" db:"quality_last"`
+	NextReviewDate time.Time    `json:"next_review_date" db:"next_review_date"`
+	LastReviewedAt sql.NullTime `json:"last_reviewed_at" db:"last_reviewed_at"`
+	SeenRevision   int          `json:"seen_revision" db:"seen_revision"`
+}
+
+// FlashcardDeckVersion is a changelog entry for a published deck
+type FlashcardDeckVersion struct {
+	ID        int64         `json:"id" db:"id"`
+	DeckID    int64         `json:"deck_id" db:"deck_id"`
+	Version   int           `json:"version" db:"version"`
+	Summary   string        `json:"summary" db:"summary"`
+	ChangedBy sql.NullInt64 `json:"changed_by" db:"changed_by"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+}
@@ -19,6 +19,9 @@ type ForumPost struct {
 	ViewCount    int            `json:"view_count" db:"view_count"`
 	IsPinned     bool           `json:"is_pinned" db:"is_pinned"`
 	IsLocked     bool           `json:"is_locked" db:"is_locked"`
+	IsHidden     bool           `json:"is_hidden" db:"is_hidden"`
+	HiddenReason sql.NullString `json:"hidden_reason" db:"hidden_reason"`
+	ReportCount  int            `json:"report_count" db:"report_count"`
 	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at" db:"updated_at"`
 }
@@ -29,6 +32,9 @@ type ForumPostWithUser struct {
 	UserName      string `json:"user_name" db:"user_name"`
 	UserEmail     string `json:"user_email" db:"user_email"`
 	CurrentUserVote sql.NullString `json:"current_user_vote" db:"current_user_vote"`
+	// Read state of the current user; only populated by ListPosts
+	LastReadAt         sql.NullTime `json:"last_read_at" db:"last_read_at"`
+	UnreadCommentCount int          `json:"unread_comment_count" db:"unread_comment_count"`
 }
 
 // ForumComment represents a comment on a forum post
@@ -42,6 +48,9 @@ type ForumComment struct {
 	Downvotes       int            `json:"downvotes" db:"downvotes"`
 	IsAccepted      bool           `json:"is_accepted" db:"is_accepted"`
 	Depth           int            `json:"depth" db:"depth"`
+	IsHidden        bool           `json:"is_hidden" db:"is_hidden"`
+	HiddenReason    sql.NullString `json:"hidden_reason" db:"hidden_reason"`
+	ReportCount     int            `json:"report_count" db:"report_count"`
 	CreatedAt       time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at" db:"updated_at"`
 }
@@ -83,4 +92,144 @@ const (
 	SortByNewest  = "newest"
 	SortByOldest  = "oldest"
 	SortByViews   = "views"
-)
\ No newline at end of file
+)
+
+// ============================================
+// MODERATION
+// ============================================
+
+// ForumReport is one user's report against a post or comment
+type ForumReport struct {
+	ID             int64          `json:"id" db:"id"`
+	TargetType     string         `json:"target_type" db:"target_type"`
+	TargetID       int64          `json:"target_id" db:"target_id"`
+	PostID         int64          `json:"post_id" db:"post_id"`
+	CourseID       int64          `json:"course_id" db:"course_id"`
+	ReporterID     int64          `json:"reporter_id" db:"reporter_id"`
+	Reason         string         `json:"reason" db:"reason"`
+	Details        sql.NullString `json:"details" db:"details"`
+	Status         string         `json:"status" db:"status"`
+	ResolvedBy     sql.NullInt64  `json:"resolved_by" db:"resolved_by"`
+	ResolutionNote sql.NullString `json:"resolution_note" db:"resolution_note"`
+	ResolvedAt     sql.NullTime   `json:"resolved_at" db:"resolved_at"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+}
+
+// ForumReportQueueItem groups the reports against a single post or comment
+// for the moderator queue.
+type ForumReportQueueItem struct {
+	TargetType      string         `json:"target_type" db:"target_type"`
+	TargetID        int64          `json:"target_id" db:"target_id"`
+	PostID          int64          `json:"post_id" db:"post_id"`
+	CourseID        int64          `json:"course_id" db:"course_id"`
+	CourseTitle     string         `json:"course_title" db:"course_title"`
+	ReportCount     int            `json:"report_count" db:"report_count"`
+	Reasons         []string       `json:"reasons" db:"reasons"`
+	FirstReportedAt time.Time      `json:"first_reported_at" db:"first_reported_at"`
+	LastReportedAt  time.Time      `json:"last_reported_at" db:"last_reported_at"`
+	IsHidden        bool           `json:"is_hidden" db:"is_hidden"`
+	HiddenReason    sql.NullString `json:"hidden_reason" db:"hidden_reason"`
+	Excerpt         sql.NullString `json:"excerpt" db:"excerpt"`
+	AuthorID        sql.NullInt64  `json:"author_id" db:"author_id"`
+	AuthorName      sql.NullString `json:"author_name" db:"author_name"`
+}
+
+// ForumPostingBan temporarily prevents a user from posting in a course forum
+type ForumPostingBan struct {
+	ID        int64          `json:"id" db:"id"`
+	CourseID  int64          `json:"course_id" db:"course_id"`
+	UserID    int64          `json:"user_id" db:"user_id"`
+	UserName  string         `json:"user_name" db:"user_name"`
+	BannedBy  sql.NullInt64  `json:"banned_by" db:"banned_by"`
+	Reason    sql.NullString `json:"reason" db:"reason"`
+	ExpiresAt time.Time      `json:"expires_at" db:"expires_at"`
+	RevokedAt sql.NullTime   `json:"revoked_at" db:"revoked_at"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// Report reason constants
+const (
+	ReportReasonSpam          = "SPAM"
+	ReportReasonHarassment    = "HARASSMENT"
+	ReportReasonInappropriate = "INAPPROPRIATE"
+	ReportReasonOffTopic      = "OFF_TOPIC"
+	ReportReasonOther         = "OTHER"
+)
+
+// Report status constants
+const (
+	ReportStatusOpen      = "OPEN"
+	ReportStatusDismissed = "DISMISSED"
+	ReportStatusActioned  = "ACTIONED"
+)
+
+// HiddenReasonReports marks content auto-hidden by the report threshold;
+// HiddenReasonModerator marks content hidden by a moderator decision.
+const (
+	HiddenReasonReports   = "REPORTS"
+	HiddenReasonModerator = "MODERATOR"
+)
+
+// ============================================
+// SUBSCRIPTIONS & NOTIFICATIONS
+// ============================================
+
+// ForumSubscription subscribes a user to a whole forum or to a single thread
+type ForumSubscription struct {
+	ID          int64     `json:"id" db:"id"`
+	UserID      int64     `json:"user_id" db:"user_id"`
+	TargetType  string    `json:"target_type" db:"target_type"`
+	TargetID    int64     `json:"target_id" db:"target_id"`
+	CourseID    int64     `json:"course_id" db:"course_id"`
+	IsAuto      bool      `json:"is_auto" db:"is_auto"`
+	TargetTitle string    `json:"target_title" db:"target_title"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// ForumNotification is one forum event delivered to one user
+type ForumNotification struct {
+	ID          int64          `json:"id" db:"id"`
+	UserID      int64          `json:"user_id" db:"user_id"`
+	CourseID    int64          `json:"course_id" db:"course_id"`
+	CourseTitle string         `json:"course_title" db:"course_title"`
+	PostID      int64          `json:"post_id" db:"post_id"`
+	PostTitle   string         `json:"post_title" db:"post_title"`
+	CommentID   sql.NullInt64  `json:"comment_id" db:"comment_id"`
+	Excerpt     sql.NullString `json:"excerpt" db:"excerpt"`
+	Type        string         `json:"type" db:"type"`
+	ActorID     sql.NullInt64  `json:"actor_id" db:"actor_id"`
+	ActorName   sql.NullString `json:"actor_name" db:"actor_name"`
+	ReadAt      sql.NullTime   `json:"read_at" db:"read_at"`
+	DigestedAt  sql.NullTime   `json:"digested_at" db:"digested_at"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+}
+
+// Subscription target constants
+const (
+	SubscriptionTargetForum = "forum"
+	SubscriptionTargetPost  = "post"
+)
+
+// Notification type constants
+const (
+	ForumNotificationNewPost  = "NEW_POST"
+	ForumNotificationReply    = "REPLY"
+	ForumNotificationMention  = "MENTION"
+	ForumNotificationAccepted = "ACCEPTED"
+)
+
+// ForumAttachment is a file uploaded to a forum. PostID/CommentID are unset
+// until the post or comment that uses it is saved.
+type ForumAttachment struct {
+	ID          int64         `json:"id" db:"id"`
+	CourseID    int64         `json:"course_id" db:"course_id"`
+	ContentID   int64         `json:"content_id" db:"content_id"`
+	PostID      sql.NullInt64 `json:"post_id" db:"post_id"`
+	CommentID   sql.NullInt64 `json:"comment_id" db:"comment_id"`
+	UploaderID  int64         `json:"uploader_id" db:"uploader_id"`
+	FileName    string        `json:"file_name" db:"file_name"`
+	StorageKey  string        `json:"storage_key" db:"storage_key"`
+	ContentType string        `json:"content_type" db:"content_type"`
+	SizeBytes   int64         `json:"size_bytes" db:"size_bytes"`
+	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
+}
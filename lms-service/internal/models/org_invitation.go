@@ -0,0 +1,78 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// OrgInvitation invites an email address to join an organization. Only the
+// hash of its token is stored.
+type OrgInvitation struct {
+	ID         int64         `json:"id" db:"id"`
+	OrgID      int64         `json:"org_id" db:"org_id"`
+	Email      string        `json:"email" db:"email"`
+	OrgRole    string        `json:"org_role" db:"org_role"`
+	TokenHash  string        `json:"-" db:"token_hash"`
+	InvitedBy  sql.NullInt64 `json:"invited_by" db:"invited_by"`
+	ExpiresAt  time.Time     `json:"expires_at" db:"expires_at"`
+	AcceptedAt sql.NullTime  `json:"accepted_at" db:"accepted_at"`
+	AcceptedBy sql.NullInt64 `json:"accepted_by" db:"accepted_by"`
+	RevokedAt  sql.NullTime  `json:"revoked_at" db:"revoked_at"`
+	CreatedAt  time.Time     `json:"created_at" db:"created_at"`
+}
+
+// Invitation states, derived from the timestamps
+const (
+	InvitationPending  = "PENDING"
+	InvitationAccepted = "ACCEPTED"
+	InvitationRevoked  = "REVOKED"
+	InvitationExpired  = "EXPIRED"
+)
+
+// Status reports the invitation's state at now
+func (i *OrgInvitation) Status(now time.Time) string {
+	switch {
+	case i.AcceptedAt.Valid:
+		return InvitationAccepted
+	case i.RevokedAt.Valid:
+		return InvitationRevoked
+	case !now.Before(i.ExpiresAt):
+		return InvitationExpired
+	default:
+		return InvitationPending
+	}
+}
+
+// OrgJoinCode is a shared code that lets anyone holding it join an
+// organization, optionally limited in uses and time
+type OrgJoinCode struct {
+	ID        int64         `json:"id" db:"id"`
+	OrgID     int64         `json:"org_id" db:"org_id"`
+	Code      string        `json:"code" db:"code"`
+	OrgRole   string        `json:"org_role" db:"org_role"`
+	MaxUses   sql.NullInt32 `json:"max_uses" db:"max_uses"`
+	UseCount  int           `json:"use_count" db:"use_count"`
+	ExpiresAt sql.NullTime  `json:"expires_at" db:"expires_at"`
+	CreatedBy sql.NullInt64 `json:"created_by" db:"created_by"`
+	RevokedAt sql.NullTime  `json:"revoked_at" db:"revoked_at"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+}
+
+// Usable reports whether the code can still be redeemed at now
+func (c *OrgJoinCode) Usable(now time.Time) bool {
+	if c.RevokedAt.Valid {
+		return false
+	}
+	if c.ExpiresAt.Valid && !now.Before(c.ExpiresAt.Time) {
+		return false
+	}
+	return !c.MaxUses.Valid || c.UseCount < int(c.MaxUses.Int32)
+}
+
+// Ways a member can join an organization, carried in membership events
+const (
+	OrgJoinSourceAdmin       = "admin"
+	OrgJoinSourceInvitation  = "invitation"
+	OrgJoinSourceEmailDomain = "email_domain"
+	OrgJoinSourceJoinCode    = "join_code"
+)
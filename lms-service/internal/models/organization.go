@@ -26,6 +26,9 @@ type OrgSettings struct {
 	DefaultCourseVisibility string `json:"default_course_visibility"`
 	AllowSelfEnrollment     bool   `json:"allow_self_enrollment"`
 	MaxMembers              int    `json:"max_members,omitempty"`
+	// Users whose email is in one of these domains join on first login
+	AllowedEmailDomains []string `json:"allowed_email_domains,omitempty"`
+	DomainJoinRole      string   `json:"domain_join_role,omitempty"`
 }
 
 // OrgMember represents membership of a user in an organization
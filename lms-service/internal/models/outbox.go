@@ -0,0 +1,32 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Outbox row states. Mirrors the CHECK constraint in migration V021.
+const (
+	OutboxStatusPending   = "PENDING"
+	OutboxStatusPublished = "PUBLISHED"
+	OutboxStatusDead      = "DEAD"      // attempts exhausted; blocks its key
+	OutboxStatusDiscarded = "DISCARDED" // dropped by an admin to unblock its key
+)
+
+// OutboxEvent is a Kafka event recorded in the same transaction as the
+// change it announces
+type OutboxEvent struct {
+	ID            int64           `json:"id" db:"id"`
+	Topic         string          `json:"topic" db:"topic"`
+	Key           string          `json:"key" db:"msg_key"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	Status        string          `json:"status" db:"status"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	LastError     sql.NullString  `json:"last_error" db:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	PublishedAt   sql.NullTime    `json:"published_at" db:"published_at"`
+	// TraceContext holds the propagation headers of the producing request
+	TraceContext map[string]string `json:"-" db:"trace_context"`
+}
@@ -2,13 +2,23 @@ package models
 
 import "time"
 
-// RoleDefinition represents a dynamic role created in LMS
+// Role scopes. GLOBAL roles are held through user_roles; ORG roles are what
+// organization_members.org_role names.
+const (
+	RoleScopeGlobal = "GLOBAL"
+	RoleScopeOrg    = "ORG"
+)
+
+// RoleDefinition represents a dynamic role created in LMS. An ORG role with
+// no OrgID is a template that applies to every organization.
 type RoleDefinition struct {
 	ID          int64     `json:"id" db:"id"`
 	Name        string    `json:"name" db:"name"`
 	DisplayName string    `json:"display_name" db:"display_name"`
 	Description string    `json:"description" db:"description"`
 	IsSystem    bool      `json:"is_system" db:"is_system"`
+	Scope       string    `json:"scope" db:"scope"`
+	OrgID       *int64    `json:"org_id,omitempty" db:"org_id"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"example/hello/internal/models"
+)
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// AuditFilter narrows an audit log query; zero fields match everything
+type AuditFilter struct {
+	ActorID    int64
+	Action     string
+	TargetType string
+	TargetID   string
+	OrgID      int64
+	CourseID   int64
+	RequestID  string
+	From       time.Time
+	To         time.Time
+}
+
+const auditColumns = `id, occurred_at, actor_id, actor_role, actor_service, action, target_type, target_id,
+	org_id, course_id, reason, before_state, after_state, request_id, ip_address, user_agent`
+
+// Insert appends an entry. The table rejects updates and deletes.
+func (r *AuditRepository) Insert(ctx context.Context, e *models.AuditEntry) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO audit_log (actor_id, actor_role, actor_service, action, target_type, target_id,
+			org_id, course_id, reason, before_state, after_state, request_id, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, occurred_at
+	`, e.ActorID, e.ActorRole, e.ActorService, e.Action, e.TargetType, e.TargetID,
+		e.OrgID, e.CourseID, e.Reason, nullJSON(e.Before), nullJSON(e.After), e.RequestID, e.IPAddress, e.UserAgent,
+	).Scan(&e.ID, &e.OccurredAt)
+}
+
+// List returns matching entries, newest first
+func (r *AuditRepository) List(ctx context.Context, f AuditFilter, limit, offset int) ([]*models.AuditEntry, int, error) {
+	where, args := f.where()
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	n := len(args)
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT `+auditColumns+` FROM audit_log `+where+`
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, n+1, n+2), append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditEntry
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
+// Each streams matching entries, oldest first, up to max rows, without
+// holding them all in memory
+func (r *AuditRepository) Each(ctx context.Context, f AuditFilter, max int, fn func(*models.AuditEntry) error) error {
+	where, args := f.where()
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT `+auditColumns+` FROM audit_log `+where+`
+		ORDER BY occurred_at, id
+		LIMIT $%d
+	`, len(args)+1), append(args, max)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (f AuditFilter) where() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	add := func(cond string, value interface{}) {
+		args = append(args, value)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if f.ActorID > 0 {
+		add("actor_id = $%d", f.ActorID)
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.TargetType != "" {
+		add("target_type = $%d", f.TargetType)
+	}
+	if f.TargetID != "" {
+		add("target_id = $%d", f.TargetID)
+	}
+	if f.OrgID > 0 {
+		add("org_id = $%d", f.OrgID)
+	}
+	if f.CourseID > 0 {
+		add("course_id = $%d", f.CourseID)
+	}
+	if f.RequestID != "" {
+		add("request_id = $%d", f.RequestID)
+	}
+	if !f.From.IsZero() {
+		add("occurred_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("occurred_at < $%d", f.To)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+func scanAuditEntry(rows *sql.Rows) (*models.AuditEntry, error) {
+	e := &models.AuditEntry{}
+	var before, after []byte
+	if err := rows.Scan(
+		&e.ID, &e.OccurredAt, &e.ActorID, &e.ActorRole, &e.ActorService, &e.Action, &e.TargetType, &e.TargetID,
+		&e.OrgID, &e.CourseID, &e.Reason, &before, &after, &e.RequestID, &e.IPAddress, &e.UserAgent,
+	); err != nil {
+		return nil, err
+	}
+	e.Before, e.After = before, after
+	return e, nil
+}
+
+// nullJSON stores an empty document as SQL NULL
+func nullJSON(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
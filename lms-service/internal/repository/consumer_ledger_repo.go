@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ConsumerLedgerRepository is the Postgres-backed kafka.Ledger
+type ConsumerLedgerRepository struct {
+	db *sql.DB
+}
+
+func NewConsumerLedgerRepository(db *sql.DB) *ConsumerLedgerRepository {
+	return &ConsumerLedgerRepository{db: db}
+}
+
+// Apply claims eventID for group and runs fn in the same transaction, so
+// the ledger row commits together with the handler's writes or not at all.
+// A concurrent delivery of the same event blocks on the row until the first
+// one commits or rolls back. duplicate is true when the event was already
+// applied and fn was skipped.
+func (r *ConsumerLedgerRepository) Apply(ctx context.Context, group, eventID string, fn func(ctx context.Context) error) (duplicate bool, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO consumer_processed_events (consumer_group, event_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, group, eventID)
+	if err != nil {
+		return false, err
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if claimed == 0 {
+		return true, nil
+	}
+
+	if err := fn(WithTx(ctx, tx)); err != nil {
+		return false, err
+	}
+	return false, tx.Commit()
+}
+
+// Prune deletes ledger entries older than retention
+func (r *ConsumerLedgerRepository) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM consumer_processed_events
+		WHERE processed_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
+	`, retention.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
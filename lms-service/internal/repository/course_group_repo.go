@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"example/hello/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type CourseGroupRepository struct {
+	db *sql.DB
+}
+
+func NewCourseGroupRepository(db *sql.DB) *CourseGroupRepository {
+	return &CourseGroupRepository{db: db}
+}
+
+// Create inserts a group. eventsFor receives the stored group and returns
+// events to write to the outbox in the same transaction.
+func (r *CourseGroupRepository) Create(ctx context.Context, group *models.CourseGroup, eventsFor func(*models.CourseGroup) []OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO course_groups (course_id, name, created_by)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`, group.CourseID, group.Name, group.CreatedBy).Scan(&group.ID, &group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create course group: %w", err)
+	}
+
+	if err := enqueueOutbox(ctx, tx, eventsFor(group)...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Rename changes a group's name. eventsFor runs inside the transaction, on
+// a context that repository reads through Conn join, and returns events to
+// write to the outbox with the change.
+func (r *CourseGroupRepository) Rename(ctx context.Context, groupID int64, name string, eventsFor func(ctx context.Context) ([]OutboxMessage, error)) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE course_groups SET name = $2, updated_at = NOW() WHERE id = $1
+	`, groupID, name)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	events, err := eventsFor(WithTx(ctx, tx))
+	if err != nil {
+		return err
+	}
+	if err := enqueueOutbox(ctx, tx, events...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete removes a group and its memberships, writing events to the outbox
+// in the same transaction
+func (r *CourseGroupRepository) Delete(ctx context.Context, groupID int64, events ...OutboxMessage) error {
+	return execOneWithOutbox(ctx, r.db, events, `DELETE FROM course_groups WHERE id = $1`, groupID)
+}
+
+// GetByID returns a group with its member count
+func (r *CourseGroupRepository) GetByID(ctx context.Context, groupID int64) (*models.CourseGroup, error) {
+	var g models.CourseGroup
+	err := Conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT g.id, g.course_id, g.name, g.created_by, g.created_at, g.updated_at,
+		       (SELECT COUNT(*) FROM course_group_members gm WHERE gm.group_id = g.id)
+		FROM course_groups g
+		WHERE g.id = $1
+	`, groupID).Scan(&g.ID, &g.CourseID, &g.Name, &g.CreatedBy, &g.CreatedAt, &g.UpdatedAt, &g.MemberCount)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// ListByCourse lists a course's groups by name
+func (r *CourseGroupRepository) ListByCourse(ctx context.Context, courseID int64) ([]*models.CourseGroup, error) {
+	rows, err := Conn(ctx, r.db).QueryContext(ctx, `
+		SELECT g.id, g.course_id, g.name, g.created_by, g.created_at, g.updated_at,
+		       (SELECT COUNT(*) FROM course_group_members gm WHERE gm.group_id = g.id)
+		FROM course_groups g
+		WHERE g.course_id = $1
+		ORDER BY g.name, g.id
+	`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []*models.CourseGroup
+	for rows.Next() {
+		var g models.CourseGroup
+		if err := rows.Scan(&g.ID, &g.CourseID, &g.Name, &g.CreatedBy, &g.CreatedAt, &g.UpdatedAt, &g.MemberCount); err != nil {
+			return nil, err
+		}
+		groups = append(groups, &g)
+	}
+	return groups, rows.Err()
+}
+
+// ListMembers lists the students in a group
+func (r *CourseGroupRepository) ListMembers(ctx context.Context, groupID int64) ([]*models.CourseGroupMember, error) {
+	rows, err := Conn(ctx, r.db).QueryContext(ctx, `
+		SELECT gm.group_id, gm.user_id, u.email, COALESCE(u.full_name, ''), COALESCE(u.profile_picture, ''), gm.added_at
+		FROM course_group_members gm
+		JOIN users u ON u.id = gm.user_id
+		WHERE gm.group_id = $1
+		ORDER BY gm.added_at, gm.user_id
+	`, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*models.CourseGroupMember
+	for rows.Next() {
+		var m models.CourseGroupMember
+		if err := rows.Scan(&m.GroupID, &m.UserID, &m.Email, &m.FullName, &m.AvatarURL, &m.AddedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, &m)
+	}
+	return members, rows.Err()
+}
+
+// ListMemberIDs returns the members of every group of a course, keyed by
+// group. Groups without members are absent.
+func (r *CourseGroupRepository) ListMemberIDs(ctx context.Context, courseID int64) (map[int64][]int64, error) {
+	rows, err := Conn(ctx, r.db).QueryContext(ctx, `
+		SELECT gm.group_id, gm.user_id
+		FROM course_group_members gm
+		JOIN course_groups g ON g.id = gm.group_id
+		WHERE g.course_id = $1
+		ORDER BY gm.group_id, gm.user_id
+	`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make(map[int64][]int64)
+	for rows.Next() {
+		var groupID, userID int64
+		if err := rows.Scan(&groupID, &userID); err != nil {
+			return nil, err
+		}
+		members[groupID] = append(members[groupID], userID)
+	}
+	return members, rows.Err()
+}
+
+// AddMembers puts the given students in a group, skipping anyone without an
+// accepted enrollment in the group's course and anyone already in it.
+// Returns the students added; eventsFor, if set, receives them and returns
+// events to write to the outbox in the same transaction.
+func (r *CourseGroupRepository) AddMembers(
+	ctx context.Context,
+	groupID, addedBy int64,
+	userIDs []int64,
+	eventsFor func(added []int64) []OutboxMessage,
+) ([]int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	added, err := collectIDs(tx.QueryContext(ctx, `
+		INSERT INTO course_group_members (group_id, user_id, added_by)
+		SELECT g.id, e.student_id, $2
+		FROM course_groups g
+		JOIN enrollments e ON e.course_id = g.course_id AND e.status = 'ACCEPTED'
+		WHERE g.id = $1 AND e.student_id = ANY($3)
+		ON CONFLICT (group_id, user_id) DO NOTHING
+		RETURNING user_id
+	`, groupID, addedBy, pq.Array(userIDs)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to add group members: %w", err)
+	}
+
+	if eventsFor != nil && len(added) > 0 {
+		if err := enqueueOutbox(ctx, tx, eventsFor(added)...); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// RemoveMembers takes students out of a group and returns those who were
+// in it; eventsFor, if set, receives them and returns events to write to
+// the outbox in the same transaction.
+func (r *CourseGroupRepository) RemoveMembers(
+	ctx context.Context,
+	groupID int64,
+	userIDs []int64,
+	eventsFor func(removed []int64) []OutboxMessage,
+) ([]int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	removed, err := collectIDs(tx.QueryContext(ctx, `
+		DELETE FROM course_group_members
+		WHERE group_id = $1 AND user_id = ANY($2)
+		RETURNING user_id
+	`, groupID, pq.Array(userIDs)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove group members: %w", err)
+	}
+
+	if eventsFor != nil && len(removed) > 0 {
+		if err := enqueueOutbox(ctx, tx, eventsFor(removed)...); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// collectIDs reads a single BIGINT column from rows
+func collectIDs(rows *sql.Rows, err error) ([]int64, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -125,11 +125,18 @@ func (r *CourseRepository) Update(ctx context.Context, id int64, updates map[str
 	return nil
 }
 
-// Delete deletes a course
-func (r *CourseRepository) Delete(ctx context.Context, id int64) error {
+// Delete deletes a course. events are written to the outbox in the same
+// transaction, so they are published if and only if the delete commits.
+func (r *CourseRepository) Delete(ctx context.Context, id int64, events ...OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `DELETE FROM courses WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := tx.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -143,62 +150,77 @@ func (r *CourseRepository) Delete(ctx context.Context, id int64) error {
 		return sql.ErrNoRows
 	}
 
-	return nil
+	if err := enqueueOutbox(ctx, tx, events...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // Archive makes a course inaccessible while retaining the state needed to
 // restore it exactly as it was (draft or published).
-func (r *CourseRepository) Archive(ctx context.Context, id int64) error {
-	result, err := r.db.ExecContext(ctx, `
+func (r *CourseRepository) Archive(ctx context.Context, id int64, events ...OutboxMessage) error {
+	return execOneWithOutbox(ctx, r.db, events, `
 		UPDATE courses
 		SET archived_from_status = status, status = 'ARCHIVED', updated_at = CURRENT_TIMESTAMP
 		WHERE id = $1 AND status IN ('DRAFT', 'PUBLISHED')
 	`, id)
-	if err != nil {
-		return err
-	}
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return sql.ErrNoRows
-	}
-	return nil
 }
 
 // Unarchive restores the status captured at archive time. Legacy archived
 // records without a source status return to DRAFT as the safe default.
-func (r *CourseRepository) Unarchive(ctx context.Context, id int64) error {
-	result, err := r.db.ExecContext(ctx, `
+// eventsFor receives the restored status and returns the events to write to
+// the outbox in the same transaction; it may be nil. It runs inside the
+// transaction, on a context that repository reads through Conn join.
+func (r *CourseRepository) Unarchive(ctx context.Context, id int64, eventsFor func(ctx context.Context, status string) ([]OutboxMessage, error)) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx, `
 		UPDATE courses
 		SET status = COALESCE(archived_from_status, 'DRAFT'),
 		    archived_from_status = NULL,
 		    updated_at = CURRENT_TIMESTAMP
 		WHERE id = $1 AND status = 'ARCHIVED'
-	`, id)
+		RETURNING status
+	`, id).Scan(&status)
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
+
+	if eventsFor != nil {
+		events, err := eventsFor(WithTx(ctx, tx), status)
+		if err != nil {
+			return err
+		}
+		if err := enqueueOutbox(ctx, tx, events...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Publish publishes a draft course. eventsFor returns the events to write
+// to the outbox in the same transaction; it runs inside the transaction, on
+// a context that repository reads through Conn join, so what it reads is
+// consistent with the publish.
+func (r *CourseRepository) Publish(ctx context.Context, id int64, eventsFor func(ctx context.Context) ([]OutboxMessage, error)) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return sql.ErrNoRows
-	}
-	return nil
-}
+	defer tx.Rollback()
 
-// Publish publishes a course
-func (r *CourseRepository) Publish(ctx context.Context, id int64) error {
 	query := `
 		UPDATE courses
 		SET status = $1, published_at = $2
 		WHERE id = $3 AND status = $4
 	`
-
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := tx.ExecContext(ctx, query,
 		models.CourseStatusPublished,
 		time.Now(),
 		id,
@@ -207,17 +229,51 @@ func (r *CourseRepository) Publish(ctx context.Context, id int64) error {
 	if err != nil {
 		return err
 	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("course not found or already published")
+	}
 
-	rows, err := result.RowsAffected()
+	events, err := eventsFor(WithTx(ctx, tx))
 	if err != nil {
 		return err
 	}
+	if err := enqueueOutbox(ctx, tx, events...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
-	if rows == 0 {
-		return fmt.Errorf("course not found or already published")
+// ListMembers returns everyone who belongs to a course: its creator, its
+// co-teachers and students with an accepted enrollment. Role is CREATOR,
+// CO_TEACHER or STUDENT; a user holding several roles appears once per role.
+func (r *CourseRepository) ListMembers(ctx context.Context, courseID int64) ([]models.CourseMember, error) {
+	rows, err := Conn(ctx, r.db).QueryContext(ctx, `
+		SELECT u.id, u.email, COALESCE(u.full_name, ''), 'CREATOR'
+		FROM courses c JOIN users u ON u.id = c.created_by
+		WHERE c.id = $1
+		UNION ALL
+		SELECT u.id, u.email, COALESCE(u.full_name, ''), 'CO_TEACHER'
+		FROM course_co_teachers ct JOIN users u ON u.id = ct.user_id
+		WHERE ct.course_id = $1
+		UNION ALL
+		SELECT e.student_id, COALESCE(u.email, ''), COALESCE(u.full_name, ''), 'STUDENT'
+		FROM enrollments e LEFT JOIN users u ON u.id = e.student_id
+		WHERE e.course_id = $1 AND e.status = 'ACCEPTED'
+	`, courseID)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return nil
+	var members []models.CourseMember
+	for rows.Next() {
+		var m models.CourseMember
+		if err := rows.Scan(&m.UserID, &m.Email, &m.FullName, &m.Role); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
 }
 
 // ListByCreator lists one page of courses owned or co-taught by a user.
@@ -750,11 +806,18 @@ func (r *CourseRepository) UpdateContent(ctx context.Context, id int64, updates
 	return nil
 }
 
-// DeleteContent deletes section content
-func (r *CourseRepository) DeleteContent(ctx context.Context, id int64) error {
+// DeleteContent deletes section content, writing events to the outbox in the
+// same transaction
+func (r *CourseRepository) DeleteContent(ctx context.Context, id int64, events ...OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `DELETE FROM section_content WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := tx.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -768,7 +831,11 @@ func (r *CourseRepository) DeleteContent(ctx context.Context, id int64) error {
 		return sql.ErrNoRows
 	}
 
-	return nil
+	if err := enqueueOutbox(ctx, tx, events...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // Helper function to convert metadata to JSON
@@ -786,7 +853,7 @@ func (r *CourseRepository) UpdateContentAIIndexStatus(
 	status string,
 ) error {
 	query := `UPDATE section_content SET ai_index_status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
-	_, err := r.db.ExecContext(ctx, query, status, contentID)
+	_, err := Conn(ctx, r.db).ExecContext(ctx, query, status, contentID)
 	return err
 }
 
@@ -922,35 +989,29 @@ func (r *CourseRepository) ListVisibleForUser(ctx context.Context, filter Course
 	return courses, total, nil
 }
 
-// AddCoTeacher inserts a co-teacher into a course
-func (r *CourseRepository) AddCoTeacher(ctx context.Context, courseID, userID, addedBy int64) error {
+// AddCoTeacher inserts a co-teacher into a course. events are written to
+// the outbox only when the co-teacher is new.
+func (r *CourseRepository) AddCoTeacher(ctx context.Context, courseID, userID, addedBy int64, events ...OutboxMessage) error {
 	query := `
 		INSERT INTO course_co_teachers (course_id, user_id, added_by)
 		VALUES ($1, $2, $3)
 		ON CONFLICT (course_id, user_id) DO NOTHING
 	`
-	_, err := r.db.ExecContext(ctx, query, courseID, userID, addedBy)
+	err := execOneWithOutbox(ctx, r.db, events, query, courseID, userID, addedBy)
+	if err == sql.ErrNoRows {
+		return nil
+	}
 	return err
 }
 
-// RemoveCoTeacher deletes a co-teacher from a course
-func (r *CourseRepository) RemoveCoTeacher(ctx context.Context, courseID, userID int64) error {
+// RemoveCoTeacher deletes a co-teacher from a course, writing events to the
+// outbox in the same transaction
+func (r *CourseRepository) RemoveCoTeacher(ctx context.Context, courseID, userID int64, events ...OutboxMessage) error {
 	query := `
 		DELETE FROM course_co_teachers
 		WHERE course_id = $1 AND user_id = $2
 	`
-	result, err := r.db.ExecContext(ctx, query, courseID, userID)
-	if err != nil {
-		return err
-	}
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return sql.ErrNoRows
-	}
-	return nil
+	return execOneWithOutbox(ctx, r.db, events, query, courseID, userID)
 }
 
 // ListCoTeachers lists all co-teachers of a course with user info
@@ -17,8 +17,15 @@ func NewEnrollmentRepository(db *sql.DB) *EnrollmentRepository {
 	return &EnrollmentRepository{db: db}
 }
 
-// Create creates a new enrollment
-func (r *EnrollmentRepository) Create(ctx context.Context, enrollment *models.Enrollment) (*models.Enrollment, error) {
+// Create creates a new enrollment, writing events to the outbox in the same
+// transaction
+func (r *EnrollmentRepository) Create(ctx context.Context, enrollment *models.Enrollment, events ...OutboxMessage) (*models.Enrollment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO enrollments (course_id, student_id, status)
 		VALUES ($1, $2, $3)
@@ -26,7 +33,7 @@ func (r *EnrollmentRepository) Create(ctx context.Context, enrollment *models.En
 	`
 
 	var result models.Enrollment
-	err := r.db.QueryRowContext(ctx, query,
+	err = tx.QueryRowContext(ctx, query,
 		enrollment.CourseID,
 		enrollment.StudentID,
 		enrollment.Status,
@@ -46,6 +53,12 @@ func (r *EnrollmentRepository) Create(ctx context.Context, enrollment *models.En
 		return nil, fmt.Errorf("failed to create enrollment: %w", err)
 	}
 
+	if err := enqueueOutbox(ctx, tx, events...); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
@@ -187,7 +200,7 @@ func (r *EnrollmentRepository) ListByCourse(ctx context.Context, courseID int64,
 }
 
 // UpdateStatus updates enrollment status
-func (r *EnrollmentRepository) UpdateStatus(ctx context.Context, id int64, status string) error {
+func (r *EnrollmentRepository) UpdateStatus(ctx context.Context, id int64, status string, events ...OutboxMessage) error {
 	var query string
 	var args []interface{}
 
@@ -202,41 +215,26 @@ func (r *EnrollmentRepository) UpdateStatus(ctx context.Context, id int64, statu
 		return fmt.Errorf("invalid status: %s", status)
 	}
 
-	result, err := r.db.ExecContext(ctx, query, args...)
-	if err != nil {
+	if err := execOneWithOutbox(ctx, r.db, events, query, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return err
+		}
 		return fmt.Errorf("failed to update enrollment status: %w", err)
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
-	}
-
 	return nil
 }
 
-// Delete deletes an enrollment
-func (r *EnrollmentRepository) Delete(ctx context.Context, id int64) error {
+// Delete deletes an enrollment, writing events to the outbox in the same
+// transaction
+func (r *EnrollmentRepository) Delete(ctx context.Context, id int64, events ...OutboxMessage) error {
 	query := `DELETE FROM enrollments WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, id)
-	if err != nil {
+	if err := execOneWithOutbox(ctx, r.db, events, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return err
+		}
 		return fmt.Errorf("failed to delete enrollment: %w", err)
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
-	}
-
 	return nil
 }
 
@@ -286,10 +284,14 @@ func (r *EnrollmentRepository) UpdateBulkLog(ctx context.Context, id int64, succ
 	return err
 }
 
+// BulkCreate enrolls studentIDs, skipping existing enrollments, and returns
+// the students actually inserted. eventsFor, if set, receives them and
+// returns events to write to the outbox in the same transaction.
 func (r *EnrollmentRepository) BulkCreate(
 	ctx context.Context,
 	courseID int64,
 	studentIDs []int64,
+	eventsFor func(inserted []int64) []OutboxMessage,
 ) (inserted []int64, err error) {
 	if len(studentIDs) == 0 {
 		return nil, nil
@@ -313,19 +315,38 @@ func (r *EnrollmentRepository) BulkCreate(
 		RETURNING student_id
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("BulkCreate enrollments course=%d: %w", courseID, err)
 	}
-	defer rows.Close()
 
 	inserted = make([]int64, 0, len(studentIDs))
 	for rows.Next() {
 		var sid int64
 		if err := rows.Scan(&sid); err != nil {
+			rows.Close()
 			return nil, err
 		}
 		inserted = append(inserted, sid)
 	}
-	return inserted, rows.Err()
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if eventsFor != nil && len(inserted) > 0 {
+		if err := enqueueOutbox(ctx, tx, eventsFor(inserted)...); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return inserted, nil
 }
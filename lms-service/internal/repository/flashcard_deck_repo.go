@@ -0,0 +1,428 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"example/hello/internal/models"
+)
+
+type FlashcardDeckRepository struct {
+	db *sql.DB
+}
+
+func NewFlashcardDeckRepository(db *sql.DB) *FlashcardDeckRepository {
+	return &FlashcardDeckRepository{db: db}
+}
+
+// ============================================
+// DECK OPERATIONS
+// ============================================
+
+const deckSelect = `
+	SELECT d.id, d.course_id, d.node_id, d.title, d.description, d.status, d.version,
+	       d.created_by, d.published_at, d.created_at, d.updated_at,
+	       (SELECT COUNT(*) FROM flashcard_deck_cards c WHERE c.deck_id = d.id AND c.is_active) AS card_count
+	FROM flashcard_decks d
+`
+
+func scanDeck(row interface{ Scan(...interface{}) error }) (*models.FlashcardDeck, error) {
+	var d models.FlashcardDeck
+	err := row.Scan(
+		&d.ID, &d.CourseID, &d.NodeID, &d.Title, &d.Description, &d.Status, &d.Version,
+		&d.CreatedBy, &d.PublishedAt, &d.CreatedAt, &d.UpdatedAt, &d.CardCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// CreateDeck inserts a new DRAFT deck
+func (r *FlashcardDeckRepository) CreateDeck(ctx context.Context, d *models.FlashcardDeck) error {
+	query := `
+		INSERT INTO flashcard_decks (course_id, node_id, title, description, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, version, created_at, updated_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		d.CourseID, d.NodeID, d.Title, d.Description, d.CreatedBy,
+	).Scan(&d.ID, &d.Status, &d.Version, &d.CreatedAt, &d.UpdatedAt)
+}
+
+// GetDeckByID returns a deck with its active card count
+func (r *FlashcardDeckRepository) GetDeckByID(ctx context.Context, deckID int64) (*models.FlashcardDeck, error) {
+	return scanDeck(r.db.QueryRowContext(ctx, deckSelect+` WHERE d.id = $1`, deckID))
+}
+
+// ListDecksByCourse lists a course's decks. Students only see published ones.
+func (r *FlashcardDeckRepository) ListDecksByCourse(ctx context.Context, courseID int64, publishedOnly bool) ([]*models.FlashcardDeck, error) {
+	query := deckSelect + ` WHERE d.course_id = $1 AND d.status <> 'ARCHIVED'`
+	if publishedOnly {
+		query = deckSelect + ` WHERE d.course_id = $1 AND d.status = 'PUBLISHED'`
+	}
+	query += ` ORDER BY d.created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("FlashcardDeckRepo.ListDecksByCourse: %w", err)
+	}
+	defer rows.Close()
+
+	var decks []*models.FlashcardDeck
+	for rows.Next() {
+		d, err := scanDeck(rows)
+		if err != nil {
+			return nil, fmt.Errorf("FlashcardDeckRepo.ListDecksByCourse scan: %w", err)
+		}
+		decks = append(decks, d)
+	}
+	return decks, rows.Err()
+}
+
+// UpdateDeck updates deck metadata, recording a new version when published
+func (r *FlashcardDeckRepository) UpdateDeck(ctx context.Context, d *models.FlashcardDeck, changedBy int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := bumpDeckVersion(ctx, tx, d.ID, changedBy, "Deck details updated"); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE flashcard_decks SET title = $1, description = $2, node_id = $3
+		WHERE id = $4
+	`, d.Title, d.Description, d.NodeID, d.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PublishDeck makes a deck visible to students and starts a new version.
+// Cards written while the deck was a draft are stamped with that version.
+func (r *FlashcardDeckRepository) PublishDeck(ctx context.Context, deckID, changedBy int64, summary string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var version int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT version FROM flashcard_decks WHERE id = $1 FOR UPDATE`, deckID,
+	).Scan(&version); err != nil {
+		return 0, err
+	}
+	version++
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE flashcard_decks
+		SET status = 'PUBLISHED', version = $1, published_at = COALESCE(published_at, CURRENT_TIMESTAMP)
+		WHERE id = $2
+	`, version, deckID); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE flashcard_deck_cards
+		SET added_in_version   = CASE WHEN added_in_version = 0 THEN $1 ELSE added_in_version END,
+		    updated_in_version = CASE WHEN updated_in_version = 0 THEN $1 ELSE updated_in_version END
+		WHERE deck_id = $2 AND (added_in_version = 0 OR updated_in_version = 0)
+	`, version, deckID); err != nil {
+		return 0, err
+	}
+	if err := insertDeckVersion(ctx, tx, deckID, version, changedBy, summary); err != nil {
+		return 0, err
+	}
+	return version, tx.Commit()
+}
+
+// SetDeckStatus changes status without a version bump (archive / unpublish)
+func (r *FlashcardDeckRepository) SetDeckStatus(ctx context.Context, deckID int64, status string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE flashcard_decks SET status = $1 WHERE id = $2`, status, deckID)
+	return err
+}
+
+// ListVersions returns the changelog of a deck, newest first
+func (r *FlashcardDeckRepository) ListVersions(ctx context.Context, deckID int64) ([]*models.FlashcardDeckVersion, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, deck_id, version, summary, changed_by, created_at
+		FROM flashcard_deck_versions
+		WHERE deck_id = $1
+		ORDER BY version DESC, id DESC
+	`, deckID)
+	if err != nil {
+		return nil, fmt.Errorf("FlashcardDeckRepo.ListVersions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*models.FlashcardDeckVersion
+	for rows.Next() {
+		var v models.FlashcardDeckVersion
+		if err := rows.Scan(&v.ID, &v.DeckID, &v.Version, &v.Summary, &v.ChangedBy, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, &v)
+	}
+	return versions, rows.Err()
+}
+
+// ============================================
+// CARD OPERATIONS
+// ============================================
+
+// AddCards appends cards to the end of a deck in one version
+func (r *FlashcardDeckRepository) AddCards(ctx context.Context, deckID, changedBy int64, cards []*models.FlashcardDeckCard) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	version, err := bumpDeckVersion(ctx, tx, deckID, changedBy, fmt.Sprintf("Added %d card(s)", len(cards)))
+	if err != nil {
+		return err
+	}
+
+	var nextOrder int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(order_index) + 1, 0) FROM flashcard_deck_cards WHERE deck_id = $1`, deckID,
+	).Scan(&nextOrder); err != nil {
+		return err
+	}
+
+	for i, c := range cards {
+		c.DeckID = deckID
+		c.OrderIndex = nextOrder + i
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO flashcard_deck_cards (deck_id, front_text, back_text, order_index, added_in_version, updated_in_version)
+			VALUES ($1, $2, $3, $4, $5, $5)
+			RETURNING id, revision, added_in_version, updated_in_version, is_active, created_at, updated_at
+		`, deckID, c.FrontText, c.BackText, c.OrderIndex, version,
+		).Scan(&c.ID, &c.Revision, &c.AddedInVersion, &c.UpdatedInVersion, &c.IsActive, &c.CreatedAt, &c.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("FlashcardDeckRepo.AddCards: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCardByID returns a deck card (active or not)
+func (r *FlashcardDeckRepository) GetCardByID(ctx context.Context, cardID int64) (*models.FlashcardDeckCard, error) {
+	var c models.FlashcardDeckCard
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, deck_id, front_text, back_text, order_index, revision,
+		       added_in_version, updated_in_version, is_active, created_at, updated_at
+		FROM flashcard_deck_cards WHERE id = $1
+	`, cardID).Scan(
+		&c.ID, &c.DeckID, &c.FrontText, &c.BackText, &c.OrderIndex, &c.Revision,
+		&c.AddedInVersion, &c.UpdatedInVersion, &c.IsActive, &c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// UpdateCard edits a card in place. The card keeps its ID so students'
+// schedules carry over; resetProgress drops them when the meaning changed.
+func (r *FlashcardDeckRepository) UpdateCard(ctx context.Context, c *models.FlashcardDeckCard, changedBy int64, resetProgress bool) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	summary := fmt.Sprintf("Edited card #%d", c.ID)
+	if resetProgress {
+		summary += " (progress reset)"
+	}
+	version, err := bumpDeckVersion(ctx, tx, c.DeckID, changedBy, summary)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE flashcard_deck_cards
+		SET front_text = $1, back_text = $2, revision = revision + 1, updated_in_version = $3
+		WHERE id = $4
+		RETURNING revision, updated_in_version, updated_at
+	`, c.FrontText, c.BackText, version, c.ID).Scan(&c.Revision, &c.UpdatedInVersion, &c.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if resetProgress {
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM flashcard_deck_repetitions WHERE deck_card_id = $1`, c.ID,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RemoveCard deactivates a card; students' repetition rows are kept so review
+// statistics for past versions stay intact.
+func (r *FlashcardDeckRepository) RemoveCard(ctx context.Context, c *models.FlashcardDeckCard, changedBy int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	version, err := bumpDeckVersion(ctx, tx, c.DeckID, changedBy, fmt.Sprintf("Removed card #%d", c.ID))
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE flashcard_deck_cards SET is_active = FALSE, updated_in_version = $1 WHERE id = $2`,
+		version, c.ID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+const deckCardWithRepSelect = `
+	SELECT c.id, c.deck_id, c.front_text, c.back_text, c.order_index, c.revision,
+	       c.added_in_version, c.updated_in_version, c.is_active, c.created_at, c.updated_at,
+	       fr.id, fr.easiness_factor, fr.interval_days, fr.repetitions,
+	       fr.next_review_date, fr.last_reviewed_at, fr.seen_revision, d.title
+	FROM flashcard_deck_cards c
+	JOIN flashcard_decks d ON d.id = c.deck_id
+	LEFT JOIN flashcard_deck_repetitions fr ON fr.deck_card_id = c.id AND fr.student_id = $1
+`
+
+func (r *FlashcardDeckRepository) queryCardsWithRep(ctx context.Context, query string, args ...interface{}) ([]*models.FlashcardDeckCardWithRepetition, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*models.FlashcardDeckCardWithRepetition
+	for rows.Next() {
+		var item models.FlashcardDeckCardWithRepetition
+		if err := rows.Scan(
+			&item.ID, &item.DeckID, &item.FrontText, &item.BackText, &item.OrderIndex, &item.Revision,
+			&item.AddedInVersion, &item.UpdatedInVersion, &item.IsActive, &item.CreatedAt, &item.UpdatedAt,
+			&item.RepetitionID, &item.EasinessFactor, &item.IntervalDays, &item.Repetitions,
+			&item.NextReviewDate, &item.LastReviewedAt, &item.SeenRevision, &item.DeckTitle,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, &item)
+	}
+	return result, rows.Err()
+}
+
+// ListDeckCards returns a deck's active cards with the given student's SM-2 state
+func (r *FlashcardDeckRepository) ListDeckCards(ctx context.Context, deckID, studentID int64) ([]*models.FlashcardDeckCardWithRepetition, error) {
+	cards, err := r.queryCardsWithRep(ctx,
+		deckCardWithRepSelect+` WHERE c.deck_id = $2 AND c.is_active ORDER BY c.order_index, c.id`,
+		studentID, deckID)
+	if err != nil {
+		return nil, fmt.Errorf("FlashcardDeckRepo.ListDeckCards: %w", err)
+	}
+	return cards, nil
+}
+
+// ListDueCards returns shared-deck cards due for a student across a course's
+// published decks. Cards never reviewed count as due (new).
+func (r *FlashcardDeckRepository) ListDueCards(ctx context.Context, courseID, studentID int64, limit int) ([]*models.FlashcardDeckCardWithRepetition, error) {
+	cards, err := r.queryCardsWithRep(ctx, deckCardWithRepSelect+`
+		WHERE d.course_id = $2 AND d.status = 'PUBLISHED' AND c.is_active
+		  AND (fr.id IS NULL OR fr.next_review_date <= CURRENT_DATE)
+		ORDER BY fr.next_review_date ASC NULLS LAST, fr.easiness_factor ASC, c.deck_id, c.order_index
+		LIMIT $3`,
+		studentID, courseID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("FlashcardDeckRepo.ListDueCards: %w", err)
+	}
+	return cards, nil
+}
+
+// GetRepetition returns a student's SM-2 state for a deck card, or nil
+func (r *FlashcardDeckRepository) GetRepetition(ctx context.Context, studentID, cardID int64) (*models.FlashcardDeckRepetition, error) {
+	var rep models.FlashcardDeckRepetition
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, student_id, deck_card_id, deck_id, course_id, easiness_factor, interval_days,
+		       repetitions, quality_last, next_review_date, last_reviewed_at, seen_revision
+		FROM flashcard_deck_repetitions
+		WHERE student_id = $1 AND deck_card_id = $2
+	`, studentID, cardID).Scan(
+		&rep.ID, &rep.StudentID, &rep.DeckCardID, &rep.DeckID, &rep.CourseID, &rep.EasinessFactor, &rep.IntervalDays,
+		&rep.Repetitions, &rep.QualityLast, &rep.NextReviewDate, &rep.LastReviewedAt, &rep.SeenRevision,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rep, nil
+}
+
+// UpsertRepetition stores a student's SM-2 state after a review
+func (r *FlashcardDeckRepository) UpsertRepetition(ctx context.Context, rep *models.FlashcardDeckRepetition) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO flashcard_deck_repetitions (
+			student_id, deck_card_id, deck_id, course_id, easiness_factor, interval_days,
+			repetitions, quality_last, next_review_date, last_reviewed_at, seen_revision
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, CURRENT_TIMESTAMP, $10)
+		ON CONFLICT (student_id, deck_card_id) DO UPDATE SET
+			easiness_factor  = EXCLUDED.easiness_factor,
+			interval_days    = EXCLUDED.interval_days,
+			repetitions      = EXCLUDED.repetitions,
+			quality_last     = EXCLUDED.quality_last,
+			next_review_date = EXCLUDED.next_review_date,
+			last_reviewed_at = EXCLUDED.last_reviewed_at,
+			seen_revision    = EXCLUDED.seen_revision
+		RETURNING id, last_reviewed_at
+	`,
+		rep.StudentID, rep.DeckCardID, rep.DeckID, rep.CourseID, rep.EasinessFactor, rep.IntervalDays,
+		rep.Repetitions, rep.QualityLast, rep.NextReviewDate, rep.SeenRevision,
+	).Scan(&rep.ID, &rep.LastReviewedAt)
+}
+
+// ============================================
+// HELPERS
+// ============================================
+
+// bumpDeckVersion starts a new deck version for a change to a PUBLISHED deck
+// and returns it. Draft edits are unversioned and return the current value.
+func bumpDeckVersion(ctx context.Context, tx *sql.Tx, deckID, changedBy int64, summary string) (int, error) {
+	var status string
+	var version int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT status, version FROM flashcard_decks WHERE id = $1 FOR UPDATE`, deckID,
+	).Scan(&status, &version); err != nil {
+		return 0, err
+	}
+	if status != models.FlashcardDeckStatusPublished {
+		return version, nil
+	}
+
+	version++
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE flashcard_decks SET version = $1 WHERE id = $2`, version, deckID,
+	); err != nil {
+		return 0, err
+	}
+	if err := insertDeckVersion(ctx, tx, deckID, version, changedBy, summary); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+func insertDeckVersion(ctx context.Context, tx *sql.Tx, deckID int64, version int, changedBy int64, summary string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO flashcard_deck_versions (deck_id, version, summary, changed_by)
+		VALUES ($1, $2, $3, $4)
+	`, deckID, version, summary, changedBy)
+	return err
+}
@@ -127,6 +127,18 @@ func (r *FlashcardRepository) UpdateRepetition(ctx context.Context, rep *models.
 	).Scan(&rep.UpdatedAt)
 }
 
+// NodeInCourse reports whether a knowledge node belongs to a course
+func (r *FlashcardRepository) NodeInCourse(ctx context.Context, courseID, nodeID int64) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM knowledge_nodes WHERE id = $1 AND course_id = $2)
+	`, nodeID, courseID).Scan(&ok)
+	if err != nil {
+		return false, fmt.Errorf("FlashcardRepo.NodeInCourse: %w", err)
+	}
+	return ok, nil
+}
+
 // ListFlashcardsByNode returns ALL flashcards for a student+course+node regardless of status or due date.
 // Joined with flashcard_repetitions to include SM-2 state for display purposes.
 func (r *FlashcardRepository) ListFlashcardsByNode(ctx context.Context, studentID, courseID, nodeID int64) ([]models.FlashcardWithRepetition, error) {
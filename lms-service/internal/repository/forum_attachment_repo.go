@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"example/hello/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type ForumAttachmentRepository struct {
+	db *sql.DB
+}
+
+func NewForumAttachmentRepository(db *sql.DB) *ForumAttachmentRepository {
+	return &ForumAttachmentRepository{db: db}
+}
+
+const forumAttachmentColumns = `
+	id, course_id, content_id, post_id, comment_id, uploader_id,
+	file_name, storage_key, content_type, size_bytes, created_at`
+
+// CreateWithinQuota inserts an attachment unless it would push the course
+// past quota bytes. The course row is locked so concurrent uploads to the
+// same course cannot both squeeze under the limit. Returns false when the
+// quota would be exceeded.
+func (r *ForumAttachmentRepository) CreateWithinQuota(ctx context.Context, a *models.ForumAttachment, quota int64) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM courses WHERE id = $1 FOR UPDATE`, a.CourseID); err != nil {
+		return false, err
+	}
+
+	var used int64
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(size_bytes), 0) FROM forum_attachments WHERE course_id = $1`, a.CourseID,
+	).Scan(&used); err != nil {
+		return false, err
+	}
+	if quota > 0 && used+a.SizeBytes > quota {
+		return false, nil
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO forum_attachments (course_id, content_id, uploader_id, file_name, storage_key, content_type, size_bytes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, a.CourseID, a.ContentID, a.UploaderID, a.FileName, a.StorageKey, a.ContentType, a.SizeBytes,
+	).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// GetByID retrieves an attachment
+func (r *ForumAttachmentRepository) GetByID(ctx context.Context, id int64) (*models.ForumAttachment, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+forumAttachmentColumns+` FROM forum_attachments WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := scanForumAttachments(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return attachments[0], nil
+}
+
+// GetUsage returns the bytes of attachments stored for a course
+func (r *ForumAttachmentRepository) GetUsage(ctx context.Context, courseID int64) (int64, error) {
+	var used int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(size_bytes), 0) FROM forum_attachments WHERE course_id = $1`, courseID,
+	).Scan(&used)
+	return used, err
+}
+
+// Link attaches the uploader's unlinked uploads in a forum to a post or a
+// comment. It is all-or-nothing: false means at least one ID was not an
+// unlinked upload of this user in this forum, and nothing was changed.
+func (r *ForumAttachmentRepository) Link(ctx context.Context, ids []int64, uploaderID, contentID int64, postID, commentID sql.NullInt64) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE forum_attachments
+		SET post_id = $1, comment_id = $2
+		WHERE id = ANY($3) AND uploader_id = $4 AND content_id = $5
+		  AND post_id IS NULL AND comment_id IS NULL
+	`, postID, commentID, pq.Array(ids), uploaderID, contentID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n != int64(len(ids)) {
+		return false, nil
+	}
+	return true, tx.Commit()
+}
+
+// ListLinked returns the attachments of the given posts and comments
+func (r *ForumAttachmentRepository) ListLinked(ctx context.Context, postIDs, commentIDs []int64) ([]*models.ForumAttachment, error) {
+	if len(postIDs) == 0 && len(commentIDs) == 0 {
+		return nil, nil
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+forumAttachmentColumns+`
+		FROM forum_attachments
+		WHERE post_id = ANY($1) OR comment_id = ANY($2)
+		ORDER BY id
+	`, pq.Array(postIDs), pq.Array(commentIDs))
+	if err != nil {
+		return nil, err
+	}
+	return scanForumAttachments(rows)
+}
+
+// ListOrphans returns attachments not linked to any post or comment that
+// were uploaded before olderThan
+func (r *ForumAttachmentRepository) ListOrphans(ctx context.Context, olderThan time.Time, limit int) ([]*models.ForumAttachment, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+forumAttachmentColumns+`
+		FROM forum_attachments
+		WHERE post_id IS NULL AND comment_id IS NULL AND created_at < $1
+		ORDER BY id
+		LIMIT $2
+	`, olderThan, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanForumAttachments(rows)
+}
+
+// Delete removes an attachment row
+func (r *ForumAttachmentRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM forum_attachments WHERE id = $1`, id)
+	return err
+}
+
+func scanForumAttachments(rows *sql.Rows) ([]*models.ForumAttachment, error) {
+	defer rows.Close()
+
+	var attachments []*models.ForumAttachment
+	for rows.Next() {
+		a := &models.ForumAttachment{}
+		if err := rows.Scan(
+			&a.ID, &a.CourseID, &a.ContentID, &a.PostID, &a.CommentID, &a.UploaderID,
+			&a.FileName, &a.StorageKey, &a.ContentType, &a.SizeBytes, &a.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
@@ -0,0 +1,377 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"example/hello/internal/models"
+	"github.com/lib/pq"
+)
+
+type ForumNotificationRepository struct {
+	db *sql.DB
+}
+
+func NewForumNotificationRepository(db *sql.DB) *ForumNotificationRepository {
+	return &ForumNotificationRepository{db: db}
+}
+
+// ============================================
+// SUBSCRIPTIONS
+// ============================================
+
+// Subscribe subscribes a user to a forum or thread. An explicit subscription
+// upgrades an existing automatic one; an automatic one never downgrades.
+func (r *ForumNotificationRepository) Subscribe(ctx context.Context, sub *models.ForumSubscription) error {
+	query := `
+		INSERT INTO forum_subscriptions (user_id, target_type, target_id, course_id, is_auto)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, target_type, target_id)
+		DO UPDATE SET is_auto = forum_subscriptions.is_auto AND EXCLUDED.is_auto
+		RETURNING id, is_auto, created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		sub.UserID, sub.TargetType, sub.TargetID, sub.CourseID, sub.IsAuto,
+	).Scan(&sub.ID, &sub.IsAuto, &sub.CreatedAt)
+}
+
+// Unsubscribe removes a subscription
+func (r *ForumNotificationRepository) Unsubscribe(ctx context.Context, userID int64, targetType string, targetID int64) error {
+	query := `DELETE FROM forum_subscriptions WHERE user_id = $1 AND target_type = $2 AND target_id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, userID, targetType, targetID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// IsSubscribed reports whether the user follows the target
+func (r *ForumNotificationRepository) IsSubscribed(ctx context.Context, userID int64, targetType string, targetID int64) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM forum_subscriptions WHERE user_id = $1 AND target_type = $2 AND target_id = $3)`
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, userID, targetType, targetID).Scan(&exists)
+	return exists, err
+}
+
+// ListSubscriptions lists a user's subscriptions with the forum/thread title
+func (r *ForumNotificationRepository) ListSubscriptions(ctx context.Context, userID int64) ([]*models.ForumSubscription, error) {
+	query := `
+		SELECT s.id, s.user_id, s.target_type, s.target_id, s.course_id, s.is_auto,
+		       COALESCE(p.title, sc.title, '') AS target_title, s.created_at
+		FROM forum_subscriptions s
+		LEFT JOIN forum_posts p ON s.target_type = 'post' AND p.id = s.target_id
+		LEFT JOIN section_content sc ON s.target_type = 'forum' AND sc.id = s.target_id
+		WHERE s.user_id = $1
+		ORDER BY s.created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*models.ForumSubscription
+	for rows.Next() {
+		var sub models.ForumSubscription
+		err := rows.Scan(
+			&sub.ID,
+			&sub.UserID,
+			&sub.TargetType,
+			&sub.TargetID,
+			&sub.CourseID,
+			&sub.IsAuto,
+			&sub.TargetTitle,
+			&sub.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, &sub)
+	}
+
+	return subs, rows.Err()
+}
+
+// ============================================
+// READ STATE & MENTIONS
+// ============================================
+
+// MarkPostRead records that the user has seen a thread up to now
+func (r *ForumNotificationRepository) MarkPostRead(ctx context.Context, userID, postID int64) error {
+	query := `
+		INSERT INTO forum_post_reads (user_id, post_id, last_read_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id, post_id) DO UPDATE SET last_read_at = CURRENT_TIMESTAMP
+	`
+
+	_, err := r.db.ExecContext(ctx, query, userID, postID)
+	return err
+}
+
+// ResolveMentions maps @handles to members of a course: accepted students,
+// the owner and co-teachers. A handle matches a full email address or its
+// local part, case-insensitively.
+func (r *ForumNotificationRepository) ResolveMentions(ctx context.Context, courseID int64, handles []string) ([]int64, error) {
+	query := `
+		WITH members AS (
+			SELECT student_id AS user_id FROM enrollments WHERE course_id = $1 AND status = 'ACCEPTED'
+			UNION
+			SELECT created_by FROM courses WHERE id = $1
+			UNION
+			SELECT user_id FROM course_co_teachers WHERE course_id = $1
+		)
+		SELECT u.id
+		FROM users u
+		JOIN members m ON m.user_id = u.id
+		WHERE LOWER(u.email) = ANY($2)
+		   OR LOWER(SPLIT_PART(u.email, '@', 1)) = ANY($2)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, courseID, pq.Array(handles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// ============================================
+// NOTIFICATIONS
+// ============================================
+
+// NotifySubscribers fans a notification out to every subscriber of a target
+// except the users in exclude (the actor, users already notified).
+func (r *ForumNotificationRepository) NotifySubscribers(ctx context.Context, n *models.ForumNotification, targetType string, targetID int64, exclude []int64) (int64, error) {
+	query := `
+		INSERT INTO forum_notifications (user_id, course_id, post_id, comment_id, type, actor_id)
+		SELECT s.user_id, $1, $2, $3, $4, $5
+		FROM forum_subscriptions s
+		WHERE s.target_type = $6 AND s.target_id = $7
+		  AND NOT (s.user_id = ANY($8))
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		n.CourseID, n.PostID, n.CommentID, n.Type, n.ActorID,
+		targetType, targetID, pq.Array(exclude),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// NotifyUsers creates the same notification for each of the given users
+func (r *ForumNotificationRepository) NotifyUsers(ctx context.Context, n *models.ForumNotification, userIDs []int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO forum_notifications (user_id, course_id, post_id, comment_id, type, actor_id)
+		SELECT UNNEST($1::bigint[]), $2, $3, $4, $5, $6
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		pq.Array(userIDs), n.CourseID, n.PostID, n.CommentID, n.Type, n.ActorID)
+	return err
+}
+
+const notificationColumns = `
+	n.id, n.user_id, n.course_id, COALESCE(co.title, '') AS course_title,
+	n.post_id, COALESCE(p.title, '') AS post_title, n.comment_id,
+	LEFT(COALESCE(c.body, p.body), 280) AS excerpt,
+	n.type, n.actor_id, a.full_name AS actor_name,
+	n.read_at, n.digested_at, n.created_at
+`
+
+const notificationJoins = `
+	LEFT JOIN courses co ON co.id = n.course_id
+	LEFT JOIN forum_posts p ON p.id = n.post_id
+	LEFT JOIN forum_comments c ON c.id = n.comment_id
+	LEFT JOIN users a ON a.id = n.actor_id
+`
+
+func scanNotifications(rows *sql.Rows) ([]*models.ForumNotification, error) {
+	defer rows.Close()
+
+	var items []*models.ForumNotification
+	for rows.Next() {
+		var n models.ForumNotification
+		err := rows.Scan(
+			&n.ID,
+			&n.UserID,
+			&n.CourseID,
+			&n.CourseTitle,
+			&n.PostID,
+			&n.PostTitle,
+			&n.CommentID,
+			&n.Excerpt,
+			&n.Type,
+			&n.ActorID,
+			&n.ActorName,
+			&n.ReadAt,
+			&n.DigestedAt,
+			&n.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &n)
+	}
+
+	return items, rows.Err()
+}
+
+// ListNotifications lists a user's notifications, newest first, and returns
+// the total matching count along with the overall unread count.
+func (r *ForumNotificationRepository) ListNotifications(ctx context.Context, userID int64, unreadOnly bool, page, limit int) ([]*models.ForumNotification, int, int, error) {
+	var total, unread int
+	countQuery := `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE read_at IS NULL)
+		FROM forum_notifications WHERE user_id = $1
+	`
+	if err := r.db.QueryRowContext(ctx, countQuery, userID).Scan(&total, &unread); err != nil {
+		return nil, 0, 0, err
+	}
+	if unreadOnly {
+		total = unread
+	}
+
+	query := `SELECT ` + notificationColumns + `
+		FROM forum_notifications n ` + notificationJoins + `
+		WHERE n.user_id = $1 AND ($2 = false OR n.read_at IS NULL)
+		ORDER BY n.created_at DESC, n.id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, unreadOnly, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	items, err := scanNotifications(rows)
+	return items, total, unread, err
+}
+
+// MarkNotificationsRead marks the given notifications (or all, when ids is
+// empty) as read.
+func (r *ForumNotificationRepository) MarkNotificationsRead(ctx context.Context, userID int64, ids []int64) (int64, error) {
+	query := `
+		UPDATE forum_notifications SET read_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND read_at IS NULL
+		  AND (CARDINALITY($2::bigint[]) = 0 OR id = ANY($2))
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userID, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// MarkPostNotificationsRead clears a user's notifications for a thread, used
+// when the thread is opened.
+func (r *ForumNotificationRepository) MarkPostNotificationsRead(ctx context.Context, userID, postID int64) error {
+	query := `
+		UPDATE forum_notifications SET read_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND post_id = $2 AND read_at IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, userID, postID)
+	return err
+}
+
+// ============================================
+// DIGEST
+// ============================================
+
+// DigestRecipient is a user with notifications not yet rolled into a digest
+type DigestRecipient struct {
+	UserID   int64
+	Email    string
+	FullName string
+}
+
+// ListDigestRecipients returns users with undigested notifications
+func (r *ForumNotificationRepository) ListDigestRecipients(ctx context.Context, limit int) ([]DigestRecipient, error) {
+	query := `
+		SELECT u.id, u.email, COALESCE(u.full_name, '')
+		FROM users u
+		WHERE u.id IN (SELECT DISTINCT user_id FROM forum_notifications WHERE digested_at IS NULL)
+		ORDER BY u.id
+		LIMIT $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DigestRecipient
+	for rows.Next() {
+		var d DigestRecipient
+		if err := rows.Scan(&d.UserID, &d.Email, &d.FullName); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+
+	return out, rows.Err()
+}
+
+// ClaimDigest marks a user's pending notifications as digested and returns
+// the unread ones. Claiming is atomic per user, so concurrent producers on
+// several replicas never send the same notification twice.
+func (r *ForumNotificationRepository) ClaimDigest(ctx context.Context, userID int64) ([]*models.ForumNotification, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE forum_notifications SET digested_at = CURRENT_TIMESTAMP
+			WHERE user_id = $1 AND digested_at IS NULL
+			RETURNING *
+		)
+		SELECT ` + notificationColumns + `
+		FROM claimed n ` + notificationJoins + `
+		WHERE n.read_at IS NULL
+		ORDER BY n.course_id, n.post_id, n.created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanNotifications(rows)
+}
+
+// ReleaseDigest un-claims notifications after a failed publish so the next
+// run picks them up again.
+func (r *ForumNotificationRepository) ReleaseDigest(ctx context.Context, ids []int64) error {
+	query := `UPDATE forum_notifications SET digested_at = NULL WHERE id = ANY($1)`
+
+	_, err := r.db.ExecContext(ctx, query, pq.Array(ids))
+	return err
+}
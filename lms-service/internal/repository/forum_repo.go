@@ -51,7 +51,8 @@ func (r *ForumRepository) GetPostByID(ctx context.Context, postID, currentUserID
 		SELECT 
 			p.id, p.content_id, p.user_id, p.title, p.body, p.tags,
 			p.upvotes, p.downvotes, p.comment_count, p.view_count,
-			p.is_pinned, p.is_locked, p.created_at, p.updated_at,
+			p.is_pinned, p.is_locked, p.is_hidden, p.hidden_reason, p.report_count,
+			p.created_at, p.updated_at,
 			u.full_name as user_name, u.email as user_email,
 			v.vote_type as current_user_vote
 		FROM forum_posts p
@@ -78,6 +79,9 @@ func (r *ForumRepository) GetPostByID(ctx context.Context, postID, currentUserID
 		&post.ViewCount,
 		&post.IsPinned,
 		&post.IsLocked,
+		&post.IsHidden,
+		&post.HiddenReason,
+		&post.ReportCount,
 		&post.CreatedAt,
 		&post.UpdatedAt,
 		&post.UserName,
@@ -93,13 +97,20 @@ func (r *ForumRepository) GetPostByID(ctx context.Context, postID, currentUserID
 	return &post, nil
 }
 
-// ListPosts lists posts with sorting, filtering, and pagination
-func (r *ForumRepository) ListPosts(ctx context.Context, contentID, currentUserID int64, sortBy, search, tags string, page, limit int) ([]*models.ForumPostWithUser, int, error) {
+// ListPosts lists posts with sorting, filtering, and pagination. Hidden posts
+// are only included for their author unless includeHidden is set.
+func (r *ForumRepository) ListPosts(ctx context.Context, contentID, currentUserID int64, sortBy, search, tags string, page, limit int, includeHidden bool) ([]*models.ForumPostWithUser, int, error) {
 	// Build WHERE clause
 	where := []string{"p.content_id = $1"}
 	args := []interface{}{contentID}
 	argIndex := 2
 
+	if !includeHidden {
+		where = append(where, fmt.Sprintf("(NOT p.is_hidden OR p.user_id = $%d)", argIndex))
+		args = append(args, currentUserID)
+		argIndex++
+	}
+
 	// Add search filter
 	if search != "" {
 		where = append(where, fmt.Sprintf("to_tsvector('english', p.title || ' ' || p.body) @@ plainto_tsquery('english', $%d)", argIndex))
@@ -146,17 +157,24 @@ func (r *ForumRepository) ListPosts(ctx context.Context, contentID, currentUserI
 		SELECT 
 			p.id, p.content_id, p.user_id, p.title, p.body, p.tags,
 			p.upvotes, p.downvotes, p.comment_count, p.view_count,
-			p.is_pinned, p.is_locked, p.created_at, p.updated_at,
+			p.is_pinned, p.is_locked, p.is_hidden, p.hidden_reason, p.report_count,
+			p.created_at, p.updated_at,
 			u.full_name as user_name, u.email as user_email,
-			v.vote_type as current_user_vote
+			v.vote_type as current_user_vote,
+			rd.last_read_at,
+			(SELECT COUNT(*) FROM forum_comments fc
+			 WHERE fc.post_id = p.id
+			   AND fc.user_id <> $%[1]d
+			   AND fc.created_at > COALESCE(rd.last_read_at, '-infinity'::timestamp)) AS unread_comment_count
 		FROM forum_posts p
 		LEFT JOIN users u ON p.user_id = u.id
 		LEFT JOIN forum_votes v ON v.votable_type = 'post' 
 			AND v.votable_id = p.id 
-			AND v.user_id = $%d
-		WHERE %s
-		ORDER BY %s
-		LIMIT $%d OFFSET $%d
+			AND v.user_id = $%[1]d
+		LEFT JOIN forum_post_reads rd ON rd.post_id = p.id AND rd.user_id = $%[1]d
+		WHERE %[2]s
+		ORDER BY %[3]s
+		LIMIT $%[4]d OFFSET $%[5]d
 	`, argIndex, whereClause, orderBy, argIndex+1, argIndex+2)
 
 	args = append(args, currentUserID, limit, offset)
@@ -185,11 +203,16 @@ func (r *ForumRepository) ListPosts(ctx context.Context, contentID, currentUserI
 			&post.ViewCount,
 			&post.IsPinned,
 			&post.IsLocked,
+			&post.IsHidden,
+			&post.HiddenReason,
+			&post.ReportCount,
 			&post.CreatedAt,
 			&post.UpdatedAt,
 			&post.UserName,
 			&post.UserEmail,
 			&post.CurrentUserVote,
+			&post.LastReadAt,
+			&post.UnreadCommentCount,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -312,6 +335,7 @@ func (r *ForumRepository) GetCommentByID(ctx context.Context, commentID, current
 		SELECT 
 			c.id, c.post_id, c.parent_comment_id, c.user_id, c.body,
 			c.upvotes, c.downvotes, c.is_accepted, c.depth,
+			c.is_hidden, c.hidden_reason, c.report_count,
 			c.created_at, c.updated_at,
 			u.full_name as user_name, u.email as user_email,
 			v.vote_type as current_user_vote
@@ -335,6 +359,9 @@ func (r *ForumRepository) GetCommentByID(ctx context.Context, commentID, current
 		&comment.Downvotes,
 		&comment.IsAccepted,
 		&comment.Depth,
+		&comment.IsHidden,
+		&comment.HiddenReason,
+		&comment.ReportCount,
 		&comment.CreatedAt,
 		&comment.UpdatedAt,
 		&comment.UserName,
@@ -355,6 +382,7 @@ func (r *ForumRepository) ListCommentsByPost(ctx context.Context, postID, curren
 		SELECT 
 			c.id, c.post_id, c.parent_comment_id, c.user_id, c.body,
 			c.upvotes, c.downvotes, c.is_accepted, c.depth,
+			c.is_hidden, c.hidden_reason, c.report_count,
 			c.created_at, c.updated_at,
 			u.full_name as user_name, u.email as user_email,
 			v.vote_type as current_user_vote
@@ -387,6 +415,9 @@ func (r *ForumRepository) ListCommentsByPost(ctx context.Context, postID, curren
 			&comment.Downvotes,
 			&comment.IsAccepted,
 			&comment.Depth,
+			&comment.IsHidden,
+			&comment.HiddenReason,
+			&comment.ReportCount,
 			&comment.CreatedAt,
 			&comment.UpdatedAt,
 			&comment.UserName,
@@ -502,4 +533,407 @@ func (r *ForumRepository) GetUserVote(ctx context.Context, userID int64, votable
 	}
 
 	return &voteType, nil
-}
\ No newline at end of file
+}
+// ============================================
+// MODERATION OPERATIONS
+// ============================================
+
+func moderationTable(targetType string) (string, error) {
+	switch targetType {
+	case models.VotableTypePost:
+		return "forum_posts", nil
+	case models.VotableTypeComment:
+		return "forum_comments", nil
+	}
+	return "", fmt.Errorf("invalid target type: %s", targetType)
+}
+
+// GetCourseIDByContentID resolves the course a forum content item belongs to
+func (r *ForumRepository) GetCourseIDByContentID(ctx context.Context, contentID int64) (int64, error) {
+	query := `
+		SELECT s.course_id
+		FROM section_content sc
+		JOIN course_sections s ON s.id = sc.section_id
+		WHERE sc.id = $1
+	`
+
+	var courseID int64
+	err := r.db.QueryRowContext(ctx, query, contentID).Scan(&courseID)
+	return courseID, err
+}
+
+// GetCourseIDByPostID resolves the course a forum post belongs to
+func (r *ForumRepository) GetCourseIDByPostID(ctx context.Context, postID int64) (int64, error) {
+	query := `
+		SELECT s.course_id
+		FROM forum_posts p
+		JOIN section_content sc ON sc.id = p.content_id
+		JOIN course_sections s ON s.id = sc.section_id
+		WHERE p.id = $1
+	`
+
+	var courseID int64
+	err := r.db.QueryRowContext(ctx, query, postID).Scan(&courseID)
+	return courseID, err
+}
+
+// CreateReport records a report and bumps the target's report counter. A user
+// reporting the same target twice is a no-op (created = false). openReports
+// is the number of OPEN reports against the target after the insert.
+func (r *ForumRepository) CreateReport(ctx context.Context, report *models.ForumReport) (created bool, openReports int, err error) {
+	table, err := moderationTable(report.TargetType)
+	if err != nil {
+		return false, 0, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback()
+
+	insert := `
+		INSERT INTO forum_reports (target_type, target_id, post_id, course_id, reporter_id, reason, details)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (target_type, target_id, reporter_id) DO NOTHING
+		RETURNING id, status, created_at
+	`
+	err = tx.QueryRowContext(ctx, insert,
+		report.TargetType,
+		report.TargetID,
+		report.PostID,
+		report.CourseID,
+		report.ReporterID,
+		report.Reason,
+		report.Details,
+	).Scan(&report.ID, &report.Status, &report.CreatedAt)
+	switch {
+	case err == sql.ErrNoRows:
+		created = false
+	case err != nil:
+		return false, 0, err
+	default:
+		created = true
+		bump := fmt.Sprintf(`UPDATE %s SET report_count = report_count + 1 WHERE id = $1`, table)
+		if _, err := tx.ExecContext(ctx, bump, report.TargetID); err != nil {
+			return false, 0, err
+		}
+	}
+
+	count := `SELECT COUNT(*) FROM forum_reports WHERE target_type = $1 AND target_id = $2 AND status = 'OPEN'`
+	if err := tx.QueryRowContext(ctx, count, report.TargetType, report.TargetID).Scan(&openReports); err != nil {
+		return false, 0, err
+	}
+
+	return created, openReports, tx.Commit()
+}
+
+// SetHidden hides or restores a post or comment
+func (r *ForumRepository) SetHidden(ctx context.Context, targetType string, targetID int64, hidden bool, reason string) error {
+	table, err := moderationTable(targetType)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET is_hidden = $1, hidden_reason = $2 WHERE id = $3`, table)
+	hiddenReason := sql.NullString{String: reason, Valid: hidden && reason != ""}
+
+	result, err := r.db.ExecContext(ctx, query, hidden, hiddenReason, targetID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ResolveReports closes every OPEN report against a target
+func (r *ForumRepository) ResolveReports(ctx context.Context, targetType string, targetID int64, status string, resolvedBy int64, note string) (int64, error) {
+	query := `
+		UPDATE forum_reports
+		SET status = $1, resolved_by = $2, resolution_note = $3, resolved_at = CURRENT_TIMESTAMP
+		WHERE target_type = $4 AND target_id = $5 AND status = 'OPEN'
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		status, resolvedBy, sql.NullString{String: note, Valid: note != ""}, targetType, targetID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListReportQueue lists reported targets grouped per post/comment, most
+// reported first. courseID and orgID narrow the queue when set.
+func (r *ForumRepository) ListReportQueue(ctx context.Context, status string, courseID, orgID *int64, page, limit int) ([]*models.ForumReportQueueItem, int, error) {
+	where := []string{"r.status = $1"}
+	args := []interface{}{status}
+	argIndex := 2
+
+	if courseID != nil {
+		where = append(where, fmt.Sprintf("r.course_id = $%d", argIndex))
+		args = append(args, *courseID)
+		argIndex++
+	}
+	if orgID != nil {
+		where = append(where, fmt.Sprintf("co.org_id = $%d", argIndex))
+		args = append(args, *orgID)
+		argIndex++
+	}
+
+	whereClause := strings.Join(where, " AND ")
+
+	countQuery := fmt.Sprintf(`
+		SELECT COUNT(DISTINCT (r.target_type, r.target_id))
+		FROM forum_reports r
+		JOIN courses co ON co.id = r.course_id
+		WHERE %s
+	`, whereClause)
+	var total int
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+
+	query := fmt.Sprintf(`
+		SELECT
+			r.target_type, r.target_id, r.post_id, r.course_id, co.title AS course_title,
+			COUNT(*) AS report_count,
+			ARRAY_AGG(DISTINCT r.reason) AS reasons,
+			MIN(r.created_at) AS first_reported_at,
+			MAX(r.created_at) AS last_reported_at,
+			COALESCE(c.is_hidden, p.is_hidden, false) AS is_hidden,
+			COALESCE(c.hidden_reason, p.hidden_reason) AS hidden_reason,
+			COALESCE(LEFT(c.body, 280), p.title) AS excerpt,
+			COALESCE(c.user_id, p.user_id) AS author_id,
+			u.full_name AS author_name
+		FROM forum_reports r
+		JOIN courses co ON co.id = r.course_id
+		LEFT JOIN forum_posts p ON r.target_type = 'post' AND p.id = r.target_id
+		LEFT JOIN forum_comments c ON r.target_type = 'comment' AND c.id = r.target_id
+		LEFT JOIN users u ON u.id = COALESCE(c.user_id, p.user_id)
+		WHERE %s
+		GROUP BY r.target_type, r.target_id, r.post_id, r.course_id, co.title,
+			c.is_hidden, p.is_hidden, c.hidden_reason, p.hidden_reason,
+			c.body, p.title, c.user_id, p.user_id, u.full_name
+		ORDER BY COUNT(*) DESC, MIN(r.created_at) ASC
+		LIMIT $%d OFFSET $%d
+	`, whereClause, argIndex, argIndex+1)
+
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var items []*models.ForumReportQueueItem
+	for rows.Next() {
+		var item models.ForumReportQueueItem
+		var reasons pq.StringArray
+
+		err := rows.Scan(
+			&item.TargetType,
+			&item.TargetID,
+			&item.PostID,
+			&item.CourseID,
+			&item.CourseTitle,
+			&item.ReportCount,
+			&reasons,
+			&item.FirstReportedAt,
+			&item.LastReportedAt,
+			&item.IsHidden,
+			&item.HiddenReason,
+			&item.Excerpt,
+			&item.AuthorID,
+			&item.AuthorName,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		item.Reasons = reasons
+		items = append(items, &item)
+	}
+
+	return items, total, rows.Err()
+}
+
+// ListReportsForTarget returns every report filed against a post or comment
+func (r *ForumRepository) ListReportsForTarget(ctx context.Context, targetType string, targetID int64) ([]*models.ForumReport, error) {
+	query := `
+		SELECT id, target_type, target_id, post_id, course_id, reporter_id, reason, details,
+		       status, resolved_by, resolution_note, resolved_at, created_at
+		FROM forum_reports
+		WHERE target_type = $1 AND target_id = $2
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []*models.ForumReport
+	for rows.Next() {
+		var rep models.ForumReport
+		err := rows.Scan(
+			&rep.ID,
+			&rep.TargetType,
+			&rep.TargetID,
+			&rep.PostID,
+			&rep.CourseID,
+			&rep.ReporterID,
+			&rep.Reason,
+			&rep.Details,
+			&rep.Status,
+			&rep.ResolvedBy,
+			&rep.ResolutionNote,
+			&rep.ResolvedAt,
+			&rep.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, &rep)
+	}
+
+	return reports, rows.Err()
+}
+
+// CreateBan issues a posting ban, replacing any active ban for the same user
+func (r *ForumRepository) CreateBan(ctx context.Context, ban *models.ForumPostingBan) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	revoke := `
+		UPDATE forum_posting_bans SET revoked_at = CURRENT_TIMESTAMP
+		WHERE course_id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+	`
+	if _, err := tx.ExecContext(ctx, revoke, ban.CourseID, ban.UserID); err != nil {
+		return err
+	}
+
+	insert := `
+		INSERT INTO forum_posting_bans (course_id, user_id, banned_by, reason, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err = tx.QueryRowContext(ctx, insert,
+		ban.CourseID, ban.UserID, ban.BannedBy, ban.Reason, ban.ExpiresAt,
+	).Scan(&ban.ID, &ban.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetActiveBan returns the user's active posting ban in a course, or nil
+func (r *ForumRepository) GetActiveBan(ctx context.Context, courseID, userID int64) (*models.ForumPostingBan, error) {
+	query := `
+		SELECT id, course_id, user_id, banned_by, reason, expires_at, revoked_at, created_at
+		FROM forum_posting_bans
+		WHERE course_id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		ORDER BY expires_at DESC
+		LIMIT 1
+	`
+
+	var ban models.ForumPostingBan
+	err := r.db.QueryRowContext(ctx, query, courseID, userID).Scan(
+		&ban.ID,
+		&ban.CourseID,
+		&ban.UserID,
+		&ban.BannedBy,
+		&ban.Reason,
+		&ban.ExpiresAt,
+		&ban.RevokedAt,
+		&ban.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ban, nil
+}
+
+// RevokeBan lifts a user's active posting ban in a course
+func (r *ForumRepository) RevokeBan(ctx context.Context, courseID, userID int64) error {
+	query := `
+		UPDATE forum_posting_bans SET revoked_at = CURRENT_TIMESTAMP
+		WHERE course_id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+	`
+
+	result, err := r.db.ExecContext(ctx, query, courseID, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListActiveBans lists the active posting bans in a course
+func (r *ForumRepository) ListActiveBans(ctx context.Context, courseID int64) ([]*models.ForumPostingBan, error) {
+	query := `
+		SELECT b.id, b.course_id, b.user_id, COALESCE(u.full_name, '') AS user_name,
+		       b.banned_by, b.reason, b.expires_at, b.revoked_at, b.created_at
+		FROM forum_posting_bans b
+		LEFT JOIN users u ON u.id = b.user_id
+		WHERE b.course_id = $1 AND b.revoked_at IS NULL AND b.expires_at > CURRENT_TIMESTAMP
+		ORDER BY b.expires_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []*models.ForumPostingBan
+	for rows.Next() {
+		var ban models.ForumPostingBan
+		err := rows.Scan(
+			&ban.ID,
+			&ban.CourseID,
+			&ban.UserID,
+			&ban.UserName,
+			&ban.BannedBy,
+			&ban.Reason,
+			&ban.ExpiresAt,
+			&ban.RevokedAt,
+			&ban.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		bans = append(bans, &ban)
+	}
+
+	return bans, rows.Err()
+}
@@ -23,7 +23,16 @@ func NewLearningEventRepository(db *sql.DB) *LearningEventRepository {
 // LEARNING EVENTS
 // ══════════════════════════════════════════════════════════════════════════════
 
-func (r *LearningEventRepository) CreateEvent(ctx context.Context, event *models.LearningEvent) error {
+// CreateEvent inserts a learning event. When toMessage is non-nil the
+// message it builds from the saved event is written to the outbox in the
+// same transaction.
+func (r *LearningEventRepository) CreateEvent(ctx context.Context, event *models.LearningEvent, toMessage func(*models.LearningEvent) OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO learning_events (
 			event_id, event_type, student_id, session_id, course_id,
@@ -33,14 +42,23 @@ func (r *LearningEventRepository) CreateEvent(ctx context.Context, event *models
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
 		) RETURNING id, created_at`
 
-	err := r.db.QueryRowContext(
+	err = tx.QueryRowContext(
 		ctx, query,
 		event.EventID, event.EventType, event.StudentID, event.SessionID, event.CourseID,
 		event.LessonID, event.QuestionID, event.SkillID, event.Difficulty, event.Correct,
 		event.AttemptNo, event.ResponseTimeMs, event.HintCount, event.Metadata,
 	).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if toMessage != nil {
+		if err := enqueueOutbox(ctx, tx, toMessage(event)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 func (r *LearningEventRepository) GetEventByID(ctx context.Context, id int64) (*models.LearningEvent, error) {
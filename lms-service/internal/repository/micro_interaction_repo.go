@@ -22,9 +22,10 @@ func NewMicroInteractionRepository(db *sql.DB) *MicroInteractionRepository {
 
 // ── Raw log ────────────────────────────────────────────────────────
 
-// Insert appends a raw interaction row and returns the generated id +
-// created_at so the producer can include them in the Kafka event.
-func (r *MicroInteractionRepository) Insert(ctx context.Context, m *models.MicroLessonInteraction) (*models.MicroLessonInteraction, error) {
+// Insert appends a raw interaction row and fills in the generated id +
+// created_at. When toMessage is non-nil the Kafka event it builds from the
+// saved row is written to the outbox in the same transaction.
+func (r *MicroInteractionRepository) Insert(ctx context.Context, m *models.MicroLessonInteraction, toMessage func(*models.MicroLessonInteraction) OutboxMessage) (*models.MicroLessonInteraction, error) {
 	const q = `
 		INSERT INTO micro_lesson_interactions
 			(user_id, course_id, lesson_id, node_id, action_type, score, status, payload)
@@ -34,12 +35,26 @@ func (r *MicroInteractionRepository) Insert(ctx context.Context, m *models.Micro
 	if len(m.Payload) == 0 {
 		m.Payload = []byte(`{}`)
 	}
-	if err := r.db.QueryRowContext(ctx, q,
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRowContext(ctx, q,
 		m.UserID, m.CourseID, m.LessonID, m.NodeID,
 		m.ActionType, m.Score, m.Status, string(m.Payload),
 	).Scan(&m.ID, &m.CreatedAt); err != nil {
 		return nil, fmt.Errorf("insert micro_lesson_interactions: %w", err)
 	}
+	if toMessage != nil {
+		if err := enqueueOutbox(ctx, tx, toMessage(m)); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return m, nil
 }
 
@@ -115,7 +130,7 @@ func (r *MicroInteractionRepository) ApplyDelta(ctx context.Context, d MasteryCo
 			last_interaction_at = CURRENT_TIMESTAMP,
 			updated_at          = CURRENT_TIMESTAMP
 	`
-	if _, err := r.db.ExecContext(ctx, q,
+	if _, err := Conn(ctx, r.db).ExecContext(ctx, q,
 		d.UserID, d.CourseID, d.NodeID,
 		d.FormalQuizSample, d.MiniQuizSample,
 		d.CompletionSample, d.EngagementSample,
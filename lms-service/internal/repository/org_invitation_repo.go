@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"example/hello/internal/models"
+)
+
+var (
+	// ErrInvitationUnavailable is returned when an invitation has been
+	// accepted, revoked or has expired
+	ErrInvitationUnavailable = errors.New("invitation is no longer valid")
+	// ErrJoinCodeUnavailable is returned when a join code is revoked,
+	// expired or used up
+	ErrJoinCodeUnavailable = errors.New("join code is no longer valid")
+	// ErrAlreadyMember is returned when the user joining already belongs to
+	// the organization; nothing is consumed
+	ErrAlreadyMember = errors.New("user is already a member of this organization")
+)
+
+// OrgInvitationRepository stores organization invitations and join codes
+type OrgInvitationRepository struct {
+	db *sql.DB
+}
+
+func NewOrgInvitationRepository(db *sql.DB) *OrgInvitationRepository {
+	return &OrgInvitationRepository{db: db}
+}
+
+const invitationColumns = `id, org_id, email, org_role, token_hash, invited_by, expires_at,
+	accepted_at, accepted_by, revoked_at, created_at`
+
+// CreateInvitation stores inv, revoking any open invitation to the same
+// address first. event builds the outbox message (the invitation email)
+// once inv has its ID; it is written in the same transaction.
+func (r *OrgInvitationRepository) CreateInvitation(ctx context.Context, inv *models.OrgInvitation, event func(*models.OrgInvitation) OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE org_invitations SET revoked_at = NOW()
+		WHERE org_id = $1 AND LOWER(email) = LOWER($2) AND accepted_at IS NULL AND revoked_at IS NULL
+	`, inv.OrgID, inv.Email); err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO org_invitations (org_id, email, org_role, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, inv.OrgID, inv.Email, inv.OrgRole, inv.TokenHash, inv.InvitedBy, inv.ExpiresAt).Scan(&inv.ID, &inv.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if event != nil {
+		if err := enqueueOutbox(ctx, tx, event(inv)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *OrgInvitationRepository) GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*models.OrgInvitation, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+invitationColumns+` FROM org_invitations WHERE token_hash = $1`, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	invs, err := scanInvitations(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(invs) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return invs[0], nil
+}
+
+// ListInvitations returns an organization's invitations, newest first
+func (r *OrgInvitationRepository) ListInvitations(ctx context.Context, orgID int64, limit, offset int) ([]*models.OrgInvitation, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM org_invitations WHERE org_id = $1`, orgID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+invitationColumns+` FROM org_invitations
+		WHERE org_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, orgID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	invs, err := scanInvitations(rows)
+	return invs, total, err
+}
+
+// RevokeInvitation revokes an open invitation of the organization
+func (r *OrgInvitationRepository) RevokeInvitation(ctx context.Context, orgID, id int64) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE org_invitations SET revoked_at = NOW()
+		WHERE id = $1 AND org_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL
+	`, id, orgID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AcceptInvitation marks inv accepted by userID and adds them to the
+// organization with the invited role, in one transaction with events. An
+// existing member gets ErrAlreadyMember and the invitation stays open.
+func (r *OrgInvitationRepository) AcceptInvitation(ctx context.Context, inv *models.OrgInvitation, userID int64, events ...OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE org_invitations SET accepted_at = NOW(), accepted_by = $2
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+	`, inv.ID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrInvitationUnavailable
+	}
+
+	if err := joinTx(ctx, tx, inv.OrgID, userID, inv.OrgRole); err != nil {
+		return err
+	}
+	if err := enqueueOutbox(ctx, tx, events...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+const joinCodeColumns = `id, org_id, code, org_role, max_uses, use_count, expires_at, created_by, revoked_at, created_at`
+
+// CreateJoinCode stores code; a clash with an existing code surfaces as a
+// unique violation for the caller to retry with another
+func (r *OrgInvitationRepository) CreateJoinCode(ctx context.Context, code *models.OrgJoinCode) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO org_join_codes (org_id, code, org_role, max_uses, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, use_count, created_at
+	`, code.OrgID, code.Code, code.OrgRole, code.MaxUses, code.ExpiresAt, code.CreatedBy,
+	).Scan(&code.ID, &code.UseCount, &code.CreatedAt)
+}
+
+func (r *OrgInvitationRepository) GetJoinCode(ctx context.Context, code string) (*models.OrgJoinCode, error) {
+	codes, err := r.queryJoinCodes(ctx, `SELECT `+joinCodeColumns+` FROM org_join_codes WHERE code = $1`, code)
+	if err != nil {
+		return nil, err
+	}
+	if len(codes) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return codes[0], nil
+}
+
+// ListJoinCodes returns an organization's join codes, newest first
+func (r *OrgInvitationRepository) ListJoinCodes(ctx context.Context, orgID int64) ([]*models.OrgJoinCode, error) {
+	return r.queryJoinCodes(ctx, `
+		SELECT `+joinCodeColumns+` FROM org_join_codes
+		WHERE org_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, orgID)
+}
+
+func (r *OrgInvitationRepository) RevokeJoinCode(ctx context.Context, orgID, id int64) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE org_join_codes SET revoked_at = NOW() WHERE id = $1 AND org_id = $2 AND revoked_at IS NULL`,
+		id, orgID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RedeemJoinCode counts one use of code and adds userID to its organization,
+// in one transaction with events. An existing member gets ErrAlreadyMember
+// and no use is counted.
+func (r *OrgInvitationRepository) RedeemJoinCode(ctx context.Context, code *models.OrgJoinCode, userID int64, events ...OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE org_join_codes SET use_count = use_count + 1
+		WHERE id = $1 AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		  AND (max_uses IS NULL OR use_count < max_uses)
+	`, code.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrJoinCodeUnavailable
+	}
+
+	if err := joinTx(ctx, tx, code.OrgID, userID, code.OrgRole); err != nil {
+		return err
+	}
+	if err := enqueueOutbox(ctx, tx, events...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// joinTx adds a self-joining member within the member limit, leaving an
+// existing member untouched
+func joinTx(ctx context.Context, tx *sql.Tx, orgID, userID int64, role string) error {
+	n, err := addMembersTx(ctx, tx, orgID, []int64{userID}, role, true)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrAlreadyMember
+	}
+	return nil
+}
+
+func (r *OrgInvitationRepository) queryJoinCodes(ctx context.Context, query string, args ...interface{}) ([]*models.OrgJoinCode, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []*models.OrgJoinCode
+	for rows.Next() {
+		var c models.OrgJoinCode
+		if err := rows.Scan(&c.ID, &c.OrgID, &c.Code, &c.OrgRole, &c.MaxUses, &c.UseCount,
+			&c.ExpiresAt, &c.CreatedBy, &c.RevokedAt, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		codes = append(codes, &c)
+	}
+	return codes, rows.Err()
+}
+
+func scanInvitations(rows *sql.Rows) ([]*models.OrgInvitation, error) {
+	defer rows.Close()
+
+	var invs []*models.OrgInvitation
+	for rows.Next() {
+		var inv models.OrgInvitation
+		if err := rows.Scan(&inv.ID, &inv.OrgID, &inv.Email, &inv.OrgRole, &inv.TokenHash, &inv.InvitedBy,
+			&inv.ExpiresAt, &inv.AcceptedAt, &inv.AcceptedBy, &inv.RevokedAt, &inv.CreatedAt); err != nil {
+			return nil, err
+		}
+		invs = append(invs, &inv)
+	}
+	return invs, rows.Err()
+}
@@ -3,12 +3,19 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
 	"example/hello/internal/models"
+
+	"github.com/lib/pq"
 )
 
+// ErrOrgMemberLimit is returned when new members would take an organization
+// past settings.max_members
+var ErrOrgMemberLimit = errors.New("organization has reached its member limit")
+
 type OrganizationRepository struct {
 	db *sql.DB
 }
@@ -259,38 +266,62 @@ func (r *OrganizationRepository) AddMember(ctx context.Context, orgID, userID in
 	return err
 }
 
-// RemoveMember removes a user from an organization
-func (r *OrganizationRepository) RemoveMember(ctx context.Context, orgID, userID int64) error {
-	query := `DELETE FROM organization_members WHERE org_id = $1 AND user_id = $2`
-	result, err := r.db.ExecContext(ctx, query, orgID, userID)
-	if err != nil {
-		return err
-	}
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return sql.ErrNoRows
-	}
-	return nil
+// RemoveMember removes a user from an organization. events are written to
+// the outbox in the same transaction.
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, orgID, userID int64, events ...OutboxMessage) error {
+	return r.withOutbox(ctx, events, func(tx *sql.Tx) error {
+		query := `DELETE FROM organization_members WHERE org_id = $1 AND user_id = $2`
+		result, err := tx.ExecContext(ctx, query, orgID, userID)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
 }
 
-// UpdateMemberRole updates a member's role
-func (r *OrganizationRepository) UpdateMemberRole(ctx context.Context, orgID, userID int64, role string) error {
-	query := `UPDATE organization_members SET org_role = $1 WHERE org_id = $2 AND user_id = $3`
-	result, err := r.db.ExecContext(ctx, query, role, orgID, userID)
+// UpdateMemberRole updates a member's role. events are written to the outbox
+// in the same transaction.
+func (r *OrganizationRepository) UpdateMemberRole(ctx context.Context, orgID, userID int64, role string, events ...OutboxMessage) error {
+	return r.withOutbox(ctx, events, func(tx *sql.Tx) error {
+		query := `UPDATE organization_members SET org_role = $1 WHERE org_id = $2 AND user_id = $3`
+		result, err := tx.ExecContext(ctx, query, role, orgID, userID)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+}
+
+// withOutbox runs fn in a transaction and writes events to the outbox in it,
+// so they are published if and only if fn's changes commit
+func (r *OrganizationRepository) withOutbox(ctx context.Context, events []OutboxMessage, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
-	if err != nil {
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
 		return err
 	}
-	if rows == 0 {
-		return sql.ErrNoRows
+	if err := enqueueOutbox(ctx, tx, events...); err != nil {
+		return err
 	}
-	return nil
+	return tx.Commit()
 }
 
 // ListMembers lists members of an organization with pagination
@@ -430,6 +461,30 @@ func (r *OrganizationRepository) IsMember(ctx context.Context, orgID, userID int
 	return true, role, nil
 }
 
+// MemberRoles returns the org_role of each of userIDs that is a member of
+// the organization
+func (r *OrganizationRepository) MemberRoles(ctx context.Context, orgID int64, userIDs []int64) (map[int64]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT user_id, org_role FROM organization_members
+		WHERE org_id = $1 AND user_id = ANY($2)
+	`, orgID, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make(map[int64]string)
+	for rows.Next() {
+		var userID int64
+		var role string
+		if err := rows.Scan(&userID, &role); err != nil {
+			return nil, err
+		}
+		roles[userID] = role
+	}
+	return roles, rows.Err()
+}
+
 // GetStats returns organization statistics
 func (r *OrganizationRepository) GetStats(ctx context.Context, orgID int64) (*models.OrgStats, error) {
 	stats := &models.OrgStats{OrgID: orgID}
@@ -460,11 +515,100 @@ func (r *OrganizationRepository) GetStats(ctx context.Context, orgID int64) (*mo
 	return stats, nil
 }
 
-// AddMembersBulk adds multiple users to an organization in a single bulk insert query
-func (r *OrganizationRepository) AddMembersBulk(ctx context.Context, orgID int64, userIDs []int64, role string) error {
+// AddMembersBulk adds multiple users to an organization in a single bulk
+// insert, or changes the role of those already in it. It fails with
+// ErrOrgMemberLimit, adding no one, if the new members would exceed
+// settings.max_members. events are written to the outbox in the same
+// transaction.
+func (r *OrganizationRepository) AddMembersBulk(ctx context.Context, orgID int64, userIDs []int64, role string, events ...OutboxMessage) error {
 	if len(userIDs) == 0 {
 		return nil
 	}
+	return r.withOutbox(ctx, events, func(tx *sql.Tx) error {
+		_, err := addMembersTx(ctx, tx, orgID, userIDs, role, false)
+		return err
+	})
+}
+
+// JoinMember adds a user who joins by themselves (invitation, join code,
+// email domain). An existing member keeps their role; joined is false and
+// no events are written. The member limit applies as in AddMembersBulk.
+func (r *OrganizationRepository) JoinMember(ctx context.Context, orgID, userID int64, role string, events ...OutboxMessage) (joined bool, err error) {
+	// Joins the caller's transaction when there is one: an email-domain join
+	// runs inside the user sync that inserted the user
+	tx, commit, rollback, err := beginTx(ctx, r.db)
+	if err != nil {
+		return false, err
+	}
+	defer rollback()
+
+	n, err := addMembersTx(ctx, tx, orgID, []int64{userID}, role, true)
+	if err != nil || n == 0 {
+		return false, err
+	}
+	if err := enqueueOutbox(ctx, tx, events...); err != nil {
+		return false, err
+	}
+	return true, commit()
+}
+
+// FindActiveByEmailDomain returns the active organizations whose
+// settings.allowed_email_domains contains domain
+func (r *OrganizationRepository) FindActiveByEmailDomain(ctx context.Context, domain string) ([]*models.Organization, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, slug, description, logo_url, is_active, settings, created_by, created_at, updated_at
+		FROM organizations
+		WHERE is_active = true AND settings->'allowed_email_domains' ? $1
+		ORDER BY id
+	`, domain)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orgs []*models.Organization
+	for rows.Next() {
+		var org models.Organization
+		if err := rows.Scan(
+			&org.ID, &org.Name, &org.Slug, &org.Description, &org.LogoURL, &org.IsActive,
+			&org.Settings, &org.CreatedBy, &org.CreatedAt, &org.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, &org)
+	}
+	return orgs, rows.Err()
+}
+
+// addMembersTx is AddMembersBulk inside tx and returns how many rows it
+// inserted or updated. keepExisting leaves current members' roles alone.
+// It locks the organization row so concurrent joins cannot overshoot the
+// member limit between the count and the insert.
+func addMembersTx(ctx context.Context, tx *sql.Tx, orgID int64, userIDs []int64, role string, keepExisting bool) (int64, error) {
+	var maxMembers sql.NullInt64
+	err := tx.QueryRowContext(ctx,
+		`SELECT (settings->>'max_members')::int FROM organizations WHERE id = $1 FOR UPDATE`, orgID,
+	).Scan(&maxMembers)
+	if err != nil {
+		return 0, err
+	}
+	if maxMembers.Valid && maxMembers.Int64 > 0 {
+		var current, joining int64
+		err := tx.QueryRowContext(ctx, `
+			SELECT
+				(SELECT COUNT(*) FROM organization_members WHERE org_id = $1),
+				(SELECT COUNT(DISTINCT u.id) FROM unnest($2::bigint[]) AS u(id)
+				 WHERE NOT EXISTS (
+				     SELECT 1 FROM organization_members m WHERE m.org_id = $1 AND m.user_id = u.id
+				 ))
+		`, orgID, pq.Array(userIDs)).Scan(&current, &joining)
+		if err != nil {
+			return 0, err
+		}
+		if joining > 0 && current+joining > maxMembers.Int64 {
+			return 0, ErrOrgMemberLimit
+		}
+	}
 
 	var sb strings.Builder
 	sb.WriteString(`INSERT INTO organization_members (org_id, user_id, org_role) VALUES `)
@@ -481,8 +625,15 @@ func (r *OrganizationRepository) AddMembersBulk(ctx context.Context, orgID int64
 		sb.WriteString(fmt.Sprintf("($1, $%d, $2)", i+3))
 		args = append(args, userID)
 	}
-	sb.WriteString(` ON CONFLICT (org_id, user_id) DO UPDATE SET org_role = EXCLUDED.org_role`)
+	if keepExisting {
+		sb.WriteString(` ON CONFLICT (org_id, user_id) DO NOTHING`)
+	} else {
+		sb.WriteString(` ON CONFLICT (org_id, user_id) DO UPDATE SET org_role = EXCLUDED.org_role`)
+	}
 
-	_, err := r.db.ExecContext(ctx, sb.String(), args...)
-	return err
+	result, err := tx.ExecContext(ctx, sb.String(), args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"example/hello/internal/models"
+	"example/hello/pkg/telemetry"
+)
+
+// outboxRelayLockID is the advisory lock held while relaying so that only
+// one instance publishes at a time, which keeps per-key order intact
+const outboxRelayLockID = 7340021
+
+// OutboxMessage is an event to publish once the surrounding transaction
+// commits. Payload is JSON-encoded when it is written.
+type OutboxMessage struct {
+	Topic   string
+	Key     string
+	Payload interface{}
+}
+
+// OutboxResult is the relay's verdict on one claimed event. A failed event
+// is retried after RetryAfter, or becomes DEAD once attempts are exhausted.
+type OutboxResult struct {
+	ID         int64
+	Published  bool
+	Dead       bool
+	Error      string
+	RetryAfter time.Duration
+}
+
+// OutboxStats counts events per status
+type OutboxStats struct {
+	Pending         int
+	Dead            int
+	Published       int
+	Discarded       int
+	OldestPendingAt sql.NullTime
+}
+
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+const outboxColumns = `
+	id, topic, msg_key, payload, status, attempts, last_error,
+	next_attempt_at, created_at, published_at, trace_context`
+
+// enqueueOutbox records events in tx; they are published by the relay only
+// if tx commits
+func enqueueOutbox(ctx context.Context, tx *sql.Tx, msgs ...OutboxMessage) error {
+	var traceContext []byte
+	headers := map[string]string{}
+	telemetry.InjectHeaders(ctx, headers)
+	if len(headers) > 0 {
+		traceContext, _ = json.Marshal(headers)
+	}
+
+	for _, m := range msgs {
+		payload, err := json.Marshal(m.Payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal outbox payload for %s: %w", m.Topic, err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO event_outbox (topic, msg_key, payload, trace_context) VALUES ($1, $2, $3, $4)`,
+			m.Topic, m.Key, payload, traceContext,
+		); err != nil {
+			return fmt.Errorf("failed to write outbox event for %s: %w", m.Topic, err)
+		}
+	}
+	return nil
+}
+
+// execOneWithOutbox runs a single-row change and writes events to the outbox
+// in the same transaction. Returns sql.ErrNoRows when nothing changed, in
+// which case no event is written.
+func execOneWithOutbox(ctx context.Context, db *sql.DB, events []OutboxMessage, query string, args ...interface{}) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := enqueueOutbox(ctx, tx, events...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Enqueue records events outside of any other change, for producers whose
+// state lives elsewhere
+func (r *OutboxRepository) Enqueue(ctx context.Context, msgs ...OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := enqueueOutbox(ctx, tx, msgs...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RelayBatch claims up to limit due events and hands them to publish in id
+// order. A keyed event is only due when no earlier event with the same topic
+// and key is still waiting for a retry or is DEAD, so a key never overtakes
+// itself; events without a key are unordered. Events publish leaves out of its results are left untouched.
+// Returns the number of events claimed; locked is false when another
+// instance is relaying.
+func (r *OutboxRepository) RelayBatch(ctx context.Context, limit int, publish func([]*models.OutboxEvent) []OutboxResult) (claimed int, locked bool, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxRelayLockID).Scan(&locked); err != nil {
+		return 0, false, err
+	}
+	if !locked {
+		return 0, false, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+outboxColumns+`
+		FROM event_outbox o
+		WHERE o.status = 'PENDING' AND o.next_attempt_at <= CURRENT_TIMESTAMP
+		  AND NOT EXISTS (
+			SELECT 1 FROM event_outbox p
+			WHERE o.msg_key <> '' AND p.topic = o.topic AND p.msg_key = o.msg_key AND p.id < o.id
+			  AND (p.status = 'DEAD' OR (p.status = 'PENDING' AND p.next_attempt_at > CURRENT_TIMESTAMP))
+		  )
+		ORDER BY o.id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return 0, true, err
+	}
+	events, err := scanOutboxEvents(rows)
+	if err != nil {
+		return 0, true, err
+	}
+	if len(events) == 0 {
+		return 0, true, nil
+	}
+
+	for _, res := range publish(events) {
+		switch {
+		case res.Published:
+			_, err = tx.ExecContext(ctx, `
+				UPDATE event_outbox
+				SET status = 'PUBLISHED', attempts = attempts + 1, published_at = CURRENT_TIMESTAMP
+				WHERE id = $1
+			`, res.ID)
+		case res.Dead:
+			_, err = tx.ExecContext(ctx, `
+				UPDATE event_outbox SET status = 'DEAD', attempts = attempts + 1, last_error = $2 WHERE id = $1
+			`, res.ID, res.Error)
+		default:
+			_, err = tx.ExecContext(ctx, `
+				UPDATE event_outbox
+				SET attempts = attempts + 1, last_error = $2,
+				    next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $3)
+				WHERE id = $1
+			`, res.ID, res.Error, res.RetryAfter.Seconds())
+		}
+		if err != nil {
+			return 0, true, err
+		}
+	}
+
+	return len(events), true, tx.Commit()
+}
+
+// List returns outbox events, newest first, optionally filtered by status
+// and topic
+func (r *OutboxRepository) List(ctx context.Context, status, topic string, limit, offset int) ([]*models.OutboxEvent, int, error) {
+	where := `WHERE ($1 = '' OR status = $1) AND ($2 = '' OR topic = $2)`
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM event_outbox `+where, status, topic).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+outboxColumns+` FROM event_outbox `+where+`
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4
+	`, status, topic, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	events, err := scanOutboxEvents(rows)
+	return events, total, err
+}
+
+// GetByID retrieves an outbox event
+func (r *OutboxRepository) GetByID(ctx context.Context, id int64) (*models.OutboxEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+outboxColumns+` FROM event_outbox WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	events, err := scanOutboxEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return events[0], nil
+}
+
+// GetStats counts events per status and finds the oldest pending one
+func (r *OutboxRepository) GetStats(ctx context.Context) (*OutboxStats, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM event_outbox GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := &OutboxStats{}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		switch status {
+		case models.OutboxStatusPending:
+			stats.Pending = count
+		case models.OutboxStatusDead:
+			stats.Dead = count
+		case models.OutboxStatusPublished:
+			stats.Published = count
+		case models.OutboxStatusDiscarded:
+			stats.Discarded = count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT MIN(created_at) FROM event_outbox WHERE status = 'PENDING'`,
+	).Scan(&stats.OldestPendingAt); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Replay makes a DEAD, DISCARDED or backed-off PENDING event due now with a
+// fresh attempt budget. Returns false if the event does not exist or was
+// already published.
+func (r *OutboxRepository) Replay(ctx context.Context, id int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE event_outbox
+		SET status = 'PENDING', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status <> 'PUBLISHED'
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ReplayDead makes every DEAD event (of a topic, if given) due now
+func (r *OutboxRepository) ReplayDead(ctx context.Context, topic string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE event_outbox
+		SET status = 'PENDING', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP
+		WHERE status = 'DEAD' AND ($1 = '' OR topic = $1)
+	`, topic)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Discard gives up on an unpublished event so later events for its key can
+// proceed. Returns false if the event does not exist or was already published.
+func (r *OutboxRepository) Discard(ctx context.Context, id int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE event_outbox SET status = 'DISCARDED'
+		WHERE id = $1 AND status IN ('PENDING', 'DEAD')
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// PrunePublished deletes events published longer than retention ago
+func (r *OutboxRepository) PrunePublished(ctx context.Context, retention time.Duration) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM event_outbox
+		WHERE status = 'PUBLISHED' AND published_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
+	`, retention.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanOutboxEvents(rows *sql.Rows) ([]*models.OutboxEvent, error) {
+	defer rows.Close()
+
+	var events []*models.OutboxEvent
+	for rows.Next() {
+		e := &models.OutboxEvent{}
+		var payload, traceContext []byte
+		if err := rows.Scan(
+			&e.ID, &e.Topic, &e.Key, &payload, &e.Status, &e.Attempts, &e.LastError,
+			&e.NextAttemptAt, &e.CreatedAt, &e.PublishedAt, &traceContext,
+		); err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		if len(traceContext) > 0 {
+			// A trace link is best effort; a bad value just starts a new trace
+			_ = json.Unmarshal(traceContext, &e.TraceContext)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
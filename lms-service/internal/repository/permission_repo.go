@@ -85,7 +85,7 @@ func (r *PermissionRepository) FindCodesByRoleName(ctx context.Context, roleName
 		 FROM permissions p
 		 JOIN role_permissions rp ON rp.permission_id = p.id
 		 JOIN role_definitions rd ON rd.id = rp.role_id
-		 WHERE rd.name = $1`, roleName)
+		 WHERE rd.name = $1 AND rd.scope = 'GLOBAL'`, roleName)
 	if err != nil {
 		return nil, fmt.Errorf("FindCodesByRoleName: %w", err)
 	}
@@ -102,6 +102,36 @@ func (r *PermissionRepository) FindCodesByRoleName(ctx context.Context, roleName
 	return codes, rows.Err()
 }
 
+// FindCodesByOrgRole returns the permission codes an org_role grants in an
+// organization: the organization's own definition of the role if it has
+// one, otherwise the template.
+func (r *PermissionRepository) FindCodesByOrgRole(ctx context.Context, orgID int64, roleName string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT p.code
+		 FROM permissions p
+		 JOIN role_permissions rp ON rp.permission_id = p.id
+		 WHERE rp.role_id = (
+		     SELECT id FROM role_definitions
+		     WHERE scope = 'ORG' AND name = $2 AND (org_id = $1 OR org_id IS NULL)
+		     ORDER BY org_id NULLS LAST
+		     LIMIT 1
+		 )`, orgID, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("FindCodesByOrgRole: %w", err)
+	}
+	defer rows.Close()
+
+	var codes []string
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, rows.Err()
+}
+
 // AssignToRole replaces all permissions for a role in a single transaction.
 func (r *PermissionRepository) AssignToRole(ctx context.Context, roleID int64, permissionIDs []int64) error {
 	tx, err := r.db.BeginTx(ctx, nil)
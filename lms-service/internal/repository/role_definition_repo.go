@@ -17,9 +17,51 @@ func NewRoleDefinitionRepository(db *sql.DB) *RoleDefinitionRepository {
 	return &RoleDefinitionRepository{db: db}
 }
 
+const roleDefinitionColumns = `id, name, display_name, description, is_system, scope, org_id, created_at`
+
+// FindAll returns the global roles; organization roles are listed per
+// organization by FindOrgRoles
 func (r *RoleDefinitionRepository) FindAll(ctx context.Context) ([]models.RoleDefinition, error) {
-	query := `SELECT id, name, display_name, description, is_system, created_at FROM role_definitions ORDER BY id`
-	rows, err := r.db.QueryContext(ctx, query)
+	query := `SELECT ` + roleDefinitionColumns + ` FROM role_definitions WHERE scope = 'GLOBAL' ORDER BY id`
+	return r.queryRoles(ctx, query)
+}
+
+// FindOrgRoles returns the template org roles followed by the organization's own
+func (r *RoleDefinitionRepository) FindOrgRoles(ctx context.Context, orgID int64) ([]models.RoleDefinition, error) {
+	query := `
+		SELECT ` + roleDefinitionColumns + ` FROM role_definitions
+		WHERE scope = 'ORG' AND (org_id IS NULL OR org_id = $1)
+		ORDER BY org_id NULLS FIRST, id
+	`
+	return r.queryRoles(ctx, query, orgID)
+}
+
+func (r *RoleDefinitionRepository) FindByID(ctx context.Context, id int64) (*models.RoleDefinition, error) {
+	query := `SELECT ` + roleDefinitionColumns + ` FROM role_definitions WHERE id = $1`
+	roles, err := r.queryRoles(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &roles[0], nil
+}
+
+// OrgRoleExists reports whether name is a template org role or one of the
+// organization's own
+func (r *RoleDefinitionRepository) OrgRoleExists(ctx context.Context, orgID int64, name string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM role_definitions
+			WHERE scope = 'ORG' AND name = $2 AND (org_id IS NULL OR org_id = $1)
+		)`, orgID, name).Scan(&exists)
+	return exists, err
+}
+
+func (r *RoleDefinitionRepository) queryRoles(ctx context.Context, query string, args ...interface{}) ([]models.RoleDefinition, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -29,12 +71,16 @@ func (r *RoleDefinitionRepository) FindAll(ctx context.Context) ([]models.RoleDe
 	for rows.Next() {
 		var role models.RoleDefinition
 		var desc sql.NullString
-		if err := rows.Scan(&role.ID, &role.Name, &role.DisplayName, &desc, &role.IsSystem, &role.CreatedAt); err != nil {
+		var orgID sql.NullInt64
+		if err := rows.Scan(&role.ID, &role.Name, &role.DisplayName, &desc, &role.IsSystem, &role.Scope, &orgID, &role.CreatedAt); err != nil {
 			return nil, err
 		}
 		if desc.Valid {
 			role.Description = desc.String
 		}
+		if orgID.Valid {
+			role.OrgID = &orgID.Int64
+		}
 		roles = append(roles, role)
 	}
 	return roles, rows.Err()
@@ -47,32 +93,103 @@ func (r *RoleDefinitionRepository) Create(ctx context.Context, role *models.Role
 		RETURNING id, created_at
 	`
 	name := strings.ToUpper(role.Name)
+	role.Scope = models.RoleScopeGlobal
 	return r.db.QueryRowContext(ctx, query, name, role.DisplayName, role.Description).
 		Scan(&role.ID, &role.CreatedAt)
 }
 
-func (r *RoleDefinitionRepository) Update(ctx context.Context, id int64, displayName, description string) error {
-	query := `UPDATE role_definitions SET display_name = $1, description = $2 WHERE id = $3`
-	_, err := r.db.ExecContext(ctx, query, displayName, description, id)
+// CreateOrgRole adds a role owned by one organization
+func (r *RoleDefinitionRepository) CreateOrgRole(ctx context.Context, orgID int64, role *models.RoleDefinition) error {
+	query := `
+		INSERT INTO role_definitions (name, display_name, description, is_system, scope, org_id)
+		VALUES ($1, $2, $3, false, 'ORG', $4)
+		RETURNING id, created_at
+	`
+	role.Name = strings.ToUpper(role.Name)
+	role.Scope = models.RoleScopeOrg
+	role.OrgID = &orgID
+	return r.db.QueryRowContext(ctx, query, role.Name, role.DisplayName, role.Description, orgID).
+		Scan(&role.ID, &role.CreatedAt)
+}
+
+// UpdateOrgRole edits one of the organization's own roles; templates and
+// other organizations' roles report sql.ErrNoRows
+func (r *RoleDefinitionRepository) UpdateOrgRole(ctx context.Context, orgID, id int64, displayName, description string) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE role_definitions SET display_name = $1, description = $2 WHERE id = $3 AND org_id = $4`,
+		displayName, description, id, orgID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteOrgRole removes one of the organization's own roles. A role still
+// held by members is kept, since they would silently lose its permissions.
+func (r *RoleDefinitionRepository) DeleteOrgRole(ctx context.Context, orgID, id int64) error {
+	var name string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT name FROM role_definitions WHERE id = $1 AND org_id = $2`, id, orgID).Scan(&name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("role not found")
+		}
+		return err
+	}
+
+	var inUse bool
+	err = r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM organization_members WHERE org_id = $1 AND org_role = $2)`,
+		orgID, name).Scan(&inUse)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return fmt.Errorf("role %s is still assigned to members", name)
+	}
+
+	_, err = r.db.ExecContext(ctx, `DELETE FROM role_definitions WHERE id = $1 AND org_id = $2`, id, orgID)
 	return err
 }
 
+// Update edits a global role; organization roles, which UpdateOrgRole
+// edits, report sql.ErrNoRows
+func (r *RoleDefinitionRepository) Update(ctx context.Context, id int64, displayName, description string) error {
+	query := `UPDATE role_definitions SET display_name = $1, description = $2 WHERE id = $3 AND scope = 'GLOBAL'`
+	res, err := r.db.ExecContext(ctx, query, displayName, description, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Delete removes a global role other than the system ones; organization
+// roles report sql.ErrNoRows
 func (r *RoleDefinitionRepository) Delete(ctx context.Context, id int64) error {
 	// First check if it's a system role
 	var isSystem bool
-	err := r.db.QueryRowContext(ctx, `SELECT is_system FROM role_definitions WHERE id = $1`, id).Scan(&isSystem)
+	err := r.db.QueryRowContext(ctx, `SELECT is_system FROM role_definitions WHERE id = $1 AND scope = 'GLOBAL'`, id).Scan(&isSystem)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("role not found")
-		}
 		return err
 	}
 	if isSystem {
 		return fmt.Errorf("cannot delete system role")
 	}
 
-	_, err = r.db.ExecContext(ctx, `DELETE FROM role_definitions WHERE id = $1`, id)
-	return err
+	res, err := r.db.ExecContext(ctx, `DELETE FROM role_definitions WHERE id = $1 AND scope = 'GLOBAL'`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
 
 func (r *RoleDefinitionRepository) GetUserRoleDetails(ctx context.Context, userID int64) ([]models.UserRoleDetail, error) {
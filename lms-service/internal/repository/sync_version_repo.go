@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Entity types tracked in sync_versions
+const (
+	SyncEntityUser   = "user"
+	SyncEntityOrg    = "org"
+	SyncEntityMember = "member"
+)
+
+// SyncVersionRepository records the newest auth-service version applied to
+// each synced entity
+type SyncVersionRepository struct {
+	db *sql.DB
+}
+
+func NewSyncVersionRepository(db *sql.DB) *SyncVersionRepository {
+	return &SyncVersionRepository{db: db}
+}
+
+// Claim records version for the entity and reports whether the change
+// should be applied. It returns false when a newer version has already been
+// applied; the same version is claimed again so a retried event re-applies.
+func (r *SyncVersionRepository) Claim(ctx context.Context, entityType, entityID string, version int64, deleted bool) (bool, error) {
+	var claimed int64
+	err := Conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO sync_versions (entity_type, entity_id, version, deleted)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (entity_type, entity_id) DO UPDATE SET
+			version = EXCLUDED.version,
+			deleted = EXCLUDED.deleted,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE sync_versions.version <= EXCLUDED.version
+		RETURNING version
+	`, entityType, entityID, version, deleted).Scan(&claimed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListStale returns the IDs of live (not deleted) entities whose version
+// was last recorded before the last window. Reconciliation prunes the ones
+// missing from the snapshot; anything touched while the snapshot was being
+// paged is left alone.
+func (r *SyncVersionRepository) ListStale(ctx context.Context, entityType string, window time.Duration) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT entity_id FROM sync_versions
+		WHERE entity_type = $1 AND NOT deleted
+		  AND updated_at < CURRENT_TIMESTAMP - make_interval(secs => $2)
+	`, entityType, window.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// MarkDeleted tombstones an entity without changing its version
+func (r *SyncVersionRepository) MarkDeleted(ctx context.Context, entityType, entityID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE sync_versions SET deleted = TRUE, updated_at = CURRENT_TIMESTAMP
+		WHERE entity_type = $1 AND entity_id = $2
+	`, entityType, entityID)
+	return err
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX is the query surface shared by *sql.DB and *sql.Tx
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// WithTx returns a context whose repository calls run inside tx. Only
+// repositories that resolve their connection through Conn join it.
+func WithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// Conn returns the transaction carried by ctx, or db when there is none
+func Conn(ctx context.Context, db *sql.DB) DBTX {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// beginTx starts a transaction, or joins the one carried by ctx. A joined
+// transaction belongs to whoever started it, so commit and rollback are
+// no-ops for it; starting a second one would block on rows the first has
+// written but not committed.
+func beginTx(ctx context.Context, db *sql.DB) (tx *sql.Tx, commit, rollback func() error, err error) {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		noop := func() error { return nil }
+		return tx, noop, noop, nil
+	}
+	tx, err = db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return tx, tx.Commit, tx.Rollback, nil
+}
@@ -21,6 +21,11 @@ func (r *UserRepository) GetDB() *sql.DB {
 	return r.db
 }
 
+// Conn returns the transaction carried by ctx (see WithTx), or the pool
+func (r *UserRepository) Conn(ctx context.Context) DBTX {
+	return Conn(ctx, r.db)
+}
+
 // GetOrCreateUser gets user by ID or creates if not exists
 func (r *UserRepository) GetOrCreateUser(ctx context.Context, userID int64, email, fullName, organization string) (*models.User, error) {
 	// Try to get user first
@@ -42,7 +47,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, e
 	query := `SELECT id, email, full_name, COALESCE(profile_picture, ''), COALESCE(organization, ''), created_at, updated_at FROM users WHERE id = $1`
 
 	var user models.User
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := Conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&user.ID,
 		&user.Email,
 		&user.FullName,
@@ -68,7 +73,7 @@ func (r *UserRepository) Create(ctx context.Context, id int64, email, fullName,
 	`
 
 	var user models.User
-	err := r.db.QueryRowContext(ctx, query, id, email, fullName, organization).Scan(
+	err := Conn(ctx, r.db).QueryRowContext(ctx, query, id, email, fullName, organization).Scan(
 		&user.ID,
 		&user.Email,
 		&user.FullName,
@@ -87,7 +92,7 @@ func (r *UserRepository) Create(ctx context.Context, id int64, email, fullName,
 
 // UpdateProfilePicture keeps the LMS's local projection current for joined course responses.
 func (r *UserRepository) UpdateProfilePicture(ctx context.Context, userID int64, profilePicture string) error {
-	_, err := r.db.ExecContext(ctx, `UPDATE users SET profile_picture = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, profilePicture, userID)
+	_, err := Conn(ctx, r.db).ExecContext(ctx, `UPDATE users SET profile_picture = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, profilePicture, userID)
 	return err
 }
 
@@ -95,7 +100,7 @@ func (r *UserRepository) UpdateProfilePicture(ctx context.Context, userID int64,
 func (r *UserRepository) UpdateFullName(ctx context.Context, userID int64, fullName string) error {
 	query := `UPDATE users SET full_name = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
 
-	result, err := r.db.ExecContext(ctx, query, fullName, userID)
+	result, err := Conn(ctx, r.db).ExecContext(ctx, query, fullName, userID)
 	if err != nil {
 		return err
 	}
@@ -116,7 +121,7 @@ func (r *UserRepository) UpdateFullName(ctx context.Context, userID int64, fullN
 func (r *UserRepository) GetUserRoles(ctx context.Context, userID int64) ([]string, error) {
 	query := `SELECT role FROM user_roles WHERE user_id = $1 ORDER BY role`
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := Conn(ctx, r.db).QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -148,7 +153,7 @@ func (r *UserRepository) AddRole(ctx context.Context, userID int64, role string)
 		ON CONFLICT (user_id, role) DO NOTHING
 	`
 
-	_, err := r.db.ExecContext(ctx, query, userID, role)
+	_, err := Conn(ctx, r.db).ExecContext(ctx, query, userID, role)
 	return err
 }
 
@@ -156,7 +161,7 @@ func (r *UserRepository) AddRole(ctx context.Context, userID int64, role string)
 func (r *UserRepository) RemoveRole(ctx context.Context, userID int64, role string) error {
 	query := `DELETE FROM user_roles WHERE user_id = $1 AND role = $2`
 
-	result, err := r.db.ExecContext(ctx, query, userID, role)
+	result, err := Conn(ctx, r.db).ExecContext(ctx, query, userID, role)
 	if err != nil {
 		return err
 	}
@@ -178,7 +183,7 @@ func (r *UserRepository) HasRole(ctx context.Context, userID int64, role string)
 	query := `SELECT EXISTS(SELECT 1 FROM user_roles WHERE user_id = $1 AND role = $2)`
 
 	var exists bool
-	err := r.db.QueryRowContext(ctx, query, userID, role).Scan(&exists)
+	err := Conn(ctx, r.db).QueryRowContext(ctx, query, userID, role).Scan(&exists)
 	if err != nil {
 		return false, err
 	}
@@ -189,14 +194,14 @@ func (r *UserRepository) HasRole(ctx context.Context, userID int64, role string)
 // ClearUserRoles removes all roles from a user
 func (r *UserRepository) ClearUserRoles(ctx context.Context, userID int64) error {
 	query := `DELETE FROM user_roles WHERE user_id = $1`
-	_, err := r.db.ExecContext(ctx, query, userID)
+	_, err := Conn(ctx, r.db).ExecContext(ctx, query, userID)
 	return err
 }
 
 // ClearSyncedRoles removes only roles with source='sync', preserving manual overrides.
 func (r *UserRepository) ClearSyncedRoles(ctx context.Context, userID int64) error {
 	query := `DELETE FROM user_roles WHERE user_id = $1 AND source = 'sync'`
-	_, err := r.db.ExecContext(ctx, query, userID)
+	_, err := Conn(ctx, r.db).ExecContext(ctx, query, userID)
 	return err
 }
 
@@ -207,7 +212,7 @@ func (r *UserRepository) AddRoleWithSource(ctx context.Context, userID int64, ro
 		VALUES ($1, $2, $3)
 		ON CONFLICT (user_id, role) DO NOTHING
 	`
-	_, err := r.db.ExecContext(ctx, query, userID, role, source)
+	_, err := Conn(ctx, r.db).ExecContext(ctx, query, userID, role, source)
 	return err
 }
 
@@ -219,7 +224,7 @@ func (r *UserRepository) GetByEmails(ctx context.Context, emails []string) ([]*m
 
 	query := `SELECT id, email, full_name, COALESCE(profile_picture, ''), COALESCE(organization, ''), created_at, updated_at FROM users WHERE email = ANY($1)`
 
-	rows, err := r.db.QueryContext(ctx, query, pq.Array(emails))
+	rows, err := Conn(ctx, r.db).QueryContext(ctx, query, pq.Array(emails))
 	if err != nil {
 		return nil, err
 	}
@@ -254,7 +259,7 @@ func (r *UserRepository) GetByEmails(ctx context.Context, emails []string) ([]*m
 func (r *UserRepository) UpdateOrganization(ctx context.Context, userID int64, organization string) error {
 	query := `UPDATE users SET organization = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
 
-	result, err := r.db.ExecContext(ctx, query, organization, userID)
+	result, err := Conn(ctx, r.db).ExecContext(ctx, query, organization, userID)
 	if err != nil {
 		return err
 	}
@@ -279,7 +284,7 @@ func (r *UserRepository) AssociateUserWithOrganization(ctx context.Context, user
 	// 1. Find organization id by name or slug
 	var orgID int64
 	queryFind := `SELECT id FROM organizations WHERE name = $1 OR slug = $2`
-	err := r.db.QueryRowContext(ctx, queryFind, orgNameOrSlug, orgNameOrSlug).Scan(&orgID)
+	err := Conn(ctx, r.db).QueryRowContext(ctx, queryFind, orgNameOrSlug, orgNameOrSlug).Scan(&orgID)
 	if err == sql.ErrNoRows {
 		// If org doesn't exist, we don't do anything (silent ignore)
 		return nil
@@ -293,7 +298,7 @@ func (r *UserRepository) AssociateUserWithOrganization(ctx context.Context, user
 		VALUES ($1, $2, 'MEMBER')
 		ON CONFLICT (org_id, user_id) DO NOTHING
 	`
-	_, err = r.db.ExecContext(ctx, queryInsert, orgID, userID)
+	_, err = Conn(ctx, r.db).ExecContext(ctx, queryInsert, orgID, userID)
 	return err
 }
 
@@ -308,7 +313,7 @@ func (r *UserRepository) SearchTeachers(ctx context.Context, queryStr string) ([
 		ORDER BY u.full_name ASC
 		LIMIT 10
 	`
-	rows, err := r.db.QueryContext(ctx, query, "%"+queryStr+"%")
+	rows, err := Conn(ctx, r.db).QueryContext(ctx, query, "%"+queryStr+"%")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/models"
+	"example/hello/internal/repository"
+	"example/hello/pkg/logger"
+)
+
+// MaxAuditExportRows bounds a single CSV export; narrow the date range to
+// export more
+const MaxAuditExportRows = 100000
+
+// AuditActor is who made a change and from which request.
+// middleware.AuditContext puts it on the request context after
+// authentication; work without one is recorded as a system action.
+type AuditActor struct {
+	UserID    int64
+	Role      string
+	Service   string // verified internal caller, if any
+	RequestID string
+	IP        string
+	UserAgent string
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor returns ctx carrying actor
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// AuditActorFrom returns the actor on ctx, or the zero actor (system)
+func AuditActorFrom(ctx context.Context) AuditActor {
+	actor, _ := ctx.Value(auditActorKey{}).(AuditActor)
+	return actor
+}
+
+// AuditRecord describes one audited change. TargetID is 0 for an action on
+// a batch of targets. Before and After are marshalled to JSON; leave them
+// nil when there is no prior or resulting state.
+type AuditRecord struct {
+	Action     string
+	TargetType string
+	TargetID   int64
+	OrgID      int64
+	CourseID   int64
+	Reason     string
+	Before     interface{}
+	After      interface{}
+}
+
+// AuditService writes and queries the append-only audit log
+type AuditService struct {
+	repo *repository.AuditRepository
+}
+
+func NewAuditService(repo *repository.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Record appends rec, attributed to the actor on ctx. It is called after the
+// change has been made; a failure to write is logged rather than undoing a
+// change the caller has already seen succeed. A nil service records nothing.
+func (s *AuditService) Record(ctx context.Context, rec AuditRecord) {
+	if s == nil {
+		return
+	}
+	actor := AuditActorFrom(ctx)
+	entry := &models.AuditEntry{
+		ActorRole:    actor.Role,
+		ActorService: actor.Service,
+		Action:       rec.Action,
+		TargetType:   rec.TargetType,
+		OrgID:        sql.NullInt64{Int64: rec.OrgID, Valid: rec.OrgID > 0},
+		CourseID:     sql.NullInt64{Int64: rec.CourseID, Valid: rec.CourseID > 0},
+		Reason:       toNullString(rec.Reason),
+		RequestID:    actor.RequestID,
+		IPAddress:    actor.IP,
+		UserAgent:    actor.UserAgent,
+	}
+	if rec.TargetID > 0 {
+		entry.TargetID = strconv.FormatInt(rec.TargetID, 10)
+	}
+	if actor.UserID > 0 {
+		entry.ActorID = sql.NullInt64{Int64: actor.UserID, Valid: true}
+	}
+	var err error
+	if entry.Before, err = marshalAuditState(rec.Before); err != nil {
+		logger.Error("Failed to encode audit state for "+rec.Action, err)
+	}
+	if entry.After, err = marshalAuditState(rec.After); err != nil {
+		logger.Error("Failed to encode audit state for "+rec.Action, err)
+	}
+
+	// The request may be cancelled as soon as the response is written
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.repo.Insert(writeCtx, entry); err != nil {
+		logger.Error(fmt.Sprintf("Failed to write audit entry %s %s/%s by user %d",
+			rec.Action, rec.TargetType, entry.TargetID, actor.UserID), err)
+	}
+}
+
+func marshalAuditState(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// List returns audit entries, newest first
+func (s *AuditService) List(ctx context.Context, req *dto.ListAuditLogRequest) (*dto.ListResponse, error) {
+	page, limit := req.Page, req.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 50
+	}
+
+	entries, total, err := s.repo.List(ctx, auditFilter(req), limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*dto.AuditEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, auditEntryToResponse(e))
+	}
+	return dto.NewListResponse(items, page, limit, total), nil
+}
+
+var auditCSVHeader = []string{
+	"id", "occurred_at", "actor_id", "actor_role", "actor_service", "action", "target_type", "target_id",
+	"org_id", "course_id", "reason", "before", "after", "request_id", "ip_address", "user_agent",
+}
+
+// ExportCSV writes matching entries to w as CSV, oldest first, at most
+// MaxAuditExportRows of them
+func (s *AuditService) ExportCSV(ctx context.Context, req *dto.ListAuditLogRequest, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(auditCSVHeader); err != nil {
+		return err
+	}
+	err := s.repo.Each(ctx, auditFilter(req), MaxAuditExportRows, func(e *models.AuditEntry) error {
+		return cw.Write([]string{
+			strconv.FormatInt(e.ID, 10),
+			e.OccurredAt.UTC().Format(time.RFC3339),
+			nullInt64String(e.ActorID),
+			csvCell(e.ActorRole),
+			csvCell(e.ActorService),
+			csvCell(e.Action),
+			csvCell(e.TargetType),
+			csvCell(e.TargetID),
+			nullInt64String(e.OrgID),
+			nullInt64String(e.CourseID),
+			csvCell(e.Reason.String),
+			string(e.Before),
+			string(e.After),
+			csvCell(e.RequestID),
+			csvCell(e.IPAddress),
+			csvCell(e.UserAgent),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func auditFilter(req *dto.ListAuditLogRequest) repository.AuditFilter {
+	return repository.AuditFilter{
+		ActorID:    req.ActorID,
+		Action:     req.Action,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		OrgID:      req.OrgID,
+		CourseID:   req.CourseID,
+		RequestID:  req.RequestID,
+		From:       req.From,
+		To:         req.To,
+	}
+}
+
+// csvCell neutralises values a spreadsheet would evaluate as a formula;
+// reasons and user agents are user-controlled
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func nullInt64String(v sql.NullInt64) string {
+	if !v.Valid {
+		return ""
+	}
+	return strconv.FormatInt(v.Int64, 10)
+}
+
+func auditEntryToResponse(e *models.AuditEntry) *dto.AuditEntryResponse {
+	resp := &dto.AuditEntryResponse{
+		ID:           e.ID,
+		OccurredAt:   e.OccurredAt,
+		ActorRole:    e.ActorRole,
+		ActorService: e.ActorService,
+		Action:       e.Action,
+		TargetType:   e.TargetType,
+		TargetID:     e.TargetID,
+		Before:       e.Before,
+		After:        e.After,
+		RequestID:    e.RequestID,
+		IPAddress:    e.IPAddress,
+		UserAgent:    e.UserAgent,
+	}
+	if e.ActorID.Valid {
+		resp.ActorID = &e.ActorID.Int64
+	}
+	if e.OrgID.Valid {
+		resp.OrgID = &e.OrgID.Int64
+	}
+	if e.CourseID.Valid {
+		resp.CourseID = &e.CourseID.Int64
+	}
+	if e.Reason.Valid {
+		resp.Reason = &e.Reason.String
+	}
+	return resp
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/models"
+)
+
+func TestAuditActorRoundTrip(t *testing.T) {
+	if actor := AuditActorFrom(context.Background()); actor != (AuditActor{}) {
+		t.Errorf("actor without middleware = %+v; want system (zero) actor", actor)
+	}
+
+	want := AuditActor{UserID: 7, Role: "ADMIN", RequestID: "req-1", IP: "10.0.0.1"}
+	if got := AuditActorFrom(WithAuditActor(context.Background(), want)); got != want {
+		t.Errorf("actor = %+v; want %+v", got, want)
+	}
+
+	// A nil service must be safe for services built without auditing
+	var s *AuditService
+	s.Record(context.Background(), AuditRecord{Action: "test"})
+}
+
+func TestCSVCellNeutralisesFormulas(t *testing.T) {
+	cases := map[string]string{
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+1":                "'+1",
+		"@SUM(A1)":          "'@SUM(A1)",
+		"Mozilla/5.0":       "Mozilla/5.0",
+		"":                  "",
+	}
+	for in, want := range cases {
+		if got := csvCell(in); got != want {
+			t.Errorf("csvCell(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
+
+func TestAnswerGradeState(t *testing.T) {
+	answer := &models.QuizStudentAnswer{AttemptID: 4, QuestionID: 9}
+	if got, want := answerGradeState(answer), map[string]interface{}{"attempt_id": int64(4), "question_id": int64(9)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ungraded state = %v; want %v", got, want)
+	}
+
+	answer.PointsEarned = sql.NullFloat64{Float64: 2.5, Valid: true}
+	answer.IsCorrect = sql.NullBool{Bool: false, Valid: true}
+	answer.GraderFeedback = sql.NullString{String: "show your work", Valid: true}
+	answer.GradedBy = sql.NullInt64{Int64: 7, Valid: true}
+	want := map[string]interface{}{
+		"attempt_id":      int64(4),
+		"question_id":     int64(9),
+		"points_earned":   2.5,
+		"is_correct":      false,
+		"grader_feedback": "show your work",
+		"graded_by":       int64(7),
+	}
+	if got := answerGradeState(answer); !reflect.DeepEqual(got, want) {
+		t.Errorf("graded state = %v; want %v", got, want)
+	}
+}
+
+func TestBulkGradeAuditCourse(t *testing.T) {
+	response := &dto.BulkGradeResponse{
+		Succeeded: []int64{1, 2},
+		Failed:    []dto.GradeError{{AnswerID: 3, Error: "answer not found"}},
+	}
+
+	record := bulkGradeAudit(3, response, []int64{5, 5})
+	if record.Action != models.AuditAnswerBulkGrade || record.CourseID != 5 {
+		t.Errorf("one course = %+v; want bulk grade filed under course 5", record)
+	}
+	after := record.After.(map[string]interface{})
+	if after["requested"] != 3 || !reflect.DeepEqual(after["succeeded"], response.Succeeded) || after["course_ids"] != nil {
+		t.Errorf("after = %v", after)
+	}
+
+	record = bulkGradeAudit(2, response, []int64{5, 6})
+	if record.CourseID != 0 || !reflect.DeepEqual(record.After.(map[string]interface{})["course_ids"], []int64{5, 6}) {
+		t.Errorf("two courses = %+v; want no course and both listed", record)
+	}
+
+	if record = bulkGradeAudit(1, response, nil); record.CourseID != 0 {
+		t.Errorf("nothing graded = %+v; want no course", record)
+	}
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/models"
+	"example/hello/internal/repository"
+	"example/hello/pkg/kafka"
+)
+
+// Course groups split a course's accepted students into named groups.
+// Course staff manage them; every change is announced on
+// lms.course.membership so chat-service keeps a channel per group.
+
+// CreateGroup creates a group in a course with any initial members
+func (s *CourseService) CreateGroup(ctx context.Context, courseID, userID int64, role string, req *dto.CreateCourseGroupRequest) (*dto.CourseGroupResponse, error) {
+	if _, err := s.checkCanManageGroups(ctx, courseID, userID, role); err != nil {
+		return nil, err
+	}
+
+	group := &models.CourseGroup{CourseID: courseID, Name: strings.TrimSpace(req.Name), CreatedBy: userID}
+	err := s.groupRepo.Create(ctx, group, func(g *models.CourseGroup) []repository.OutboxMessage {
+		// A new group has no members yet; they are announced as they are added
+		return []repository.OutboxMessage{courseMembershipEvent(kafka.CourseMembershipEvent{
+			Type:      kafka.CourseGroupSynced,
+			CourseID:  g.CourseID,
+			GroupID:   g.ID,
+			GroupName: g.Name,
+		})}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.MemberIDs) > 0 {
+		if _, err := s.groupRepo.AddMembers(ctx, group.ID, userID, req.MemberIDs, func(added []int64) []repository.OutboxMessage {
+			return []repository.OutboxMessage{groupMemberChangeEvent(courseID, group.ID, kafka.CourseMemberAdded, added...)}
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return s.getGroupResponse(ctx, group.ID)
+}
+
+// ListGroups lists a course's groups
+func (s *CourseService) ListGroups(ctx context.Context, courseID, userID int64, role string) ([]*dto.CourseGroupResponse, error) {
+	if _, err := s.checkCanManageGroups(ctx, courseID, userID, role); err != nil {
+		return nil, err
+	}
+	groups, err := s.groupRepo.ListByCourse(ctx, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list course groups: %w", err)
+	}
+	resp := make([]*dto.CourseGroupResponse, len(groups))
+	for i, g := range groups {
+		resp[i] = toCourseGroupResponse(g)
+	}
+	return resp, nil
+}
+
+// RenameGroup renames a group
+func (s *CourseService) RenameGroup(ctx context.Context, courseID, groupID, userID int64, role string, req *dto.UpdateCourseGroupRequest) (*dto.CourseGroupResponse, error) {
+	group, err := s.getManageableGroup(ctx, courseID, groupID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	group.Name = strings.TrimSpace(req.Name)
+	err = s.groupRepo.Rename(ctx, groupID, group.Name, func(ctx context.Context) ([]repository.OutboxMessage, error) {
+		synced, err := groupSyncedEvent(ctx, s.groupRepo, group)
+		if err != nil {
+			return nil, err
+		}
+		return []repository.OutboxMessage{synced}, nil
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("group not found")
+		}
+		return nil, err
+	}
+	return s.getGroupResponse(ctx, groupID)
+}
+
+// DeleteGroup deletes a group and its memberships
+func (s *CourseService) DeleteGroup(ctx context.Context, courseID, groupID, userID int64, role string) error {
+	if _, err := s.getManageableGroup(ctx, courseID, groupID, userID, role); err != nil {
+		return err
+	}
+	err := s.groupRepo.Delete(ctx, groupID, courseMembershipEvent(kafka.CourseMembershipEvent{
+		Type:     kafka.CourseGroupRemoved,
+		CourseID: courseID,
+		GroupID:  groupID,
+	}))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("group not found")
+	}
+	return err
+}
+
+// ListGroupMembers lists the students in a group
+func (s *CourseService) ListGroupMembers(ctx context.Context, courseID, groupID, userID int64, role string) ([]*dto.CourseGroupMemberResponse, error) {
+	if _, err := s.getManageableGroup(ctx, courseID, groupID, userID, role); err != nil {
+		return nil, err
+	}
+	members, err := s.groupRepo.ListMembers(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group members: %w", err)
+	}
+	resp := make([]*dto.CourseGroupMemberResponse, len(members))
+	for i, m := range members {
+		resp[i] = &dto.CourseGroupMemberResponse{
+			UserID:    m.UserID,
+			FullName:  m.FullName,
+			Email:     m.Email,
+			AvatarURL: m.AvatarURL,
+			AddedAt:   m.AddedAt,
+		}
+	}
+	return resp, nil
+}
+
+// AddGroupMembers puts students of the course in a group. Students without
+// an accepted enrollment, and those already in the group, are skipped.
+func (s *CourseService) AddGroupMembers(ctx context.Context, courseID, groupID, userID int64, role string, req *dto.CourseGroupMembersRequest) (*dto.CourseGroupMembersResponse, error) {
+	if _, err := s.getManageableGroup(ctx, courseID, groupID, userID, role); err != nil {
+		return nil, err
+	}
+	added, err := s.groupRepo.AddMembers(ctx, groupID, userID, req.UserIDs, func(added []int64) []repository.OutboxMessage {
+		return []repository.OutboxMessage{groupMemberChangeEvent(courseID, groupID, kafka.CourseMemberAdded, added...)}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return groupMembersResponse(req.UserIDs, added), nil
+}
+
+// RemoveGroupMembers takes students out of a group
+func (s *CourseService) RemoveGroupMembers(ctx context.Context, courseID, groupID, userID int64, role string, req *dto.CourseGroupMembersRequest) (*dto.CourseGroupMembersResponse, error) {
+	if _, err := s.getManageableGroup(ctx, courseID, groupID, userID, role); err != nil {
+		return nil, err
+	}
+	removed, err := s.groupRepo.RemoveMembers(ctx, groupID, req.UserIDs, func(removed []int64) []repository.OutboxMessage {
+		return []repository.OutboxMessage{groupMemberChangeEvent(courseID, groupID, kafka.CourseMemberRemoved, removed...)}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return groupMembersResponse(req.UserIDs, removed), nil
+}
+
+// checkCanManageGroups lets the course owner, co-teachers, admins and org
+// roles with COURSE_EDIT manage a course's groups
+func (s *CourseService) checkCanManageGroups(ctx context.Context, courseID, userID int64, role string) (*models.CourseWithCreator, error) {
+	course, err := s.getCourseCached(ctx, courseID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("course not found")
+		}
+		return nil, fmt.Errorf("failed to get course: %w", err)
+	}
+
+	isCoTeacher, _ := s.courseRepo.IsCoTeacher(ctx, courseID, userID)
+	if role != models.RoleAdmin && course.CreatedBy != userID && !isCoTeacher && !s.orgAllows(ctx, course, userID, "COURSE_EDIT") {
+		return nil, fmt.Errorf("unauthorized to manage groups in this course")
+	}
+	return course, nil
+}
+
+// getManageableGroup loads a group of courseID the caller may manage
+func (s *CourseService) getManageableGroup(ctx context.Context, courseID, groupID, userID int64, role string) (*models.CourseGroup, error) {
+	if _, err := s.checkCanManageGroups(ctx, courseID, userID, role); err != nil {
+		return nil, err
+	}
+	group, err := s.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("group not found")
+		}
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	if group.CourseID != courseID {
+		return nil, fmt.Errorf("group not found")
+	}
+	return group, nil
+}
+
+func (s *CourseService) getGroupResponse(ctx context.Context, groupID int64) (*dto.CourseGroupResponse, error) {
+	group, err := s.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	return toCourseGroupResponse(group), nil
+}
+
+func toCourseGroupResponse(g *models.CourseGroup) *dto.CourseGroupResponse {
+	return &dto.CourseGroupResponse{
+		ID:          g.ID,
+		CourseID:    g.CourseID,
+		Name:        g.Name,
+		CreatedBy:   g.CreatedBy,
+		MemberCount: g.MemberCount,
+		CreatedAt:   g.CreatedAt,
+		UpdatedAt:   g.UpdatedAt,
+	}
+}
+
+// groupMembersResponse splits requested into the students that changed and
+// those that were skipped
+func groupMembersResponse(requested, changed []int64) *dto.CourseGroupMembersResponse {
+	done := make(map[int64]bool, len(changed))
+	for _, id := range changed {
+		done[id] = true
+	}
+	seen := make(map[int64]bool, len(requested))
+	resp := &dto.CourseGroupMembersResponse{Changed: []int64{}, Skipped: []int64{}}
+	for _, id := range requested {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if done[id] {
+			resp.Changed = append(resp.Changed, id)
+		} else {
+			resp.Skipped = append(resp.Skipped, id)
+		}
+	}
+	return resp
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupMembersResponse(t *testing.T) {
+	// 4 is requested twice and 9 has no accepted enrollment
+	resp := groupMembersResponse([]int64{3, 4, 9, 4}, []int64{4, 3})
+	if want := []int64{3, 4}; !reflect.DeepEqual(resp.Changed, want) {
+		t.Errorf("changed = %v; want %v", resp.Changed, want)
+	}
+	if want := []int64{9}; !reflect.DeepEqual(resp.Skipped, want) {
+		t.Errorf("skipped = %v; want %v", resp.Skipped, want)
+	}
+
+	resp = groupMembersResponse([]int64{5}, nil)
+	if resp.Changed == nil || len(resp.Changed) != 0 || !reflect.DeepEqual(resp.Skipped, []int64{5}) {
+		t.Errorf("nothing changed = %+v; want an empty changed list and 5 skipped", resp)
+	}
+}
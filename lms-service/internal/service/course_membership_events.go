@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"example/hello/internal/models"
+	"example/hello/internal/repository"
+	"example/hello/pkg/kafka"
+
+	"github.com/google/uuid"
+)
+
+// courseMembershipEvent is an outbox message announcing a course lifecycle
+// or membership change, keyed by course so consumers apply a course's
+// changes in order.
+func courseMembershipEvent(event kafka.CourseMembershipEvent) repository.OutboxMessage {
+	event.EventID = uuid.NewString()
+	event.OccurredAt = time.Now().UTC()
+	return repository.OutboxMessage{
+		Topic:   kafka.TopicCourseMembership,
+		Key:     fmt.Sprintf("course-%d", event.CourseID),
+		Payload: event,
+	}
+}
+
+// courseMembersSyncedEvent lists everyone who belongs to course, and every
+// group of it, for a COURSE_MEMBERS_SYNCED event. Call it inside the
+// transaction that writes the event so the lists match what it commits.
+func courseMembersSyncedEvent(ctx context.Context, courseRepo *repository.CourseRepository, groupRepo *repository.CourseGroupRepository, course *models.Course) (repository.OutboxMessage, error) {
+	rows, err := courseRepo.ListMembers(ctx, course.ID)
+	if err != nil {
+		return repository.OutboxMessage{}, fmt.Errorf("failed to list course members: %w", err)
+	}
+	members := make([]kafka.CourseMember, len(rows))
+	for i, m := range rows {
+		members[i] = kafka.CourseMember{UserID: m.UserID, Email: m.Email, FullName: m.FullName, Role: m.Role}
+	}
+
+	groupRows, err := groupRepo.ListByCourse(ctx, course.ID)
+	if err != nil {
+		return repository.OutboxMessage{}, fmt.Errorf("failed to list course groups: %w", err)
+	}
+	memberIDs, err := groupRepo.ListMemberIDs(ctx, course.ID)
+	if err != nil {
+		return repository.OutboxMessage{}, fmt.Errorf("failed to list course group members: %w", err)
+	}
+	groups := make([]kafka.CourseGroup, len(groupRows))
+	for i, g := range groupRows {
+		groups[i] = kafka.CourseGroup{GroupID: g.ID, Name: g.Name, MemberIDs: memberIDs[g.ID]}
+		if groups[i].MemberIDs == nil {
+			groups[i].MemberIDs = []int64{}
+		}
+	}
+
+	return courseMembershipEvent(kafka.CourseMembershipEvent{
+		Type:        kafka.CourseMembersSynced,
+		CourseID:    course.ID,
+		CourseTitle: course.Title,
+		CreatedBy:   course.CreatedBy,
+		Members:     members,
+		Groups:      groups,
+	}), nil
+}
+
+// memberChangeEvent announces that members joined (MEMBER_ADDED) or left
+// (MEMBER_REMOVED) a course in role
+func memberChangeEvent(courseID int64, eventType, role string, userIDs ...int64) repository.OutboxMessage {
+	members := make([]kafka.CourseMember, len(userIDs))
+	for i, id := range userIDs {
+		members[i] = kafka.CourseMember{UserID: id, Role: role}
+	}
+	return courseMembershipEvent(kafka.CourseMembershipEvent{
+		Type:     eventType,
+		CourseID: courseID,
+		Members:  members,
+	})
+}
+
+// groupSyncedEvent announces a group's name and members in a GROUP_SYNCED
+// event. Call it inside the transaction that writes the event.
+func groupSyncedEvent(ctx context.Context, groupRepo *repository.CourseGroupRepository, group *models.CourseGroup) (repository.OutboxMessage, error) {
+	rows, err := groupRepo.ListMembers(ctx, group.ID)
+	if err != nil {
+		return repository.OutboxMessage{}, fmt.Errorf("failed to list group members: %w", err)
+	}
+	members := make([]kafka.CourseMember, len(rows))
+	for i, m := range rows {
+		members[i] = kafka.CourseMember{UserID: m.UserID, Email: m.Email, FullName: m.FullName, Role: kafka.CourseRoleGroupMember}
+	}
+	return courseMembershipEvent(kafka.CourseMembershipEvent{
+		Type:      kafka.CourseGroupSynced,
+		CourseID:  group.CourseID,
+		GroupID:   group.ID,
+		GroupName: group.Name,
+		Members:   members,
+	}), nil
+}
+
+// groupMemberChangeEvent announces that students joined (MEMBER_ADDED) or
+// left (MEMBER_REMOVED) a group
+func groupMemberChangeEvent(courseID, groupID int64, eventType string, userIDs ...int64) repository.OutboxMessage {
+	members := make([]kafka.CourseMember, len(userIDs))
+	for i, id := range userIDs {
+		members[i] = kafka.CourseMember{UserID: id, Role: kafka.CourseRoleGroupMember}
+	}
+	return courseMembershipEvent(kafka.CourseMembershipEvent{
+		Type:     eventType,
+		CourseID: courseID,
+		GroupID:  groupID,
+		Members:  members,
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"example/hello/internal/models"
 	"example/hello/internal/repository"
 	"example/hello/pkg/cache"
+	"example/hello/pkg/kafka"
 )
 
 // enrollmentMembershipTTL caps how long a "is X enrolled in Y?" answer can
@@ -158,7 +159,8 @@ func (s *EnrollmentService) EnrollCourse(ctx context.Context, courseID, studentI
 		Status:    models.EnrollmentAccepted,
 	}
 
-	result, err := s.enrollmentRepo.Create(ctx, enrollment)
+	result, err := s.enrollmentRepo.Create(ctx, enrollment,
+		memberChangeEvent(courseID, kafka.CourseMemberAdded, kafka.CourseRoleStudent, studentID))
 	if err != nil {
 		return nil, err
 	}
@@ -275,12 +277,15 @@ func (s *EnrollmentService) AcceptEnrollment(ctx context.Context, enrollmentID,
 		}
 	}
 
-	if err := s.enrollmentRepo.UpdateStatus(ctx, enrollmentID, models.EnrollmentAccepted); err != nil {
+	e, err := s.enrollmentRepo.GetByID(ctx, enrollmentID)
+	if err != nil {
 		return err
 	}
-	if e, _ := s.enrollmentRepo.GetByID(ctx, enrollmentID); e != nil {
-		s.invalidateMembership(ctx, e.StudentID, e.CourseID)
+	if err := s.enrollmentRepo.UpdateStatus(ctx, enrollmentID, models.EnrollmentAccepted,
+		memberChangeEvent(e.CourseID, kafka.CourseMemberAdded, kafka.CourseRoleStudent, e.StudentID)); err != nil {
+		return err
 	}
+	s.invalidateMembership(ctx, e.StudentID, e.CourseID)
 	return nil
 }
 
@@ -298,12 +303,15 @@ func (s *EnrollmentService) RejectEnrollment(ctx context.Context, enrollmentID,
 		}
 	}
 
-	if err := s.enrollmentRepo.UpdateStatus(ctx, enrollmentID, models.EnrollmentRejected); err != nil {
+	e, err := s.enrollmentRepo.GetByID(ctx, enrollmentID)
+	if err != nil {
 		return err
 	}
-	if e, _ := s.enrollmentRepo.GetByID(ctx, enrollmentID); e != nil {
-		s.invalidateMembership(ctx, e.StudentID, e.CourseID)
+	if err := s.enrollmentRepo.UpdateStatus(ctx, enrollmentID, models.EnrollmentRejected,
+		memberChangeEvent(e.CourseID, kafka.CourseMemberRemoved, kafka.CourseRoleStudent, e.StudentID)); err != nil {
+		return err
 	}
+	s.invalidateMembership(ctx, e.StudentID, e.CourseID)
 	return nil
 }
 
@@ -331,7 +339,11 @@ func (s *EnrollmentService) BulkEnroll(
 		}
 	}
 
-	inserted, err := s.enrollmentRepo.BulkCreate(ctx, courseID, studentIDs)
+	inserted, err := s.enrollmentRepo.BulkCreate(ctx, courseID, studentIDs, func(inserted []int64) []repository.OutboxMessage {
+		return []repository.OutboxMessage{
+			memberChangeEvent(courseID, kafka.CourseMemberAdded, kafka.CourseRoleStudent, inserted...),
+		}
+	})
 	if err != nil {
 		failed := make([]dto.EnrollmentError, total)
 		for i, sid := range studentIDs {
@@ -378,7 +390,11 @@ func (s *EnrollmentService) CancelEnrollment(ctx context.Context, enrollmentID i
 		return fmt.Errorf("unauthorized")
 	}
 
-	if err := s.enrollmentRepo.Delete(ctx, enrollmentID); err != nil {
+	var events []repository.OutboxMessage
+	if enrollment.Status == models.EnrollmentAccepted {
+		events = append(events, memberChangeEvent(enrollment.CourseID, kafka.CourseMemberRemoved, kafka.CourseRoleStudent, studentID))
+	}
+	if err := s.enrollmentRepo.Delete(ctx, enrollmentID, events...); err != nil {
 		return err
 	}
 	s.invalidateMembership(ctx, enrollment.StudentID, enrollment.CourseID)
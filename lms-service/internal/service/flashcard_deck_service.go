@@ -0,0 +1,510 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/models"
+	"example/hello/internal/repository"
+	"example/hello/pkg/anki"
+)
+
+// sm2MinEasiness mirrors the ai-service scheduler so personal and shared
+// cards progress at the same pace.
+const sm2MinEasiness = 1.3
+
+// dueDeckCardsLimit caps one study session of shared-deck cards.
+const dueDeckCardsLimit = 200
+
+// FlashcardDeckService manages teacher-authored decks shared with a course.
+// Authoring is limited to the course owner, co-teachers and admins; studying
+// is open to anyone who passes EnrollmentService.VerifyAccess.
+type FlashcardDeckService struct {
+	deckRepo      *repository.FlashcardDeckRepository
+	courseRepo    *repository.CourseRepository
+	enrollmentSvc *EnrollmentService
+}
+
+func NewFlashcardDeckService(
+	deckRepo *repository.FlashcardDeckRepository,
+	courseRepo *repository.CourseRepository,
+	enrollmentSvc *EnrollmentService,
+) *FlashcardDeckService {
+	return &FlashcardDeckService{
+		deckRepo:      deckRepo,
+		courseRepo:    courseRepo,
+		enrollmentSvc: enrollmentSvc,
+	}
+}
+
+// ============================================
+// DECK AUTHORING (Teacher / Co-teacher / Admin)
+// ============================================
+
+// CreateDeck creates a DRAFT deck, optionally with an initial set of cards
+func (s *FlashcardDeckService) CreateDeck(ctx context.Context, userID int64, role string, courseID int64, req *dto.CreateFlashcardDeckRequest) (*dto.FlashcardDeckResponse, error) {
+	if err := s.checkCanManage(ctx, courseID, userID, role); err != nil {
+		return nil, err
+	}
+
+	deck := &models.FlashcardDeck{
+		CourseID:    courseID,
+		Title:       strings.TrimSpace(req.Title),
+		Description: sql.NullString{String: req.Description, Valid: req.Description != ""},
+		CreatedBy:   userID,
+	}
+	if req.NodeID != nil {
+		deck.NodeID = sql.NullInt64{Int64: *req.NodeID, Valid: true}
+	}
+	if err := s.deckRepo.CreateDeck(ctx, deck); err != nil {
+		return nil, fmt.Errorf("failed to create deck: %w", err)
+	}
+
+	if len(req.Cards) > 0 {
+		if err := s.deckRepo.AddCards(ctx, deck.ID, userID, toDeckCards(req.Cards)); err != nil {
+			return nil, fmt.Errorf("failed to add cards: %w", err)
+		}
+	}
+	return s.GetDeck(ctx, userID, role, deck.ID)
+}
+
+// UpdateDeck edits deck metadata
+func (s *FlashcardDeckService) UpdateDeck(ctx context.Context, userID int64, role string, deckID int64, req *dto.UpdateFlashcardDeckRequest) (*dto.FlashcardDeckResponse, error) {
+	deck, err := s.getManageableDeck(ctx, deckID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title != nil {
+		deck.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Description != nil {
+		deck.Description = sql.NullString{String: *req.Description, Valid: *req.Description != ""}
+	}
+	if req.NodeID != nil {
+		deck.NodeID = sql.NullInt64{Int64: *req.NodeID, Valid: *req.NodeID > 0}
+	}
+	if err := s.deckRepo.UpdateDeck(ctx, deck, userID); err != nil {
+		return nil, fmt.Errorf("failed to update deck: %w", err)
+	}
+	return s.GetDeck(ctx, userID, role, deckID)
+}
+
+// PublishDeck makes a deck visible to enrolled students (or re-publishes an
+// archived one) and starts a new version.
+func (s *FlashcardDeckService) PublishDeck(ctx context.Context, userID int64, role string, deckID int64, summary string) (*dto.FlashcardDeckResponse, error) {
+	deck, err := s.getManageableDeck(ctx, deckID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if deck.Status == models.FlashcardDeckStatusPublished {
+		return nil, errors.New("deck is already published")
+	}
+	if deck.CardCount == 0 {
+		return nil, errors.New("cannot publish an empty deck")
+	}
+	if summary == "" {
+		summary = "Published"
+		if deck.PublishedAt.Valid {
+			summary = "Re-published"
+		}
+	}
+	if _, err := s.deckRepo.PublishDeck(ctx, deckID, userID, summary); err != nil {
+		return nil, fmt.Errorf("failed to publish deck: %w", err)
+	}
+	return s.GetDeck(ctx, userID, role, deckID)
+}
+
+// ArchiveDeck hides a deck from students. Their schedules are kept so
+// re-publishing resumes where they left off.
+func (s *FlashcardDeckService) ArchiveDeck(ctx context.Context, userID int64, role string, deckID int64) error {
+	if _, err := s.getManageableDeck(ctx, deckID, userID, role); err != nil {
+		return err
+	}
+	return s.deckRepo.SetDeckStatus(ctx, deckID, models.FlashcardDeckStatusArchived)
+}
+
+// ListVersions returns a deck's changelog
+func (s *FlashcardDeckService) ListVersions(ctx context.Context, userID int64, role string, deckID int64) ([]dto.FlashcardDeckVersionResponse, error) {
+	if _, err := s.getVisibleDeck(ctx, deckID, userID, role); err != nil {
+		return nil, err
+	}
+	versions, err := s.deckRepo.ListVersions(ctx, deckID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.FlashcardDeckVersionResponse, 0, len(versions))
+	for _, v := range versions {
+		item := dto.FlashcardDeckVersionResponse{Version: v.Version, Summary: v.Summary, CreatedAt: v.CreatedAt}
+		if v.ChangedBy.Valid {
+			item.ChangedBy = &v.ChangedBy.Int64
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// AddCards appends cards to a deck
+func (s *FlashcardDeckService) AddCards(ctx context.Context, userID int64, role string, deckID int64, req *dto.AddFlashcardDeckCardsRequest) (*dto.FlashcardDeckResponse, error) {
+	if _, err := s.getManageableDeck(ctx, deckID, userID, role); err != nil {
+		return nil, err
+	}
+	if err := s.deckRepo.AddCards(ctx, deckID, userID, toDeckCards(req.Cards)); err != nil {
+		return nil, fmt.Errorf("failed to add cards: %w", err)
+	}
+	return s.GetDeck(ctx, userID, role, deckID)
+}
+
+// ImportCards appends the cards of an .apkg or CSV file to a deck. Review
+// history in the file is ignored: it belongs to whoever exported it, not to
+// the students of this course.
+func (s *FlashcardDeckService) ImportCards(ctx context.Context, userID int64, role string, deckID int64, fileName string, data []byte) (*dto.ImportFlashcardsResponse, error) {
+	if _, err := s.getManageableDeck(ctx, deckID, userID, role); err != nil {
+		return nil, err
+	}
+
+	cards, format, err := readDeckFile(fileName, data)
+	if err != nil {
+		return nil, err
+	}
+
+	deckCards := make([]*models.FlashcardDeckCard, 0, len(cards))
+	for _, c := range cards {
+		deckCards = append(deckCards, &models.FlashcardDeckCard{FrontText: c.Front, BackText: c.Back})
+	}
+	if err := s.deckRepo.AddCards(ctx, deckID, userID, deckCards); err != nil {
+		return nil, fmt.Errorf("failed to import cards: %w", err)
+	}
+	return &dto.ImportFlashcardsResponse{Format: format, Total: len(cards), Imported: len(cards)}, nil
+}
+
+// UpdateCard edits a card in place; students keep their schedule unless
+// ResetProgress is set.
+func (s *FlashcardDeckService) UpdateCard(ctx context.Context, userID int64, role string, cardID int64, req *dto.UpdateFlashcardDeckCardRequest) (*dto.FlashcardDeckCardResponse, error) {
+	card, err := s.getManageableCard(ctx, cardID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+	if req.FrontText != nil && strings.TrimSpace(*req.FrontText) != card.FrontText {
+		card.FrontText = strings.TrimSpace(*req.FrontText)
+		changed = true
+	}
+	if req.BackText != nil && strings.TrimSpace(*req.BackText) != card.BackText {
+		card.BackText = strings.TrimSpace(*req.BackText)
+		changed = true
+	}
+	if card.FrontText == "" || card.BackText == "" {
+		return nil, errors.New("front_text and back_text cannot be empty")
+	}
+	if changed || req.ResetProgress {
+		if err := s.deckRepo.UpdateCard(ctx, card, userID, req.ResetProgress); err != nil {
+			return nil, fmt.Errorf("failed to update card: %w", err)
+		}
+	}
+	resp := deckCardToResponse(&models.FlashcardDeckCardWithRepetition{FlashcardDeckCard: *card})
+	return &resp, nil
+}
+
+// RemoveCard removes a card from the deck
+func (s *FlashcardDeckService) RemoveCard(ctx context.Context, userID int64, role string, cardID int64) error {
+	card, err := s.getManageableCard(ctx, cardID, userID, role)
+	if err != nil {
+		return err
+	}
+	if !card.IsActive {
+		return nil
+	}
+	return s.deckRepo.RemoveCard(ctx, card, userID)
+}
+
+// ============================================
+// STUDYING (Enrolled students)
+// ============================================
+
+// ListDecks lists a course's decks. Course staff also see drafts.
+func (s *FlashcardDeckService) ListDecks(ctx context.Context, userID int64, role string, courseID int64) ([]dto.FlashcardDeckResponse, error) {
+	err := s.checkCanManage(ctx, courseID, userID, role)
+	if err != nil && !errors.Is(err, errNotDeckManager) {
+		return nil, err
+	}
+	publishedOnly := err != nil
+	if publishedOnly {
+		if err := s.enrollmentSvc.VerifyAccess(ctx, userID, courseID, role); err != nil {
+			return nil, fmt.Errorf("unauthorized: %w", err)
+		}
+	}
+	decks, err := s.deckRepo.ListDecksByCourse(ctx, courseID, publishedOnly)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.FlashcardDeckResponse, 0, len(decks))
+	for _, d := range decks {
+		out = append(out, deckToResponse(d))
+	}
+	return out, nil
+}
+
+// GetDeck returns a deck with its cards and the caller's schedule per card
+func (s *FlashcardDeckService) GetDeck(ctx context.Context, userID int64, role string, deckID int64) (*dto.FlashcardDeckResponse, error) {
+	deck, err := s.getVisibleDeck(ctx, deckID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	cards, err := s.deckRepo.ListDeckCards(ctx, deckID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := deckToResponse(deck)
+	resp.Cards = make([]dto.FlashcardDeckCardResponse, 0, len(cards))
+	for _, c := range cards {
+		resp.Cards = append(resp.Cards, deckCardToResponse(c))
+	}
+	return &resp, nil
+}
+
+// ListDueCards returns the caller's due and new cards across the course's
+// published decks.
+func (s *FlashcardDeckService) ListDueCards(ctx context.Context, studentID int64, role string, courseID int64) ([]dto.FlashcardDeckCardResponse, error) {
+	if err := s.enrollmentSvc.VerifyAccess(ctx, studentID, courseID, role); err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+	cards, err := s.deckRepo.ListDueCards(ctx, courseID, studentID, dueDeckCardsLimit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.FlashcardDeckCardResponse, 0, len(cards))
+	for _, c := range cards {
+		out = append(out, deckCardToResponse(c))
+	}
+	return out, nil
+}
+
+// ReviewCard applies an SM-2 review to the caller's schedule for a deck card,
+// creating the schedule on first review.
+func (s *FlashcardDeckService) ReviewCard(ctx context.Context, studentID int64, role string, cardID int64, quality int) (*dto.ReviewFlashcardResponse, error) {
+	card, err := s.deckRepo.GetCardByID(ctx, cardID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("card not found")
+		}
+		return nil, err
+	}
+	if !card.IsActive {
+		return nil, errors.New("card not found")
+	}
+	deck, err := s.getVisibleDeck(ctx, card.DeckID, studentID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	rep, err := s.deckRepo.GetRepetition(ctx, studentID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if rep == nil {
+		rep = &models.FlashcardDeckRepetition{
+			StudentID:      studentID,
+			DeckCardID:     cardID,
+			DeckID:         deck.ID,
+			CourseID:       deck.CourseID,
+			EasinessFactor: anki.DefaultEasinessFactor,
+			IntervalDays:   1,
+		}
+	}
+
+	rep.EasinessFactor, rep.IntervalDays, rep.Repetitions = applySM2(rep.EasinessFactor, rep.IntervalDays, rep.Repetitions, quality)
+	rep.QualityLast = quality
+	rep.SeenRevision = card.Revision
+	y, m, d := time.Now().Date()
+	rep.NextReviewDate = time.Date(y, m, d, 0, 0, 0, 0, time.UTC).AddDate(0, 0, rep.IntervalDays)
+
+	if err := s.deckRepo.UpsertRepetition(ctx, rep); err != nil {
+		return nil, fmt.Errorf("failed to save review: %w", err)
+	}
+	return &dto.ReviewFlashcardResponse{
+		FlashcardID:    cardID,
+		EasinessFactor: math.Round(rep.EasinessFactor*100) / 100,
+		IntervalDays:   rep.IntervalDays,
+		Repetitions:    rep.Repetitions,
+		NextReviewDate: rep.NextReviewDate,
+	}, nil
+}
+
+// ============================================
+// HELPERS
+// ============================================
+
+// applySM2 is the SuperMemo-2 update used by the ai-service for personal
+// flashcards (see flashcard_service.py::_update_sm2).
+func applySM2(ef float64, interval, reps, quality int) (float64, int, int) {
+	q := float64(5 - quality)
+	ef = math.Max(sm2MinEasiness, ef+(0.1-q*(0.08+q*0.02)))
+	if quality < 3 {
+		return ef, 1, 0
+	}
+	reps++
+	switch reps {
+	case 1:
+		interval = 1
+	case 2:
+		interval = 6
+	default:
+		interval = int(math.Round(float64(interval) * ef))
+	}
+	return ef, interval, reps
+}
+
+// errNotDeckManager is returned by checkCanManage to callers who may still
+// study the course's published decks.
+var errNotDeckManager = errors.New("unauthorized: only course teachers can manage shared decks")
+
+func (s *FlashcardDeckService) checkCanManage(ctx context.Context, courseID, userID int64, role string) error {
+	course, err := s.courseRepo.GetByID(ctx, courseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("course not found")
+		}
+		return fmt.Errorf("failed to load course: %w", err)
+	}
+	if role == models.RoleAdmin || course.CreatedBy == userID {
+		return nil
+	}
+	isCoTeacher, err := s.courseRepo.IsCoTeacher(ctx, courseID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check co-teacher status: %w", err)
+	}
+	if !isCoTeacher {
+		return errNotDeckManager
+	}
+	return nil
+}
+
+func (s *FlashcardDeckService) getDeck(ctx context.Context, deckID int64) (*models.FlashcardDeck, error) {
+	deck, err := s.deckRepo.GetDeckByID(ctx, deckID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("deck not found")
+		}
+		return nil, err
+	}
+	return deck, nil
+}
+
+func (s *FlashcardDeckService) getManageableDeck(ctx context.Context, deckID, userID int64, role string) (*models.FlashcardDeck, error) {
+	deck, err := s.getDeck(ctx, deckID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCanManage(ctx, deck.CourseID, userID, role); err != nil {
+		return nil, err
+	}
+	return deck, nil
+}
+
+// getVisibleDeck lets course staff see any deck and students only published
+// decks of courses they can access.
+func (s *FlashcardDeckService) getVisibleDeck(ctx context.Context, deckID, userID int64, role string) (*models.FlashcardDeck, error) {
+	deck, err := s.getDeck(ctx, deckID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCanManage(ctx, deck.CourseID, userID, role); err == nil {
+		return deck, nil
+	} else if !errors.Is(err, errNotDeckManager) {
+		return nil, err
+	}
+	if deck.Status != models.FlashcardDeckStatusPublished {
+		return nil, errors.New("deck not found")
+	}
+	if err := s.enrollmentSvc.VerifyAccess(ctx, userID, deck.CourseID, role); err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+	return deck, nil
+}
+
+func (s *FlashcardDeckService) getManageableCard(ctx context.Context, cardID, userID int64, role string) (*models.FlashcardDeckCard, error) {
+	card, err := s.deckRepo.GetCardByID(ctx, cardID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("card not found")
+		}
+		return nil, err
+	}
+	if _, err := s.getManageableDeck(ctx, card.DeckID, userID, role); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func toDeckCards(in []dto.FlashcardDeckCardInput) []*models.FlashcardDeckCard {
+	cards := make([]*models.FlashcardDeckCard, 0, len(in))
+	for _, c := range in {
+		cards = append(cards, &models.FlashcardDeckCard{
+			FrontText: strings.TrimSpace(c.FrontText),
+			BackText:  strings.TrimSpace(c.BackText),
+		})
+	}
+	return cards
+}
+
+func deckToResponse(d *models.FlashcardDeck) dto.FlashcardDeckResponse {
+	resp := dto.FlashcardDeckResponse{
+		ID:          d.ID,
+		CourseID:    d.CourseID,
+		Title:       d.Title,
+		Description: d.Description.String,
+		Status:      d.Status,
+		Version:     d.Version,
+		CardCount:   d.CardCount,
+		CreatedBy:   d.CreatedBy,
+		CreatedAt:   d.CreatedAt,
+		UpdatedAt:   d.UpdatedAt,
+	}
+	if d.NodeID.Valid {
+		resp.NodeID = &d.NodeID.Int64
+	}
+	if d.PublishedAt.Valid {
+		resp.PublishedAt = &d.PublishedAt.Time
+	}
+	return resp
+}
+
+func deckCardToResponse(c *models.FlashcardDeckCardWithRepetition) dto.FlashcardDeckCardResponse {
+	resp := dto.FlashcardDeckCardResponse{
+		ID:               c.ID,
+		DeckID:           c.DeckID,
+		DeckTitle:        c.DeckTitle,
+		FrontText:        c.FrontText,
+		BackText:         c.BackText,
+		OrderIndex:       c.OrderIndex,
+		Revision:         c.Revision,
+		UpdatedInVersion: c.UpdatedInVersion,
+		IsNew:            !c.RepetitionID.Valid,
+	}
+	if !c.RepetitionID.Valid {
+		return resp
+	}
+	ef := c.EasinessFactor.Float64
+	interval := int(c.IntervalDays.Int32)
+	reps := int(c.Repetitions.Int32)
+	resp.EasinessFactor = &ef
+	resp.IntervalDays = &interval
+	resp.Repetitions = &reps
+	if c.NextReviewDate.Valid {
+		resp.NextReviewDate = &c.NextReviewDate.Time
+	}
+	if c.LastReviewedAt.Valid {
+		resp.LastReviewedAt = &c.LastReviewedAt.Time
+	}
+	resp.UpdatedSinceReview = c.SeenRevision.Valid && int(c.SeenRevision.Int32) < c.Revision
+	return resp
+}
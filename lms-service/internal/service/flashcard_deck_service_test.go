@@ -0,0 +1,88 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+
+	"example/hello/internal/dto"
+	"example/hello/pkg/anki"
+)
+
+func TestApplySM2_MatchesAIServiceSchedule(t *testing.T) {
+	tests := []struct {
+		name         string
+		ef           float64
+		interval     int
+		reps         int
+		quality      int
+		wantEF       float64
+		wantInterval int
+		wantReps     int
+	}{
+		{"first success", 2.5, 1, 0, 4, 2.5, 1, 1},
+		{"second success", 2.5, 1, 1, 5, 2.6, 6, 2},
+		{"third success scales interval", 2.5, 6, 2, 4, 2.5, 15, 3},
+		{"failure resets", 2.5, 15, 3, 2, 2.18, 1, 0},
+		{"easiness floor", 1.3, 1, 0, 0, 1.3, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			ef, interval, reps := applySM2(tt.ef, tt.interval, tt.reps, tt.quality)
+
+			// Assert
+			if math.Abs(ef-tt.wantEF) > 1e-9 {
+				t.Errorf("ef = %v; want %v", ef, tt.wantEF)
+			}
+			if interval != tt.wantInterval || reps != tt.wantReps {
+				t.Errorf("interval, reps = %d, %d; want %d, %d", interval, reps, tt.wantInterval, tt.wantReps)
+			}
+		})
+	}
+}
+
+func TestReadDeckFile(t *testing.T) {
+	var apkg bytes.Buffer
+	if err := anki.WriteAPKG(&apkg, "Deck", []anki.Card{{Front: "2 + 2", Back: "4", EasinessFactor: anki.DefaultEasinessFactor}}); err != nil {
+		t.Fatal(err)
+	}
+	csv := []byte("front,back\nHà Nội,Capital\nOne,1\n")
+
+	tests := []struct {
+		name       string
+		fileName   string
+		data       []byte
+		wantFormat string
+		wantCards  int
+		wantErr    error
+	}{
+		{"apkg by content", "deck.zip", apkg.Bytes(), dto.FlashcardFormatAPKG, 1, nil},
+		{"csv", "deck.csv", csv, dto.FlashcardFormatCSV, 2, nil},
+		{"csv named apkg", "deck.APKG", csv, "", 0, anki.ErrInvalidPackage},
+		{"empty csv", "deck.csv", []byte("front,back\n"), dto.FlashcardFormatCSV, 0, anki.ErrEmptyDeck},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			cards, format, err := readDeckFile(tt.fileName, tt.data)
+
+			// Assert
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || !anki.IsInvalidDeck(err) {
+					t.Fatalf("err = %v; want an invalid deck error %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tt.wantFormat || len(cards) != tt.wantCards {
+				t.Errorf("format, cards = %s, %d; want %s, %d", format, len(cards), tt.wantFormat, tt.wantCards)
+			}
+		})
+	}
+}
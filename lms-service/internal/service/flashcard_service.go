@@ -1,14 +1,20 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"example/hello/internal/dto"
+	"example/hello/internal/models"
 	"example/hello/internal/repository"
 	"example/hello/pkg/ai"
+	"example/hello/pkg/anki"
 	"example/hello/pkg/cache"
 	"example/hello/pkg/kafka"
 	"example/hello/pkg/logger"
@@ -17,14 +23,16 @@ import (
 )
 
 type FlashcardService struct {
-	flashcardRepo *repository.FlashcardRepository // Kept for compilation compatibility, but unused
+	flashcardRepo *repository.FlashcardRepository // Only checks knowledge nodes; flashcards live in the AI service
+	courseRepo    *repository.CourseRepository
 	aiClient      *ai.Client
 	redisCache    *cache.RedisCache
 }
 
-func NewFlashcardService(flashcardRepo *repository.FlashcardRepository, aiClient *ai.Client, redisCache *cache.RedisCache) *FlashcardService {
+func NewFlashcardService(flashcardRepo *repository.FlashcardRepository, courseRepo *repository.CourseRepository, aiClient *ai.Client, redisCache *cache.RedisCache) *FlashcardService {
 	return &FlashcardService{
 		flashcardRepo: flashcardRepo,
+		courseRepo:    courseRepo,
 		aiClient:      aiClient,
 		redisCache:    redisCache,
 	}
@@ -237,3 +245,237 @@ func (s *FlashcardService) mapAIResultToDTO(rows []map[string]interface{}) []dto
 	}
 	return results
 }
+
+// ── Deck import / export ──────────────────────────────────────────────────────
+
+// importBatchSize bounds each bulk-save call to the ai-service during import.
+const importBatchSize = 200
+
+// Errors returned by ExportFlashcards
+var (
+	ErrFlashcardCourseNotFound = errors.New("course not found")
+	ErrNotCourseTeacher        = errors.New("unauthorized: only course teachers can export the course deck")
+	ErrNoFlashcardsToExport    = errors.New("no flashcards to export")
+	ErrFlashcardNodeNotFound   = errors.New("knowledge node not found in this course")
+)
+
+// ExportedDeck is a rendered flashcard deck ready to be streamed to the client.
+type ExportedDeck struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+	CardCount   int
+}
+
+// ExportFlashcards renders a deck as .apkg or CSV. scope=mine exports the
+// caller's own cards with their SM-2 schedule; scope=course (teachers only)
+// exports the distinct cards of every student in the course without any
+// per-student review history.
+func (s *FlashcardService) ExportFlashcards(ctx context.Context, userID int64, role string, courseID int64, q dto.ExportFlashcardsQuery) (*ExportedDeck, error) {
+	format := q.Format
+	if format == "" {
+		format = dto.FlashcardFormatAPKG
+	}
+
+	course, err := s.courseRepo.GetByID(ctx, courseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrFlashcardCourseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load course: %w", err)
+	}
+
+	studentID := &userID
+	if q.Scope == dto.FlashcardExportScopeCourse {
+		if err := s.checkCourseTeacher(ctx, course, userID, role); err != nil {
+			return nil, err
+		}
+		studentID = nil
+	}
+	if q.NodeID != nil {
+		if err := s.checkNodeInCourse(ctx, courseID, *q.NodeID); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := s.aiClient.ExportFlashcards(ctx, courseID, studentID, q.NodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch flashcards from AI: %w", err)
+	}
+
+	cards := toAnkiCards(resp.Flashcards, studentID != nil)
+	if len(cards) == 0 {
+		return nil, ErrNoFlashcardsToExport
+	}
+
+	var buf bytes.Buffer
+	deck := &ExportedDeck{CardCount: len(cards)}
+	base := fmt.Sprintf("course-%d-flashcards", courseID)
+	switch format {
+	case dto.FlashcardFormatCSV:
+		err = anki.WriteCSV(&buf, cards)
+		deck.FileName, deck.ContentType = base+".csv", "text/csv; charset=utf-8"
+	default:
+		err = anki.WriteAPKG(&buf, course.Title, cards)
+		deck.FileName, deck.ContentType = base+".apkg", "application/apkg"
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s deck: %w", format, err)
+	}
+	deck.Data = buf.Bytes()
+	return deck, nil
+}
+
+// ImportFlashcards parses an .apkg or CSV deck and saves it into the caller's
+// flashcards for a course node, carrying over review history when the file
+// has it. Duplicates (same front text) are skipped by the ai-service.
+func (s *FlashcardService) ImportFlashcards(ctx context.Context, studentID, courseID, nodeID int64, fileName string, data []byte) (*dto.ImportFlashcardsResponse, error) {
+	if err := s.checkNodeInCourse(ctx, courseID, nodeID); err != nil {
+		return nil, err
+	}
+	cards, format, err := readDeckFile(fileName, data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.ImportFlashcardsResponse{Format: format, Total: len(cards)}
+	for start := 0; start < len(cards); start += importBatchSize {
+		end := min(start+importBatchSize, len(cards))
+		batch := make([]map[string]interface{}, 0, end-start)
+		for _, c := range cards[start:end] {
+			batch = append(batch, ankiCardToPayload(c))
+			if c.HasHistory() {
+				result.WithHistory++
+			}
+		}
+
+		resp, err := s.aiClient.BulkSaveFlashcards(ctx, ai.BulkSaveFlashcardsRequest{
+			StudentID:  studentID,
+			CourseID:   courseID,
+			NodeID:     &nodeID,
+			Flashcards: batch,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to import flashcards via AI (%d of %d saved): %w", result.Imported, result.Total, err)
+		}
+		result.Imported += len(resp.Flashcards)
+	}
+	result.Skipped = result.Total - result.Imported
+	return result, nil
+}
+
+// checkNodeInCourse returns ErrFlashcardNodeNotFound unless nodeID is one of
+// the course's knowledge nodes
+func (s *FlashcardService) checkNodeInCourse(ctx context.Context, courseID, nodeID int64) error {
+	ok, err := s.flashcardRepo.NodeInCourse(ctx, courseID, nodeID)
+	if err != nil {
+		return fmt.Errorf("failed to check knowledge node: %w", err)
+	}
+	if !ok {
+		return ErrFlashcardNodeNotFound
+	}
+	return nil
+}
+
+// readDeckFile parses an uploaded deck, telling .apkg packages from CSV by
+// their content. A file named .apkg that is not a package is rejected rather
+// than read as CSV. Errors about the file itself satisfy anki.IsInvalidDeck.
+func readDeckFile(fileName string, data []byte) ([]anki.Card, string, error) {
+	if anki.IsAPKG(data) {
+		cards, err := anki.ReadAPKG(bytes.NewReader(data), int64(len(data)))
+		return cards, dto.FlashcardFormatAPKG, err
+	}
+	if strings.HasSuffix(strings.ToLower(fileName), ".apkg") {
+		return nil, "", anki.ErrInvalidPackage
+	}
+	cards, err := anki.ReadCSV(bytes.NewReader(data))
+	return cards, dto.FlashcardFormatCSV, err
+}
+
+func (s *FlashcardService) checkCourseTeacher(ctx context.Context, course *models.CourseWithCreator, userID int64, role string) error {
+	if role == models.RoleAdmin || course.CreatedBy == userID {
+		return nil
+	}
+	isCoTeacher, err := s.courseRepo.IsCoTeacher(ctx, course.ID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check co-teacher status: %w", err)
+	}
+	if !isCoTeacher {
+		return ErrNotCourseTeacher
+	}
+	return nil
+}
+
+// toAnkiCards maps ai-service rows onto anki cards. Without withHistory the
+// rows come from many students, so cards are de-duplicated and unscheduled.
+func toAnkiCards(rows []ai.AIFlashcardExport, withHistory bool) []anki.Card {
+	seen := make(map[string]bool, len(rows))
+	cards := make([]anki.Card, 0, len(rows))
+	for _, r := range rows {
+		card := anki.Card{Front: r.FrontText, Back: r.BackText, EasinessFactor: anki.DefaultEasinessFactor}
+		if !withHistory {
+			key := strings.ToLower(strings.TrimSpace(r.FrontText)) + "\x00" + strings.ToLower(strings.TrimSpace(r.BackText))
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			cards = append(cards, card)
+			continue
+		}
+		if r.EasinessFactor != nil {
+			card.EasinessFactor = *r.EasinessFactor
+		}
+		if r.IntervalDays != nil {
+			card.IntervalDays = *r.IntervalDays
+		}
+		if r.Repetitions != nil {
+			card.Repetitions = *r.Repetitions
+		}
+		if r.QualityLast != nil {
+			card.QualityLast = *r.QualityLast
+		}
+		if r.NextReviewDate != nil {
+			if t, err := time.Parse("2006-01-02", *r.NextReviewDate); err == nil {
+				card.NextReviewDate = &t
+			}
+		}
+		if r.LastReviewedAt != nil {
+			if t, ok := parseAITimestamp(*r.LastReviewedAt); ok {
+				card.LastReviewedAt = &t
+			}
+		}
+		cards = append(cards, card)
+	}
+	return cards
+}
+
+func ankiCardToPayload(c anki.Card) map[string]interface{} {
+	item := map[string]interface{}{
+		"front_text": c.Front,
+		"back_text":  c.Back,
+	}
+	if !c.HasHistory() {
+		return item
+	}
+	item["easiness_factor"] = c.EasinessFactor
+	item["interval_days"] = c.IntervalDays
+	item["repetitions"] = c.Repetitions
+	item["quality_last"] = c.QualityLast
+	if c.NextReviewDate != nil {
+		item["next_review_date"] = c.NextReviewDate.Format("2006-01-02")
+	}
+	if c.LastReviewedAt != nil {
+		item["last_reviewed_at"] = c.LastReviewedAt.UTC().Format(time.RFC3339)
+	}
+	return item
+}
+
+// parseAITimestamp accepts the timestamp shapes the ai-service emits.
+func parseAITimestamp(v string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999", "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/models"
+	"example/hello/internal/repository"
+	"example/hello/pkg/logger"
+	"example/hello/pkg/storage"
+)
+
+// forumAttachmentGrace is how long an upload may stay unlinked (a draft that
+// was never posted, or whose post was deleted) before it is swept
+const forumAttachmentGrace = 24 * time.Hour
+
+// forumAttachmentSweepBatch bounds how many orphans one sweep removes
+const forumAttachmentSweepBatch = 500
+
+// inlineImageTypes are served inline so they can be embedded in posts.
+// Everything else, SVG included, is forced to download.
+var inlineImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ForumAttachmentConfig limits forum uploads
+type ForumAttachmentConfig struct {
+	MaxSize     int64    // bytes per file
+	CourseQuota int64    // total bytes per course, 0 for unlimited
+	Extensions  []string // allowed extensions without the dot
+}
+
+// ForumAttachmentService stores files attached to forum posts and comments
+type ForumAttachmentService struct {
+	attachmentRepo *repository.ForumAttachmentRepository
+	forumRepo      *repository.ForumRepository
+	enrollmentSvc  *EnrollmentService
+	storage        storage.Storage
+	cfg            ForumAttachmentConfig
+	allowedExt     map[string]bool
+}
+
+func NewForumAttachmentService(
+	attachmentRepo *repository.ForumAttachmentRepository,
+	forumRepo *repository.ForumRepository,
+	enrollmentSvc *EnrollmentService,
+	storage storage.Storage,
+	cfg ForumAttachmentConfig,
+) *ForumAttachmentService {
+	allowed := make(map[string]bool, len(cfg.Extensions))
+	for _, ext := range cfg.Extensions {
+		allowed[strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))] = true
+	}
+	return &ForumAttachmentService{
+		attachmentRepo: attachmentRepo,
+		forumRepo:      forumRepo,
+		enrollmentSvc:  enrollmentSvc,
+		storage:        storage,
+		cfg:            cfg,
+		allowedExt:     allowed,
+	}
+}
+
+// Upload stores a file for a forum. It is not visible on any post until it
+// is linked by creating or editing a post/comment with its ID.
+func (s *ForumAttachmentService) Upload(ctx context.Context, userID int64, role string, contentID int64, fileName string, body io.Reader, size int64) (*dto.ForumAttachmentResponse, error) {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(fileName), "."))
+	if !s.allowedExt[ext] {
+		return nil, fmt.Errorf("file type .%s is not allowed", ext)
+	}
+	if s.cfg.MaxSize > 0 && size > s.cfg.MaxSize {
+		return nil, fmt.Errorf("file exceeds maximum size of %d MB", s.cfg.MaxSize/1024/1024)
+	}
+
+	courseID, err := s.forumRepo.GetCourseIDByContentID(ctx, contentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("forum not found")
+		}
+		return nil, err
+	}
+	if err := s.enrollmentSvc.VerifyAccess(ctx, userID, courseID, role); err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+	ban, err := s.forumRepo.GetActiveBan(ctx, courseID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if ban != nil {
+		return nil, fmt.Errorf("posting banned until %s", ban.ExpiresAt.Format(time.RFC3339))
+	}
+
+	// Cheap pre-check so an obviously full course does not cost an upload;
+	// the authoritative check happens when the row is inserted.
+	if s.cfg.CourseQuota > 0 {
+		used, err := s.attachmentRepo.GetUsage(ctx, courseID)
+		if err != nil {
+			return nil, err
+		}
+		if used+size > s.cfg.CourseQuota {
+			return nil, fmt.Errorf("course attachment quota exceeded")
+		}
+	}
+
+	contentType := mime.TypeByExtension("." + ext)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	key := fmt.Sprintf("forum/%d/%s_%s", courseID, uuid.NewString()[:8], safeFileName(fileName))
+
+	if _, err := s.storage.Upload(ctx, key, body, size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	attachment := &models.ForumAttachment{
+		CourseID:    courseID,
+		ContentID:   contentID,
+		UploaderID:  userID,
+		FileName:    path.Base(fileName),
+		StorageKey:  key,
+		ContentType: contentType,
+		SizeBytes:   size,
+	}
+	ok, err := s.attachmentRepo.CreateWithinQuota(ctx, attachment, s.cfg.CourseQuota)
+	if err != nil || !ok {
+		if delErr := s.storage.Delete(context.Background(), key); delErr != nil {
+			logger.Error(fmt.Sprintf("Failed to remove rejected forum attachment %s", key), delErr)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("course attachment quota exceeded")
+	}
+
+	return attachmentToResponse(attachment), nil
+}
+
+// Get returns an attachment for a user with course access
+func (s *ForumAttachmentService) Get(ctx context.Context, userID int64, role string, attachmentID int64) (*models.ForumAttachment, error) {
+	attachment, err := s.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, err
+	}
+	if err := s.enrollmentSvc.VerifyAccess(ctx, userID, attachment.CourseID, role); err != nil {
+		return nil, fmt.Errorf("attachment not found")
+	}
+	return attachment, nil
+}
+
+// Open returns the content of an attachment already checked with Get. The
+// caller must close the object body.
+func (s *ForumAttachmentService) Open(ctx context.Context, attachment *models.ForumAttachment) (*storage.ObjectResult, error) {
+	object, err := s.storage.GetObject(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("attachment not found: %w", err)
+	}
+	return object, nil
+}
+
+// Delete removes an attachment. Only the uploader or an admin may delete it.
+func (s *ForumAttachmentService) Delete(ctx context.Context, userID int64, isAdmin bool, attachmentID int64) error {
+	attachment, err := s.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("attachment not found")
+		}
+		return err
+	}
+	if attachment.UploaderID != userID && !isAdmin {
+		return fmt.Errorf("unauthorized: not the uploader of this attachment")
+	}
+	return s.remove(ctx, attachment)
+}
+
+// GetUsage reports how much of the course quota is used
+func (s *ForumAttachmentService) GetUsage(ctx context.Context, userID int64, role string, courseID int64) (*dto.ForumAttachmentUsageResponse, error) {
+	if err := s.enrollmentSvc.VerifyAccess(ctx, userID, courseID, role); err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+	used, err := s.attachmentRepo.GetUsage(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ForumAttachmentUsageResponse{
+		CourseID:     courseID,
+		UsedBytes:    used,
+		QuotaBytes:   s.cfg.CourseQuota,
+		MaxFileBytes: s.cfg.MaxSize,
+		Extensions:   s.cfg.Extensions,
+	}, nil
+}
+
+// LinkToPost attaches the user's uploads in the forum to a post
+func (s *ForumAttachmentService) LinkToPost(ctx context.Context, ids []int64, userID, contentID, postID int64) error {
+	return s.link(ctx, ids, userID, contentID, sql.NullInt64{Int64: postID, Valid: true}, sql.NullInt64{})
+}
+
+// LinkToComment attaches the user's uploads in the forum to a comment
+func (s *ForumAttachmentService) LinkToComment(ctx context.Context, ids []int64, userID, contentID, commentID int64) error {
+	return s.link(ctx, ids, userID, contentID, sql.NullInt64{}, sql.NullInt64{Int64: commentID, Valid: true})
+}
+
+func (s *ForumAttachmentService) link(ctx context.Context, ids []int64, userID, contentID int64, postID, commentID sql.NullInt64) error {
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return nil
+	}
+	ok, err := s.attachmentRepo.Link(ctx, ids, userID, contentID, postID, commentID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("invalid attachment: attachments must be your own unused uploads to this forum")
+	}
+	return nil
+}
+
+// ListForTargets returns attachments grouped by post ID and by comment ID
+func (s *ForumAttachmentService) ListForTargets(ctx context.Context, postIDs, commentIDs []int64) (map[int64][]*dto.ForumAttachmentResponse, map[int64][]*dto.ForumAttachmentResponse, error) {
+	attachments, err := s.attachmentRepo.ListLinked(ctx, postIDs, commentIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byPost := make(map[int64][]*dto.ForumAttachmentResponse)
+	byComment := make(map[int64][]*dto.ForumAttachmentResponse)
+	for _, a := range attachments {
+		switch {
+		case a.PostID.Valid:
+			byPost[a.PostID.Int64] = append(byPost[a.PostID.Int64], attachmentToResponse(a))
+		case a.CommentID.Valid:
+			byComment[a.CommentID.Int64] = append(byComment[a.CommentID.Int64], attachmentToResponse(a))
+		}
+	}
+	return byPost, byComment, nil
+}
+
+// RunOrphanSweeper periodically deletes uploads that were never linked or
+// whose post/comment was deleted, freeing their quota
+func (s *ForumAttachmentService) RunOrphanSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.SweepOrphans(ctx)
+			if err != nil {
+				logger.Error("Forum attachment sweep failed", err)
+			}
+			if n > 0 {
+				logger.Info(fmt.Sprintf("Removed %d orphaned forum attachments", n))
+			}
+		}
+	}
+}
+
+// SweepOrphans deletes one batch of orphaned attachments
+func (s *ForumAttachmentService) SweepOrphans(ctx context.Context) (int, error) {
+	orphans, err := s.attachmentRepo.ListOrphans(ctx, time.Now().Add(-forumAttachmentGrace), forumAttachmentSweepBatch)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, a := range orphans {
+		if err := s.remove(ctx, a); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// remove deletes the stored object, then the row. A missing object is not
+// an error so a half-finished earlier removal can complete.
+func (s *ForumAttachmentService) remove(ctx context.Context, a *models.ForumAttachment) error {
+	if err := s.storage.Delete(ctx, a.StorageKey); err != nil && !strings.Contains(err.Error(), "not found") {
+		return fmt.Errorf("failed to delete attachment object: %w", err)
+	}
+	return s.attachmentRepo.Delete(ctx, a.ID)
+}
+
+// IsInlineImage reports whether an attachment may be displayed inline
+func IsInlineImage(contentType string) bool {
+	return inlineImageTypes[contentType]
+}
+
+func attachmentToResponse(a *models.ForumAttachment) *dto.ForumAttachmentResponse {
+	return &dto.ForumAttachmentResponse{
+		ID:          a.ID,
+		FileName:    a.FileName,
+		ContentType: a.ContentType,
+		SizeBytes:   a.SizeBytes,
+		IsImage:     IsInlineImage(a.ContentType),
+		URL:         fmt.Sprintf("/api/v1/forum/attachments/%d", a.ID),
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+// safeFileName keeps a storage-key-friendly version of an uploaded name
+func safeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+		if b.Len() >= 100 {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "file"
+	}
+	return b.String()
+}
+
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	out := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,458 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/models"
+	"example/hello/internal/repository"
+	"example/hello/pkg/kafka"
+	"example/hello/pkg/logger"
+)
+
+// maxMentionsPerMessage bounds the fan-out a single post or comment can cause
+const maxMentionsPerMessage = 20
+
+// digestBatchSize is the number of recipients loaded per digest round
+const digestBatchSize = 200
+
+// mentionPattern matches @handle or @full.email@domain, not preceded by a
+// word character so that plain email addresses in text are not mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([A-Za-z0-9._%+\-]+(?:@[A-Za-z0-9.\-]+\.[A-Za-z]{2,})?)`)
+
+// ForumNotificationService handles forum subscriptions, @mentions, per-thread
+// read state and the daily digest.
+type ForumNotificationService struct {
+	notifRepo     *repository.ForumNotificationRepository
+	forumRepo     *repository.ForumRepository
+	enrollmentSvc *EnrollmentService
+}
+
+func NewForumNotificationService(
+	notifRepo *repository.ForumNotificationRepository,
+	forumRepo *repository.ForumRepository,
+	enrollmentSvc *EnrollmentService,
+) *ForumNotificationService {
+	return &ForumNotificationService{
+		notifRepo:     notifRepo,
+		forumRepo:     forumRepo,
+		enrollmentSvc: enrollmentSvc,
+	}
+}
+
+// ============================================
+// ACTIVITY HOOKS (called by ForumService)
+// ============================================
+
+// OnPostCreated subscribes the author to the new thread, notifies mentioned
+// course members and tells forum subscribers about the new post.
+func (s *ForumNotificationService) OnPostCreated(ctx context.Context, post *models.ForumPost, courseID int64) error {
+	if err := s.autoSubscribe(ctx, post.UserID, models.SubscriptionTargetPost, post.ID, courseID); err != nil {
+		return err
+	}
+
+	n := &models.ForumNotification{
+		CourseID: courseID,
+		PostID:   post.ID,
+		ActorID:  sql.NullInt64{Int64: post.UserID, Valid: true},
+	}
+	mentioned, err := s.notifyMentions(ctx, n, post.Title+"\n"+post.Body)
+	if err != nil {
+		return err
+	}
+
+	n.Type = models.ForumNotificationNewPost
+	_, err = s.notifRepo.NotifySubscribers(ctx, n, models.SubscriptionTargetForum, post.ContentID, append(mentioned, post.UserID))
+	return err
+}
+
+// OnCommentCreated subscribes the commenter to the thread, notifies mentioned
+// course members and tells thread subscribers about the reply.
+func (s *ForumNotificationService) OnCommentCreated(ctx context.Context, comment *models.ForumComment, courseID int64) error {
+	if err := s.autoSubscribe(ctx, comment.UserID, models.SubscriptionTargetPost, comment.PostID, courseID); err != nil {
+		return err
+	}
+
+	n := &models.ForumNotification{
+		CourseID:  courseID,
+		PostID:    comment.PostID,
+		CommentID: sql.NullInt64{Int64: comment.ID, Valid: true},
+		ActorID:   sql.NullInt64{Int64: comment.UserID, Valid: true},
+	}
+	mentioned, err := s.notifyMentions(ctx, n, comment.Body)
+	if err != nil {
+		return err
+	}
+
+	n.Type = models.ForumNotificationReply
+	_, err = s.notifRepo.NotifySubscribers(ctx, n, models.SubscriptionTargetPost, comment.PostID, append(mentioned, comment.UserID))
+	return err
+}
+
+// OnCommentAccepted tells the answer's author and the thread's subscribers
+// that an answer was accepted.
+func (s *ForumNotificationService) OnCommentAccepted(ctx context.Context, comment *models.ForumCommentWithUser, courseID, actorID int64) error {
+	n := &models.ForumNotification{
+		CourseID:  courseID,
+		PostID:    comment.PostID,
+		CommentID: sql.NullInt64{Int64: comment.ID, Valid: true},
+		Type:      models.ForumNotificationAccepted,
+		ActorID:   sql.NullInt64{Int64: actorID, Valid: true},
+	}
+
+	exclude := []int64{actorID}
+	if comment.UserID != actorID {
+		subscribed, err := s.notifRepo.IsSubscribed(ctx, comment.UserID, models.SubscriptionTargetPost, comment.PostID)
+		if err != nil {
+			return err
+		}
+		if !subscribed {
+			if err := s.notifRepo.NotifyUsers(ctx, n, []int64{comment.UserID}); err != nil {
+				return err
+			}
+			exclude = append(exclude, comment.UserID)
+		}
+	}
+
+	_, err := s.notifRepo.NotifySubscribers(ctx, n, models.SubscriptionTargetPost, comment.PostID, exclude)
+	return err
+}
+
+// MarkPostRead records that the user opened a thread and clears the
+// notifications it produced for them.
+func (s *ForumNotificationService) MarkPostRead(ctx context.Context, userID, postID int64) error {
+	if err := s.notifRepo.MarkPostRead(ctx, userID, postID); err != nil {
+		return err
+	}
+	return s.notifRepo.MarkPostNotificationsRead(ctx, userID, postID)
+}
+
+// MarkThreadRead is MarkPostRead for an explicit "mark as read" request
+func (s *ForumNotificationService) MarkThreadRead(ctx context.Context, userID, postID int64) error {
+	if _, err := s.forumRepo.GetCourseIDByPostID(ctx, postID); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("post not found")
+		}
+		return err
+	}
+	return s.MarkPostRead(ctx, userID, postID)
+}
+
+// ============================================
+// SUBSCRIPTIONS
+// ============================================
+
+// SubscribeForum follows every new thread in a forum content item
+func (s *ForumNotificationService) SubscribeForum(ctx context.Context, userID int64, role string, contentID int64) (*dto.ForumSubscriptionResponse, error) {
+	courseID, err := s.forumRepo.GetCourseIDByContentID(ctx, contentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("forum not found")
+		}
+		return nil, err
+	}
+	return s.subscribe(ctx, userID, role, models.SubscriptionTargetForum, contentID, courseID)
+}
+
+// SubscribePost follows replies to a thread
+func (s *ForumNotificationService) SubscribePost(ctx context.Context, userID int64, role string, postID int64) (*dto.ForumSubscriptionResponse, error) {
+	courseID, err := s.forumRepo.GetCourseIDByPostID(ctx, postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("post not found")
+		}
+		return nil, err
+	}
+	return s.subscribe(ctx, userID, role, models.SubscriptionTargetPost, postID, courseID)
+}
+
+// Unsubscribe stops following a forum or thread
+func (s *ForumNotificationService) Unsubscribe(ctx context.Context, userID int64, targetType string, targetID int64) error {
+	if err := s.notifRepo.Unsubscribe(ctx, userID, targetType, targetID); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("subscription not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// IsSubscribedToPost reports whether the user follows a thread
+func (s *ForumNotificationService) IsSubscribedToPost(ctx context.Context, userID, postID int64) (bool, error) {
+	return s.notifRepo.IsSubscribed(ctx, userID, models.SubscriptionTargetPost, postID)
+}
+
+// ListSubscriptions lists what the user follows
+func (s *ForumNotificationService) ListSubscriptions(ctx context.Context, userID int64) ([]*dto.ForumSubscriptionResponse, error) {
+	subs, err := s.notifRepo.ListSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.ForumSubscriptionResponse, 0, len(subs))
+	for _, sub := range subs {
+		responses = append(responses, subscriptionToResponse(sub))
+	}
+	return responses, nil
+}
+
+// ============================================
+// NOTIFICATIONS
+// ============================================
+
+// ListNotifications returns the user's forum notification feed
+func (s *ForumNotificationService) ListNotifications(ctx context.Context, userID int64, req *dto.ListForumNotificationsRequest) (*dto.ForumNotificationListResponse, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 {
+		req.Limit = 20
+	}
+
+	items, total, unread, err := s.notifRepo.ListNotifications(ctx, userID, req.UnreadOnly, req.Page, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.ForumNotificationResponse, 0, len(items))
+	for _, n := range items {
+		responses = append(responses, notificationToResponse(n))
+	}
+
+	return &dto.ForumNotificationListResponse{
+		ListResponse: dto.NewListResponse(responses, req.Page, req.Limit, total),
+		UnreadCount:  unread,
+	}, nil
+}
+
+// MarkNotificationsRead marks notifications read; an empty list marks all
+func (s *ForumNotificationService) MarkNotificationsRead(ctx context.Context, userID int64, ids []int64) (int64, error) {
+	return s.notifRepo.MarkNotificationsRead(ctx, userID, ids)
+}
+
+// ============================================
+// DAILY DIGEST
+// ============================================
+
+// RunDigestProducer publishes the daily digest at hourUTC every day until
+// ctx is cancelled. Safe to run on every replica: recipients are claimed
+// atomically, so each notification is sent at most once.
+func (s *ForumNotificationService) RunDigestProducer(ctx context.Context, hourUTC int) {
+	logger.Info(fmt.Sprintf("Forum digest producer scheduled daily at %02d:00 UTC", hourUTC))
+
+	for {
+		timer := time.NewTimer(time.Until(nextDigestRun(time.Now().UTC(), hourUTC)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		sent, err := s.ProduceDigests(ctx)
+		if err != nil {
+			logger.Error("Forum digest run failed", err)
+		}
+		logger.Info(fmt.Sprintf("Forum digest run published %d digests", sent))
+	}
+}
+
+// ProduceDigests publishes one digest event per user with unread forum
+// activity and returns how many were published.
+func (s *ForumNotificationService) ProduceDigests(ctx context.Context) (int, error) {
+	sent := 0
+	for {
+		recipients, err := s.notifRepo.ListDigestRecipients(ctx, digestBatchSize)
+		if err != nil {
+			return sent, err
+		}
+		if len(recipients) == 0 {
+			return sent, nil
+		}
+
+		for _, rcpt := range recipients {
+			items, err := s.notifRepo.ClaimDigest(ctx, rcpt.UserID)
+			if err != nil {
+				return sent, err
+			}
+			// Everything was already read in-app; nothing to send.
+			if len(items) == 0 {
+				continue
+			}
+
+			event := buildDigestEvent(rcpt, items)
+			key := []byte(fmt.Sprintf("user-%d", rcpt.UserID))
+			if err := kafka.PublishEvent(ctx, kafka.TopicForumDigest, key, event); err != nil {
+				ids := make([]int64, 0, len(items))
+				for _, n := range items {
+					ids = append(ids, n.ID)
+				}
+				if relErr := s.notifRepo.ReleaseDigest(ctx, ids); relErr != nil {
+					logger.Error(fmt.Sprintf("Failed to release digest for user %d", rcpt.UserID), relErr)
+				}
+				return sent, err
+			}
+			sent++
+		}
+
+		if len(recipients) < digestBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// ============================================
+// HELPERS
+// ============================================
+
+func (s *ForumNotificationService) subscribe(ctx context.Context, userID int64, role, targetType string, targetID, courseID int64) (*dto.ForumSubscriptionResponse, error) {
+	if err := s.enrollmentSvc.VerifyAccess(ctx, userID, courseID, role); err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	sub := &models.ForumSubscription{
+		UserID:     userID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		CourseID:   courseID,
+	}
+	if err := s.notifRepo.Subscribe(ctx, sub); err != nil {
+		return nil, err
+	}
+	return subscriptionToResponse(sub), nil
+}
+
+func (s *ForumNotificationService) autoSubscribe(ctx context.Context, userID int64, targetType string, targetID, courseID int64) error {
+	return s.notifRepo.Subscribe(ctx, &models.ForumSubscription{
+		UserID:     userID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		CourseID:   courseID,
+		IsAuto:     true,
+	})
+}
+
+// notifyMentions creates MENTION notifications for course members @-mentioned
+// in text and returns their IDs so they are not notified twice.
+func (s *ForumNotificationService) notifyMentions(ctx context.Context, n *models.ForumNotification, text string) ([]int64, error) {
+	handles := parseMentions(text)
+	if len(handles) == 0 {
+		return nil, nil
+	}
+
+	userIDs, err := s.notifRepo.ResolveMentions(ctx, n.CourseID, handles)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := userIDs[:0]
+	for _, id := range userIDs {
+		if id != n.ActorID.Int64 {
+			recipients = append(recipients, id)
+		}
+	}
+
+	mention := *n
+	mention.Type = models.ForumNotificationMention
+	if err := s.notifRepo.NotifyUsers(ctx, &mention, recipients); err != nil {
+		return nil, err
+	}
+	return recipients, nil
+}
+
+// parseMentions extracts lower-cased, de-duplicated @handles from text
+func parseMentions(text string) []string {
+	matches := mentionPattern.FindAllStringSubmatch(text, -1)
+	seen := make(map[string]bool, len(matches))
+	var handles []string
+	for _, m := range matches {
+		h := strings.ToLower(strings.TrimRight(m[1], ".-"))
+		if h == "" || seen[h] {
+			continue
+		}
+		seen[h] = true
+		handles = append(handles, h)
+		if len(handles) == maxMentionsPerMessage {
+			break
+		}
+	}
+	return handles
+}
+
+// nextDigestRun returns the next occurrence of hourUTC:00 strictly after now
+func nextDigestRun(now time.Time, hourUTC int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hourUTC, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func buildDigestEvent(rcpt repository.DigestRecipient, items []*models.ForumNotification) kafka.ForumDigestEvent {
+	event := kafka.ForumDigestEvent{
+		EventID:     uuid.NewString(),
+		UserID:      rcpt.UserID,
+		Email:       rcpt.Email,
+		FullName:    rcpt.FullName,
+		Items:       make([]kafka.ForumDigestItem, 0, len(items)),
+		GeneratedAt: time.Now().UTC(),
+	}
+	for _, n := range items {
+		item := kafka.ForumDigestItem{
+			Type:        n.Type,
+			CourseID:    n.CourseID,
+			CourseTitle: n.CourseTitle,
+			PostID:      n.PostID,
+			PostTitle:   n.PostTitle,
+			ActorName:   n.ActorName.String,
+			Excerpt:     n.Excerpt.String,
+			CreatedAt:   n.CreatedAt,
+		}
+		if n.CommentID.Valid {
+			item.CommentID = &n.CommentID.Int64
+		}
+		event.Items = append(event.Items, item)
+	}
+	return event
+}
+
+func subscriptionToResponse(sub *models.ForumSubscription) *dto.ForumSubscriptionResponse {
+	return &dto.ForumSubscriptionResponse{
+		ID:          sub.ID,
+		TargetType:  sub.TargetType,
+		TargetID:    sub.TargetID,
+		CourseID:    sub.CourseID,
+		TargetTitle: sub.TargetTitle,
+		IsAuto:      sub.IsAuto,
+		CreatedAt:   sub.CreatedAt,
+	}
+}
+
+func notificationToResponse(n *models.ForumNotification) *dto.ForumNotificationResponse {
+	resp := &dto.ForumNotificationResponse{
+		ID:          n.ID,
+		Type:        n.Type,
+		CourseID:    n.CourseID,
+		CourseTitle: n.CourseTitle,
+		PostID:      n.PostID,
+		PostTitle:   n.PostTitle,
+		ActorName:   n.ActorName.String,
+		Excerpt:     n.Excerpt.String,
+		IsRead:      n.ReadAt.Valid,
+		CreatedAt:   n.CreatedAt,
+	}
+	if n.CommentID.Valid {
+		resp.CommentID = &n.CommentID.Int64
+	}
+	if n.ActorID.Valid {
+		resp.ActorID = &n.ActorID.Int64
+	}
+	return resp
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"none", "no mentions here", nil},
+		{"handle", "cc @An.Nguyen please check", []string{"an.nguyen"}},
+		{"full email", "hỏi @tran.b@example.edu.vn nhé", []string{"tran.b@example.edu.vn"}},
+		{"trailing punctuation", "thanks @minh.", []string{"minh"}},
+		{"dedupe", "@minh and @MINH", []string{"minh"}},
+		{"plain email is not a mention", "mail me at a.b@example.com", nil},
+		{"start of text", "@lan: xem lại bài 2", []string{"lan"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseMentions(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMentions(%q) = %v; want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextDigestRun(t *testing.T) {
+	now := time.Date(2024, 3, 10, 5, 30, 0, 0, time.UTC)
+	if got := nextDigestRun(now, 7); !got.Equal(time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("later today: got %v", got)
+	}
+	if got := nextDigestRun(now, 5); !got.Equal(time.Date(2024, 3, 11, 5, 0, 0, 0, time.UTC)) {
+		t.Errorf("already passed: got %v", got)
+	}
+}
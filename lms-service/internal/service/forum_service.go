@@ -3,25 +3,57 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"example/hello/internal/dto"
 	"example/hello/internal/models"
 	"example/hello/internal/repository"
+	"example/hello/pkg/logger"
+	"example/hello/pkg/markdown"
+	"example/hello/pkg/moderation"
+	"example/hello/pkg/storage"
 )
 
+// ForumModerationConfig tunes report handling and posting bans
+type ForumModerationConfig struct {
+	// AutoHideReportCount is the number of distinct open reports that hide a
+	// post or comment until a moderator reviews it. 0 disables auto-hide.
+	AutoHideReportCount int
+	MaxBanDuration      time.Duration
+}
+
 type ForumService struct {
-	forumRepo  *repository.ForumRepository
-	courseRepo *repository.CourseRepository
+	forumRepo     *repository.ForumRepository
+	courseRepo    *repository.CourseRepository
+	orgRepo       *repository.OrganizationRepository
+	enrollmentSvc *EnrollmentService
+	contentFilter *moderation.Filter
+	moderationCfg ForumModerationConfig
+	notifier      *ForumNotificationService
+	attachments   *ForumAttachmentService
 }
 
 func NewForumService(
 	forumRepo *repository.ForumRepository,
 	courseRepo *repository.CourseRepository,
+	orgRepo *repository.OrganizationRepository,
+	enrollmentSvc *EnrollmentService,
+	contentFilter *moderation.Filter,
+	moderationCfg ForumModerationConfig,
+	notifier *ForumNotificationService,
+	attachments *ForumAttachmentService,
 ) *ForumService {
 	return &ForumService{
-		forumRepo:  forumRepo,
-		courseRepo: courseRepo,
+		forumRepo:     forumRepo,
+		courseRepo:    courseRepo,
+		orgRepo:       orgRepo,
+		enrollmentSvc: enrollmentSvc,
+		contentFilter: contentFilter,
+		moderationCfg: moderationCfg,
+		notifier:      notifier,
+		attachments:   attachments,
 	}
 }
 
@@ -41,6 +73,14 @@ func (s *ForumService) CreatePost(ctx context.Context, contentID, userID int64,
 		return nil, fmt.Errorf("content is not a forum")
 	}
 
+	courseID, err := s.forumRepo.GetCourseIDByContentID(ctx, contentID)
+	if err != nil {
+		return nil, fmt.Errorf("content not found: %w", err)
+	}
+	if err := s.checkCanPost(ctx, courseID, userID, req.Title, req.Body); err != nil {
+		return nil, err
+	}
+
 	// Create post
 	post := &models.ForumPost{
 		ContentID: contentID,
@@ -59,17 +99,29 @@ func (s *ForumService) CreatePost(ctx context.Context, contentID, userID int64,
 		return nil, err
 	}
 
+	if err := s.attachments.LinkToPost(ctx, req.AttachmentIDs, userID, contentID, createdPost.ID); err != nil {
+		if delErr := s.forumRepo.DeletePost(ctx, createdPost.ID); delErr != nil {
+			logger.Error(fmt.Sprintf("Failed to roll back forum post %d", createdPost.ID), delErr)
+		}
+		return nil, err
+	}
+
+	if err := s.notifier.OnPostCreated(ctx, createdPost, courseID); err != nil {
+		logger.Error(fmt.Sprintf("Failed to send notifications for forum post %d", createdPost.ID), err)
+	}
+
 	// Get full post with user info
 	fullPost, err := s.forumRepo.GetPostByID(ctx, createdPost.ID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.postToResponse(fullPost), nil
+	return s.withPostAttachments(ctx, s.postToResponse(fullPost)), nil
 }
 
-// GetPost retrieves a post by ID and increments view count
-func (s *ForumService) GetPost(ctx context.Context, postID, currentUserID int64) (*dto.ForumPostResponse, error) {
+// GetPost retrieves a post by ID and increments view count. Hidden posts are
+// only returned to their author and to moderators.
+func (s *ForumService) GetPost(ctx context.Context, postID, currentUserID int64, isAdmin bool) (*dto.ForumPostResponse, error) {
 	post, err := s.forumRepo.GetPostByID(ctx, postID, currentUserID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -78,14 +130,29 @@ func (s *ForumService) GetPost(ctx context.Context, postID, currentUserID int64)
 		return nil, err
 	}
 
+	if post.IsHidden && post.UserID != currentUserID {
+		courseID, err := s.forumRepo.GetCourseIDByPostID(ctx, postID)
+		if err != nil || !s.canModerate(ctx, courseID, currentUserID, isAdmin) {
+			return nil, fmt.Errorf("post not found")
+		}
+	}
+
 	// Increment view count (fire and forget)
 	go s.forumRepo.IncrementViewCount(context.Background(), postID)
 
-	return s.postToResponse(post), nil
+	if err := s.notifier.MarkPostRead(ctx, currentUserID, postID); err != nil {
+		logger.Error(fmt.Sprintf("Failed to mark forum post %d read", postID), err)
+	}
+
+	resp := s.withPostAttachments(ctx, s.postToResponse(post))
+	if subscribed, err := s.notifier.IsSubscribedToPost(ctx, currentUserID, postID); err == nil {
+		resp.IsSubscribed = &subscribed
+	}
+	return resp, nil
 }
 
 // ListPosts lists posts with filtering and sorting
-func (s *ForumService) ListPosts(ctx context.Context, contentID, currentUserID int64, req *dto.ListForumPostsRequest) (*dto.ListResponse, error) {
+func (s *ForumService) ListPosts(ctx context.Context, contentID, currentUserID int64, isAdmin bool, req *dto.ListForumPostsRequest) (*dto.ListResponse, error) {
 	// Set defaults
 	if req.Page < 1 {
 		req.Page = 1
@@ -100,14 +167,24 @@ func (s *ForumService) ListPosts(ctx context.Context, contentID, currentUserID i
 		req.SortBy = "newest"
 	}
 
-	posts, total, err := s.forumRepo.ListPosts(ctx, contentID, currentUserID, req.SortBy, req.Search, req.Tags, req.Page, req.Limit)
+	includeHidden := isAdmin
+	if !includeHidden {
+		if courseID, err := s.forumRepo.GetCourseIDByContentID(ctx, contentID); err == nil {
+			includeHidden = s.canModerate(ctx, courseID, currentUserID, false)
+		}
+	}
+
+	posts, total, err := s.forumRepo.ListPosts(ctx, contentID, currentUserID, req.SortBy, req.Search, req.Tags, req.Page, req.Limit, includeHidden)
 	if err != nil {
 		return nil, err
 	}
 
 	responses := make([]*dto.ForumPostResponse, 0, len(posts))
 	for _, post := range posts {
-		responses = append(responses, s.postToResponse(post))
+		resp := s.postToResponse(post)
+		resp.IsUnread = !post.LastReadAt.Valid && post.UserID != currentUserID
+		resp.UnreadCommentCount = post.UnreadCommentCount
+		responses = append(responses, resp)
 	}
 
 	return dto.NewListResponse(responses, req.Page, req.Limit, total), nil
@@ -129,6 +206,23 @@ func (s *ForumService) UpdatePost(ctx context.Context, postID, userID int64, req
 		return nil, fmt.Errorf("not authorized to update this post")
 	}
 
+	if req.Title != nil || req.Body != nil {
+		courseID, err := s.forumRepo.GetCourseIDByPostID(ctx, postID)
+		if err != nil {
+			return nil, err
+		}
+		title, body := post.Title, post.Body
+		if req.Title != nil {
+			title = *req.Title
+		}
+		if req.Body != nil {
+			body = *req.Body
+		}
+		if err := s.checkCanPost(ctx, courseID, userID, title, body); err != nil {
+			return nil, err
+		}
+	}
+
 	// Build updates
 	updates := make(map[string]interface{})
 	if req.Title != nil {
@@ -141,8 +235,12 @@ func (s *ForumService) UpdatePost(ctx context.Context, postID, userID int64, req
 		updates["tags"] = *req.Tags
 	}
 
+	if err := s.attachments.LinkToPost(ctx, req.AttachmentIDs, userID, post.ContentID, postID); err != nil {
+		return nil, err
+	}
+
 	if len(updates) == 0 {
-		return s.postToResponse(post), nil
+		return s.withPostAttachments(ctx, s.postToResponse(post)), nil
 	}
 
 	// Update post
@@ -157,7 +255,7 @@ func (s *ForumService) UpdatePost(ctx context.Context, postID, userID int64, req
 		return nil, err
 	}
 
-	return s.postToResponse(updatedPost), nil
+	return s.withPostAttachments(ctx, s.postToResponse(updatedPost)), nil
 }
 
 // DeletePost deletes a post
@@ -214,6 +312,14 @@ func (s *ForumService) CreateComment(ctx context.Context, postID, userID int64,
 		return nil, fmt.Errorf("post is locked")
 	}
 
+	courseID, err := s.forumRepo.GetCourseIDByPostID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCanPost(ctx, courseID, userID, req.Body); err != nil {
+		return nil, err
+	}
+
 	// Calculate depth if this is a reply
 	depth := 0
 	if req.ParentCommentID != nil {
@@ -246,26 +352,66 @@ func (s *ForumService) CreateComment(ctx context.Context, postID, userID int64,
 		return nil, err
 	}
 
+	if err := s.attachments.LinkToComment(ctx, req.AttachmentIDs, userID, post.ContentID, createdComment.ID); err != nil {
+		if delErr := s.forumRepo.DeleteComment(ctx, createdComment.ID); delErr != nil {
+			logger.Error(fmt.Sprintf("Failed to roll back forum comment %d", createdComment.ID), delErr)
+		}
+		return nil, err
+	}
+
+	if err := s.notifier.OnCommentCreated(ctx, createdComment, courseID); err != nil {
+		logger.Error(fmt.Sprintf("Failed to send notifications for forum comment %d", createdComment.ID), err)
+	}
+
 	// Get full comment with user info
 	fullComment, err := s.forumRepo.GetCommentByID(ctx, createdComment.ID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.commentToResponse(fullComment), nil
+	return s.withCommentAttachments(ctx, s.commentToResponse(fullComment)), nil
 }
 
-// ListComments lists comments for a post
-func (s *ForumService) ListComments(ctx context.Context, postID, currentUserID int64) ([]*dto.ForumCommentResponse, error) {
+// ListComments lists comments for a post. Hidden comments stay in the tree
+// so their replies keep a parent, but their body is withheld from everyone
+// except the author and moderators.
+func (s *ForumService) ListComments(ctx context.Context, postID, currentUserID int64, isAdmin bool) ([]*dto.ForumCommentResponse, error) {
 	comments, err := s.forumRepo.ListCommentsByPost(ctx, postID, currentUserID)
 	if err != nil {
 		return nil, err
 	}
 
+	isModerator := isAdmin
+	for _, comment := range comments {
+		if comment.IsHidden && !isModerator {
+			if courseID, err := s.forumRepo.GetCourseIDByPostID(ctx, postID); err == nil {
+				isModerator = s.canModerate(ctx, courseID, currentUserID, false)
+			}
+			break
+		}
+	}
+
 	// Convert to responses
 	responses := make([]*dto.ForumCommentResponse, 0, len(comments))
+	visibleIDs := make([]int64, 0, len(comments))
 	for _, comment := range comments {
-		responses = append(responses, s.commentToResponse(comment))
+		resp := s.commentToResponse(comment)
+		if comment.IsHidden && !isModerator && comment.UserID != currentUserID {
+			resp.Body = ""
+			resp.BodyHTML = ""
+			resp.HiddenReason = nil
+		} else {
+			visibleIDs = append(visibleIDs, comment.ID)
+		}
+		responses = append(responses, resp)
+	}
+
+	if _, byComment, err := s.attachments.ListForTargets(ctx, nil, visibleIDs); err != nil {
+		logger.Error(fmt.Sprintf("Failed to load attachments for forum post %d", postID), err)
+	} else {
+		for _, resp := range responses {
+			resp.Attachments = byComment[resp.ID]
+		}
 	}
 
 	// Build comment tree
@@ -288,6 +434,26 @@ func (s *ForumService) UpdateComment(ctx context.Context, commentID, userID int6
 		return nil, fmt.Errorf("not authorized to update this comment")
 	}
 
+	if req.Body != nil {
+		courseID, err := s.forumRepo.GetCourseIDByPostID(ctx, comment.PostID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkCanPost(ctx, courseID, userID, *req.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(req.AttachmentIDs) > 0 {
+		post, err := s.forumRepo.GetPostByID(ctx, comment.PostID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.attachments.LinkToComment(ctx, req.AttachmentIDs, userID, post.ContentID, commentID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Build updates
 	updates := make(map[string]interface{})
 	if req.Body != nil {
@@ -295,7 +461,7 @@ func (s *ForumService) UpdateComment(ctx context.Context, commentID, userID int6
 	}
 
 	if len(updates) == 0 {
-		return s.commentToResponse(comment), nil
+		return s.withCommentAttachments(ctx, s.commentToResponse(comment)), nil
 	}
 
 	// Update comment
@@ -310,7 +476,7 @@ func (s *ForumService) UpdateComment(ctx context.Context, commentID, userID int6
 		return nil, err
 	}
 
-	return s.commentToResponse(updatedComment), nil
+	return s.withCommentAttachments(ctx, s.commentToResponse(updatedComment)), nil
 }
 
 // DeleteComment deletes a comment
@@ -361,7 +527,20 @@ func (s *ForumService) AcceptComment(ctx context.Context, commentID, userID int6
 	updates := map[string]interface{}{
 		"is_accepted": true,
 	}
-	return s.forumRepo.UpdateComment(ctx, commentID, updates)
+	if err := s.forumRepo.UpdateComment(ctx, commentID, updates); err != nil {
+		return err
+	}
+
+	if !comment.IsAccepted {
+		courseID, err := s.forumRepo.GetCourseIDByPostID(ctx, comment.PostID)
+		if err == nil {
+			err = s.notifier.OnCommentAccepted(ctx, comment, courseID, userID)
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to send notifications for accepted comment %d", commentID), err)
+		}
+	}
+	return nil
 }
 
 // ============================================
@@ -460,6 +639,7 @@ func (s *ForumService) postToResponse(post *models.ForumPostWithUser) *dto.Forum
 		UserEmail:       post.UserEmail,
 		Title:           post.Title,
 		Body:            post.Body,
+		BodyHTML:        markdown.Render(post.Body),
 		Tags:            post.Tags,
 		Upvotes:         post.Upvotes,
 		Downvotes:       post.Downvotes,
@@ -468,6 +648,8 @@ func (s *ForumService) postToResponse(post *models.ForumPostWithUser) *dto.Forum
 		ViewCount:       post.ViewCount,
 		IsPinned:        post.IsPinned,
 		IsLocked:        post.IsLocked,
+		IsHidden:        post.IsHidden,
+		HiddenReason:    nullStringPtr(post.HiddenReason),
 		CurrentUserVote: currentVote,
 		CreatedAt:       post.CreatedAt,
 		UpdatedAt:       post.UpdatedAt,
@@ -493,17 +675,86 @@ func (s *ForumService) commentToResponse(comment *models.ForumCommentWithUser) *
 		UserName:        comment.UserName,
 		UserEmail:       comment.UserEmail,
 		Body:            comment.Body,
+		BodyHTML:        markdown.Render(comment.Body),
 		Upvotes:         comment.Upvotes,
 		Downvotes:       comment.Downvotes,
 		Score:           comment.Upvotes - comment.Downvotes,
 		IsAccepted:      comment.IsAccepted,
 		Depth:           comment.Depth,
+		IsHidden:        comment.IsHidden,
+		HiddenReason:    nullStringPtr(comment.HiddenReason),
 		CurrentUserVote: currentVote,
 		CreatedAt:       comment.CreatedAt,
 		UpdatedAt:       comment.UpdatedAt,
 	}
 }
 
+// withPostAttachments fills in a single post's attachments
+func (s *ForumService) withPostAttachments(ctx context.Context, resp *dto.ForumPostResponse) *dto.ForumPostResponse {
+	byPost, _, err := s.attachments.ListForTargets(ctx, []int64{resp.ID}, nil)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load attachments for forum post %d", resp.ID), err)
+		return resp
+	}
+	resp.Attachments = byPost[resp.ID]
+	return resp
+}
+
+// withCommentAttachments fills in a single comment's attachments
+func (s *ForumService) withCommentAttachments(ctx context.Context, resp *dto.ForumCommentResponse) *dto.ForumCommentResponse {
+	_, byComment, err := s.attachments.ListForTargets(ctx, nil, []int64{resp.ID})
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load attachments for forum comment %d", resp.ID), err)
+		return resp
+	}
+	resp.Attachments = byComment[resp.ID]
+	return resp
+}
+
+// OpenAttachment returns an attachment and its content. Attachments of
+// hidden posts and comments are only served to their author and to
+// moderators, like the posts and comments themselves. The caller must close
+// the object body.
+func (s *ForumService) OpenAttachment(ctx context.Context, userID int64, role string, isAdmin bool, attachmentID int64) (*models.ForumAttachment, *storage.ObjectResult, error) {
+	attachment, err := s.attachments.Get(ctx, userID, role, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !s.canSeeAttachment(ctx, attachment, userID, isAdmin) {
+		return nil, nil, fmt.Errorf("attachment not found")
+	}
+	object, err := s.attachments.Open(ctx, attachment)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, object, nil
+}
+
+// canSeeAttachment reports whether userID may see the post or comment a is
+// linked to. Unlinked uploads belong to no post yet.
+func (s *ForumService) canSeeAttachment(ctx context.Context, a *models.ForumAttachment, userID int64, isAdmin bool) bool {
+	postID := a.PostID
+	if a.CommentID.Valid {
+		comment, err := s.forumRepo.GetCommentByID(ctx, a.CommentID.Int64, userID)
+		if err != nil {
+			return false
+		}
+		if comment.IsHidden && comment.UserID != userID && !s.canModerate(ctx, a.CourseID, userID, isAdmin) {
+			return false
+		}
+		postID = sql.NullInt64{Int64: comment.PostID, Valid: true}
+	}
+	if !postID.Valid {
+		return true
+	}
+
+	post, err := s.forumRepo.GetPostByID(ctx, postID.Int64, userID)
+	if err != nil {
+		return false
+	}
+	return !post.IsHidden || post.UserID == userID || s.canModerate(ctx, a.CourseID, userID, isAdmin)
+}
+
 // buildCommentTree builds a tree structure from flat comment list
 func (s *ForumService) buildCommentTree(comments []*dto.ForumCommentResponse) []*dto.ForumCommentResponse {
 	// Create a map for quick lookup
@@ -526,4 +777,425 @@ func (s *ForumService) buildCommentTree(comments []*dto.ForumCommentResponse) []
 	}
 
 	return rootComments
-}
\ No newline at end of file
+}
+
+// ============================================
+// MODERATION
+// ============================================
+
+// ReportPost files the caller's report against a post
+func (s *ForumService) ReportPost(ctx context.Context, postID, userID int64, isAdmin bool, req *dto.ReportForumContentRequest) (*dto.ReportForumContentResponse, error) {
+	post, err := s.forumRepo.GetPostByID(ctx, postID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("post not found")
+		}
+		return nil, err
+	}
+
+	return s.report(ctx, models.VotableTypePost, postID, postID, post.UserID, post.IsHidden, userID, isAdmin, req)
+}
+
+// ReportComment files the caller's report against a comment
+func (s *ForumService) ReportComment(ctx context.Context, commentID, userID int64, isAdmin bool, req *dto.ReportForumContentRequest) (*dto.ReportForumContentResponse, error) {
+	comment, err := s.forumRepo.GetCommentByID(ctx, commentID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("comment not found")
+		}
+		return nil, err
+	}
+
+	return s.report(ctx, models.VotableTypeComment, commentID, comment.PostID, comment.UserID, comment.IsHidden, userID, isAdmin, req)
+}
+
+// report files a report from a reader of the course forum: someone enrolled
+// in the course or one of its moderators.
+func (s *ForumService) report(ctx context.Context, targetType string, targetID, postID, authorID int64, isHidden bool, reporterID int64, isAdmin bool, req *dto.ReportForumContentRequest) (*dto.ReportForumContentResponse, error) {
+	if authorID == reporterID {
+		return nil, fmt.Errorf("cannot report your own content")
+	}
+
+	courseID, err := s.forumRepo.GetCourseIDByPostID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.canModerate(ctx, courseID, reporterID, isAdmin) {
+		if err := s.enrollmentSvc.VerifyAccess(ctx, reporterID, courseID, ""); err != nil {
+			return nil, fmt.Errorf("unauthorized: %w", err)
+		}
+	}
+
+	report := &models.ForumReport{
+		TargetType: targetType,
+		TargetID:   targetID,
+		PostID:     postID,
+		CourseID:   courseID,
+		ReporterID: reporterID,
+		Reason:     req.Reason,
+		Details:    sql.NullString{String: req.Details, Valid: req.Details != ""},
+	}
+	created, openReports, err := s.forumRepo.CreateReport(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := s.moderationCfg.AutoHideReportCount
+	if created && !isHidden && threshold > 0 && openReports >= threshold {
+		if err := s.forumRepo.SetHidden(ctx, targetType, targetID, true, models.HiddenReasonReports); err != nil {
+			return nil, err
+		}
+		isHidden = true
+		logger.Info(fmt.Sprintf("forum %s %d auto-hidden after %d reports", targetType, targetID, openReports))
+	}
+
+	return &dto.ReportForumContentResponse{
+		Reported:        created,
+		AlreadyReported: !created,
+		IsHidden:        isHidden,
+	}, nil
+}
+
+// ListReportQueue returns the moderator queue. Teachers pass a course they
+// moderate, org admins an organization they administer; system admins may
+// omit both to see every course.
+func (s *ForumService) ListReportQueue(ctx context.Context, userID int64, isAdmin bool, req *dto.ListForumReportsRequest) (*dto.ListResponse, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 {
+		req.Limit = 20
+	}
+	if req.Status == "" {
+		req.Status = models.ReportStatusOpen
+	}
+
+	switch {
+	case req.CourseID != nil:
+		if !s.canModerate(ctx, *req.CourseID, userID, isAdmin) {
+			return nil, fmt.Errorf("unauthorized: not a moderator of this course")
+		}
+	case req.OrgID != nil:
+		if !isAdmin && !s.isOrgAdmin(ctx, *req.OrgID, userID) {
+			return nil, fmt.Errorf("unauthorized: not an admin of this organization")
+		}
+	case !isAdmin:
+		return nil, fmt.Errorf("course_id or org_id is required")
+	}
+
+	items, total, err := s.forumRepo.ListReportQueue(ctx, req.Status, req.CourseID, req.OrgID, req.Page, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.ForumReportQueueItemResponse, 0, len(items))
+	for _, item := range items {
+		responses = append(responses, reportQueueItemToResponse(item))
+	}
+
+	return dto.NewListResponse(responses, req.Page, req.Limit, total), nil
+}
+
+// ListTargetReports returns the individual reports against a post or comment
+func (s *ForumService) ListTargetReports(ctx context.Context, targetType string, targetID, userID int64, isAdmin bool) ([]*dto.ForumReportResponse, error) {
+	courseID, _, err := s.resolveTarget(ctx, targetType, targetID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.canModerate(ctx, courseID, userID, isAdmin) {
+		return nil, fmt.Errorf("unauthorized: not a moderator of this course")
+	}
+
+	reports, err := s.forumRepo.ListReportsForTarget(ctx, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.ForumReportResponse, 0, len(reports))
+	for _, r := range reports {
+		resp := &dto.ForumReportResponse{
+			ID:             r.ID,
+			ReporterID:     r.ReporterID,
+			Reason:         r.Reason,
+			Details:        r.Details.String,
+			Status:         r.Status,
+			ResolutionNote: r.ResolutionNote.String,
+			CreatedAt:      r.CreatedAt,
+		}
+		if r.ResolvedBy.Valid {
+			resp.ResolvedBy = &r.ResolvedBy.Int64
+		}
+		if r.ResolvedAt.Valid {
+			resp.ResolvedAt = &r.ResolvedAt.Time
+		}
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
+
+// ResolveReports applies a moderator decision to a reported post or comment
+// and closes its open reports, optionally banning the author from posting.
+func (s *ForumService) ResolveReports(ctx context.Context, targetType string, targetID, moderatorID int64, isAdmin bool, req *dto.ResolveForumReportRequest) error {
+	courseID, authorID, err := s.resolveTarget(ctx, targetType, targetID, moderatorID)
+	if err != nil {
+		return err
+	}
+	if !s.canModerate(ctx, courseID, moderatorID, isAdmin) {
+		return fmt.Errorf("unauthorized: not a moderator of this course")
+	}
+
+	status := models.ReportStatusActioned
+	switch req.Action {
+	case dto.ForumModerationDismiss:
+		status = models.ReportStatusDismissed
+		// Dismissing clears an automatic hide; a moderator's own hide stays.
+		if err := s.unhideIfAutoHidden(ctx, targetType, targetID, moderatorID); err != nil {
+			return err
+		}
+	case dto.ForumModerationRestore:
+		status = models.ReportStatusDismissed
+		if err := s.forumRepo.SetHidden(ctx, targetType, targetID, false, ""); err != nil {
+			return err
+		}
+	case dto.ForumModerationHide:
+		if err := s.forumRepo.SetHidden(ctx, targetType, targetID, true, models.HiddenReasonModerator); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.forumRepo.ResolveReports(ctx, targetType, targetID, status, moderatorID, req.Note); err != nil {
+		return err
+	}
+
+	if req.Action == dto.ForumModerationDelete {
+		if targetType == models.VotableTypePost {
+			err = s.forumRepo.DeletePost(ctx, targetID)
+		} else {
+			err = s.forumRepo.DeleteComment(ctx, targetID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if req.BanHours != nil && authorID != moderatorID {
+		_, err := s.BanUser(ctx, courseID, moderatorID, isAdmin, &dto.CreateForumBanRequest{
+			UserID:        authorID,
+			DurationHours: *req.BanHours,
+			Reason:        req.Note,
+		})
+		return err
+	}
+	return nil
+}
+
+// BanUser temporarily blocks a user from posting in a course forum
+func (s *ForumService) BanUser(ctx context.Context, courseID, moderatorID int64, isAdmin bool, req *dto.CreateForumBanRequest) (*dto.ForumBanResponse, error) {
+	if !s.canModerate(ctx, courseID, moderatorID, isAdmin) {
+		return nil, fmt.Errorf("unauthorized: not a moderator of this course")
+	}
+	if req.UserID == moderatorID {
+		return nil, fmt.Errorf("cannot ban yourself")
+	}
+
+	duration := time.Duration(req.DurationHours) * time.Hour
+	if maxBan := s.moderationCfg.MaxBanDuration; maxBan > 0 && duration > maxBan {
+		return nil, fmt.Errorf("ban duration exceeds the maximum of %s", maxBan)
+	}
+
+	ban := &models.ForumPostingBan{
+		CourseID:  courseID,
+		UserID:    req.UserID,
+		BannedBy:  sql.NullInt64{Int64: moderatorID, Valid: true},
+		Reason:    sql.NullString{String: req.Reason, Valid: req.Reason != ""},
+		ExpiresAt: time.Now().Add(duration),
+	}
+	if err := s.forumRepo.CreateBan(ctx, ban); err != nil {
+		return nil, err
+	}
+
+	return banToResponse(ban), nil
+}
+
+// LiftBan revokes a user's active posting ban
+func (s *ForumService) LiftBan(ctx context.Context, courseID, userID, moderatorID int64, isAdmin bool) error {
+	if !s.canModerate(ctx, courseID, moderatorID, isAdmin) {
+		return fmt.Errorf("unauthorized: not a moderator of this course")
+	}
+
+	if err := s.forumRepo.RevokeBan(ctx, courseID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("ban not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// ListBans lists active posting bans in a course
+func (s *ForumService) ListBans(ctx context.Context, courseID, moderatorID int64, isAdmin bool) ([]*dto.ForumBanResponse, error) {
+	if !s.canModerate(ctx, courseID, moderatorID, isAdmin) {
+		return nil, fmt.Errorf("unauthorized: not a moderator of this course")
+	}
+
+	bans, err := s.forumRepo.ListActiveBans(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.ForumBanResponse, 0, len(bans))
+	for _, ban := range bans {
+		responses = append(responses, banToResponse(ban))
+	}
+	return responses, nil
+}
+
+// checkCanPost rejects authors under a posting ban and text the content
+// filter flags.
+func (s *ForumService) checkCanPost(ctx context.Context, courseID, userID int64, fields ...string) error {
+	ban, err := s.forumRepo.GetActiveBan(ctx, courseID, userID)
+	if err != nil {
+		return err
+	}
+	if ban != nil {
+		return fmt.Errorf("posting banned until %s", ban.ExpiresAt.Format(time.RFC3339))
+	}
+
+	if s.contentFilter == nil {
+		return nil
+	}
+	verdict := s.contentFilter.Check(fields...)
+	if !verdict.Allowed {
+		logger.Info(fmt.Sprintf("forum content from user %d rejected: %s (%s)", userID, verdict.Reason, verdict.Match))
+	}
+	return verdict.Err()
+}
+
+// canModerate reports whether the user moderates the course forum: system
+// admins, the course owner, co-teachers and admins of the course's org.
+func (s *ForumService) canModerate(ctx context.Context, courseID, userID int64, isAdmin bool) bool {
+	if isAdmin {
+		return true
+	}
+
+	course, err := s.courseRepo.GetByID(ctx, courseID)
+	if err != nil {
+		return false
+	}
+	if course.CreatedBy == userID {
+		return true
+	}
+
+	if isCoTeacher, err := s.courseRepo.IsCoTeacher(ctx, courseID, userID); err == nil && isCoTeacher {
+		return true
+	}
+
+	return course.OrgID != 0 && s.isOrgAdmin(ctx, course.OrgID, userID)
+}
+
+func (s *ForumService) isOrgAdmin(ctx context.Context, orgID, userID int64) bool {
+	isMember, orgRole, err := s.orgRepo.IsMember(ctx, orgID, userID)
+	if err != nil || !isMember {
+		return false
+	}
+	return orgRole == models.OrgRoleOwner || orgRole == models.OrgRoleAdmin
+}
+
+// resolveTarget returns the course and author of a post or comment
+func (s *ForumService) resolveTarget(ctx context.Context, targetType string, targetID, currentUserID int64) (courseID, authorID int64, err error) {
+	postID := targetID
+	switch targetType {
+	case models.VotableTypePost:
+		post, err := s.forumRepo.GetPostByID(ctx, targetID, currentUserID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return 0, 0, fmt.Errorf("post not found")
+			}
+			return 0, 0, err
+		}
+		authorID = post.UserID
+	case models.VotableTypeComment:
+		comment, err := s.forumRepo.GetCommentByID(ctx, targetID, currentUserID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return 0, 0, fmt.Errorf("comment not found")
+			}
+			return 0, 0, err
+		}
+		authorID = comment.UserID
+		postID = comment.PostID
+	default:
+		return 0, 0, errors.New("invalid target type")
+	}
+
+	courseID, err = s.forumRepo.GetCourseIDByPostID(ctx, postID)
+	return courseID, authorID, err
+}
+
+func (s *ForumService) unhideIfAutoHidden(ctx context.Context, targetType string, targetID, currentUserID int64) error {
+	var hiddenReason sql.NullString
+	if targetType == models.VotableTypePost {
+		post, err := s.forumRepo.GetPostByID(ctx, targetID, currentUserID)
+		if err != nil {
+			return err
+		}
+		hiddenReason = post.HiddenReason
+	} else {
+		comment, err := s.forumRepo.GetCommentByID(ctx, targetID, currentUserID)
+		if err != nil {
+			return err
+		}
+		hiddenReason = comment.HiddenReason
+	}
+
+	if hiddenReason.String != models.HiddenReasonReports {
+		return nil
+	}
+	return s.forumRepo.SetHidden(ctx, targetType, targetID, false, "")
+}
+
+func reportQueueItemToResponse(item *models.ForumReportQueueItem) *dto.ForumReportQueueItemResponse {
+	resp := &dto.ForumReportQueueItemResponse{
+		TargetType:      item.TargetType,
+		TargetID:        item.TargetID,
+		PostID:          item.PostID,
+		CourseID:        item.CourseID,
+		CourseTitle:     item.CourseTitle,
+		ReportCount:     item.ReportCount,
+		Reasons:         item.Reasons,
+		FirstReportedAt: item.FirstReportedAt,
+		LastReportedAt:  item.LastReportedAt,
+		IsHidden:        item.IsHidden,
+		HiddenReason:    nullStringPtr(item.HiddenReason),
+		Excerpt:         item.Excerpt.String,
+		AuthorName:      item.AuthorName.String,
+		TargetDeleted:   !item.AuthorID.Valid,
+	}
+	if item.AuthorID.Valid {
+		resp.AuthorID = &item.AuthorID.Int64
+	}
+	return resp
+}
+
+func banToResponse(ban *models.ForumPostingBan) *dto.ForumBanResponse {
+	resp := &dto.ForumBanResponse{
+		ID:        ban.ID,
+		CourseID:  ban.CourseID,
+		UserID:    ban.UserID,
+		UserName:  ban.UserName,
+		Reason:    ban.Reason.String,
+		ExpiresAt: ban.ExpiresAt,
+		CreatedAt: ban.CreatedAt,
+	}
+	if ban.BannedBy.Valid {
+		resp.BannedBy = &ban.BannedBy.Int64
+	}
+	return resp
+}
+
+func nullStringPtr(ns sql.NullString) *string {
+	if !ns.Valid {
+		return nil
+	}
+	return &ns.String
+}
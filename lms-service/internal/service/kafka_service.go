@@ -1,15 +1,15 @@
 package service
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 
 	"example/hello/internal/models"
+	"example/hello/internal/repository"
 	"example/hello/pkg/kafka"
 )
 
-// KafkaService wraps Kafka publishing for learning events
+// KafkaService maps learning events onto their Kafka message format
 type KafkaService struct{}
 
 func NewKafkaService() *KafkaService {
@@ -35,10 +35,8 @@ type LearningEventMessage struct {
 	CreatedAt      string                 `json:"created_at"`
 }
 
-// PublishLearningEvent publishes a learning event to Kafka
-func (s *KafkaService) PublishLearningEvent(event *models.LearningEvent) error {
-	ctx := context.Background()
-
+// LearningEventOutboxMessage builds the outbox message for a saved learning event
+func (s *KafkaService) LearningEventOutboxMessage(event *models.LearningEvent) repository.OutboxMessage {
 	// Convert to Kafka message format
 	msg := LearningEventMessage{
 		EventID:   event.EventID,
@@ -97,13 +95,9 @@ func (s *KafkaService) PublishLearningEvent(event *models.LearningEvent) error {
 	}
 
 	// Kafka key: student_id for partitioning
-	key := []byte(fmt.Sprintf("%d", event.StudentID))
-
-	// Publish to Kafka topic
-	topic := "learning-events"
-	if err := kafka.PublishEvent(ctx, topic, key, msg); err != nil {
-		return fmt.Errorf("failed to publish learning event: %w", err)
+	return repository.OutboxMessage{
+		Topic:   kafka.TopicLearningEvents,
+		Key:     fmt.Sprintf("%d", event.StudentID),
+		Payload: msg,
 	}
-
-	return nil
 }
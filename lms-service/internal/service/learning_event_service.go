@@ -82,8 +82,13 @@ func (s *LearningEventService) TrackEvent(ctx context.Context, studentID int64,
 		}
 	}
 
-	// Save to database
-	if err := s.repo.CreateEvent(ctx, event); err != nil {
+	// Save to database. The Kafka copy goes through the outbox in the same
+	// transaction, so a broker outage delays analytics but never drops them.
+	var toMessage func(*models.LearningEvent) repository.OutboxMessage
+	if s.kafkaService != nil {
+		toMessage = s.kafkaService.LearningEventOutboxMessage
+	}
+	if err := s.repo.CreateEvent(ctx, event, toMessage); err != nil {
 		return nil, fmt.Errorf("failed to create event: %w", err)
 	}
 
@@ -97,17 +102,6 @@ func (s *LearningEventService) TrackEvent(ctx context.Context, studentID int64,
 		}
 	}
 
-	// Kafka is an optional downstream projection. The event itself is already
-	// committed above, so a transient broker outage must not lose student work.
-	if s.kafkaService != nil {
-		go func(event *models.LearningEvent) {
-			if err := s.kafkaService.PublishLearningEvent(event); err != nil {
-				// Log error but don't fail the request
-				fmt.Printf("Failed to publish learning event to Kafka: %v\n", err)
-			}
-		}(event)
-	}
-
 	return event, nil
 }
 
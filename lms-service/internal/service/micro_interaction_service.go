@@ -3,8 +3,8 @@
 // Owns the business logic for the Quick Action Panel analytics
 // pipeline:
 //
-//   * RecordInteraction  - synchronous: validate, persist raw row,
-//                           publish Kafka event for the worker.
+//   * RecordInteraction  - synchronous: validate, persist raw row
+//                           and its outbox Kafka event for the worker.
 //   * ApplyEvent         - asynchronous worker: convert an event into
 //                           a MasteryComponentDelta and upsert it.
 //   * Heatmap / StudentHeatmap - read-side helpers for the analytics
@@ -16,13 +16,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
-	"time"
 
 	"example/hello/internal/dto"
 	"example/hello/internal/models"
 	"example/hello/internal/repository"
 	"example/hello/pkg/kafka"
-	"example/hello/pkg/logger"
 )
 
 type MicroInteractionService struct {
@@ -55,8 +53,8 @@ var validActions = map[string]struct{}{
 }
 
 // RecordInteraction is the synchronous portion of the pipeline. It
-// validates input, writes a raw log row, and publishes a Kafka event
-// for the analytics worker. Returns the freshly-assigned interaction
+// validates input and writes a raw log row together with the outbox
+// Kafka event for the analytics worker. Returns the freshly-assigned interaction
 // id so the FE can correlate later events.
 func (s *MicroInteractionService) RecordInteraction(
 	ctx context.Context,
@@ -107,38 +105,29 @@ func (s *MicroInteractionService) RecordInteraction(
 		}
 	}
 
-	saved, err := s.repo.Insert(ctx, row)
+	// The analytics event is written to the outbox with the raw row, so
+	// the heatmap catches up even if Kafka is down when the student acts.
+	saved, err := s.repo.Insert(ctx, row, func(saved *models.MicroLessonInteraction) repository.OutboxMessage {
+		return repository.OutboxMessage{
+			Topic: kafka.TopicMicroInteractions,
+			Key:   strconv.FormatInt(req.CourseID, 10),
+			Payload: kafka.MicroInteractionEvent{
+				InteractionID: saved.ID,
+				UserID:        userID,
+				CourseID:      req.CourseID,
+				LessonID:      req.LessonID,
+				NodeID:        nodeID,
+				ActionType:    req.ActionType,
+				Score:         req.Score,
+				Status:        req.Status,
+				CreatedAt:     saved.CreatedAt,
+			},
+		}
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Fan-out async to the analytics worker. We deliberately ignore
-	// publish errors here: the raw row is already durable in
-	// Postgres, and a separate replay tool can backfill failed
-	// publishes. Keeping the endpoint hot-path responsive matters
-	// more than per-event delivery guarantees.
-	event := kafka.MicroInteractionEvent{
-		InteractionID: saved.ID,
-		UserID:        userID,
-		CourseID:      req.CourseID,
-		LessonID:      req.LessonID,
-		NodeID:        nodeID,
-		ActionType:    req.ActionType,
-		Score:         req.Score,
-		Status:        req.Status,
-		CreatedAt:     saved.CreatedAt,
-	}
-
-	go func() {
-		bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		key := []byte(strconv.FormatInt(req.CourseID, 10))
-		if err := kafka.PublishEvent(bgCtx, kafka.TopicMicroInteractions, key, event); err != nil {
-			logger.Error(fmt.Sprintf(
-				"publish micro-interaction event id=%d", saved.ID), err)
-		}
-	}()
-
 	return &dto.MicroInteractionResponse{
 		InteractionID: saved.ID,
 		AcceptedAt:    saved.CreatedAt,
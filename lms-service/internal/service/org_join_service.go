@@ -0,0 +1,436 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/models"
+	"example/hello/internal/repository"
+	"example/hello/pkg/kafka"
+	"example/hello/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrInvitationNotFound is returned for an unknown invitation token
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvitationEmailMismatch is returned when an invitation is accepted
+	// by a user whose email is not the one invited
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to a different email address")
+	// ErrJoinCodeNotFound is returned for an unknown join code
+	ErrJoinCodeNotFound = errors.New("join code not found")
+	// ErrOrgInactive is returned when joining a deactivated organization
+	ErrOrgInactive = errors.New("organization is not active")
+	// ErrOwnerNotJoinable is returned when an invitation, join code or email
+	// domain would grant OWNER; owners are made through the member API,
+	// where ErrOwnerRequired applies
+	ErrOwnerNotJoinable = errors.New("the owner role cannot be granted by invitation, join code or email domain")
+)
+
+// joinCodeAlphabet leaves out characters that are easily confused when a
+// code is read aloud or copied from a slide (0/O, 1/I/L)
+const joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+const joinCodeLength = 8
+
+// OrgInvitationConfig configures invitation emails
+type OrgInvitationConfig struct {
+	TTL time.Duration
+	// AcceptURL is the frontend page the email links to; the token is
+	// added as the "token" query parameter
+	AcceptURL string
+}
+
+// OrgJoinService lets users join organizations without an admin adding them:
+// by email invitation, join code or, on first login, by email domain. Every
+// join respects the organization's member limit and is reported to the auth
+// service through the outbox.
+type OrgJoinService struct {
+	orgs       *OrganizationService
+	orgRepo    *repository.OrganizationRepository
+	inviteRepo *repository.OrgInvitationRepository
+	userRepo   *repository.UserRepository
+	cfg        OrgInvitationConfig
+}
+
+func NewOrgJoinService(
+	orgs *OrganizationService,
+	orgRepo *repository.OrganizationRepository,
+	inviteRepo *repository.OrgInvitationRepository,
+	userRepo *repository.UserRepository,
+	cfg OrgInvitationConfig,
+) *OrgJoinService {
+	return &OrgJoinService{
+		orgs:       orgs,
+		orgRepo:    orgRepo,
+		inviteRepo: inviteRepo,
+		userRepo:   userRepo,
+		cfg:        cfg,
+	}
+}
+
+// Invite creates an invitation and queues the email carrying its token. An
+// open invitation to the same address is revoked.
+func (s *OrgJoinService) Invite(ctx context.Context, orgID int64, req *dto.CreateInvitationRequest, actorID int64) (*dto.InvitationResponse, error) {
+	org, err := s.activeOrg(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.joinRole(ctx, orgID, req.OrgRole)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+	inv := &models.OrgInvitation{
+		OrgID:     orgID,
+		Email:     strings.ToLower(strings.TrimSpace(req.Email)),
+		OrgRole:   role,
+		TokenHash: hashInvitationToken(token),
+		InvitedBy: sql.NullInt64{Int64: actorID, Valid: actorID > 0},
+		ExpiresAt: time.Now().Add(s.cfg.TTL),
+	}
+
+	var inviterName string
+	if inviter, err := s.userRepo.GetByID(ctx, actorID); err == nil {
+		inviterName = inviter.FullName
+	}
+	acceptURL := s.acceptURL(token)
+
+	err = s.inviteRepo.CreateInvitation(ctx, inv, func(saved *models.OrgInvitation) repository.OutboxMessage {
+		return repository.OutboxMessage{
+			Topic: kafka.TopicOrgInvitation,
+			Key:   strconv.FormatInt(orgID, 10),
+			Payload: kafka.OrgInvitationEvent{
+				EventID:      uuid.NewString(),
+				InvitationID: saved.ID,
+				OrgID:        orgID,
+				OrgName:      org.Name,
+				Email:        saved.Email,
+				OrgRole:      saved.OrgRole,
+				InviterName:  inviterName,
+				AcceptURL:    acceptURL,
+				ExpiresAt:    saved.ExpiresAt.UTC(),
+			},
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+	return toInvitationResponse(inv, time.Now()), nil
+}
+
+func (s *OrgJoinService) ListInvitations(ctx context.Context, orgID int64, limit, offset int) ([]*dto.InvitationResponse, int, error) {
+	invs, total, err := s.inviteRepo.ListInvitations(ctx, orgID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	resps := make([]*dto.InvitationResponse, len(invs))
+	for i, inv := range invs {
+		resps[i] = toInvitationResponse(inv, now)
+	}
+	return resps, total, nil
+}
+
+// RevokeInvitation revokes an open invitation; sql.ErrNoRows if there is none
+func (s *OrgJoinService) RevokeInvitation(ctx context.Context, orgID, invitationID int64) error {
+	return s.inviteRepo.RevokeInvitation(ctx, orgID, invitationID)
+}
+
+// AcceptInvitation adds the user to the organization the token invites them
+// to. The user's email must be the invited address.
+func (s *OrgJoinService) AcceptInvitation(ctx context.Context, token string, userID int64) (*dto.OrgResponse, error) {
+	inv, err := s.inviteRepo.GetInvitationByTokenHash(ctx, hashInvitationToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	if inv.Status(time.Now()) != models.InvitationPending {
+		return nil, repository.ErrInvitationUnavailable
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(strings.TrimSpace(user.Email), inv.Email) {
+		return nil, ErrInvitationEmailMismatch
+	}
+	org, err := s.activeOrg(ctx, inv.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.inviteRepo.AcceptInvitation(ctx, inv, userID,
+		memberEvent(inv.OrgID, userID, inv.OrgRole, models.OrgJoinSourceInvitation, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	s.joined(ctx, inv.OrgID, userID, inv.OrgRole, models.OrgJoinSourceInvitation)
+	return s.orgs.toOrgResponse(org), nil
+}
+
+// CreateJoinCode creates a join code for the organization
+func (s *OrgJoinService) CreateJoinCode(ctx context.Context, orgID int64, req *dto.CreateJoinCodeRequest, actorID int64) (*dto.JoinCodeResponse, error) {
+	if _, err := s.activeOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	role, err := s.joinRole(ctx, orgID, req.OrgRole)
+	if err != nil {
+		return nil, err
+	}
+
+	code := &models.OrgJoinCode{
+		OrgID:     orgID,
+		OrgRole:   role,
+		CreatedBy: sql.NullInt64{Int64: actorID, Valid: actorID > 0},
+	}
+	if req.MaxUses != nil {
+		code.MaxUses = sql.NullInt32{Int32: int32(*req.MaxUses), Valid: true}
+	}
+	if req.ExpiresInHours != nil {
+		code.ExpiresAt = sql.NullTime{Time: time.Now().Add(time.Duration(*req.ExpiresInHours) * time.Hour), Valid: true}
+	}
+
+	// Codes are short, so a clash with an existing one is possible; draw again
+	for attempt := 0; ; attempt++ {
+		if code.Code, err = newJoinCode(); err != nil {
+			return nil, err
+		}
+		err = s.inviteRepo.CreateJoinCode(ctx, code)
+		var pqErr *pq.Error
+		if err == nil || attempt == 4 || !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create join code: %w", err)
+	}
+	return toJoinCodeResponse(code, time.Now()), nil
+}
+
+func (s *OrgJoinService) ListJoinCodes(ctx context.Context, orgID int64) ([]*dto.JoinCodeResponse, error) {
+	codes, err := s.inviteRepo.ListJoinCodes(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	resps := make([]*dto.JoinCodeResponse, len(codes))
+	for i, code := range codes {
+		resps[i] = toJoinCodeResponse(code, now)
+	}
+	return resps, nil
+}
+
+// RevokeJoinCode revokes a join code; sql.ErrNoRows if there is none
+func (s *OrgJoinService) RevokeJoinCode(ctx context.Context, orgID, codeID int64) error {
+	return s.inviteRepo.RevokeJoinCode(ctx, orgID, codeID)
+}
+
+// RedeemJoinCode adds the user to the organization the code belongs to
+func (s *OrgJoinService) RedeemJoinCode(ctx context.Context, code string, userID int64) (*dto.OrgResponse, error) {
+	joinCode, err := s.inviteRepo.GetJoinCode(ctx, normalizeJoinCode(code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrJoinCodeNotFound
+		}
+		return nil, err
+	}
+	if !joinCode.Usable(time.Now()) {
+		return nil, repository.ErrJoinCodeUnavailable
+	}
+	org, err := s.activeOrg(ctx, joinCode.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.inviteRepo.RedeemJoinCode(ctx, joinCode, userID,
+		memberEvent(joinCode.OrgID, userID, joinCode.OrgRole, models.OrgJoinSourceJoinCode, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	s.joined(ctx, joinCode.OrgID, userID, joinCode.OrgRole, models.OrgJoinSourceJoinCode)
+	return s.orgs.toOrgResponse(org), nil
+}
+
+// JoinByEmailDomain adds a newly seen user to every active organization
+// that lists their email domain. It is best effort: a failure for one
+// organization (e.g. a full one) is logged and the others still apply.
+func (s *OrgJoinService) JoinByEmailDomain(ctx context.Context, userID int64, email string) {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return
+	}
+	domain := normalizeEmailDomain(email[at+1:])
+	if domain == "" {
+		return
+	}
+
+	orgs, err := s.orgRepo.FindActiveByEmailDomain(ctx, domain)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to look up organizations for email domain %s", domain), err)
+		return
+	}
+	for _, org := range orgs {
+		role := models.OrgRoleMember
+		var settings models.OrgSettings
+		if err := json.Unmarshal(org.Settings, &settings); err == nil && settings.DomainJoinRole != "" {
+			role = settings.DomainJoinRole
+		}
+
+		joined, err := s.orgRepo.JoinMember(ctx, org.ID, userID, role,
+			memberEvent(org.ID, userID, role, models.OrgJoinSourceEmailDomain, 0))
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to auto-join user %d to organization %d by email domain", userID, org.ID), err)
+			continue
+		}
+		if joined {
+			s.joined(ctx, org.ID, userID, role, models.OrgJoinSourceEmailDomain)
+		}
+	}
+}
+
+// activeOrg loads an organization that members can still join
+func (s *OrgJoinService) activeOrg(ctx context.Context, orgID int64) (*models.Organization, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !org.IsActive {
+		return nil, ErrOrgInactive
+	}
+	return org, nil
+}
+
+// joinRole resolves the role an invitation or code grants, MEMBER by default
+func (s *OrgJoinService) joinRole(ctx context.Context, orgID int64, role string) (string, error) {
+	if strings.TrimSpace(role) == "" {
+		return models.OrgRoleMember, nil
+	}
+	role, err := s.orgs.resolveOrgRole(ctx, orgID, role)
+	if err == nil && role == models.OrgRoleOwner {
+		return "", ErrOwnerNotJoinable
+	}
+	return role, err
+}
+
+// joined clears the caches a new membership affects and audits it
+func (s *OrgJoinService) joined(ctx context.Context, orgID, userID int64, role, source string) {
+	s.orgs.invalidateUserOrgsCache(ctx, userID)
+	_ = s.orgs.redisCache.Delete(ctx, fmt.Sprintf("org_stats:%d", orgID))
+	s.orgs.audit.Record(ctx, AuditRecord{
+		Action:     models.AuditOrgMemberAdd,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID,
+		OrgID:      orgID,
+		After:      map[string]string{"org_role": role, "source": source},
+	})
+}
+
+func (s *OrgJoinService) acceptURL(token string) string {
+	u, err := url.Parse(s.cfg.AcceptURL)
+	if err != nil {
+		return s.cfg.AcceptURL + "?token=" + token
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// newInvitationToken returns 32 random bytes, hex encoded
+func newInvitationToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashInvitationToken is what is stored and looked up; the token itself
+// only exists in the email
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(token))))
+	return hex.EncodeToString(sum[:])
+}
+
+func newJoinCode() (string, error) {
+	b := make([]byte, joinCodeLength)
+	max := big.NewInt(int64(len(joinCodeAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = joinCodeAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// normalizeJoinCode accepts codes typed in lower case or with the dashes
+// and spaces people add when copying them
+func normalizeJoinCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func toInvitationResponse(inv *models.OrgInvitation, now time.Time) *dto.InvitationResponse {
+	resp := &dto.InvitationResponse{
+		ID:        inv.ID,
+		OrgID:     inv.OrgID,
+		Email:     inv.Email,
+		OrgRole:   inv.OrgRole,
+		Status:    inv.Status(now),
+		ExpiresAt: inv.ExpiresAt,
+		CreatedAt: inv.CreatedAt,
+	}
+	if inv.InvitedBy.Valid {
+		resp.InvitedBy = &inv.InvitedBy.Int64
+	}
+	if inv.AcceptedAt.Valid {
+		resp.AcceptedAt = &inv.AcceptedAt.Time
+	}
+	return resp
+}
+
+func toJoinCodeResponse(code *models.OrgJoinCode, now time.Time) *dto.JoinCodeResponse {
+	resp := &dto.JoinCodeResponse{
+		ID:        code.ID,
+		OrgID:     code.OrgID,
+		Code:      code.Code,
+		OrgRole:   code.OrgRole,
+		UseCount:  code.UseCount,
+		Usable:    code.Usable(now),
+		CreatedAt: code.CreatedAt,
+	}
+	if code.MaxUses.Valid {
+		n := int(code.MaxUses.Int32)
+		resp.MaxUses = &n
+	}
+	if code.ExpiresAt.Valid {
+		resp.ExpiresAt = &code.ExpiresAt.Time
+	}
+	return resp
+}
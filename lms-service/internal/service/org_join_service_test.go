@@ -0,0 +1,81 @@
+package service
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"example/hello/internal/models"
+)
+
+func TestInvitationTokenIsStoredOnlyAsHash(t *testing.T) {
+	token, err := newInvitationToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != 64 {
+		t.Fatalf("token length = %d, want 64", len(token))
+	}
+
+	hash := hashInvitationToken(token)
+	if hash == token || len(hash) != 64 {
+		t.Errorf("hash %q is not a SHA-256 of the token", hash)
+	}
+	// A token copied from the email in upper case still matches
+	if hashInvitationToken(" "+strings.ToUpper(token)+" ") != hash {
+		t.Error("hash depends on the token's case or surrounding spaces")
+	}
+}
+
+func TestJoinCodesUseOnlyUnambiguousCharacters(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		code, err := newJoinCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != joinCodeLength {
+			t.Fatalf("code %q has length %d", code, len(code))
+		}
+		if strings.Trim(code, joinCodeAlphabet) != "" {
+			t.Fatalf("code %q uses a character outside the alphabet", code)
+		}
+	}
+	if got := normalizeJoinCode("abcd-ef 23"); got != "ABCDEF23" {
+		t.Errorf("normalizeJoinCode = %q", got)
+	}
+}
+
+func TestInvitationStatusAndJoinCodeUsable(t *testing.T) {
+	now := time.Now()
+	inv := &models.OrgInvitation{ExpiresAt: now.Add(time.Hour)}
+	if got := inv.Status(now); got != models.InvitationPending {
+		t.Errorf("open invitation: %s", got)
+	}
+	if got := inv.Status(now.Add(2 * time.Hour)); got != models.InvitationExpired {
+		t.Errorf("past expiry: %s", got)
+	}
+	inv.RevokedAt = sql.NullTime{Time: now, Valid: true}
+	if got := inv.Status(now); got != models.InvitationRevoked {
+		t.Errorf("revoked: %s", got)
+	}
+
+	code := &models.OrgJoinCode{MaxUses: sql.NullInt32{Int32: 2, Valid: true}, UseCount: 1}
+	if !code.Usable(now) {
+		t.Error("code with a use left is not usable")
+	}
+	code.UseCount = 2
+	if code.Usable(now) {
+		t.Error("used-up code is usable")
+	}
+	code = &models.OrgJoinCode{ExpiresAt: sql.NullTime{Time: now, Valid: true}}
+	if code.Usable(now) {
+		t.Error("expired code is usable")
+	}
+}
+
+func TestNormalizeEmailDomain(t *testing.T) {
+	if got := normalizeEmailDomain(" @Example.EDU.vn "); got != "example.edu.vn" {
+		t.Errorf("normalizeEmailDomain = %q", got)
+	}
+}
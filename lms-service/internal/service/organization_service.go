@@ -7,34 +7,54 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"example/hello/internal/dto"
 	"example/hello/internal/models"
 	"example/hello/internal/repository"
+	"example/hello/pkg/authsync"
 	"example/hello/pkg/cache"
 	"example/hello/pkg/logger"
+
+	"github.com/google/uuid"
 )
 
 type OrganizationService struct {
 	orgRepo    *repository.OrganizationRepository
 	userRepo   *repository.UserRepository
 	redisCache *cache.RedisCache
+	audit      *AuditService
+	perms      *PermissionService
 }
 
 func NewOrganizationService(
 	orgRepo *repository.OrganizationRepository,
 	userRepo *repository.UserRepository,
 	redisCache *cache.RedisCache,
+	audit *AuditService,
+	perms *PermissionService,
 ) *OrganizationService {
 	return &OrganizationService{
 		orgRepo:    orgRepo,
 		userRepo:   userRepo,
 		redisCache: redisCache,
+		audit:      audit,
+		perms:      perms,
 	}
 }
 
+// ErrUnknownOrgRole is returned when a member would be given an org_role that
+// is neither a template nor one of the organization's own roles
+var ErrUnknownOrgRole = errors.New("unknown organization role")
+
+// ErrOwnerRequired is returned when an actor who is neither a system admin
+// nor an owner of the organization would make someone an owner, or change
+// or remove an owner. ORG_MEMBER_MANAGE alone, which custom org roles can
+// hold, is not enough.
+var ErrOwnerRequired = errors.New("only an owner can grant the owner role or change an owner")
+
 var slugRegex = regexp.MustCompile("^[a-z0-9-_]+$")
 var emailParserRegex = regexp.MustCompile(`(?i)[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}`)
 
@@ -62,11 +82,12 @@ func (s *OrganizationService) CreateOrganization(ctx context.Context, req *dto.C
 	// Build settings JSON for DB storage
 	var settingsBytes []byte
 	if req.Settings != nil {
-		settingsBytes, _ = json.Marshal(models.OrgSettings{
-			AllowCrossOrgCourses:    req.Settings.AllowCrossOrgCourses,
-			DefaultCourseVisibility: req.Settings.DefaultCourseVisibility,
-			AllowSelfEnrollment:     req.Settings.AllowSelfEnrollment,
-		})
+		// No organization yet: only template roles can join by domain
+		settings, err := s.orgSettings(ctx, 0, req.Settings)
+		if err != nil {
+			return nil, err
+		}
+		settingsBytes, _ = json.Marshal(settings)
 	}
 	if len(settingsBytes) == 0 {
 		defaultSettings := models.OrgSettings{
@@ -143,7 +164,7 @@ func (s *OrganizationService) ListOrganizations(ctx context.Context, limit, offs
 
 func (s *OrganizationService) UpdateOrganization(ctx context.Context, id int64, req *dto.UpdateOrgRequest, actorID int64, sysRole string) (*dto.OrgResponse, error) {
 	// Check access: must be Super Admin or Org Admin/Owner
-	hasAccess, err := s.checkOrgAccess(ctx, id, actorID, sysRole, models.OrgRoleAdmin)
+	hasAccess, err := s.checkOrgAccess(ctx, id, actorID, sysRole, "ORG_MANAGE")
 	if err != nil {
 		return nil, err
 	}
@@ -179,10 +200,9 @@ func (s *OrganizationService) UpdateOrganization(ctx context.Context, id int64,
 		org.LogoURL = sql.NullString{String: *req.LogoURL, Valid: *req.LogoURL != ""}
 	}
 	if req.Settings != nil {
-		updatedSettings := models.OrgSettings{
-			AllowCrossOrgCourses:    req.Settings.AllowCrossOrgCourses,
-			DefaultCourseVisibility: req.Settings.DefaultCourseVisibility,
-			AllowSelfEnrollment:     req.Settings.AllowSelfEnrollment,
+		updatedSettings, err := s.orgSettings(ctx, id, req.Settings)
+		if err != nil {
+			return nil, err
 		}
 		if b, err := json.Marshal(updatedSettings); err == nil {
 			org.Settings = b
@@ -224,13 +244,16 @@ func (s *OrganizationService) DeactivateOrganization(ctx context.Context, id int
 }
 
 func (s *OrganizationService) AddMember(ctx context.Context, orgID int64, req *dto.AddMemberRequest, actorID int64, sysRole string) error {
-	hasAccess, err := s.checkOrgAccess(ctx, orgID, actorID, sysRole, models.OrgRoleAdmin)
+	hasAccess, err := s.checkOrgAccess(ctx, orgID, actorID, sysRole, "ORG_MEMBER_MANAGE")
 	if err != nil {
 		return err
 	}
 	if !hasAccess {
 		return errors.New("unauthorized to manage members of this organization")
 	}
+	if req.OrgRole, err = s.resolveOrgRole(ctx, orgID, req.OrgRole); err != nil {
+		return err
+	}
 
 	// Verify user exists
 	_, err = s.userRepo.GetByID(ctx, req.UserID)
@@ -241,17 +264,27 @@ func (s *OrganizationService) AddMember(ctx context.Context, orgID int64, req *d
 		return err
 	}
 
-	err = s.orgRepo.AddMember(ctx, orgID, req.UserID, req.OrgRole)
+	_, previousRole, err := s.orgRepo.IsMember(ctx, orgID, req.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkOwnerChange(ctx, orgID, actorID, sysRole, previousRole, req.OrgRole); err != nil {
+		return err
+	}
+
+	err = s.orgRepo.AddMembersBulk(ctx, orgID, []int64{req.UserID}, req.OrgRole,
+		memberEvent(orgID, req.UserID, req.OrgRole, models.OrgJoinSourceAdmin, actorID))
 	if err != nil {
 		return err
 	}
 
 	s.invalidateUserOrgsCache(ctx, req.UserID)
+	s.recordMemberChange(ctx, models.AuditOrgMemberAdd, orgID, req.UserID, previousRole, req.OrgRole)
 	return nil
 }
 
 func (s *OrganizationService) RemoveMember(ctx context.Context, orgID int64, userID int64, actorID int64, sysRole string) error {
-	// Actor must be Admin/Owner OR the user themselves leaving the org
+	// Actor must hold ORG_MEMBER_MANAGE OR be the user themselves leaving the org
 	isSelf := actorID == userID
 	var hasAccess bool
 	var err error
@@ -259,7 +292,7 @@ func (s *OrganizationService) RemoveMember(ctx context.Context, orgID int64, use
 	if isSelf {
 		hasAccess = true
 	} else {
-		hasAccess, err = s.checkOrgAccess(ctx, orgID, actorID, sysRole, models.OrgRoleAdmin)
+		hasAccess, err = s.checkOrgAccess(ctx, orgID, actorID, sysRole, "ORG_MEMBER_MANAGE")
 		if err != nil {
 			return err
 		}
@@ -282,6 +315,12 @@ func (s *OrganizationService) RemoveMember(ctx context.Context, orgID int64, use
 		return err
 	}
 
+	if !isSelf {
+		if err := s.checkOwnerChange(ctx, orgID, actorID, sysRole, currentRole, ""); err != nil {
+			return err
+		}
+	}
+
 	if currentRole == models.OrgRoleOwner {
 		members, _, err := s.orgRepo.ListMembers(ctx, orgID, 100, 0)
 		if err == nil {
@@ -297,23 +336,28 @@ func (s *OrganizationService) RemoveMember(ctx context.Context, orgID int64, use
 		}
 	}
 
-	err = s.orgRepo.RemoveMember(ctx, orgID, userID)
+	err = s.orgRepo.RemoveMember(ctx, orgID, userID,
+		memberEvent(orgID, userID, "", models.OrgJoinSourceAdmin, actorID))
 	if err != nil {
 		return err
 	}
 
 	s.invalidateUserOrgsCache(ctx, userID)
+	s.recordMemberChange(ctx, models.AuditOrgMemberRemove, orgID, userID, currentRole, "")
 	return nil
 }
 
 func (s *OrganizationService) UpdateMemberRole(ctx context.Context, orgID int64, userID int64, req *dto.UpdateMemberRoleRequest, actorID int64, sysRole string) error {
-	hasAccess, err := s.checkOrgAccess(ctx, orgID, actorID, sysRole, models.OrgRoleAdmin)
+	hasAccess, err := s.checkOrgAccess(ctx, orgID, actorID, sysRole, "ORG_MEMBER_MANAGE")
 	if err != nil {
 		return err
 	}
 	if !hasAccess {
 		return errors.New("unauthorized to update roles in this organization")
 	}
+	if req.OrgRole, err = s.resolveOrgRole(ctx, orgID, req.OrgRole); err != nil {
+		return err
+	}
 
 	// Get member current role
 	isMember, currentRole, err := s.orgRepo.IsMember(ctx, orgID, userID)
@@ -323,6 +367,9 @@ func (s *OrganizationService) UpdateMemberRole(ctx context.Context, orgID int64,
 	if !isMember {
 		return errors.New("user is not a member of this organization")
 	}
+	if err := s.checkOwnerChange(ctx, orgID, actorID, sysRole, currentRole, req.OrgRole); err != nil {
+		return err
+	}
 
 	// If changing from Owner, check last owner constraint
 	if currentRole == models.OrgRoleOwner && req.OrgRole != models.OrgRoleOwner {
@@ -340,15 +387,47 @@ func (s *OrganizationService) UpdateMemberRole(ctx context.Context, orgID int64,
 		}
 	}
 
-	err = s.orgRepo.UpdateMemberRole(ctx, orgID, userID, req.OrgRole)
+	err = s.orgRepo.UpdateMemberRole(ctx, orgID, userID, req.OrgRole,
+		memberEvent(orgID, userID, req.OrgRole, models.OrgJoinSourceAdmin, actorID))
 	if err != nil {
 		return err
 	}
 
 	s.invalidateUserOrgsCache(ctx, userID)
+	s.recordMemberChange(ctx, models.AuditOrgMemberRoleChange, orgID, userID, currentRole, req.OrgRole)
 	return nil
 }
 
+// recordMemberChange audits a membership change; an empty role means "not a
+// member"
+func (s *OrganizationService) recordMemberChange(ctx context.Context, action string, orgID, userID int64, beforeRole, afterRole string) {
+	rec := AuditRecord{Action: action, TargetType: models.AuditTargetUser, TargetID: userID, OrgID: orgID}
+	if beforeRole != "" {
+		rec.Before = map[string]string{"org_role": beforeRole}
+	}
+	if afterRole != "" {
+		rec.After = map[string]string{"org_role": afterRole}
+	}
+	s.audit.Record(ctx, rec)
+}
+
+// memberEvent reports a membership change back to the auth service through
+// the outbox; an empty role means the user left the organization
+func memberEvent(orgID, userID int64, role, source string, actorID int64) repository.OutboxMessage {
+	eventType := authsync.MemberUpserted
+	if role == "" {
+		eventType = authsync.MemberRemoved
+	}
+	return repository.OutboxMessage{
+		Topic: authsync.TopicLMSMemberEvents,
+		Key:   strconv.FormatInt(orgID, 10),
+		Payload: authsync.MemberChangeEvent{
+			EventID: uuid.NewString(), Type: eventType, OrgID: orgID, UserID: userID,
+			OrgRole: role, Source: source, ActorID: actorID, OccurredAt: time.Now().UTC(),
+		},
+	}
+}
+
 func (s *OrganizationService) ListMembers(ctx context.Context, orgID int64, limit, offset int) ([]*dto.OrgMemberResponse, int, error) {
 	members, total, err := s.orgRepo.ListMembers(ctx, orgID, limit, offset)
 	if err != nil {
@@ -475,30 +554,88 @@ func (s *OrganizationService) GetOrgStats(ctx context.Context, orgID int64) (*dt
 	return resp, nil
 }
 
-// Check if actor belongs to the org with at least the requiredRole (Owner > Admin > Member)
-func (s *OrganizationService) checkOrgAccess(ctx context.Context, orgID int64, actorID int64, sysRole string, requiredRole string) (bool, error) {
+// checkOrgAccess reports whether the actor holds the permission code in the
+// org, through their global role or the role they hold as a member
+func (s *OrganizationService) checkOrgAccess(ctx context.Context, orgID int64, actorID int64, sysRole string, code string) (bool, error) {
 	// Super Admin always has full access
 	if sysRole == models.RoleAdmin {
 		return true, nil
 	}
+	return s.perms.CheckOrgPermission(ctx, actorID, sysRole, orgID, code)
+}
+
+// resolveOrgRole normalises an org_role name and checks the org can use it
+func (s *OrganizationService) resolveOrgRole(ctx context.Context, orgID int64, role string) (string, error) {
+	role = strings.ToUpper(strings.TrimSpace(role))
+	exists, err := s.perms.OrgRoleExists(ctx, orgID, role)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", fmt.Errorf("%w: %s", ErrUnknownOrgRole, role)
+	}
+	return role, nil
+}
 
-	isMember, role, err := s.orgRepo.IsMember(ctx, orgID, actorID)
+// checkOwnerChange returns ErrOwnerRequired when a member moving from
+// fromRole to toRole gains or loses OWNER and the actor is neither a system
+// admin nor an owner. An empty role means "not a member".
+func (s *OrganizationService) checkOwnerChange(ctx context.Context, orgID, actorID int64, sysRole, fromRole, toRole string) error {
+	if !touchesOwner(fromRole, toRole) || sysRole == models.RoleAdmin {
+		return nil
+	}
+	_, actorRole, err := s.orgRepo.IsMember(ctx, orgID, actorID)
 	if err != nil {
-		return false, err
+		return err
 	}
-	if !isMember {
-		return false, nil
+	if actorRole != models.OrgRoleOwner {
+		return ErrOwnerRequired
+	}
+	return nil
+}
+
+// touchesOwner reports whether a member moving from fromRole to toRole
+// gains or loses OWNER, or is re-added as one
+func touchesOwner(fromRole, toRole string) bool {
+	return fromRole == models.OrgRoleOwner || toRole == models.OrgRoleOwner
+}
+
+// orgSettings converts request settings for storage, normalising the
+// auto-join email domains and checking the role they join with
+func (s *OrganizationService) orgSettings(ctx context.Context, orgID int64, req *dto.OrgSettingsDTO) (models.OrgSettings, error) {
+	settings := models.OrgSettings{
+		AllowCrossOrgCourses:    req.AllowCrossOrgCourses,
+		DefaultCourseVisibility: req.DefaultCourseVisibility,
+		AllowSelfEnrollment:     req.AllowSelfEnrollment,
+	}
+	if req.MaxMembers != nil {
+		settings.MaxMembers = *req.MaxMembers
 	}
 
-	// Role ranking evaluation
-	switch requiredRole {
-	case models.OrgRoleOwner:
-		return role == models.OrgRoleOwner, nil
-	case models.OrgRoleAdmin:
-		return role == models.OrgRoleOwner || role == models.OrgRoleAdmin, nil
-	default:
-		return true, nil // MEMBER role or any
+	seen := make(map[string]bool)
+	for _, domain := range req.AllowedEmailDomains {
+		domain = normalizeEmailDomain(domain)
+		if domain != "" && !seen[domain] {
+			seen[domain] = true
+			settings.AllowedEmailDomains = append(settings.AllowedEmailDomains, domain)
+		}
 	}
+	if req.DomainJoinRole != "" {
+		role, err := s.resolveOrgRole(ctx, orgID, req.DomainJoinRole)
+		if err != nil {
+			return settings, err
+		}
+		if role == models.OrgRoleOwner {
+			return settings, ErrOwnerNotJoinable
+		}
+		settings.DomainJoinRole = role
+	}
+	return settings, nil
+}
+
+// normalizeEmailDomain lowercases a domain and strips a leading "@"
+func normalizeEmailDomain(domain string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "@")
 }
 
 func (s *OrganizationService) invalidateUserOrgsCache(ctx context.Context, userID int64) {
@@ -554,6 +691,8 @@ func (s *OrganizationService) toOrgResponse(org *models.Organization) *dto.OrgRe
 				m := ms.MaxMembers
 				settingsDTO.MaxMembers = &m
 			}
+			settingsDTO.AllowedEmailDomains = ms.AllowedEmailDomains
+			settingsDTO.DomainJoinRole = ms.DomainJoinRole
 		}
 	}
 
@@ -573,14 +712,17 @@ func (s *OrganizationService) toOrgResponse(org *models.Organization) *dto.OrgRe
 
 // BulkAddMembers adds multiple members to an organization by email, parsing them intelligently
 func (s *OrganizationService) BulkAddMembers(ctx context.Context, orgID int64, req *dto.BulkAddMembersRequest, actorID int64, sysRole string) (*dto.BulkAddMembersResponse, error) {
-	// 1. Check access: actor must be Org Admin/Owner or Super Admin
-	hasAccess, err := s.checkOrgAccess(ctx, orgID, actorID, sysRole, models.OrgRoleAdmin)
+	// 1. Check access: actor must hold ORG_MEMBER_MANAGE in the org or be Super Admin
+	hasAccess, err := s.checkOrgAccess(ctx, orgID, actorID, sysRole, "ORG_MEMBER_MANAGE")
 	if err != nil {
 		return nil, err
 	}
 	if !hasAccess {
 		return nil, errors.New("unauthorized to manage members of this organization")
 	}
+	if req.OrgRole, err = s.resolveOrgRole(ctx, orgID, req.OrgRole); err != nil {
+		return nil, err
+	}
 
 	// 2. Extract and parse emails
 	emailMap := make(map[string]bool)
@@ -633,9 +775,27 @@ func (s *OrganizationService) BulkAddMembers(ctx context.Context, orgID int64, r
 		}
 	}
 
-	// 5. Bulk insert member records in a single query
+	// 5. Bulk insert member records in a single query. Existing members
+	// take the new role, so adding owners here changes them.
 	if len(userIDs) > 0 {
-		err = s.orgRepo.AddMembersBulk(ctx, orgID, userIDs, req.OrgRole)
+		previousRoles, err := s.orgRepo.MemberRoles(ctx, orgID, userIDs)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkOwnerChange(ctx, orgID, actorID, sysRole, "", req.OrgRole); err != nil {
+			return nil, err
+		}
+		for _, role := range previousRoles {
+			if err := s.checkOwnerChange(ctx, orgID, actorID, sysRole, role, req.OrgRole); err != nil {
+				return nil, err
+			}
+		}
+
+		events := make([]repository.OutboxMessage, len(userIDs))
+		for i, id := range userIDs {
+			events[i] = memberEvent(orgID, id, req.OrgRole, models.OrgJoinSourceAdmin, actorID)
+		}
+		err = s.orgRepo.AddMembersBulk(ctx, orgID, userIDs, req.OrgRole, events...)
 		if err != nil {
 			return nil, err
 		}
@@ -643,6 +803,7 @@ func (s *OrganizationService) BulkAddMembers(ctx context.Context, orgID int64, r
 		// 6. Invalidate caches for all added users and org stats
 		for _, u := range existingUsers {
 			s.invalidateUserOrgsCache(ctx, u.ID)
+			s.recordMemberChange(ctx, models.AuditOrgMemberAdd, orgID, u.ID, previousRoles[u.ID], req.OrgRole)
 		}
 		
 		statsKey := fmt.Sprintf("org_stats:%d", orgID)
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"testing"
 )
 
@@ -35,3 +36,35 @@ func TestEmailParserRegex_ExtractEmails_WithVariousSeparators(t *testing.T) {
 		}
 	}
 }
+
+func TestTouchesOwner(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{"", "MEMBER", false},
+		{"MEMBER", "ADMIN", false},
+		{"MEMBER", "CUSTOM_MANAGER", false},
+		{"", "OWNER", true},
+		{"MEMBER", "OWNER", true},
+		{"OWNER", "ADMIN", true},
+		{"OWNER", "", true},
+		{"OWNER", "OWNER", true},
+	}
+	for _, tc := range cases {
+		if got := touchesOwner(tc.from, tc.to); got != tc.want {
+			t.Errorf("touchesOwner(%q, %q) = %v; want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestCheckOwnerChangeSkipsLookupWhenNotNeeded(t *testing.T) {
+	// No repository: these cases must be decided without asking who the actor is
+	s := &OrganizationService{}
+	if err := s.checkOwnerChange(context.Background(), 1, 2, "TEACHER", "MEMBER", "CUSTOM_MANAGER"); err != nil {
+		t.Errorf("non-owner change: err = %v", err)
+	}
+	if err := s.checkOwnerChange(context.Background(), 1, 2, "ADMIN", "MEMBER", "OWNER"); err != nil {
+		t.Errorf("system admin granting OWNER: err = %v", err)
+	}
+}
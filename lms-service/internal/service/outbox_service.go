@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/models"
+	"example/hello/internal/repository"
+	"example/hello/pkg/kafka"
+	"example/hello/pkg/logger"
+)
+
+// outboxPublishTimeout bounds a single broker round trip so a hung broker
+// cannot hold the relay lock indefinitely
+const outboxPublishTimeout = 15 * time.Second
+
+// outboxPruneInterval is how often PUBLISHED events past retention are deleted
+const outboxPruneInterval = time.Hour
+
+type OutboxConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Retention    time.Duration
+}
+
+// OutboxService relays outbox events to Kafka and backs the admin endpoints
+// for inspecting and replaying them
+type OutboxService struct {
+	outboxRepo *repository.OutboxRepository
+	publisher  kafka.Publisher
+	cfg        OutboxConfig
+}
+
+func NewOutboxService(outboxRepo *repository.OutboxRepository, publisher kafka.Publisher, cfg OutboxConfig) *OutboxService {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	return &OutboxService{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		cfg:        cfg,
+	}
+}
+
+// RunRelay publishes due events every poll interval, draining full batches
+// back to back, and prunes old PUBLISHED events
+func (s *OutboxService) RunRelay(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	lastPrune := time.Time{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				n, err := s.RelayOnce(ctx)
+				if err != nil {
+					logger.Error("Outbox relay failed", err)
+				}
+				if err != nil || n < s.cfg.BatchSize {
+					break
+				}
+			}
+
+			if s.cfg.Retention > 0 && time.Since(lastPrune) >= outboxPruneInterval {
+				lastPrune = time.Now()
+				n, err := s.outboxRepo.PrunePublished(ctx, s.cfg.Retention)
+				if err != nil {
+					logger.Error("Outbox prune failed", err)
+				} else if n > 0 {
+					logger.Info(fmt.Sprintf("Pruned %d published outbox events", n))
+				}
+			}
+		}
+	}
+}
+
+// RelayOnce publishes one batch of due events and returns how many were
+// claimed
+func (s *OutboxService) RelayOnce(ctx context.Context) (int, error) {
+	n, _, err := s.outboxRepo.RelayBatch(ctx, s.cfg.BatchSize, func(events []*models.OutboxEvent) []repository.OutboxResult {
+		return publishInKeyOrder(ctx, s.publisher, events, s.cfg.MaxAttempts, s.backoff)
+	})
+	return n, err
+}
+
+// publishInKeyOrder publishes events in rounds holding at most one event per
+// topic and key, so a later event is only sent after the earlier one for the
+// same key was acknowledged. Once an event fails, the rest of its key is left
+// out of the results and stays pending behind it. Unkeyed events all go in
+// the first round.
+func publishInKeyOrder(ctx context.Context, publisher kafka.Publisher, events []*models.OutboxEvent, maxAttempts int, backoff func(attempt int) time.Duration) []repository.OutboxResult {
+	results := make([]repository.OutboxResult, 0, len(events))
+	blocked := make(map[string]bool)
+	remaining := events
+
+	for len(remaining) > 0 {
+		var round, next []*models.OutboxEvent
+		inRound := make(map[string]bool)
+		for _, e := range remaining {
+			k := e.Topic + "\x00" + e.Key
+			switch {
+			case e.Key != "" && blocked[k]:
+				// dropped: stays pending behind the failed event
+			case e.Key != "" && inRound[k]:
+				next = append(next, e)
+			default:
+				inRound[k] = true
+				round = append(round, e)
+			}
+		}
+		remaining = next
+		if len(round) == 0 {
+			break
+		}
+
+		msgs := make([]kafka.Message, len(round))
+		for i, e := range round {
+			msgs[i] = kafka.Message{Topic: e.Topic, Value: e.Payload, Headers: e.TraceContext}
+			if e.Key != "" {
+				msgs[i].Key = []byte(e.Key)
+			}
+		}
+
+		pubCtx, cancel := context.WithTimeout(ctx, outboxPublishTimeout)
+		err := publisher.Publish(pubCtx, msgs)
+		cancel()
+
+		for i, e := range round {
+			msgErr := kafka.MessageError(err, i)
+			if msgErr == nil {
+				results = append(results, repository.OutboxResult{ID: e.ID, Published: true})
+				continue
+			}
+			blocked[e.Topic+"\x00"+e.Key] = true
+			attempt := e.Attempts + 1
+			res := repository.OutboxResult{ID: e.ID, Error: msgErr.Error()}
+			if attempt >= maxAttempts {
+				res.Dead = true
+				logger.Error(fmt.Sprintf("Outbox event %d (%s) is dead after %d attempts", e.ID, e.Topic, attempt), msgErr)
+			} else {
+				res.RetryAfter = backoff(attempt)
+			}
+			results = append(results, res)
+		}
+	}
+	return results
+}
+
+// backoff doubles the delay per attempt up to the configured maximum
+func (s *OutboxService) backoff(attempt int) time.Duration {
+	return outboxBackoff(attempt, s.cfg.BaseBackoff, s.cfg.MaxBackoff)
+}
+
+func outboxBackoff(attempt int, base, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+// ============================================
+// ADMIN
+// ============================================
+
+// ListEvents returns outbox events, newest first
+func (s *OutboxService) ListEvents(ctx context.Context, req *dto.ListOutboxEventsRequest) (*dto.ListResponse, error) {
+	page, limit := req.Page, req.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	events, total, err := s.outboxRepo.List(ctx, req.Status, req.Topic, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*dto.OutboxEventResponse, 0, len(events))
+	for _, e := range events {
+		items = append(items, outboxEventToResponse(e))
+	}
+	return dto.NewListResponse(items, page, limit, total), nil
+}
+
+// GetEvent returns one outbox event, or sql.ErrNoRows
+func (s *OutboxService) GetEvent(ctx context.Context, id int64) (*dto.OutboxEventResponse, error) {
+	event, err := s.outboxRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return outboxEventToResponse(event), nil
+}
+
+// GetStats summarises the backlog
+func (s *OutboxService) GetStats(ctx context.Context) (*dto.OutboxStatsResponse, error) {
+	stats, err := s.outboxRepo.GetStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &dto.OutboxStatsResponse{
+		Pending:   stats.Pending,
+		Dead:      stats.Dead,
+		Published: stats.Published,
+		Discarded: stats.Discarded,
+	}
+	if stats.OldestPendingAt.Valid {
+		oldest := stats.OldestPendingAt.Time
+		resp.OldestPendingAt = &oldest
+		resp.OldestPendingAgeSeconds = int64(time.Since(oldest).Seconds())
+	}
+	return resp, nil
+}
+
+// ReplayEvent makes an unpublished event due now with a fresh attempt
+// budget. Returns sql.ErrNoRows if there is no such unpublished event.
+func (s *OutboxService) ReplayEvent(ctx context.Context, id int64) error {
+	ok, err := s.outboxRepo.Replay(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ReplayDead makes every DEAD event (of a topic, if given) due now
+func (s *OutboxService) ReplayDead(ctx context.Context, topic string) (int64, error) {
+	return s.outboxRepo.ReplayDead(ctx, topic)
+}
+
+// DiscardEvent gives up on an unpublished event so its key can proceed.
+// Returns sql.ErrNoRows if there is no such unpublished event.
+func (s *OutboxService) DiscardEvent(ctx context.Context, id int64) error {
+	ok, err := s.outboxRepo.Discard(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func outboxEventToResponse(e *models.OutboxEvent) *dto.OutboxEventResponse {
+	resp := &dto.OutboxEventResponse{
+		ID:            e.ID,
+		Topic:         e.Topic,
+		Key:           e.Key,
+		Payload:       e.Payload,
+		Status:        e.Status,
+		Attempts:      e.Attempts,
+		NextAttemptAt: e.NextAttemptAt,
+		CreatedAt:     e.CreatedAt,
+	}
+	if e.LastError.Valid {
+		resp.LastError = &e.LastError.String
+	}
+	if e.PublishedAt.Valid {
+		resp.PublishedAt = &e.PublishedAt.Time
+	}
+	return resp
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"example/hello/internal/models"
+	"example/hello/internal/repository"
+	"example/hello/pkg/kafka"
+)
+
+func outboxEvent(id int64, key string, attempts int) *models.OutboxEvent {
+	return &models.OutboxEvent{ID: id, Topic: "t", Key: key, Payload: []byte(`{}`), Attempts: attempts}
+}
+
+func TestPublishInKeyOrder(t *testing.T) {
+	broker := kafka.NewMemoryBroker()
+	// Key "a" cannot be written, so event 2 fails and event 4 must not be tried
+	broker.FailWith(func(m kafka.Message) error {
+		if string(m.Key) == "a" {
+			return errors.New("broker unavailable")
+		}
+		return nil
+	})
+
+	events := []*models.OutboxEvent{
+		outboxEvent(1, "b", 0),
+		outboxEvent(2, "a", 0),
+		outboxEvent(3, "b", 0),
+		outboxEvent(4, "a", 0),
+		outboxEvent(5, "", 0),
+		outboxEvent(6, "", 0),
+	}
+	backoff := func(attempt int) time.Duration { return time.Duration(attempt) * time.Second }
+	results := publishInKeyOrder(context.Background(), broker, events, 5, backoff)
+
+	got := make(map[int64]repository.OutboxResult)
+	for _, r := range results {
+		got[r.ID] = r
+	}
+	for _, id := range []int64{1, 3, 5, 6} {
+		if !got[id].Published {
+			t.Errorf("event %d not published: %+v", id, got[id])
+		}
+	}
+	if r := got[2]; r.Published || r.Dead || r.RetryAfter != time.Second {
+		t.Errorf("event 2 = %+v; want retry after 1s", r)
+	}
+	if _, ok := got[4]; ok {
+		t.Errorf("event 4 must wait behind failed event 2, got %+v", got[4])
+	}
+
+	msgs := broker.Messages("t")
+	var keyB []int
+	for i, m := range msgs {
+		if string(m.Key) == "b" {
+			keyB = append(keyB, i)
+		}
+	}
+	if len(msgs) != 4 || len(keyB) != 2 {
+		t.Fatalf("published %d messages (%d for key b); want 4 (2)", len(msgs), len(keyB))
+	}
+}
+
+func TestPublishInKeyOrderMarksDead(t *testing.T) {
+	broker := kafka.NewMemoryBroker()
+	broker.FailWith(func(kafka.Message) error { return errors.New("topic authorization failed") })
+
+	results := publishInKeyOrder(context.Background(), broker,
+		[]*models.OutboxEvent{outboxEvent(1, "k", 4)}, 5, func(int) time.Duration { return time.Second })
+	if len(results) != 1 || !results[0].Dead || results[0].Error == "" {
+		t.Fatalf("results = %+v; want one dead event with an error", results)
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{20, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := outboxBackoff(tt.attempt, time.Second, time.Minute); got != tt.want {
+			t.Errorf("outboxBackoff(%d) = %v; want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -14,18 +16,51 @@ import (
 )
 
 const (
-	permCachePrefix = "perm:role:"
-	permCacheTTL    = 10 * time.Minute
+	permCachePrefix    = "perm:role:"
+	permOrgCachePrefix = "perm:org:"
+	permCacheTTL       = 10 * time.Minute
 )
 
+var (
+	ErrOrgRoleNotFound   = errors.New("organization role not found")
+	ErrOrgRoleTemplate   = errors.New("template organization roles are managed by system administrators")
+	ErrOrgRolePermission = errors.New("permission not allowed for organization roles")
+)
+
+// orgExcludedPermissions cannot be granted by an organization role; they
+// only make sense system-wide
+var orgExcludedPermissions = map[string]bool{"ROLE_MANAGE": true}
+
 // PermissionService handles permission logic with Redis-backed caching.
+// Global roles come from user_roles; org roles from the member's org_role in
+// the organization a request acts on.
 type PermissionService struct {
-	repo  *repository.PermissionRepository
-	cache *cache.RedisCache
+	repo        *repository.PermissionRepository
+	roleDefRepo *repository.RoleDefinitionRepository
+	orgRepo     *repository.OrganizationRepository
+	courseRepo  *repository.CourseRepository
+	cache       *cache.RedisCache
+	loader      *cache.Loader
+	audit       *AuditService
 }
 
-func NewPermissionService(repo *repository.PermissionRepository, cache *cache.RedisCache) *PermissionService {
-	return &PermissionService{repo: repo, cache: cache}
+func NewPermissionService(
+	repo *repository.PermissionRepository,
+	roleDefRepo *repository.RoleDefinitionRepository,
+	orgRepo *repository.OrganizationRepository,
+	courseRepo *repository.CourseRepository,
+	c *cache.RedisCache,
+	audit *AuditService,
+) *PermissionService {
+	return &PermissionService{
+		repo:        repo,
+		roleDefRepo: roleDefRepo,
+		orgRepo:     orgRepo,
+		courseRepo:  courseRepo,
+		cache:       c,
+		loader:      cache.NewLoader(c),
+		audit:       audit,
+	}
 }
 
 // ListAll returns every permission (master data for UI).
@@ -54,7 +89,7 @@ func (s *PermissionService) GetRolePermissions(ctx context.Context, roleID int64
 // AssignPermissions replaces the permission set for a role and invalidates cache.
 func (s *PermissionService) AssignPermissions(ctx context.Context, roleID int64, permIDs []int64) error {
 	// Get role name for cache invalidation (single query that also confirms role exists)
-	roleName, _, err := s.repo.FindByRoleID(ctx, roleID)
+	roleName, before, err := s.repo.FindByRoleID(ctx, roleID)
 	if err != nil {
 		return fmt.Errorf("role not found: %w", err)
 	}
@@ -63,15 +98,44 @@ func (s *PermissionService) AssignPermissions(ctx context.Context, roleID int64,
 		return err
 	}
 
-	// Invalidate cache
-	cacheKey := permCachePrefix + roleName
-	if err := s.cache.Delete(ctx, cacheKey); err != nil {
-		logger.Warn(fmt.Sprintf("Failed to invalidate permission cache for %s: %v", roleName, err))
+	_, after, err := s.repo.FindByRoleID(ctx, roleID)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Failed to reload permissions of role %s for audit: %v", roleName, err))
 	}
+	s.audit.Record(ctx, AuditRecord{
+		Action:     models.AuditRolePermissionsSet,
+		TargetType: models.AuditTargetRole,
+		TargetID:   roleID,
+		Before:     map[string]interface{}{"role": roleName, "permissions": permissionCodes(before)},
+		After:      map[string]interface{}{"role": roleName, "permissions": permissionCodes(after)},
+	})
 
+	s.invalidateRoleCache(ctx, roleID, roleName)
 	return nil
 }
 
+// invalidateRoleCache drops cached codes for a role. A template org role is
+// cached once per organization, so every organization's entry goes.
+func (s *PermissionService) invalidateRoleCache(ctx context.Context, roleID int64, roleName string) {
+	role, err := s.roleDefRepo.FindByID(ctx, roleID)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Failed to load role %d for cache invalidation: %v", roleID, err))
+		return
+	}
+
+	switch {
+	case role.Scope != models.RoleScopeOrg:
+		err = s.cache.Delete(ctx, permCachePrefix+roleName)
+	case role.OrgID != nil:
+		err = s.cache.Delete(ctx, orgPermCacheKey(*role.OrgID, roleName))
+	default:
+		err = s.cache.DeletePattern(ctx, permOrgCachePrefix+"*:"+roleName)
+	}
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Failed to invalidate permission cache for %s: %v", roleName, err))
+	}
+}
+
 // CheckPermission checks whether a role has a specific permission code.
 // ADMIN always passes. Results are cached in Redis.
 func (s *PermissionService) CheckPermission(ctx context.Context, roleName string, code string) (bool, error) {
@@ -93,11 +157,142 @@ func (s *PermissionService) CheckPermission(ctx context.Context, roleName string
 	return false, nil
 }
 
+// CheckOrgPermission checks whether a user holds a permission code while
+// acting in an organization: through their global role, or through the role
+// they hold as a member of orgID. An orgID of 0 checks the global role only.
+func (s *PermissionService) CheckOrgPermission(ctx context.Context, userID int64, roleName string, orgID int64, code string) (bool, error) {
+	if roleName != "" {
+		allowed, err := s.CheckPermission(ctx, roleName, code)
+		if err != nil || allowed {
+			return allowed, err
+		}
+	}
+	return s.HasOrgPermission(ctx, userID, orgID, code)
+}
+
+// HasOrgPermission checks only the role userID holds in orgID, ignoring their
+// global role. Services use it to let an organization's admins manage what
+// belongs to that organization without widening what a global role allows.
+func (s *PermissionService) HasOrgPermission(ctx context.Context, userID, orgID int64, code string) (bool, error) {
+	if userID <= 0 || orgID <= 0 || orgExcludedPermissions[code] {
+		return false, nil
+	}
+
+	isMember, orgRole, err := s.orgRepo.IsMember(ctx, orgID, userID)
+	if err != nil || !isMember {
+		return false, err
+	}
+
+	codes, err := s.getCachedOrgCodes(ctx, orgID, orgRole)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range codes {
+		if c == code {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// OrgRoleExists reports whether name is an org role orgID can assign
+func (s *PermissionService) OrgRoleExists(ctx context.Context, orgID int64, name string) (bool, error) {
+	return s.roleDefRepo.OrgRoleExists(ctx, orgID, name)
+}
+
+// CourseOrgID returns the organization a course belongs to, or 0
+func (s *PermissionService) CourseOrgID(ctx context.Context, courseID int64) (int64, error) {
+	return cache.GetOrLoad(ctx, s.loader, cache.KeyCourseOrg(courseID), permCacheTTL,
+		func(ctx context.Context) (int64, error) {
+			course, err := s.courseRepo.GetByID(ctx, courseID)
+			if err != nil {
+				return 0, err
+			}
+			return course.OrgID, nil
+		})
+}
+
+// GetOrgRolePermissions returns the permissions of a role usable in orgID:
+// a template or one of the organization's own roles
+func (s *PermissionService) GetOrgRolePermissions(ctx context.Context, orgID, roleID int64) (*dto.RolePermissionsResponse, error) {
+	if _, err := s.orgRole(ctx, orgID, roleID, false); err != nil {
+		return nil, err
+	}
+	return s.GetRolePermissions(ctx, roleID)
+}
+
+// AssignOrgRolePermissions replaces the permissions of one of the
+// organization's own roles. Templates are shared by every organization and
+// are managed through the global role endpoints.
+func (s *PermissionService) AssignOrgRolePermissions(ctx context.Context, orgID, roleID int64, permIDs []int64) error {
+	if _, err := s.orgRole(ctx, orgID, roleID, true); err != nil {
+		return err
+	}
+
+	all, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	excluded := make(map[int64]string)
+	for _, p := range all {
+		if orgExcludedPermissions[p.Code] {
+			excluded[p.ID] = p.Code
+		}
+	}
+	for _, id := range permIDs {
+		if code, ok := excluded[id]; ok {
+			return fmt.Errorf("%w: %s cannot be granted by an organization role", ErrOrgRolePermission, code)
+		}
+	}
+
+	return s.AssignPermissions(ctx, roleID, permIDs)
+}
+
+// orgRole loads roleID and checks it is usable in orgID; owned additionally
+// excludes templates
+func (s *PermissionService) orgRole(ctx context.Context, orgID, roleID int64, owned bool) (*models.RoleDefinition, error) {
+	role, err := s.roleDefRepo.FindByID(ctx, roleID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrOrgRoleNotFound
+		}
+		return nil, err
+	}
+	if role.Scope != models.RoleScopeOrg {
+		return nil, ErrOrgRoleNotFound
+	}
+	if role.OrgID == nil {
+		if owned {
+			return nil, ErrOrgRoleTemplate
+		}
+		return role, nil
+	}
+	if *role.OrgID != orgID {
+		return nil, ErrOrgRoleNotFound
+	}
+	return role, nil
+}
+
+func orgPermCacheKey(orgID int64, roleName string) string {
+	return fmt.Sprintf("%s%d:%s", permOrgCachePrefix, orgID, roleName)
+}
+
 // getCachedCodes returns the permission codes for a role, using Redis as a
 // read-through cache. On miss it queries the DB and populates the cache.
 func (s *PermissionService) getCachedCodes(ctx context.Context, roleName string) ([]string, error) {
-	cacheKey := permCachePrefix + roleName
+	return s.cachedCodes(ctx, permCachePrefix+roleName, func() ([]string, error) {
+		return s.repo.FindCodesByRoleName(ctx, roleName)
+	})
+}
 
+// getCachedOrgCodes is getCachedCodes for an org role in one organization
+func (s *PermissionService) getCachedOrgCodes(ctx context.Context, orgID int64, roleName string) ([]string, error) {
+	return s.cachedCodes(ctx, orgPermCacheKey(orgID, roleName), func() ([]string, error) {
+		return s.repo.FindCodesByOrgRole(ctx, orgID, roleName)
+	})
+}
+
+func (s *PermissionService) cachedCodes(ctx context.Context, cacheKey string, load func() ([]string, error)) ([]string, error) {
 	// Try cache first
 	raw, err := s.cache.Get(ctx, cacheKey)
 	if err == nil && raw != "" {
@@ -108,7 +303,7 @@ func (s *PermissionService) getCachedCodes(ctx context.Context, roleName string)
 	}
 
 	// Cache miss - query DB
-	codes, err := s.repo.FindCodesByRoleName(ctx, roleName)
+	codes, err := load()
 	if err != nil {
 		return nil, err
 	}
@@ -119,7 +314,7 @@ func (s *PermissionService) getCachedCodes(ctx context.Context, roleName string)
 	// Populate cache
 	data, _ := json.Marshal(codes)
 	if setErr := s.cache.Set(ctx, cacheKey, string(data), permCacheTTL); setErr != nil {
-		logger.Warn(fmt.Sprintf("Failed to cache permissions for %s: %v", roleName, setErr))
+		logger.Warn(fmt.Sprintf("Failed to cache permissions for %s: %v", cacheKey, setErr))
 	}
 
 	return codes, nil
@@ -137,3 +332,11 @@ func toPermissionResponses(perms []models.Permission) []dto.PermissionResponse {
 	}
 	return out
 }
+
+func permissionCodes(perms []models.Permission) []string {
+	codes := make([]string, 0, len(perms))
+	for _, p := range perms {
+		codes = append(codes, p.Code)
+	}
+	return codes
+}
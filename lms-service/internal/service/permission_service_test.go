@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"path"
+	"testing"
+)
+
+func TestHasOrgPermissionNeedsAnOrgAndAnOrgGrantableCode(t *testing.T) {
+	// No repositories: these cases must be decided before any lookup
+	s := &PermissionService{}
+	ctx := context.Background()
+
+	if ok, err := s.HasOrgPermission(ctx, 1, 0, "COURSE_EDIT"); ok || err != nil {
+		t.Errorf("no organization: got %v, %v; want denied", ok, err)
+	}
+	if ok, err := s.HasOrgPermission(ctx, 0, 3, "COURSE_EDIT"); ok || err != nil {
+		t.Errorf("no user: got %v, %v; want denied", ok, err)
+	}
+	if ok, err := s.HasOrgPermission(ctx, 1, 3, "ROLE_MANAGE"); ok || err != nil {
+		t.Errorf("system permission through an org role: got %v, %v; want denied", ok, err)
+	}
+}
+
+func TestOrgPermCacheKeyMatchesTemplateInvalidation(t *testing.T) {
+	key := orgPermCacheKey(7, "ADMIN")
+	if key != "perm:org:7:ADMIN" {
+		t.Errorf("key = %q", key)
+	}
+	// Changing a template drops every organization's entry for it
+	if ok, _ := path.Match(permOrgCachePrefix+"*:ADMIN", key); !ok {
+		t.Errorf("template pattern does not match %q", key)
+	}
+	if ok, _ := path.Match(permOrgCachePrefix+"*:ADMIN", orgPermCacheKey(7, "MEMBER")); ok {
+		t.Error("template pattern matches another role")
+	}
+}
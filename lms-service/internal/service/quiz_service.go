@@ -24,6 +24,7 @@ type QuizService struct {
 	userRepo       *repository.UserRepository
 	progressRepo   *repository.ProgressRepository
 	aiClient       *ai.Client
+	audit          *AuditService
 }
 
 func NewQuizService(
@@ -32,6 +33,7 @@ func NewQuizService(
 	userRepo *repository.UserRepository,
 	progressRepo *repository.ProgressRepository,
 	aiClient *ai.Client,
+	audit *AuditService,
 ) *QuizService {
 	return &QuizService{
 		quizRepo:     quizRepo,
@@ -39,6 +41,7 @@ func NewQuizService(
 		userRepo:     userRepo,
 		progressRepo: progressRepo,
 		aiClient:     aiClient,
+		audit:        audit,
 	}
 }
 
@@ -1001,6 +1004,7 @@ func (s *QuizService) GradeAnswer(ctx context.Context, req *dto.GradeAnswerReque
 	}
 
 	// Update answer
+	before := answerGradeState(answer)
 	now := time.Now()
 	answer.PointsEarned = sql.NullFloat64{Float64: req.PointsEarned, Valid: true}
 	answer.GraderFeedback = toNullString(req.GraderFeedback)
@@ -1039,6 +1043,21 @@ func (s *QuizService) GradeAnswer(ctx context.Context, req *dto.GradeAnswerReque
 		return err
 	}
 
+	courseID, err := s.quizRepo.GetQuizCourseID(ctx, quiz.ID)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Failed to resolve course of quiz %d for audit: %v", quiz.ID, err))
+	}
+	after := answerGradeState(answer)
+	after["attempt_status"] = attempt.Status
+	s.audit.Record(ctx, AuditRecord{
+		Action:     models.AuditAnswerGrade,
+		TargetType: models.AuditTargetAnswer,
+		TargetID:   answer.ID,
+		CourseID:   courseID,
+		Before:     before,
+		After:      after,
+	})
+
 	// AI: Notify AI service about the manual grading result for progress tracking
 	if question.NodeID.Valid {
 		go func() {
@@ -1118,6 +1137,7 @@ func (s *QuizService) BulkGrade(ctx context.Context, req *dto.BulkGradeRequest,
 	type perAttemptResult struct {
 		succeeded []int64
 		failed    []dto.GradeError
+		courseID  int64
 	}
  
 	attemptIDs := make([]int64, 0, len(grouped))
@@ -1154,19 +1174,68 @@ func (s *QuizService) BulkGrade(ctx context.Context, req *dto.BulkGradeRequest,
 			}
  
 			results[idx] = perAttemptResult{succeeded: succeeded, failed: failed}
+			if len(succeeded) > 0 {
+				results[idx].courseID = s.attemptCourseID(gradeCtx, aID)
+			}
 			return nil
 		})
 	}
 	gradeG.Wait()
  
+	var courseIDs []int64
 	for _, r := range results {
 		response.Succeeded = append(response.Succeeded, r.succeeded...)
 		response.Failed = append(response.Failed, r.failed...)
+		if r.courseID != 0 {
+			courseIDs = append(courseIDs, r.courseID)
+		}
 	}
+
+	// Each graded answer has its own entry; this one ties the batch together
+	s.audit.Record(ctx, bulkGradeAudit(len(req.Grades), response, courseIDs))
  
 	return response
 }
 
+// attemptCourseID resolves the course of an attempt for the audit log; 0 if
+// it cannot be found
+func (s *QuizService) attemptCourseID(ctx context.Context, attemptID int64) int64 {
+	attempt, err := s.quizRepo.GetAttempt(ctx, attemptID)
+	if err == nil {
+		var courseID int64
+		if courseID, err = s.quizRepo.GetQuizCourseID(ctx, attempt.QuizID); err == nil {
+			return courseID
+		}
+	}
+	logger.Warn(fmt.Sprintf("Failed to resolve course of attempt %d for audit: %v", attemptID, err))
+	return 0
+}
+
+// bulkGradeAudit is the audit entry of a bulk grade. It is filed under the
+// course of the graded answers when they all belong to one.
+func bulkGradeAudit(requested int, response *dto.BulkGradeResponse, courseIDs []int64) AuditRecord {
+	after := map[string]interface{}{
+		"requested": requested,
+		"succeeded": response.Succeeded,
+		"failed":    response.Failed,
+	}
+	var courseID int64
+	for _, id := range courseIDs {
+		if courseID != 0 && id != courseID {
+			courseID = 0
+			after["course_ids"] = courseIDs
+			break
+		}
+		courseID = id
+	}
+	return AuditRecord{
+		Action:     models.AuditAnswerBulkGrade,
+		TargetType: models.AuditTargetAnswer,
+		CourseID:   courseID,
+		After:      after,
+	}
+}
+
 // ListStudentAnswersForGrading lists answers that need grading
 func (s *QuizService) ListStudentAnswersForGrading(ctx context.Context, quizID int64, userID int64, userRole string) ([]dto.StudentAnswerForGrading, error) {
 	// Verify permission
@@ -1931,6 +2000,27 @@ func (s *QuizService) buildQuizReviewResponse(ctx context.Context, attempt *mode
 // UTILITY FUNCTIONS
 // ============================================
 
+// answerGradeState is the grading part of an answer, for the audit log
+func answerGradeState(a *models.QuizStudentAnswer) map[string]interface{} {
+	state := map[string]interface{}{
+		"attempt_id":  a.AttemptID,
+		"question_id": a.QuestionID,
+	}
+	if a.PointsEarned.Valid {
+		state["points_earned"] = a.PointsEarned.Float64
+	}
+	if a.IsCorrect.Valid {
+		state["is_correct"] = a.IsCorrect.Bool
+	}
+	if a.GraderFeedback.Valid {
+		state["grader_feedback"] = a.GraderFeedback.String
+	}
+	if a.GradedBy.Valid {
+		state["graded_by"] = a.GradedBy.Int64
+	}
+	return state
+}
+
 // Null type conversion helpers
 func toNullString(s string) sql.NullString {
 	return sql.NullString{String: s, Valid: s != ""}
@@ -13,13 +13,15 @@ type RoleAdminService struct {
 	roleDefRepo *repository.RoleDefinitionRepository
 	userRepo    *repository.UserRepository
 	cache       *cache.RedisCache
+	audit       *AuditService
 }
 
-func NewRoleAdminService(roleDefRepo *repository.RoleDefinitionRepository, userRepo *repository.UserRepository, c *cache.RedisCache) *RoleAdminService {
+func NewRoleAdminService(roleDefRepo *repository.RoleDefinitionRepository, userRepo *repository.UserRepository, c *cache.RedisCache, audit *AuditService) *RoleAdminService {
 	return &RoleAdminService{
 		roleDefRepo: roleDefRepo,
 		userRepo:    userRepo,
 		cache:       c,
+		audit:       audit,
 	}
 }
 
@@ -47,11 +49,61 @@ func (s *RoleAdminService) DeleteRole(ctx context.Context, id int64) error {
 	return s.roleDefRepo.Delete(ctx, id)
 }
 
+// ListOrgRoles returns the roles members of orgID can hold: the templates
+// and the organization's own
+func (s *RoleAdminService) ListOrgRoles(ctx context.Context, orgID int64) ([]models.RoleDefinition, error) {
+	return s.roleDefRepo.FindOrgRoles(ctx, orgID)
+}
+
+// CreateOrgRole adds a role owned by orgID. A role named like a template
+// replaces the template within that organization.
+func (s *RoleAdminService) CreateOrgRole(ctx context.Context, orgID int64, req *dto.RoleDefinitionRequest) (*models.RoleDefinition, error) {
+	role := &models.RoleDefinition{
+		Name:        req.Name,
+		DisplayName: req.DisplayName,
+		Description: req.Description,
+	}
+	if err := s.roleDefRepo.CreateOrgRole(ctx, orgID, role); err != nil {
+		return nil, err
+	}
+	s.invalidateOrgRole(ctx, orgID, role.Name)
+	return role, nil
+}
+
+func (s *RoleAdminService) UpdateOrgRole(ctx context.Context, orgID, id int64, req *dto.RoleDefinitionRequest) error {
+	return s.roleDefRepo.UpdateOrgRole(ctx, orgID, id, req.DisplayName, req.Description)
+}
+
+func (s *RoleAdminService) DeleteOrgRole(ctx context.Context, orgID, id int64) error {
+	role, err := s.roleDefRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.roleDefRepo.DeleteOrgRole(ctx, orgID, id); err != nil {
+		return err
+	}
+	s.invalidateOrgRole(ctx, orgID, role.Name)
+	return nil
+}
+
+// invalidateOrgRole drops cached codes for a role name in one organization,
+// which may have switched between the template and the org's own definition
+func (s *RoleAdminService) invalidateOrgRole(ctx context.Context, orgID int64, name string) {
+	if s.cache != nil {
+		cache.Invalidate(ctx, s.cache, orgPermCacheKey(orgID, name))
+	}
+}
+
 func (s *RoleAdminService) GetUserRoles(ctx context.Context, userID int64) ([]models.UserRoleDetail, error) {
 	return s.roleDefRepo.GetUserRoleDetails(ctx, userID)
 }
 
 func (s *RoleAdminService) AssignRoleToUser(ctx context.Context, userID int64, role string) error {
+	before, err := s.userRepo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return err
+	}
+
 	// Add role with source='manual' so it is not overwritten by Auth sync
 	if err := s.userRepo.AddRoleWithSource(ctx, userID, role, "manual"); err != nil {
 		return err
@@ -60,10 +112,16 @@ func (s *RoleAdminService) AssignRoleToUser(ctx context.Context, userID int64, r
 	if s.cache != nil {
 		cache.Invalidate(ctx, s.cache, cache.KeyUserRoles(userID))
 	}
+	s.recordRoleChange(ctx, models.AuditRoleAssign, userID, role, before)
 	return nil
 }
 
 func (s *RoleAdminService) RemoveRoleFromUser(ctx context.Context, userID int64, role string) error {
+	before, err := s.userRepo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return err
+	}
+
 	if err := s.roleDefRepo.RemoveRoleFromUser(ctx, userID, role); err != nil {
 		return err
 	}
@@ -71,5 +129,19 @@ func (s *RoleAdminService) RemoveRoleFromUser(ctx context.Context, userID int64,
 	if s.cache != nil {
 		cache.Invalidate(ctx, s.cache, cache.KeyUserRoles(userID))
 	}
+	s.recordRoleChange(ctx, models.AuditRoleRemove, userID, role, before)
 	return nil
 }
+
+// recordRoleChange audits a user's role set before and after a change
+func (s *RoleAdminService) recordRoleChange(ctx context.Context, action string, userID int64, role string, before []string) {
+	// The change is already made; a failed reload leaves "after" empty
+	after, _ := s.userRepo.GetUserRoles(ctx, userID)
+	s.audit.Record(ctx, AuditRecord{
+		Action:     action,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID,
+		Before:     map[string]interface{}{"roles": before},
+		After:      map[string]interface{}{"roles": after, "changed_role": role},
+	})
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"example/hello/internal/dto"
+	"example/hello/internal/repository"
+	"example/hello/pkg/authsync"
+	"example/hello/pkg/kafka"
+	"example/hello/pkg/logger"
+)
+
+// ApplyUserEvent applies a user lifecycle event from auth.user.events.
+// Events older than the version LMS already holds are ignored.
+func (s *UserSyncService) ApplyUserEvent(ctx context.Context, e authsync.UserEvent) error {
+	if e.UserID <= 0 || e.Version <= 0 {
+		return kafka.Permanent(fmt.Errorf("user event %q: missing user_id or version", e.EventID))
+	}
+
+	switch e.Type {
+	case authsync.UserUpserted:
+		if e.User == nil {
+			return kafka.Permanent(fmt.Errorf("user event %q: %s without user", e.EventID, e.Type))
+		}
+		user := *e.User
+		user.UserID = e.UserID
+		_, err := s.applyUser(ctx, user, e.Version)
+		return err
+	case authsync.UserDeleted:
+		_, err := s.deleteUser(ctx, e.UserID, e.Version)
+		return err
+	default:
+		logger.Warn(fmt.Sprintf("Ignoring user event %q of unknown type %s", e.EventID, e.Type))
+		return nil
+	}
+}
+
+// ApplyOrgEvent applies an organization or membership event from
+// auth.org.events
+func (s *UserSyncService) ApplyOrgEvent(ctx context.Context, e authsync.OrgEvent) error {
+	if e.OrgID <= 0 || e.Version <= 0 {
+		return kafka.Permanent(fmt.Errorf("org event %q: missing org_id or version", e.EventID))
+	}
+
+	switch e.Type {
+	case authsync.OrgUpserted:
+		if e.Organization == nil {
+			return kafka.Permanent(fmt.Errorf("org event %q: %s without organization", e.EventID, e.Type))
+		}
+		org := *e.Organization
+		org.ID = e.OrgID
+		_, err := s.applyOrg(ctx, org, e.Version)
+		return err
+	case authsync.OrgDeleted:
+		_, err := s.deleteOrg(ctx, e.OrgID, e.Version)
+		return err
+	case authsync.MemberUpserted:
+		if e.Member == nil {
+			return kafka.Permanent(fmt.Errorf("org event %q: %s without member", e.EventID, e.Type))
+		}
+		member := *e.Member
+		member.OrgID = e.OrgID
+		_, err := s.applyMember(ctx, member, e.Version)
+		return err
+	case authsync.MemberRemoved:
+		if e.UserID <= 0 {
+			return kafka.Permanent(fmt.Errorf("org event %q: %s without user_id", e.EventID, e.Type))
+		}
+		_, err := s.removeMember(ctx, e.OrgID, e.UserID, e.Version)
+		return err
+	default:
+		logger.Warn(fmt.Sprintf("Ignoring org event %q of unknown type %s", e.EventID, e.Type))
+		return nil
+	}
+}
+
+// claim reports whether a change at version should be applied, logging
+// the ones skipped as stale
+func (s *UserSyncService) claim(ctx context.Context, entityType, entityID string, version int64, deleted bool) (bool, error) {
+	ok, err := s.versionRepo.Claim(ctx, entityType, entityID, version, deleted)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim %s %s version %d: %w", entityType, entityID, version, err)
+	}
+	if !ok {
+		logger.Info(fmt.Sprintf("Skipping stale %s %s version %d", entityType, entityID, version))
+	}
+	return ok, nil
+}
+
+func (s *UserSyncService) applyUser(ctx context.Context, u authsync.UserSnapshot, version int64) (bool, error) {
+	ok, err := s.claim(ctx, repository.SyncEntityUser, strconv.FormatInt(u.UserID, 10), version, false)
+	if err != nil || !ok {
+		return false, err
+	}
+	_, err = s.SyncUser(ctx, &dto.UserSyncRequest{
+		UserID:         u.UserID,
+		Email:          u.Email,
+		FullName:       u.FullName,
+		ProfilePicture: u.ProfilePicture,
+		Roles:          u.Roles,
+		Org:            u.Org,
+	})
+	return err == nil, err
+}
+
+func (s *UserSyncService) deleteUser(ctx context.Context, userID, version int64) (bool, error) {
+	ok, err := s.claim(ctx, repository.SyncEntityUser, strconv.FormatInt(userID, 10), version, true)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, s.DeleteUser(ctx, userID)
+}
+
+func (s *UserSyncService) applyOrg(ctx context.Context, o authsync.OrgSnapshot, version int64) (bool, error) {
+	ok, err := s.claim(ctx, repository.SyncEntityOrg, strconv.FormatInt(o.ID, 10), version, false)
+	if err != nil || !ok {
+		return false, err
+	}
+	settings := string(o.Settings)
+	if settings == "" || settings == "null" {
+		settings = "{}"
+	}
+	err = s.SyncOrganization(ctx, &dto.OrgSyncRequest{
+		ID:          o.ID,
+		Name:        o.Name,
+		Slug:        o.Slug,
+		Description: o.Description,
+		LogoURL:     o.LogoURL,
+		IsActive:    o.IsActive,
+		Settings:    settings,
+	})
+	return err == nil, err
+}
+
+func (s *UserSyncService) deleteOrg(ctx context.Context, orgID, version int64) (bool, error) {
+	ok, err := s.claim(ctx, repository.SyncEntityOrg, strconv.FormatInt(orgID, 10), version, true)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, s.DeleteOrganization(ctx, orgID)
+}
+
+func memberKey(orgID, userID int64) string {
+	return strconv.FormatInt(orgID, 10) + ":" + strconv.FormatInt(userID, 10)
+}
+
+func (s *UserSyncService) applyMember(ctx context.Context, m authsync.MemberSnapshot, version int64) (bool, error) {
+	ok, err := s.claim(ctx, repository.SyncEntityMember, memberKey(m.OrgID, m.UserID), version, false)
+	if err != nil || !ok {
+		return false, err
+	}
+	err = s.SyncOrganizationMember(ctx, &dto.OrgMemberSyncRequest{
+		OrgID:   m.OrgID,
+		UserID:  m.UserID,
+		OrgRole: m.OrgRole,
+	})
+	return err == nil, err
+}
+
+func (s *UserSyncService) removeMember(ctx context.Context, orgID, userID, version int64) (bool, error) {
+	ok, err := s.claim(ctx, repository.SyncEntityMember, memberKey(orgID, userID), version, true)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, s.RemoveOrganizationMember(ctx, orgID, userID)
+}
+
+// ============================================
+// RECONCILIATION
+// ============================================
+
+// SnapshotSource pages through the auth service's full state;
+// *authsync.Client implements it
+type SnapshotSource interface {
+	EachUser(ctx context.Context, pageSize int, fn func(authsync.UserSnapshot) error) error
+	EachOrganization(ctx context.Context, pageSize int, fn func(authsync.OrgSnapshot) error) error
+}
+
+type ReconcileOptions struct {
+	PageSize int
+	// Prune removes users, organizations and memberships that an earlier
+	// sync recorded but the snapshot no longer contains
+	Prune bool
+}
+
+type ReconcileReport struct {
+	UsersSeen      int
+	UsersApplied   int
+	OrgsSeen       int
+	OrgsApplied    int
+	MembersSeen    int
+	MembersApplied int
+	Failed         int
+	UsersPruned    int
+	OrgsPruned     int
+	MembersPruned  int
+}
+
+// Reconcile pulls a full snapshot from src and applies every entity whose
+// version is not older than the one LMS holds, repairing state left stale
+// by missed events. A failure on one entity is counted and skipped; a
+// failure to fetch a page aborts the run before anything is pruned.
+func (s *UserSyncService) Reconcile(ctx context.Context, src SnapshotSource, opts ReconcileOptions) (*ReconcileReport, error) {
+	if opts.PageSize <= 0 {
+		opts.PageSize = 500
+	}
+	started := time.Now()
+	report := &ReconcileReport{}
+	seen := map[string]map[string]bool{
+		repository.SyncEntityUser:   {},
+		repository.SyncEntityOrg:    {},
+		repository.SyncEntityMember: {},
+	}
+
+	noteFailure := func(err error, what string) {
+		if err != nil {
+			report.Failed++
+			logger.Error("Reconcile: failed to apply "+what, err)
+		}
+	}
+
+	err := src.EachUser(ctx, opts.PageSize, func(u authsync.UserSnapshot) error {
+		report.UsersSeen++
+		seen[repository.SyncEntityUser][strconv.FormatInt(u.UserID, 10)] = true
+		applied, err := s.applyUser(ctx, u, u.Version)
+		if applied {
+			report.UsersApplied++
+		}
+		noteFailure(err, fmt.Sprintf("user %d", u.UserID))
+		return ctx.Err()
+	})
+	if err != nil {
+		return report, fmt.Errorf("reconcile users: %w", err)
+	}
+
+	err = src.EachOrganization(ctx, opts.PageSize, func(o authsync.OrgSnapshot) error {
+		report.OrgsSeen++
+		seen[repository.SyncEntityOrg][strconv.FormatInt(o.ID, 10)] = true
+		applied, err := s.applyOrg(ctx, o, o.Version)
+		if applied {
+			report.OrgsApplied++
+		}
+		noteFailure(err, fmt.Sprintf("organization %d", o.ID))
+
+		for _, m := range o.Members {
+			m.OrgID = o.ID
+			report.MembersSeen++
+			seen[repository.SyncEntityMember][memberKey(m.OrgID, m.UserID)] = true
+			applied, err := s.applyMember(ctx, m, m.Version)
+			if applied {
+				report.MembersApplied++
+			}
+			noteFailure(err, fmt.Sprintf("membership of user %d in organization %d", m.UserID, m.OrgID))
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return report, fmt.Errorf("reconcile organizations: %w", err)
+	}
+
+	if opts.Prune {
+		// Anything recorded after the run started may be missing from pages
+		// fetched earlier, so only entities untouched since then are pruned
+		window := time.Since(started)
+		if err := s.prune(ctx, seen, window, report); err != nil {
+			return report, err
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Reconcile finished: %+v", *report))
+	return report, nil
+}
+
+func (s *UserSyncService) prune(ctx context.Context, seen map[string]map[string]bool, window time.Duration, report *ReconcileReport) error {
+	// Memberships first, so they are not left pointing at pruned orgs
+	for _, entityType := range []string{repository.SyncEntityMember, repository.SyncEntityOrg, repository.SyncEntityUser} {
+		ids, err := s.versionRepo.ListStale(ctx, entityType, window)
+		if err != nil {
+			return fmt.Errorf("list synced %s entities: %w", entityType, err)
+		}
+		for _, id := range ids {
+			if seen[entityType][id] {
+				continue
+			}
+			if err := s.pruneEntity(ctx, entityType, id); err != nil {
+				report.Failed++
+				logger.Error(fmt.Sprintf("Reconcile: failed to prune %s %s", entityType, id), err)
+				continue
+			}
+			if err := s.versionRepo.MarkDeleted(ctx, entityType, id); err != nil {
+				return fmt.Errorf("tombstone %s %s: %w", entityType, id, err)
+			}
+			switch entityType {
+			case repository.SyncEntityUser:
+				report.UsersPruned++
+			case repository.SyncEntityOrg:
+				report.OrgsPruned++
+			case repository.SyncEntityMember:
+				report.MembersPruned++
+			}
+		}
+	}
+	return nil
+}
+
+func (s *UserSyncService) pruneEntity(ctx context.Context, entityType, id string) error {
+	switch entityType {
+	case repository.SyncEntityUser:
+		userID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return err
+		}
+		return s.DeleteUser(ctx, userID)
+	case repository.SyncEntityOrg:
+		orgID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return err
+		}
+		return s.DeleteOrganization(ctx, orgID)
+	default:
+		var orgID, userID int64
+		if _, err := fmt.Sscanf(id, "%d:%d", &orgID, &userID); err != nil {
+			return err
+		}
+		return s.RemoveOrganizationMember(ctx, orgID, userID)
+	}
+}
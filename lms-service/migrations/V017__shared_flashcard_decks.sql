@@ -0,0 +1,98 @@
+-- V017: Teacher-authored shared flashcard decks
+--
+-- Personal flashcards live in the ai-service and belong to one student. A
+-- shared deck belongs to a course: teachers and co-teachers author it once and
+-- every enrolled student studies it on their own SM-2 schedule.
+--
+-- Versioning: each change to a published deck bumps flashcard_decks.version
+-- and is recorded in flashcard_deck_versions. Card edits keep the card id, so
+-- the per-student repetition rows (and therefore progress) survive edits;
+-- a card's `revision` lets clients flag cards changed since the last review.
+
+CREATE TABLE IF NOT EXISTS flashcard_decks (
+    id           BIGSERIAL PRIMARY KEY,
+    course_id    BIGINT NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    node_id      BIGINT,
+    title        VARCHAR(255) NOT NULL,
+    description  TEXT,
+    status       VARCHAR(20) NOT NULL DEFAULT 'DRAFT'
+                     CHECK (status IN ('DRAFT', 'PUBLISHED', 'ARCHIVED')),
+    version      INTEGER NOT NULL DEFAULT 0,
+    created_by   BIGINT NOT NULL REFERENCES users(id),
+    published_at TIMESTAMP,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_flashcard_decks_course ON flashcard_decks(course_id, status);
+
+CREATE TABLE IF NOT EXISTS flashcard_deck_cards (
+    id                 BIGSERIAL PRIMARY KEY,
+    deck_id            BIGINT NOT NULL REFERENCES flashcard_decks(id) ON DELETE CASCADE,
+    front_text         TEXT NOT NULL,
+    back_text          TEXT NOT NULL,
+    order_index        INTEGER NOT NULL DEFAULT 0,
+    revision           INTEGER NOT NULL DEFAULT 1,
+    added_in_version   INTEGER NOT NULL DEFAULT 0,
+    updated_in_version INTEGER NOT NULL DEFAULT 0,
+    is_active          BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_flashcard_deck_cards_deck ON flashcard_deck_cards(deck_id, is_active, order_index);
+
+-- Per-student SM-2 state. Rows are created lazily on first review, so cards
+-- added to a published deck reach every enrolled student without a fan-out.
+CREATE TABLE IF NOT EXISTS flashcard_deck_repetitions (
+    id               BIGSERIAL PRIMARY KEY,
+    student_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    deck_card_id     BIGINT NOT NULL REFERENCES flashcard_deck_cards(id) ON DELETE CASCADE,
+    deck_id          BIGINT NOT NULL REFERENCES flashcard_decks(id) ON DELETE CASCADE,
+    course_id        BIGINT NOT NULL,
+    easiness_factor  FLOAT NOT NULL DEFAULT 2.5,
+    interval_days    INTEGER NOT NULL DEFAULT 1,
+    repetitions      INTEGER NOT NULL DEFAULT 0,
+    quality_last     INTEGER NOT NULL DEFAULT 0,
+    next_review_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    last_reviewed_at TIMESTAMP,
+    seen_revision    INTEGER NOT NULL DEFAULT 1,
+    created_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(student_id, deck_card_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_flashcard_deck_reps_due
+    ON flashcard_deck_repetitions(student_id, course_id, next_review_date);
+
+CREATE TABLE IF NOT EXISTS flashcard_deck_versions (
+    id         BIGSERIAL PRIMARY KEY,
+    deck_id    BIGINT NOT NULL REFERENCES flashcard_decks(id) ON DELETE CASCADE,
+    version    INTEGER NOT NULL,
+    summary    TEXT NOT NULL,
+    changed_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_flashcard_deck_versions_deck ON flashcard_deck_versions(deck_id, version DESC);
+
+DO $$ BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_flashcard_decks_updated_at'
+                   AND tgrelid = 'flashcard_decks'::regclass) THEN
+        CREATE TRIGGER update_flashcard_decks_updated_at
+            BEFORE UPDATE ON flashcard_decks
+            FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_flashcard_deck_cards_updated_at'
+                   AND tgrelid = 'flashcard_deck_cards'::regclass) THEN
+        CREATE TRIGGER update_flashcard_deck_cards_updated_at
+            BEFORE UPDATE ON flashcard_deck_cards
+            FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_flashcard_deck_repetitions_updated_at'
+                   AND tgrelid = 'flashcard_deck_repetitions'::regclass) THEN
+        CREATE TRIGGER update_flashcard_deck_repetitions_updated_at
+            BEFORE UPDATE ON flashcard_deck_repetitions
+            FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END $$;
@@ -0,0 +1,52 @@
+-- V018: Forum moderation
+--
+-- Students report posts/comments; once a target collects enough distinct
+-- reports it is hidden until a moderator (course teacher, co-teacher, org
+-- admin or system admin) reviews it. Moderators can also issue temporary
+-- per-course posting bans.
+
+ALTER TABLE forum_posts
+    ADD COLUMN IF NOT EXISTS is_hidden     BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS hidden_reason VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS report_count  INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE forum_comments
+    ADD COLUMN IF NOT EXISTS is_hidden     BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS hidden_reason VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS report_count  INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS forum_reports (
+    id              BIGSERIAL PRIMARY KEY,
+    target_type     VARCHAR(20) NOT NULL CHECK (target_type IN ('post', 'comment')),
+    target_id       BIGINT NOT NULL,
+    post_id         BIGINT NOT NULL REFERENCES forum_posts(id) ON DELETE CASCADE,
+    course_id       BIGINT NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    reporter_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason          VARCHAR(30) NOT NULL
+                        CHECK (reason IN ('SPAM', 'HARASSMENT', 'INAPPROPRIATE', 'OFF_TOPIC', 'OTHER')),
+    details         TEXT,
+    status          VARCHAR(20) NOT NULL DEFAULT 'OPEN'
+                        CHECK (status IN ('OPEN', 'DISMISSED', 'ACTIONED')),
+    resolved_by     BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    resolution_note TEXT,
+    resolved_at     TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(target_type, target_id, reporter_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_forum_reports_queue ON forum_reports(course_id, status, created_at);
+CREATE INDEX IF NOT EXISTS idx_forum_reports_target ON forum_reports(target_type, target_id);
+
+CREATE TABLE IF NOT EXISTS forum_posting_bans (
+    id         BIGSERIAL PRIMARY KEY,
+    course_id  BIGINT NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    user_id    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    banned_by  BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    reason     TEXT,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_forum_posting_bans_active
+    ON forum_posting_bans(course_id, user_id, expires_at) WHERE revoked_at IS NULL;
@@ -0,0 +1,43 @@
+-- V019: Forum subscriptions, mentions, unread tracking and digests
+--
+-- Users subscribe to a whole forum (section_content of type FORUM) or to a
+-- single thread; posting or commenting subscribes them to the thread
+-- automatically. Activity fans out into forum_notifications, which back the
+-- in-app feed and are rolled up once a day into a digest event per user.
+
+CREATE TABLE IF NOT EXISTS forum_subscriptions (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('forum', 'post')),
+    target_id   BIGINT NOT NULL,
+    course_id   BIGINT NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    is_auto     BOOLEAN NOT NULL DEFAULT false,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, target_type, target_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_forum_subscriptions_target ON forum_subscriptions(target_type, target_id);
+
+-- Last time a user opened a thread; comments newer than this are unread.
+CREATE TABLE IF NOT EXISTS forum_post_reads (
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id      BIGINT NOT NULL REFERENCES forum_posts(id) ON DELETE CASCADE,
+    last_read_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE TABLE IF NOT EXISTS forum_notifications (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    course_id   BIGINT NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    post_id     BIGINT NOT NULL REFERENCES forum_posts(id) ON DELETE CASCADE,
+    comment_id  BIGINT REFERENCES forum_comments(id) ON DELETE CASCADE,
+    type        VARCHAR(20) NOT NULL CHECK (type IN ('NEW_POST', 'REPLY', 'MENTION', 'ACCEPTED')),
+    actor_id    BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    read_at     TIMESTAMP,
+    digested_at TIMESTAMP,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_forum_notifications_user ON forum_notifications(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_forum_notifications_digest ON forum_notifications(user_id) WHERE digested_at IS NULL;
@@ -0,0 +1,25 @@
+-- V020: Forum attachments
+--
+-- Files are uploaded to a forum first (post_id/comment_id NULL) and linked
+-- to exactly one post or comment when it is saved. size_bytes is summed per
+-- course to enforce the attachment quota. Uploads that are never linked, or
+-- whose post/comment was deleted, fall back to NULL and are swept (row and
+-- stored object) after a grace period.
+
+CREATE TABLE IF NOT EXISTS forum_attachments (
+    id           BIGSERIAL PRIMARY KEY,
+    course_id    BIGINT NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    content_id   BIGINT NOT NULL REFERENCES section_content(id) ON DELETE CASCADE,
+    post_id      BIGINT REFERENCES forum_posts(id) ON DELETE SET NULL,
+    comment_id   BIGINT REFERENCES forum_comments(id) ON DELETE SET NULL,
+    uploader_id  BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    file_name    VARCHAR(255) NOT NULL,
+    storage_key  VARCHAR(500) NOT NULL UNIQUE,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes   BIGINT NOT NULL CHECK (size_bytes >= 0),
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_forum_attachments_course ON forum_attachments(course_id);
+CREATE INDEX IF NOT EXISTS idx_forum_attachments_post ON forum_attachments(post_id) WHERE post_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_forum_attachments_comment ON forum_attachments(comment_id) WHERE comment_id IS NOT NULL;
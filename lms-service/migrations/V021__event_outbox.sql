@@ -0,0 +1,33 @@
+-- V021: Transactional outbox for Kafka events
+--
+-- Domain changes that must be announced on Kafka insert their event here in
+-- the same transaction, so the event exists if and only if the change was
+-- committed. A relay publishes PENDING rows in id order per (topic, msg_key),
+-- retrying with backoff; rows that exhaust their attempts become DEAD and
+-- block later events for the same key until an admin replays or discards
+-- them. PUBLISHED rows are pruned after a retention period.
+
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    topic           VARCHAR(255) NOT NULL,
+    msg_key         VARCHAR(255) NOT NULL DEFAULT '',
+    payload         JSONB NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'PENDING'
+                    CHECK (status IN ('PENDING', 'PUBLISHED', 'DEAD', 'DISCARDED')),
+    attempts        INT NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at    TIMESTAMP
+);
+
+-- Relay scan: due rows in order
+CREATE INDEX IF NOT EXISTS idx_event_outbox_pending
+    ON event_outbox(next_attempt_at, id) WHERE status = 'PENDING';
+
+-- Head-of-line check per key
+CREATE INDEX IF NOT EXISTS idx_event_outbox_key_unpublished
+    ON event_outbox(topic, msg_key, id) WHERE status IN ('PENDING', 'DEAD');
+
+CREATE INDEX IF NOT EXISTS idx_event_outbox_published
+    ON event_outbox(published_at) WHERE status = 'PUBLISHED';
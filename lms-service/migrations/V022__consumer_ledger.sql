@@ -0,0 +1,16 @@
+-- V022: Processed-event ledger for Kafka consumers
+--
+-- Each consumer group records the IDs of events it has applied. A
+-- redelivered or replayed event whose ID is already here is acknowledged
+-- without running its handler again. Rows are pruned after a retention
+-- period longer than any realistic redelivery window.
+
+CREATE TABLE IF NOT EXISTS consumer_processed_events (
+    consumer_group VARCHAR(255) NOT NULL,
+    event_id       VARCHAR(255) NOT NULL,
+    processed_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (consumer_group, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_consumer_processed_events_processed_at
+    ON consumer_processed_events(processed_at);
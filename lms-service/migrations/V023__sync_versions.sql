@@ -0,0 +1,19 @@
+-- V023: Version ledger for user/organization sync from the auth service
+--
+-- Lifecycle events carry a version that grows with every change. The
+-- highest version applied per entity is recorded here, so a redelivered or
+-- out-of-order event older than what LMS already holds is ignored. Deletes
+-- leave a tombstone row that blocks stale upserts from resurrecting the
+-- entity.
+
+CREATE TABLE IF NOT EXISTS sync_versions (
+    entity_type VARCHAR(20)  NOT NULL, -- user, org, member
+    entity_id   VARCHAR(100) NOT NULL, -- member rows use '<org_id>:<user_id>'
+    version     BIGINT       NOT NULL,
+    deleted     BOOLEAN      NOT NULL DEFAULT FALSE,
+    updated_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (entity_type, entity_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_versions_updated_at
+    ON sync_versions(entity_type, updated_at);
@@ -0,0 +1,7 @@
+-- V024: Carry trace context through the outbox
+--
+-- The relay publishes events long after the request that produced them has
+-- finished, so the W3C trace headers of that request are stored with the
+-- event and attached to the Kafka message, linking consumers' spans to it.
+
+ALTER TABLE event_outbox ADD COLUMN IF NOT EXISTS trace_context JSONB;
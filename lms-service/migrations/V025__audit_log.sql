@@ -0,0 +1,50 @@
+-- V025: Append-only audit log
+--
+-- One row per administrative or grading action: who did it (actor, role,
+-- calling service), what was done (action, target), the target's state
+-- before and after, and the request it came from. Rows reference users,
+-- organizations and courses by plain id, not foreign key, so the trail
+-- survives deletion of what it describes. A trigger rejects UPDATE and
+-- DELETE; the table is only ever appended to.
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id            BIGSERIAL PRIMARY KEY,
+    occurred_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor_id      BIGINT,                 -- NULL for system actions
+    actor_role    VARCHAR(50) NOT NULL DEFAULT '',
+    actor_service VARCHAR(100) NOT NULL DEFAULT '',
+    action        VARCHAR(100) NOT NULL,
+    target_type   VARCHAR(50) NOT NULL,
+    target_id     VARCHAR(100) NOT NULL,
+    org_id        BIGINT,
+    course_id     BIGINT,
+    reason        TEXT,
+    before_state  JSONB,
+    after_state   JSONB,
+    request_id    VARCHAR(100) NOT NULL DEFAULT '',
+    ip_address    VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent    TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_occurred_at ON audit_log(occurred_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor_id, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target_type, target_id, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_org ON audit_log(org_id, occurred_at DESC) WHERE org_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_log_course ON audit_log(course_id, occurred_at DESC) WHERE course_id IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_log_append_only ON audit_log;
+CREATE TRIGGER trg_audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+DROP TRIGGER IF EXISTS trg_audit_log_no_truncate ON audit_log;
+CREATE TRIGGER trg_audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
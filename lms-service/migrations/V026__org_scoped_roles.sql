@@ -0,0 +1,86 @@
+-- V026: Organization-scoped roles
+--
+-- role_definitions gains a scope. GLOBAL roles are the existing user_roles
+-- names (STUDENT, TEACHER, ADMIN, ...). ORG roles are what
+-- organization_members.org_role refers to: template rows (org_id NULL)
+-- apply to every organization, and an organization may define its own rows
+-- (org_id set). A member's org_role resolves to the organization's own
+-- definition of that name first, then to the template.
+
+-- 1. Scope and owning organization
+ALTER TABLE role_definitions
+    ADD COLUMN IF NOT EXISTS scope  VARCHAR(10) NOT NULL DEFAULT 'GLOBAL'
+        CHECK (scope IN ('GLOBAL', 'ORG')),
+    ADD COLUMN IF NOT EXISTS org_id BIGINT REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE role_definitions DROP CONSTRAINT IF EXISTS role_definitions_org_scope_check;
+ALTER TABLE role_definitions ADD CONSTRAINT role_definitions_org_scope_check
+    CHECK (scope = 'ORG' OR org_id IS NULL);
+
+-- 2. Names are unique per scope and organization, not globally
+ALTER TABLE role_definitions DROP CONSTRAINT IF EXISTS role_definitions_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_role_definitions_scope_name
+    ON role_definitions (scope, COALESCE(org_id, 0), name);
+CREATE INDEX IF NOT EXISTS idx_role_definitions_org ON role_definitions (org_id) WHERE org_id IS NOT NULL;
+
+-- 3. Organization permissions
+INSERT INTO permissions (code, module, description) VALUES
+    ('ORG_MANAGE',        'ORG', 'Edit organization profile and settings'),
+    ('ORG_MEMBER_MANAGE', 'ORG', 'Add, remove and change roles of organization members'),
+    ('ORG_ROLE_MANAGE',   'ORG', 'Create organization roles and assign their permissions')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT rd.id, p.id
+FROM role_definitions rd
+CROSS JOIN permissions p
+WHERE rd.scope = 'GLOBAL' AND rd.name = 'ADMIN'
+  AND p.module = 'ORG'
+ON CONFLICT DO NOTHING;
+
+-- 4. Template org roles, matching what checkOrgAccess used to hard-code
+INSERT INTO role_definitions (name, display_name, description, is_system, scope) VALUES
+    ('OWNER',  'Owner',  'Full control of the organization',                 true, 'ORG'),
+    ('ADMIN',  'Admin',  'Manages the organization''s courses and members',  true, 'ORG'),
+    ('MEMBER', 'Member', 'Belongs to the organization',                       true, 'ORG')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT rd.id, p.id
+FROM role_definitions rd
+CROSS JOIN permissions p
+WHERE rd.scope = 'ORG' AND rd.org_id IS NULL AND rd.name = 'OWNER'
+  AND p.code IN (
+    'COURSE_VIEW', 'COURSE_CREATE', 'COURSE_EDIT', 'COURSE_DELETE', 'COURSE_PUBLISH',
+    'QUIZ_CREATE', 'QUIZ_EDIT', 'QUIZ_DELETE', 'QUIZ_GRADE',
+    'ENROLLMENT_MANAGE', 'ENROLLMENT_BULK',
+    'AI_INDEX', 'AI_GENERATE', 'ANALYTICS_VIEW',
+    'ORG_MANAGE', 'ORG_MEMBER_MANAGE', 'ORG_ROLE_MANAGE'
+  )
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT rd.id, p.id
+FROM role_definitions rd
+CROSS JOIN permissions p
+WHERE rd.scope = 'ORG' AND rd.org_id IS NULL AND rd.name = 'ADMIN'
+  AND p.code IN (
+    'COURSE_VIEW', 'COURSE_CREATE', 'COURSE_EDIT', 'COURSE_DELETE', 'COURSE_PUBLISH',
+    'QUIZ_CREATE', 'QUIZ_EDIT', 'QUIZ_DELETE', 'QUIZ_GRADE',
+    'ENROLLMENT_MANAGE', 'ENROLLMENT_BULK',
+    'AI_INDEX', 'AI_GENERATE', 'ANALYTICS_VIEW',
+    'ORG_MANAGE', 'ORG_MEMBER_MANAGE'
+  )
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT rd.id, p.id
+FROM role_definitions rd
+CROSS JOIN permissions p
+WHERE rd.scope = 'ORG' AND rd.org_id IS NULL AND rd.name = 'MEMBER'
+  AND p.code IN ('COURSE_VIEW')
+ON CONFLICT DO NOTHING;
+
+-- 5. org_role may now name an organization's own role
+ALTER TABLE organization_members DROP CONSTRAINT IF EXISTS organization_members_org_role_check;
+ALTER TABLE organization_members ALTER COLUMN org_role TYPE VARCHAR(50);
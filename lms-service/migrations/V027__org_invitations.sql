@@ -0,0 +1,47 @@
+-- V027: Organization invitations, join codes and email-domain auto-join
+--
+-- Invitations are addressed to an email and carry a single-use token; only
+-- its SHA-256 is stored, the token itself travels in the invitation email.
+-- Join codes are short shared codes (e.g. written on a whiteboard) with an
+-- optional use limit and expiry. Email domains that join automatically live
+-- in organizations.settings.allowed_email_domains.
+
+CREATE TABLE IF NOT EXISTS org_invitations (
+    id          BIGSERIAL PRIMARY KEY,
+    org_id      BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    email       VARCHAR(255) NOT NULL,
+    org_role    VARCHAR(50) NOT NULL DEFAULT 'MEMBER',
+    token_hash  CHAR(64) NOT NULL UNIQUE,
+    invited_by  BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    expires_at  TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    accepted_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at  TIMESTAMP,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- At most one open invitation per address and organization; re-inviting
+-- revokes the previous one
+CREATE UNIQUE INDEX IF NOT EXISTS uq_org_invitations_open
+    ON org_invitations (org_id, LOWER(email))
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_org_invitations_org ON org_invitations (org_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS org_join_codes (
+    id         BIGSERIAL PRIMARY KEY,
+    org_id     BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    code       VARCHAR(32) NOT NULL UNIQUE,
+    org_role   VARCHAR(50) NOT NULL DEFAULT 'MEMBER',
+    max_uses   INT CHECK (max_uses IS NULL OR max_uses > 0),
+    use_count  INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP,
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_org_join_codes_org ON org_join_codes (org_id, created_at DESC);
+
+-- Domain lookup on first login
+CREATE INDEX IF NOT EXISTS idx_organizations_email_domains
+    ON organizations USING GIN ((settings->'allowed_email_domains'));
@@ -0,0 +1,48 @@
+-- V028: Course groups
+--
+-- Teachers split a course's students into named groups (a lab section, a
+-- project team). Only students with an accepted enrollment belong to a group:
+-- when an enrollment is removed or leaves ACCEPTED, its group memberships go
+-- with it. Group changes are announced on lms.course.membership so
+-- chat-service can keep a channel per group.
+
+CREATE TABLE IF NOT EXISTS course_groups (
+    id         BIGSERIAL PRIMARY KEY,
+    course_id  BIGINT NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    name       VARCHAR(120) NOT NULL,
+    created_by BIGINT NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_course_groups_course ON course_groups(course_id);
+
+CREATE TABLE IF NOT EXISTS course_group_members (
+    group_id BIGINT NOT NULL REFERENCES course_groups(id) ON DELETE CASCADE,
+    user_id  BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    added_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    added_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_course_group_members_user ON course_group_members(user_id);
+
+CREATE OR REPLACE FUNCTION drop_course_group_memberships()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.status = 'ACCEPTED' THEN
+        RETURN NULL;
+    END IF;
+    DELETE FROM course_group_members gm
+    USING course_groups g
+    WHERE gm.group_id = g.id
+      AND g.course_id = OLD.course_id
+      AND gm.user_id = OLD.student_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS enrollments_drop_group_memberships ON enrollments;
+CREATE TRIGGER enrollments_drop_group_memberships
+    AFTER DELETE OR UPDATE OF status ON enrollments
+    FOR EACH ROW EXECUTE FUNCTION drop_course_group_memberships();
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"example/hello/pkg/logger"
+	"example/hello/pkg/telemetry"
 )
 
 // Client is the HTTP client for the ai-service.
@@ -32,7 +33,8 @@ func NewClient() *Client {
 		baseURL: baseURL,
 		secret:  secret,
 		httpClient: &http.Client{
-			Timeout: 600 * time.Second, // quiz gen can take 6+ LLM calls (~60s each)
+			Timeout:   600 * time.Second, // quiz gen can take 6+ LLM calls (~60s each)
+			Transport: telemetry.Transport(nil),
 		},
 	}
 }
@@ -385,6 +387,40 @@ func (c *Client) ListFlashcards(ctx context.Context, studentID, courseID int64,
 	return &resp, nil
 }
 
+// AIFlashcardExport is a flashcard with its SM-2 state, as returned by the
+// deck export endpoint. Dates come back as plain strings from asyncpg.
+type AIFlashcardExport struct {
+	AIFlashcard
+	StudentID      int64    `json:"student_id"`
+	EasinessFactor *float64 `json:"easiness_factor"`
+	IntervalDays   *int     `json:"interval_days"`
+	Repetitions    *int     `json:"repetitions"`
+	QualityLast    *int     `json:"quality_last"`
+	NextReviewDate *string  `json:"next_review_date"`
+	LastReviewedAt *string  `json:"last_reviewed_at"`
+}
+
+type FlashcardExportResponse struct {
+	Flashcards []AIFlashcardExport `json:"flashcards"`
+}
+
+// ExportFlashcards lists a course's flashcards with scheduling state. A nil
+// studentID exports every student's cards (teacher course export).
+func (c *Client) ExportFlashcards(ctx context.Context, courseID int64, studentID, nodeID *int64) (*FlashcardExportResponse, error) {
+	path := fmt.Sprintf("/ai/flashcards/export?course_id=%d", courseID)
+	if studentID != nil {
+		path += fmt.Sprintf("&student_id=%d", *studentID)
+	}
+	if nodeID != nil {
+		path += fmt.Sprintf("&node_id=%d", *nodeID)
+	}
+	var resp FlashcardExportResponse
+	if err := c.get(ctx, path, &resp); err != nil {
+		return nil, fmt.Errorf("ai.ExportFlashcards: %w", err)
+	}
+	return &resp, nil
+}
+
 type ReviewFlashcardRequest struct {
 	StudentID   int64 `json:"student_id"`
 	FlashcardID int64 `json:"flashcard_id"`
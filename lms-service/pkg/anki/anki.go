@@ -0,0 +1,286 @@
+// pkg/anki/anki.go
+// Import/export of flashcard decks in Anki-compatible formats (.apkg and CSV).
+// The package only deals with the file formats; callers map Card values to
+// and from their own flashcard models.
+package anki
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxImportCards bounds how many cards a single import may contain so a
+// hostile upload cannot flood the flashcard store.
+const MaxImportCards = 5000
+
+// DefaultEasinessFactor is the SM-2 starting ease (Anki stores it as 2500‰).
+const DefaultEasinessFactor = 2.5
+
+const dateLayout = "2006-01-02"
+
+var (
+	ErrEmptyDeck        = errors.New("anki: deck contains no cards")
+	ErrTooManyCards     = fmt.Errorf("anki: deck exceeds %d cards", MaxImportCards)
+	ErrInvalidPackage   = errors.New("anki: not a valid .apkg package")
+	ErrUnsupportedAnki2 = errors.New("anki: collection format is not supported (re-export with \"Support older Anki versions\")")
+	ErrInvalidCSV       = errors.New("anki: invalid csv")
+)
+
+// IsInvalidDeck reports whether err is a problem with the deck itself, which
+// the uploader can fix, rather than a failure to process it
+func IsInvalidDeck(err error) bool {
+	for _, target := range []error{ErrEmptyDeck, ErrTooManyCards, ErrInvalidPackage, ErrUnsupportedAnki2, ErrInvalidCSV} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Card is a single front/back card plus its SM-2 scheduling state. A zero
+// Repetitions value with nil dates means the card has never been reviewed.
+type Card struct {
+	Front          string
+	Back           string
+	EasinessFactor float64
+	IntervalDays   int
+	Repetitions    int
+	QualityLast    int
+	NextReviewDate *time.Time
+	LastReviewedAt *time.Time
+}
+
+// HasHistory reports whether the card carries review state worth preserving.
+func (c Card) HasHistory() bool {
+	return c.Repetitions > 0 || c.LastReviewedAt != nil
+}
+
+var csvHeader = []string{
+	"front", "back", "easiness_factor", "interval_days", "repetitions", "next_review_date", "last_reviewed_at",
+}
+
+// WriteCSV writes cards as CSV with a header row. Scheduling columns are
+// left empty for cards without review history. Fronts and backs that a
+// spreadsheet would run as a formula are prefixed with "'".
+func WriteCSV(w io.Writer, cards []Card) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, c := range cards {
+		row := []string{escapeCell(c.Front), escapeCell(c.Back), "", "", "", "", ""}
+		if c.HasHistory() {
+			row[2] = strconv.FormatFloat(c.EasinessFactor, 'f', 2, 64)
+			row[3] = strconv.Itoa(c.IntervalDays)
+			row[4] = strconv.Itoa(c.Repetitions)
+		}
+		if c.NextReviewDate != nil {
+			row[5] = c.NextReviewDate.Format(dateLayout)
+		}
+		if c.LastReviewedAt != nil {
+			row[6] = c.LastReviewedAt.UTC().Format(time.RFC3339)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV parses a CSV (or Anki "Notes in Plain Text" tab-separated) deck.
+// Only the first two columns are required; a header row is detected by a
+// first cell equal to "front". Rows with an empty front or back are skipped.
+// The "'" WriteCSV puts before formula-like cells is removed again.
+func ReadCSV(r io.Reader) ([]Card, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	directives, body := splitDirectives(data)
+	cr := csv.NewReader(bytes.NewReader(body))
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.Comma = detectSeparator(directives["separator"], body)
+
+	var cards []Card
+	for line := 0; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidCSV, line+1, err)
+		}
+		if line == 0 && len(rec) > 0 && strings.EqualFold(strings.TrimSpace(rec[0]), "front") {
+			continue
+		}
+		if len(rec) < 2 {
+			continue
+		}
+		card := Card{
+			Front:          unescapeCell(strings.TrimSpace(rec[0])),
+			Back:           unescapeCell(strings.TrimSpace(rec[1])),
+			EasinessFactor: DefaultEasinessFactor,
+		}
+		if card.Front == "" || card.Back == "" {
+			continue
+		}
+		if err := parseScheduleColumns(&card, rec[2:]); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidCSV, line+1, err)
+		}
+		cards = append(cards, card)
+		if len(cards) > MaxImportCards {
+			return nil, ErrTooManyCards
+		}
+	}
+	if len(cards) == 0 {
+		return nil, ErrEmptyDeck
+	}
+	return cards, nil
+}
+
+// ankiDirectives are the header keys Anki writes as "#key:value" lines at
+// the top of its text exports.
+var ankiDirectives = map[string]bool{
+	"separator": true, "html": true, "tags": true, "columns": true, "notetype": true, "deck": true,
+	"notetype column": true, "deck column": true, "tags column": true, "guid column": true,
+}
+
+// splitDirectives takes Anki's header lines off the top of data, returning
+// their values by key and the rest of the file. Only known keys are taken,
+// so a first card whose front starts with '#' stays in the body.
+func splitDirectives(data []byte) (map[string]string, []byte) {
+	directives := make(map[string]string)
+	for len(data) > 0 {
+		line, rest, _ := bytes.Cut(data, []byte("\n"))
+		key, value, ok := strings.Cut(strings.TrimSpace(string(line)), ":")
+		if !ok || !strings.HasPrefix(key, "#") || !ankiDirectives[strings.ToLower(key[1:])] {
+			break
+		}
+		directives[strings.ToLower(key[1:])] = strings.TrimSpace(value)
+		data = rest
+	}
+	return directives, data
+}
+
+// detectSeparator honours Anki's "#separator:" directive and otherwise
+// guesses from the first data line.
+func detectSeparator(directive string, data []byte) rune {
+	switch strings.ToLower(directive) {
+	case "tab":
+		return '\t'
+	case "semicolon":
+		return ';'
+	case "comma":
+		return ','
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.Contains(line, "\t") {
+			return '\t'
+		}
+		return ','
+	}
+	return ','
+}
+
+// formulaPrefixes are the leading characters that make a spreadsheet treat
+// a cell as a formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// needsEscape reports whether s would be read as a formula, or already
+// starts with the "'" escapeCell adds, which must itself be escaped for the
+// cell to round-trip.
+func needsEscape(s string) bool {
+	if s == "" {
+		return false
+	}
+	if s[0] == '\'' {
+		return needsEscape(s[1:])
+	}
+	return strings.IndexByte(formulaPrefixes, s[0]) >= 0
+}
+
+// escapeCell prefixes formula-like text with "'" so spreadsheets show it
+// as text.
+func escapeCell(s string) string {
+	if needsEscape(s) {
+		return "'" + s
+	}
+	return s
+}
+
+// unescapeCell undoes escapeCell.
+func unescapeCell(s string) string {
+	if strings.HasPrefix(s, "'") && needsEscape(s[1:]) {
+		return s[1:]
+	}
+	return s
+}
+
+func parseScheduleColumns(c *Card, cols []string) error {
+	get := func(i int) string {
+		if i < len(cols) {
+			return strings.TrimSpace(cols[i])
+		}
+		return ""
+	}
+	if v := get(0); v != "" {
+		ef, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid easiness_factor %q", v)
+		}
+		c.EasinessFactor = clampEase(ef)
+	}
+	if v := get(1); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid interval_days %q", v)
+		}
+		c.IntervalDays = n
+	}
+	if v := get(2); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid repetitions %q", v)
+		}
+		c.Repetitions = n
+	}
+	if v := get(3); v != "" {
+		t, err := time.Parse(dateLayout, v)
+		if err != nil {
+			return fmt.Errorf("invalid next_review_date %q", v)
+		}
+		c.NextReviewDate = &t
+	}
+	if v := get(4); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return fmt.Errorf("invalid last_reviewed_at %q", v)
+		}
+		c.LastReviewedAt = &t
+	}
+	return nil
+}
+
+// clampEase keeps the easiness factor inside the SM-2 range the ai-service
+// scheduler accepts.
+func clampEase(ef float64) float64 {
+	if ef < 1.3 {
+		return 1.3
+	}
+	if ef > 5 {
+		return 5
+	}
+	return ef
+}
@@ -0,0 +1,112 @@
+package anki
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPKGRoundTripPreservesSchedule(t *testing.T) {
+	next := startOfDay(time.Now().UTC()).Add(6 * dayDuration)
+	last := time.Now().UTC().Add(-2 * time.Hour).Truncate(time.Millisecond)
+	cards := []Card{
+		{Front: "Thủ đô của Việt Nam?", Back: "Hà Nội", EasinessFactor: DefaultEasinessFactor},
+		{Front: "2 + 2", Back: "4", EasinessFactor: 2.36, IntervalDays: 6, Repetitions: 2, QualityLast: 4, NextReviewDate: &next, LastReviewedAt: &last},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAPKG(&buf, "Course 1", cards); err != nil {
+		t.Fatalf("WriteAPKG: %v", err)
+	}
+	got, err := ReadAPKG(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadAPKG: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 cards, got %d", len(got))
+	}
+
+	if got[0].Front != cards[0].Front || got[0].HasHistory() {
+		t.Errorf("new card mismatch: %+v", got[0])
+	}
+	r := got[1]
+	if r.IntervalDays != 6 || r.Repetitions != 2 || r.EasinessFactor != 2.36 || r.QualityLast != 4 {
+		t.Errorf("schedule not preserved: %+v", r)
+	}
+	if r.NextReviewDate == nil || !r.NextReviewDate.Equal(next) {
+		t.Errorf("next review = %v; want %v", r.NextReviewDate, next)
+	}
+	if r.LastReviewedAt == nil || !r.LastReviewedAt.Equal(last) {
+		t.Errorf("last reviewed = %v; want %v", r.LastReviewedAt, last)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{"header and schedule", "front,back,easiness_factor,interval_days,repetitions\nQ1,A1,2.6,3,1\nQ2,A2,,,\n", 2},
+		{"anki plain text export", "#separator:tab\n#html:false\nQ1\tA1\nQ2\tA2\n", 2},
+		{"skips incomplete rows", "Q1,A1\nonly-front\n,A3\n", 1},
+		{"front starting with #", "#hashtag,A1\n#tags:x,A2\n", 2},
+		{"directive then # front", "#separator:semicolon\n#1;A1\n", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadCSV(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ReadCSV: %v", err)
+			}
+			if len(got) != tt.want {
+				t.Fatalf("got %d cards; want %d", len(got), tt.want)
+			}
+		})
+	}
+
+	if _, err := ReadCSV(strings.NewReader("front,back\n")); err != ErrEmptyDeck {
+		t.Errorf("empty deck err = %v; want ErrEmptyDeck", err)
+	}
+	_, err := ReadCSV(strings.NewReader("q,a,not-a-number\n"))
+	if !errors.Is(err, ErrInvalidCSV) || !IsInvalidDeck(err) {
+		t.Errorf("bad schedule column err = %v; want ErrInvalidCSV", err)
+	}
+	if IsInvalidDeck(io.ErrUnexpectedEOF) {
+		t.Error("IsInvalidDeck(io.ErrUnexpectedEOF) = true; want false")
+	}
+}
+
+func TestCSVRoundTripEscapesFormulas(t *testing.T) {
+	fronts := []string{"=SUM(A1:A2)", "+1", "-5 degrees", "@mention", "'=already quoted", "'plain quote", "#hashtag"}
+	cards := make([]Card, len(fronts))
+	for i, f := range fronts {
+		cards[i] = Card{Front: f, Back: "=back"}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, cards); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	for _, cell := range []string{"'=SUM(A1:A2)", "'+1", "'-5 degrees", "'@mention", "''=already quoted", "'=back"} {
+		if !strings.Contains(buf.String(), cell) {
+			t.Errorf("export lacks escaped cell %q:\n%s", cell, buf.String())
+		}
+	}
+
+	got, err := ReadCSV(&buf)
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+	if len(got) != len(fronts) {
+		t.Fatalf("got %d cards; want %d", len(got), len(fronts))
+	}
+	for i, c := range got {
+		if c.Front != fronts[i] || c.Back != "=back" {
+			t.Errorf("card %d = %q/%q; want %q/%q", i, c.Front, c.Back, fronts[i], "=back")
+		}
+	}
+}
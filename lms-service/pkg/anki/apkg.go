@@ -0,0 +1,461 @@
+// pkg/anki/apkg.go
+// .apkg is a zip holding a SQLite "collection.anki2" database plus a "media"
+// JSON index. We write the legacy schema (v11) every Anki client can import
+// and read both collection.anki2 and collection.anki21.
+package anki
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	fieldSeparator = "\x1f"
+	deckID         = int64(1700000000001)
+	modelID        = int64(1700000000002)
+	dayDuration    = 24 * time.Hour
+
+	// maxCollectionBytes caps the decompressed SQLite file (zip-bomb guard).
+	maxCollectionBytes = 256 << 20
+)
+
+// Anki card.type / card.queue values.
+const (
+	cardTypeNew        = 0
+	cardTypeLearning   = 1
+	cardTypeReview     = 2
+	cardTypeRelearning = 3
+)
+
+const schemaSQL = `
+CREATE TABLE col (id integer primary key, crt integer not null, mod integer not null, scm integer not null, ver integer not null, dty integer not null, usn integer not null, ls integer not null, conf text not null, models text not null, decks text not null, dconf text not null, tags text not null);
+CREATE TABLE notes (id integer primary key, guid text not null, mid integer not null, mod integer not null, usn integer not null, tags text not null, flds text not null, sfld integer not null, csum integer not null, flags integer not null, data text not null);
+CREATE TABLE cards (id integer primary key, nid integer not null, did integer not null, ord integer not null, mod integer not null, usn integer not null, type integer not null, queue integer not null, due integer not null, ivl integer not null, factor integer not null, reps integer not null, lapses integer not null, left integer not null, odue integer not null, odid integer not null, flags integer not null, data text not null);
+CREATE TABLE revlog (id integer primary key, cid integer not null, usn integer not null, ease integer not null, ivl integer not null, lastIvl integer not null, factor integer not null, time integer not null, type integer not null);
+CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null);
+CREATE INDEX ix_notes_usn on notes (usn);
+CREATE INDEX ix_cards_usn on cards (usn);
+CREATE INDEX ix_revlog_usn on revlog (usn);
+CREATE INDEX ix_cards_nid on cards (nid);
+CREATE INDEX ix_cards_sched on cards (did, queue, due);
+CREATE INDEX ix_revlog_cid on revlog (cid);
+CREATE INDEX ix_notes_csum on notes (csum);
+`
+
+// WriteAPKG writes cards as a single-deck Anki package using the built-in
+// "Basic" note type. Review state is mapped onto Anki's card columns so the
+// deck keeps its schedule after import; the last review becomes a revlog row.
+func WriteAPKG(w io.Writer, deckName string, cards []Card) error {
+	if len(cards) == 0 {
+		return ErrEmptyDeck
+	}
+
+	dir, err := os.MkdirTemp("", "apkg-export-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	dbPath := filepath.Join(dir, "collection.anki2")
+
+	if err := writeCollection(dbPath, deckName, cards); err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	f, err := zw.Create("collection.anki2")
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, src)
+	src.Close()
+	if err != nil {
+		return err
+	}
+	media, err := zw.Create("media")
+	if err != nil {
+		return err
+	}
+	if _, err := media.Write([]byte("{}")); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeCollection(dbPath, deckName string, cards []Card) error {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(schemaSQL); err != nil {
+		return fmt.Errorf("anki: create schema: %w", err)
+	}
+
+	now := time.Now().UTC()
+	crt := startOfDay(now)
+	for _, c := range cards {
+		if c.NextReviewDate != nil && c.NextReviewDate.Before(crt) {
+			crt = startOfDay(*c.NextReviewDate)
+		}
+	}
+
+	models, decks, dconf, conf := collectionJSON(deckName, now)
+	if _, err := db.Exec(
+		`INSERT INTO col VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')`,
+		crt.Unix(), now.UnixMilli(), now.UnixMilli(), conf, models, decks, dconf,
+	); err != nil {
+		return fmt.Errorf("anki: write col: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	baseID := now.UnixMilli()
+	revlogIDs := make(map[int64]bool)
+	for i, c := range cards {
+		id := baseID + int64(i)
+		flds := c.Front + fieldSeparator + c.Back
+		if _, err := tx.Exec(
+			`INSERT INTO notes VALUES (?, ?, ?, ?, -1, '', ?, ?, ?, 0, '')`,
+			id, noteGUID(c), modelID, now.Unix(), flds, c.Front, fieldChecksum(c.Front),
+		); err != nil {
+			return fmt.Errorf("anki: write note: %w", err)
+		}
+
+		ctype, due := cardTypeNew, int64(i)
+		ivl, reps := 0, 0
+		if c.HasHistory() {
+			ctype = cardTypeReview
+			ivl, reps = c.IntervalDays, c.Repetitions
+			next := now
+			if c.NextReviewDate != nil {
+				next = *c.NextReviewDate
+			}
+			due = int64(startOfDay(next).Sub(crt) / dayDuration)
+		}
+		factor := int(easeOrDefault(c.EasinessFactor) * 1000)
+		if _, err := tx.Exec(
+			`INSERT INTO cards VALUES (?, ?, ?, 0, ?, -1, ?, ?, ?, ?, ?, ?, 0, 0, 0, 0, 0, '')`,
+			id, id, deckID, now.Unix(), ctype, ctype, due, ivl, factor, reps,
+		); err != nil {
+			return fmt.Errorf("anki: write card: %w", err)
+		}
+
+		if c.LastReviewedAt != nil {
+			// revlog ids are review timestamps in ms and must be unique.
+			revID := c.LastReviewedAt.UnixMilli()
+			for revlogIDs[revID] {
+				revID++
+			}
+			revlogIDs[revID] = true
+			if _, err := tx.Exec(
+				`INSERT INTO revlog VALUES (?, ?, -1, ?, ?, 0, ?, 0, 1)`,
+				revID, id, qualityToEase(c.QualityLast), ivl, factor,
+			); err != nil {
+				return fmt.Errorf("anki: write revlog: %w", err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// ReadAPKG extracts the cards of every deck in an Anki package. Only the first
+// two fields of each note are used (front/back) and HTML is reduced to text.
+func ReadAPKG(r io.ReaderAt, size int64) ([]Card, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrInvalidPackage
+	}
+
+	var entry *zip.File
+	for _, name := range []string{"collection.anki21", "collection.anki2"} {
+		for _, f := range zr.File {
+			if f.Name == name {
+				entry = f
+				break
+			}
+		}
+		if entry != nil {
+			break
+		}
+	}
+	if entry == nil {
+		for _, f := range zr.File {
+			if f.Name == "collection.anki21b" {
+				return nil, ErrUnsupportedAnki2
+			}
+		}
+		return nil, ErrInvalidPackage
+	}
+
+	dir, err := os.MkdirTemp("", "apkg-import-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	dbPath := filepath.Join(dir, "collection.db")
+
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, ErrInvalidPackage
+	}
+	dst, err := os.Create(dbPath)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	n, err := io.Copy(dst, io.LimitReader(rc, maxCollectionBytes+1))
+	rc.Close()
+	dst.Close()
+	if err != nil {
+		return nil, ErrInvalidPackage
+	}
+	if n > maxCollectionBytes {
+		return nil, ErrTooManyCards
+	}
+
+	return readCollection(dbPath)
+}
+
+func readCollection(dbPath string) ([]Card, error) {
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var crtUnix int64
+	if err := db.QueryRow(`SELECT crt FROM col LIMIT 1`).Scan(&crtUnix); err != nil {
+		return nil, ErrInvalidPackage
+	}
+	crt := time.Unix(crtUnix, 0).UTC()
+
+	// One card per note: the lowest template ordinal is the "front → back" card.
+	rows, err := db.Query(`
+		SELECT n.flds, c.type, c.due, c.ivl, c.factor, c.reps,
+		       (SELECT MAX(id) FROM revlog WHERE cid = c.id),
+		       (SELECT ease FROM revlog WHERE cid = c.id ORDER BY id DESC LIMIT 1)
+		FROM notes n
+		JOIN cards c ON c.nid = n.id
+		WHERE c.ord = (SELECT MIN(ord) FROM cards WHERE nid = n.id)
+		ORDER BY n.id`)
+	if err != nil {
+		return nil, ErrInvalidPackage
+	}
+	defer rows.Close()
+
+	today := startOfDay(time.Now().UTC())
+	var cards []Card
+	for rows.Next() {
+		var (
+			flds                     string
+			ctype, ivl, factor, reps int
+			due                      int64
+			lastReviewMs, lastEase   sql.NullInt64
+		)
+		if err := rows.Scan(&flds, &ctype, &due, &ivl, &factor, &reps, &lastReviewMs, &lastEase); err != nil {
+			return nil, err
+		}
+		parts := strings.Split(flds, fieldSeparator)
+		if len(parts) < 2 {
+			continue
+		}
+		card := Card{
+			Front:          htmlToText(parts[0]),
+			Back:           htmlToText(parts[1]),
+			EasinessFactor: DefaultEasinessFactor,
+		}
+		if card.Front == "" || card.Back == "" {
+			continue
+		}
+
+		if ctype != cardTypeNew {
+			if factor > 0 {
+				card.EasinessFactor = clampEase(float64(factor) / 1000)
+			}
+			card.IntervalDays = max(ivl, 0)
+			card.Repetitions = reps
+			var next time.Time
+			switch ctype {
+			case cardTypeReview:
+				next = crt.Add(time.Duration(due) * dayDuration)
+			case cardTypeLearning, cardTypeRelearning:
+				// Learning steps store an epoch-seconds due timestamp.
+				next = time.Unix(due, 0).UTC()
+			}
+			if next.IsZero() || next.Before(today) {
+				next = today
+			}
+			next = startOfDay(next)
+			card.NextReviewDate = &next
+		}
+		if lastReviewMs.Valid {
+			t := time.UnixMilli(lastReviewMs.Int64).UTC()
+			card.LastReviewedAt = &t
+		}
+		if lastEase.Valid {
+			card.QualityLast = easeToQuality(int(lastEase.Int64))
+		}
+
+		cards = append(cards, card)
+		if len(cards) > MaxImportCards {
+			return nil, ErrTooManyCards
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(cards) == 0 {
+		return nil, ErrEmptyDeck
+	}
+	return cards, nil
+}
+
+// collectionJSON returns the JSON blobs Anki expects in the col row.
+func collectionJSON(deckName string, now time.Time) (models, decks, dconf, conf string) {
+	mod := now.Unix()
+	model := map[string]interface{}{
+		"id": modelID, "name": "Basic", "type": 0, "mod": mod, "usn": -1, "sortf": 0, "did": deckID,
+		"tmpls": []map[string]interface{}{{
+			"name": "Card 1", "ord": 0, "qfmt": "{{Front}}",
+			"afmt": "{{FrontSide}}<hr id=answer>{{Back}}", "did": nil, "bqfmt": "", "bafmt": "",
+		}},
+		"flds": []map[string]interface{}{
+			{"name": "Front", "ord": 0, "sticky": false, "rtl": false, "font": "Arial", "size": 20, "media": []string{}},
+			{"name": "Back", "ord": 1, "sticky": false, "rtl": false, "font": "Arial", "size": 20, "media": []string{}},
+		},
+		"css":       ".card { font-family: arial; font-size: 20px; text-align: center; }",
+		"latexPre":  "\\documentclass[12pt]{article}\n\\begin{document}\n",
+		"latexPost": "\\end{document}",
+		"tags":      []string{}, "vers": []string{},
+		"req": []interface{}{[]interface{}{0, "all", []int{0}}},
+	}
+	deck := func(id int64, name string) map[string]interface{} {
+		return map[string]interface{}{
+			"id": id, "name": name, "desc": "", "mod": mod, "usn": -1, "collapsed": false,
+			"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+			"dyn": 0, "conf": 1, "extendNew": 10, "extendRev": 50,
+		}
+	}
+	deckConf := map[string]interface{}{
+		"id": 1, "name": "Default", "mod": 0, "usn": 0, "maxTaken": 60, "autoplay": true, "timer": 0, "replayq": true, "dyn": false,
+		"new":   map[string]interface{}{"delays": []int{1, 10}, "ints": []int{1, 4, 7}, "initialFactor": 2500, "order": 1, "perDay": 20, "bury": true, "separate": true},
+		"rev":   map[string]interface{}{"perDay": 200, "ease4": 1.3, "fuzz": 0.05, "maxIvl": 36500, "ivlFct": 1, "bury": true, "minSpace": 1},
+		"lapse": map[string]interface{}{"delays": []int{10}, "mult": 0, "minInt": 1, "leechFails": 8, "leechAction": 0},
+	}
+	collConf := map[string]interface{}{
+		"nextPos": 1, "estTimes": true, "activeDecks": []int64{deckID}, "sortType": "noteFld", "timeLim": 0,
+		"sortBackwards": false, "addToCur": true, "curDeck": deckID, "newSpread": 0, "dueCounts": true,
+		"curModel": strconv.FormatInt(modelID, 10), "collapseTime": 1200,
+	}
+
+	mustJSON := func(v interface{}) string {
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+	models = mustJSON(map[string]interface{}{strconv.FormatInt(modelID, 10): model})
+	decks = mustJSON(map[string]interface{}{
+		"1":                           deck(1, "Default"),
+		strconv.FormatInt(deckID, 10): deck(deckID, deckName),
+	})
+	dconf = mustJSON(map[string]interface{}{"1": deckConf})
+	conf = mustJSON(collConf)
+	return
+}
+
+// noteGUID is stable for identical content so re-importing an exported deck
+// into Anki updates the existing notes instead of duplicating them.
+func noteGUID(c Card) string {
+	sum := sha1.Sum([]byte(c.Front + fieldSeparator + c.Back))
+	return hex.EncodeToString(sum[:8])
+}
+
+// fieldChecksum mirrors Anki's csum: first 8 hex digits of sha1(stripped field).
+func fieldChecksum(field string) int64 {
+	sum := sha1.Sum([]byte(htmlToText(field)))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
+
+var (
+	breakTagRe = regexp.MustCompile(`(?i)<br\s*/?>|</div>|</p>`)
+	anyTagRe   = regexp.MustCompile(`<[^>]*>`)
+	soundRe    = regexp.MustCompile(`\[sound:[^\]]*\]`)
+)
+
+func htmlToText(s string) string {
+	s = breakTagRe.ReplaceAllString(s, "\n")
+	s = anyTagRe.ReplaceAllString(s, "")
+	s = soundRe.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(strings.ReplaceAll(s, "\u00a0", " "))
+}
+
+// qualityToEase maps an SM-2 quality (0-5) onto Anki's answer buttons (1-4).
+func qualityToEase(q int) int {
+	switch {
+	case q <= 2:
+		return 1
+	case q == 3:
+		return 2
+	case q == 4:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// easeToQuality is the inverse of qualityToEase.
+func easeToQuality(ease int) int {
+	switch ease {
+	case 1:
+		return 1
+	case 2:
+		return 3
+	case 3:
+		return 4
+	default:
+		return 5
+	}
+}
+
+func easeOrDefault(ef float64) float64 {
+	if ef <= 0 {
+		return DefaultEasinessFactor
+	}
+	return clampEase(ef)
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// IsAPKG sniffs the zip magic so callers can route uploads without trusting
+// the file extension.
+func IsAPKG(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("PK\x03\x04"))
+}
@@ -0,0 +1,96 @@
+package authsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"example/hello/pkg/svcauth"
+)
+
+// Snapshot endpoints on the auth service. Both return a Spring Data page.
+const (
+	usersPath         = "/api/internal/sync/users"
+	organizationsPath = "/api/internal/sync/organizations"
+)
+
+// Client pulls full snapshots from the auth service. Requests are signed
+// as Service with Secret.
+type Client struct {
+	BaseURL    string
+	Service    string
+	Secret     string
+	HTTPClient *http.Client
+}
+
+func NewClient(baseURL, service, secret string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Service:    service,
+		Secret:     secret,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type page[T any] struct {
+	Content []T  `json:"content"`
+	Last    bool `json:"last"`
+}
+
+// EachUser calls fn for every user, fetching pageSize users at a time
+func (c *Client) EachUser(ctx context.Context, pageSize int, fn func(UserSnapshot) error) error {
+	return eachItem(ctx, c, usersPath, pageSize, fn)
+}
+
+// EachOrganization calls fn for every organization with its members
+func (c *Client) EachOrganization(ctx context.Context, pageSize int, fn func(OrgSnapshot) error) error {
+	return eachItem(ctx, c, organizationsPath, pageSize, fn)
+}
+
+func eachItem[T any](ctx context.Context, c *Client, path string, pageSize int, fn func(T) error) error {
+	for n := 0; ; n++ {
+		var p page[T]
+		if err := c.get(ctx, path, n, pageSize, &p); err != nil {
+			return fmt.Errorf("fetch %s page %d: %w", path, n, err)
+		}
+		for _, item := range p.Content {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		if p.Last || len(p.Content) == 0 {
+			return nil
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context, path string, pageNum, pageSize int, out interface{}) error {
+	q := url.Values{}
+	q.Set("page", strconv.Itoa(pageNum))
+	q.Set("size", strconv.Itoa(pageSize))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path+"?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if err := svcauth.Sign(req, c.Service, c.Secret); err != nil {
+		return err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,65 @@
+package authsync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"example/hello/pkg/svcauth"
+)
+
+type acceptAll struct{}
+
+func (acceptAll) SetNX(context.Context, string, interface{}, time.Duration) (bool, error) {
+	return true, nil
+}
+
+func TestEachUserPagesUntilLast(t *testing.T) {
+	verifier := svcauth.NewVerifier([]svcauth.Client{{Name: "lms-service", Secret: "s"}}, acceptAll{}, svcauth.Options{})
+	var pages []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := verifier.Verify(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		n, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pages = append(pages, n)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"content": []UserSnapshot{{UserID: int64(n*2 + 1)}, {UserID: int64(n*2 + 2)}},
+			"last":    n == 2,
+		})
+	}))
+	defer srv.Close()
+
+	var ids []int64
+	err := NewClient(srv.URL+"/", "lms-service", "s").EachUser(context.Background(), 2, func(u UserSnapshot) error {
+		ids = append(ids, u.UserID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 3 || len(ids) != 6 || ids[5] != 6 {
+		t.Errorf("pages = %v, ids = %v; want 3 pages of 2", pages, ids)
+	}
+}
+
+func TestEachUserStopsOnUpstreamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	called := false
+	err := NewClient(srv.URL, "lms-service", "s").EachUser(context.Background(), 10, func(UserSnapshot) error {
+		called = true
+		return nil
+	})
+	if err == nil || called {
+		t.Errorf("err = %v, called = %v; want error before any callback", err, called)
+	}
+}
@@ -38,6 +38,10 @@ func KeyCourseCoTeachers(courseID int64) string {
 	return fmt.Sprintf("%s%d:co-teachers", PrefixCourse, courseID)
 }
 
+func KeyCourseOrg(courseID int64) string {
+	return fmt.Sprintf("%s%d:org", PrefixCourse, courseID)
+}
+
 func KeyCourseStats(courseID int64) string {
 	return fmt.Sprintf("%s%d:stats", PrefixCourse, courseID)
 }
@@ -120,6 +124,10 @@ func KeyUserRoles(userID int64) string {
 	return fmt.Sprintf("%s%d:roles", PrefixUser, userID)
 }
 
+func KeyUserOrgIDs(userID int64) string {
+	return fmt.Sprintf("%s%d:org_ids", PrefixUser, userID)
+}
+
 // RedisCache wraps redis client with helper methods
 type RedisCache struct {
 	client *redis.Client
@@ -322,6 +330,11 @@ func (c *RedisCache) Pipeline() redis.Pipeliner {
 	return c.client.Pipeline()
 }
 
+// RunScript runs a Lua script, loading it on first use
+func (c *RedisCache) RunScript(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return script.Run(ctx, c.client, keys, args...).Result()
+}
+
 // Close closes the redis connection
 func (c *RedisCache) Close() error {
 	return c.client.Close()
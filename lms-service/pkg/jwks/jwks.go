@@ -0,0 +1,369 @@
+// Package jwks verifies access tokens issued by auth-and-management-service.
+//
+// Asymmetric tokens (RS256/ES256) are checked against the JSON Web Key Set the
+// auth service publishes. Keys are cached by kid, refreshed periodically, and
+// refetched when a token names a kid that is not cached yet, so the auth
+// service can rotate keys without redeploying the Go services. Tokens signed
+// with the legacy shared HMAC secret are still accepted until the migration
+// window closes.
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"example/hello/pkg/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrKeyNotFound    = errors.New("jwks: no key for kid")
+	ErrNoUsableKeys   = errors.New("jwks: document has no usable signing keys")
+	ErrAlgMismatch    = errors.New("jwks: key does not match token algorithm")
+	ErrHMACNotAllowed = errors.New("jwks: HMAC-signed tokens are no longer accepted")
+)
+
+// maxDocumentSize bounds the JWKS response body
+const maxDocumentSize = 1 << 20
+
+// Options tune how a KeySet talks to the JWKS endpoint
+type Options struct {
+	// RefreshInterval is how long a fetched document is trusted before it is
+	// refetched. Defaults to 15 minutes.
+	RefreshInterval time.Duration
+	// MinRefetchInterval rate-limits refetches triggered by unknown kids, so
+	// garbage tokens cannot hammer the auth service. Defaults to 30 seconds.
+	MinRefetchInterval time.Duration
+	HTTPClient         *http.Client
+}
+
+type signingKey struct {
+	pub crypto.PublicKey
+	alg string // from the JWK; empty when the document leaves it out
+}
+
+// KeySet is a cached, self-refreshing JSON Web Key Set
+type KeySet struct {
+	url  string
+	opts Options
+
+	mu          sync.RWMutex
+	keys        map[string]signingKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	lastErr     error
+
+	fetchMu sync.Mutex
+}
+
+func NewKeySet(url string, opts Options) *KeySet {
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = 15 * time.Minute
+	}
+	if opts.MinRefetchInterval <= 0 {
+		opts.MinRefetchInterval = 30 * time.Second
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &KeySet{url: url, opts: opts, keys: make(map[string]signingKey)}
+}
+
+// Run refreshes the key set every RefreshInterval until ctx is cancelled.
+// A failed refresh keeps the previously fetched keys.
+func (s *KeySet) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+				logger.Error("Failed to refresh JWKS from "+s.url, err)
+			}
+		}
+	}
+}
+
+// Refresh fetches the document now. Concurrent callers share one fetch.
+func (s *KeySet) Refresh(ctx context.Context) error {
+	started := time.Now()
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+
+	s.mu.RLock()
+	fresh := !s.lastAttempt.Before(started)
+	lastErr := s.lastErr
+	s.mu.RUnlock()
+	if fresh {
+		// Someone else fetched while we waited for the lock
+		return lastErr
+	}
+
+	keys, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastAttempt = time.Now()
+	s.lastErr = err
+	if err != nil {
+		return err
+	}
+	// Replacing the map drops keys the auth service has retired
+	s.keys = keys
+	s.fetchedAt = s.lastAttempt
+	return nil
+}
+
+// key returns the public key for kid, refetching the document when the kid
+// is unknown or the cache is stale. An empty kid is accepted only while the
+// set holds exactly one key.
+func (s *KeySet) key(ctx context.Context, kid string) (signingKey, error) {
+	s.mu.RLock()
+	k, ok := s.lookup(kid)
+	stale := time.Since(s.fetchedAt) > s.opts.RefreshInterval
+	throttled := time.Since(s.lastAttempt) < s.opts.MinRefetchInterval
+	s.mu.RUnlock()
+
+	if ok && !stale {
+		return k, nil
+	}
+	if !throttled {
+		if err := s.Refresh(ctx); err != nil {
+			logger.Warn(fmt.Sprintf("JWKS refresh for kid %q failed: %v", kid, err))
+		}
+		s.mu.RLock()
+		k, ok = s.lookup(kid)
+		s.mu.RUnlock()
+	}
+	if !ok {
+		return signingKey{}, fmt.Errorf("%w %q", ErrKeyNotFound, kid)
+	}
+	return k, nil
+}
+
+func (s *KeySet) lookup(kid string) (signingKey, bool) {
+	if kid == "" {
+		if len(s.keys) == 1 {
+			for _, k := range s.keys {
+				return k, true
+			}
+		}
+		return signingKey{}, false
+	}
+	k, ok := s.keys[kid]
+	return k, ok
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (s *KeySet) fetch(ctx context.Context) (map[string]signingKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: fetch %s: %w", s.url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: fetch %s: unexpected status %d", s.url, resp.StatusCode)
+	}
+
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDocumentSize)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("jwks: decode %s: %w", s.url, err)
+	}
+
+	keys := make(map[string]signingKey, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		pub, err := parseKey(jwk)
+		if err != nil {
+			// One malformed entry must not take down the whole rotation
+			logger.Warn(fmt.Sprintf("Skipping JWKS key %q: %v", jwk.Kid, err))
+			continue
+		}
+		keys[jwk.Kid] = signingKey{pub: pub, alg: jwk.Alg}
+	}
+	if len(keys) == 0 {
+		return nil, ErrNoUsableKeys
+	}
+	return keys, nil
+}
+
+func parseKey(jwk jsonWebKey) (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("exponent: %w", err)
+		}
+		if n.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA key is %d bits, want at least 2048", n.BitLen())
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("unsupported RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != 32 {
+			return nil, errors.New("invalid x coordinate")
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil || len(y) != 32 {
+			return nil, errors.New("invalid y coordinate")
+		}
+		// crypto/ecdh rejects points that are not on the curve
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// ============================================
+// VERIFIER
+// ============================================
+
+// Verifier resolves the key for a token from its alg and kid header. Only
+// the algorithms it is configured for are accepted, so a token cannot switch
+// from RS256 to HS256 and be verified with a public key as the HMAC secret.
+type Verifier struct {
+	keys       *KeySet
+	hmacSecret []byte
+	hmacUntil  time.Time
+	now        func() time.Time
+}
+
+// NewVerifier accepts RS256/ES256 tokens signed by a key in keys, and HMAC
+// tokens signed with hmacSecret until hmacUntil. A nil keys disables
+// asymmetric tokens; an empty hmacSecret disables HMAC; a zero hmacUntil
+// leaves the HMAC window open.
+func NewVerifier(keys *KeySet, hmacSecret string, hmacUntil time.Time) *Verifier {
+	return &Verifier{
+		keys:       keys,
+		hmacSecret: []byte(hmacSecret),
+		hmacUntil:  hmacUntil,
+		now:        time.Now,
+	}
+}
+
+// Parse verifies tokenString and decodes its claims into claims
+func (v *Verifier) Parse(ctx context.Context, tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return v.keyFor(ctx, token)
+	}, jwt.WithValidMethods(v.methods()))
+}
+
+func (v *Verifier) hmacAllowed() bool {
+	return len(v.hmacSecret) > 0 && (v.hmacUntil.IsZero() || v.now().Before(v.hmacUntil))
+}
+
+func (v *Verifier) methods() []string {
+	var methods []string
+	if v.keys != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+	}
+	if v.hmacAllowed() {
+		methods = append(methods, jwt.SigningMethodHS256.Alg(), jwt.SigningMethodHS384.Alg(), jwt.SigningMethodHS512.Alg())
+	}
+	return methods
+}
+
+func (v *Verifier) keyFor(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if !v.hmacAllowed() {
+			return nil, ErrHMACNotAllowed
+		}
+		return v.hmacSecret, nil
+
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		if v.keys == nil {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		kid, _ := token.Header["kid"].(string)
+		k, err := v.keys.key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		alg := token.Method.Alg()
+		if k.alg != "" && k.alg != alg {
+			return nil, ErrAlgMismatch
+		}
+		switch pub := k.pub.(type) {
+		case *rsa.PublicKey:
+			if _, ok := token.Method.(*jwt.SigningMethodRSA); ok {
+				return pub, nil
+			}
+		case *ecdsa.PublicKey:
+			if _, ok := token.Method.(*jwt.SigningMethodECDSA); ok {
+				return pub, nil
+			}
+		}
+		return nil, ErrAlgMismatch
+
+	default:
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+}
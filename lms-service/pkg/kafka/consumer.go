@@ -5,50 +5,111 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
-	"example/hello/pkg/logger"
+	"example/hello/pkg/authsync"
 
 	"github.com/segmentio/kafka-go"
 )
 
-type StatusUpdateFunc func(ctx context.Context, event ProcessDocumentStatusEvent) error
+// ConsumerOptions are shared by every consumer started by this package
+type ConsumerOptions struct {
+	MaxRetries  int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Ledger      Ledger
+	DeadLetter  Publisher
+}
 
-func StartConsumer(ctx context.Context, onStatusUpdate StatusUpdateFunc) {
+var consumerOptions = ConsumerOptions{
+	MaxRetries:  5,
+	BaseBackoff: 500 * time.Millisecond,
+	MaxBackoff:  30 * time.Second,
+}
+
+// ConfigureConsumers sets the retry policy, idempotency ledger and
+// dead-letter publisher. Call it before starting any consumer.
+func ConfigureConsumers(opts ConsumerOptions) {
+	consumerOptions = opts
+}
+
+// startConsumer runs handler on a consumer-group reader for topic and
+// registers it for /health
+func startConsumer(ctx context.Context, topic, groupID string, eventID func(Message) (string, error), handler Handler) {
 	brokers := os.Getenv("KAFKA_BROKERS")
 	if brokers == "" {
 		brokers = "localhost:9092"
 	}
 
-	r := kafka.NewReader(kafka.ReaderConfig{
+	c := NewConsumer(ConsumerConfig{
+		Topic:       topic,
+		GroupID:     groupID,
+		MaxRetries:  consumerOptions.MaxRetries,
+		BaseBackoff: consumerOptions.BaseBackoff,
+		MaxBackoff:  consumerOptions.MaxBackoff,
+		EventID:     eventID,
+	}, handler, consumerOptions.Ledger, consumerOptions.DeadLetter)
+	register(c)
+
+	c.Run(ctx, &readerSource{r: kafka.NewReader(kafka.ReaderConfig{
 		Brokers: []string{brokers},
-		Topic:   "ai.document.processed.status",
-		GroupID: "lms-service-group",
-	})
-
-	defer r.Close()
-	logger.Info("Kafka Consumer started for ai.document.processed.status")
-
-	for {
-		m, err := r.ReadMessage(ctx)
-		if err != nil {
-			if ctx.Err() != nil {
-				return
-			}
-			logger.Error("Failed to read kafka message", err)
-			continue
-		}
+		Topic:   topic,
+		GroupID: groupID,
+	})})
+}
 
-		var event ProcessDocumentStatusEvent
-		if err := json.Unmarshal(m.Value, &event); err != nil {
-			logger.Error("Failed to unmarshal kafka status event", err)
-			continue
-		}
+// readerSource adapts a kafka-go consumer-group reader. Offsets are
+// committed explicitly after each message is settled.
+type readerSource struct {
+	r *kafka.Reader
+}
 
-		err = onStatusUpdate(ctx, event)
-		if err != nil {
-			logger.Error(fmt.Sprintf("Failed to process status update for content %d", event.ContentID), err)
+func (s *readerSource) Fetch(ctx context.Context) (Message, error) {
+	m, err := s.r.FetchMessage(ctx)
+	if err != nil {
+		return Message{}, err
+	}
+	msg := Message{
+		Topic:     m.Topic,
+		Key:       m.Key,
+		Value:     m.Value,
+		Partition: m.Partition,
+		Offset:    m.Offset,
+		raw:       m,
+	}
+	if len(m.Headers) > 0 {
+		msg.Headers = make(map[string]string, len(m.Headers))
+		for _, h := range m.Headers {
+			msg.Headers[h.Key] = string(h.Value)
 		}
 	}
+	return msg, nil
+}
+
+func (s *readerSource) Commit(ctx context.Context, m Message) error {
+	return s.r.CommitMessages(ctx, m.raw)
+}
+
+func (s *readerSource) Lag() int64 {
+	return s.r.Stats().Lag
+}
+
+func (s *readerSource) Close() error {
+	return s.r.Close()
+}
+
+type StatusUpdateFunc func(ctx context.Context, event ProcessDocumentStatusEvent) error
+
+func StartConsumer(ctx context.Context, onStatusUpdate StatusUpdateFunc) {
+	startConsumer(ctx, "ai.document.processed.status", "lms-service-group",
+		jsonEventID(func(e ProcessDocumentStatusEvent) string {
+			if e.JobID == 0 {
+				return ""
+			}
+			return fmt.Sprintf("content-%d:job-%d:%s", e.ContentID, e.JobID, e.Status)
+		}),
+		JSONHandler(onStatusUpdate))
 }
 
 type NodeMergedFunc func(ctx context.Context, event NodeMergedEvent) error
@@ -56,125 +117,70 @@ type NodeMergedFunc func(ctx context.Context, event NodeMergedEvent) error
 // StartNodeMergedConsumer subscribes to ai.graph.node_merged and rewires the
 // LMS-side node_id references (micro_lessons, quiz_questions). The handler
 // receives the parsed event and is responsible for the actual UPDATE.
+// Merge events carry no ID, so they are deduplicated by content.
 func StartNodeMergedConsumer(ctx context.Context, onMerged NodeMergedFunc) {
-	brokers := os.Getenv("KAFKA_BROKERS")
-	if brokers == "" {
-		brokers = "localhost:9092"
-	}
-
-	r := kafka.NewReader(kafka.ReaderConfig{
-		Brokers: []string{brokers},
-		Topic:   "ai.graph.node_merged",
-		GroupID: "lms-service-graph-merged-group",
-	})
-
-	defer r.Close()
-	logger.Info("Kafka Consumer started for ai.graph.node_merged")
-
-	for {
-		m, err := r.ReadMessage(ctx)
-		if err != nil {
-			if ctx.Err() != nil {
-				return
-			}
-			logger.Error("Failed to read kafka message on ai.graph.node_merged", err)
-			continue
-		}
-
-		var event NodeMergedEvent
-		if err := json.Unmarshal(m.Value, &event); err != nil {
-			logger.Error("Failed to unmarshal NodeMergedEvent", err)
-			continue
-		}
-
-		if err := onMerged(ctx, event); err != nil {
-			logger.Error(fmt.Sprintf(
-				"Failed to apply node-merge cascade (survivor=%d, absorbed=%d)",
-				event.SurvivorID, len(event.AbsorbedIDs)), err)
-		}
-	}
+	startConsumer(ctx, "ai.graph.node_merged", "lms-service-graph-merged-group", nil,
+		JSONHandler(onMerged))
 }
 
 type MicroInteractionFunc func(ctx context.Context, event MicroInteractionEvent) error
 
 // StartMicroInteractionConsumer subscribes to lms.analytics.interactions
 // and forwards each parsed event to the heatmap aggregator. Runs in
-// its own goroutine; cancel ctx to stop.
+// its own goroutine; cancel ctx to stop. Mastery deltas are not
+// idempotent, so events are deduplicated by interaction id.
 func StartMicroInteractionConsumer(ctx context.Context, onEvent MicroInteractionFunc) {
-	brokers := os.Getenv("KAFKA_BROKERS")
-	if brokers == "" {
-		brokers = "localhost:9092"
-	}
-
-	r := kafka.NewReader(kafka.ReaderConfig{
-		Brokers: []string{brokers},
-		Topic:   TopicMicroInteractions,
-		GroupID: "lms-service-micro-interactions-group",
-	})
-
-	defer r.Close()
-	logger.Info("Kafka Consumer started for " + TopicMicroInteractions)
-
-	for {
-		m, err := r.ReadMessage(ctx)
-		if err != nil {
-			if ctx.Err() != nil {
-				return
+	startConsumer(ctx, TopicMicroInteractions, "lms-service-micro-interactions-group",
+		jsonEventID(func(e MicroInteractionEvent) string {
+			if e.InteractionID == 0 {
+				return ""
 			}
-			logger.Error("Failed to read kafka message on "+TopicMicroInteractions, err)
-			continue
-		}
-
-		var event MicroInteractionEvent
-		if err := json.Unmarshal(m.Value, &event); err != nil {
-			logger.Error("Failed to unmarshal MicroInteractionEvent", err)
-			continue
-		}
-
-		if err := onEvent(ctx, event); err != nil {
-			logger.Error(fmt.Sprintf(
-				"Failed to aggregate interaction id=%d action=%s",
-				event.InteractionID, event.ActionType), err)
-		}
-	}
+			return "interaction-" + strconv.FormatInt(e.InteractionID, 10)
+		}),
+		JSONHandler(onEvent))
 }
 
 type AIJobStatusUpdateFunc func(ctx context.Context, event AIJobStatusEvent) error
 
+// StartAIJobStatusConsumer subscribes to ai.job.status. A job may report
+// the same status several times with new results, so events are
+// deduplicated by content rather than by job id.
 func StartAIJobStatusConsumer(ctx context.Context, onStatusUpdate AIJobStatusUpdateFunc) {
-	brokers := os.Getenv("KAFKA_BROKERS")
-	if brokers == "" {
-		brokers = "localhost:9092"
-	}
+	startConsumer(ctx, "ai.job.status", "lms-service-ai-job-status-group", nil,
+		JSONHandler(onStatusUpdate))
+}
 
-	r := kafka.NewReader(kafka.ReaderConfig{
-		Brokers: []string{brokers},
-		Topic:   "ai.job.status",
-		GroupID: "lms-service-ai-job-status-group",
-	})
-
-	defer r.Close()
-	logger.Info("Kafka Consumer started for ai.job.status")
-
-	for {
-		m, err := r.ReadMessage(ctx)
-		if err != nil {
-			if ctx.Err() != nil {
-				return
-			}
-			logger.Error("Failed to read kafka message on ai.job.status", err)
-			continue
-		}
+type UserEventFunc func(ctx context.Context, event authsync.UserEvent) error
+
+// StartUserEventConsumer subscribes to the auth service's user lifecycle
+// events. Events carry their own ID; ordering per user is kept by the
+// partition key and stale versions are dropped by the handler.
+func StartUserEventConsumer(ctx context.Context, onEvent UserEventFunc) {
+	startConsumer(ctx, authsync.TopicUserEvents, "lms-service-auth-user-sync-group",
+		jsonEventID(func(e authsync.UserEvent) string { return e.EventID }),
+		JSONHandler(onEvent))
+}
 
-		var event AIJobStatusEvent
+type OrgEventFunc func(ctx context.Context, event authsync.OrgEvent) error
+
+// StartOrgEventConsumer subscribes to organization and membership events
+func StartOrgEventConsumer(ctx context.Context, onEvent OrgEventFunc) {
+	startConsumer(ctx, authsync.TopicOrgEvents, "lms-service-auth-org-sync-group",
+		jsonEventID(func(e authsync.OrgEvent) string { return e.EventID }),
+		JSONHandler(onEvent))
+}
+
+// jsonEventID derives the ledger ID from the decoded event, falling back to
+// the content hash when the event lacks the identifying fields
+func jsonEventID[T any](id func(T) string) func(Message) (string, error) {
+	return func(m Message) (string, error) {
+		var event T
 		if err := json.Unmarshal(m.Value, &event); err != nil {
-			logger.Error("Failed to unmarshal kafka AI job status event", err)
-			continue
+			return "", err
 		}
-
-		err = onStatusUpdate(ctx, event)
-		if err != nil {
-			logger.Error(fmt.Sprintf("Failed to process status update for job %s", event.JobID), err)
+		if eventID := id(event); eventID != "" {
+			return eventID, nil
 		}
+		return hashEventID(m)
 	}
 }
@@ -4,6 +4,10 @@ import "time"
 
 const TopicCourseDeleted = "lms.course.deleted"
 
+// TopicMaintenanceCommand carries cleanup commands (DELETE_COURSE,
+// DELETE_CONTENT) for services holding data derived from LMS content
+const TopicMaintenanceCommand = "lms.maintenance.command"
+
 type CourseInstructor struct {
 	UserID   int64  `json:"user_id"`
 	FullName string `json:"full_name"`
@@ -24,6 +28,75 @@ type CourseDeletedEvent struct {
 	DeletedAt   time.Time          `json:"deleted_at"`
 }
 
+// TopicCourseMembership carries course lifecycle and membership changes for
+// services that mirror who belongs to a course, such as chat-service's
+// course channels. Events are keyed by course so each course's changes
+// arrive in order.
+const TopicCourseMembership = "lms.course.membership"
+
+// Course membership event types
+const (
+	// CourseMembersSynced is sent when a course is published, or restored
+	// from the archive as published, with the full member list
+	CourseMembersSynced = "COURSE_MEMBERS_SYNCED"
+	CourseArchived      = "COURSE_ARCHIVED"
+	CourseRemoved       = "COURSE_DELETED"
+	CourseMemberAdded   = "MEMBER_ADDED"
+	CourseMemberRemoved = "MEMBER_REMOVED"
+	// CourseGroupSynced is sent when a group is created or renamed, with
+	// its full member list
+	CourseGroupSynced  = "GROUP_SYNCED"
+	CourseGroupRemoved = "GROUP_DELETED"
+)
+
+// Course member roles
+const (
+	CourseRoleCreator   = "CREATOR"
+	CourseRoleCoTeacher = "CO_TEACHER"
+	CourseRoleStudent   = "STUDENT"
+	// CourseRoleGroupMember is a student's place in a course group
+	CourseRoleGroupMember = "GROUP_MEMBER"
+)
+
+// CourseMember is one person's role in a course. Email and FullName are set
+// when the producer already has them.
+type CourseMember struct {
+	UserID   int64  `json:"user_id"`
+	Email    string `json:"email,omitempty"`
+	FullName string `json:"full_name,omitempty"`
+	Role     string `json:"role"`
+}
+
+// CourseGroup is one group of a course in a COURSE_MEMBERS_SYNCED event
+type CourseGroup struct {
+	GroupID   int64   `json:"group_id"`
+	Name      string  `json:"name"`
+	MemberIDs []int64 `json:"member_ids"`
+}
+
+// CourseMembershipEvent is published to TopicCourseMembership. Members is
+// every member for COURSE_MEMBERS_SYNCED and the changed members for
+// MEMBER_ADDED and MEMBER_REMOVED. A student is a member once their
+// enrollment is accepted; leaving the course also takes them out of its
+// groups.
+//
+// Group events set GroupID: GROUP_SYNCED carries the group's name and every
+// member, GROUP_DELETED nothing else, and MEMBER_ADDED or MEMBER_REMOVED
+// with GroupID set the changed GROUP_MEMBER members. COURSE_MEMBERS_SYNCED
+// lists every group in Groups.
+type CourseMembershipEvent struct {
+	EventID     string         `json:"event_id"`
+	Type        string         `json:"type"`
+	CourseID    int64          `json:"course_id"`
+	CourseTitle string         `json:"course_title,omitempty"`
+	CreatedBy   int64          `json:"created_by,omitempty"`
+	GroupID     int64          `json:"group_id,omitempty"`
+	GroupName   string         `json:"group_name,omitempty"`
+	Members     []CourseMember `json:"members,omitempty"`
+	Groups      []CourseGroup  `json:"groups,omitempty"`
+	OccurredAt  time.Time      `json:"occurred_at"`
+}
+
 // ProcessDocumentEvent represents the payload sent from LMS to AI Service
 type ProcessDocumentEvent struct {
 	EventID        string    `json:"event_id"`
@@ -72,6 +145,10 @@ type NodeMergedEvent struct {
 	AbsorbedIDs []int64 `json:"absorbed_ids"`
 }
 
+// TopicLearningEvents carries every tracked learning event for analytics,
+// keyed by student.
+const TopicLearningEvents = "learning-events"
+
 // Topic name for Quick Action Panel micro-interactions.
 const TopicMicroInteractions = "lms.analytics.interactions"
 
@@ -92,3 +169,49 @@ type MicroInteractionEvent struct {
 	Status        string    `json:"status,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
 }
+
+// TopicForumDigest carries one daily forum digest per subscriber; the
+// notification mailer in auth-and-management-service renders and sends it.
+const TopicForumDigest = "lms.forum.digest"
+
+// ForumDigestItem is a single forum activity included in a digest
+type ForumDigestItem struct {
+	Type        string    `json:"type"` // NEW_POST, REPLY, MENTION, ACCEPTED
+	CourseID    int64     `json:"course_id"`
+	CourseTitle string    `json:"course_title"`
+	PostID      int64     `json:"post_id"`
+	PostTitle   string    `json:"post_title"`
+	CommentID   *int64    `json:"comment_id,omitempty"`
+	ActorName   string    `json:"actor_name,omitempty"`
+	Excerpt     string    `json:"excerpt,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ForumDigestEvent summarises a user's unread forum activity since their
+// previous digest.
+type ForumDigestEvent struct {
+	EventID     string            `json:"event_id"`
+	UserID      int64             `json:"user_id"`
+	Email       string            `json:"email"`
+	FullName    string            `json:"full_name"`
+	Items       []ForumDigestItem `json:"items"`
+	GeneratedAt time.Time         `json:"generated_at"`
+}
+
+// TopicOrgInvitation carries organization invitations; the notification
+// mailer in auth-and-management-service sends them to Email.
+const TopicOrgInvitation = "lms.org.invitation"
+
+// OrgInvitationEvent asks for one invitation email. AcceptURL embeds the
+// invitation token and is the only place it appears.
+type OrgInvitationEvent struct {
+	EventID      string    `json:"event_id"`
+	InvitationID int64     `json:"invitation_id"`
+	OrgID        int64     `json:"org_id"`
+	OrgName      string    `json:"org_name"`
+	Email        string    `json:"email"`
+	OrgRole      string    `json:"org_role"`
+	InviterName  string    `json:"inviter_name,omitempty"`
+	AcceptURL    string    `json:"accept_url"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBroker is an in-process Publisher for tests and for running the
+// service without Kafka. Published messages are kept per topic in order.
+type MemoryBroker struct {
+	mu       sync.Mutex
+	messages map[string][]Message
+	failFn   func(Message) error
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{messages: make(map[string][]Message)}
+}
+
+// FailWith installs a hook that can reject individual messages; a nil
+// return lets the message through. Pass nil to remove the hook.
+func (b *MemoryBroker) FailWith(fn func(Message) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failFn = fn
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, msgs []Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	errs := make(PublishErrors, len(msgs))
+	failed := false
+	for i, m := range msgs {
+		if b.failFn != nil {
+			if err := b.failFn(m); err != nil {
+				errs[i] = err
+				failed = true
+				continue
+			}
+		}
+		b.messages[m.Topic] = append(b.messages[m.Topic], m)
+	}
+	if failed {
+		return errs
+	}
+	return nil
+}
+
+// Messages returns a copy of what has been published to topic
+func (b *MemoryBroker) Messages(topic string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Message(nil), b.messages[topic]...)
+}
+
+func (b *MemoryBroker) Close() error {
+	return nil
+}
+
+// MemoryLedger is an in-process Ledger for tests
+type MemoryLedger struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func NewMemoryLedger() *MemoryLedger {
+	return &MemoryLedger{seen: make(map[string]bool)}
+}
+
+func (l *MemoryLedger) Apply(ctx context.Context, group, eventID string, fn func(ctx context.Context) error) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := group + "\x00" + eventID
+	if l.seen[key] {
+		return true, nil
+	}
+	if err := fn(ctx); err != nil {
+		return false, err
+	}
+	l.seen[key] = true
+	return false, nil
+}
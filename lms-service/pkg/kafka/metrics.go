@@ -0,0 +1,58 @@
+package kafka
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	messagesPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_messages_published_total",
+		Help: "Messages written to Kafka, by topic and result (ok, error).",
+	}, []string{"topic", "result"})
+
+	messagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_messages_consumed_total",
+		Help: "Messages settled by consumers, by result (processed, duplicate, dead_lettered).",
+	}, []string{"topic", "group", "result"})
+
+	handlerRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_handler_retries_total",
+		Help: "Handler attempts retried after a failure.",
+	}, []string{"topic", "group"})
+
+	handlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_handler_duration_seconds",
+		Help:    "Time from fetching a message to settling it, retries included.",
+		Buckets: prometheus.ExponentialBuckets(0.005, 4, 8),
+	}, []string{"topic", "group"})
+
+	consumerLagDesc = prometheus.NewDesc("kafka_consumer_lag",
+		"Messages behind the partition head; -1 before the first fetch.",
+		[]string{"topic", "group"}, nil)
+)
+
+func init() {
+	prometheus.MustRegister(lagCollector{})
+}
+
+// lagCollector reads lag from the running consumers at scrape time
+type lagCollector struct{}
+
+func (lagCollector) Describe(ch chan<- *prometheus.Desc) { ch <- consumerLagDesc }
+
+func (lagCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range ConsumerStatuses() {
+		if s.Running {
+			ch <- prometheus.MustNewConstMetric(consumerLagDesc, prometheus.GaugeValue, float64(s.Lag), s.Topic, s.GroupID)
+		}
+	}
+}
+
+func countPublished(topic string, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	messagesPublished.WithLabelValues(topic, result).Inc()
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"example/hello/pkg/logger"
+	"example/hello/pkg/telemetry"
 
 	"github.com/segmentio/kafka-go"
 )
@@ -36,6 +37,9 @@ func CloseProducer() {
 	}
 }
 
+// PublishEvent writes a message synchronously and reports broker errors to
+// the caller. Events announcing a committed database change should be written
+// to the outbox in the same transaction instead, so they cannot be lost.
 func PublishEvent(ctx context.Context, topic string, key []byte, payload interface{}) error {
 	data, err := json.Marshal(payload)
 	if err != nil {
@@ -47,8 +51,14 @@ func PublishEvent(ctx context.Context, topic string, key []byte, payload interfa
 		Key:   key,
 		Value: data,
 	}
+	headers := map[string]string{}
+	telemetry.InjectHeaders(ctx, headers)
+	for k, v := range headers {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
 
 	err = Writer.WriteMessages(ctx, msg)
+	countPublished(topic, err)
 	if err != nil {
 		return fmt.Errorf("failed to publish to kafka topic %s: %w", topic, err)
 	}
@@ -0,0 +1,116 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"example/hello/pkg/telemetry"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Message is an encoded event. Partition and Offset are only set on consumed
+// messages and are ignored when publishing.
+type Message struct {
+	Topic     string
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Partition int
+	Offset    int64
+
+	raw kafka.Message // as fetched, for committing
+}
+
+// Publisher writes a batch of messages to a broker. When only some messages
+// fail it returns PublishErrors so callers can tell which ones to retry.
+type Publisher interface {
+	Publish(ctx context.Context, msgs []Message) error
+	Close() error
+}
+
+// PublishErrors holds one entry per message of a batch; nil entries were
+// written successfully
+type PublishErrors []error
+
+func (e PublishErrors) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	if first == nil {
+		return "kafka: no messages failed"
+	}
+	return fmt.Sprintf("kafka: %d of %d messages failed: %v", failed, len(e), first)
+}
+
+// MessageError returns the failure for message i of a batch, or nil if it
+// was written. Errors other than PublishErrors apply to every message.
+func MessageError(err error, i int) error {
+	var perMessage PublishErrors
+	if errors.As(err, &perMessage) {
+		if i < len(perMessage) {
+			return perMessage[i]
+		}
+		return nil
+	}
+	return err
+}
+
+// WriterPublisher publishes through a dedicated kafka-go writer
+type WriterPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewWriterPublisher creates a publisher that waits for all in-sync replicas.
+// Messages are hash-partitioned by key so events for one key stay ordered.
+func NewWriterPublisher(brokers string) *WriterPublisher {
+	return &WriterPublisher{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			MaxAttempts:            1, // the outbox relay owns retries
+			BatchTimeout:           10 * time.Millisecond,
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+func (p *WriterPublisher) Publish(ctx context.Context, msgs []Message) error {
+	out := make([]kafka.Message, len(msgs))
+	for i, m := range msgs {
+		out[i] = kafka.Message{Topic: m.Topic, Key: m.Key, Value: m.Value}
+		headers := m.Headers
+		if headers == nil {
+			// Messages that carry no stored trace context join the caller's
+			headers = map[string]string{}
+			telemetry.InjectHeaders(ctx, headers)
+		}
+		for k, v := range headers {
+			out[i].Headers = append(out[i].Headers, kafka.Header{Key: k, Value: []byte(v)})
+		}
+	}
+
+	err := p.writer.WriteMessages(ctx, out...)
+	for i, m := range msgs {
+		countPublished(m.Topic, MessageError(err, i))
+	}
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) {
+		return PublishErrors(writeErrs)
+	}
+	return err
+}
+
+func (p *WriterPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,396 @@
+package kafka
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"example/hello/pkg/logger"
+	"example/hello/pkg/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Ledger records which events a consumer group has already applied, so a
+// redelivered or replayed event is acknowledged without running its handler
+// again. Apply claims the event and runs fn atomically with the claim:
+// when fn fails the claim is released, and when the event was claimed
+// before fn is skipped and duplicate is true.
+type Ledger interface {
+	Apply(ctx context.Context, group, eventID string, fn func(ctx context.Context) error) (duplicate bool, err error)
+}
+
+// Handler applies one message. Errors are retried with backoff; wrap an
+// error with Permanent to dead-letter the message straight away.
+type Handler func(ctx context.Context, m Message) error
+
+// MessageSource is where a consumer reads from: a kafka-go reader in
+// production, a slice in tests
+type MessageSource interface {
+	Fetch(ctx context.Context) (Message, error)
+	Commit(ctx context.Context, m Message) error
+	Lag() int64
+	Close() error
+}
+
+// ConsumerConfig describes one consumer. DeadLetterTopic defaults to
+// Topic + ".dlq"; EventID defaults to a hash of the message value.
+type ConsumerConfig struct {
+	Topic           string
+	GroupID         string
+	DeadLetterTopic string
+	MaxRetries      int
+	BaseBackoff     time.Duration
+	MaxBackoff      time.Duration
+	EventID         func(Message) (string, error)
+}
+
+// ConsumerStatus is one consumer's entry in /health
+type ConsumerStatus struct {
+	Topic          string     `json:"topic"`
+	GroupID        string     `json:"group_id"`
+	Running        bool       `json:"running"`
+	Lag            int64      `json:"lag"`
+	Processed      int64      `json:"processed"`
+	Duplicates     int64      `json:"duplicates"`
+	Retries        int64      `json:"retries"`
+	DeadLettered   int64      `json:"dead_lettered"`
+	LastMessageAt  *time.Time `json:"last_message_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+	DeadLetterDown bool       `json:"dead_letter_down"`
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error that retrying cannot fix, such as a malformed
+// payload
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// JSONHandler decodes the message value into T before calling fn. Payloads
+// that do not decode are dead-lettered without retries.
+func JSONHandler[T any](fn func(ctx context.Context, event T) error) Handler {
+	return func(ctx context.Context, m Message) error {
+		var event T
+		if err := json.Unmarshal(m.Value, &event); err != nil {
+			return Permanent(fmt.Errorf("failed to unmarshal %s event: %w", m.Topic, err))
+		}
+		return fn(ctx, event)
+	}
+}
+
+// Consumer runs a handler over a message source with bounded retries, a
+// dead-letter topic and an idempotency ledger. Messages are committed only
+// once they were applied, found in the ledger or written to the dead-letter
+// topic, so nothing is dropped silently.
+type Consumer struct {
+	cfg        ConsumerConfig
+	handler    Handler
+	ledger     Ledger
+	deadLetter Publisher
+
+	mu     sync.Mutex
+	status ConsumerStatus
+	src    MessageSource
+}
+
+func NewConsumer(cfg ConsumerConfig, handler Handler, ledger Ledger, deadLetter Publisher) *Consumer {
+	if cfg.DeadLetterTopic == "" {
+		cfg.DeadLetterTopic = cfg.Topic + ".dlq"
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 500 * time.Millisecond
+	}
+	if cfg.MaxBackoff < cfg.BaseBackoff {
+		cfg.MaxBackoff = cfg.BaseBackoff
+	}
+	if cfg.EventID == nil {
+		cfg.EventID = hashEventID
+	}
+	return &Consumer{
+		cfg:        cfg,
+		handler:    handler,
+		ledger:     ledger,
+		deadLetter: deadLetter,
+		status:     ConsumerStatus{Topic: cfg.Topic, GroupID: cfg.GroupID, Lag: -1},
+	}
+}
+
+// Run consumes from src until ctx is cancelled
+func (c *Consumer) Run(ctx context.Context, src MessageSource) {
+	defer src.Close()
+	c.mu.Lock()
+	c.src = src
+	c.status.Running = true
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.status.Running = false
+		c.src = nil
+		c.mu.Unlock()
+	}()
+
+	logger.Info(fmt.Sprintf("Kafka Consumer started for %s (group %s)", c.cfg.Topic, c.cfg.GroupID))
+
+	fetchFailures := 0
+	for {
+		m, err := src.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			fetchFailures++
+			c.recordError(fmt.Errorf("fetch: %w", err))
+			logger.Error("Failed to read kafka message on "+c.cfg.Topic, err)
+			if !sleepCtx(ctx, c.backoff(fetchFailures)) {
+				return
+			}
+			continue
+		}
+		fetchFailures = 0
+
+		if err := c.Process(ctx, m); err != nil {
+			// Only cancellation stops processing; the message stays
+			// uncommitted and is redelivered after restart
+			return
+		}
+		if err := src.Commit(ctx, m); err != nil && ctx.Err() == nil {
+			c.recordError(fmt.Errorf("commit: %w", err))
+			logger.Error(fmt.Sprintf("Failed to commit %s offset %d", c.cfg.Topic, m.Offset), err)
+		}
+	}
+}
+
+// Process applies one message: skipped if the ledger has it, otherwise
+// retried up to MaxRetries times and then dead-lettered. It returns an error
+// only when ctx was cancelled before the message was settled.
+func (c *Consumer) Process(ctx context.Context, m Message) error {
+	now := time.Now()
+	c.mu.Lock()
+	c.status.LastMessageAt = &now
+	c.mu.Unlock()
+	defer func() {
+		handlerDuration.WithLabelValues(c.cfg.Topic, c.cfg.GroupID).Observe(time.Since(now).Seconds())
+	}()
+
+	// Continue the producer's trace when the message carries one
+	ctx, span := telemetry.Tracer().Start(telemetry.ExtractHeaders(ctx, m.Headers), c.cfg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", c.cfg.Topic),
+			attribute.String("messaging.consumer.group.name", c.cfg.GroupID),
+			attribute.Int("messaging.kafka.destination.partition", m.Partition),
+			attribute.Int64("messaging.kafka.offset", m.Offset),
+		))
+	defer span.End()
+
+	eventID, err := c.cfg.EventID(m)
+	if err != nil {
+		return c.sendToDeadLetter(ctx, m, Permanent(fmt.Errorf("event id: %w", err)), 0)
+	}
+
+	var lastErr error
+	attempts := 0
+	for attempts <= c.cfg.MaxRetries {
+		if attempts > 0 {
+			c.bump(func(s *ConsumerStatus) { s.Retries++ })
+			handlerRetries.WithLabelValues(c.cfg.Topic, c.cfg.GroupID).Inc()
+			if !sleepCtx(ctx, c.backoff(attempts)) {
+				return ctx.Err()
+			}
+		}
+		attempts++
+
+		var done bool
+		done, lastErr = c.apply(ctx, m, eventID)
+		if lastErr == nil {
+			if done {
+				c.bump(func(s *ConsumerStatus) { s.Duplicates++ })
+				c.countConsumed("duplicate")
+			} else {
+				c.bump(func(s *ConsumerStatus) { s.Processed++ })
+				c.countConsumed("processed")
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c.recordError(lastErr)
+		span.RecordError(lastErr)
+		var perm permanentError
+		if errors.As(lastErr, &perm) {
+			break
+		}
+		logger.Warn(fmt.Sprintf("Handler for %s failed (event %s, attempt %d/%d): %v",
+			c.cfg.Topic, eventID, attempts, c.cfg.MaxRetries+1, lastErr))
+	}
+
+	span.SetStatus(codes.Error, "dead-lettered")
+	return c.sendToDeadLetter(ctx, m, lastErr, attempts)
+}
+
+// apply runs the handler unless the ledger already has the event. done
+// reports a duplicate.
+func (c *Consumer) apply(ctx context.Context, m Message, eventID string) (done bool, err error) {
+	if c.ledger == nil {
+		return false, c.handler(ctx, m)
+	}
+	return c.ledger.Apply(ctx, c.cfg.GroupID, eventID, func(ctx context.Context) error {
+		return c.handler(ctx, m)
+	})
+}
+
+// sendToDeadLetter keeps trying to park the message until it succeeds or
+// ctx is cancelled; committing past a message that was not parked would
+// lose it
+func (c *Consumer) sendToDeadLetter(ctx context.Context, m Message, cause error, attempts int) error {
+	dl := Message{
+		Topic: c.cfg.DeadLetterTopic,
+		Key:   m.Key,
+		Value: m.Value,
+		Headers: map[string]string{
+			"x-original-topic":     m.Topic,
+			"x-original-partition": strconv.Itoa(m.Partition),
+			"x-original-offset":    strconv.FormatInt(m.Offset, 10),
+			"x-consumer-group":     c.cfg.GroupID,
+			"x-error":              cause.Error(),
+			"x-attempts":           strconv.Itoa(attempts),
+			"x-failed-at":          time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+	telemetry.InjectHeaders(ctx, dl.Headers)
+
+	for failures := 0; ; failures++ {
+		if failures > 0 && !sleepCtx(ctx, c.backoff(failures)) {
+			return ctx.Err()
+		}
+		err := errors.New("no dead-letter publisher configured")
+		if c.deadLetter != nil {
+			err = c.deadLetter.Publish(ctx, []Message{dl})
+		}
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c.bump(func(s *ConsumerStatus) { s.DeadLetterDown = true })
+		logger.Error(fmt.Sprintf("Failed to dead-letter %s offset %d; partition is blocked", m.Topic, m.Offset), err)
+	}
+
+	c.bump(func(s *ConsumerStatus) {
+		s.DeadLettered++
+		s.DeadLetterDown = false
+	})
+	c.countConsumed("dead_lettered")
+	logger.Error(fmt.Sprintf("Dead-lettered %s offset %d to %s after %d attempts",
+		m.Topic, m.Offset, c.cfg.DeadLetterTopic, attempts), cause)
+	return nil
+}
+
+// Status returns a snapshot of the consumer's health
+func (c *Consumer) Status() ConsumerStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.status
+	if c.src != nil {
+		s.Lag = c.src.Lag()
+	}
+	return s
+}
+
+func (c *Consumer) backoff(attempt int) time.Duration {
+	delay := c.cfg.BaseBackoff
+	for i := 1; i < attempt && delay < c.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > c.cfg.MaxBackoff {
+		delay = c.cfg.MaxBackoff
+	}
+	return delay
+}
+
+func (c *Consumer) bump(fn func(*ConsumerStatus)) {
+	c.mu.Lock()
+	fn(&c.status)
+	c.mu.Unlock()
+}
+
+func (c *Consumer) countConsumed(result string) {
+	messagesConsumed.WithLabelValues(c.cfg.Topic, c.cfg.GroupID, result).Inc()
+}
+
+func (c *Consumer) recordError(err error) {
+	now := time.Now()
+	c.bump(func(s *ConsumerStatus) {
+		s.LastError = err.Error()
+		s.LastErrorAt = &now
+	})
+}
+
+// hashEventID identifies events without an ID of their own by content, so
+// an identical replayed payload is recognised
+func hashEventID(m Message) (string, error) {
+	sum := sha256.Sum256(m.Value)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// ============================================
+// REGISTRY
+// ============================================
+
+var (
+	registryMu sync.Mutex
+	registry   []*Consumer
+)
+
+func register(c *Consumer) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, c)
+}
+
+// ConsumerStatuses reports every consumer started in this process, sorted
+// by topic
+func ConsumerStatuses() []ConsumerStatus {
+	registryMu.Lock()
+	consumers := append([]*Consumer(nil), registry...)
+	registryMu.Unlock()
+
+	statuses := make([]ConsumerStatus, 0, len(consumers))
+	for _, c := range consumers {
+		statuses = append(statuses, c.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Topic < statuses[j].Topic })
+	return statuses
+}
@@ -0,0 +1,156 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"example/hello/pkg/telemetry"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func testConsumer(handler Handler, ledger Ledger, dlq Publisher) *Consumer {
+	return NewConsumer(ConsumerConfig{
+		Topic:       "t",
+		GroupID:     "g",
+		MaxRetries:  2,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+	}, handler, ledger, dlq)
+}
+
+func TestConsumerRetriesThenSucceeds(t *testing.T) {
+	calls := 0
+	c := testConsumer(func(context.Context, Message) error {
+		calls++
+		if calls < 3 {
+			return errors.New("db unavailable")
+		}
+		return nil
+	}, NewMemoryLedger(), NewMemoryBroker())
+
+	if err := c.Process(context.Background(), Message{Topic: "t", Value: []byte(`{"a":1}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.Status(); calls != 3 || s.Processed != 1 || s.Retries != 2 || s.DeadLettered != 0 {
+		t.Errorf("calls=%d status=%+v; want 3 calls, 1 processed, 2 retries", calls, s)
+	}
+}
+
+func TestConsumerDeadLettersAfterRetries(t *testing.T) {
+	dlq := NewMemoryBroker()
+	calls := 0
+	c := testConsumer(func(context.Context, Message) error {
+		calls++
+		return errors.New("always fails")
+	}, NewMemoryLedger(), dlq)
+
+	m := Message{Topic: "t", Key: []byte("k"), Value: []byte(`{}`), Partition: 2, Offset: 41}
+	if err := c.Process(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("handler called %d times; want 3", calls)
+	}
+	parked := dlq.Messages("t.dlq")
+	if len(parked) != 1 {
+		t.Fatalf("dead-lettered %d messages; want 1", len(parked))
+	}
+	h := parked[0].Headers
+	if h["x-original-topic"] != "t" || h["x-original-offset"] != "41" || h["x-error"] != "always fails" || h["x-attempts"] != "3" {
+		t.Errorf("unexpected dead-letter headers %v", h)
+	}
+}
+
+func TestConsumerPermanentErrorSkipsRetries(t *testing.T) {
+	dlq := NewMemoryBroker()
+	calls := 0
+	c := testConsumer(JSONHandler(func(context.Context, struct{ ID int }) error {
+		calls++
+		return nil
+	}), NewMemoryLedger(), dlq)
+
+	if err := c.Process(context.Background(), Message{Topic: "t", Value: []byte(`not json`)}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 0 || len(dlq.Messages("t.dlq")) != 1 || c.Status().Retries != 0 {
+		t.Errorf("calls=%d dlq=%d status=%+v; want malformed payload dead-lettered without retries",
+			calls, len(dlq.Messages("t.dlq")), c.Status())
+	}
+}
+
+func TestConsumerSkipsProcessedEvents(t *testing.T) {
+	calls := 0
+	c := testConsumer(func(context.Context, Message) error {
+		calls++
+		return nil
+	}, NewMemoryLedger(), NewMemoryBroker())
+
+	m := Message{Topic: "t", Value: []byte(`{"interaction_id":7}`)}
+	for i := 0; i < 2; i++ {
+		if err := c.Process(context.Background(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := c.Status(); calls != 1 || s.Duplicates != 1 {
+		t.Errorf("calls=%d status=%+v; want the replay skipped", calls, s)
+	}
+}
+
+func TestConsumerBlocksUntilDeadLetterAccepts(t *testing.T) {
+	dlq := NewMemoryBroker()
+	dlq.FailWith(func(Message) error { return errors.New("dlq down") })
+	c := testConsumer(func(context.Context, Message) error {
+		return Permanent(errors.New("bad"))
+	}, nil, dlq)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.Process(ctx, Message{Topic: "t", Value: []byte(`{}`)}); err == nil {
+		t.Fatal("Process returned nil although the message was never parked")
+	}
+	if !c.Status().DeadLetterDown {
+		t.Error("status does not report the dead-letter topic as down")
+	}
+}
+
+func TestJSONEventIDFallsBackToHash(t *testing.T) {
+	id := jsonEventID(func(e MicroInteractionEvent) string {
+		if e.InteractionID == 0 {
+			return ""
+		}
+		return "interaction"
+	})
+	got, err := id(Message{Value: []byte(`{"user_id":1}`)})
+	if err != nil || len(got) != len("sha256:")+64 {
+		t.Errorf("id = %q, %v; want content hash", got, err)
+	}
+	if _, err := id(Message{Value: []byte(`{`)}); err == nil {
+		t.Error("expected an error for a malformed payload")
+	}
+}
+
+func TestConsumerContinuesProducerTrace(t *testing.T) {
+	if _, err := telemetry.InitTracing(context.Background(), telemetry.TracingConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	var got string
+	c := testConsumer(func(ctx context.Context, _ Message) error {
+		got = trace.SpanContextFromContext(ctx).TraceID().String()
+		return nil
+	}, nil, NewMemoryBroker())
+
+	m := Message{Topic: "t", Value: []byte(`{}`), Headers: map[string]string{
+		"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01",
+	}}
+	if err := c.Process(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+	if got != traceID {
+		t.Errorf("handler trace id = %q; want %q from the message headers", got, traceID)
+	}
+}
@@ -0,0 +1,473 @@
+package markdown
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// asciiPunct are the characters a backslash can escape
+const asciiPunct = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+// maxLinkSpan caps how far the link parser looks for "]" and ")" so that
+// unbalanced brackets cannot make rendering quadratic.
+const maxLinkSpan = 2048
+
+// inline renders one run of inline text. missing remembers delimiters that
+// have no closer anywhere after a failed search; since any later search
+// covers a suffix of the same text, it would fail too.
+type inline struct {
+	b       *strings.Builder
+	s       string
+	depth   int
+	noLinks bool
+	missing map[string]bool
+}
+
+func renderInline(b *strings.Builder, s string, depth int) {
+	renderInlineOpts(b, s, depth, false)
+}
+
+func renderInlineOpts(b *strings.Builder, s string, depth int, noLinks bool) {
+	p := &inline{b: b, s: s, depth: depth, noLinks: noLinks, missing: map[string]bool{}}
+	p.run()
+}
+
+func (p *inline) run() {
+	s := p.s
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\':
+			i = p.backslash(i)
+		case c == '`':
+			i = p.codeSpan(i)
+		case c == '$':
+			i = p.dollarMath(i)
+		case c == '!' && i+1 < len(s) && s[i+1] == '[' && !p.noLinks:
+			i = p.link(i+1, true)
+		case c == '[' && !p.noLinks:
+			i = p.link(i, false)
+		case c == '<':
+			i = p.angleAutolink(i)
+		case (c == 'h' || c == 'H') && !p.noLinks && (i == 0 || !isWordByte(s[i-1])):
+			i = p.bareURL(i)
+		case c == '*' || c == '_' || c == '~':
+			i = p.emphasis(i)
+		case c == '\n':
+			p.b.WriteString("<br>\n")
+			i++
+		default:
+			writeEscaped(p.b, c)
+			i++
+		}
+	}
+}
+
+// backslash handles escapes, hard breaks and \( \) / \[ \] math
+func (p *inline) backslash(i int) int {
+	s := p.s
+	if i+1 >= len(s) {
+		p.b.WriteByte('\\')
+		return i + 1
+	}
+	switch next := s[i+1]; {
+	case next == '(' || next == '[':
+		closer := `\)`
+		class := "math math-inline"
+		if next == '[' {
+			closer, class = `\]`, "math math-display"
+		}
+		if end := p.find(closer, i+2); end >= 0 {
+			p.mathSpan(class, s[i+2:end])
+			return end + 2
+		}
+		writeEscaped(p.b, next)
+		return i + 2
+	case next == '\n':
+		p.b.WriteString("<br>\n")
+		return i + 2
+	case strings.IndexByte(asciiPunct, next) >= 0:
+		writeEscaped(p.b, next)
+		return i + 2
+	}
+	p.b.WriteByte('\\')
+	return i + 1
+}
+
+func (p *inline) codeSpan(i int) int {
+	s := p.s
+	n := 0
+	for i+n < len(s) && s[i+n] == '`' {
+		n++
+	}
+	fence := s[i : i+n]
+
+	// The closing run must be exactly as long as the opening one
+	for from := i + n; ; {
+		end := p.find(fence, from)
+		if end < 0 {
+			p.b.WriteString(fence)
+			return i + n
+		}
+		if end+n < len(s) && s[end+n] == '`' {
+			from = end + n
+			for from < len(s) && s[from] == '`' {
+				from++
+			}
+			continue
+		}
+
+		code := strings.ReplaceAll(s[i+n:end], "\n", " ")
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+			code = code[1 : len(code)-1]
+		}
+		p.b.WriteString("<code>" + escapeHTML(code) + "</code>")
+		return end + n
+	}
+}
+
+// dollarMath handles $inline$ and $$display$$ math. Like Pandoc, an opening
+// $ must be followed by a non-space and a closing $ must follow a non-space
+// and not be followed by a digit, so "costs $5 or $10" stays plain text.
+func (p *inline) dollarMath(i int) int {
+	s := p.s
+	if i+1 < len(s) && s[i+1] == '$' {
+		if end := p.find("$$", i+2); end > i+2 {
+			p.mathSpan("math math-display", s[i+2:end])
+			return end + 2
+		}
+		p.b.WriteString("$$")
+		return i + 2
+	}
+
+	if i+1 < len(s) && s[i+1] != ' ' && s[i+1] != '\n' && s[i+1] != '\t' {
+		for from := i + 1; ; {
+			end := p.find("$", from)
+			if end < 0 {
+				break
+			}
+			prev := s[end-1]
+			if end > i+1 && prev != ' ' && prev != '\t' && prev != '\n' && prev != '\\' &&
+				(end+1 >= len(s) || s[end+1] < '0' || s[end+1] > '9') {
+				p.mathSpan("math math-inline", s[i+1:end])
+				return end + 1
+			}
+			from = end + 1
+		}
+	}
+	p.b.WriteByte('$')
+	return i + 1
+}
+
+func (p *inline) mathSpan(class, tex string) {
+	p.b.WriteString(`<span class="` + class + `">`)
+	p.b.WriteString(escapeHTML(strings.TrimSpace(tex)))
+	p.b.WriteString("</span>")
+}
+
+// link parses [text](url "title") starting at the "[" at i
+func (p *inline) link(i int, image bool) int {
+	s := p.s
+	start := i
+	if image {
+		start = i - 1
+	}
+
+	textEnd := matchBracket(s, i)
+	if textEnd < 0 || textEnd+1 >= len(s) || s[textEnd+1] != '(' {
+		p.b.WriteString(escapeHTML(s[start : i+1]))
+		return i + 1
+	}
+	dest, title, end, ok := parseDestination(s, textEnd+2)
+	if !ok {
+		p.b.WriteString(escapeHTML(s[start : i+1]))
+		return i + 1
+	}
+	text := s[i+1 : textEnd]
+
+	if image {
+		if u, ok := safeURL(dest, true); ok {
+			p.b.WriteString(`<img src="` + escapeHTML(u) + `" alt="` + escapeHTML(plainText(text)) + `"`)
+			if title != "" {
+				p.b.WriteString(` title="` + escapeHTML(title) + `"`)
+			}
+			p.b.WriteString(">")
+		} else {
+			p.b.WriteString(escapeHTML(plainText(text)))
+		}
+		return end
+	}
+
+	u, ok := safeURL(dest, false)
+	if ok {
+		p.b.WriteString(`<a href="` + escapeHTML(u) + `"`)
+		if title != "" {
+			p.b.WriteString(` title="` + escapeHTML(title) + `"`)
+		}
+		p.b.WriteString(">")
+	}
+	if p.depth < maxNesting {
+		renderInlineOpts(p.b, text, p.depth+1, true)
+	} else {
+		p.b.WriteString(escapeHTML(text))
+	}
+	if ok {
+		p.b.WriteString("</a>")
+	}
+	return end
+}
+
+// matchBracket returns the index of the "]" closing the "[" at i, or -1
+func matchBracket(s string, i int) int {
+	depth := 0
+	limit := min(len(s), i+maxLinkSpan)
+	for j := i; j < limit; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// parseDestination parses `url "title")` starting just after "("
+func parseDestination(s string, i int) (dest, title string, end int, ok bool) {
+	limit := min(len(s), i+maxLinkSpan)
+	for i < limit && (s[i] == ' ' || s[i] == '\n') {
+		i++
+	}
+
+	if i < limit && s[i] == '<' {
+		j := strings.IndexAny(s[i+1:limit], ">\n")
+		if j < 0 || s[i+1+j] != '>' {
+			return "", "", 0, false
+		}
+		dest = s[i+1 : i+1+j]
+		i += j + 2
+	} else {
+		parens := 0
+		j := i
+		for ; j < limit; j++ {
+			c := s[j]
+			if c == ' ' || c == '\n' || c < 0x20 {
+				break
+			}
+			if c == '(' {
+				parens++
+			}
+			if c == ')' {
+				if parens == 0 {
+					break
+				}
+				parens--
+			}
+		}
+		dest = s[i:j]
+		i = j
+	}
+
+	for i < limit && (s[i] == ' ' || s[i] == '\n') {
+		i++
+	}
+	if i < limit && (s[i] == '"' || s[i] == '\'') {
+		q := s[i]
+		j := strings.IndexByte(s[i+1:limit], q)
+		if j < 0 {
+			return "", "", 0, false
+		}
+		title = s[i+1 : i+1+j]
+		i += j + 2
+		for i < limit && s[i] == ' ' {
+			i++
+		}
+	}
+	if i >= limit || s[i] != ')' {
+		return "", "", 0, false
+	}
+	return dest, title, i + 1, true
+}
+
+// angleAutolink handles <https://…> and <mailto:…>; any other "<" is text
+func (p *inline) angleAutolink(i int) int {
+	s := p.s
+	if !p.noLinks {
+		limit := min(len(s), i+maxLinkSpan)
+		if j := strings.IndexAny(s[i+1:limit], "> \n<"); j > 0 && s[i+1+j] == '>' {
+			target := s[i+1 : i+1+j]
+			if u, ok := safeURL(target, false); ok && strings.Contains(target, ":") {
+				p.b.WriteString(`<a href="` + escapeHTML(u) + `">` + escapeHTML(strings.TrimPrefix(target, "mailto:")) + "</a>")
+				return i + j + 2
+			}
+		}
+	}
+	p.b.WriteString("&lt;")
+	return i + 1
+}
+
+// bareURL links plain http(s):// URLs written in running text
+func (p *inline) bareURL(i int) int {
+	s := p.s
+	rest := strings.ToLower(s[i:min(len(s), i+8)])
+	if !strings.HasPrefix(rest, "http://") && !strings.HasPrefix(rest, "https://") {
+		writeEscaped(p.b, s[i])
+		return i + 1
+	}
+
+	end := i
+	for end < len(s) && end-i < maxLinkSpan && s[end] > ' ' && s[end] != '<' {
+		end++
+	}
+	// Trailing punctuation belongs to the sentence, not the URL
+	for end > i {
+		c := s[end-1]
+		if strings.IndexByte(".,:;!?'\"*_~", c) >= 0 {
+			end--
+			continue
+		}
+		if c == ')' && strings.Count(s[i:end], "(") < strings.Count(s[i:end], ")") {
+			end--
+			continue
+		}
+		break
+	}
+
+	target := s[i:end]
+	u, ok := safeURL(target, false)
+	if !ok || len(target) <= len("https://") {
+		writeEscaped(p.b, s[i])
+		return i + 1
+	}
+	p.b.WriteString(`<a href="` + escapeHTML(u) + `">` + escapeHTML(target) + "</a>")
+	return end
+}
+
+// emphasis handles *em*, _em_, **strong**, __strong__, ***both*** and ~~del~~
+func (p *inline) emphasis(i int) int {
+	s := p.s
+	c := s[i]
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+
+	// Openers must be left-flanking; "_" must also start a word so that
+	// snake_case identifiers stay intact.
+	opens := i+n < len(s) && !unicode.IsSpace(rune(s[i+n]))
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		opens = false
+	}
+	if !opens || p.depth >= maxNesting || (c == '~' && n != 2) {
+		p.b.WriteString(s[i : i+n])
+		return i + n
+	}
+
+	for _, size := range []int{3, 2, 1} {
+		if size > n || (c == '~' && size != 2) {
+			continue
+		}
+		delim := strings.Repeat(string(c), size)
+		end := p.findCloser(delim, i+size)
+		if end < 0 {
+			continue
+		}
+
+		// Extra opening delimiters beyond the matched run are literal
+		p.b.WriteString(s[i : i+n-size])
+		open, closeTags := emphasisTags(c, size)
+		p.b.WriteString(open)
+		renderInlineOpts(p.b, s[i+n:end], p.depth+1, p.noLinks)
+		p.b.WriteString(closeTags)
+		return end + size
+	}
+
+	p.b.WriteString(s[i : i+n])
+	return i + n
+}
+
+func emphasisTags(c byte, size int) (string, string) {
+	switch {
+	case c == '~':
+		return "<del>", "</del>"
+	case size == 3:
+		return "<em><strong>", "</strong></em>"
+	case size == 2:
+		return "<strong>", "</strong>"
+	}
+	return "<em>", "</em>"
+}
+
+// findCloser finds a right-flanking run of exactly delim at or after from,
+// skipping code spans.
+func (p *inline) findCloser(delim string, from int) int {
+	s := p.s
+	key := "emph:" + delim
+	if p.missing[key] {
+		return -1
+	}
+	c := delim[0]
+	for j := from; j < len(s); j++ {
+		if s[j] == '\\' {
+			j++
+			continue
+		}
+		if s[j] == '`' {
+			n := 0
+			for j+n < len(s) && s[j+n] == '`' {
+				n++
+			}
+			if end := strings.Index(s[j+n:], s[j:j+n]); end >= 0 {
+				j += n + end + n - 1
+			}
+			continue
+		}
+		if s[j] != c {
+			continue
+		}
+		run := 0
+		for j+run < len(s) && s[j+run] == c {
+			run++
+		}
+		if run == len(delim) && j > from && !unicode.IsSpace(rune(s[j-1])) &&
+			(c != '_' || j+run >= len(s) || !isWordByte(s[j+run])) {
+			return j
+		}
+		j += run - 1
+	}
+	p.missing[key] = true
+	return -1
+}
+
+// find is strings.Index from an offset, remembering failures
+func (p *inline) find(sub string, from int) int {
+	if p.missing[sub] || from > len(p.s) {
+		return -1
+	}
+	j := strings.Index(p.s[from:], sub)
+	if j < 0 {
+		p.missing[sub] = true
+		return -1
+	}
+	return from + j
+}
+
+// plainText strips Markdown punctuation for use in alt attributes
+func plainText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune("*_~`[]\\", r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func isWordByte(c byte) bool {
+	return c >= utf8.RuneSelf || c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,586 @@
+// Package markdown renders the Markdown dialect used by the course forum to
+// HTML that is safe to insert into the page as-is.
+//
+// Supported: ATX headings, paragraphs (single newlines become <br>), block
+// quotes, bullet and ordered lists, fenced code blocks, GFM pipe tables,
+// thematic breaks, emphasis, strikethrough, code spans, links, images,
+// autolinks and TeX math. Math is emitted as KaTeX-ready elements:
+//
+//	<span class="math math-inline">x^2</span>
+//	<div class="math math-display">\int_0^1 f(x)\,dx</div>
+//
+// Raw HTML in the source is escaped, never passed through, and the rendered
+// output is run through Sanitize so only an allowlisted set of tags and
+// attributes can reach the browser.
+package markdown
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxNesting bounds recursion through block quotes, lists and emphasis so
+// hostile input cannot blow the stack.
+const maxNesting = 8
+
+// Render converts Markdown source to sanitized HTML
+func Render(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.ReplaceAll(src, "\x00", "\uFFFD")
+
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"), 0, false)
+	return Sanitize(b.String())
+}
+
+// ============================================
+// BLOCKS
+// ============================================
+
+// renderBlocks renders a run of lines. tight drops the <p> wrapper around
+// paragraphs, as in list items that contain no blank lines.
+func renderBlocks(b *strings.Builder, lines []string, depth int, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+		case isFenceOpen(line):
+			i = renderFence(b, lines, i)
+		case strings.HasPrefix(trimmed, "$$") && indentWidth(line) < 4:
+			i = renderMathBlock(b, lines, i)
+		case headingLevel(line) > 0:
+			renderHeading(b, line, depth)
+			i++
+		case isThematicBreak(line):
+			b.WriteString("<hr>\n")
+			i++
+		case depth < maxNesting && isBlockquote(line):
+			i = renderBlockquote(b, lines, i, depth)
+		case depth < maxNesting && isListItem(line):
+			i = renderList(b, lines, i, depth)
+		case isTableStart(lines, i):
+			i = renderTable(b, lines, i, depth)
+		default:
+			i = renderParagraph(b, lines, i, depth, tight)
+		}
+	}
+}
+
+// startsBlock reports whether line interrupts a paragraph
+func startsBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" ||
+		isFenceOpen(line) ||
+		(strings.HasPrefix(trimmed, "$$") && indentWidth(line) < 4) ||
+		headingLevel(line) > 0 ||
+		isThematicBreak(line) ||
+		isBlockquote(line) ||
+		isListItem(line)
+}
+
+func renderParagraph(b *strings.Builder, lines []string, i, depth int, tight bool) int {
+	start := i
+	i++
+	for i < len(lines) && !startsBlock(lines[i]) && !isTableStart(lines, i) {
+		i++
+	}
+
+	para := make([]string, 0, i-start)
+	for _, l := range lines[start:i] {
+		para = append(para, strings.TrimSpace(l))
+	}
+
+	if !tight {
+		b.WriteString("<p>")
+	}
+	renderInline(b, strings.Join(para, "\n"), depth)
+	if !tight {
+		b.WriteString("</p>")
+	}
+	b.WriteString("\n")
+	return i
+}
+
+func headingLevel(line string) int {
+	if indentWidth(line) > 3 {
+		return 0
+	}
+	t := strings.TrimLeft(line, " ")
+	n := 0
+	for n < len(t) && t[n] == '#' {
+		n++
+	}
+	if n == 0 || n > 6 {
+		return 0
+	}
+	if n < len(t) && t[n] != ' ' && t[n] != '\t' {
+		return 0
+	}
+	return n
+}
+
+func renderHeading(b *strings.Builder, line string, depth int) {
+	level := headingLevel(line)
+	text := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+	// Optional closing sequence: "## Title ##"
+	if stripped := strings.TrimRight(text, "#"); stripped != text && (stripped == "" || strings.HasSuffix(stripped, " ")) {
+		text = strings.TrimSpace(stripped)
+	}
+
+	tag := "h" + strconv.Itoa(level)
+	b.WriteString("<" + tag + ">")
+	renderInline(b, text, depth)
+	b.WriteString("</" + tag + ">\n")
+}
+
+func isThematicBreak(line string) bool {
+	if indentWidth(line) > 3 {
+		return false
+	}
+	t := strings.TrimSpace(line)
+	if len(t) < 3 {
+		return false
+	}
+	c := t[0]
+	if c != '-' && c != '*' && c != '_' {
+		return false
+	}
+	count := 0
+	for i := 0; i < len(t); i++ {
+		switch t[i] {
+		case c:
+			count++
+		case ' ', '\t':
+		default:
+			return false
+		}
+	}
+	return count >= 3
+}
+
+// ============================================
+// CODE AND MATH BLOCKS
+// ============================================
+
+func fenceOf(line string) (char byte, length int, info string) {
+	if indentWidth(line) > 3 {
+		return 0, 0, ""
+	}
+	t := strings.TrimLeft(line, " \t")
+	if len(t) < 3 || (t[0] != '`' && t[0] != '~') {
+		return 0, 0, ""
+	}
+	n := 0
+	for n < len(t) && t[n] == t[0] {
+		n++
+	}
+	if n < 3 {
+		return 0, 0, ""
+	}
+	info = strings.TrimSpace(t[n:])
+	if t[0] == '`' && strings.Contains(info, "`") {
+		return 0, 0, ""
+	}
+	return t[0], n, info
+}
+
+func isFenceOpen(line string) bool {
+	c, _, _ := fenceOf(line)
+	return c != 0
+}
+
+func renderFence(b *strings.Builder, lines []string, i int) int {
+	char, length, info := fenceOf(lines[i])
+	i++
+
+	var code []string
+	for ; i < len(lines); i++ {
+		c, n, rest := fenceOf(lines[i])
+		if c == char && n >= length && rest == "" {
+			i++
+			break
+		}
+		code = append(code, lines[i])
+	}
+
+	b.WriteString("<pre><code")
+	if lang := codeLanguage(info); lang != "" {
+		b.WriteString(` class="language-` + lang + `"`)
+	}
+	b.WriteString(">")
+	if len(code) > 0 {
+		b.WriteString(escapeHTML(strings.Join(code, "\n")))
+		b.WriteString("\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+// codeLanguage reduces a fence info string to a class-safe language name
+func codeLanguage(info string) string {
+	if f := strings.Fields(info); len(f) > 0 {
+		info = f[0]
+	}
+	var sb strings.Builder
+	for _, r := range strings.ToLower(info) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || strings.ContainsRune("_+#-", r) {
+			sb.WriteRune(r)
+		}
+		if sb.Len() >= 32 {
+			break
+		}
+	}
+	return sb.String()
+}
+
+// renderMathBlock handles $$ … $$ on one line or spread over several
+func renderMathBlock(b *strings.Builder, lines []string, i int) int {
+	first := strings.TrimPrefix(strings.TrimSpace(lines[i]), "$$")
+	i++
+
+	var tex []string
+	if strings.HasSuffix(first, "$$") {
+		tex = append(tex, strings.TrimSuffix(first, "$$"))
+	} else {
+		if strings.TrimSpace(first) != "" {
+			tex = append(tex, first)
+		}
+		for ; i < len(lines); i++ {
+			t := strings.TrimSpace(lines[i])
+			if strings.HasSuffix(t, "$$") {
+				if rest := strings.TrimSuffix(t, "$$"); rest != "" {
+					tex = append(tex, rest)
+				}
+				i++
+				break
+			}
+			tex = append(tex, lines[i])
+		}
+	}
+
+	b.WriteString(`<div class="math math-display">`)
+	b.WriteString(escapeHTML(strings.TrimSpace(strings.Join(tex, "\n"))))
+	b.WriteString("</div>\n")
+	return i
+}
+
+// ============================================
+// QUOTES AND LISTS
+// ============================================
+
+func isBlockquote(line string) bool {
+	return indentWidth(line) < 4 && strings.HasPrefix(strings.TrimLeft(line, " "), ">")
+}
+
+func renderBlockquote(b *strings.Builder, lines []string, i, depth int) int {
+	var inner []string
+	for ; i < len(lines) && isBlockquote(lines[i]); i++ {
+		l := strings.TrimPrefix(strings.TrimLeft(lines[i], " "), ">")
+		inner = append(inner, strings.TrimPrefix(l, " "))
+	}
+
+	b.WriteString("<blockquote>\n")
+	renderBlocks(b, inner, depth+1, false)
+	b.WriteString("</blockquote>\n")
+	return i
+}
+
+type listMarker struct {
+	ordered bool
+	delim   byte // bullet char, or '.' / ')' for ordered lists
+	start   int
+	content int // column where the item's content starts
+}
+
+func parseListMarker(line string) (listMarker, string, bool) {
+	indent := indentWidth(line)
+	if indent > 3 {
+		return listMarker{}, "", false
+	}
+	t := strings.TrimLeft(line, " ")
+
+	var m listMarker
+	n := 0
+	switch {
+	case len(t) > 0 && (t[0] == '-' || t[0] == '*' || t[0] == '+'):
+		m.delim, n = t[0], 1
+	default:
+		for n < len(t) && n < 9 && t[n] >= '0' && t[n] <= '9' {
+			n++
+		}
+		if n == 0 || n >= len(t) || (t[n] != '.' && t[n] != ')') {
+			return listMarker{}, "", false
+		}
+		m.ordered, m.delim = true, t[n]
+		m.start, _ = strconv.Atoi(t[:n])
+		n++
+	}
+
+	rest := t[n:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return listMarker{}, "", false
+	}
+	content := strings.TrimLeft(rest, " \t")
+	spaces := len(rest) - len(content)
+	if spaces > 4 || content == "" {
+		spaces = 1
+	}
+	m.content = indent + n + spaces
+	return m, content, true
+}
+
+func isListItem(line string) bool {
+	_, _, ok := parseListMarker(line)
+	return ok
+}
+
+func renderList(b *strings.Builder, lines []string, i, depth int) int {
+	first, _, _ := parseListMarker(lines[i])
+
+	var items [][]string
+	loose := false
+	for i < len(lines) {
+		m, content, ok := parseListMarker(lines[i])
+		if !ok || m.ordered != first.ordered || m.delim != first.delim {
+			break
+		}
+		item := []string{content}
+		i++
+
+		for i < len(lines) {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				// A blank line continues the item only if indented content follows
+				j := i
+				for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+					j++
+				}
+				if j < len(lines) && indentWidth(lines[j]) >= m.content {
+					item = append(item, lines[i:j]...)
+					loose = true
+					i = j
+					continue
+				}
+				break
+			}
+			if indentWidth(line) >= m.content {
+				item = append(item, stripIndent(line, m.content))
+				i++
+				continue
+			}
+			// Lazy continuation of the item's paragraph
+			if !startsBlock(line) {
+				item = append(item, strings.TrimSpace(line))
+				i++
+				continue
+			}
+			break
+		}
+		items = append(items, item)
+
+		// Blank lines between items make the list loose
+		j := i
+		for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+			j++
+		}
+		if j > i && j < len(lines) {
+			if next, _, ok := parseListMarker(lines[j]); ok && next.ordered == first.ordered && next.delim == first.delim {
+				loose = true
+				i = j
+			}
+		}
+	}
+
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if first.ordered && first.start != 1 {
+		b.WriteString(` start="` + strconv.Itoa(first.start) + `"`)
+	}
+	b.WriteString(">\n")
+	for _, item := range items {
+		b.WriteString("<li>")
+		var inner strings.Builder
+		renderBlocks(&inner, item, depth+1, !loose)
+		b.WriteString(strings.TrimSuffix(inner.String(), "\n"))
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// ============================================
+// TABLES
+// ============================================
+
+// maxTableColumns keeps a single pipe-heavy line from producing a huge table
+const maxTableColumns = 64
+
+func isTableStart(lines []string, i int) bool {
+	if i+1 >= len(lines) || !strings.Contains(lines[i], "|") {
+		return false
+	}
+	header := splitTableRow(lines[i])
+	aligns, ok := parseTableDelimiter(lines[i+1])
+	return ok && len(header) == len(aligns) && len(header) <= maxTableColumns
+}
+
+func parseTableDelimiter(line string) ([]string, bool) {
+	if !strings.Contains(line, "-") {
+		return nil, false
+	}
+	cells := splitTableRow(line)
+	aligns := make([]string, len(cells))
+	for i, c := range cells {
+		c = strings.TrimSpace(c)
+		left, right := strings.HasPrefix(c, ":"), strings.HasSuffix(c, ":")
+		dashes := strings.Trim(c, ":")
+		if dashes == "" || strings.Trim(dashes, "-") != "" {
+			return nil, false
+		}
+		switch {
+		case left && right:
+			aligns[i] = "center"
+		case left:
+			aligns[i] = "left"
+		case right:
+			aligns[i] = "right"
+		}
+	}
+	return aligns, true
+}
+
+// splitTableRow splits a pipe table row, honouring \| escapes
+func splitTableRow(line string) []string {
+	t := strings.TrimSpace(line)
+	t = strings.TrimPrefix(t, "|")
+	if strings.HasSuffix(t, "|") && !strings.HasSuffix(t, `\|`) {
+		t = t[:len(t)-1]
+	}
+
+	var cells []string
+	var cur strings.Builder
+	for i := 0; i < len(t); i++ {
+		switch {
+		case t[i] == '\\' && i+1 < len(t) && t[i+1] == '|':
+			cur.WriteByte('|')
+			i++
+		case t[i] == '|':
+			cells = append(cells, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteByte(t[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cur.String()))
+}
+
+func renderTable(b *strings.Builder, lines []string, i, depth int) int {
+	header := splitTableRow(lines[i])
+	aligns, _ := parseTableDelimiter(lines[i+1])
+	i += 2
+
+	writeRow := func(cells []string, tag string) {
+		b.WriteString("<tr>\n")
+		for c := range aligns {
+			b.WriteString("<" + tag)
+			if aligns[c] != "" {
+				b.WriteString(` align="` + aligns[c] + `"`)
+			}
+			b.WriteString(">")
+			if c < len(cells) {
+				renderInline(b, cells[c], depth)
+			}
+			b.WriteString("</" + tag + ">\n")
+		}
+		b.WriteString("</tr>\n")
+	}
+
+	b.WriteString("<table>\n<thead>\n")
+	writeRow(header, "th")
+	b.WriteString("</thead>\n")
+
+	bodyStart := i
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "" && !startsBlock(lines[i]) {
+		if i == bodyStart {
+			b.WriteString("<tbody>\n")
+		}
+		writeRow(splitTableRow(lines[i]), "td")
+		i++
+	}
+	if i > bodyStart {
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>\n")
+	return i
+}
+
+// ============================================
+// HELPERS
+// ============================================
+
+// indentWidth counts leading indentation, a tab counting as four columns
+func indentWidth(line string) int {
+	w := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			w++
+		case '\t':
+			w += 4 - w%4
+		default:
+			return w
+		}
+	}
+	return w
+}
+
+// stripIndent removes up to n columns of leading indentation
+func stripIndent(line string, n int) string {
+	w := 0
+	for i, c := range line {
+		if w >= n {
+			return line[i:]
+		}
+		switch c {
+		case ' ':
+			w++
+		case '\t':
+			w += 4 - w%4
+		default:
+			return line[i:]
+		}
+	}
+	return ""
+}
+
+func escapeHTML(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		writeEscaped(&b, s[i])
+	}
+	return b.String()
+}
+
+func writeEscaped(b *strings.Builder, c byte) {
+	switch c {
+	case '&':
+		b.WriteString("&amp;")
+	case '<':
+		b.WriteString("&lt;")
+	case '>':
+		b.WriteString("&gt;")
+	case '"':
+		b.WriteString("&#34;")
+	case '\'':
+		b.WriteString("&#39;")
+	default:
+		b.WriteByte(c)
+	}
+}
@@ -0,0 +1,95 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"paragraph with line break", "Xin chào\nbạn", "<p>Xin chào<br>\nbạn</p>\n"},
+		{"heading", "## Bài 3 ##", "<h2>Bài 3</h2>\n"},
+		{"emphasis", "**bold**, *em*, ~~del~~ and snake_case_name", "<p><strong>bold</strong>, <em>em</em>, <del>del</del> and snake_case_name</p>\n"},
+		{"code span", "use `a < b`", "<p>use <code>a &lt; b</code></p>\n"},
+		{"fenced code", "```go\nfmt.Println(\"<hi>\")\n```", "<pre><code class=\"language-go\">fmt.Println(&#34;&lt;hi&gt;&#34;)\n</code></pre>\n"},
+		{"inline math", "Let $x^2 < 1$ hold", "<p>Let <span class=\"math math-inline\">x^2 &lt; 1</span> hold</p>\n"},
+		{"dollars are not math", "costs $5 or $10", "<p>costs $5 or $10</p>\n"},
+		{"display math", "$$\n\\int_0^1 f(x)\\,dx\n$$", "<div class=\"math math-display\">\\int_0^1 f(x)\\,dx</div>\n"},
+		{"paren math", `\(a_1\)`, "<p><span class=\"math math-inline\">a_1</span></p>\n"},
+		{"link", "[docs](https://go.dev \"Go\")", "<p><a href=\"https://go.dev\" title=\"Go\" rel=\"nofollow ugc noopener noreferrer\">docs</a></p>\n"},
+		{"bare url", "see https://go.dev/doc.", "<p>see <a href=\"https://go.dev/doc\" rel=\"nofollow ugc noopener noreferrer\">https://go.dev/doc</a>.</p>\n"},
+		{"image", "![graph](/api/v1/forum/attachments/7)", "<p><img src=\"/api/v1/forum/attachments/7\" alt=\"graph\" loading=\"lazy\"></p>\n"},
+		{"tight list", "- a\n- b", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n"},
+		{"ordered list start", "3. c\n4. d", "<ol start=\"3\">\n<li>c</li>\n<li>d</li>\n</ol>\n"},
+		{"nested list", "- a\n  - b", "<ul>\n<li>a\n<ul>\n<li>b</li>\n</ul></li>\n</ul>\n"},
+		{"blockquote", "> quoted", "<blockquote>\n<p>quoted</p>\n</blockquote>\n"},
+		{"table", "| a | b |\n|:-|-:|\n| 1 | 2 |", "<table>\n<thead>\n<tr>\n<th align=\"left\">a</th>\n<th align=\"right\">b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td align=\"left\">1</td>\n<td align=\"right\">2</td>\n</tr>\n</tbody>\n</table>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.src); got != tt.want {
+				t.Errorf("Render(%q)\n got: %q\nwant: %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderEscapesUnsafeInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		forbidden []string
+	}{
+		{"raw script", "<script>alert(1)</script>", []string{"<script"}},
+		{"event handler", `<img src=x onerror="alert(1)">`, []string{"<img"}},
+		{"javascript link", "[click](javascript:alert(1))", []string{"href"}},
+		{"obfuscated scheme", "[click](java\tscript:alert(1))", []string{"href"}},
+		{"data image", "![x](data:image/svg+xml;base64,PHN2Zz4=)", []string{"<img"}},
+		{"mailto image", "![x](mailto:a@b.c)", []string{"<img"}},
+		{"attribute breakout", `[x](https://a.io/"onmouseover="alert(1))`, []string{`"onmouseover`}},
+		{"code language injection", "```go\" onclick=\"x\ncode\n```", []string{"onclick"}},
+		{"math breakout", "$</span><script>x</script>$", []string{"<script"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Render(tt.src)
+			for _, f := range tt.forbidden {
+				if strings.Contains(got, f) {
+					t.Errorf("Render(%q) = %q; must not contain %q", tt.src, got, f)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`<p onclick="x">hi</p>`, "<p>hi</p>"},
+		{`<a href="javascript:alert(1)">t</a>`, "t"},
+		{`<a href="JaVaScRiPt:alert(1)">t</a>`, "t"},
+		{`<div class="evil">x</div>`, "<div>x</div>"},
+		{`<span class="math math-inline">x</span>`, `<span class="math math-inline">x</span>`},
+		{`<svg><script>alert(1)</script></svg>ok`, "ok"},
+		{`<style>p{}</style><b>bold</b>`, "bold"},
+		{`<!-- c --><br>`, "<br>"},
+	}
+	for _, tt := range tests {
+		if got := Sanitize(tt.in); got != tt.want {
+			t.Errorf("Sanitize(%q) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderPathologicalInputIsFast(t *testing.T) {
+	src := strings.Repeat("*a _b [c `d $e ", 20000)
+	if out := Render(src); out == "" {
+		t.Fatal("empty output")
+	}
+}
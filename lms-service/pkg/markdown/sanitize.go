@@ -0,0 +1,178 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedTags are the elements the renderer emits; anything else is unwrapped
+// (its children are kept) or, for dropTags, removed together with its content.
+var allowedTags = map[string]bool{
+	"p": true, "br": true, "hr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"strong": true, "em": true, "del": true, "code": true, "pre": true,
+	"blockquote": true, "ul": true, "ol": true, "li": true,
+	"a": true, "img": true, "span": true, "div": true,
+	"table": true, "thead": true, "tbody": true, "tr": true, "th": true, "td": true,
+}
+
+var dropTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"noscript": true, "template": true, "textarea": true, "title": true,
+	"xmp": true, "noembed": true, "noframes": true, "plaintext": true, "select": true,
+}
+
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+var (
+	languageClass = regexp.MustCompile(`^language-[a-z0-9_+#-]{1,32}$`)
+	mathClasses   = map[string]bool{"math math-inline": true, "math math-display": true}
+	tableAligns   = map[string]bool{"left": true, "center": true, "right": true}
+	digitsOnly    = regexp.MustCompile(`^[0-9]{1,9}$`)
+)
+
+// Sanitize parses an HTML fragment and re-serializes only allowlisted
+// elements and attributes. Link and image URLs are limited to http(s),
+// mailto (links only) and relative paths; links always get
+// rel="nofollow ugc noopener noreferrer".
+func Sanitize(fragment string) string {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		return html.EscapeString(fragment)
+	}
+
+	var b strings.Builder
+	for _, n := range nodes {
+		writeNode(&b, n)
+	}
+	return b.String()
+}
+
+func writeNode(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+		// Foreign content (svg, math) is never emitted by the renderer
+		if dropTags[n.Data] || n.Namespace != "" {
+			return
+		}
+		if allowedTags[n.Data] {
+			if attrs, ok := sanitizeAttrs(n); ok {
+				b.WriteString("<" + n.Data + attrs + ">")
+				if voidTags[n.Data] {
+					return
+				}
+				writeChildren(b, n)
+				b.WriteString("</" + n.Data + ">")
+				return
+			}
+		}
+	case html.CommentNode, html.DoctypeNode:
+		return
+	}
+	writeChildren(b, n)
+}
+
+func writeChildren(b *strings.Builder, n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeNode(b, c)
+	}
+}
+
+// sanitizeAttrs returns the serialized allowed attributes of n. ok is false
+// when the element is pointless without an attribute that was rejected
+// (a link without a safe href, an image without a safe src).
+func sanitizeAttrs(n *html.Node) (string, bool) {
+	var b strings.Builder
+	write := func(key, val string) {
+		b.WriteString(" " + key + `="` + html.EscapeString(val) + `"`)
+	}
+
+	switch n.Data {
+	case "a":
+		href, ok := safeURL(attr(n, "href"), false)
+		if !ok {
+			return "", false
+		}
+		write("href", href)
+		if t := attr(n, "title"); t != "" {
+			write("title", t)
+		}
+		write("rel", "nofollow ugc noopener noreferrer")
+	case "img":
+		src, ok := safeURL(attr(n, "src"), true)
+		if !ok {
+			return "", false
+		}
+		write("src", src)
+		write("alt", attr(n, "alt"))
+		if t := attr(n, "title"); t != "" {
+			write("title", t)
+		}
+		write("loading", "lazy")
+	case "code":
+		if c := attr(n, "class"); languageClass.MatchString(c) {
+			write("class", c)
+		}
+	case "span", "div":
+		if c := attr(n, "class"); mathClasses[c] {
+			write("class", c)
+		}
+	case "ol":
+		if s := attr(n, "start"); digitsOnly.MatchString(s) {
+			write("start", s)
+		}
+	case "th", "td":
+		if a := attr(n, "align"); tableAligns[a] {
+			write("align", a)
+		}
+	}
+	return b.String(), true
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// safeURL accepts http(s), mailto (links only) and scheme-less relative URLs.
+// URLs containing whitespace or control characters are rejected outright,
+// since browsers strip them and "java\tscript:" would otherwise slip past.
+func safeURL(raw string, image bool) (string, bool) {
+	u := strings.TrimSpace(raw)
+	if u == "" {
+		return "", false
+	}
+	for _, r := range u {
+		if r <= ' ' || r == 0x7f {
+			return "", false
+		}
+	}
+
+	end := strings.IndexAny(u, "/?#")
+	if end < 0 {
+		end = len(u)
+	}
+	colon := strings.IndexByte(u[:end], ':')
+	if colon < 0 {
+		return u, true
+	}
+
+	switch strings.ToLower(u[:colon]) {
+	case "http", "https":
+		return u, true
+	case "mailto":
+		return u, !image
+	}
+	return "", false
+}
@@ -0,0 +1,152 @@
+// Package moderation screens user-generated text (forum posts and comments)
+// for banned words and link spam before it is stored.
+package moderation
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Reasons returned in a Verdict. Rejected authors see the reason in the
+// RejectedError; the matched word or domain only goes to the moderator log.
+const (
+	ReasonBannedWord    = "BANNED_WORD"
+	ReasonTooManyLinks  = "TOO_MANY_LINKS"
+	ReasonBlockedDomain = "BLOCKED_DOMAIN"
+	ReasonRepeatedLinks = "REPEATED_LINKS"
+)
+
+// ErrRejected is wrapped by Verdict.Err so callers can map it to a 400.
+var ErrRejected = errors.New("moderation: content rejected")
+
+// Config controls what the filter rejects. Zero values disable a check.
+type Config struct {
+	// BannedWords are matched case-insensitively on whole words. Entries may
+	// be phrases ("free money"); they match on word boundaries as well.
+	BannedWords []string
+	// MaxLinks is the number of URLs a single post or comment may contain.
+	MaxLinks int
+	// BlockedDomains rejects links to these hosts and their subdomains.
+	BlockedDomains []string
+	// MaxLinksPerDomain rejects text linking the same host more than this
+	// many times, the usual shape of promotional spam.
+	MaxLinksPerDomain int
+}
+
+// Verdict is the outcome of checking a piece of text.
+type Verdict struct {
+	Allowed bool
+	Reason  string
+	// Match is the offending word or domain, for the moderator log. It is
+	// never echoed back to the author.
+	Match string
+}
+
+// Err returns nil for allowed text and an ErrRejected-wrapping error otherwise.
+func (v Verdict) Err() error {
+	if v.Allowed {
+		return nil
+	}
+	return &RejectedError{Reason: v.Reason}
+}
+
+// RejectedError carries the rejection reason.
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return "moderation: content rejected (" + strings.ToLower(e.Reason) + ")"
+}
+
+func (e *RejectedError) Unwrap() error { return ErrRejected }
+
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>()"']+`)
+
+// Filter is safe for concurrent use.
+type Filter struct {
+	cfg            Config
+	bannedPhrases  []string
+	blockedDomains []string
+}
+
+// NewFilter normalises the configured word and domain lists.
+func NewFilter(cfg Config) *Filter {
+	f := &Filter{cfg: cfg}
+	for _, w := range cfg.BannedWords {
+		if n := normalise(w); n != "" {
+			f.bannedPhrases = append(f.bannedPhrases, n)
+		}
+	}
+	for _, d := range cfg.BlockedDomains {
+		d = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "www.")
+		if d != "" {
+			f.blockedDomains = append(f.blockedDomains, d)
+		}
+	}
+	return f
+}
+
+// Check runs every configured check over the given fields (e.g. title and
+// body) and returns the first failure.
+func (f *Filter) Check(fields ...string) Verdict {
+	text := strings.Join(fields, "\n")
+
+	if len(f.bannedPhrases) > 0 {
+		padded := " " + normalise(text) + " "
+		for _, p := range f.bannedPhrases {
+			if strings.Contains(padded, " "+p+" ") {
+				return Verdict{Reason: ReasonBannedWord, Match: p}
+			}
+		}
+	}
+
+	links := urlPattern.FindAllString(text, -1)
+	if len(links) == 0 {
+		return Verdict{Allowed: true}
+	}
+	if f.cfg.MaxLinks > 0 && len(links) > f.cfg.MaxLinks {
+		return Verdict{Reason: ReasonTooManyLinks}
+	}
+
+	perHost := make(map[string]int)
+	for _, l := range links {
+		host := linkHost(l)
+		if host == "" {
+			continue
+		}
+		for _, d := range f.blockedDomains {
+			if host == d || strings.HasSuffix(host, "."+d) {
+				return Verdict{Reason: ReasonBlockedDomain, Match: host}
+			}
+		}
+		perHost[host]++
+		if f.cfg.MaxLinksPerDomain > 0 && perHost[host] > f.cfg.MaxLinksPerDomain {
+			return Verdict{Reason: ReasonRepeatedLinks, Match: host}
+		}
+	}
+	return Verdict{Allowed: true}
+}
+
+// normalise lower-cases text and collapses every run of non letters/digits
+// into a single space, so "FREE!!" matches "free" while "freedom" does not.
+func normalise(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r)
+	})
+	return strings.Join(fields, " ")
+}
+
+func linkHost(link string) string {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
@@ -0,0 +1,48 @@
+package moderation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFilterCheck(t *testing.T) {
+	f := NewFilter(Config{
+		BannedWords:       []string{"scam", "đồ ngốc", "free money"},
+		MaxLinks:          3,
+		BlockedDomains:    []string{"spam.example"},
+		MaxLinksPerDomain: 2,
+	})
+
+	tests := []struct {
+		name   string
+		fields []string
+		reason string
+	}{
+		{"clean text", []string{"Câu hỏi về bài 3", "Mình chưa hiểu đạo hàm"}, ""},
+		{"banned word any case", []string{"This is a SCAM!"}, ReasonBannedWord},
+		{"banned vietnamese phrase", []string{"bạn là đồ ngốc"}, ReasonBannedWord},
+		{"banned phrase across punctuation", []string{"Get free, money now"}, ReasonBannedWord},
+		{"substring is not a word", []string{"scampi recipe"}, ""},
+		{"too many links", []string{"https://a.io https://b.io https://c.io https://d.io"}, ReasonTooManyLinks},
+		{"blocked subdomain", []string{"see www.shop.spam.example/deal"}, ReasonBlockedDomain},
+		{"repeated domain", []string{"http://x.io/1 http://x.io/2 http://x.io/3"}, ReasonRepeatedLinks},
+		{"docs links allowed", []string{"Xem https://go.dev/doc và https://pkg.go.dev"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := f.Check(tt.fields...)
+			if tt.reason == "" {
+				if !v.Allowed {
+					t.Fatalf("expected allowed, got %+v", v)
+				}
+				return
+			}
+			if v.Allowed || v.Reason != tt.reason {
+				t.Fatalf("got %+v; want reason %s", v, tt.reason)
+			}
+			if !errors.Is(v.Err(), ErrRejected) {
+				t.Errorf("Err() = %v; want ErrRejected", v.Err())
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Subject is who a request is charged to
+type Subject struct {
+	UserID int64 // 0 for anonymous requests
+	IP     string
+	Roles  []string
+	OrgIDs []int64
+}
+
+// Policy is the budget for one route group. The rule for a subject is the
+// most generous of the overrides for its organizations; failing that, of
+// the overrides for its roles; failing that, Default.
+type Policy struct {
+	Name    string
+	Default Rule
+	Roles   map[string]Rule
+	Orgs    map[int64]Rule
+}
+
+// RuleFor picks the rule that applies to s
+func (p Policy) RuleFor(s Subject) Rule {
+	if rule, ok := pickMostGenerous(s.OrgIDs, p.Orgs); ok {
+		return rule
+	}
+	if rule, ok := pickMostGenerous(s.Roles, p.Roles); ok {
+		return rule
+	}
+	return p.Default
+}
+
+func pickMostGenerous[K comparable](keys []K, rules map[K]Rule) (Rule, bool) {
+	var best Rule
+	found := false
+	for _, k := range keys {
+		if r, ok := rules[k]; ok && (!found || r.moreGenerous(best)) {
+			best, found = r, true
+		}
+	}
+	return best, found
+}
+
+// Key is the bucket s is charged to: its user, or its IP when anonymous
+func (p Policy) Key(s Subject) string {
+	if s.UserID > 0 {
+		return p.Name + ":user:" + strconv.FormatInt(s.UserID, 10)
+	}
+	return p.Name + ":ip:" + s.IP
+}
+
+// NeedsOrgs reports whether resolving the rule requires the subject's
+// organizations
+func (p Policy) NeedsOrgs() bool {
+	return len(p.Orgs) > 0
+}
+
+// ParsePolicy reads a comma-separated list of overrides, e.g.
+//
+//	default=20/1h:5,TEACHER=60/1h:10,ADMIN=unlimited,org:12=200/1h
+//
+// Keys are "default", a role name, or "org:<id>".
+func ParsePolicy(name, spec string) (Policy, error) {
+	p := Policy{Name: name, Roles: map[string]Rule{}, Orgs: map[int64]Rule{}}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Policy{}, fmt.Errorf("rate limit policy %s: %q is not key=rule", name, part)
+		}
+		rule, err := ParseRule(value)
+		if err != nil {
+			return Policy{}, fmt.Errorf("rate limit policy %s: %w", name, err)
+		}
+		key = strings.TrimSpace(key)
+		switch {
+		case key == "default":
+			p.Default = rule
+		case strings.HasPrefix(key, "org:"):
+			id, err := strconv.ParseInt(strings.TrimPrefix(key, "org:"), 10, 64)
+			if err != nil || id <= 0 {
+				return Policy{}, fmt.Errorf("rate limit policy %s: invalid organization %q", name, key)
+			}
+			p.Orgs[id] = rule
+		default:
+			p.Roles[strings.ToUpper(key)] = rule
+		}
+	}
+	return p, nil
+}
+
+// Overrides exempts callers from every limit: trusted networks, individual
+// users and authenticated internal services
+type Overrides struct {
+	Networks []*net.IPNet
+	UserIDs  map[int64]bool
+	Services map[string]bool
+}
+
+// ParseOverrides reads CIDRs (a bare IP is a /32 or /128), user IDs and
+// service names
+func ParseOverrides(cidrs, userIDs, services []string) (Overrides, error) {
+	o := Overrides{UserIDs: map[int64]bool{}, Services: map[string]bool{}}
+	for _, c := range cidrs {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		if !strings.Contains(c, "/") {
+			if ip := net.ParseIP(c); ip != nil && ip.To4() != nil {
+				c += "/32"
+			} else {
+				c += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(c)
+		if err != nil {
+			return Overrides{}, fmt.Errorf("rate limit override: invalid network %q", c)
+		}
+		o.Networks = append(o.Networks, network)
+	}
+	for _, u := range userIDs {
+		u = strings.TrimSpace(u)
+		if u == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(u, 10, 64)
+		if err != nil || id <= 0 {
+			return Overrides{}, fmt.Errorf("rate limit override: invalid user id %q", u)
+		}
+		o.UserIDs[id] = true
+	}
+	for _, s := range services {
+		if s = strings.TrimSpace(s); s != "" {
+			o.Services[s] = true
+		}
+	}
+	return o, nil
+}
+
+func (o Overrides) ExemptIP(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range o.Networks {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o Overrides) ExemptUser(userID int64) bool {
+	return userID > 0 && o.UserIDs[userID]
+}
+
+func (o Overrides) ExemptService(name string) bool {
+	return name != "" && o.Services[name]
+}
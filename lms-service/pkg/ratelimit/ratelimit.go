@@ -0,0 +1,265 @@
+// Package ratelimit implements GCRA rate limiting, a token bucket that
+// refills continuously: a rule of 60/1m with burst 10 admits 10 requests at
+// once, then one per second. State is one timestamp per key, kept in Redis
+// so every replica enforces the same budget; MemoryStore holds it in
+// process for tests and as a fallback while Redis is unreachable.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Rule admits Limit requests per Period, at most Burst of them back to back.
+// The zero Rule is unlimited.
+type Rule struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// Unlimited reports whether the rule admits everything
+func (r Rule) Unlimited() bool {
+	return r.Limit <= 0 || r.Period <= 0
+}
+
+func (r Rule) burst() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+	return r.Limit
+}
+
+// interval is the time one request's token takes to refill
+func (r Rule) interval() time.Duration {
+	return r.Period / time.Duration(r.Limit)
+}
+
+// moreGenerous reports whether r admits a higher sustained rate than other
+func (r Rule) moreGenerous(other Rule) bool {
+	if r.Unlimited() || other.Unlimited() {
+		return r.Unlimited() && !other.Unlimited()
+	}
+	return r.interval() < other.interval() ||
+		r.interval() == other.interval() && r.burst() > other.burst()
+}
+
+func (r Rule) String() string {
+	if r.Unlimited() {
+		return "unlimited"
+	}
+	s := strconv.Itoa(r.Limit) + "/" + r.Period.String()
+	if r.Burst > 0 {
+		s += ":" + strconv.Itoa(r.Burst)
+	}
+	return s
+}
+
+// ParseRule reads "limit/period[:burst]", e.g. "20/1h:5", or "unlimited"
+func ParseRule(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+	if s == "unlimited" {
+		return Rule{}, nil
+	}
+	rate, burst, hasBurst := strings.Cut(s, ":")
+	limit, period, ok := strings.Cut(rate, "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("rate limit %q: want limit/period[:burst]", s)
+	}
+	var r Rule
+	var err error
+	if r.Limit, err = strconv.Atoi(limit); err != nil || r.Limit <= 0 {
+		return Rule{}, fmt.Errorf("rate limit %q: limit must be a positive integer", s)
+	}
+	if r.Period, err = time.ParseDuration(period); err != nil || r.Period <= 0 {
+		return Rule{}, fmt.Errorf("rate limit %q: invalid period", s)
+	}
+	if hasBurst {
+		if r.Burst, err = strconv.Atoi(burst); err != nil || r.Burst <= 0 {
+			return Rule{}, fmt.Errorf("rate limit %q: burst must be a positive integer", s)
+		}
+	}
+	if r.interval() <= 0 {
+		return Rule{}, fmt.Errorf("rate limit %q: period too short for limit", s)
+	}
+	return r, nil
+}
+
+// Result is the verdict for one request
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until a denied request would be admitted
+	RetryAfter time.Duration
+	// ResetAfter is how long until the bucket is full again
+	ResetAfter time.Duration
+}
+
+// Store takes one token for key under rule
+type Store interface {
+	Allow(ctx context.Context, key string, rule Rule) (Result, error)
+}
+
+// ============================================
+// REDIS
+// ============================================
+
+// ScriptRunner runs a Lua script; RedisCache satisfies it
+type ScriptRunner interface {
+	RunScript(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// gcraScript stores the theoretical arrival time (TAT) of the next request
+// in microseconds of Redis' own clock, so replicas with skewed clocks agree
+var gcraScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+local allow_at = new_tat - tolerance
+if allow_at > now then
+	return {0, 0, allow_at - now, tat - now}
+end
+-- %.0f keeps every digit; Lua's default number format would round
+redis.call("SET", KEYS[1], string.format("%.0f", new_tat), "PX", math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now - allow_at) / interval), 0, new_tat - now}
+`)
+
+// RedisStore keeps buckets in Redis under prefix
+type RedisStore struct {
+	redis  ScriptRunner
+	prefix string
+}
+
+func NewRedisStore(r ScriptRunner, prefix string) *RedisStore {
+	return &RedisStore{redis: r, prefix: prefix}
+}
+
+func (s *RedisStore) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	if rule.Unlimited() {
+		return Result{Allowed: true, Remaining: -1}, nil
+	}
+	interval := rule.interval().Microseconds()
+	tolerance := interval * int64(rule.burst())
+	raw, err := s.redis.RunScript(ctx, gcraScript, []string{s.prefix + key}, interval, tolerance)
+	if err != nil {
+		return Result{}, err
+	}
+	vals, ok := raw.([]interface{})
+	if !ok || len(vals) != 4 {
+		return Result{}, errors.New("ratelimit: unexpected script reply")
+	}
+	n := make([]int64, 4)
+	for i, v := range vals {
+		if n[i], ok = v.(int64); !ok {
+			return Result{}, errors.New("ratelimit: unexpected script reply")
+		}
+	}
+	return Result{
+		Allowed:    n[0] == 1,
+		Limit:      rule.burst(),
+		Remaining:  int(n[1]),
+		RetryAfter: time.Duration(n[2]) * time.Microsecond,
+		ResetAfter: time.Duration(n[3]) * time.Microsecond,
+	}, nil
+}
+
+// ============================================
+// MEMORY
+// ============================================
+
+// MemoryStore keeps buckets in process. Idle buckets are dropped on the
+// next sweep once they are full again.
+type MemoryStore struct {
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tats: make(map[string]time.Time), now: time.Now}
+}
+
+func (s *MemoryStore) Allow(_ context.Context, key string, rule Rule) (Result, error) {
+	if rule.Unlimited() {
+		return Result{Allowed: true, Remaining: -1}, nil
+	}
+	interval := rule.interval()
+	tolerance := interval * time.Duration(rule.burst())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+
+	tat, ok := s.tats[key]
+	if !ok || tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-tolerance)
+	if allowAt.After(now) {
+		return Result{Limit: rule.burst(), RetryAfter: allowAt.Sub(now), ResetAfter: tat.Sub(now)}, nil
+	}
+	s.tats[key] = newTAT
+	return Result{
+		Allowed:    true,
+		Limit:      rule.burst(),
+		Remaining:  int(now.Sub(allowAt) / interval),
+		ResetAfter: newTAT.Sub(now),
+	}, nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for k, tat := range s.tats {
+		if tat.Before(now) {
+			delete(s.tats, k)
+		}
+	}
+}
+
+// FallbackStore uses primary and switches to secondary for any call where
+// primary fails, so a Redis outage degrades to per-replica limits instead
+// of none
+type FallbackStore struct {
+	Primary   Store
+	Secondary Store
+	// OnError is called with each primary failure
+	OnError func(error)
+}
+
+func (s *FallbackStore) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	res, err := s.Primary.Allow(ctx, key, rule)
+	if err == nil {
+		return res, nil
+	}
+	if s.OnError != nil {
+		s.OnError(err)
+	}
+	return s.Secondary.Allow(ctx, key, rule)
+}
+
+// RetryAfterSeconds rounds d up to whole seconds for the Retry-After header
+func RetryAfterSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
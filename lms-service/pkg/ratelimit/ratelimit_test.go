@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	r, err := ParseRule("60/1m:10")
+	if err != nil || r.Limit != 60 || r.Period != time.Minute || r.Burst != 10 {
+		t.Errorf("ParseRule(60/1m:10) = %+v, %v", r, err)
+	}
+	if r, err := ParseRule("unlimited"); err != nil || !r.Unlimited() {
+		t.Errorf("ParseRule(unlimited) = %+v, %v", r, err)
+	}
+	for _, bad := range []string{"", "60", "0/1m", "60/soon", "60/1m:x"} {
+		if _, err := ParseRule(bad); err == nil {
+			t.Errorf("ParseRule(%q) accepted", bad)
+		}
+	}
+}
+
+func TestPolicyPicksMostGenerousOverride(t *testing.T) {
+	p, err := ParsePolicy("ai", "default=10/1h, teacher=60/1h:10, ADMIN=unlimited, org:7=200/1h, org:8=100/1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		subject Subject
+		want    string
+	}{
+		{"student", Subject{UserID: 1, Roles: []string{"STUDENT"}}, "10/1h0m0s"},
+		{"teacher", Subject{UserID: 1, Roles: []string{"STUDENT", "TEACHER"}}, "60/1h0m0s:10"},
+		{"admin", Subject{UserID: 1, Roles: []string{"TEACHER", "ADMIN"}}, "unlimited"},
+		{"org beats role", Subject{UserID: 1, Roles: []string{"TEACHER"}, OrgIDs: []int64{8, 7}}, "200/1h0m0s"},
+	}
+	for _, tc := range cases {
+		if got := p.RuleFor(tc.subject).String(); got != tc.want {
+			t.Errorf("%s: rule = %s; want %s", tc.name, got, tc.want)
+		}
+	}
+
+	if k := p.Key(Subject{UserID: 5, IP: "1.2.3.4"}); k != "ai:user:5" {
+		t.Errorf("key = %q; want per-user key", k)
+	}
+	if k := p.Key(Subject{IP: "1.2.3.4"}); k != "ai:ip:1.2.3.4" {
+		t.Errorf("key = %q; want per-IP key", k)
+	}
+}
+
+func TestMemoryStoreBurstThenRefill(t *testing.T) {
+	s := NewMemoryStore()
+	now := time.Unix(1_700_000_000, 0)
+	s.now = func() time.Time { return now }
+	rule := Rule{Limit: 60, Period: time.Minute, Burst: 3}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		res, _ := s.Allow(ctx, "k", rule)
+		if !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("request %d: %+v; want allowed with %d remaining", i, res, 2-i)
+		}
+	}
+	res, _ := s.Allow(ctx, "k", rule)
+	if res.Allowed || res.RetryAfter != time.Second || RetryAfterSeconds(res.RetryAfter) != 1 {
+		t.Fatalf("over burst: %+v; want denied, retry after 1s", res)
+	}
+
+	now = now.Add(time.Second)
+	if res, _ := s.Allow(ctx, "k", rule); !res.Allowed {
+		t.Errorf("after one interval: %+v; want allowed", res)
+	}
+	if res, _ := s.Allow(ctx, "other", rule); !res.Allowed {
+		t.Errorf("separate key shares a bucket: %+v", res)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Allow(context.Context, string, Rule) (Result, error) {
+	return Result{}, errors.New("connection refused")
+}
+
+func TestFallbackStoreUsesSecondaryOnError(t *testing.T) {
+	var reported error
+	s := &FallbackStore{Primary: failingStore{}, Secondary: NewMemoryStore(), OnError: func(err error) { reported = err }}
+	res, err := s.Allow(context.Background(), "k", Rule{Limit: 1, Period: time.Minute})
+	if err != nil || !res.Allowed {
+		t.Errorf("res = %+v, err = %v; want allowed by the secondary", res, err)
+	}
+	if reported == nil {
+		t.Error("primary failure was not reported")
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	o, err := ParseOverrides([]string{"10.1.0.0/16", "203.0.113.7"}, []string{"42"}, []string{"ai-service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !o.ExemptIP("10.1.2.3") || !o.ExemptIP("203.0.113.7") {
+		t.Error("listed networks are not exempt")
+	}
+	if o.ExemptIP("10.2.0.1") || o.ExemptIP("192.168.1.1") {
+		t.Error("unlisted private address is exempt")
+	}
+	if !o.ExemptUser(42) || o.ExemptUser(43) || !o.ExemptService("ai-service") || o.ExemptService("") {
+		t.Error("user or service overrides wrong")
+	}
+	if _, err := ParseOverrides([]string{"not-an-ip"}, nil, nil); err == nil {
+		t.Error("accepted an invalid network")
+	}
+}
@@ -6,6 +6,9 @@ from pydantic_settings import BaseSettings
 class Settings(BaseSettings):
     app_env: str = "development"
     ai_service_secret: str = "ai-service-secret-change-me"
+    # LMS client name the secret above belongs to; the recommender signs as
+    # the AI service until LMS lists it as a client of its own
+    service_auth_name: str = "ai-service"
     personalize_service_url: str = "http://personalize-service:8082"
     lms_service_url: str = "http://lms-service:8081"
     kafka_brokers: str = "kafka:9092"
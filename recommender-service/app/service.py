@@ -26,6 +26,7 @@ from app.schemas import (
     RecommendationRequest,
     RecommendationResponse,
 )
+from app.service_auth import ServiceAuth
 
 logger = logging.getLogger(__name__)
 settings = get_settings()
@@ -94,7 +95,7 @@ class RecommendationService:
                 response = await client.get(
                     url,
                     params={"course_id": course_id},
-                    headers={"X-API-Secret": settings.ai_service_secret},
+                    auth=ServiceAuth(),
                 )
                 response.raise_for_status()
                 body = response.json()
@@ -0,0 +1,81 @@
+"""
+Signed service-to-service requests to the Go services (LMS); the same
+scheme as ai-service's app/core/service_auth.py.
+
+The receiver (pkg/svcauth) checks an HMAC-SHA256 over the method, path,
+query, a hash of the exact body bytes, a timestamp, a one-time nonce, the
+calling service and the user it acts for. Pass ``ServiceAuth`` as the
+``auth=`` of an httpx call; set ``X-User-Id`` through ``user_id`` so it is
+covered by the signature.
+"""
+from __future__ import annotations
+
+import hashlib
+import hmac
+import secrets
+import time
+from typing import Optional
+
+import httpx
+
+from app.config import get_settings
+
+
+def sign_headers(
+    method: str,
+    path: str,
+    query: str,
+    body: bytes,
+    secret: str,
+    service: str,
+    user_id: str = "",
+    timestamp: Optional[int] = None,
+    nonce: Optional[str] = None,
+) -> dict[str, str]:
+    """Headers authenticating one request. ``path`` and ``query`` are the
+    escaped forms the receiver sees, without the leading ``?``."""
+    ts = str(int(time.time()) if timestamp is None else timestamp)
+    nonce = nonce or secrets.token_hex(16)
+    # Must match canonical() in pkg/svcauth
+    canonical = "\n".join([
+        "v1",
+        method.upper(),
+        path,
+        query,
+        hashlib.sha256(body or b"").hexdigest(),
+        ts,
+        nonce,
+        service,
+        user_id,
+    ])
+    signature = hmac.new(secret.encode(), canonical.encode(), hashlib.sha256).hexdigest()
+    headers = {
+        "X-Service-Name": service,
+        "X-Service-Timestamp": ts,
+        "X-Service-Nonce": nonce,
+        "X-Service-Signature": signature,
+    }
+    if user_id:
+        headers["X-User-Id"] = user_id
+    return headers
+
+
+class ServiceAuth(httpx.Auth):
+    """httpx auth that signs each request; a retry gets a fresh nonce."""
+
+    requires_request_body = True
+
+    def __init__(self, user_id: Optional[int] = None):
+        settings = get_settings()
+        self._service = settings.service_auth_name
+        self._secret = settings.ai_service_secret
+        self._user_id = str(user_id) if user_id else ""
+
+    def auth_flow(self, request: httpx.Request):
+        path = request.url.raw_path.split(b"?", 1)[0].decode("ascii")
+        query = request.url.query.decode("ascii")
+        request.headers.update(sign_headers(
+            request.method, path, query, request.content, self._secret,
+            self._service, user_id=self._user_id,
+        ))
+        yield request